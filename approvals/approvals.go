@@ -5,11 +5,13 @@ import (
 	"errors"
 	"fmt"
 	"strconv"
+	"strings"
 	"sync"
 	"sync/atomic"
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/keel-hq/keel/extension/notification"
 	"github.com/keel-hq/keel/pkg/store"
 	"github.com/keel-hq/keel/types"
 
@@ -34,7 +36,7 @@ type Manager interface {
 	// Increases Approval votes by 1
 	Approve(identifier, voter string) (*types.Approval, error)
 	// Rejects Approval
-	Reject(identifier string) (*types.Approval, error)
+	Reject(identifier, voter string) (*types.Approval, error)
 
 	Get(identifier string) (*types.Approval, error)
 	List() ([]*types.Approval, error)
@@ -54,6 +56,9 @@ const (
 	ApprovalsPrefix = "approvals"
 )
 
+// ExpiryCheckInterval - how often pending approvals are checked against their deadlines
+const ExpiryCheckInterval = time.Minute
+
 // DefaultManager - default manager implementation
 type DefaultManager struct {
 	// cache is used to store approvals, key example:
@@ -62,6 +67,12 @@ type DefaultManager struct {
 
 	store store.Store
 
+	// optional, notified about expired approvals
+	sender notification.Sender
+
+	// optional, resolves approver groups of approvals
+	groups GroupResolver
+
 	// subscriber channels
 	channels map[uint32]chan *types.Approval
 	index    uint32
@@ -75,6 +86,11 @@ type DefaultManager struct {
 
 type Opts struct {
 	Store store.Store
+	// Sender - optional sender for expired approval notifications
+	Sender notification.Sender
+	// Groups - optional resolver of approver groups set via keel.sh/approvers,
+	// approvals with approver groups can't be voted on without it
+	Groups GroupResolver
 	// Cache cache.Cache
 }
 
@@ -83,6 +99,8 @@ func New(opts *Opts) *DefaultManager {
 	man := &DefaultManager{
 		// cache:      opts.Cache,
 		store:      opts.Store,
+		sender:     opts.Sender,
+		groups:     opts.Groups,
 		channels:   make(map[uint32]chan *types.Approval),
 		approvedCh: make(map[uint32]chan *types.Approval),
 		index:      0,
@@ -96,7 +114,7 @@ func New(opts *Opts) *DefaultManager {
 // StartExpiryService - starts approval expiry service which deletes approvals
 // that already reached their deadline
 func (m *DefaultManager) StartExpiryService(ctx context.Context) error {
-	ticker := time.NewTicker(60 * time.Minute)
+	ticker := time.NewTicker(ExpiryCheckInterval)
 	defer ticker.Stop()
 	err := m.expireEntries()
 	if err != nil {
@@ -140,12 +158,42 @@ func (m *DefaultManager) expireEntries() error {
 			}
 
 			m.addAuditEntry(approval, types.AuditActionApprovalExpired, "")
+			m.notifyExpired(approval)
 		}
 	}
 
 	return nil
 }
 
+// notifyExpired - lets approvers know that the update won't happen, sent to
+// the approvals channel override if the approval has one
+func (m *DefaultManager) notifyExpired(approval *types.Approval) {
+	log.WithFields(log.Fields{
+		"identifier": approval.Identifier,
+		"delta":      approval.Delta(),
+		"votes":      fmt.Sprintf("%d/%d", approval.VotesReceived, approval.VotesRequired),
+	}).Info("approvals.expireEntries: approval expired")
+
+	if m.sender == nil {
+		return
+	}
+
+	var channels []string
+	if approval.Channel != "" {
+		channels = []string{approval.Channel}
+	}
+
+	m.sender.Send(types.EventNotification{
+		Name: "approval expired",
+		Message: fmt.Sprintf("Approval for %s (%s) expired with %d/%d votes, update won't be applied",
+			approval.Identifier, approval.Delta(), approval.VotesReceived, approval.VotesRequired),
+		CreatedAt: time.Now(),
+		Type:      types.NotificationUpdateExpired,
+		Level:     types.LevelWarn,
+		Channels:  channels,
+	})
+}
+
 // Subscribe - subscribe for approval events
 func (m *DefaultManager) Subscribe(ctx context.Context) (<-chan *types.Approval, error) {
 	m.subMu.Lock()
@@ -251,6 +299,11 @@ func (m *DefaultManager) Approve(identifier, voter string) (*types.Approval, err
 		return nil, err
 	}
 
+	err = m.authorize(existing, voter)
+	if err != nil {
+		return nil, err
+	}
+
 	for _, v := range existing.GetVoters() {
 		if v == voter {
 			// nothing to do, same voter
@@ -310,7 +363,7 @@ func (m *DefaultManager) addAuditEntry(approval *types.Approval, action string,
 
 // Reject - rejects approval (marks rejected=true), approval will not be valid even if it
 // collects required votes
-func (m *DefaultManager) Reject(identifier string) (*types.Approval, error) {
+func (m *DefaultManager) Reject(identifier, voter string) (*types.Approval, error) {
 	m.mu.Lock()
 	defer m.mu.Unlock()
 
@@ -319,6 +372,12 @@ func (m *DefaultManager) Reject(identifier string) (*types.Approval, error) {
 		return nil, err
 	}
 
+	// a single approver is enough to veto the update
+	err = m.authorize(existing, voter)
+	if err != nil {
+		return nil, err
+	}
+
 	existing.Rejected = true
 
 	err = m.Update(existing)
@@ -326,11 +385,53 @@ func (m *DefaultManager) Reject(identifier string) (*types.Approval, error) {
 		return nil, err
 	}
 
-	m.addAuditEntry(existing, types.AuditActionApprovalRejected, "")
+	m.addAuditEntry(existing, types.AuditActionApprovalRejected, voter)
 
 	return existing, nil
 }
 
+// authorize - checks that voter is a member of one of the approval approver
+// groups, anyone can vote on approvals without them
+func (m *DefaultManager) authorize(approval *types.Approval, voter string) error {
+	groups := approval.GetApprovers()
+	if len(groups) == 0 {
+		return nil
+	}
+
+	if m.groups == nil {
+		log.WithFields(log.Fields{
+			"identifier": approval.Identifier,
+			"approvers":  approval.Approvers,
+		}).Error("approvals.manager: approval has approver groups but no group resolver is configured")
+		return ErrNotApprover
+	}
+
+	for _, group := range groups {
+		members, err := m.groups.Members(group)
+		if err != nil {
+			log.WithFields(log.Fields{
+				"error":      err,
+				"identifier": approval.Identifier,
+				"group":      group,
+			}).Warn("approvals.manager: failed to get approver group members")
+			continue
+		}
+		for _, member := range members {
+			if voter != "" && strings.EqualFold(member, voter) {
+				return nil
+			}
+		}
+	}
+
+	log.WithFields(log.Fields{
+		"identifier": approval.Identifier,
+		"approvers":  approval.Approvers,
+		"voter":      voter,
+	}).Warn("approvals.manager: voter is not a member of the approver groups")
+
+	return ErrNotApprover
+}
+
 // Get - get specified, not archived approval
 func (m *DefaultManager) Get(identifier string) (*types.Approval, error) {
 
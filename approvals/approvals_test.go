@@ -11,6 +11,7 @@ import (
 
 	_ "github.com/jinzhu/gorm/dialects/sqlite"
 
+	"github.com/keel-hq/keel/extension/notification"
 	"github.com/keel-hq/keel/pkg/store/sql"
 	"github.com/keel-hq/keel/types"
 )
@@ -380,7 +381,7 @@ func TestReject(t *testing.T) {
 		t.Fatalf("failed to create approval: %s", err)
 	}
 
-	am.Reject("xxx/app-1")
+	am.Reject("xxx/app-1", "")
 
 	stored, err := am.Get("xxx/app-1")
 	if err != nil {
@@ -425,6 +426,73 @@ func TestExpire(t *testing.T) {
 	}
 }
 
+type fakeSender struct {
+	sent []types.EventNotification
+}
+
+func (s *fakeSender) Configure(*notification.Config) (bool, error) { return true, nil }
+
+func (s *fakeSender) Send(event types.EventNotification) error {
+	s.sent = append(s.sent, event)
+	return nil
+}
+
+func TestExpireNotification(t *testing.T) {
+	store, teardown := NewTestingUtils()
+	defer teardown()
+
+	sender := &fakeSender{}
+	am := New(&Opts{
+		Store:  store,
+		Sender: sender,
+	})
+
+	err := am.Create(&types.Approval{
+		Provider:       types.ProviderTypeKubernetes,
+		Identifier:     "xxx/app-1",
+		CurrentVersion: "1.2.3",
+		NewVersion:     "1.2.5",
+		Channel:        "releases",
+		Deadline:       time.Now().Add(-time.Minute),
+		VotesRequired:  2,
+		VotesReceived:  1,
+	})
+	if err != nil {
+		t.Fatalf("failed to create approval: %s", err)
+	}
+
+	err = am.Create(&types.Approval{
+		Provider:       types.ProviderTypeKubernetes,
+		Identifier:     "xxx/app-2",
+		CurrentVersion: "1.2.3",
+		NewVersion:     "1.2.5",
+		Deadline:       time.Now().Add(time.Hour),
+		VotesRequired:  2,
+	})
+	if err != nil {
+		t.Fatalf("failed to create approval: %s", err)
+	}
+
+	err = am.expireEntries()
+	if err != nil {
+		t.Fatalf("got error while expiring entries: %s", err)
+	}
+
+	if len(sender.sent) != 1 {
+		t.Fatalf("expected 1 notification, got: %d", len(sender.sent))
+	}
+	if sender.sent[0].Type != types.NotificationUpdateExpired {
+		t.Errorf("unexpected notification type: %s", sender.sent[0].Type)
+	}
+	if len(sender.sent[0].Channels) != 1 || sender.sent[0].Channels[0] != "releases" {
+		t.Errorf("expected notification to be sent to approvals channel, got: %v", sender.sent[0].Channels)
+	}
+
+	if _, err := am.Get("xxx/app-2"); err != nil {
+		t.Errorf("pending approval shouldn't be deleted: %s", err)
+	}
+}
+
 func TestGetArchived(t *testing.T) {
 	store, teardown := NewTestingUtils()
 	defer teardown()
@@ -469,3 +537,115 @@ func TestGetArchived(t *testing.T) {
 		t.Errorf("didn't expect approval to be archived")
 	}
 }
+
+func TestApproveApproverGroups(t *testing.T) {
+	store, teardown := NewTestingUtils()
+	defer teardown()
+
+	am := New(&Opts{
+		Store:  store,
+		Groups: StaticGroups{"team-payments": {"alice", "bob"}, "sre": {"carol"}},
+	})
+
+	err := am.Create(&types.Approval{
+		Provider:       types.ProviderTypeKubernetes,
+		Identifier:     "xxx/app-1",
+		CurrentVersion: "1.2.3",
+		NewVersion:     "1.2.5",
+		Deadline:       time.Now().Add(5 * time.Minute),
+		VotesRequired:  2,
+		Approvers:      "team-payments, sre",
+	})
+	if err != nil {
+		t.Fatalf("failed to create approval: %s", err)
+	}
+
+	_, err = am.Approve("xxx/app-1", "mallory")
+	if err != ErrNotApprover {
+		t.Errorf("expected ErrNotApprover, got: %v", err)
+	}
+
+	_, err = am.Approve("xxx/app-1", "Alice")
+	if err != nil {
+		t.Fatalf("failed to approve: %s", err)
+	}
+	stored, err := am.Approve("xxx/app-1", "carol")
+	if err != nil {
+		t.Fatalf("failed to approve: %s", err)
+	}
+
+	if stored.VotesReceived != 2 {
+		t.Errorf("unexpected number of received votes: %d", stored.VotesReceived)
+	}
+	if stored.Status() != types.ApprovalStatusApproved {
+		t.Errorf("unexpected status: %s", stored.Status())
+	}
+}
+
+func TestRejectApproverGroups(t *testing.T) {
+	store, teardown := NewTestingUtils()
+	defer teardown()
+
+	am := New(&Opts{
+		Store:  store,
+		Groups: StaticGroups{"team-payments": {"alice", "bob"}},
+	})
+
+	err := am.Create(&types.Approval{
+		Provider:       types.ProviderTypeKubernetes,
+		Identifier:     "xxx/app-1",
+		CurrentVersion: "1.2.3",
+		NewVersion:     "1.2.5",
+		Deadline:       time.Now().Add(5 * time.Minute),
+		VotesRequired:  2,
+		Approvers:      "team-payments",
+	})
+	if err != nil {
+		t.Fatalf("failed to create approval: %s", err)
+	}
+
+	_, err = am.Reject("xxx/app-1", "mallory")
+	if err != ErrNotApprover {
+		t.Errorf("expected ErrNotApprover, got: %v", err)
+	}
+
+	// single approver vetoes the update even after votes from others
+	_, err = am.Approve("xxx/app-1", "alice")
+	if err != nil {
+		t.Fatalf("failed to approve: %s", err)
+	}
+	stored, err := am.Reject("xxx/app-1", "bob")
+	if err != nil {
+		t.Fatalf("failed to reject: %s", err)
+	}
+	if stored.Status() != types.ApprovalStatusRejected {
+		t.Errorf("unexpected status: %s", stored.Status())
+	}
+}
+
+func TestApproveApproverGroupsWithoutResolver(t *testing.T) {
+	store, teardown := NewTestingUtils()
+	defer teardown()
+
+	am := New(&Opts{
+		Store: store,
+	})
+
+	err := am.Create(&types.Approval{
+		Provider:       types.ProviderTypeKubernetes,
+		Identifier:     "xxx/app-1",
+		CurrentVersion: "1.2.3",
+		NewVersion:     "1.2.5",
+		Deadline:       time.Now().Add(5 * time.Minute),
+		VotesRequired:  1,
+		Approvers:      "team-payments",
+	})
+	if err != nil {
+		t.Fatalf("failed to create approval: %s", err)
+	}
+
+	_, err = am.Approve("xxx/app-1", "alice")
+	if err != ErrNotApprover {
+		t.Errorf("expected ErrNotApprover, got: %v", err)
+	}
+}
@@ -0,0 +1,68 @@
+package approvals
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Approver groups related errors
+var (
+	ErrNotApprover   = errors.New("voter is not a member of the approver groups")
+	ErrGroupNotFound = errors.New("approver group not found")
+)
+
+// GroupResolver - resolves members of approver groups set via keel.sh/approvers
+type GroupResolver interface {
+	Members(group string) ([]string, error)
+}
+
+// StaticGroups - approver groups from configuration, group name -> members
+type StaticGroups map[string][]string
+
+// ParseStaticGroups - parses groups separated by semicolons with comma
+// separated members, ie: "team-payments=alice,bob;sre=carol"
+func ParseStaticGroups(spec string) (StaticGroups, error) {
+	groups := make(StaticGroups)
+	for _, entry := range strings.Split(spec, ";") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		parts := strings.SplitN(entry, "=", 2)
+		name := strings.TrimSpace(parts[0])
+		if len(parts) != 2 || name == "" {
+			return nil, fmt.Errorf("invalid approver group '%s', expected <group>=<member>,<member>", entry)
+		}
+		for _, member := range strings.Split(parts[1], ",") {
+			if member = strings.TrimSpace(member); member != "" {
+				groups[name] = append(groups[name], member)
+			}
+		}
+	}
+	return groups, nil
+}
+
+// Members - returns group members
+func (g StaticGroups) Members(group string) ([]string, error) {
+	members, ok := g[group]
+	if !ok {
+		return nil, ErrGroupNotFound
+	}
+	return members, nil
+}
+
+// Groups - combines resolvers, first resolver that knows the group wins
+type Groups []GroupResolver
+
+// Members - returns group members from the first resolver that has the group
+func (g Groups) Members(group string) ([]string, error) {
+	for _, resolver := range g {
+		members, err := resolver.Members(group)
+		if err == ErrGroupNotFound {
+			continue
+		}
+		return members, err
+	}
+	return nil, ErrGroupNotFound
+}
@@ -0,0 +1,71 @@
+package approvals
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestParseStaticGroups(t *testing.T) {
+	groups, err := ParseStaticGroups(" team-payments = alice, bob ;sre=carol;")
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	expected := StaticGroups{
+		"team-payments": {"alice", "bob"},
+		"sre":           {"carol"},
+	}
+	if !reflect.DeepEqual(groups, expected) {
+		t.Errorf("unexpected groups: %v", groups)
+	}
+
+	_, err = ParseStaticGroups("team-payments")
+	if err == nil {
+		t.Errorf("expected error for group without members")
+	}
+}
+
+type fakeResolver struct {
+	members map[string][]string
+	err     error
+}
+
+func (r *fakeResolver) Members(group string) ([]string, error) {
+	if r.err != nil {
+		return nil, r.err
+	}
+	members, ok := r.members[group]
+	if !ok {
+		return nil, ErrGroupNotFound
+	}
+	return members, nil
+}
+
+func TestGroups(t *testing.T) {
+	groups := Groups{
+		&fakeResolver{members: map[string][]string{"sre": {"U01"}}},
+		StaticGroups{"sre": {"carol"}, "team-payments": {"alice"}},
+	}
+
+	members, err := groups.Members("sre")
+	if err != nil || !reflect.DeepEqual(members, []string{"U01"}) {
+		t.Errorf("unexpected sre members: %v, err: %v", members, err)
+	}
+
+	members, err = groups.Members("team-payments")
+	if err != nil || !reflect.DeepEqual(members, []string{"alice"}) {
+		t.Errorf("unexpected team-payments members: %v, err: %v", members, err)
+	}
+
+	_, err = groups.Members("missing")
+	if err != ErrGroupNotFound {
+		t.Errorf("expected ErrGroupNotFound, got: %v", err)
+	}
+
+	failing := Groups{&fakeResolver{err: errors.New("slack is down")}, StaticGroups{"sre": {"carol"}}}
+	_, err = failing.Members("sre")
+	if err == nil || err == ErrGroupNotFound {
+		t.Errorf("expected resolver error, got: %v", err)
+	}
+}
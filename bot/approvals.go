@@ -104,7 +104,7 @@ func (bm *BotManager) processRejectedResponse(approvalResponse *ApprovalResponse
 	}
 
 	for _, identifier := range identifiers {
-		approval, err := bm.approvalsManager.Reject(identifier)
+		approval, err := bm.approvalsManager.Reject(identifier, approvalResponse.User)
 		if err != nil {
 			log.WithFields(log.Fields{
 				"error":      err,
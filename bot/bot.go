@@ -7,6 +7,7 @@ import (
 	"sync"
 
 	"github.com/keel-hq/keel/approvals"
+	"github.com/keel-hq/keel/provider"
 	"github.com/keel-hq/keel/provider/kubernetes"
 	"github.com/keel-hq/keel/types"
 
@@ -14,7 +15,13 @@ import (
 )
 
 const (
-	RemoveApprovalPrefix = "rm approval"
+	RemoveApprovalPrefix    = "rm approval"
+	PauseDeploymentPrefix   = "pause"
+	ResumeDeploymentPrefix  = "resume"
+	CheckRepositoryPrefix   = "check"
+	UpdateDeploymentPrefix  = "update"
+	ReplayDeadLetterPrefix  = "replay"
+	DiscardDeadLetterPrefix = "discard"
 )
 
 var (
@@ -22,10 +29,17 @@ var (
 		"help": {
 			`Here's a list of supported commands`,
 			`- "get deployments" -> get a list of all deployments`,
-			`- "get approvals" -> get a list of approvals`,
+			`- "get approvals" or "list" -> get a list of approvals`,
 			`- "rm approval <approval identifier>" -> remove approval`,
 			`- "approve <approval identifier>" -> approve update request`,
 			`- "reject <approval identifier>" -> reject update request`,
+			`- "pause <namespace>/<deployment>" -> stop updating deployment`,
+			`- "resume <namespace>/<deployment>" -> resume deployment updates`,
+			`- "check <image>" -> check watched repository for new versions now`,
+			`- "update <namespace>/<deployment> <tag>" -> update deployment to a specific version`,
+			`- "get deadletters" -> get a list of events that couldn't be applied`,
+			`- "replay <dead letter id>" -> apply failed event again`,
+			`- "discard <dead letter id>" -> remove failed event`,
 			// `- "get deployments all" -> get a list of all deployments`,
 			// `- "describe deployment <deployment>" -> get details for specified deployment`,
 		},
@@ -35,10 +49,20 @@ var (
 	staticBotCommands = map[string]bool{
 		"get deployments": true,
 		"get approvals":   true,
+		"list":            true,
+		"get deadletters": true,
 	}
 
 	// dynamic bot command prefixes have to be matched
-	dynamicBotCommandPrefixes = []string{RemoveApprovalPrefix}
+	dynamicBotCommandPrefixes = []string{
+		RemoveApprovalPrefix,
+		PauseDeploymentPrefix + " ",
+		ResumeDeploymentPrefix + " ",
+		CheckRepositoryPrefix + " ",
+		UpdateDeploymentPrefix + " ",
+		ReplayDeadLetterPrefix + " ",
+		DiscardDeadLetterPrefix + " ",
+	}
 
 	ApprovalResponseKeyword = "approve"
 	RejectResponseKeyword   = "reject"
@@ -77,10 +101,27 @@ type ApprovalResponse struct {
 	Text   string
 }
 
+// RepositoryChecker - checks watched repositories on demand, returns the
+// number of checked watches
+type RepositoryChecker interface {
+	Check(image string) (int, error)
+}
+
+// Opts - optional bot dependencies, commands that need a missing dependency
+// are reported as unavailable
+type Opts struct {
+	Providers   provider.Providers
+	Checker     RepositoryChecker
+	DeadLetters provider.DeadLetterStore
+}
+
 // BotManager holds approvalsManager and k8sImplementer for every bot
 type BotManager struct {
 	approvalsManager   approvals.Manager
 	k8sImplementer     kubernetes.Implementer
+	providers          provider.Providers
+	checker            RepositoryChecker
+	deadLetters        provider.DeadLetterStore
 	botMessagesChannel chan *BotMessage
 	approvalsRespCh    chan *ApprovalResponse
 }
@@ -110,13 +151,18 @@ func RegisterBot(name string, b Bot) {
 }
 
 // Run all implemented bots
-func Run(k8sImplementer kubernetes.Implementer, approvalsManager approvals.Manager) {
+func Run(k8sImplementer kubernetes.Implementer, approvalsManager approvals.Manager, opts *Opts) {
 	bm := &BotManager{
 		approvalsManager:   approvalsManager,
 		k8sImplementer:     k8sImplementer,
 		approvalsRespCh:    make(chan *ApprovalResponse), // don't add buffer to make it blocking
 		botMessagesChannel: make(chan *BotMessage),
 	}
+	if opts != nil {
+		bm.providers = opts.Providers
+		bm.checker = opts.Checker
+		bm.deadLetters = opts.DeadLetters
+	}
 	for botName, bot := range bots {
 		configured := bot.Configure(bm.approvalsRespCh, bm.botMessagesChannel)
 		if configured {
@@ -186,9 +232,12 @@ func (bm *BotManager) handleCommand(eventText string) string {
 	case "get deployments":
 		log.Info("HandleCommand: getting deployments")
 		return DeploymentsResponse(Filter{}, bm.k8sImplementer)
-	case "get approvals":
+	case "get approvals", "list":
 		log.Info("HandleCommand: getting approvals")
 		return ApprovalsResponse(bm.approvalsManager)
+	case "get deadletters":
+		log.Info("HandleCommand: getting dead letters")
+		return DeadLettersResponse(bm.deadLetters)
 	}
 
 	// handle dynamic commands
@@ -196,6 +245,24 @@ func (bm *BotManager) handleCommand(eventText string) string {
 		id := strings.TrimSpace(strings.TrimPrefix(eventText, RemoveApprovalPrefix))
 		return RemoveApprovalHandler(id, bm.approvalsManager)
 	}
+	if strings.HasPrefix(eventText, PauseDeploymentPrefix+" ") {
+		return PauseHandler(strings.TrimPrefix(eventText, PauseDeploymentPrefix), true, bm.k8sImplementer)
+	}
+	if strings.HasPrefix(eventText, ResumeDeploymentPrefix+" ") {
+		return PauseHandler(strings.TrimPrefix(eventText, ResumeDeploymentPrefix), false, bm.k8sImplementer)
+	}
+	if strings.HasPrefix(eventText, CheckRepositoryPrefix+" ") {
+		return CheckHandler(strings.TrimPrefix(eventText, CheckRepositoryPrefix), bm.checker)
+	}
+	if strings.HasPrefix(eventText, UpdateDeploymentPrefix+" ") {
+		return UpdateHandler(strings.TrimPrefix(eventText, UpdateDeploymentPrefix), bm.k8sImplementer, bm.providers)
+	}
+	if strings.HasPrefix(eventText, ReplayDeadLetterPrefix+" ") {
+		return ReplayDeadLetterHandler(strings.TrimPrefix(eventText, ReplayDeadLetterPrefix), bm.deadLetters, bm.providers)
+	}
+	if strings.HasPrefix(eventText, DiscardDeadLetterPrefix+" ") {
+		return DiscardDeadLetterHandler(strings.TrimPrefix(eventText, DiscardDeadLetterPrefix), bm.deadLetters)
+	}
 
 	log.Infof("bot.HandleCommand(): command [%s] not found", eventText)
 	return ""
@@ -0,0 +1,81 @@
+package bot
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+
+	"github.com/keel-hq/keel/pkg/store"
+	"github.com/keel-hq/keel/provider"
+)
+
+// DeadLettersResponse - lists events that couldn't be applied
+func DeadLettersResponse(deadLetters provider.DeadLetterStore) string {
+	if deadLetters == nil {
+		return "dead letters are not available"
+	}
+
+	stored, err := deadLetters.ListDeadLetters()
+	if err != nil {
+		return fmt.Sprintf("got error while fetching dead letters: %s", err)
+	}
+	if len(stored) == 0 {
+		return "there are no dead letters."
+	}
+
+	buf := bytes.NewBufferString("")
+	for _, dl := range stored {
+		target := dl.Identifier
+		if target == "" {
+			target = "all resources"
+		}
+		var image string
+		if dl.Event != nil {
+			image = dl.Event.Repository.String()
+		}
+		fmt.Fprintf(buf, "%s: %s -> %s, %d attempt(s), error: %s\n", dl.ID, image, target, dl.Attempts, dl.Error)
+	}
+	return buf.String()
+}
+
+// ReplayDeadLetterHandler - submits stored event again
+func ReplayDeadLetterHandler(id string, deadLetters provider.DeadLetterStore, providers provider.Providers) string {
+	id = strings.TrimSpace(id)
+	if id == "" {
+		return "dead letter ID is required, ie: 'replay <id>'"
+	}
+	if deadLetters == nil || providers == nil {
+		return "dead letters are not available"
+	}
+
+	dl, err := provider.ReplayDeadLetter(deadLetters, providers, id)
+	if err == store.ErrRecordNotFound {
+		return fmt.Sprintf("dead letter '%s' was not found", id)
+	}
+	if err != nil {
+		return fmt.Sprintf("failed to replay dead letter %s: %s", id, err)
+	}
+
+	return fmt.Sprintf("event %s replayed.", dl.Event.Repository.String())
+}
+
+// DiscardDeadLetterHandler - removes stored event without applying it
+func DiscardDeadLetterHandler(id string, deadLetters provider.DeadLetterStore) string {
+	id = strings.TrimSpace(id)
+	if id == "" {
+		return "dead letter ID is required, ie: 'discard <id>'"
+	}
+	if deadLetters == nil {
+		return "dead letters are not available"
+	}
+
+	_, err := provider.DiscardDeadLetter(deadLetters, id)
+	if err == store.ErrRecordNotFound {
+		return fmt.Sprintf("dead letter '%s' was not found", id)
+	}
+	if err != nil {
+		return fmt.Sprintf("failed to discard dead letter %s: %s", id, err)
+	}
+
+	return fmt.Sprintf("dead letter %s discarded.", id)
+}
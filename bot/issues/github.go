@@ -0,0 +1,122 @@
+package issues
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strings"
+)
+
+// github - GitHub issues, repository is set as owner/name
+type github struct {
+	client     *http.Client
+	apiURL     string
+	repository string
+	token      string
+}
+
+func (g *github) name() string {
+	return "github/" + g.repository
+}
+
+func (g *github) do(method, path string, in, out interface{}) error {
+	var body io.Reader
+	if in != nil {
+		b, err := json.Marshal(in)
+		if err != nil {
+			return err
+		}
+		body = bytes.NewReader(b)
+	}
+
+	req, err := http.NewRequest(method, strings.TrimSuffix(g.apiURL, "/")+"/repos/"+g.repository+path, body)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/vnd.github.v3+json")
+	req.Header.Set("Content-Type", "application/json")
+	if g.token != "" {
+		req.Header.Set("Authorization", "token "+g.token)
+	}
+
+	return send(g.client, req, out)
+}
+
+func (g *github) createIssue(title, body string) (int, error) {
+	var created struct {
+		Number int `json:"number"`
+	}
+	err := g.do(http.MethodPost, "/issues", map[string]interface{}{
+		"title":  title,
+		"body":   body,
+		"labels": []string{approvalLabel},
+	}, &created)
+	return created.Number, err
+}
+
+func (g *github) comment(number int, body string) error {
+	return g.do(http.MethodPost, fmt.Sprintf("/issues/%d/comments", number), map[string]string{"body": body}, nil)
+}
+
+func (g *github) closeIssue(number int) error {
+	return g.do(http.MethodPatch, fmt.Sprintf("/issues/%d", number), map[string]string{"state": "closed"}, nil)
+}
+
+func (g *github) openIssues() ([]issue, error) {
+	var listed []struct {
+		Number int    `json:"number"`
+		Body   string `json:"body"`
+	}
+	err := g.do(http.MethodGet, "/issues?state=open&per_page=100&labels="+approvalLabel, nil, &listed)
+	if err != nil {
+		return nil, err
+	}
+
+	issues := make([]issue, 0, len(listed))
+	for _, is := range listed {
+		issues = append(issues, issue{Number: is.Number, Body: is.Body})
+	}
+	return issues, nil
+}
+
+func (g *github) comments(number int) ([]comment, error) {
+	var listed []struct {
+		ID   int64  `json:"id"`
+		Body string `json:"body"`
+		User struct {
+			Login string `json:"login"`
+		} `json:"user"`
+	}
+	err := g.do(http.MethodGet, fmt.Sprintf("/issues/%d/comments?per_page=100", number), nil, &listed)
+	if err != nil {
+		return nil, err
+	}
+
+	comments := make([]comment, 0, len(listed))
+	for _, c := range listed {
+		comments = append(comments, comment{ID: c.ID, User: c.User.Login, Body: c.Body})
+	}
+	return comments, nil
+}
+
+// send - sends API request, decodes response into out if set
+func send(client *http.Client, req *http.Request, out interface{}) error {
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		msg, _ := ioutil.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("%s %s: got status %d: %s", req.Method, req.URL.Path, resp.StatusCode, strings.TrimSpace(string(msg)))
+	}
+
+	if out == nil {
+		return nil
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
@@ -0,0 +1,108 @@
+package issues
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// gitlab - GitLab issues, project is set as group/name or project ID
+type gitlab struct {
+	client  *http.Client
+	apiURL  string
+	project string
+	token   string
+}
+
+func (g *gitlab) name() string {
+	return "gitlab/" + g.project
+}
+
+func (g *gitlab) do(method, path string, in, out interface{}) error {
+	var body io.Reader
+	if in != nil {
+		b, err := json.Marshal(in)
+		if err != nil {
+			return err
+		}
+		body = bytes.NewReader(b)
+	}
+
+	endpoint := strings.TrimSuffix(g.apiURL, "/") + "/api/v4/projects/" + url.PathEscape(g.project) + path
+	req, err := http.NewRequest(method, endpoint, body)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if g.token != "" {
+		req.Header.Set("PRIVATE-TOKEN", g.token)
+	}
+
+	return send(g.client, req, out)
+}
+
+func (g *gitlab) createIssue(title, body string) (int, error) {
+	var created struct {
+		IID int `json:"iid"`
+	}
+	err := g.do(http.MethodPost, "/issues", map[string]string{
+		"title":       title,
+		"description": body,
+		"labels":      approvalLabel,
+	}, &created)
+	return created.IID, err
+}
+
+func (g *gitlab) comment(number int, body string) error {
+	return g.do(http.MethodPost, fmt.Sprintf("/issues/%d/notes", number), map[string]string{"body": body}, nil)
+}
+
+func (g *gitlab) closeIssue(number int) error {
+	return g.do(http.MethodPut, fmt.Sprintf("/issues/%d", number), map[string]string{"state_event": "close"}, nil)
+}
+
+func (g *gitlab) openIssues() ([]issue, error) {
+	var listed []struct {
+		IID         int    `json:"iid"`
+		Description string `json:"description"`
+	}
+	err := g.do(http.MethodGet, "/issues?state=opened&per_page=100&labels="+approvalLabel, nil, &listed)
+	if err != nil {
+		return nil, err
+	}
+
+	issues := make([]issue, 0, len(listed))
+	for _, is := range listed {
+		issues = append(issues, issue{Number: is.IID, Body: is.Description})
+	}
+	return issues, nil
+}
+
+func (g *gitlab) comments(number int) ([]comment, error) {
+	var listed []struct {
+		ID     int64  `json:"id"`
+		Body   string `json:"body"`
+		System bool   `json:"system"`
+		Author struct {
+			Username string `json:"username"`
+		} `json:"author"`
+	}
+	err := g.do(http.MethodGet, fmt.Sprintf("/issues/%d/notes?per_page=100&sort=asc", number), nil, &listed)
+	if err != nil {
+		return nil, err
+	}
+
+	comments := make([]comment, 0, len(listed))
+	for _, c := range listed {
+		// label and state changes
+		if c.System {
+			continue
+		}
+		comments = append(comments, comment{ID: c.ID, User: c.Author.Username, Body: c.Body})
+	}
+	return comments, nil
+}
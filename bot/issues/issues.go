@@ -0,0 +1,301 @@
+package issues
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"os"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/keel-hq/keel/bot"
+	"github.com/keel-hq/keel/constants"
+	"github.com/keel-hq/keel/types"
+
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	defaultPollInterval = time.Minute
+	timeout             = 10 * time.Second
+
+	// label of approval issues, only labeled issues are checked for votes
+	approvalLabel = "keel-approval"
+
+	approveCommand = "/approve"
+	rejectCommand  = "/reject"
+)
+
+// marker - hidden approval identifier in the issue description, issues are
+// matched to approvals through it after restart
+var marker = regexp.MustCompile(`<!-- keel-approval: (\S+) -->`)
+
+type issue struct {
+	Number int
+	Body   string
+}
+
+type comment struct {
+	ID   int64
+	User string
+	Body string
+}
+
+// tracker - issue tracker API
+type tracker interface {
+	name() string
+	createIssue(title, body string) (int, error)
+	comment(number int, body string) error
+	closeIssue(number int) error
+	openIssues() ([]issue, error)
+	comments(number int) ([]comment, error)
+}
+
+// Bot - opens an issue per approval request in a GitHub or GitLab project,
+// /approve and /reject comments of allowed users are counted as votes
+type Bot struct {
+	tracker  tracker
+	users    map[string]bool
+	interval time.Duration
+
+	mu sync.Mutex
+	// approval identifier -> issue number
+	issues map[string]int
+	// processed comments
+	seen map[int64]bool
+
+	approvalsRespCh chan *bot.ApprovalResponse
+}
+
+func init() {
+	bot.RegisterBot("issues", &Bot{})
+}
+
+// Configure - bot is enabled when a GitHub repository or a GitLab project
+// and users allowed to vote are set
+func (b *Bot) Configure(approvalsRespCh chan *bot.ApprovalResponse, botMessagesChannel chan *bot.BotMessage) bool {
+	client := &http.Client{Timeout: timeout}
+
+	switch {
+	case os.Getenv(constants.EnvApprovalsGithubRepository) != "":
+		b.tracker = &github{
+			client:     client,
+			apiURL:     envOr(constants.EnvApprovalsGithubURL, "https://api.github.com"),
+			repository: os.Getenv(constants.EnvApprovalsGithubRepository),
+			token:      os.Getenv(constants.EnvApprovalsGithubToken),
+		}
+	case os.Getenv(constants.EnvApprovalsGitlabProject) != "":
+		b.tracker = &gitlab{
+			client:  client,
+			apiURL:  envOr(constants.EnvApprovalsGitlabURL, "https://gitlab.com"),
+			project: os.Getenv(constants.EnvApprovalsGitlabProject),
+			token:   os.Getenv(constants.EnvApprovalsGitlabToken),
+		}
+	default:
+		log.Info("bot.issues.Configure(): issue approvals are not configured")
+		return false
+	}
+
+	b.users = make(map[string]bool)
+	for _, user := range strings.Split(os.Getenv(constants.EnvApprovalsGitUsers), ",") {
+		if user = strings.ToLower(strings.TrimSpace(user)); user != "" {
+			b.users[user] = true
+		}
+	}
+	if len(b.users) == 0 {
+		log.Errorf("bot.issues.Configure(): %s not set, nobody would be able to vote", constants.EnvApprovalsGitUsers)
+		return false
+	}
+
+	b.interval = defaultPollInterval
+	if interval := os.Getenv(constants.EnvApprovalsGitPollInterval); interval != "" {
+		d, err := time.ParseDuration(interval)
+		if err != nil {
+			log.WithFields(log.Fields{
+				"error":    err,
+				"interval": interval,
+			}).Warn("bot.issues.Configure(): failed to parse poll interval, using default")
+		} else {
+			b.interval = d
+		}
+	}
+
+	b.issues = make(map[string]int)
+	b.seen = make(map[int64]bool)
+	b.approvalsRespCh = approvalsRespCh
+
+	return true
+}
+
+func envOr(name, defaultValue string) string {
+	if v := os.Getenv(name); v != "" {
+		return v
+	}
+	return defaultValue
+}
+
+// Start - starts checking approval issues for votes
+func (b *Bot) Start(ctx context.Context) error {
+	log.WithFields(log.Fields{
+		"tracker":  b.tracker.name(),
+		"interval": b.interval,
+	}).Info("bot.issues: checking approval issues for votes")
+
+	go func() {
+		ticker := time.NewTicker(b.interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				err := b.checkVotes(ctx)
+				if err != nil {
+					log.WithError(err).Error("bot.issues: failed to check approval issues")
+				}
+			}
+		}
+	}()
+
+	return nil
+}
+
+// checkVotes - passes new /approve and /reject comments of allowed users on
+// open approval issues to the approvals manager
+func (b *Bot) checkVotes(ctx context.Context) error {
+	open, err := b.tracker.openIssues()
+	if err != nil {
+		return err
+	}
+
+	for _, is := range open {
+		matches := marker.FindStringSubmatch(is.Body)
+		if matches == nil {
+			continue
+		}
+		identifier := matches[1]
+
+		b.mu.Lock()
+		b.issues[identifier] = is.Number
+		b.mu.Unlock()
+
+		comments, err := b.tracker.comments(is.Number)
+		if err != nil {
+			log.WithFields(log.Fields{
+				"error": err,
+				"issue": is.Number,
+			}).Error("bot.issues: failed to get issue comments")
+			continue
+		}
+
+		for _, c := range comments {
+			resp, ok := b.vote(c, identifier)
+			if !ok {
+				continue
+			}
+			select {
+			case b.approvalsRespCh <- resp:
+			case <-ctx.Done():
+				return nil
+			}
+		}
+	}
+
+	return nil
+}
+
+// vote - parses comment, only the first comment line is checked
+func (b *Bot) vote(c comment, identifier string) (*bot.ApprovalResponse, bool) {
+	b.mu.Lock()
+	if b.seen[c.ID] {
+		b.mu.Unlock()
+		return nil, false
+	}
+	b.seen[c.ID] = true
+	b.mu.Unlock()
+
+	command := strings.ToLower(strings.TrimSpace(strings.SplitN(strings.TrimSpace(c.Body), "\n", 2)[0]))
+
+	var resp *bot.ApprovalResponse
+	switch command {
+	case approveCommand:
+		resp = &bot.ApprovalResponse{User: c.User, Status: types.ApprovalStatusApproved, Text: bot.ApprovalResponseKeyword + " " + identifier}
+	case rejectCommand:
+		resp = &bot.ApprovalResponse{User: c.User, Status: types.ApprovalStatusRejected, Text: bot.RejectResponseKeyword + " " + identifier}
+	default:
+		return nil, false
+	}
+
+	if !b.users[strings.ToLower(c.User)] {
+		log.WithFields(log.Fields{
+			"user":       c.User,
+			"identifier": identifier,
+		}).Warn("bot.issues: ignoring vote of a user that is not allowed to vote")
+		return nil, false
+	}
+
+	return resp, true
+}
+
+// Respond - bot commands are not supported
+func (b *Bot) Respond(text string, channel string) {}
+
+// RequestApproval - opens approval issue
+func (b *Bot) RequestApproval(req *types.Approval) error {
+	title := fmt.Sprintf("Approval required: %s (%s)", req.Identifier, req.Delta())
+	body := fmt.Sprintf("%s\n\n| Votes | Delta | Identifier | Provider |\n|---|---|---|---|\n| %d/%d | %s | %s | %s |\n\nComment `%s` to vote for the change or `%s` to reject it.\n\n<!-- keel-approval: %s -->",
+		req.Message, req.VotesReceived, req.VotesRequired, req.Delta(), req.Identifier, req.Provider.String(), approveCommand, rejectCommand, req.Identifier)
+
+	number, err := b.tracker.createIssue(title, body)
+	if err != nil {
+		return err
+	}
+
+	b.mu.Lock()
+	b.issues[req.Identifier] = number
+	b.mu.Unlock()
+
+	return nil
+}
+
+// ReplyToApproval - reports vote results on the approval issue, issue is
+// closed once the approval is approved or rejected
+func (b *Bot) ReplyToApproval(approval *types.Approval) error {
+	b.mu.Lock()
+	number, ok := b.issues[approval.Identifier]
+	b.mu.Unlock()
+	if !ok {
+		// approval requested through other bots
+		return nil
+	}
+
+	var text string
+	switch approval.Status() {
+	case types.ApprovalStatusPending:
+		text = fmt.Sprintf("Vote received, waiting for remaining votes (%d/%d).", approval.VotesReceived, approval.VotesRequired)
+	case types.ApprovalStatusRejected:
+		text = "Change was rejected."
+	case types.ApprovalStatusApproved:
+		text = "Update approved, all approvals received, thanks for voting!"
+	default:
+		return nil
+	}
+
+	err := b.tracker.comment(number, text)
+	if err != nil {
+		return err
+	}
+
+	if approval.Status() == types.ApprovalStatusPending {
+		return nil
+	}
+
+	b.mu.Lock()
+	delete(b.issues, approval.Identifier)
+	b.mu.Unlock()
+
+	return b.tracker.closeIssue(number)
+}
@@ -0,0 +1,138 @@
+package issues
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/keel-hq/keel/bot"
+	"github.com/keel-hq/keel/types"
+)
+
+// fakeGithub - minimal GitHub issues API
+type fakeGithub struct {
+	mu       sync.Mutex
+	body     string
+	comments []map[string]interface{}
+	posted   []string
+	closed   bool
+}
+
+func (f *fakeGithub) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	switch {
+	case r.Method == http.MethodPost && r.URL.Path == "/repos/org/infra/issues":
+		var req map[string]interface{}
+		json.NewDecoder(r.Body).Decode(&req)
+		f.body = req["body"].(string)
+		json.NewEncoder(w).Encode(map[string]int{"number": 7})
+	case r.Method == http.MethodGet && r.URL.Path == "/repos/org/infra/issues":
+		if r.URL.Query().Get("labels") != approvalLabel {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		json.NewEncoder(w).Encode([]map[string]interface{}{{"number": 7, "body": f.body}})
+	case r.Method == http.MethodGet && r.URL.Path == "/repos/org/infra/issues/7/comments":
+		json.NewEncoder(w).Encode(f.comments)
+	case r.Method == http.MethodPost && r.URL.Path == "/repos/org/infra/issues/7/comments":
+		body, _ := ioutil.ReadAll(r.Body)
+		f.posted = append(f.posted, string(body))
+		w.WriteHeader(http.StatusCreated)
+	case r.Method == http.MethodPatch && r.URL.Path == "/repos/org/infra/issues/7":
+		f.closed = true
+	default:
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
+
+func githubComment(id int, user, body string) map[string]interface{} {
+	return map[string]interface{}{"id": id, "body": body, "user": map[string]string{"login": user}}
+}
+
+func newTestBot(apiURL string) *Bot {
+	return &Bot{
+		tracker: &github{
+			client:     &http.Client{},
+			apiURL:     apiURL,
+			repository: "org/infra",
+			token:      "secret",
+		},
+		users:           map[string]bool{"alice": true},
+		issues:          make(map[string]int),
+		seen:            make(map[int64]bool),
+		approvalsRespCh: make(chan *bot.ApprovalResponse, 10),
+	}
+}
+
+func TestRequestApprovalAndVote(t *testing.T) {
+	gh := &fakeGithub{}
+	srv := httptest.NewServer(gh)
+	defer srv.Close()
+
+	b := newTestBot(srv.URL)
+
+	err := b.RequestApproval(&types.Approval{
+		Identifier:     "default/wd:1.2.0",
+		Message:        "New image is available for resource default/wd",
+		CurrentVersion: "1.1.0",
+		NewVersion:     "1.2.0",
+		VotesRequired:  1,
+	})
+	if err != nil {
+		t.Fatalf("failed to request approval: %s", err)
+	}
+	if !strings.Contains(gh.body, "<!-- keel-approval: default/wd:1.2.0 -->") {
+		t.Errorf("expected approval identifier marker in issue body: %s", gh.body)
+	}
+
+	gh.comments = []map[string]interface{}{
+		githubComment(1, "mallory", "/approve"),
+		githubComment(2, "Alice", "/approve\nlooks good"),
+		githubComment(3, "alice", "thanks"),
+	}
+
+	for i := 0; i < 2; i++ {
+		if err := b.checkVotes(context.Background()); err != nil {
+			t.Fatalf("failed to check votes: %s", err)
+		}
+	}
+
+	if len(b.approvalsRespCh) != 1 {
+		t.Fatalf("expected a single vote, got: %d", len(b.approvalsRespCh))
+	}
+	resp := <-b.approvalsRespCh
+	if resp.Status != types.ApprovalStatusApproved || resp.User != "Alice" || resp.Text != "approve default/wd:1.2.0" {
+		t.Errorf("unexpected approval response: %+v", resp)
+	}
+
+	err = b.ReplyToApproval(&types.Approval{
+		Identifier:    "default/wd:1.2.0",
+		VotesRequired: 1,
+		VotesReceived: 1,
+	})
+	if err != nil {
+		t.Fatalf("failed to reply: %s", err)
+	}
+	if len(gh.posted) != 1 || !gh.closed {
+		t.Errorf("expected approval issue to be commented and closed, comments: %v, closed: %v", gh.posted, gh.closed)
+	}
+}
+
+func TestVoteReject(t *testing.T) {
+	b := newTestBot("")
+
+	resp, ok := b.vote(comment{ID: 1, User: "alice", Body: " /Reject "}, "default/wd:1.2.0")
+	if !ok {
+		t.Fatalf("expected reject vote")
+	}
+	if resp.Status != types.ApprovalStatusRejected || resp.Text != "reject default/wd:1.2.0" {
+		t.Errorf("unexpected approval response: %+v", resp)
+	}
+}
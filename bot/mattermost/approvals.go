@@ -0,0 +1,52 @@
+package mattermost
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/keel-hq/keel/types"
+)
+
+// getApprovalChannel - returns channel override for specific approval or
+// default approvals channel
+func (b *Bot) getApprovalChannel(approval *types.Approval) string {
+	channel := strings.TrimLeft(strings.TrimSpace(approval.Channel), "#~")
+	if channel == "" {
+		return b.approvalsChannel
+	}
+
+	b.approvalChannelsM.Lock()
+	if b.approvalChannels == nil {
+		b.approvalChannels = make(map[string]bool)
+	}
+	b.approvalChannels[channel] = true
+	b.approvalChannelsM.Unlock()
+
+	return channel
+}
+
+// RequestApproval - request approval
+func (b *Bot) RequestApproval(req *types.Approval) error {
+	text := fmt.Sprintf("#### Approval required!\n%s\n\n| Votes | Delta | Identifier | Provider |\n|---|---|---|---|\n| %d/%d | %s | %s | %s |\n\nTo vote for change type `/keel approve %s`, to reject it: `/keel reject %s`.",
+		req.Message, req.VotesReceived, req.VotesRequired, req.Delta(), req.Identifier, req.Provider.String(), req.Identifier, req.Identifier)
+	return b.postMessage(b.getApprovalChannel(req), text)
+}
+
+// ReplyToApproval - reports vote results
+func (b *Bot) ReplyToApproval(approval *types.Approval) error {
+	var title string
+	switch approval.Status() {
+	case types.ApprovalStatusPending:
+		title = "Vote received, waiting for remaining votes."
+	case types.ApprovalStatusRejected:
+		title = "Change was rejected."
+	case types.ApprovalStatusApproved:
+		title = "Update approved, all approvals received, thanks for voting!"
+	default:
+		return nil
+	}
+
+	text := fmt.Sprintf("#### %s\n\n| Votes | Delta | Identifier |\n|---|---|---|\n| %d/%d | %s | %s |",
+		title, approval.VotesReceived, approval.VotesRequired, approval.Delta(), approval.Identifier)
+	return b.postMessage(b.getApprovalChannel(approval), text)
+}
@@ -0,0 +1,216 @@
+package mattermost
+
+import (
+	"bytes"
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/keel-hq/keel/bot"
+	"github.com/keel-hq/keel/constants"
+
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	defaultAddress = ":9301"
+	commandPath    = "/v1/mattermost/command"
+	timeout        = 5 * time.Second
+)
+
+// Bot - Mattermost bot, receives /keel slash commands and posts replies and
+// approval requests through an incoming webhook
+type Bot struct {
+	name     string
+	token    string // slash command token
+	address  string
+	endpoint string // incoming webhook
+
+	approvalsChannel string
+
+	// per deployment approval channels, votes are accepted from these
+	// channels as well as from the default approvals channel
+	approvalChannelsM sync.RWMutex
+	approvalChannels  map[string]bool
+
+	client *http.Client
+	server *http.Server
+
+	botMessagesChannel chan *bot.BotMessage
+	approvalsRespCh    chan *bot.ApprovalResponse
+}
+
+func init() {
+	bot.RegisterBot("mattermost", &Bot{})
+}
+
+// Configure - bot is enabled when both slash command token and incoming
+// webhook are set
+func (b *Bot) Configure(approvalsRespCh chan *bot.ApprovalResponse, botMessagesChannel chan *bot.BotMessage) bool {
+	b.token = os.Getenv(constants.EnvMattermostSlashToken)
+	b.endpoint = os.Getenv(constants.EnvMattermostEndpoint)
+	if b.token == "" || b.endpoint == "" {
+		log.Info("bot.mattermost.Configure(): Mattermost approval bot is not configured")
+		return false
+	}
+
+	b.name = "keel"
+	if name := os.Getenv(constants.EnvMattermostName); name != "" {
+		b.name = name
+	}
+
+	b.address = defaultAddress
+	if address := os.Getenv(constants.EnvMattermostBotAddress); address != "" {
+		b.address = address
+	}
+
+	b.approvalsChannel = "town-square"
+	if channel := os.Getenv(constants.EnvMattermostApprovalsChannel); channel != "" {
+		b.approvalsChannel = strings.TrimPrefix(channel, "~")
+	}
+
+	b.client = &http.Client{Timeout: timeout}
+	b.approvalsRespCh = approvalsRespCh
+	b.botMessagesChannel = botMessagesChannel
+
+	return true
+}
+
+// Start - starts listening for slash commands
+func (b *Bot) Start(ctx context.Context) error {
+	mux := http.NewServeMux()
+	mux.HandleFunc(commandPath, b.commandHandler)
+	b.server = &http.Server{Addr: b.address, Handler: mux}
+
+	go func() {
+		log.WithFields(log.Fields{
+			"address": b.address,
+			"path":    commandPath,
+		}).Info("bot.mattermost: listening for slash commands")
+		if err := b.server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+			log.WithError(err).Error("bot.mattermost: slash command server failed")
+		}
+	}()
+
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), timeout)
+		defer cancel()
+		b.server.Shutdown(shutdownCtx)
+	}()
+
+	return nil
+}
+
+type commandResponse struct {
+	ResponseType string `json:"response_type"`
+	Text         string `json:"text"`
+}
+
+func ephemeral(resp http.ResponseWriter, text string) {
+	resp.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(resp).Encode(commandResponse{ResponseType: "ephemeral", Text: text})
+}
+
+// commandHandler - handles /keel slash command requests, command output is
+// posted to the channel asynchronously
+func (b *Bot) commandHandler(resp http.ResponseWriter, req *http.Request) {
+	if req.Method != http.MethodPost {
+		http.Error(resp, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if err := req.ParseForm(); err != nil {
+		http.Error(resp, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if subtle.ConstantTimeCompare([]byte(req.PostForm.Get("token")), []byte(b.token)) != 1 {
+		log.WithField("remote", req.RemoteAddr).Warn("bot.mattermost: invalid slash command token")
+		http.Error(resp, "invalid token", http.StatusUnauthorized)
+		return
+	}
+
+	user := req.PostForm.Get("user_name")
+	channel := req.PostForm.Get("channel_name")
+	text := strings.Trim(strings.ToLower(req.PostForm.Get("text")), " \n\r")
+	if text == "" {
+		text = "help"
+	}
+
+	approval, ok := bot.IsApproval(user, text)
+	// only accepting approvals from approvals channel
+	if ok && b.isApprovalsChannel(channel) {
+		b.approvalsRespCh <- approval
+		ephemeral(resp, "vote received")
+		return
+	} else if ok {
+		log.WithFields(log.Fields{
+			"received_on":    channel,
+			"approvals_chan": b.approvalsChannel,
+		}).Warnf("message was received not in approvals channel: %s", channel)
+		ephemeral(resp, fmt.Sprintf("please use approvals channel '%s'", b.approvalsChannel))
+		return
+	}
+
+	b.botMessagesChannel <- &bot.BotMessage{
+		Message: text,
+		User:    user,
+		Channel: channel,
+		Name:    "mattermost",
+	}
+	resp.WriteHeader(http.StatusOK)
+}
+
+func (b *Bot) isApprovalsChannel(channel string) bool {
+	if channel == b.approvalsChannel {
+		return true
+	}
+	b.approvalChannelsM.RLock()
+	defer b.approvalChannelsM.RUnlock()
+	return b.approvalChannels[channel]
+}
+
+type webhookMessage struct {
+	Channel  string `json:"channel,omitempty"`
+	Username string `json:"username"`
+	IconURL  string `json:"icon_url"`
+	Text     string `json:"text"`
+}
+
+func (b *Bot) postMessage(channel, text string) error {
+	body, err := json.Marshal(webhookMessage{
+		Channel:  channel,
+		Username: b.name,
+		IconURL:  constants.KeelLogoURL,
+		Text:     text,
+	})
+	if err != nil {
+		return fmt.Errorf("could not marshal: %s", err)
+	}
+
+	resp, err := b.client.Post(b.endpoint, "application/json", bytes.NewBuffer(body))
+	if err != nil {
+		log.WithFields(log.Fields{
+			"error":   err,
+			"channel": channel,
+		}).Error("bot.mattermost: failed to send message")
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusCreated {
+		return fmt.Errorf("got status %d, expected 200/201", resp.StatusCode)
+	}
+	return nil
+}
+
+// Respond - posts command output to the channel it was received from
+func (b *Bot) Respond(text string, channel string) {
+	b.postMessage(channel, "```\n"+text+"\n```")
+}
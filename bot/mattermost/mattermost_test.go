@@ -0,0 +1,117 @@
+package mattermost
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/keel-hq/keel/bot"
+	"github.com/keel-hq/keel/types"
+)
+
+func newTestBot(endpoint string) *Bot {
+	return &Bot{
+		name:               "keel",
+		token:              "slash-token",
+		endpoint:           endpoint,
+		approvalsChannel:   "approvals",
+		client:             &http.Client{},
+		approvalsRespCh:    make(chan *bot.ApprovalResponse, 1),
+		botMessagesChannel: make(chan *bot.BotMessage, 1),
+	}
+}
+
+func command(b *Bot, token, channel, text string) *httptest.ResponseRecorder {
+	form := url.Values{
+		"token":        {token},
+		"user_name":    {"alice"},
+		"channel_name": {channel},
+		"command":      {"/keel"},
+		"text":         {text},
+	}
+	req := httptest.NewRequest("POST", commandPath, strings.NewReader(form.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	rec := httptest.NewRecorder()
+	b.commandHandler(rec, req)
+	return rec
+}
+
+func TestCommandInvalidToken(t *testing.T) {
+	b := newTestBot("")
+	if rec := command(b, "wrong", "approvals", "list"); rec.Code != http.StatusUnauthorized {
+		t.Errorf("unexpected status code: %d", rec.Code)
+	}
+}
+
+func TestCommandApprove(t *testing.T) {
+	b := newTestBot("")
+
+	rec := command(b, "slash-token", "approvals", "approve default/wd:1.2.0")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("unexpected status code: %d", rec.Code)
+	}
+
+	select {
+	case resp := <-b.approvalsRespCh:
+		if resp.Status != types.ApprovalStatusApproved || resp.User != "alice" || resp.Text != "approve default/wd:1.2.0" {
+			t.Errorf("unexpected approval response: %+v", resp)
+		}
+	default:
+		t.Fatalf("expected approval response")
+	}
+
+	command(b, "slash-token", "town-square", "approve default/wd:1.2.0")
+	select {
+	case resp := <-b.approvalsRespCh:
+		t.Errorf("approval outside of approvals channel accepted: %+v", resp)
+	default:
+	}
+}
+
+func TestCommandMessage(t *testing.T) {
+	b := newTestBot("")
+
+	command(b, "slash-token", "town-square", "pause default/wd")
+	select {
+	case m := <-b.botMessagesChannel:
+		if m.Message != "pause default/wd" || m.Channel != "town-square" || m.Name != "mattermost" {
+			t.Errorf("unexpected bot message: %+v", m)
+		}
+	default:
+		t.Fatalf("expected bot message")
+	}
+}
+
+func TestRequestApproval(t *testing.T) {
+	var msg webhookMessage
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		json.Unmarshal(body, &msg)
+	}))
+	defer ts.Close()
+
+	b := newTestBot(ts.URL)
+	err := b.RequestApproval(&types.Approval{
+		Identifier:    "default/wd:1.2.0",
+		Message:       "New image is available for deployment default/wd",
+		VotesRequired: 1,
+		Channel:       "~releases",
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if msg.Channel != "releases" {
+		t.Errorf("unexpected channel: %s", msg.Channel)
+	}
+	if !strings.Contains(msg.Text, "/keel approve default/wd:1.2.0") {
+		t.Errorf("unexpected text: %s", msg.Text)
+	}
+	if !b.isApprovalsChannel("releases") {
+		t.Errorf("expected approval channel override to accept votes")
+	}
+}
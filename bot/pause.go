@@ -0,0 +1,57 @@
+package bot
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/keel-hq/keel/internal/k8s"
+	"github.com/keel-hq/keel/provider/kubernetes"
+	"github.com/keel-hq/keel/types"
+)
+
+// PauseHandler - sets or removes keel.sh/paused on a deployment, target is
+// "<namespace>/<name>" or just the name for deployments in the default namespace
+func PauseHandler(target string, paused bool, k8sImplementer kubernetes.Implementer) string {
+	namespace, name := "default", strings.TrimSpace(target)
+	if parts := strings.SplitN(name, "/", 2); len(parts) == 2 {
+		namespace, name = parts[0], parts[1]
+	}
+	if name == "" {
+		return "deployment is required, ie: 'pause default/my-app'"
+	}
+
+	deps, err := k8sImplementer.Deployments(namespace)
+	if err != nil {
+		return fmt.Sprintf("got error while fetching deployments: %s", err)
+	}
+
+	for i := range deps.Items {
+		deployment := &deps.Items[i]
+		if deployment.Name != name {
+			continue
+		}
+
+		resource, err := k8s.NewGenericResource(deployment)
+		if err != nil {
+			return fmt.Sprintf("failed to update %s/%s: %s", namespace, name, err)
+		}
+		annotations := resource.GetAnnotations()
+		if paused {
+			annotations[types.KeelPausedAnnotation] = "true"
+		} else {
+			delete(annotations, types.KeelPausedAnnotation)
+		}
+		resource.SetAnnotations(annotations)
+
+		if err := k8sImplementer.Update(resource); err != nil {
+			return fmt.Sprintf("failed to update %s/%s: %s", namespace, name, err)
+		}
+
+		if paused {
+			return fmt.Sprintf("updates of %s/%s paused.", namespace, name)
+		}
+		return fmt.Sprintf("updates of %s/%s resumed.", namespace, name)
+	}
+
+	return fmt.Sprintf("deployment '%s/%s' was not found", namespace, name)
+}
@@ -0,0 +1,56 @@
+package bot
+
+import (
+	"testing"
+
+	"github.com/keel-hq/keel/internal/k8s"
+	"github.com/keel-hq/keel/provider/kubernetes"
+	"github.com/keel-hq/keel/types"
+
+	apps_v1 "k8s.io/api/apps/v1"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+type fakeImplementer struct {
+	kubernetes.Implementer
+
+	deployments *apps_v1.DeploymentList
+	updated     []*k8s.GenericResource
+}
+
+func (i *fakeImplementer) Deployments(namespace string) (*apps_v1.DeploymentList, error) {
+	return i.deployments, nil
+}
+
+func (i *fakeImplementer) Update(obj *k8s.GenericResource) error {
+	i.updated = append(i.updated, obj)
+	return nil
+}
+
+func TestPauseHandler(t *testing.T) {
+	fi := &fakeImplementer{
+		deployments: &apps_v1.DeploymentList{
+			Items: []apps_v1.Deployment{
+				{ObjectMeta: meta_v1.ObjectMeta{Name: "wd", Namespace: "default"}},
+			},
+		},
+	}
+
+	if resp := PauseHandler(" default/wd", true, fi); resp != "updates of default/wd paused." {
+		t.Errorf("unexpected response: %s", resp)
+	}
+	if len(fi.updated) != 1 || fi.updated[0].GetAnnotations()[types.KeelPausedAnnotation] != "true" {
+		t.Fatalf("expected deployment to be paused")
+	}
+
+	if resp := PauseHandler("wd", false, fi); resp != "updates of default/wd resumed." {
+		t.Errorf("unexpected response: %s", resp)
+	}
+	if _, ok := fi.updated[1].GetAnnotations()[types.KeelPausedAnnotation]; ok {
+		t.Errorf("expected paused annotation to be removed")
+	}
+
+	if resp := PauseHandler("default/missing", true, fi); resp != "deployment 'default/missing' was not found" {
+		t.Errorf("unexpected response: %s", resp)
+	}
+}
@@ -10,6 +10,7 @@ import (
 // Request - request approval
 func (b *Bot) RequestApproval(req *types.Approval) error {
 	return b.postMessage(
+		b.getApprovalChannel(req),
 		"Approval required",
 		req.Message,
 		types.LevelSuccess.Color(),
@@ -46,6 +47,7 @@ func (b *Bot) ReplyToApproval(approval *types.Approval) error {
 	switch approval.Status() {
 	case types.ApprovalStatusPending:
 		b.postMessage(
+			b.getApprovalChannel(approval),
 			"Vote received",
 			"All approvals received, thanks for voting!",
 			types.LevelInfo.Color(),
@@ -73,6 +75,7 @@ func (b *Bot) ReplyToApproval(approval *types.Approval) error {
 			})
 	case types.ApprovalStatusRejected:
 		b.postMessage(
+			b.getApprovalChannel(approval),
 			"Change rejected",
 			"Change was rejected",
 			types.LevelWarn.Color(),
@@ -105,6 +108,7 @@ func (b *Bot) ReplyToApproval(approval *types.Approval) error {
 			})
 	case types.ApprovalStatusApproved:
 		b.postMessage(
+			b.getApprovalChannel(approval),
 			"approval received",
 			"All approvals received, thanks for voting!",
 			types.LevelSuccess.Color(),
@@ -8,12 +8,14 @@ import (
 	"os"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/nlopes/slack"
 
 	"github.com/keel-hq/keel/bot"
 	"github.com/keel-hq/keel/constants"
+	"github.com/keel-hq/keel/types"
 	"github.com/keel-hq/keel/version"
 
 	log "github.com/sirupsen/logrus"
@@ -41,6 +43,11 @@ type Bot struct {
 
 	approvalsChannel string // slack approvals channel name
 
+	// per deployment approval channels, votes are accepted from
+	// these channels as well as from the default approvals channel
+	approvalChannelsM sync.RWMutex
+	approvalChannels  map[string]bool
+
 	ctx                context.Context
 	botMessagesChannel chan *bot.BotMessage
 	approvalsRespCh    chan *bot.ApprovalResponse
@@ -144,7 +151,34 @@ func (b *Bot) startInternal() error {
 	}
 }
 
-func (b *Bot) postMessage(title, message, color string, fields []slack.AttachmentField) error {
+// getApprovalChannel - returns channel override for specific approval or
+// default approvals channel
+func (b *Bot) getApprovalChannel(approval *types.Approval) string {
+	channel := strings.TrimPrefix(strings.TrimSpace(approval.Channel), "#")
+	if channel == "" {
+		return b.approvalsChannel
+	}
+
+	b.approvalChannelsM.Lock()
+	if b.approvalChannels == nil {
+		b.approvalChannels = make(map[string]bool)
+	}
+	b.approvalChannels[channel] = true
+	b.approvalChannelsM.Unlock()
+
+	return channel
+}
+
+func (b *Bot) isKnownApprovalChannel(name string) bool {
+	if name == b.approvalsChannel {
+		return true
+	}
+	b.approvalChannelsM.RLock()
+	defer b.approvalChannelsM.RUnlock()
+	return b.approvalChannels[name]
+}
+
+func (b *Bot) postMessage(channel, title, message, color string, fields []slack.AttachmentField) error {
 	params := slack.NewPostMessageParameters()
 	params.Username = b.name
 	params.IconURL = b.getBotUserIconURL()
@@ -164,11 +198,11 @@ func (b *Bot) postMessage(title, message, color string, fields []slack.Attachmen
 	mgsOpts = append(mgsOpts, slack.MsgOptionPostMessageParameters(params))
 	mgsOpts = append(mgsOpts, slack.MsgOptionAttachments(attachements...))
 
-	_, _, err := b.slackHTTPClient.PostMessage(b.approvalsChannel, mgsOpts...)
+	_, _, err := b.slackHTTPClient.PostMessage(channel, mgsOpts...)
 	if err != nil {
 		log.WithFields(log.Fields{
 			"error":             err,
-			"approvals_channel": b.approvalsChannel,
+			"approvals_channel": channel,
 		}).Error("bot.postMessage: failed to send message")
 	}
 	return err
@@ -183,7 +217,7 @@ func (b *Bot) isApprovalsChannel(event *slack.MessageEvent) bool {
 		conv, err := b.slackRTM.GetConversationInfo(event.Channel, true)
 		if err != nil {
 			log.Errorf("couldn't find amongst private conversations: %s", err)
-		} else if conv.Name == b.approvalsChannel {
+		} else if b.isKnownApprovalChannel(conv.Name) {
 			return true
 		}
 
@@ -192,7 +226,7 @@ func (b *Bot) isApprovalsChannel(event *slack.MessageEvent) bool {
 	}
 
 	log.Debugf("checking if approvals channel: %s==%s", channel.Name, b.approvalsChannel)
-	if channel.Name == b.approvalsChannel {
+	if b.isKnownApprovalChannel(channel.Name) {
 		return true
 	}
 
@@ -363,3 +363,22 @@ func TestIsApproval(t *testing.T) {
 		t.Errorf("event expected to be an approval")
 	}
 }
+
+func TestGetApprovalChannel(t *testing.T) {
+	bot := &Bot{approvalsChannel: "approvals"}
+
+	if got := bot.getApprovalChannel(&types.Approval{}); got != "approvals" {
+		t.Errorf("expected default approvals channel, got: %s", got)
+	}
+
+	if got := bot.getApprovalChannel(&types.Approval{Channel: "#team-a"}); got != "team-a" {
+		t.Errorf("expected approval channel override, got: %s", got)
+	}
+
+	if !bot.isKnownApprovalChannel("team-a") {
+		t.Errorf("expected votes to be accepted from team-a channel")
+	}
+	if bot.isKnownApprovalChannel("random") {
+		t.Errorf("didn't expect votes to be accepted from random channel")
+	}
+}
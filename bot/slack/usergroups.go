@@ -0,0 +1,83 @@
+package slack
+
+import (
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/nlopes/slack"
+
+	"github.com/keel-hq/keel/approvals"
+)
+
+// userGroupsTTL - how long user groups and their members are cached
+const userGroupsTTL = 5 * time.Minute
+
+// UserGroupsClient - slack API used to resolve user groups
+type UserGroupsClient interface {
+	GetUserGroups(options ...slack.GetUserGroupsOption) ([]slack.UserGroup, error)
+	GetUserGroupMembers(userGroup string) ([]string, error)
+}
+
+// UserGroups - resolves keel.sh/approvers groups to members of the Slack user
+// group with the same handle (ie: team-payments for @team-payments). Members
+// are Slack user IDs, the same IDs bot votes are attributed to
+type UserGroups struct {
+	client UserGroupsClient
+
+	mu        sync.Mutex
+	groups    map[string]string // handle -> user group ID
+	members   map[string][]string
+	refreshed time.Time
+}
+
+// NewUserGroups - create new Slack user groups resolver
+func NewUserGroups(client UserGroupsClient) *UserGroups {
+	return &UserGroups{
+		client:  client,
+		members: make(map[string][]string),
+	}
+}
+
+// NewUserGroupsFromToken - create new Slack user groups resolver, token needs
+// usergroups:read scope
+func NewUserGroupsFromToken(token string) *UserGroups {
+	return NewUserGroups(slack.New(token))
+}
+
+// Members - returns members of the user group with given handle
+func (g *UserGroups) Members(group string) ([]string, error) {
+	handle := strings.ToLower(strings.TrimPrefix(group, "@"))
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if time.Since(g.refreshed) > userGroupsTTL {
+		userGroups, err := g.client.GetUserGroups()
+		if err != nil {
+			return nil, err
+		}
+		g.groups = make(map[string]string, len(userGroups))
+		for _, ug := range userGroups {
+			g.groups[strings.ToLower(ug.Handle)] = ug.ID
+		}
+		g.members = make(map[string][]string)
+		g.refreshed = time.Now()
+	}
+
+	id, ok := g.groups[handle]
+	if !ok {
+		return nil, approvals.ErrGroupNotFound
+	}
+
+	if members, ok := g.members[id]; ok {
+		return members, nil
+	}
+
+	members, err := g.client.GetUserGroupMembers(id)
+	if err != nil {
+		return nil, err
+	}
+	g.members[id] = members
+	return members, nil
+}
@@ -0,0 +1,63 @@
+package slack
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/nlopes/slack"
+
+	"github.com/keel-hq/keel/approvals"
+)
+
+type fakeUserGroupsClient struct {
+	groups       []slack.UserGroup
+	members      map[string][]string
+	groupsCalls  int
+	membersCalls int
+}
+
+func (c *fakeUserGroupsClient) GetUserGroups(options ...slack.GetUserGroupsOption) ([]slack.UserGroup, error) {
+	c.groupsCalls++
+	return c.groups, nil
+}
+
+func (c *fakeUserGroupsClient) GetUserGroupMembers(userGroup string) ([]string, error) {
+	c.membersCalls++
+	return c.members[userGroup], nil
+}
+
+func TestUserGroupsMembers(t *testing.T) {
+	client := &fakeUserGroupsClient{
+		groups: []slack.UserGroup{
+			{ID: "S01", Handle: "team-payments", Name: "Payments"},
+			{ID: "S02", Handle: "sre", Name: "SRE"},
+		},
+		members: map[string][]string{
+			"S01": {"U01", "U02"},
+			"S02": {"U03"},
+		},
+	}
+	groups := NewUserGroups(client)
+
+	members, err := groups.Members("@team-payments")
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if !reflect.DeepEqual(members, []string{"U01", "U02"}) {
+		t.Errorf("unexpected members: %v", members)
+	}
+
+	// cached
+	_, err = groups.Members("team-payments")
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if client.groupsCalls != 1 || client.membersCalls != 1 {
+		t.Errorf("expected cached lookups, got %d group and %d member calls", client.groupsCalls, client.membersCalls)
+	}
+
+	_, err = groups.Members("missing")
+	if err != approvals.ErrGroupNotFound {
+		t.Errorf("expected ErrGroupNotFound, got: %v", err)
+	}
+}
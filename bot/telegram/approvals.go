@@ -0,0 +1,89 @@
+package telegram
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/keel-hq/keel/bot"
+	"github.com/keel-hq/keel/pkg/telegram"
+	"github.com/keel-hq/keel/types"
+)
+
+// getApprovalChat - returns chat override for specific approval or default
+// approvals chat, overrides that aren't Telegram chats are ignored
+func (b *Bot) getApprovalChat(approval *types.Approval) string {
+	chat := strings.TrimSpace(approval.Channel)
+	if !telegram.IsChatID(chat) {
+		return b.approvalsChat
+	}
+
+	b.approvalChatsM.Lock()
+	if b.approvalChats == nil {
+		b.approvalChats = make(map[string]bool)
+	}
+	b.approvalChats[chat] = true
+	b.approvalChatsM.Unlock()
+
+	return chat
+}
+
+// approvalKeyboard - approve/reject buttons, nil if identifier doesn't fit
+// into callback data
+func approvalKeyboard(identifier string) *telegram.InlineKeyboardMarkup {
+	approve := bot.ApprovalResponseKeyword + " " + identifier
+	reject := bot.RejectResponseKeyword + " " + identifier
+	if len(approve) > telegram.MaxCallbackDataLength || len(reject) > telegram.MaxCallbackDataLength {
+		return nil
+	}
+	return &telegram.InlineKeyboardMarkup{
+		InlineKeyboard: [][]telegram.InlineKeyboardButton{
+			{
+				{Text: "Approve", CallbackData: approve},
+				{Text: "Reject", CallbackData: reject},
+			},
+		},
+	}
+}
+
+// RequestApproval - request approval
+func (b *Bot) RequestApproval(req *types.Approval) error {
+	lines := []string{
+		"Approval required!",
+		req.Message,
+		"",
+		formatVotes(req.VotesReceived, req.VotesRequired),
+		"Delta: " + req.Delta(),
+		"Identifier: " + req.Identifier,
+		"Provider: " + req.Provider.String(),
+		"",
+		fmt.Sprintf("To vote for change type '/approve %s', to reject it: '/reject %s'.", req.Identifier, req.Identifier),
+	}
+	return b.send(b.getApprovalChat(req), strings.Join(lines, "\n"), approvalKeyboard(req.Identifier))
+}
+
+// ReplyToApproval - reports vote results
+func (b *Bot) ReplyToApproval(approval *types.Approval) error {
+	var title string
+	switch approval.Status() {
+	case types.ApprovalStatusPending:
+		title = "Vote received, waiting for remaining votes."
+	case types.ApprovalStatusRejected:
+		title = "Change was rejected."
+	case types.ApprovalStatusApproved:
+		title = "Update approved, all approvals received, thanks for voting!"
+	default:
+		return nil
+	}
+
+	lines := []string{
+		title,
+		formatVotes(approval.VotesReceived, approval.VotesRequired),
+		"Delta: " + approval.Delta(),
+		"Identifier: " + approval.Identifier,
+	}
+	return b.send(b.getApprovalChat(approval), strings.Join(lines, "\n"), nil)
+}
+
+func formatVotes(received, required int) string {
+	return fmt.Sprintf("Votes: %d/%d", received, required)
+}
@@ -0,0 +1,230 @@
+package telegram
+
+import (
+	"context"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/keel-hq/keel/bot"
+	"github.com/keel-hq/keel/constants"
+	"github.com/keel-hq/keel/pkg/telegram"
+
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	pollTimeout   = 30 * time.Second
+	retryInterval = 5 * time.Second
+	sendTimeout   = 10 * time.Second
+)
+
+// TelegramImplementer - Telegram Bot API functionality used by the bot
+type TelegramImplementer interface {
+	GetMe(ctx context.Context) (*telegram.User, error)
+	SendMessage(ctx context.Context, chatID, text string, keyboard *telegram.InlineKeyboardMarkup) error
+	GetUpdates(ctx context.Context, offset int64, timeout time.Duration) ([]telegram.Update, error)
+	AnswerCallbackQuery(ctx context.Context, id, text string) error
+}
+
+// Bot - Telegram bot, approvals are requested with inline approve/reject
+// buttons, commands are accepted in private chats or when addressed to the bot
+type Bot struct {
+	name string // bot username
+
+	approvalsChat string
+
+	// per deployment approval chats, votes are accepted from these chats
+	// as well as from the default approvals chat
+	approvalChatsM sync.RWMutex
+	approvalChats  map[string]bool
+
+	client TelegramImplementer
+
+	ctx                context.Context
+	botMessagesChannel chan *bot.BotMessage
+	approvalsRespCh    chan *bot.ApprovalResponse
+}
+
+func init() {
+	bot.RegisterBot("telegram", &Bot{})
+}
+
+// Configure - bot is enabled when both token and approvals chat are set
+func (b *Bot) Configure(approvalsRespCh chan *bot.ApprovalResponse, botMessagesChannel chan *bot.BotMessage) bool {
+	token := os.Getenv(constants.EnvTelegramToken)
+	chat := os.Getenv(constants.EnvTelegramApprovalsChat)
+	if token == "" || chat == "" {
+		log.Info("bot.telegram.Configure(): Telegram approval bot is not configured")
+		return false
+	}
+
+	b.approvalsChat = strings.TrimSpace(chat)
+	b.client = telegram.New(token)
+	b.approvalsRespCh = approvalsRespCh
+	b.botMessagesChannel = botMessagesChannel
+
+	return true
+}
+
+// Start - start bot
+func (b *Bot) Start(ctx context.Context) error {
+	b.ctx = ctx
+
+	me, err := b.client.GetMe(ctx)
+	if err != nil {
+		return err
+	}
+	b.name = strings.ToLower(me.Username)
+
+	log.WithFields(log.Fields{
+		"name":           b.name,
+		"approvals_chat": b.approvalsChat,
+	}).Info("bot.telegram: bot started")
+
+	go b.poll()
+
+	return nil
+}
+
+func (b *Bot) poll() {
+	var offset int64
+	for {
+		updates, err := b.client.GetUpdates(b.ctx, offset, pollTimeout)
+		if err != nil {
+			select {
+			case <-b.ctx.Done():
+				return
+			default:
+			}
+			log.WithError(err).Error("bot.telegram: failed to get updates")
+			select {
+			case <-b.ctx.Done():
+				return
+			case <-time.After(retryInterval):
+			}
+			continue
+		}
+
+		for _, update := range updates {
+			offset = update.UpdateID + 1
+			b.handleUpdate(update)
+		}
+	}
+}
+
+func (b *Bot) handleUpdate(update telegram.Update) {
+	switch {
+	case update.CallbackQuery != nil:
+		b.handleCallbackQuery(update.CallbackQuery)
+	case update.Message != nil:
+		b.handleMessage(update.Message)
+	}
+}
+
+// handleCallbackQuery - approve/reject button pressed, callback data is the
+// same as the typed command (ie: "approve default/wd:1.2.0")
+func (b *Bot) handleCallbackQuery(query *telegram.CallbackQuery) {
+	var chat string
+	if query.Message != nil {
+		chat = strconv.FormatInt(query.Message.Chat.ID, 10)
+	}
+
+	approval, ok := bot.IsApproval(query.From.Name(), query.Data)
+	if !ok || !b.isApprovalsChat(chat) {
+		b.answer(query.ID, "votes are only accepted in the approvals chat")
+		return
+	}
+
+	b.answer(query.ID, "vote received")
+	b.approvalsRespCh <- approval
+}
+
+func (b *Bot) handleMessage(message *telegram.Message) {
+	if message.From == nil || message.From.IsBot || message.Text == "" {
+		return
+	}
+
+	chat := strconv.FormatInt(message.Chat.ID, 10)
+	text := strings.Trim(strings.ToLower(message.Text), " \n\r")
+
+	if !b.isBotMessage(message, text) {
+		return
+	}
+	text = b.trimBot(text)
+
+	approval, ok := bot.IsApproval(message.From.Name(), text)
+	// only accepting approvals from approvals chat
+	if ok && b.isApprovalsChat(chat) {
+		b.approvalsRespCh <- approval
+		return
+	} else if ok {
+		log.WithFields(log.Fields{
+			"received_on":    chat,
+			"approvals_chat": b.approvalsChat,
+		}).Warn("bot.telegram: approval received outside of approvals chat")
+		b.Respond("please use the approvals chat", chat)
+		return
+	}
+
+	b.botMessagesChannel <- &bot.BotMessage{
+		Message: text,
+		User:    message.From.Name(),
+		Channel: chat,
+		Name:    "telegram",
+	}
+}
+
+// isBotMessage - commands (/approve ...), mentions and private chat messages
+func (b *Bot) isBotMessage(message *telegram.Message, text string) bool {
+	if message.Chat.Type == "private" {
+		return true
+	}
+	return strings.HasPrefix(text, "/") || (b.name != "" && strings.HasPrefix(text, "@"+b.name))
+}
+
+// trimBot - "/approve@keel_bot x" and "@keel_bot approve x" become "approve x"
+func (b *Bot) trimBot(text string) string {
+	text = strings.TrimPrefix(text, "/")
+	if b.name != "" {
+		text = strings.Replace(text, "@"+b.name, "", 1)
+	}
+	return strings.Trim(text, " :\n")
+}
+
+func (b *Bot) isApprovalsChat(chat string) bool {
+	if chat == b.approvalsChat {
+		return true
+	}
+	b.approvalChatsM.RLock()
+	defer b.approvalChatsM.RUnlock()
+	return b.approvalChats[chat]
+}
+
+func (b *Bot) answer(id, text string) {
+	ctx, cancel := context.WithTimeout(context.Background(), sendTimeout)
+	defer cancel()
+	if err := b.client.AnswerCallbackQuery(ctx, id, text); err != nil {
+		log.WithError(err).Error("bot.telegram: failed to answer callback query")
+	}
+}
+
+func (b *Bot) send(chat, text string, keyboard *telegram.InlineKeyboardMarkup) error {
+	ctx, cancel := context.WithTimeout(context.Background(), sendTimeout)
+	defer cancel()
+	err := b.client.SendMessage(ctx, chat, text, keyboard)
+	if err != nil {
+		log.WithFields(log.Fields{
+			"error": err,
+			"chat":  chat,
+		}).Error("bot.telegram: failed to send message")
+	}
+	return err
+}
+
+// Respond - replies to bot command
+func (b *Bot) Respond(text string, channel string) {
+	b.send(channel, text, nil)
+}
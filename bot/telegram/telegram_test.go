@@ -0,0 +1,186 @@
+package telegram
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/keel-hq/keel/bot"
+	"github.com/keel-hq/keel/pkg/telegram"
+	"github.com/keel-hq/keel/types"
+)
+
+type sentMessage struct {
+	chat     string
+	text     string
+	keyboard *telegram.InlineKeyboardMarkup
+}
+
+type fakeTelegram struct {
+	sent     []sentMessage
+	answered []string
+}
+
+func (f *fakeTelegram) GetMe(ctx context.Context) (*telegram.User, error) {
+	return &telegram.User{Username: "keel_bot", IsBot: true}, nil
+}
+
+func (f *fakeTelegram) SendMessage(ctx context.Context, chatID, text string, keyboard *telegram.InlineKeyboardMarkup) error {
+	f.sent = append(f.sent, sentMessage{chat: chatID, text: text, keyboard: keyboard})
+	return nil
+}
+
+func (f *fakeTelegram) GetUpdates(ctx context.Context, offset int64, timeout time.Duration) ([]telegram.Update, error) {
+	<-ctx.Done()
+	return nil, ctx.Err()
+}
+
+func (f *fakeTelegram) AnswerCallbackQuery(ctx context.Context, id, text string) error {
+	f.answered = append(f.answered, text)
+	return nil
+}
+
+func newTestBot() (*Bot, *fakeTelegram) {
+	ft := &fakeTelegram{}
+	return &Bot{
+		name:               "keel_bot",
+		approvalsChat:      "-100",
+		client:             ft,
+		approvalsRespCh:    make(chan *bot.ApprovalResponse, 1),
+		botMessagesChannel: make(chan *bot.BotMessage, 1),
+	}, ft
+}
+
+func TestCallbackQueryApproval(t *testing.T) {
+	b, ft := newTestBot()
+
+	b.handleUpdate(telegram.Update{
+		CallbackQuery: &telegram.CallbackQuery{
+			ID:      "cb",
+			From:    telegram.User{Username: "alice"},
+			Message: &telegram.Message{Chat: telegram.Chat{ID: -100}},
+			Data:    "approve default/wd:1.2.0",
+		},
+	})
+
+	select {
+	case resp := <-b.approvalsRespCh:
+		if resp.Status != types.ApprovalStatusApproved || resp.User != "alice" || resp.Text != "approve default/wd:1.2.0" {
+			t.Errorf("unexpected approval response: %+v", resp)
+		}
+	default:
+		t.Fatalf("expected approval response")
+	}
+	if len(ft.answered) != 1 || ft.answered[0] != "vote received" {
+		t.Errorf("unexpected callback answers: %v", ft.answered)
+	}
+}
+
+func TestCallbackQueryOtherChat(t *testing.T) {
+	b, ft := newTestBot()
+
+	b.handleUpdate(telegram.Update{
+		CallbackQuery: &telegram.CallbackQuery{
+			ID:      "cb",
+			From:    telegram.User{Username: "alice"},
+			Message: &telegram.Message{Chat: telegram.Chat{ID: -200}},
+			Data:    "approve default/wd:1.2.0",
+		},
+	})
+
+	select {
+	case resp := <-b.approvalsRespCh:
+		t.Fatalf("unexpected approval response: %+v", resp)
+	default:
+	}
+	if len(ft.answered) != 1 || !strings.Contains(ft.answered[0], "approvals chat") {
+		t.Errorf("unexpected callback answers: %v", ft.answered)
+	}
+}
+
+func TestMessageCommands(t *testing.T) {
+	b, _ := newTestBot()
+
+	// group messages not addressed to the bot are ignored
+	b.handleUpdate(telegram.Update{
+		Message: &telegram.Message{
+			From: &telegram.User{Username: "alice"},
+			Chat: telegram.Chat{ID: -100, Type: "group"},
+			Text: "get approvals",
+		},
+	})
+	select {
+	case m := <-b.botMessagesChannel:
+		t.Fatalf("unexpected bot message: %+v", m)
+	default:
+	}
+
+	b.handleUpdate(telegram.Update{
+		Message: &telegram.Message{
+			From: &telegram.User{Username: "alice"},
+			Chat: telegram.Chat{ID: -100, Type: "group"},
+			Text: "/get@keel_bot approvals",
+		},
+	})
+	select {
+	case m := <-b.botMessagesChannel:
+		if m.Message != "get approvals" || m.Channel != "-100" || m.Name != "telegram" {
+			t.Errorf("unexpected bot message: %+v", m)
+		}
+	default:
+		t.Fatalf("expected bot message")
+	}
+
+	b.handleUpdate(telegram.Update{
+		Message: &telegram.Message{
+			From: &telegram.User{Username: "alice"},
+			Chat: telegram.Chat{ID: -100, Type: "group"},
+			Text: "/reject default/wd:1.2.0",
+		},
+	})
+	select {
+	case resp := <-b.approvalsRespCh:
+		if resp.Status != types.ApprovalStatusRejected || resp.Text != "reject default/wd:1.2.0" {
+			t.Errorf("unexpected approval response: %+v", resp)
+		}
+	default:
+		t.Fatalf("expected approval response")
+	}
+}
+
+func TestRequestApproval(t *testing.T) {
+	b, ft := newTestBot()
+
+	err := b.RequestApproval(&types.Approval{
+		Identifier:     "default/wd:1.2.0",
+		Message:        "New image is available for deployment default/wd",
+		CurrentVersion: "1.1.0",
+		NewVersion:     "1.2.0",
+		VotesRequired:  2,
+		Channel:        "#devops",
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if len(ft.sent) != 1 {
+		t.Fatalf("expected 1 message, got: %d", len(ft.sent))
+	}
+	msg := ft.sent[0]
+	if msg.chat != "-100" {
+		t.Errorf("expected default approvals chat, got: %s", msg.chat)
+	}
+	if !strings.Contains(msg.text, "Votes: 0/2") {
+		t.Errorf("unexpected text: %s", msg.text)
+	}
+	if msg.keyboard == nil || msg.keyboard.InlineKeyboard[0][1].CallbackData != "reject default/wd:1.2.0" {
+		t.Errorf("unexpected keyboard: %+v", msg.keyboard)
+	}
+}
+
+func TestApprovalKeyboardTooLong(t *testing.T) {
+	if k := approvalKeyboard(strings.Repeat("x", telegram.MaxCallbackDataLength)); k != nil {
+		t.Errorf("expected no keyboard for long identifiers")
+	}
+}
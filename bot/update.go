@@ -0,0 +1,125 @@
+package bot
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/keel-hq/keel/provider"
+	"github.com/keel-hq/keel/provider/kubernetes"
+	"github.com/keel-hq/keel/types"
+	"github.com/keel-hq/keel/util/image"
+
+	v1 "k8s.io/api/core/v1"
+)
+
+// CheckHandler - checks watched repository of the image right away
+func CheckHandler(imageName string, checker RepositoryChecker) string {
+	imageName = strings.TrimSpace(imageName)
+	if imageName == "" {
+		return "image is required, ie: 'check karolisr/webhook-demo'"
+	}
+	if checker == nil {
+		return "repository checks are not available, poll trigger is disabled"
+	}
+
+	checked, err := checker.Check(imageName)
+	if err != nil {
+		return fmt.Sprintf("failed to check %s: %s", imageName, err)
+	}
+	if checked == 0 {
+		return fmt.Sprintf("image %s is not watched.", imageName)
+	}
+
+	return fmt.Sprintf("checked %d watch(es) of %s.", checked, imageName)
+}
+
+// UpdateHandler - submits a forced update of a deployment to the providers,
+// args are "<namespace>/<name> <tag>" where the tag can be a full image
+// reference (ie: karolisr/webhook-demo:0.0.10) for multi image deployments.
+// Update policy is ignored, approvals still apply
+func UpdateHandler(args string, k8sImplementer kubernetes.Implementer, providers provider.Providers) string {
+	fields := strings.Fields(args)
+	if len(fields) != 2 {
+		return "deployment and tag are required, ie: 'update default/my-app 1.2.3'"
+	}
+	if providers == nil {
+		return "updates are not available"
+	}
+
+	namespace, name := "default", fields[0]
+	if parts := strings.SplitN(name, "/", 2); len(parts) == 2 {
+		namespace, name = parts[0], parts[1]
+	}
+
+	deps, err := k8sImplementer.Deployments(namespace)
+	if err != nil {
+		return fmt.Sprintf("got error while fetching deployments: %s", err)
+	}
+
+	for _, deployment := range deps.Items {
+		if deployment.Name != name {
+			continue
+		}
+
+		repo, err := updateRepository(deployment.Spec.Template.Spec.Containers, fields[1])
+		if err != nil {
+			return fmt.Sprintf("failed to update %s/%s: %s", namespace, name, err)
+		}
+
+		err = providers.Submit(types.Event{
+			Repository:  *repo,
+			Target:      "deployment/" + namespace + "/" + name,
+			CreatedAt:   time.Now(),
+			TriggerName: "bot",
+		})
+		if err != nil {
+			return fmt.Sprintf("failed to update %s/%s: %s", namespace, name, err)
+		}
+
+		return fmt.Sprintf("update of %s/%s to %s:%s submitted, approvals still apply.", namespace, name, repo.Name, repo.Tag)
+	}
+
+	return fmt.Sprintf("deployment '%s/%s' was not found", namespace, name)
+}
+
+// updateRepository - repository to update, either set explicitly or the only
+// one used by the deployment containers
+func updateRepository(containers []v1.Container, tag string) (*types.Repository, error) {
+	if strings.Contains(tag, ":") {
+		ref, err := image.Parse(tag)
+		if err != nil {
+			return nil, err
+		}
+		return &types.Repository{Name: ref.Repository(), Tag: ref.Tag()}, nil
+	}
+
+	var repositories []string
+	for _, c := range containers {
+		ref, err := image.Parse(c.Image)
+		if err != nil {
+			return nil, err
+		}
+		if !contains(repositories, ref.Repository()) {
+			repositories = append(repositories, ref.Repository())
+		}
+	}
+
+	switch len(repositories) {
+	case 0:
+		return nil, fmt.Errorf("deployment has no containers")
+	case 1:
+		return &types.Repository{Name: repositories[0], Tag: tag}, nil
+	}
+
+	return nil, fmt.Errorf("deployment uses multiple images, specify one of them, ie: %s:%s", repositories[0], tag)
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,116 @@
+package bot
+
+import (
+	"testing"
+
+	"github.com/keel-hq/keel/provider"
+	"github.com/keel-hq/keel/types"
+
+	apps_v1 "k8s.io/api/apps/v1"
+	v1 "k8s.io/api/core/v1"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+type fakeProviders struct {
+	provider.Providers
+
+	submitted []types.Event
+}
+
+func (p *fakeProviders) Submit(event types.Event) error {
+	p.submitted = append(p.submitted, event)
+	return nil
+}
+
+type fakeChecker struct {
+	checked []string
+}
+
+func (c *fakeChecker) Check(image string) (int, error) {
+	c.checked = append(c.checked, image)
+	if image == "karolisr/webhook-demo" {
+		return 1, nil
+	}
+	return 0, nil
+}
+
+func TestCheckHandler(t *testing.T) {
+	checker := &fakeChecker{}
+
+	if resp := CheckHandler(" karolisr/webhook-demo", checker); resp != "checked 1 watch(es) of karolisr/webhook-demo." {
+		t.Errorf("unexpected response: %s", resp)
+	}
+	if resp := CheckHandler("karolisr/other", checker); resp != "image karolisr/other is not watched." {
+		t.Errorf("unexpected response: %s", resp)
+	}
+	if resp := CheckHandler("karolisr/other", nil); resp != "repository checks are not available, poll trigger is disabled" {
+		t.Errorf("unexpected response: %s", resp)
+	}
+}
+
+func TestUpdateHandler(t *testing.T) {
+	fi := &fakeImplementer{
+		deployments: &apps_v1.DeploymentList{
+			Items: []apps_v1.Deployment{
+				{
+					ObjectMeta: meta_v1.ObjectMeta{Name: "wd", Namespace: "default"},
+					Spec: apps_v1.DeploymentSpec{
+						Template: v1.PodTemplateSpec{
+							Spec: v1.PodSpec{
+								Containers: []v1.Container{
+									{Image: "gcr.io/v2-namespace/hello-world:1.1.1"},
+								},
+							},
+						},
+					},
+				},
+				{
+					ObjectMeta: meta_v1.ObjectMeta{Name: "multi", Namespace: "default"},
+					Spec: apps_v1.DeploymentSpec{
+						Template: v1.PodTemplateSpec{
+							Spec: v1.PodSpec{
+								Containers: []v1.Container{
+									{Image: "gcr.io/v2-namespace/hello-world:1.1.1"},
+									{Image: "gcr.io/v2-namespace/bye-world:1.1.1"},
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+	providers := &fakeProviders{}
+
+	resp := UpdateHandler(" default/wd 1.0.0", fi, providers)
+	if resp != "update of default/wd to gcr.io/v2-namespace/hello-world:1.0.0 submitted, approvals still apply." {
+		t.Errorf("unexpected response: %s", resp)
+	}
+	if len(providers.submitted) != 1 {
+		t.Fatalf("expected 1 submitted event, got: %d", len(providers.submitted))
+	}
+	event := providers.submitted[0]
+	if event.Target != "deployment/default/wd" {
+		t.Errorf("unexpected target: %s", event.Target)
+	}
+	if event.Repository.Name != "gcr.io/v2-namespace/hello-world" || event.Repository.Tag != "1.0.0" {
+		t.Errorf("unexpected repository: %s", event.Repository.String())
+	}
+
+	resp = UpdateHandler("default/multi 1.0.0", fi, providers)
+	if resp != "failed to update default/multi: deployment uses multiple images, specify one of them, ie: gcr.io/v2-namespace/hello-world:1.0.0" {
+		t.Errorf("unexpected response: %s", resp)
+	}
+
+	resp = UpdateHandler("default/multi gcr.io/v2-namespace/bye-world:1.0.0", fi, providers)
+	if resp != "update of default/multi to gcr.io/v2-namespace/bye-world:1.0.0 submitted, approvals still apply." {
+		t.Errorf("unexpected response: %s", resp)
+	}
+
+	if resp := UpdateHandler("default/missing 1.0.0", fi, providers); resp != "deployment 'default/missing' was not found" {
+		t.Errorf("unexpected response: %s", resp)
+	}
+	if len(providers.submitted) != 2 {
+		t.Errorf("expected 2 submitted events, got: %d", len(providers.submitted))
+	}
+}
@@ -0,0 +1,85 @@
+package bolt
+
+import (
+	"bytes"
+	"fmt"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+
+	"github.com/keel-hq/keel/cache"
+)
+
+var bucket = []byte("keel")
+
+// Cache - BoltDB backed cache, persists state in a single file
+type Cache struct {
+	db *bolt.DB
+}
+
+// New - open (or create) cache file
+func New(path string) (*Cache, error) {
+	db, err := bolt.Open(path, 0600, &bolt.Options{Timeout: 5 * time.Second})
+	if err != nil {
+		return nil, fmt.Errorf("failed to open cache file %s, error: %s", path, err)
+	}
+
+	err = db.Update(func(tx *bolt.Tx) error {
+		_, err := tx.CreateBucketIfNotExists(bucket)
+		return err
+	})
+	if err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to create cache bucket, error: %s", err)
+	}
+
+	return &Cache{db: db}, nil
+}
+
+// Put - store value
+func (c *Cache) Put(key string, value []byte) error {
+	return c.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(bucket).Put([]byte(key), value)
+	})
+}
+
+// Get - get value, returns cache.ErrNotFound if key doesn't exist
+func (c *Cache) Get(key string) ([]byte, error) {
+	var value []byte
+	err := c.db.View(func(tx *bolt.Tx) error {
+		v := tx.Bucket(bucket).Get([]byte(key))
+		if v == nil {
+			return cache.ErrNotFound
+		}
+		// values are only valid during transaction
+		value = append([]byte(nil), v...)
+		return nil
+	})
+	return value, err
+}
+
+// Delete - delete value, deleting non existing key is not an error
+func (c *Cache) Delete(key string) error {
+	return c.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(bucket).Delete([]byte(key))
+	})
+}
+
+// List - get all values with keys that start with prefix
+func (c *Cache) List(prefix string) (map[string][]byte, error) {
+	values := make(map[string][]byte)
+	err := c.db.View(func(tx *bolt.Tx) error {
+		cursor := tx.Bucket(bucket).Cursor()
+		p := []byte(prefix)
+		for k, v := cursor.Seek(p); k != nil && bytes.HasPrefix(k, p); k, v = cursor.Next() {
+			values[string(k)] = append([]byte(nil), v...)
+		}
+		return nil
+	})
+	return values, err
+}
+
+// Close - close cache file
+func (c *Cache) Close() error {
+	return c.db.Close()
+}
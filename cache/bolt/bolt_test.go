@@ -0,0 +1,60 @@
+package bolt
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/keel-hq/keel/cache"
+)
+
+func TestCache(t *testing.T) {
+	dir, err := ioutil.TempDir("", "keelcachetest")
+	if err != nil {
+		t.Fatalf("failed to create temp dir: %s", err)
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "cache.db")
+	c, err := New(path)
+	if err != nil {
+		t.Fatalf("failed to open cache: %s", err)
+	}
+
+	if _, err := c.Get("poll/missing"); err != cache.ErrNotFound {
+		t.Errorf("expected not found error, got: %v", err)
+	}
+
+	c.Put("poll/a", []byte("1"))
+	c.Put("poll/b", []byte("2"))
+	c.Put("approvals/c", []byte("3"))
+
+	values, err := c.List("poll/")
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if len(values) != 2 || string(values["poll/a"]) != "1" || string(values["poll/b"]) != "2" {
+		t.Errorf("unexpected values: %v", values)
+	}
+
+	if err := c.Delete("poll/a"); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	c.Close()
+
+	// values should survive reopening
+	c, err = New(path)
+	if err != nil {
+		t.Fatalf("failed to reopen cache: %s", err)
+	}
+	defer c.Close()
+
+	if _, err := c.Get("poll/a"); err != cache.ErrNotFound {
+		t.Errorf("expected deleted key to be gone, got: %v", err)
+	}
+	val, err := c.Get("poll/b")
+	if err != nil || string(val) != "2" {
+		t.Errorf("unexpected value: %s, error: %v", val, err)
+	}
+}
@@ -0,0 +1,19 @@
+package cache
+
+import (
+	"errors"
+)
+
+// ErrNotFound - returned when key doesn't exist in the cache
+var ErrNotFound = errors.New("not found")
+
+// Cache - key/value storage for state that should survive keel restarts
+// (and be shared between instances when backed by a remote store)
+type Cache interface {
+	Put(key string, value []byte) error
+	Get(key string) ([]byte, error)
+	Delete(key string) error
+	// List - returns all values with keys that start with prefix
+	List(prefix string) (map[string][]byte, error)
+	Close() error
+}
@@ -0,0 +1,66 @@
+package memory
+
+import (
+	"strings"
+	"sync"
+
+	"github.com/keel-hq/keel/cache"
+)
+
+// Cache - in memory cache, state is lost on restart
+type Cache struct {
+	mu     sync.RWMutex
+	values map[string][]byte
+}
+
+// New - create new in memory cache
+func New() *Cache {
+	return &Cache{
+		values: make(map[string][]byte),
+	}
+}
+
+// Put - store value
+func (c *Cache) Put(key string, value []byte) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.values[key] = append([]byte(nil), value...)
+	return nil
+}
+
+// Get - get value, returns cache.ErrNotFound if key doesn't exist
+func (c *Cache) Get(key string) ([]byte, error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	value, ok := c.values[key]
+	if !ok {
+		return nil, cache.ErrNotFound
+	}
+	return append([]byte(nil), value...), nil
+}
+
+// Delete - delete value, deleting non existing key is not an error
+func (c *Cache) Delete(key string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.values, key)
+	return nil
+}
+
+// List - get all values with keys that start with prefix
+func (c *Cache) List(prefix string) (map[string][]byte, error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	values := make(map[string][]byte)
+	for k, v := range c.values {
+		if strings.HasPrefix(k, prefix) {
+			values[k] = append([]byte(nil), v...)
+		}
+	}
+	return values, nil
+}
+
+// Close - noop
+func (c *Cache) Close() error {
+	return nil
+}
@@ -0,0 +1,29 @@
+package memory
+
+import (
+	"testing"
+
+	"github.com/keel-hq/keel/cache"
+)
+
+func TestCache(t *testing.T) {
+	c := New()
+
+	if _, err := c.Get("poll/missing"); err != cache.ErrNotFound {
+		t.Errorf("expected not found error, got: %v", err)
+	}
+
+	c.Put("poll/a", []byte("1"))
+	c.Put("poll/b", []byte("2"))
+	c.Put("approvals/c", []byte("3"))
+
+	values, _ := c.List("poll/")
+	if len(values) != 2 || string(values["poll/a"]) != "1" || string(values["poll/b"]) != "2" {
+		t.Errorf("unexpected values: %v", values)
+	}
+
+	c.Delete("poll/a")
+	if _, err := c.Get("poll/a"); err != cache.ErrNotFound {
+		t.Errorf("expected deleted key to be gone, got: %v", err)
+	}
+}
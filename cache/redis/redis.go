@@ -0,0 +1,128 @@
+package redis
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+
+	"github.com/keel-hq/keel/cache"
+)
+
+const timeout = 5 * time.Second
+
+// DefaultPrefix - prefix for all keys keel stores in redis
+const DefaultPrefix = "keel:"
+
+// Opts - redis connection options
+type Opts struct {
+	Address  string
+	Password string
+	DB       int
+	// Prefix - namespaces keel keys, defaults to DefaultPrefix
+	Prefix string
+}
+
+// Cache - redis backed cache, state is shared between keel instances
+// pointed at the same redis
+type Cache struct {
+	client *redis.Client
+	prefix string
+}
+
+// New - connect to redis
+func New(opts Opts) (*Cache, error) {
+	if opts.Address == "" {
+		return nil, fmt.Errorf("redis address cannot be empty")
+	}
+
+	prefix := opts.Prefix
+	if prefix == "" {
+		prefix = DefaultPrefix
+	}
+
+	client := redis.NewClient(&redis.Options{
+		Addr:     opts.Address,
+		Password: opts.Password,
+		DB:       opts.DB,
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	err := client.Ping(ctx).Err()
+	if err != nil {
+		client.Close()
+		return nil, fmt.Errorf("failed to connect to redis %s, error: %s", opts.Address, err)
+	}
+
+	return &Cache{client: client, prefix: prefix}, nil
+}
+
+// Put - store value
+func (c *Cache) Put(key string, value []byte) error {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	return c.client.Set(ctx, c.prefix+key, value, 0).Err()
+}
+
+// Get - get value, returns cache.ErrNotFound if key doesn't exist
+func (c *Cache) Get(key string) ([]byte, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	value, err := c.client.Get(ctx, c.prefix+key).Bytes()
+	if err == redis.Nil {
+		return nil, cache.ErrNotFound
+	}
+	return value, err
+}
+
+// Delete - delete value, deleting non existing key is not an error
+func (c *Cache) Delete(key string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	return c.client.Del(ctx, c.prefix+key).Err()
+}
+
+// List - get all values with keys that start with prefix
+func (c *Cache) List(prefix string) (map[string][]byte, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	values := make(map[string][]byte)
+	iter := c.client.Scan(ctx, 0, escapePattern(c.prefix+prefix)+"*", 100).Iterator()
+	for iter.Next(ctx) {
+		key := iter.Val()
+		value, err := c.client.Get(ctx, key).Bytes()
+		if err == redis.Nil {
+			// deleted in the meantime
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		values[strings.TrimPrefix(key, c.prefix)] = value
+	}
+
+	return values, iter.Err()
+}
+
+// Close - close redis connection
+func (c *Cache) Close() error {
+	return c.client.Close()
+}
+
+// escapePattern - escapes glob characters so keys are matched literally
+func escapePattern(s string) string {
+	var b strings.Builder
+	for _, r := range s {
+		switch r {
+		case '*', '?', '[', ']', '\\':
+			b.WriteRune('\\')
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
@@ -0,0 +1,56 @@
+package redis
+
+import (
+	"testing"
+
+	"github.com/alicebob/miniredis/v2"
+
+	"github.com/keel-hq/keel/cache"
+)
+
+func TestCache(t *testing.T) {
+	srv, err := miniredis.Run()
+	if err != nil {
+		t.Fatalf("failed to start redis: %s", err)
+	}
+	defer srv.Close()
+
+	c, err := New(Opts{Address: srv.Addr()})
+	if err != nil {
+		t.Fatalf("failed to connect: %s", err)
+	}
+	defer c.Close()
+
+	if _, err := c.Get("poll/missing"); err != cache.ErrNotFound {
+		t.Errorf("expected not found error, got: %v", err)
+	}
+
+	c.Put("poll/a", []byte("1"))
+	c.Put("poll/b", []byte("2"))
+	c.Put("poll*/c", []byte("3"))
+	c.Put("approvals/d", []byte("4"))
+
+	if !srv.Exists("keel:poll/a") {
+		t.Errorf("expected key to be stored with prefix")
+	}
+
+	values, err := c.List("poll/")
+	if err != nil {
+		t.Fatalf("failed to list: %s", err)
+	}
+	if len(values) != 2 || string(values["poll/a"]) != "1" || string(values["poll/b"]) != "2" {
+		t.Errorf("unexpected values: %v", values)
+	}
+
+	c.Delete("poll/a")
+	if _, err := c.Get("poll/a"); err != cache.ErrNotFound {
+		t.Errorf("expected deleted key to be gone, got: %v", err)
+	}
+}
+
+func TestNewUnreachable(t *testing.T) {
+	_, err := New(Opts{Address: "127.0.0.1:1"})
+	if err == nil {
+		t.Errorf("expected error when redis is unreachable")
+	}
+}
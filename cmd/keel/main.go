@@ -2,61 +2,98 @@ package main
 
 import (
 	"fmt"
+	"math/rand"
 	"os"
 	"os/signal"
 	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
 	"time"
 
 	"context"
 
 	"github.com/prometheus/client_golang/prometheus"
+	"google.golang.org/grpc"
 	kingpin "gopkg.in/alecthomas/kingpin.v2"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
 	kube "k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/rest"
 	"k8s.io/helm/pkg/helm/portforwarder"
 
 	"github.com/keel-hq/keel/approvals"
 	"github.com/keel-hq/keel/bot"
+	"github.com/keel-hq/keel/cache"
+	"github.com/keel-hq/keel/cache/bolt"
+	"github.com/keel-hq/keel/cache/memory"
+	"github.com/keel-hq/keel/cache/redis"
 
-	// "github.com/keel-hq/keel/cache/memory"
 	"github.com/keel-hq/keel/pkg/auth"
+	"github.com/keel-hq/keel/pkg/commits"
+	"github.com/keel-hq/keel/pkg/cosign"
+	"github.com/keel-hq/keel/pkg/gitops"
 	"github.com/keel-hq/keel/pkg/http"
+	"github.com/keel-hq/keel/pkg/notary"
+	"github.com/keel-hq/keel/pkg/promquery"
+	"github.com/keel-hq/keel/pkg/rpc"
 	"github.com/keel-hq/keel/pkg/store"
 	"github.com/keel-hq/keel/pkg/store/sql"
+	"github.com/keel-hq/keel/pkg/tracing"
+	"github.com/keel-hq/keel/pkg/trivy"
 
 	"github.com/keel-hq/keel/constants"
 	"github.com/keel-hq/keel/extension/credentialshelper"
 	"github.com/keel-hq/keel/extension/notification"
+	"github.com/keel-hq/keel/extension/trigger"
+	"github.com/keel-hq/keel/internal/history"
 	"github.com/keel-hq/keel/internal/k8s"
+	"github.com/keel-hq/keel/internal/leader"
+	"github.com/keel-hq/keel/internal/shard"
 	"github.com/keel-hq/keel/internal/workgroup"
 	"github.com/keel-hq/keel/provider"
+	"github.com/keel-hq/keel/provider/executor"
 	"github.com/keel-hq/keel/provider/helm"
 	"github.com/keel-hq/keel/provider/helm3"
 	"github.com/keel-hq/keel/provider/kubernetes"
 	"github.com/keel-hq/keel/registry"
 	"github.com/keel-hq/keel/secrets"
+	"github.com/keel-hq/keel/trigger/kafka"
+	"github.com/keel-hq/keel/trigger/nats"
 	"github.com/keel-hq/keel/trigger/poll"
 	"github.com/keel-hq/keel/trigger/pubsub"
+	"github.com/keel-hq/keel/trigger/sqs"
 	"github.com/keel-hq/keel/types"
 	"github.com/keel-hq/keel/version"
 
 	// notification extensions
 	"github.com/keel-hq/keel/extension/notification/auditor"
+	_ "github.com/keel-hq/keel/extension/notification/discord"
 	_ "github.com/keel-hq/keel/extension/notification/hipchat"
 	_ "github.com/keel-hq/keel/extension/notification/mail"
 	_ "github.com/keel-hq/keel/extension/notification/mattermost"
+	_ "github.com/keel-hq/keel/extension/notification/pagerduty"
 	_ "github.com/keel-hq/keel/extension/notification/slack"
+	_ "github.com/keel-hq/keel/extension/notification/slackwebhook"
+	"github.com/keel-hq/keel/extension/notification/stream"
 	_ "github.com/keel-hq/keel/extension/notification/teams"
+	_ "github.com/keel-hq/keel/extension/notification/telegram"
 	_ "github.com/keel-hq/keel/extension/notification/webhook"
 
 	// credentials helpers
 	_ "github.com/keel-hq/keel/extension/credentialshelper/aws"
+	_ "github.com/keel-hq/keel/extension/credentialshelper/azure"
+	_ "github.com/keel-hq/keel/extension/credentialshelper/docker"
 	_ "github.com/keel-hq/keel/extension/credentialshelper/gcr"
 	secretsCredentialsHelper "github.com/keel-hq/keel/extension/credentialshelper/secrets"
 
 	// bots
 	_ "github.com/keel-hq/keel/bot/hipchat"
-	_ "github.com/keel-hq/keel/bot/slack"
+	_ "github.com/keel-hq/keel/bot/issues"
+	_ "github.com/keel-hq/keel/bot/mattermost"
+	slackbot "github.com/keel-hq/keel/bot/slack"
+	_ "github.com/keel-hq/keel/bot/telegram"
 
 	log "github.com/sirupsen/logrus"
 
@@ -66,8 +103,10 @@ import (
 
 // gcloud pubsub related config
 const (
-	EnvTriggerPubSub       = "PUBSUB" // set to 1 or something to enable pub/sub trigger
-	EnvTriggerPoll         = "POLL"   // set to 0 to disable poll trigger
+	EnvTriggerPubSub       = "PUBSUB" // set to 1 or true to enable pub/sub trigger
+	EnvTriggerPoll         = "POLL"   // set to 0 or false to disable poll trigger
+	EnvPollInterval        = "POLL_INTERVAL"
+	EnvPollJitter          = "POLL_JITTER"
 	EnvProjectID           = "PROJECT_ID"
 	EnvClusterName         = "CLUSTER_NAME"
 	EnvDataDir             = "XDG_DATA_HOME"
@@ -75,7 +114,45 @@ const (
 	EnvHelmTillerAddress   = "TILLER_ADDRESS"   // helm provider
 	EnvHelmTillerNamespace = "TILLER_NAMESPACE" // helm provider
 	EnvHelm3Provider       = "HELM3_PROVIDER"   // helm3 provider
+	EnvHelm3ChartInterval  = "HELM3_CHART_POLL_INTERVAL"
 	EnvUIDir               = "UI_DIR"
+	EnvCacheType           = "CACHE_TYPE" // bolt (default), memory or redis
+	EnvRedisAddress        = "REDIS_ADDRESS"
+	EnvRedisPassword       = "REDIS_PASSWORD"
+	EnvRedisDB             = "REDIS_DB"
+	EnvRedisPrefix         = "REDIS_PREFIX"
+
+	// only the leader replica polls and applies updates
+	EnvLeaderElection          = "LEADER_ELECTION"
+	EnvLeaderElectionNamespace = "LEADER_ELECTION_NAMESPACE" // defaults to keel's namespace
+	EnvLeaderElectionLeaseName = "LEADER_ELECTION_LEASE_NAME"
+
+	// poll trigger work is split between all replicas sharing a group
+	EnvSharding          = "SHARDING"
+	EnvShardingNamespace = "SHARDING_NAMESPACE" // defaults to keel's namespace
+	EnvShardingGroup     = "SHARDING_GROUP"
+
+	// AWS ECR push events delivered through EventBridge -> SQS
+	EnvTriggerSQSQueueURL = "SQS_QUEUE_URL"
+	EnvTriggerSQSRegion   = "SQS_REGION"
+
+	// NATS image events, see trigger/nats for payload format
+	EnvTriggerNATSURL     = "NATS_URL"
+	EnvTriggerNATSSubject = "NATS_SUBJECT"
+	EnvTriggerNATSQueue   = "NATS_QUEUE"
+
+	// Kafka image events, see trigger/kafka for payload format
+	EnvTriggerKafkaBrokers       = "KAFKA_BROKERS" // comma separated list of brokers
+	EnvTriggerKafkaTopic         = "KAFKA_TOPIC"
+	EnvTriggerKafkaGroupID       = "KAFKA_GROUP_ID"
+	EnvTriggerKafkaTLS           = "KAFKA_TLS"
+	EnvTriggerKafkaSASLMechanism = "KAFKA_SASL_MECHANISM" // plain, scram-sha-256 or scram-sha-512
+	EnvTriggerKafkaUsername      = "KAFKA_USERNAME"
+	EnvTriggerKafkaPassword      = "KAFKA_PASSWORD"
+
+	// poll trigger registry check limits
+	EnvPollConcurrency         = "POLL_CONCURRENCY"
+	EnvPollRegistryConcurrency = "POLL_REGISTRY_CONCURRENCY"
 
 	// EnvDefaultDockerRegistryCfg - default registry configuration that can be passed into
 	// keel for polling trigger
@@ -85,22 +162,164 @@ const (
 // kubernetes config, if empty - will default to InCluster
 const (
 	EnvKubernetesConfig = "KUBERNETES_CONFIG"
+	// kubeconfig context, keel then runs outside of the cluster
+	EnvKubernetesContext = "KUBERNETES_CONTEXT"
+
+	// other clusters managed by this keel instance, comma separated kubeconfig
+	// contexts, optionally named: 'prod=prod-context'
+	EnvRemoteClusters   = "REMOTE_CLUSTERS"
+	EnvRemoteKubeconfig = "REMOTE_KUBECONFIG" // defaults to kubeconfig
+	EnvLocalClusterName = "LOCAL_CLUSTER_NAME"
+
+	// restrict watched resources, namespace lists are comma separated
+	EnvNamespaces        = "NAMESPACES"
+	EnvExcludeNamespaces = "EXCLUDE_NAMESPACES"
+	EnvLabelSelector     = "LABEL_SELECTOR"
 )
 
+// git write-back, updated images are committed to manifests in the repository
+const (
+	EnvGitOpsRepo         = "GITOPS_REPO"
+	EnvGitOpsBranch       = "GITOPS_BRANCH"
+	EnvGitOpsPath         = "GITOPS_PATH"
+	EnvGitOpsUsername     = "GITOPS_USERNAME"
+	EnvGitOpsToken        = "GITOPS_TOKEN"
+	EnvGitOpsPullRequest  = "GITOPS_PULL_REQUEST"  // push to a new branch and open a pull request (GitHub only)
+	EnvGitOpsPatchCluster = "GITOPS_PATCH_CLUSTER" // also update resources in the cluster
+)
+
+// image signature verification, updates to images that aren't signed by one of
+// the keys or the keyless identity are rejected
+const (
+	EnvCosignKeys       = "COSIGN_KEYS" // comma separated cosign key references
+	EnvCosignIdentity   = "COSIGN_IDENTITY"
+	EnvCosignOIDCIssuer = "COSIGN_OIDC_ISSUER"
+
+	// Docker Content Trust, see pkg/notary for the configuration file format
+	EnvNotaryConfig = "NOTARY_CONFIG"
+)
+
+// vulnerability scanning, updates to images with vulnerabilities are parked
+const (
+	EnvTrivyScan          = "TRIVY_SCAN"
+	EnvTrivyServer        = "TRIVY_SERVER" // scans run locally if not set
+	EnvTrivySeverity      = "TRIVY_SEVERITY"
+	EnvTrivyIgnoreUnfixed = "TRIVY_IGNORE_UNFIXED"
+	EnvPrometheusURL      = "PROMETHEUS_URL" // enables keel.sh/healthQuery
+)
+
+// HTTPS for the webhook and admin API server, certificate is reloaded when files change
+const (
+	EnvTLSCert = "TLS_CERT"
+	EnvTLSKey  = "TLS_KEY"
+)
+
+// EnvGRPCPort - port of the gRPC admin API, disabled when not set
+const EnvGRPCPort = "GRPC_PORT"
+
+// EnvDryRun - set to true to only report updates, resources and releases are not changed
+const EnvDryRun = "DRY_RUN"
+
 // EnvDebug - set to 1 or anything else to enable debug logging
 const EnvDebug = "DEBUG"
 
+// EnvShutdownTimeout - how long to wait for updates in progress on shutdown
+const EnvShutdownTimeout = "SHUTDOWN_TIMEOUT"
+
+// EnvHistoryLimit - number of actions kept per resource, 0 disables history
+const EnvHistoryLimit = "HISTORY_LIMIT"
+
+// EnvWebhooks - comma separated webhook endpoints to serve (ie: native,dockerhub),
+// none disables webhook triggers, all endpoints are served when not set
+const EnvWebhooks = "WEBHOOKS"
+
+// EnvExecutors - external update executors, comma separated name=address
+// pairs of Executor gRPC services (see provider/executor/executor.proto)
+const EnvExecutors = "EXECUTORS"
+
+// commit metadata, tags referencing commits of keel.sh/sourceRepo are resolved
+// to commit title, author and URL
+const (
+	EnvCommitTagPattern   = "COMMIT_TAG_PATTERN" // regexp, first capture group is the commit SHA
+	EnvCommitsGithubToken = "COMMITS_GITHUB_TOKEN"
+	EnvCommitsGitlabToken = "COMMITS_GITLAB_TOKEN"
+)
+
+// logging
+const (
+	EnvLogLevel  = "LOG_LEVEL"
+	EnvLogFormat = "LOG_FORMAT"
+)
+
 func main() {
 	ver := version.GetKeelVersion()
 
 	inCluster := kingpin.Flag("incluster", "use in cluster configuration (defaults to 'true'), use '--no-incluster' if running outside of the cluster").Default("true").Bool()
 	kubeconfig := kingpin.Flag("kubeconfig", "path to kubeconfig (if not in running inside a cluster)").Default(filepath.Join(os.Getenv("HOME"), ".kube", "config")).String()
+	kubeContext := kingpin.Flag("context", "kubeconfig context to use, implies --no-incluster").Envar(EnvKubernetesContext).String()
 	uiDir := kingpin.Flag("ui-dir", "path to web UI static files").Default("www").Envar(EnvUIDir).String()
+	namespaces := kingpin.Flag("namespaces", "comma separated list of namespaces to watch, defaults to all namespaces").Envar(EnvNamespaces).String()
+	excludeNamespaces := kingpin.Flag("exclude-namespaces", "comma separated list of namespaces to ignore").Envar(EnvExcludeNamespaces).String()
+	pollInterval := kingpin.Flag("poll-interval", "how often tracked images are rescanned, defaults to 1m resync as changes are picked up from watched resources").Envar(EnvPollInterval).Duration()
+	pollJitter := kingpin.Flag("poll-jitter", "max random delay added to scheduled registry checks (ie: 30s)").Envar(EnvPollJitter).Duration()
+	pollConcurrency := kingpin.Flag("poll-concurrency", "max concurrent registry checks, 0 for unlimited").Default("20").Envar(EnvPollConcurrency).Int()
+	pollRegistryConcurrency := kingpin.Flag("poll-registry-concurrency", "max concurrent checks against a single registry, 0 for unlimited").Default("5").Envar(EnvPollRegistryConcurrency).Int()
+	labelSelector := kingpin.Flag("selector", "label selector to filter watched resources (ie: 'team=backend')").Envar(EnvLabelSelector).String()
+	helm3ChartInterval := kingpin.Flag("helm3-chart-poll-interval", "how often chart repositories of helm3 releases with keel.chart.repository are checked for new chart versions, 0 disables chart updates").Default("5m").Envar(EnvHelm3ChartInterval).Duration()
+	dryRun := kingpin.Flag("dry-run", "detect, evaluate and report updates without applying them").Envar(EnvDryRun).Bool()
+	gitopsRepo := kingpin.Flag("gitops-repo", "git repository updated image tags are committed to, enables git write-back").Envar(EnvGitOpsRepo).String()
+	gitopsBranch := kingpin.Flag("gitops-branch", "git write-back branch").Default("master").Envar(EnvGitOpsBranch).String()
+	gitopsPath := kingpin.Flag("gitops-path", "manifests directory in the git write-back repository, defaults to the whole repository").Envar(EnvGitOpsPath).String()
+	gitopsUsername := kingpin.Flag("gitops-username", "git write-back HTTPS username").Envar(EnvGitOpsUsername).String()
+	gitopsToken := kingpin.Flag("gitops-token", "git write-back HTTPS token, also used to open pull requests").Envar(EnvGitOpsToken).String()
+	gitopsPullRequest := kingpin.Flag("gitops-pull-request", "push updates to a new branch and open a pull request instead of committing to the branch (GitHub only)").Envar(EnvGitOpsPullRequest).Bool()
+	cosignKeys := kingpin.Flag("cosign-keys", "comma separated cosign public keys (paths, env://VAR, k8s://namespace/secret), enables signature verification of updated images").Envar(EnvCosignKeys).String()
+	cosignIdentity := kingpin.Flag("cosign-identity", "keyless signing certificate identity, enables signature verification of updated images").Envar(EnvCosignIdentity).String()
+	cosignOIDCIssuer := kingpin.Flag("cosign-oidc-issuer", "keyless signing certificate OIDC issuer").Envar(EnvCosignOIDCIssuer).String()
+	notaryConfig := kingpin.Flag("notary-config", "notary trust configuration file, updates to unsigned tags of configured repositories are rejected").Envar(EnvNotaryConfig).String()
+	trivyScan := kingpin.Flag("trivy-scan", "scan updated images with trivy, updates to images with vulnerabilities are parked").Envar(EnvTrivyScan).Bool()
+	trivyServer := kingpin.Flag("trivy-server", "trivy server URL, scans run locally if not set").Envar(EnvTrivyServer).String()
+	trivySeverity := kingpin.Flag("trivy-severity", "min severity of vulnerabilities blocking updates (LOW, MEDIUM, HIGH, CRITICAL)").Default("HIGH").Envar(EnvTrivySeverity).String()
+	trivyIgnoreUnfixed := kingpin.Flag("trivy-ignore-unfixed", "ignore vulnerabilities without a fix").Envar(EnvTrivyIgnoreUnfixed).Bool()
+	prometheusURL := kingpin.Flag("prometheus-url", "Prometheus URL health queries (keel.sh/healthQuery) are evaluated against after updates").Envar(EnvPrometheusURL).String()
+	gitopsPatchCluster := kingpin.Flag("gitops-patch-cluster", "update resources in the cluster in addition to committing to git").Envar(EnvGitOpsPatchCluster).Bool()
+	remoteClusters := kingpin.Flag("remote-clusters", "comma separated kubeconfig contexts of other clusters to update, optionally named (ie: 'prod=gke_prod,staging')").Envar(EnvRemoteClusters).String()
+	remoteKubeconfig := kingpin.Flag("remote-kubeconfig", "kubeconfig with remote cluster contexts, defaults to --kubeconfig").Envar(EnvRemoteKubeconfig).String()
+	localClusterName := kingpin.Flag("local-cluster-name", "name of the cluster keel runs in when remote clusters are configured").Default("local").Envar(EnvLocalClusterName).String()
+	tlsCert := kingpin.Flag("tls-cert", "TLS certificate file, enables HTTPS for the webhook and admin API server").Envar(EnvTLSCert).String()
+	tlsKey := kingpin.Flag("tls-key", "TLS private key file").Envar(EnvTLSKey).String()
+	webhookRateLimit := kingpin.Flag("webhook-rate-limit", "webhook requests per second allowed from a single token or IP, 0 disables rate limiting").Default("10").Envar(constants.EnvWebhookRateLimit).Float64()
+	webhookRateBurst := kingpin.Flag("webhook-rate-burst", "webhook requests allowed in a burst from a single token or IP").Default("50").Envar(constants.EnvWebhookRateBurst).Int()
+	notificationDedupWindow := kingpin.Flag("notification-dedup-window", "identical notifications, or ones about the same image version, within the window are only sent once (ie: 10m)").Envar(constants.EnvNotificationDedupWindow).Duration()
+	notificationDigestInterval := kingpin.Flag("notification-digest-interval", "aggregate notifications below warning level into a summary sent every interval (ie: 1h)").Envar(constants.EnvNotificationDigestInterval).Duration()
+	grpcPort := kingpin.Flag("grpc-port", "port of the gRPC admin API (see pkg/rpc/keel.proto), requires authentication to be configured").Envar(EnvGRPCPort).Int()
+	webhookMaxBodySize := kingpin.Flag("webhook-max-body-size", "max webhook request body size in bytes").Default("1048576").Envar(constants.EnvWebhookMaxBodySize).Int64()
+	executors := kingpin.Flag("executors", "external update executors implementing provider/executor/executor.proto, comma separated name=address pairs (ie: nomad=nomad-executor:9400)").Envar(EnvExecutors).String()
+	commitTagPattern := kingpin.Flag("commit-tag-pattern", "regexp matching commit SHA in image tags, first capture group is the SHA").Default(commits.DefaultTagPattern).Envar(EnvCommitTagPattern).String()
+	pollTrigger := kingpin.Flag("poll", "poll registries for new tags, use '--no-poll' to disable the poll trigger").Default("true").Envar(EnvTriggerPoll).Bool()
+	pubsubTrigger := kingpin.Flag("pubsub", "subscribe to Google Container Registry pub/sub notifications, requires PROJECT_ID").Envar(EnvTriggerPubSub).Bool()
+	webhooks := kingpin.Flag("webhooks", "comma separated webhook endpoints to serve (native, dockerhub, quay, azure, github, harbor, artifactory, eventgrid, gitea, nexus, registry), 'none' disables webhook triggers, all are served when not set").Envar(EnvWebhooks).String()
+	historyLimit := kingpin.Flag("history-limit", "number of actions (detected, approved, applied, failed, rolled back) kept per resource, 0 disables history").Default("20").Envar(EnvHistoryLimit).Int()
+	shutdownTimeout := kingpin.Flag("shutdown-timeout", "how long to wait for updates in progress on shutdown, keep it below the pod termination grace period").Default("25s").Envar(EnvShutdownTimeout).Duration()
+	logLevel := kingpin.Flag("log-level", "log level (trace, debug, info, warn, error), can be changed at runtime with PUT /v1/config/loglevel").Default("info").Envar(EnvLogLevel).String()
+	logFormat := kingpin.Flag("log-format", "log format").Default("text").Envar(EnvLogFormat).Enum("text", "json")
 
 	kingpin.UsageTemplate(kingpin.CompactUsageTemplate).Version(ver.Version)
 	kingpin.CommandLine.Help = "Automated Kubernetes deployment updates. Learn more on https://keel.sh."
 	kingpin.Parse()
 
+	if *logFormat == "json" {
+		log.SetFormatter(&log.JSONFormatter{})
+	}
+
+	level, err := log.ParseLevel(*logLevel)
+	if err != nil {
+		log.WithFields(log.Fields{
+			"error": err,
+		}).Fatal("invalid log level")
+	}
+	log.SetLevel(level)
+
 	log.WithFields(log.Fields{
 		"os":         ver.OS,
 		"build_date": ver.BuildDate,
@@ -114,6 +333,121 @@ func main() {
 		log.SetLevel(log.DebugLevel)
 	}
 
+	if *dryRun {
+		log.Warn("dry run mode enabled, updates will be reported but not applied")
+	}
+
+	var gitWriter *gitops.Writer
+	if *gitopsRepo != "" {
+		writer, err := gitops.New(gitops.Opts{
+			URL:         *gitopsRepo,
+			Branch:      *gitopsBranch,
+			Path:        *gitopsPath,
+			Username:    *gitopsUsername,
+			Token:       *gitopsToken,
+			PullRequest: *gitopsPullRequest,
+		})
+		if err != nil {
+			log.WithFields(log.Fields{
+				"error": err,
+			}).Fatal("failed to setup git write-back")
+		}
+		gitWriter = writer
+		log.WithFields(log.Fields{
+			"repo":          *gitopsRepo,
+			"branch":        *gitopsBranch,
+			"pull_request":  *gitopsPullRequest,
+			"patch_cluster": *gitopsPatchCluster,
+		}).Info("git write-back enabled, updates are committed to the repository")
+	}
+
+	var verifiers []kubernetes.SignatureVerifier
+	if *cosignKeys != "" || *cosignIdentity != "" {
+		var keys []string
+		for _, key := range strings.Split(*cosignKeys, ",") {
+			if key = strings.TrimSpace(key); key != "" {
+				keys = append(keys, key)
+			}
+		}
+		v, err := cosign.New(cosign.Opts{
+			PublicKeys: keys,
+			Identity:   *cosignIdentity,
+			OIDCIssuer: *cosignOIDCIssuer,
+		})
+		if err != nil {
+			log.WithFields(log.Fields{
+				"error": err,
+			}).Fatal("failed to setup image signature verification")
+		}
+		verifiers = append(verifiers, v)
+		log.Info("image signature verification enabled, updates to unsigned images are rejected")
+	}
+	if *notaryConfig != "" {
+		cfg, err := notary.LoadConfig(*notaryConfig)
+		if err != nil {
+			log.WithFields(log.Fields{
+				"error": err,
+			}).Fatal("failed to read notary configuration")
+		}
+		v, err := notary.New(cfg)
+		if err != nil {
+			log.WithFields(log.Fields{
+				"error": err,
+			}).Fatal("failed to setup content trust verification")
+		}
+		verifiers = append(verifiers, v)
+		log.WithFields(log.Fields{
+			"repositories": len(cfg.Repositories),
+		}).Info("content trust verification enabled, updates to unsigned tags are rejected")
+	}
+
+	var scanner *trivy.Scanner
+	if *trivyScan {
+		s, err := trivy.New(trivy.Opts{
+			Server:        *trivyServer,
+			Severity:      *trivySeverity,
+			IgnoreUnfixed: *trivyIgnoreUnfixed,
+		})
+		if err != nil {
+			log.WithFields(log.Fields{
+				"error": err,
+			}).Fatal("failed to setup vulnerability scanning")
+		}
+		scanner = s
+		log.WithFields(log.Fields{
+			"server":   *trivyServer,
+			"severity": *trivySeverity,
+		}).Info("vulnerability scanning enabled, updates to vulnerable images are parked")
+	}
+
+	var metrics *promquery.Client
+	if *prometheusURL != "" {
+		c, err := promquery.New(promquery.Opts{URL: *prometheusURL})
+		if err != nil {
+			log.WithFields(log.Fields{
+				"error": err,
+			}).Fatal("failed to setup Prometheus health queries")
+		}
+		metrics = c
+		log.WithFields(log.Fields{
+			"url": *prometheusURL,
+		}).Info("Prometheus health queries enabled, updates breaching keel.sh/healthQuery are rolled back")
+	}
+
+	commitResolver, err := commits.New(&commits.Opts{
+		TagPattern:  *commitTagPattern,
+		GithubToken: os.Getenv(EnvCommitsGithubToken),
+		GitlabToken: os.Getenv(EnvCommitsGitlabToken),
+	})
+	if err != nil {
+		log.WithFields(log.Fields{
+			"error": err,
+		}).Fatal("failed to setup commit metadata")
+	}
+
+	// poll jitter has to differ between keel instances
+	rand.Seed(time.Now().UnixNano())
+
 	dataDir := "/data"
 	if os.Getenv(EnvDataDir) != "" {
 		dataDir = os.Getenv(EnvDataDir)
@@ -134,10 +468,33 @@ func main() {
 		"type":          "sqlite3",
 	}).Info("initializing database")
 
+	stateCache := setupCache(dataDir)
+	defer stateCache.Close()
+
+	var resourceHistory *history.History
+	if *historyLimit > 0 {
+		resourceHistory = history.New(stateCache, *historyLimit)
+	}
+
+	if tracing.Enabled() {
+		shutdownTracing, err := tracing.Setup(context.Background())
+		if err != nil {
+			log.WithFields(log.Fields{
+				"error": err,
+			}).Fatal("main: failed to configure tracing")
+		}
+		defer shutdownTracing(context.Background())
+		log.Info("main: OpenTelemetry tracing enabled")
+	}
+
 	// registering auditor to log events
 	auditLogger := auditor.New(sqlStore)
 	notification.RegisterSender("auditor", auditLogger)
 
+	// registering event stream for /v1/stream subscribers
+	eventStream := stream.New()
+	notification.RegisterSender("stream", eventStream)
+
 	// setting up triggers
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
@@ -155,8 +512,10 @@ func main() {
 	}
 
 	notifCfg := &notification.Config{
-		Attempts: 10,
-		Level:    notificationLevel,
+		Attempts:       10,
+		Level:          notificationLevel,
+		DedupWindow:    *notificationDedupWindow,
+		DigestInterval: *notificationDigestInterval,
 	}
 	sender := notification.New(ctx)
 
@@ -176,7 +535,15 @@ func main() {
 		k8sCfg.ConfigPath = os.Getenv(EnvKubernetesConfig)
 	}
 
-	k8sCfg.InCluster = *inCluster
+	k8sCfg.InCluster = *inCluster && *kubeContext == ""
+	k8sCfg.Context = *kubeContext
+	if k8sCfg.InCluster && os.Getenv("KUBERNETES_SERVICE_HOST") == "" {
+		// running locally, ie: against a dev cluster or in CI with --dry-run
+		log.WithFields(log.Fields{
+			"kubeconfig": k8sCfg.ConfigPath,
+		}).Info("main: not running inside a cluster, using kubeconfig")
+		k8sCfg.InCluster = false
+	}
 
 	implementer, err := kubernetes.NewKubernetesImplementer(k8sCfg)
 	if err != nil {
@@ -186,34 +553,111 @@ func main() {
 		}).Fatal("main: failed to create kubernetes implementer")
 	}
 
+	var elector *leader.Elector
+	if os.Getenv(EnvLeaderElection) == "true" {
+		elector, err = leader.New(&leader.Opts{
+			Client:    implementer.Client(),
+			Namespace: os.Getenv(EnvLeaderElectionNamespace),
+			Name:      os.Getenv(EnvLeaderElectionLeaseName),
+		})
+		if err != nil {
+			log.WithFields(log.Fields{
+				"error": err,
+			}).Fatal("main: failed to setup leader election")
+		}
+	}
+
+	var membership *shard.Membership
+	if os.Getenv(EnvSharding) == "true" {
+		if elector != nil {
+			log.Fatal("main: sharding and leader election can't be enabled together, sharded replicas apply updates independently")
+		}
+		membership, err = shard.New(&shard.Opts{
+			Client:    implementer.Client(),
+			Namespace: os.Getenv(EnvShardingNamespace),
+			Group:     os.Getenv(EnvShardingGroup),
+		})
+		if err != nil {
+			log.WithFields(log.Fields{
+				"error": err,
+			}).Fatal("main: failed to setup sharding")
+		}
+		go membership.Run(ctx)
+	}
+
 	var g workgroup.Group
 
-	t := &k8s.Translator{
-		FieldLogger: log.WithField("context", "translator"),
+	filter := &k8s.Filter{
+		Namespaces:        splitList(*namespaces),
+		ExcludeNamespaces: splitList(*excludeNamespaces),
+		LabelSelector:     *labelSelector,
+	}
+	_, err = labels.Parse(filter.LabelSelector)
+	if err != nil {
+		log.WithFields(log.Fields{
+			"error":    err,
+			"selector": filter.LabelSelector,
+		}).Fatal("main: invalid label selector")
 	}
 
-	buf := k8s.NewBuffer(&g, t, log.StandardLogger(), 128)
-	wl := log.WithField("context", "watch")
-	k8s.WatchDeployments(&g, implementer.Client(), wl, buf)
-	k8s.WatchStatefulSets(&g, implementer.Client(), wl, buf)
-	k8s.WatchDaemonSets(&g, implementer.Client(), wl, buf)
-	k8s.WatchCronJobs(&g, implementer.Client(), wl, buf)
+	clusters := []*cluster{{
+		implementer: implementer,
+	}}
+	for _, spec := range splitList(*remoteClusters) {
+		if clusters[0].name == "" {
+			clusters[0].name = *localClusterName
+		}
+
+		name, kubeContext := spec, spec
+		if parts := strings.SplitN(spec, "=", 2); len(parts) == 2 {
+			name, kubeContext = parts[0], parts[1]
+		}
+		remoteCfg := &kubernetes.Opts{
+			ConfigPath: *remoteKubeconfig,
+			Context:    kubeContext,
+		}
+		if remoteCfg.ConfigPath == "" {
+			remoteCfg.ConfigPath = k8sCfg.ConfigPath
+		}
+		remote, err := kubernetes.NewKubernetesImplementer(remoteCfg)
+		if err != nil {
+			log.WithFields(log.Fields{
+				"error":   err,
+				"cluster": name,
+				"context": kubeContext,
+			}).Fatal("main: failed to create kubernetes implementer for remote cluster")
+		}
+		clusters = append(clusters, &cluster{name: name, implementer: remote})
+		log.WithFields(log.Fields{
+			"cluster": name,
+			"context": kubeContext,
+		}).Info("main: remote cluster configured")
+	}
+	for _, c := range clusters {
+		c.grc = watchCluster(&g, c, filter)
+	}
+	grc := clusters[0].grc
 
 	// approvalsCache := memory.NewMemoryCache()
 	approvalsManager := approvals.New(&approvals.Opts{
 		// Cache: approvalsCache,
-		Store: sqlStore,
+		Store:  sqlStore,
+		Sender: sender,
+		Groups: setupApproverGroups(),
 	})
 
+	approvalRequests, _ := approvalsManager.Subscribe(ctx)
+	go eventStream.PublishApprovals(ctx, approvalRequests)
+
 	pendindApprovalsCounter := prometheus.NewGaugeFunc(prometheus.GaugeOpts{
 		Name: "pending_approvals",
 		Help: "Number of the pending approvals",
 	}, func() float64 {
 		approvals, err := approvalsManager.List()
 		if err != nil {
-			return float64(len(approvals))
+			return 0
 		}
-		return 0
+		return float64(len(approvals))
 	})
 	prometheus.MustRegister(pendindApprovalsCounter)
 
@@ -221,13 +665,26 @@ func main() {
 
 	// setting up providers
 	providers := setupProviders(&ProviderOpts{
-		k8sImplementer:   implementer,
+		clusters:         clusters,
 		sender:           sender,
 		approvalsManager: approvalsManager,
-		grc:              &t.GenericResourceCache,
 		store:            sqlStore,
+		elector:          elector,
 		k8sClient:        implementer.Client(),
 		config:           implementer.Config(),
+		dryRun:           *dryRun,
+		chartInterval:    *helm3ChartInterval,
+		gitWriter:        gitWriter,
+		gitPatchCluster:  *gitopsPatchCluster,
+		verifiers:        verifiers,
+		scanner:          scanner,
+		digests:          poll.NewDigestResolver(registry.New()),
+		created:          poll.NewCreatedResolver(registry.New()),
+		metrics:          metrics,
+		history:          resourceHistory,
+		commits:          commitResolver,
+		executors:        *executors,
+		cache:            stateCache,
 	})
 
 	// registering secrets based credentials helper
@@ -242,84 +699,287 @@ func main() {
 		}
 	}
 	secretsGetter := secrets.NewGetter(implementer, dockerConfig)
+	for _, c := range clusters[1:] {
+		secretsGetter.WithCluster(c.name, c.implementer)
+	}
 
 	ch := secretsCredentialsHelper.New(secretsGetter)
 	credentialshelper.RegisterCredentialsHelper("secrets", ch)
 
 	// trigger setup
 	// teardownTriggers := setupTriggers(ctx, providers, approvalsManager, &t.GenericResourceCache, implementer)
-	teardownTriggers := setupTriggers(ctx, &TriggerOpts{
+	triggerOpts := &TriggerOpts{
 		providers:        providers,
 		approvalsManager: approvalsManager,
-		grc:              &t.GenericResourceCache,
+		grc:              grc,
+		clusters:         clusters,
 		k8sClient:        implementer,
 		store:            sqlStore,
 		uiDir:            *uiDir,
-	})
+		sender:           sender,
+		events:           eventStream,
+		cache:            stateCache,
+		history:          resourceHistory,
+		elector:          elector,
+		shard:            membership,
+		pollInterval:     *pollInterval,
+		pollJitter:       *pollJitter,
+
+		pollConcurrency:         *pollConcurrency,
+		pollRegistryConcurrency: *pollRegistryConcurrency,
 
-	bot.Run(implementer, approvalsManager)
+		tlsCert: *tlsCert,
+		tlsKey:  *tlsKey,
+
+		webhookRateLimit:   *webhookRateLimit,
+		webhookRateBurst:   *webhookRateBurst,
+		webhookMaxBodySize: *webhookMaxBodySize,
+
+		grpcPort: *grpcPort,
+
+		poll:     *pollTrigger,
+		pubsub:   *pubsubTrigger,
+		webhooks: parseWebhooks(*webhooks),
+	}
+	teardownTriggers := setupTriggers(ctx, triggerOpts)
+
+	if elector != nil {
+		elector.OnStartedLeading(func(ctx context.Context) {
+			bot.Run(implementer, approvalsManager, botOpts(triggerOpts))
+		})
+		go func() {
+			elector.Run(ctx)
+			if ctx.Err() == nil {
+				// restarting so this replica rejoins as a standby with clean state
+				log.Fatal("main: leadership lost, exiting")
+			}
+		}()
+	} else {
+		bot.Run(implementer, approvalsManager, botOpts(triggerOpts))
+	}
 
 	signalChan := make(chan os.Signal, 1)
-	cleanupDone := make(chan bool)
-	signal.Notify(signalChan, os.Interrupt)
+	signal.Notify(signalChan, os.Interrupt, syscall.SIGTERM)
 	g.Add(func(stop <-chan struct{}) {
+		select {
+		case <-signalChan:
+		case <-stop:
+			return
+		}
+		log.Info("received a shutdown signal, finishing updates in progress...")
+
+		cleanupDone := make(chan struct{})
 		go func() {
-			for range signalChan {
-				log.Info("received an interrupt, shutting down...")
-				go func() {
-					select {
-					case <-time.After(10 * time.Second):
-						log.Info("connection shutdown took too long, exiting... ")
-						close(cleanupDone)
-						return
-					case <-cleanupDone:
-						return
-					}
-				}()
-				providers.Stop()
-				teardownTriggers()
-				bot.Stop()
-
-				cleanupDone <- true
-			}
+			// triggers go first so no new events arrive while providers
+			// finish updates in progress
+			teardownTriggers()
+			providers.Stop()
+			bot.Stop()
+			sender.Flush()
+			close(cleanupDone)
 		}()
-		<-cleanupDone
+
+		select {
+		case <-cleanupDone:
+			log.Info("shutdown complete")
+		case <-time.After(*shutdownTimeout):
+			log.WithFields(log.Fields{
+				"timeout": *shutdownTimeout,
+			}).Warn("shutdown took too long, exiting with updates in progress")
+		}
 	})
 	g.Run()
 }
 
+// keelPolicyClient - client for KeelPolicy resources, nil if the CRD is not installed
+func keelPolicyClient(implementer *kubernetes.KubernetesImplementer) dynamic.Interface {
+	_, err := implementer.Client().Discovery().ServerResourcesForGroupVersion(k8s.KeelPolicyResource.GroupVersion().String())
+	if err != nil {
+		log.WithFields(log.Fields{
+			"error": err,
+		}).Info("main: KeelPolicy CRD is not installed, settings are only read from labels and annotations")
+		return nil
+	}
+
+	client, err := dynamic.NewForConfig(implementer.Config())
+	if err != nil {
+		log.WithFields(log.Fields{
+			"error": err,
+		}).Error("main: failed to create dynamic client, KeelPolicy resources are ignored")
+		return nil
+	}
+	return client
+}
+
+// resourceInstalled - whether API resource is available, ie: Argo Rollouts CRD
+// or OpenShift DeploymentConfigs. These are then updated like deployments
+func resourceInstalled(implementer *kubernetes.KubernetesImplementer, resource schema.GroupVersionResource) bool {
+	resources, err := implementer.Client().Discovery().ServerResourcesForGroupVersion(resource.GroupVersion().String())
+	if err != nil {
+		log.WithFields(log.Fields{
+			"error":    err,
+			"resource": resource.String(),
+		}).Debug("main: resource is not available")
+		return false
+	}
+	for _, r := range resources.APIResources {
+		if r.Name == resource.Resource {
+			log.WithFields(log.Fields{
+				"resource": resource.String(),
+			}).Info("main: resource available, watching it")
+			return true
+		}
+	}
+	return false
+}
+
+// cluster - kubernetes cluster managed by keel, name is only set when keel
+// manages remote clusters as well
+type cluster struct {
+	name        string
+	implementer *kubernetes.KubernetesImplementer
+	grc         *k8s.GenericResourceCache
+}
+
+// watchCluster - starts watching cluster resources, returned cache is kept up
+// to date with watched resources
+func watchCluster(g *workgroup.Group, c *cluster, filter *k8s.Filter) *k8s.GenericResourceCache {
+	fields := log.Fields{"context": "translator"}
+	if c.name != "" {
+		fields["cluster"] = c.name
+	}
+	t := &k8s.Translator{
+		FieldLogger: log.WithFields(fields),
+	}
+
+	implementer := c.implementer
+	buf := k8s.NewBuffer(g, t, log.StandardLogger(), 128)
+	wl := log.WithField("context", "watch")
+	if c.name != "" {
+		wl = wl.WithField("cluster", c.name)
+	}
+	k8s.WatchDeployments(g, implementer.Client(), wl, filter, buf)
+	k8s.WatchStatefulSets(g, implementer.Client(), wl, filter, buf)
+	k8s.WatchDaemonSets(g, implementer.Client(), wl, filter, buf)
+	k8s.WatchCronJobs(g, implementer.Client(), wl, filter, buf)
+	k8s.WatchNamespaces(g, implementer.Client(), wl, filter, buf)
+	if policyClient := keelPolicyClient(implementer); policyClient != nil {
+		k8s.WatchKeelPolicies(g, policyClient, wl, filter, buf)
+	}
+	if resourceInstalled(implementer, k8s.ArgoRolloutResource) {
+		k8s.WatchArgoRollouts(g, implementer.Dynamic(), wl, filter, buf)
+	}
+	if resourceInstalled(implementer, k8s.DeploymentConfigResource) {
+		k8s.WatchDeploymentConfigs(g, implementer.Dynamic(), wl, filter, buf)
+	}
+
+	return &t.GenericResourceCache
+}
+
 type ProviderOpts struct {
-	k8sImplementer   kubernetes.Implementer
+	// first cluster is the one keel runs in
+	clusters         []*cluster
 	sender           notification.Sender
 	approvalsManager approvals.Manager
-	grc              *k8s.GenericResourceCache
 	store            store.Store
+	elector          *leader.Elector
 
 	k8sClient kube.Interface
 	config    *rest.Config
+
+	// updates are only reported
+	dryRun bool
+
+	// how often helm3 chart repositories are checked, 0 disables chart updates
+	chartInterval time.Duration
+
+	// updates are committed to git, nil if disabled
+	gitWriter       *gitops.Writer
+	gitPatchCluster bool
+
+	// verify signatures of updated images
+	verifiers []kubernetes.SignatureVerifier
+
+	// scans updated images, nil if disabled
+	scanner *trivy.Scanner
+
+	// resolves tags to digests for images pinned by digest
+	digests kubernetes.DigestResolver
+
+	// resolves image creation time for keel.sh/minAge
+	created kubernetes.CreatedResolver
+
+	// evaluates health queries, nil if disabled
+	metrics *promquery.Client
+
+	// actions taken on resources, nil if disabled
+	history *history.History
+
+	// resolves tags to commits of keel.sh/sourceRepo
+	commits kubernetes.CommitResolver
+
+	// external update executors, name=address pairs
+	executors string
+
+	// persists pause state
+	cache cache.Cache
 }
 
 // setupProviders - setting up available providers. New providers should be initialised here and added to
 // provider map
 func setupProviders(opts *ProviderOpts) (providers provider.Providers) {
 	var enabledProviders []provider.Provider
+	var chartWatcher *helm3.Provider
 
-	k8sProvider, err := kubernetes.NewProvider(opts.k8sImplementer, opts.sender, opts.approvalsManager, opts.grc)
-	if err != nil {
-		log.WithFields(log.Fields{
-			"error": err,
-		}).Fatal("main.setupProviders: failed to create kubernetes provider")
-	}
-	go func() {
-		err := k8sProvider.Start()
+	for _, c := range opts.clusters {
+		k8sProvider, err := kubernetes.NewProvider(c.implementer, opts.sender, opts.approvalsManager, c.grc)
 		if err != nil {
 			log.WithFields(log.Fields{
-				"error": err,
-			}).Fatal("kubernetes provider stopped with an error")
+				"error":   err,
+				"cluster": c.name,
+			}).Fatal("main.setupProviders: failed to create kubernetes provider")
 		}
-	}()
+		k8sProvider.SetCluster(c.name)
+		k8sProvider.SetDryRun(opts.dryRun)
+		if opts.gitWriter != nil {
+			k8sProvider.SetGitWriter(opts.gitWriter, opts.gitPatchCluster)
+		}
+		for _, verifier := range opts.verifiers {
+			k8sProvider.AddSignatureVerifier(verifier)
+		}
+		if opts.scanner != nil {
+			k8sProvider.SetVulnerabilityScanner(opts.scanner)
+		}
+		if opts.store != nil {
+			k8sProvider.SetDeadLetterStore(opts.store)
+		}
+		if opts.digests != nil {
+			k8sProvider.SetDigestResolver(opts.digests)
+		}
+		if opts.created != nil {
+			k8sProvider.SetCreatedResolver(opts.created)
+		}
+		if opts.metrics != nil {
+			k8sProvider.SetMetricsQuerier(opts.metrics)
+		}
+		if opts.history != nil {
+			k8sProvider.SetHistory(opts.history)
+		}
+		if opts.commits != nil {
+			k8sProvider.SetCommitResolver(opts.commits)
+		}
+		go func() {
+			err := k8sProvider.Start()
+			if err != nil {
+				log.WithFields(log.Fields{
+					"error":    err,
+					"provider": k8sProvider.GetName(),
+				}).Fatal("kubernetes provider stopped with an error")
+			}
+		}()
 
-	enabledProviders = append(enabledProviders, k8sProvider)
+		enabledProviders = append(enabledProviders, k8sProvider)
+	}
 
 	if os.Getenv(EnvHelmProvider) == "1" || os.Getenv(EnvHelmProvider) == "true" {
 
@@ -347,6 +1007,7 @@ func setupProviders(opts *ProviderOpts) (providers provider.Providers) {
 
 		helmImplementer := helm.NewHelmImplementer(tillerAddr)
 		helmProvider := helm.NewProvider(helmImplementer, opts.sender, opts.approvalsManager)
+		helmProvider.SetDryRun(opts.dryRun)
 
 		go func() {
 			err := helmProvider.Start()
@@ -363,6 +1024,7 @@ func setupProviders(opts *ProviderOpts) (providers provider.Providers) {
 	if os.Getenv(EnvHelm3Provider) == "1" || os.Getenv(EnvHelm3Provider) == "true" {
 		helm3Implementer := helm3.NewHelm3Implementer()
 		helm3Provider := helm3.NewProvider(helm3Implementer, opts.sender, opts.approvalsManager)
+		helm3Provider.SetDryRun(opts.dryRun)
 
 		go func() {
 			err := helm3Provider.Start()
@@ -374,11 +1036,72 @@ func setupProviders(opts *ProviderOpts) (providers provider.Providers) {
 		}()
 
 		enabledProviders = append(enabledProviders, helm3Provider)
+		chartWatcher = helm3Provider
+	}
+
+	for _, executorProvider := range setupExecutors(opts) {
+		executorProvider := executorProvider
+		go func() {
+			err := executorProvider.Start()
+			if err != nil {
+				log.WithFields(log.Fields{
+					"error":    err,
+					"provider": executorProvider.GetName(),
+				}).Fatal("executor provider stopped with an error")
+			}
+		}()
+
+		enabledProviders = append(enabledProviders, executorProvider)
+	}
 
+	dp := provider.New(enabledProviders, opts.approvalsManager).WithCache(opts.cache)
+	if opts.elector != nil {
+		dp.WithLeader(opts.elector.IsLeader)
 	}
 
-	providers = provider.New(enabledProviders, opts.approvalsManager)
+	// chart events go through providers so they are paused and gated by
+	// leadership like image updates
+	if chartWatcher != nil && opts.chartInterval > 0 {
+		go chartWatcher.WatchCharts(dp, opts.chartInterval)
+	}
+
+	return dp
+}
+
+// setupExecutors - connects to external update executors, connections are
+// established lazily so executors can start after keel
+func setupExecutors(opts *ProviderOpts) []*executor.Provider {
+	var providers []*executor.Provider
+	for _, entry := range strings.Split(opts.executors, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		parts := strings.SplitN(entry, "=", 2)
+		if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+			log.WithFields(log.Fields{
+				"executor": entry,
+			}).Fatal("main: invalid executor, expected name=address")
+		}
+
+		conn, err := grpc.Dial(parts[1], grpc.WithInsecure())
+		if err != nil {
+			log.WithFields(log.Fields{
+				"error":    err,
+				"executor": parts[0],
+				"address":  parts[1],
+			}).Fatal("main: failed to dial executor")
+		}
+
+		executorProvider := executor.NewProvider(parts[0], executor.NewExecutorClient(conn), opts.sender, opts.approvalsManager)
+		executorProvider.SetDryRun(opts.dryRun)
+		providers = append(providers, executorProvider)
 
+		log.WithFields(log.Fields{
+			"executor": parts[0],
+			"address":  parts[1],
+		}).Info("main: executor configured")
+	}
 	return providers
 }
 
@@ -386,9 +1109,109 @@ type TriggerOpts struct {
 	providers        provider.Providers
 	approvalsManager approvals.Manager
 	grc              *k8s.GenericResourceCache
+	clusters         []*cluster
 	k8sClient        kubernetes.Implementer
 	store            store.Store
 	uiDir            string
+	sender           notification.Sender
+	events           http.EventStream
+	cache            cache.Cache
+	history          *history.History
+	elector          *leader.Elector
+	shard            *shard.Membership
+	pollInterval     time.Duration
+	pollJitter       time.Duration
+
+	pollConcurrency         int
+	pollRegistryConcurrency int
+
+	tlsCert string
+	tlsKey  string
+
+	webhookRateLimit   float64
+	webhookRateBurst   int
+	webhookMaxBodySize int64
+
+	grpcPort int
+
+	// enabled triggers, webhook endpoints are all served when nil
+	poll     bool
+	pubsub   bool
+	webhooks []string
+
+	// set once triggers are started, used by the bots for on demand checks
+	watcher *poll.RepositoryWatcher
+}
+
+// botOpts - bots can submit forced updates and check repositories when the
+// poll trigger is running
+func botOpts(opts *TriggerOpts) *bot.Opts {
+	botOpts := &bot.Opts{
+		Providers:   opts.providers,
+		DeadLetters: opts.store,
+	}
+	if opts.watcher != nil {
+		botOpts.Checker = opts.watcher
+	}
+	return botOpts
+}
+
+// setupCache - cache for state that should survive restarts (ie: last seen digests),
+// falls back to in memory cache if persistent one can't be opened
+func setupCache(dataDir string) cache.Cache {
+	switch os.Getenv(EnvCacheType) {
+	case "memory":
+		return memory.New()
+	case "", "bolt":
+		path := filepath.Join(dataDir, "cache.db")
+		c, err := bolt.New(path)
+		if err != nil {
+			log.WithFields(log.Fields{
+				"error": err,
+				"path":  path,
+			}).Error("main: failed to open cache, falling back to in memory cache")
+			return memory.New()
+		}
+		log.WithFields(log.Fields{
+			"path": path,
+			"type": "bolt",
+		}).Info("main: cache initialized")
+		return c
+	case "redis":
+		// shared between replicas, failing to connect is fatal as falling back to
+		// local state would make instances disagree
+		var db int
+		if os.Getenv(EnvRedisDB) != "" {
+			var err error
+			db, err = strconv.Atoi(os.Getenv(EnvRedisDB))
+			if err != nil {
+				log.WithFields(log.Fields{
+					"error": err,
+				}).Fatal("main: invalid redis database number")
+			}
+		}
+		c, err := redis.New(redis.Opts{
+			Address:  os.Getenv(EnvRedisAddress),
+			Password: os.Getenv(EnvRedisPassword),
+			DB:       db,
+			Prefix:   os.Getenv(EnvRedisPrefix),
+		})
+		if err != nil {
+			log.WithFields(log.Fields{
+				"error": err,
+			}).Fatal("main: failed to initialize redis cache")
+		}
+		log.WithFields(log.Fields{
+			"address": os.Getenv(EnvRedisAddress),
+			"type":    "redis",
+		}).Info("main: cache initialized")
+		return c
+	default:
+		log.WithFields(log.Fields{
+			"type": os.Getenv(EnvCacheType),
+		}).Fatal("main: unknown cache type")
+	}
+	return nil
 }
 
 // setupTriggers - setting up triggers. New triggers should be added to this function. Each trigger
@@ -397,24 +1220,52 @@ type TriggerOpts struct {
 func setupTriggers(ctx context.Context, opts *TriggerOpts) (teardown func()) {
 
 	authenticator := auth.New(&auth.Opts{
-		Username: os.Getenv(constants.EnvBasicAuthUser),
-		Password: os.Getenv(constants.EnvBasicAuthPassword),
-		Secret:   []byte(os.Getenv(constants.EnvTokenSecret)),
+		Username:         os.Getenv(constants.EnvBasicAuthUser),
+		Password:         os.Getenv(constants.EnvBasicAuthPassword),
+		ReadOnlyUsername: os.Getenv(constants.EnvBasicAuthReadOnlyUser),
+		ReadOnlyPassword: os.Getenv(constants.EnvBasicAuthReadOnlyPassword),
+		AdminTokens:      splitList(os.Getenv(constants.EnvAdminTokens)),
+		ReadOnlyTokens:   splitList(os.Getenv(constants.EnvReadOnlyTokens)),
+		Secret:           []byte(os.Getenv(constants.EnvTokenSecret)),
 	})
 
+	// avoiding typed nil, history endpoint reports disabled history
+	var resourceHistory http.ResourceHistory
+	if opts.history != nil {
+		resourceHistory = opts.history
+	}
+
 	// setting up generic http webhook server
 	whs := http.NewTriggerServer(&http.Opts{
 		Port:                  types.KeelDefaultPort,
 		GRC:                   opts.grc,
 		KubernetesClient:      opts.k8sClient,
+		Clusters:              httpClusters(opts.clusters),
 		Providers:             opts.providers,
 		ApprovalManager:       opts.approvalsManager,
 		Store:                 opts.store,
 		Authenticator:         authenticator,
 		UIDir:                 opts.uiDir,
 		AuthenticatedWebhooks: os.Getenv(constants.EnvAuthenticatedWebhooks) == "true",
+		Webhooks:              opts.webhooks,
+		Sender:                opts.sender,
+		Events:                opts.events,
+		History:               resourceHistory,
+
+		RegistryNotificationToken: os.Getenv(constants.EnvRegistryNotificationToken),
+		WebhookSecrets:            webhookSecrets(),
+		TLSCertFile:               opts.tlsCert,
+		TLSKeyFile:                opts.tlsKey,
+		WebhookRateLimit:          opts.webhookRateLimit,
+		WebhookRateBurst:          opts.webhookRateBurst,
+		MaxWebhookBodySize:        opts.webhookMaxBodySize,
 	})
 
+	// standbys drop events, keeping them out of the Service
+	if opts.elector != nil {
+		whs.AddReadinessCheck("leader", opts.elector.Ready)
+	}
+
 	go func() {
 		err := whs.Start()
 		if err != nil {
@@ -425,11 +1276,104 @@ func setupTriggers(ctx context.Context, opts *TriggerOpts) (teardown func()) {
 		}
 	}()
 
+	// gRPC admin API, served next to the REST one
+	var rpcServer *rpc.Server
+	if opts.grpcPort > 0 {
+		if authenticator.Enabled() {
+			rpcServer = rpc.NewServer(&rpc.Opts{
+				Port:             opts.grpcPort,
+				GRC:              opts.grc,
+				KubernetesClient: opts.k8sClient,
+				Clusters:         httpClusters(opts.clusters),
+				Providers:        opts.providers,
+				ApprovalManager:  opts.approvalsManager,
+				Store:            opts.store,
+				Authenticator:    authenticator,
+				Sender:           opts.sender,
+				Events:           opts.events,
+				TLSCertFile:      opts.tlsCert,
+				TLSKeyFile:       opts.tlsKey,
+			})
+			go func() {
+				err := rpcServer.Start()
+				if err != nil {
+					log.WithFields(log.Fields{
+						"error": err,
+						"port":  opts.grpcPort,
+					}).Fatal("gRPC server stopped")
+				}
+			}()
+		} else {
+			log.Warn("main: gRPC API requires authentication, configure basic auth or tokens to enable it")
+		}
+	}
+
+	// queue triggers share subscriptions and poll queries registries, with leader
+	// election enabled only the leader runs them
+	if opts.elector != nil {
+		opts.elector.OnStartedLeading(func(ctx context.Context) {
+			startTriggers(ctx, opts, whs)
+		})
+	} else {
+		startTriggers(ctx, opts, whs)
+	}
+
+	teardown = func() {
+		whs.Stop()
+		if rpcServer != nil {
+			rpcServer.Stop()
+		}
+	}
+
+	return teardown
+}
+
+// webhookSecrets - webhook endpoint secrets from WEBHOOK_SECRET and
+// WEBHOOK_SECRET_<ENDPOINT> env variables, GITHUB_WEBHOOK_SECRET is the
+// GitHub endpoint secret unless WEBHOOK_SECRET_GITHUB is set
+func webhookSecrets() map[string]string {
+	secrets := make(map[string]string)
+	if secret := os.Getenv(constants.EnvWebhookSecret); secret != "" {
+		secrets["*"] = secret
+	}
+	if secret := os.Getenv(constants.EnvGithubWebhookSecret); secret != "" {
+		secrets["github"] = secret
+	}
+	prefix := constants.EnvWebhookSecret + "_"
+	for _, env := range os.Environ() {
+		parts := strings.SplitN(env, "=", 2)
+		if len(parts) != 2 || !strings.HasPrefix(parts[0], prefix) || parts[1] == "" {
+			continue
+		}
+		secrets[strings.ToLower(strings.TrimPrefix(parts[0], prefix))] = parts[1]
+	}
+	return secrets
+}
+
+// httpClusters - clusters exposed by the admin API, nil if keel only manages
+// its own cluster
+func httpClusters(clusters []*cluster) []http.Cluster {
+	if len(clusters) < 2 {
+		return nil
+	}
+	var result []http.Cluster
+	for _, c := range clusters {
+		result = append(result, http.Cluster{
+			Name:             c.name,
+			GRC:              c.grc,
+			KubernetesClient: c.implementer,
+		})
+	}
+	return result
+}
+
+// startTriggers - starts triggers that should only run on a single keel replica
+func startTriggers(ctx context.Context, opts *TriggerOpts, whs *http.TriggerServer) {
 	// checking whether pubsub (GCR) trigger is enabled
-	if os.Getenv(EnvTriggerPubSub) != "" {
+	if opts.pubsub {
 		projectID := os.Getenv(EnvProjectID)
 		if projectID == "" {
-			log.Fatalf("main.setupTriggers: project ID env variable not set")
+			log.Fatalf("main.startTriggers: project ID env variable not set")
 			return
 		}
 
@@ -440,7 +1384,7 @@ func setupTriggers(ctx context.Context, opts *TriggerOpts) (teardown func()) {
 		if err != nil {
 			log.WithFields(log.Fields{
 				"error": err,
-			}).Fatal("main.setupTriggers: failed to create gcloud pubsub subscriber")
+			}).Fatal("main.startTriggers: failed to create gcloud pubsub subscriber")
 			return
 		}
 
@@ -448,20 +1392,199 @@ func setupTriggers(ctx context.Context, opts *TriggerOpts) (teardown func()) {
 		go subManager.Start(ctx)
 	}
 
-	if os.Getenv(EnvTriggerPoll) != "0" || os.Getenv(EnvTriggerPoll) != "false" {
+	// checking whether SQS (ECR) trigger is enabled
+	if queueURL := os.Getenv(EnvTriggerSQSQueueURL); queueURL != "" {
+		sub, err := sqs.New(&sqs.Opts{
+			QueueURL:  queueURL,
+			Region:    os.Getenv(EnvTriggerSQSRegion),
+			Providers: opts.providers,
+		})
+		if err != nil {
+			log.WithFields(log.Fields{
+				"error": err,
+			}).Fatal("main.startTriggers: failed to create SQS subscriber")
+			return
+		}
+
+		go sub.Start(ctx)
+	}
+
+	// checking whether NATS trigger is enabled
+	if natsURL := os.Getenv(EnvTriggerNATSURL); natsURL != "" {
+		sub, err := nats.New(&nats.Opts{
+			URL:       natsURL,
+			Subject:   os.Getenv(EnvTriggerNATSSubject),
+			Queue:     os.Getenv(EnvTriggerNATSQueue),
+			Providers: opts.providers,
+		})
+		if err != nil {
+			log.WithFields(log.Fields{
+				"error": err,
+			}).Fatal("main.startTriggers: failed to create NATS subscriber")
+			return
+		}
+
+		go func() {
+			err := sub.Start(ctx)
+			if err != nil {
+				log.WithFields(log.Fields{
+					"error": err,
+				}).Error("main.startTriggers: NATS subscriber stopped")
+			}
+		}()
+	}
+
+	// checking whether Kafka trigger is enabled
+	if brokers := os.Getenv(EnvTriggerKafkaBrokers); brokers != "" {
+		consumer, err := kafka.New(&kafka.Opts{
+			Brokers:       strings.Split(brokers, ","),
+			Topic:         os.Getenv(EnvTriggerKafkaTopic),
+			GroupID:       os.Getenv(EnvTriggerKafkaGroupID),
+			TLS:           os.Getenv(EnvTriggerKafkaTLS) == "true",
+			SASLMechanism: os.Getenv(EnvTriggerKafkaSASLMechanism),
+			Username:      os.Getenv(EnvTriggerKafkaUsername),
+			Password:      os.Getenv(EnvTriggerKafkaPassword),
+			Providers:     opts.providers,
+		})
+		if err != nil {
+			log.WithFields(log.Fields{
+				"error": err,
+			}).Fatal("main.startTriggers: failed to create Kafka consumer")
+			return
+		}
+
+		go func() {
+			err := consumer.Start(ctx)
+			if err != nil {
+				log.WithFields(log.Fields{
+					"error": err,
+				}).Error("main.startTriggers: Kafka consumer stopped")
+			}
+		}()
+	}
+
+	// compiled-in custom triggers, see extension/trigger. Sidecar plugins
+	// submit events over the gRPC admin API instead
+	trigger.Start(ctx, opts.providers)
 
+	if opts.poll {
 		registryClient := registry.New()
-		watcher := poll.NewRepositoryWatcher(opts.providers, registryClient)
-		pollManager := poll.NewPollManager(opts.providers, watcher)
+		watcher := poll.NewRepositoryWatcher(opts.providers, registryClient).
+			WithCache(opts.cache).
+			WithJitter(opts.pollJitter).
+			WithConcurrency(opts.pollConcurrency, opts.pollRegistryConcurrency)
+		if opts.shard != nil {
+			watcher.WithShard(opts.shard)
+		}
+		pollManager := poll.NewPollManager(opts.providers, watcher).
+			WithNotifier(opts.grc).
+			WithScanInterval(opts.pollInterval)
+
+		whs.AddLivenessCheck("poll", pollManager.Alive)
+		whs.AddReadinessCheck("poll", pollManager.Ready)
+		whs.SetWatchStates(watcher)
+		opts.watcher = watcher
 
 		// start poll manager, will finish with ctx
 		go watcher.Start(ctx)
 		go pollManager.Start(ctx)
+
+		go func() {
+			select {
+			case <-ctx.Done():
+			case <-pollManager.Scanned():
+				logStatusReport(whs)
+			}
+		}()
+	} else {
+		log.Info("main.startTriggers: poll trigger disabled")
+		logStatusReport(whs)
 	}
+}
 
-	teardown = func() {
-		whs.Stop()
+// logStatusReport - logs tracked resources, watched images and resources with
+// invalid policies, same report is served at /v1/status
+func logStatusReport(whs *http.TriggerServer) {
+	report, err := whs.StatusReport()
+	if err != nil {
+		log.WithFields(log.Fields{
+			"error": err,
+		}).Error("main.logStatusReport: failed to build status report")
+		return
 	}
 
-	return teardown
+	var watched []string
+	for _, img := range report.Images {
+		w := fmt.Sprintf("%s (%s", img.Image, img.Trigger)
+		if img.Schedule != "" {
+			w += " " + img.Schedule
+		}
+		watched = append(watched, fmt.Sprintf("%s): %s", w, strings.Join(img.Resources, ", ")))
+	}
+	var policyErrors []string
+	for _, e := range report.Errors {
+		identifier := e.Identifier
+		if e.Cluster != "" {
+			identifier = e.Cluster + "/" + identifier
+		}
+		policyErrors = append(policyErrors, identifier+": "+e.Error)
+	}
+
+	entry := log.WithFields(log.Fields{
+		"resources":     report.Resources,
+		"tracked":       report.Tracked,
+		"policies":      report.Policies,
+		"triggers":      report.Triggers,
+		"watched":       watched,
+		"policy_errors": policyErrors,
+	})
+	if len(policyErrors) > 0 {
+		entry.Warn("main.logStatusReport: startup status, resources with invalid policies are not updated")
+		return
+	}
+	entry.Info("main.logStatusReport: startup status")
+}
+
+// parseWebhooks - webhook endpoints to serve, nil serves all of them
+func parseWebhooks(list string) []string {
+	if strings.TrimSpace(list) == "" {
+		return nil
+	}
+	if strings.TrimSpace(list) == "none" {
+		return []string{}
+	}
+	return splitList(list)
+}
+
+// splitList - splits comma separated list, ignoring empty items
+func splitList(list string) []string {
+	var items []string
+	for _, item := range strings.Split(list, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// setupApproverGroups - static approver groups take precedence over Slack
+// user groups, nil if neither is configured
+func setupApproverGroups() approvals.GroupResolver {
+	var groups approvals.Groups
+	if spec := os.Getenv(constants.EnvApproverGroups); spec != "" {
+		static, err := approvals.ParseStaticGroups(spec)
+		if err != nil {
+			log.WithFields(log.Fields{
+				"error": err,
+			}).Fatal("main: failed to parse approver groups")
+		}
+		groups = append(groups, static)
+	}
+	if token := os.Getenv(constants.EnvSlackToken); token != "" {
+		groups = append(groups, slackbot.NewUserGroupsFromToken(token))
+	}
+	if len(groups) == 0 {
+		return nil
+	}
+	return groups
 }
@@ -0,0 +1,252 @@
+// keelctl is a command line client for the keel admin API: listing tracked
+// images and approvals, voting on approvals, pausing updates and tailing the
+// event stream. Output is either a table or JSON (--output json) for scripts.
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	kingpin "gopkg.in/alecthomas/kingpin.v2"
+
+	"github.com/keel-hq/keel/pkg/client"
+	"github.com/keel-hq/keel/types"
+	"github.com/keel-hq/keel/version"
+)
+
+// output formats
+const (
+	outputTable = "table"
+	outputJSON  = "json"
+)
+
+var (
+	app = kingpin.New("keelctl", "Command line client for the keel API.")
+
+	keelURL  = app.Flag("url", "keel address").Default("http://localhost:9300").Envar("KEEL_URL").String()
+	username = app.Flag("username", "basic auth username").Envar("KEEL_USERNAME").String()
+	password = app.Flag("password", "basic auth password").Envar("KEEL_PASSWORD").String()
+	token    = app.Flag("token", "API token, used instead of basic auth").Envar("KEEL_TOKEN").String()
+	timeout  = app.Flag("timeout", "request timeout").Default("30s").Duration()
+	output   = app.Flag("output", "output format: table or json").Short('o').Default(outputTable).Enum(outputTable, outputJSON)
+
+	trackedCmd = app.Command("tracked", "List tracked images.")
+
+	approvalsCmd    = app.Command("approvals", "List approvals.")
+	approvalsStatus = approvalsCmd.Flag("status", "filter by status: pending, archived, rejected or all").Default("pending").Enum("pending", "archived", "rejected", "all")
+
+	approveCmd        = app.Command("approve", "Approve an update.")
+	approveIdentifier = approveCmd.Arg("identifier", "approval identifier, ie: default/wd:1.1.0").Required().String()
+	approveVoter      = approveCmd.Flag("voter", "voter name, defaults to the authenticated user").String()
+
+	rejectCmd        = app.Command("reject", "Reject an update.")
+	rejectIdentifier = rejectCmd.Arg("identifier", "approval identifier, ie: default/wd:1.1.0").Required().String()
+
+	pauseCmd      = app.Command("pause", "Pause updates of a resource or, with --all, of all resources.")
+	pauseResource = pauseCmd.Arg("resource", "resource identifier, ie: deployment/default/wd or default/wd for deployments").String()
+	pauseCluster  = pauseCmd.Flag("cluster", "cluster of the resource").String()
+	pauseAll      = pauseCmd.Flag("all", "pause all updates").Bool()
+
+	resumeCmd      = app.Command("resume", "Resume updates of a resource or, with --all, of all resources.")
+	resumeResource = resumeCmd.Arg("resource", "resource identifier, ie: deployment/default/wd or default/wd for deployments").String()
+	resumeCluster  = resumeCmd.Flag("cluster", "cluster of the resource").String()
+	resumeAll      = resumeCmd.Flag("all", "resume all updates").Bool()
+
+	streamCmd   = app.Command("stream", "Tail the event stream.")
+	streamTypes = streamCmd.Flag("type", "only show events of this type, can be repeated (ie: 'deployment update')").Strings()
+)
+
+func main() {
+	app.Version(version.GetKeelVersion().Version)
+	app.HelpFlag.Short('h')
+	command := kingpin.MustParse(app.Parse(os.Args[1:]))
+
+	c, err := client.New(&client.Opts{
+		URL:      *keelURL,
+		Username: *username,
+		Password: *password,
+		Token:    *token,
+		Timeout:  *timeout,
+	})
+	app.FatalIfError(err, "")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	signalChan := make(chan os.Signal, 1)
+	signal.Notify(signalChan, os.Interrupt)
+	go func() {
+		<-signalChan
+		cancel()
+	}()
+
+	switch command {
+	case trackedCmd.FullCommand():
+		err = tracked(ctx, c, os.Stdout)
+	case approvalsCmd.FullCommand():
+		err = approvals(ctx, c, os.Stdout)
+	case approveCmd.FullCommand():
+		err = vote(ctx, c, os.Stdout, *approveIdentifier, true)
+	case rejectCmd.FullCommand():
+		err = vote(ctx, c, os.Stdout, *rejectIdentifier, false)
+	case pauseCmd.FullCommand():
+		err = pause(ctx, c, os.Stdout, *pauseCluster, *pauseResource, *pauseAll, true)
+	case resumeCmd.FullCommand():
+		err = pause(ctx, c, os.Stdout, *resumeCluster, *resumeResource, *resumeAll, false)
+	case streamCmd.FullCommand():
+		err = stream(ctx, c, os.Stdout)
+	}
+	if ctx.Err() != nil {
+		// interrupted
+		return
+	}
+	app.FatalIfError(err, "")
+}
+
+func tracked(ctx context.Context, c *client.Client, w io.Writer) error {
+	images, err := c.TrackedImages(ctx)
+	if err != nil {
+		return err
+	}
+	if *output == outputJSON {
+		return writeJSON(w, images)
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "IMAGE\tNAMESPACE\tPOLICY\tTRIGGER\tSCHEDULE\tRESOURCES")
+	for _, img := range images {
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t%s\n", img.Image, img.Namespace, img.Policy, img.Trigger, img.PollSchedule, strings.Join(img.Resources, ","))
+	}
+	return tw.Flush()
+}
+
+func approvals(ctx context.Context, c *client.Client, w io.Writer) error {
+	status := *approvalsStatus
+	if status == "all" {
+		status = ""
+	}
+	approvals, err := c.Approvals(ctx, status)
+	if err != nil {
+		return err
+	}
+	if *output == outputJSON {
+		return writeJSON(w, approvals)
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "IDENTIFIER\tCURRENT\tNEW\tVOTES\tSTATUS\tDEADLINE")
+	for _, a := range approvals {
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%d/%d\t%s\t%s\n", a.Identifier, a.CurrentVersion, a.NewVersion, a.VotesReceived, a.VotesRequired, approvalStatus(a), a.Deadline.Format(time.RFC3339))
+	}
+	return tw.Flush()
+}
+
+func approvalStatus(a *types.Approval) string {
+	switch {
+	case a.Rejected:
+		return "rejected"
+	case a.Archived:
+		return "archived"
+	case a.Expired():
+		return "expired"
+	}
+	return "pending"
+}
+
+func vote(ctx context.Context, c *client.Client, w io.Writer, identifier string, approve bool) error {
+	var approval *types.Approval
+	var err error
+	if approve {
+		approval, err = c.Approve(ctx, identifier, *approveVoter)
+	} else {
+		approval, err = c.Reject(ctx, identifier)
+	}
+	if err != nil {
+		return err
+	}
+	if *output == outputJSON {
+		return writeJSON(w, approval)
+	}
+
+	if approve {
+		fmt.Fprintf(w, "approved %s (%d/%d votes)\n", approval.Identifier, approval.VotesReceived, approval.VotesRequired)
+	} else {
+		fmt.Fprintf(w, "rejected %s\n", approval.Identifier)
+	}
+	return nil
+}
+
+func pause(ctx context.Context, c *client.Client, w io.Writer, cluster, resource string, all, paused bool) error {
+	action := "resumed"
+	if paused {
+		action = "paused"
+	}
+
+	if all {
+		if resource != "" {
+			return fmt.Errorf("either a resource or --all can be set")
+		}
+		var err error
+		if paused {
+			err = c.Pause(ctx)
+		} else {
+			err = c.Resume(ctx)
+		}
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(w, "all updates %s\n", action)
+		return nil
+	}
+
+	if resource == "" {
+		return fmt.Errorf("resource or --all is required")
+	}
+	identifier := resourceIdentifier(resource)
+	if err := c.PauseResource(ctx, cluster, identifier, paused); err != nil {
+		return err
+	}
+	fmt.Fprintf(w, "updates of %s %s\n", identifier, action)
+	return nil
+}
+
+// resourceIdentifier - "<namespace>/<name>" is a shorthand for deployments
+func resourceIdentifier(resource string) string {
+	if strings.Count(resource, "/") == 1 {
+		return "deployment/" + resource
+	}
+	return resource
+}
+
+func stream(ctx context.Context, c *client.Client, w io.Writer) error {
+	filter := map[string]bool{}
+	for _, t := range *streamTypes {
+		filter[t] = true
+	}
+
+	enc := json.NewEncoder(w)
+	return c.Stream(ctx, func(event types.EventNotification) error {
+		if len(filter) > 0 && !filter[event.Type.String()] {
+			return nil
+		}
+		if *output == outputJSON {
+			// one event per line
+			return enc.Encode(&event)
+		}
+		_, err := fmt.Fprintf(w, "%s [%s] %s: %s\n", event.CreatedAt.Format(time.RFC3339), event.Level, event.Type, event.Message)
+		return err
+	})
+}
+
+func writeJSON(w io.Writer, v interface{}) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
@@ -9,6 +9,10 @@ const DefaultNamespace = "kube-system"
 // WebhookEndpointEnv if set - enables webhook notifications
 const WebhookEndpointEnv = "WEBHOOK_ENDPOINT"
 
+// EnvWebhookTemplate - optional text/template producing the webhook payload
+// instead of the JSON encoded notification
+const EnvWebhookTemplate = "WEBHOOK_TEMPLATE"
+
 // slack bot/token
 const (
 	EnvSlackToken            = "SLACK_TOKEN"
@@ -16,6 +20,16 @@ const (
 	EnvSlackChannels         = "SLACK_CHANNELS"
 	EnvSlackApprovalsChannel = "SLACK_APPROVALS_CHANNEL"
 
+	// EnvSlackMessageTemplate - optional text/template for notification
+	// messages of both the slack bot and the incoming webhook
+	EnvSlackMessageTemplate = "SLACK_MESSAGE_TEMPLATE"
+
+	// Slack incoming webhook, summarizes applied and failed updates,
+	// independent from the approvals bot
+	EnvSlackWebhookURL     = "SLACK_WEBHOOK_URL"
+	EnvSlackWebhookChannel = "SLACK_WEBHOOK_CHANNEL" // optional, overrides webhook's default channel
+	EnvSlackWebhookLevel   = "SLACK_WEBHOOK_LEVEL"   // optional, minimum level, defaults to info
+
 	EnvHipchatToken    = "HIPCHAT_TOKEN"
 	EnvHipchatBotName  = "HIPCHAT_BOT_NAME"
 	EnvHipchatChannels = "HIPCHAT_CHANNELS"
@@ -26,14 +40,52 @@ const (
 	EnvHipchatApprovalsPasswort  = "HIPCHAT_APPROVALS_PASSWORT"
 	EnvHipchatConnectionAttempts = "HIPCHAT_CONNECTION_ATTEMPTS"
 
+	// Telegram bot token (from @BotFather), notifications are sent to the chat
+	// IDs, approvals are requested in the approvals chat
+	EnvTelegramToken         = "TELEGRAM_BOT_TOKEN"
+	EnvTelegramChats         = "TELEGRAM_CHAT_IDS"
+	EnvTelegramApprovalsChat = "TELEGRAM_APPROVALS_CHAT_ID"
+
 	// Mattermost webhook endpoint, see https://docs.mattermost.com/developer/webhooks-incoming.html
 	// for documentation on setting it up
 	EnvMattermostEndpoint = "MATTERMOST_ENDPOINT"
 	EnvMattermostName     = "MATTERMOST_USERNAME"
 
+	// Mattermost /keel slash command token, enables the approvals bot which
+	// receives commands on the bot address and replies through MATTERMOST_ENDPOINT
+	EnvMattermostSlashToken       = "MATTERMOST_SLASH_TOKEN"
+	EnvMattermostBotAddress       = "MATTERMOST_BOT_ADDRESS" // defaults to :9301
+	EnvMattermostApprovalsChannel = "MATTERMOST_APPROVALS_CHANNEL"
+
+	// approval issues are opened in a GitHub repository (owner/name) or a
+	// GitLab project (group/name), /approve and /reject comments of the
+	// allowed users are counted as votes
+	EnvApprovalsGithubRepository = "APPROVALS_GITHUB_REPOSITORY"
+	EnvApprovalsGithubToken      = "APPROVALS_GITHUB_TOKEN"
+	EnvApprovalsGithubURL        = "APPROVALS_GITHUB_URL" // defaults to https://api.github.com
+	EnvApprovalsGitlabProject    = "APPROVALS_GITLAB_PROJECT"
+	EnvApprovalsGitlabToken      = "APPROVALS_GITLAB_TOKEN"
+	EnvApprovalsGitlabURL        = "APPROVALS_GITLAB_URL" // defaults to https://gitlab.com
+	EnvApprovalsGitUsers         = "APPROVALS_GIT_USERS"  // comma separated
+	EnvApprovalsGitPollInterval  = "APPROVALS_GIT_POLL_INTERVAL"
+
+	// approver groups referenced by keel.sh/approvers, semicolon separated
+	// groups of comma separated members (ie: team-payments=alice,bob;sre=carol).
+	// Groups not found here are looked up as Slack user groups when
+	// SLACK_TOKEN is set
+	EnvApproverGroups = "APPROVER_GROUPS"
+
 	// MS Teams webhook url, see https://docs.microsoft.com/en-us/microsoftteams/platform/webhooks-and-connectors/how-to/connectors-using#setting-up-a-custom-incoming-webhook
 	EnvTeamsWebhookUrl	= "TEAMS_WEBHOOK_URL"
 
+	// Discord webhook url, see https://support.discord.com/hc/en-us/articles/228383668-Intro-to-Webhooks
+	EnvDiscordWebhookURL = "DISCORD_WEBHOOK_URL"
+	EnvDiscordUsername   = "DISCORD_USERNAME"
+
+	// PagerDuty Events API v2 integration, alerts on failed updates and rollbacks
+	EnvPagerDutyRoutingKey = "PAGERDUTY_ROUTING_KEY"
+	EnvPagerDutyEndpoint   = "PAGERDUTY_ENDPOINT" // optional, defaults to https://events.pagerduty.com/v2/enqueue
+
 	// Mail notification settings
 	EnvMailTo         = "MAIL_TO"
 	EnvMailFrom       = "MAIL_FROM"
@@ -41,16 +93,61 @@ const (
 	EnvMailSmtpPort   = "MAIL_SMTP_PORT"
 	EnvMailSmtpUser   = "MAIL_SMTP_USER"
 	EnvMailSmtpPass   = "MAIL_SMTP_PASS"
+	EnvMailSmtpTLS    = "MAIL_SMTP_TLS" // implicit TLS (ie: port 465), STARTTLS is used automatically when available
+
+	// Optional text/template overrides, executed with the notification event
+	EnvMailSubjectTemplate = "MAIL_SUBJECT_TEMPLATE"
+	EnvMailBodyTemplate    = "MAIL_BODY_TEMPLATE"
+
+	// EnvMailToLevelPrefix - per severity recipients override MAIL_TO,
+	// ie: MAIL_TO_ERROR=oncall@example.com
+	EnvMailToLevelPrefix = "MAIL_TO_"
 )
 
 // EnvNotificationLevel - minimum level for notifications, defaults to info
 const EnvNotificationLevel = "NOTIFICATION_LEVEL"
 
+// EnvNotificationDedupWindow - identical notifications, or ones about the same
+// image version, within the window are only sent once (ie: 10m)
+const EnvNotificationDedupWindow = "NOTIFICATION_DEDUP_WINDOW"
+
+// EnvNotificationDigestInterval - aggregate notifications below warning level
+// into a single summary sent every interval (ie: 1h)
+const EnvNotificationDigestInterval = "NOTIFICATION_DIGEST_INTERVAL"
+
 // Basic Auth - User / Password
 const EnvBasicAuthUser = "BASIC_AUTH_USER"
 const EnvBasicAuthPassword = "BASIC_AUTH_PASSWORD"
+
+// Read-only admin API access, ie: for dashboards
+const EnvBasicAuthReadOnlyUser = "BASIC_AUTH_READONLY_USER"
+const EnvBasicAuthReadOnlyPassword = "BASIC_AUTH_READONLY_PASSWORD"
+
+// Static admin API bearer tokens, comma separated
+const EnvAdminTokens = "ADMIN_TOKENS"
+const EnvReadOnlyTokens = "READONLY_TOKENS"
+
 const EnvAuthenticatedWebhooks = "AUTHENTICATED_WEBHOOKS"
+
+// EnvRegistryNotificationToken - optional secret token that registry notifications
+// (/v1/webhooks/registry) have to provide, ie: GitLab X-Gitlab-Token header
+const EnvRegistryNotificationToken = "REGISTRY_NOTIFICATION_TOKEN"
+
+// EnvGithubWebhookSecret - optional GitHub webhook secret, same as
+// WEBHOOK_SECRET_GITHUB which takes precedence when both are set
+const EnvGithubWebhookSecret = "GITHUB_WEBHOOK_SECRET"
+
 const EnvTokenSecret = "TOKEN_SECRET"
 
+// EnvWebhookSecret - optional shared secret of all webhook endpoints, endpoint
+// specific secrets are set with EnvWebhookSecret_<ENDPOINT>, ie: WEBHOOK_SECRET_DOCKERHUB
+const EnvWebhookSecret = "WEBHOOK_SECRET"
+
+// Webhook limits, requests per second (and burst) from a single source IP and
+// max request body size in bytes
+const EnvWebhookRateLimit = "WEBHOOK_RATE_LIMIT"
+const EnvWebhookRateBurst = "WEBHOOK_RATE_BURST"
+const EnvWebhookMaxBodySize = "WEBHOOK_MAX_BODY_SIZE"
+
 // KeelLogoURL - is a logo URL for bot icon
 const KeelLogoURL = "https://keel.sh/images/logo.png"
@@ -25,6 +25,10 @@ import (
 // more info here: https://docs.aws.amazon.com/AmazonECR/latest/userguide/service_limits.html
 const AWSCredentialsExpiry = 2 * time.Hour
 
+// tokenRefreshMargin - ECR tokens are valid for 12 hours, cached tokens are
+// refreshed this long before they expire so in-flight polls don't fail
+const tokenRefreshMargin = 10 * time.Minute
+
 var registryRegxp *regexp.Regexp
 
 func init() {
@@ -67,21 +71,24 @@ func (h *CredentialsHelper) GetCredentials(image *types.TrackedImage) (*types.Cr
 
 	registry := image.Image.Registry()
 
-	_, region, err := parseRegistry(registry)
+	registryID, region, err := parseRegistry(registry)
 	if err != nil {
 		return nil, err
 	}
 
-	// set region
-	sess := newAwsSession(region)
-	svc := ecr.New(sess)
-
 	cached, err := h.cache.Get(registry)
 	if err == nil {
 		return cached, nil
 	}
 
-	input := &ecr.GetAuthorizationTokenInput{}
+	// set region
+	sess := newAwsSession(region)
+	svc := ecr.New(sess)
+
+	// asking for a specific registry so cross account registries work too
+	input := &ecr.GetAuthorizationTokenInput{
+		RegistryIds: []*string{aws.String(registryID)},
+	}
 
 	result, err := svc.GetAuthorizationToken(input)
 	if err != nil {
@@ -114,7 +121,6 @@ func (h *CredentialsHelper) GetCredentials(image *types.TrackedImage) (*types.Cr
 
 		log.WithFields(log.Fields{
 			"current_registry": u.Host,
-			"token":            *ad.AuthorizationToken,
 			"registry":         registry,
 		}).Debug("checking registry")
 		if u.Host == registry {
@@ -128,7 +134,16 @@ func (h *CredentialsHelper) GetCredentials(image *types.TrackedImage) (*types.Cr
 				Password: password,
 			}
 
-			h.cache.Put(registry, creds)
+			var expires time.Time
+			if ad.ExpiresAt != nil {
+				expires = ad.ExpiresAt.Add(-tokenRefreshMargin)
+			}
+			h.cache.PutUntil(registry, creds, expires)
+
+			log.WithFields(log.Fields{
+				"registry": registry,
+				"expires":  expires,
+			}).Debug("credentialshelper.aws: authorization token refreshed")
 
 			return creds, nil
 		}
@@ -137,6 +152,12 @@ func (h *CredentialsHelper) GetCredentials(image *types.TrackedImage) (*types.Cr
 	return nil, fmt.Errorf("not found")
 }
 
+// InvalidateCredentials - drops cached token for image registry, next
+// GetCredentials call exchanges IAM credentials for a new one
+func (h *CredentialsHelper) InvalidateCredentials(image *types.TrackedImage) {
+	h.cache.Delete(image.Image.Registry())
+}
+
 func newAwsSession(region string) *session.Session {
 	sess := session.Must(session.NewSession(&aws.Config{
 		Region: aws.String(region),
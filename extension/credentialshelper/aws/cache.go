@@ -10,7 +10,7 @@ import (
 
 type item struct {
 	credentials *types.Credentials
-	created     time.Time
+	expires     time.Time
 }
 
 // Cache - internal cache for aws
@@ -49,7 +49,7 @@ func (c *Cache) expire() {
 	defer c.mu.Unlock()
 	t := time.Now()
 	for k, v := range c.creds {
-		if t.Sub(v.created) > c.ttl {
+		if t.After(v.expires) {
 			delete(c.creds, k)
 		}
 	}
@@ -57,9 +57,27 @@ func (c *Cache) expire() {
 
 // Put - saves new creds
 func (c *Cache) Put(registry string, creds *types.Credentials) {
+	c.PutUntil(registry, creds, time.Time{})
+}
+
+// PutUntil - saves new creds that can't be used after expires, cache ttl
+// still applies if it's shorter
+func (c *Cache) PutUntil(registry string, creds *types.Credentials, expires time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	deadline := time.Now().Add(c.ttl)
+	if !expires.IsZero() && expires.Before(deadline) {
+		deadline = expires
+	}
+	c.creds[registry] = &item{credentials: creds, expires: deadline}
+}
+
+// Delete - removes creds, next Get will miss
+func (c *Cache) Delete(registry string) {
 	c.mu.Lock()
 	defer c.mu.Unlock()
-	c.creds[registry] = &item{credentials: creds, created: time.Now()}
+	delete(c.creds, registry)
 }
 
 // Get - retrieves creds
@@ -68,7 +86,7 @@ func (c *Cache) Get(registry string) (*types.Credentials, error) {
 	defer c.mu.RUnlock()
 
 	item, ok := c.creds[registry]
-	if !ok {
+	if !ok || time.Now().After(item.expires) {
 		return nil, fmt.Errorf("not found")
 	}
 
@@ -57,3 +57,27 @@ func TestExpiry(t *testing.T) {
 	}
 
 }
+
+func TestPutUntil(t *testing.T) {
+	c := NewCache(time.Hour)
+
+	creds := &types.Credentials{
+		Username: "AWS",
+		Password: "token",
+	}
+
+	c.PutUntil("reg1", creds, time.Now().Add(-time.Second))
+	if _, err := c.Get("reg1"); err == nil {
+		t.Errorf("expected expired token to be missing")
+	}
+
+	c.PutUntil("reg1", creds, time.Now().Add(time.Minute))
+	if _, err := c.Get("reg1"); err != nil {
+		t.Errorf("unexpected error: %s", err)
+	}
+
+	c.Delete("reg1")
+	if _, err := c.Get("reg1"); err == nil {
+		t.Errorf("expected deleted token to be missing")
+	}
+}
@@ -0,0 +1,243 @@
+package azure
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/keel-hq/keel/extension/credentialshelper"
+	"github.com/keel-hq/keel/types"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// Azure environment variables, same ones are used by Azure SDKs
+const (
+	EnvTenantID     = "AZURE_TENANT_ID"
+	EnvClientID     = "AZURE_CLIENT_ID"
+	EnvClientSecret = "AZURE_CLIENT_SECRET"
+)
+
+const (
+	defaultLoginEndpoint    = "https://login.microsoftonline.com"
+	defaultIdentityEndpoint = "http://169.254.169.254/metadata/identity/oauth2/token"
+	resource                = "https://management.azure.com/"
+
+	// acrUsername - ACR refresh tokens are used with a null GUID as username
+	acrUsername = "00000000-0000-0000-0000-000000000000"
+
+	// refreshTokenTTL - ACR refresh tokens are valid for 3 hours, refreshing
+	// them well before that
+	refreshTokenTTL = time.Hour
+	// identityRetryInterval - how long to wait before asking managed identity
+	// endpoint again after it failed (ie: keel isn't running on Azure)
+	identityRetryInterval = time.Minute
+)
+
+func init() {
+	credentialshelper.RegisterCredentialsHelper("azure", New())
+}
+
+type cachedToken struct {
+	token   string
+	expires time.Time
+}
+
+// CredentialsHelper provides authorization to Azure Container Registry. Service
+// principal is used when AZURE_TENANT_ID, AZURE_CLIENT_ID and AZURE_CLIENT_SECRET
+// are set, otherwise token is obtained from managed identity endpoint (AZURE_CLIENT_ID
+// selects user assigned identity). AAD token is exchanged for ACR refresh token
+type CredentialsHelper struct {
+	enabled bool
+
+	tenantID     string
+	clientID     string
+	clientSecret string
+
+	loginEndpoint    string
+	identityEndpoint string
+	// registryScheme - scheme used for ACR token exchange, http in tests
+	registryScheme string
+	client         *http.Client
+
+	mu               sync.Mutex
+	tokens           map[string]*cachedToken
+	unavailableUntil time.Time
+}
+
+// New creates a new instance of azure credentials helper
+func New() *CredentialsHelper {
+	return &CredentialsHelper{
+		enabled:          true,
+		tenantID:         os.Getenv(EnvTenantID),
+		clientID:         os.Getenv(EnvClientID),
+		clientSecret:     os.Getenv(EnvClientSecret),
+		loginEndpoint:    defaultLoginEndpoint,
+		identityEndpoint: defaultIdentityEndpoint,
+		registryScheme:   "https",
+		client:           &http.Client{Timeout: 10 * time.Second},
+		tokens:           make(map[string]*cachedToken),
+	}
+}
+
+// IsEnabled returns a bool whether this credentials helper is initialised or not
+func (h *CredentialsHelper) IsEnabled() bool {
+	return h.enabled
+}
+
+// GetCredentials - exchanges AAD token for ACR refresh token
+func (h *CredentialsHelper) GetCredentials(image *types.TrackedImage) (*types.Credentials, error) {
+	if !h.enabled {
+		return nil, errors.New("not initialised")
+	}
+
+	registry := image.Image.Registry()
+	if !strings.HasSuffix(registry, ".azurecr.io") {
+		return nil, credentialshelper.ErrUnsupportedRegistry
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	now := time.Now()
+	if cached, ok := h.tokens[registry]; ok && now.Before(cached.expires) {
+		return &types.Credentials{Username: acrUsername, Password: cached.token}, nil
+	}
+
+	if !h.servicePrincipal() && now.Before(h.unavailableUntil) {
+		return nil, credentialshelper.ErrCredentialsNotAvailable
+	}
+
+	aadToken, err := h.aadToken()
+	if err != nil {
+		if !h.servicePrincipal() {
+			h.unavailableUntil = now.Add(identityRetryInterval)
+		}
+		return nil, err
+	}
+
+	refreshToken, err := h.exchange(registry, aadToken)
+	if err != nil {
+		return nil, err
+	}
+
+	h.tokens[registry] = &cachedToken{token: refreshToken, expires: now.Add(refreshTokenTTL)}
+
+	log.WithFields(log.Fields{
+		"registry":          registry,
+		"service_principal": h.servicePrincipal(),
+	}).Debug("credentialshelper.azure: registry token refreshed")
+
+	return &types.Credentials{Username: acrUsername, Password: refreshToken}, nil
+}
+
+// InvalidateCredentials - drops cached registry token
+func (h *CredentialsHelper) InvalidateCredentials(image *types.TrackedImage) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	delete(h.tokens, image.Image.Registry())
+}
+
+func (h *CredentialsHelper) servicePrincipal() bool {
+	return h.tenantID != "" && h.clientID != "" && h.clientSecret != ""
+}
+
+type aadTokenResponse struct {
+	AccessToken string `json:"access_token"`
+}
+
+// aadToken - gets AAD access token either with client credentials or from
+// managed identity endpoint
+func (h *CredentialsHelper) aadToken() (string, error) {
+	var req *http.Request
+	var err error
+
+	if h.servicePrincipal() {
+		form := url.Values{
+			"grant_type":    {"client_credentials"},
+			"client_id":     {h.clientID},
+			"client_secret": {h.clientSecret},
+			"resource":      {resource},
+		}
+		req, err = http.NewRequest(http.MethodPost, h.loginEndpoint+"/"+url.PathEscape(h.tenantID)+"/oauth2/token", strings.NewReader(form.Encode()))
+		if err != nil {
+			return "", err
+		}
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	} else {
+		params := url.Values{
+			"api-version": {"2018-02-01"},
+			"resource":    {resource},
+		}
+		if h.clientID != "" {
+			params.Set("client_id", h.clientID)
+		}
+		req, err = http.NewRequest(http.MethodGet, h.identityEndpoint+"?"+params.Encode(), nil)
+		if err != nil {
+			return "", err
+		}
+		req.Header.Set("Metadata", "true")
+	}
+
+	var tr aadTokenResponse
+	if err := h.do(req, &tr); err != nil {
+		return "", fmt.Errorf("failed to get AAD token: %s", err)
+	}
+	if tr.AccessToken == "" {
+		return "", errors.New("AAD returned empty access token")
+	}
+
+	return tr.AccessToken, nil
+}
+
+type exchangeResponse struct {
+	RefreshToken string `json:"refresh_token"`
+}
+
+// exchange - exchanges AAD access token for ACR refresh token
+func (h *CredentialsHelper) exchange(registry, aadToken string) (string, error) {
+	form := url.Values{
+		"grant_type":   {"access_token"},
+		"service":      {registry},
+		"access_token": {aadToken},
+	}
+	if h.tenantID != "" {
+		form.Set("tenant", h.tenantID)
+	}
+
+	req, err := http.NewRequest(http.MethodPost, h.registryScheme+"://"+registry+"/oauth2/exchange", strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	var er exchangeResponse
+	if err := h.do(req, &er); err != nil {
+		return "", fmt.Errorf("failed to exchange AAD token for registry token: %s", err)
+	}
+	if er.RefreshToken == "" {
+		return "", errors.New("registry returned empty refresh token")
+	}
+
+	return er.RefreshToken, nil
+}
+
+func (h *CredentialsHelper) do(req *http.Request, result interface{}) error {
+	resp, err := h.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("got status code %d", resp.StatusCode)
+	}
+
+	return json.NewDecoder(resp.Body).Decode(result)
+}
@@ -0,0 +1,139 @@
+package azure
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/keel-hq/keel/extension/credentialshelper"
+	"github.com/keel-hq/keel/types"
+	"github.com/keel-hq/keel/util/image"
+)
+
+// rewriteTransport - sends all requests to the test server
+type rewriteTransport struct {
+	target *url.URL
+}
+
+func (t *rewriteTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	req.Header.Set("X-Original-Host", req.URL.Host)
+	req.URL.Scheme = t.target.Scheme
+	req.URL.Host = t.target.Host
+	return http.DefaultTransport.RoundTrip(req)
+}
+
+type fakeAzure struct {
+	t          *testing.T
+	aadCalls   int
+	exchanges  int
+	identities int
+}
+
+func (f *fakeAzure) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	r.ParseForm()
+	switch r.URL.Path {
+	case "/tenant-1/oauth2/token":
+		f.aadCalls++
+		if r.Form.Get("client_id") != "client-1" || r.Form.Get("client_secret") != "secret-1" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.Write([]byte(`{"access_token":"aad-sp-token"}`))
+	case "/metadata/identity/oauth2/token":
+		f.identities++
+		if r.Header.Get("Metadata") != "true" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		w.Write([]byte(`{"access_token":"aad-msi-token"}`))
+	case "/oauth2/exchange":
+		f.exchanges++
+		if r.Form.Get("service") != r.Header.Get("X-Original-Host") {
+			f.t.Errorf("unexpected service: %s", r.Form.Get("service"))
+		}
+		w.Write([]byte(`{"refresh_token":"acr-` + r.Form.Get("access_token") + `"}`))
+	default:
+		f.t.Errorf("unexpected path: %s", r.URL.Path)
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
+
+func newTestingHelper(t *testing.T, fake *fakeAzure) (*CredentialsHelper, func()) {
+	ts := httptest.NewServer(fake)
+	target, _ := url.Parse(ts.URL)
+
+	ch := New()
+	ch.tenantID = ""
+	ch.clientID = ""
+	ch.clientSecret = ""
+	ch.registryScheme = "http"
+	ch.loginEndpoint = "http://login.microsoftonline.com"
+	ch.identityEndpoint = "http://169.254.169.254/metadata/identity/oauth2/token"
+	ch.client = &http.Client{Transport: &rewriteTransport{target: target}}
+
+	return ch, ts.Close
+}
+
+func TestServicePrincipal(t *testing.T) {
+	fake := &fakeAzure{t: t}
+	ch, teardown := newTestingHelper(t, fake)
+	defer teardown()
+
+	ch.tenantID = "tenant-1"
+	ch.clientID = "client-1"
+	ch.clientSecret = "secret-1"
+
+	imgRef, _ := image.Parse("myacr.azurecr.io/app:1.0.888")
+	ti := &types.TrackedImage{Image: imgRef}
+
+	for i := 0; i < 3; i++ {
+		creds, err := ch.GetCredentials(ti)
+		if err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+		if creds.Username != acrUsername || creds.Password != "acr-aad-sp-token" {
+			t.Errorf("unexpected credentials: %+v", creds)
+		}
+	}
+
+	if fake.aadCalls != 1 || fake.exchanges != 1 {
+		t.Errorf("expected tokens to be cached, got %d AAD calls and %d exchanges", fake.aadCalls, fake.exchanges)
+	}
+
+	ch.InvalidateCredentials(ti)
+	if _, err := ch.GetCredentials(ti); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if fake.exchanges != 2 {
+		t.Errorf("expected token to be refreshed after invalidation, got %d exchanges", fake.exchanges)
+	}
+}
+
+func TestManagedIdentity(t *testing.T) {
+	fake := &fakeAzure{t: t}
+	ch, teardown := newTestingHelper(t, fake)
+	defer teardown()
+
+	imgRef, _ := image.Parse("myacr.azurecr.io/app:1.0.888")
+	creds, err := ch.GetCredentials(&types.TrackedImage{Image: imgRef})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if creds.Password != "acr-aad-msi-token" {
+		t.Errorf("unexpected credentials: %+v", creds)
+	}
+	if fake.identities != 1 || fake.aadCalls != 0 {
+		t.Errorf("expected managed identity to be used, got %d identity calls and %d AAD calls", fake.identities, fake.aadCalls)
+	}
+}
+
+func TestUnsupportedRegistry(t *testing.T) {
+	ch := New()
+
+	imgRef, _ := image.Parse("quay.io/foo/bar:1.1")
+	_, err := ch.GetCredentials(&types.TrackedImage{Image: imgRef})
+	if err != credentialshelper.ErrUnsupportedRegistry {
+		t.Errorf("expected unsupported registry error, got: %v", err)
+	}
+}
@@ -16,6 +16,12 @@ type CredentialsHelper interface {
 	IsEnabled() bool
 }
 
+// Invalidator is implemented by helpers that cache short lived tokens (ie: ECR),
+// cached credentials are dropped when registry rejects them
+type Invalidator interface {
+	InvalidateCredentials(image *types.TrackedImage)
+}
+
 // Common errors
 var (
 	ErrCredentialsNotAvailable = errors.New("no credentials available for this registry")
@@ -106,3 +112,22 @@ func GetCredentials(image *types.TrackedImage) (*types.Credentials, error) {
 	}).Debug("extension.credentialshelper: credentials helper not found")
 	return nil, ErrCredentialsNotAvailable
 }
+
+// InvalidateCredentials - drops cached credentials for the image from all helpers
+// that cache them, called when registry rejects credentials as expired
+func InvalidateCredentials(image *types.TrackedImage) {
+	credHelpersM.RLock()
+	defer credHelpersM.RUnlock()
+
+	for name, credHelper := range credHelpers {
+		invalidator, ok := credHelper.(Invalidator)
+		if !ok || !credHelper.IsEnabled() {
+			continue
+		}
+		log.WithFields(log.Fields{
+			"helper":        name,
+			"tracked_image": image,
+		}).Debug("extension.credentialshelper: invalidating cached credentials")
+		invalidator.InvalidateCredentials(image)
+	}
+}
@@ -0,0 +1,236 @@
+package docker
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/keel-hq/keel/extension/credentialshelper"
+	"github.com/keel-hq/keel/types"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// EnvDockerConfig - directory with docker config.json, same variable is used
+// by docker CLI. Defaults to ~/.docker
+const EnvDockerConfig = "DOCKER_CONFIG"
+
+const (
+	configFile   = "config.json"
+	helperPrefix = "docker-credential-"
+
+	// dockerHubServer - server URL docker CLI stores Docker Hub credentials under
+	dockerHubServer = "https://index.docker.io/v1/"
+
+	// tokenUsername - username returned by helpers for identity tokens
+	tokenUsername = "<token>"
+
+	// cacheTTL - how long credentials returned by helpers are reused,
+	// helpers like docker-credential-ecr-login call cloud APIs every time
+	cacheTTL      = 10 * time.Minute
+	helperTimeout = 30 * time.Second
+)
+
+func init() {
+	credentialshelper.RegisterCredentialsHelper("docker", New())
+}
+
+// dockerConfig - credential helper fields of docker config.json
+type dockerConfig struct {
+	CredsStore  string            `json:"credsStore"`
+	CredHelpers map[string]string `json:"credHelpers"`
+}
+
+// helperCredentials - credentials printed by "docker-credential-<helper> get"
+type helperCredentials struct {
+	ServerURL string `json:"ServerURL"`
+	Username  string `json:"Username"`
+	Secret    string `json:"Secret"`
+}
+
+type cachedCredentials struct {
+	credentials *types.Credentials
+	expires     time.Time
+}
+
+// CredentialsHelper gets registry credentials from docker credential helpers
+// (ie: docker-credential-gcr, docker-credential-ecr-login, osxkeychain when
+// running locally) configured with credHelpers or credsStore in docker config
+type CredentialsHelper struct {
+	enabled bool
+	config  dockerConfig
+
+	// runs helper, replaced in tests
+	run func(helper, serverURL string) ([]byte, error)
+
+	mu    sync.Mutex
+	cache map[string]*cachedCredentials
+}
+
+// New creates a new instance of docker credentials helper, helper is enabled
+// when docker config sets credential helpers
+func New() *CredentialsHelper {
+	ch := &CredentialsHelper{
+		run:   runHelper,
+		cache: make(map[string]*cachedCredentials),
+	}
+
+	path := configPath()
+	if path == "" {
+		return ch
+	}
+
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			log.WithFields(log.Fields{
+				"error": err,
+				"path":  path,
+			}).Warn("credentialshelper.docker: failed to read docker config")
+		}
+		return ch
+	}
+
+	err = json.Unmarshal(data, &ch.config)
+	if err != nil {
+		log.WithFields(log.Fields{
+			"error": err,
+			"path":  path,
+		}).Error("credentialshelper.docker: failed to parse docker config")
+		return ch
+	}
+
+	ch.enabled = ch.config.CredsStore != "" || len(ch.config.CredHelpers) > 0
+	if ch.enabled {
+		log.WithFields(log.Fields{
+			"path":         path,
+			"creds_store":  ch.config.CredsStore,
+			"cred_helpers": len(ch.config.CredHelpers),
+		}).Info("credentialshelper.docker: using credential helpers from docker config")
+	}
+
+	return ch
+}
+
+func configPath() string {
+	if dir := os.Getenv(EnvDockerConfig); dir != "" {
+		return filepath.Join(dir, configFile)
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(home, ".docker", configFile)
+}
+
+// IsEnabled returns a bool whether this credentials helper is initialised or not
+func (h *CredentialsHelper) IsEnabled() bool {
+	return h.enabled
+}
+
+// GetCredentials - runs credential helper configured for the image registry,
+// registries without credHelpers entry use credsStore
+func (h *CredentialsHelper) GetCredentials(image *types.TrackedImage) (*types.Credentials, error) {
+	if !h.enabled {
+		return nil, errors.New("not initialised")
+	}
+
+	registry := image.Image.Registry()
+	helper, ok := h.config.CredHelpers[registry]
+	if !ok {
+		helper = h.config.CredsStore
+	}
+	if helper == "" {
+		return nil, credentialshelper.ErrUnsupportedRegistry
+	}
+
+	serverURL := registry
+	if registry == "index.docker.io" || registry == "docker.io" {
+		serverURL = dockerHubServer
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	key := helper + "/" + serverURL
+	if cached, ok := h.cache[key]; ok && time.Now().Before(cached.expires) {
+		return cached.credentials, nil
+	}
+
+	out, err := h.run(helper, serverURL)
+	if err != nil {
+		if strings.Contains(string(out), "credentials not found") {
+			return nil, credentialshelper.ErrCredentialsNotAvailable
+		}
+		return nil, fmt.Errorf("%s%s failed: %s: %s", helperPrefix, helper, err, strings.TrimSpace(string(out)))
+	}
+
+	var hc helperCredentials
+	err = json.Unmarshal(out, &hc)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode %s%s output: %s", helperPrefix, helper, err)
+	}
+	if hc.Secret == "" {
+		return nil, credentialshelper.ErrCredentialsNotAvailable
+	}
+	if hc.Username == tokenUsername {
+		// identity tokens have to be exchanged through OAuth2 flow
+		return nil, fmt.Errorf("%s%s returned identity token, only username and password credentials are supported", helperPrefix, helper)
+	}
+
+	creds := &types.Credentials{
+		Username: hc.Username,
+		Password: hc.Secret,
+	}
+
+	h.cache[key] = &cachedCredentials{credentials: creds, expires: time.Now().Add(cacheTTL)}
+
+	return creds, nil
+}
+
+// InvalidateCredentials - drops cached credentials, helpers are run again
+func (h *CredentialsHelper) InvalidateCredentials(image *types.TrackedImage) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.cache = make(map[string]*cachedCredentials)
+}
+
+// runHelper - runs "docker-credential-<helper> get" with server URL on stdin,
+// returns stdout or stderr when helper fails
+func runHelper(helper, serverURL string) ([]byte, error) {
+	cmd := exec.Command(helperPrefix+helper, "get")
+	cmd.Stdin = strings.NewReader(serverURL)
+
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	err := cmd.Start()
+	if err != nil {
+		return nil, err
+	}
+
+	done := make(chan error, 1)
+	go func() { done <- cmd.Wait() }()
+
+	select {
+	case err = <-done:
+	case <-time.After(helperTimeout):
+		cmd.Process.Kill()
+		return nil, fmt.Errorf("timed out after %s", helperTimeout)
+	}
+	if err != nil {
+		// helpers report errors on stdout
+		return append(stdout.Bytes(), stderr.Bytes()...), err
+	}
+
+	return stdout.Bytes(), nil
+}
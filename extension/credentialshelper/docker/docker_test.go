@@ -0,0 +1,115 @@
+package docker
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/keel-hq/keel/extension/credentialshelper"
+	"github.com/keel-hq/keel/types"
+	"github.com/keel-hq/keel/util/image"
+)
+
+func newHelper(t *testing.T, config string) (*CredentialsHelper, func()) {
+	dir, err := ioutil.TempDir("", "keel-docker-config")
+	if err != nil {
+		t.Fatalf("failed to create temp dir: %s", err)
+	}
+	err = ioutil.WriteFile(filepath.Join(dir, configFile), []byte(config), 0600)
+	if err != nil {
+		t.Fatalf("failed to write config: %s", err)
+	}
+
+	os.Setenv(EnvDockerConfig, dir)
+	ch := New()
+	os.Unsetenv(EnvDockerConfig)
+
+	return ch, func() { os.RemoveAll(dir) }
+}
+
+func trackedImage(t *testing.T, img string) *types.TrackedImage {
+	ref, err := image.Parse(img)
+	if err != nil {
+		t.Fatalf("failed to parse image: %s", err)
+	}
+	return &types.TrackedImage{Image: ref}
+}
+
+func TestGetCredentials(t *testing.T) {
+	ch, teardown := newHelper(t, `{"credsStore": "osxkeychain", "credHelpers": {"gcr.io": "gcr"}}`)
+	defer teardown()
+
+	if !ch.IsEnabled() {
+		t.Fatalf("expected helper to be enabled")
+	}
+
+	var calls []string
+	ch.run = func(helper, serverURL string) ([]byte, error) {
+		calls = append(calls, helper+" "+serverURL)
+		if helper == "gcr" {
+			return []byte(`{"ServerURL": "gcr.io", "Username": "_dcgcr_2_0_0_token", "Secret": "ya29.token"}`), nil
+		}
+		return []byte(`{"ServerURL": "https://index.docker.io/v1/", "Username": "user", "Secret": "pass"}`), nil
+	}
+
+	creds, err := ch.GetCredentials(trackedImage(t, "gcr.io/v2-namespace/hello-world:1.1"))
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if creds.Username != "_dcgcr_2_0_0_token" || creds.Password != "ya29.token" {
+		t.Errorf("unexpected credentials: %+v", creds)
+	}
+
+	creds, err = ch.GetCredentials(trackedImage(t, "karolisr/webhook-demo:0.0.11"))
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if creds.Username != "user" || creds.Password != "pass" {
+		t.Errorf("unexpected credentials: %+v", creds)
+	}
+
+	// cached
+	ch.GetCredentials(trackedImage(t, "gcr.io/v2-namespace/hello-world:1.2"))
+	if len(calls) != 2 {
+		t.Fatalf("expected credentials to be cached, helper calls: %v", calls)
+	}
+	if calls[0] != "gcr gcr.io" || calls[1] != "osxkeychain https://index.docker.io/v1/" {
+		t.Errorf("unexpected helper calls: %v", calls)
+	}
+
+	ch.InvalidateCredentials(trackedImage(t, "gcr.io/v2-namespace/hello-world:1.2"))
+	ch.GetCredentials(trackedImage(t, "gcr.io/v2-namespace/hello-world:1.2"))
+	if len(calls) != 3 {
+		t.Errorf("expected helper to be called after invalidation, helper calls: %v", calls)
+	}
+}
+
+func TestGetCredentialsNotFound(t *testing.T) {
+	ch, teardown := newHelper(t, `{"credHelpers": {"123456789012.dkr.ecr.us-east-1.amazonaws.com": "ecr-login"}}`)
+	defer teardown()
+
+	ch.run = func(helper, serverURL string) ([]byte, error) {
+		return []byte("credentials not found in native keychain\n"), errors.New("exit status 1")
+	}
+
+	_, err := ch.GetCredentials(trackedImage(t, "quay.io/karolisr/webhook-demo:0.0.11"))
+	if err != credentialshelper.ErrUnsupportedRegistry {
+		t.Errorf("expected unsupported registry error, got: %v", err)
+	}
+
+	_, err = ch.GetCredentials(trackedImage(t, "123456789012.dkr.ecr.us-east-1.amazonaws.com/app:1.0"))
+	if err != credentialshelper.ErrCredentialsNotAvailable {
+		t.Errorf("expected credentials not available error, got: %v", err)
+	}
+}
+
+func TestNoCredentialHelpers(t *testing.T) {
+	ch, teardown := newHelper(t, `{"auths": {"quay.io": {"auth": "dXNlcjpwYXNz"}}}`)
+	defer teardown()
+
+	if ch.IsEnabled() {
+		t.Errorf("expected helper to be disabled without credential helpers")
+	}
+}
@@ -1,25 +1,69 @@
 package gcr
 
 import (
+	"encoding/json"
 	"errors"
+	"fmt"
 	"io/ioutil"
+	"net/http"
 	"os"
+	"strings"
+	"sync"
+	"time"
 
 	"github.com/keel-hq/keel/extension/credentialshelper"
 	"github.com/keel-hq/keel/types"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// EnvMetadataHost - overrides metadata server address, same variable
+// is used by Google client libraries
+const EnvMetadataHost = "GCE_METADATA_HOST"
+
+const (
+	defaultMetadataHost = "metadata.google.internal"
+	tokenPath           = "/computeMetadata/v1/instance/service-accounts/default/token"
+
+	// tokenRefreshMargin - access tokens are refreshed this long before they expire
+	tokenRefreshMargin = 5 * time.Minute
+	// metadataRetryInterval - how long to wait before asking metadata server
+	// again after it failed (ie: keel isn't running on GCP)
+	metadataRetryInterval = time.Minute
 )
 
 func init() {
 	credentialshelper.RegisterCredentialsHelper("gcr", New())
 }
 
+// CredentialsHelper provides authorization to Google Container Registry and
+// Artifact Registry. JSON key from GOOGLE_APPLICATION_CREDENTIALS is used if set,
+// otherwise access tokens are obtained from the metadata server, which works
+// on GCE and on GKE with workload identity
 type CredentialsHelper struct {
 	enabled     bool
 	credentials string
+
+	metadataHost string
+	client       *http.Client
+
+	mu               sync.Mutex
+	token            string
+	expires          time.Time
+	unavailableUntil time.Time
 }
 
+// New creates a new instance of gcr credentials helper
 func New() *CredentialsHelper {
-	ch := &CredentialsHelper{}
+	ch := &CredentialsHelper{
+		enabled:      true,
+		metadataHost: defaultMetadataHost,
+		client:       &http.Client{Timeout: 2 * time.Second},
+	}
+
+	if host, ok := os.LookupEnv(EnvMetadataHost); ok && host != "" {
+		ch.metadataHost = host
+	}
 
 	credentialsFile, ok := os.LookupEnv("GOOGLE_APPLICATION_CREDENTIALS")
 	if !ok {
@@ -31,26 +75,116 @@ func New() *CredentialsHelper {
 		return ch
 	}
 
-	ch.enabled = true
 	ch.credentials = string(credentials)
 	return ch
 }
 
+// IsEnabled returns a bool whether this credentials helper is initialised or not
 func (h *CredentialsHelper) IsEnabled() bool {
 	return h.enabled
 }
 
+// GetCredentials - returns JSON key or metadata server access token for Google registries
 func (h *CredentialsHelper) GetCredentials(image *types.TrackedImage) (*types.Credentials, error) {
 	if !h.enabled {
 		return nil, errors.New("not initialised")
 	}
 
-	if image.Image.Registry() != "gcr.io" {
+	if !isGoogleRegistry(image.Image.Registry()) {
 		return nil, credentialshelper.ErrUnsupportedRegistry
 	}
 
+	if h.credentials != "" {
+		return &types.Credentials{
+			Username: "_json_key",
+			Password: h.credentials,
+		}, nil
+	}
+
+	token, err := h.accessToken()
+	if err != nil {
+		return nil, err
+	}
+
 	return &types.Credentials{
-		Username: "_json_key",
-		Password: h.credentials,
+		Username: "oauth2accesstoken",
+		Password: token,
 	}, nil
 }
+
+// InvalidateCredentials - drops cached access token
+func (h *CredentialsHelper) InvalidateCredentials(image *types.TrackedImage) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.token = ""
+}
+
+type tokenResponse struct {
+	AccessToken string `json:"access_token"`
+	ExpiresIn   int    `json:"expires_in"`
+	TokenType   string `json:"token_type"`
+}
+
+func (h *CredentialsHelper) accessToken() (string, error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	now := time.Now()
+	if h.token != "" && now.Before(h.expires) {
+		return h.token, nil
+	}
+	if now.Before(h.unavailableUntil) {
+		return "", credentialshelper.ErrCredentialsNotAvailable
+	}
+
+	token, expiresIn, err := h.fetchToken()
+	if err != nil {
+		h.unavailableUntil = now.Add(metadataRetryInterval)
+		return "", err
+	}
+
+	h.token = token
+	h.expires = now.Add(expiresIn - tokenRefreshMargin)
+
+	log.WithFields(log.Fields{
+		"expires": h.expires,
+	}).Debug("credentialshelper.gcr: access token refreshed")
+
+	return h.token, nil
+}
+
+func (h *CredentialsHelper) fetchToken() (string, time.Duration, error) {
+	req, err := http.NewRequest(http.MethodGet, "http://"+h.metadataHost+tokenPath, nil)
+	if err != nil {
+		return "", 0, err
+	}
+	req.Header.Set("Metadata-Flavor", "Google")
+
+	resp, err := h.client.Do(req)
+	if err != nil {
+		return "", 0, fmt.Errorf("failed to reach metadata server: %s", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", 0, fmt.Errorf("metadata server returned status code %d", resp.StatusCode)
+	}
+
+	var tr tokenResponse
+	if err := json.NewDecoder(resp.Body).Decode(&tr); err != nil {
+		return "", 0, fmt.Errorf("failed to decode metadata server token: %s", err)
+	}
+	if tr.AccessToken == "" {
+		return "", 0, errors.New("metadata server returned empty access token")
+	}
+
+	return tr.AccessToken, time.Duration(tr.ExpiresIn) * time.Second, nil
+}
+
+// isGoogleRegistry - gcr.io (including regional hosts like eu.gcr.io) and
+// Artifact Registry (ie: europe-west1-docker.pkg.dev)
+func isGoogleRegistry(registry string) bool {
+	return registry == "gcr.io" ||
+		strings.HasSuffix(registry, ".gcr.io") ||
+		strings.HasSuffix(registry, "-docker.pkg.dev")
+}
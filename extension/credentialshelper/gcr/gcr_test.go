@@ -0,0 +1,76 @@
+package gcr
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/keel-hq/keel/extension/credentialshelper"
+	"github.com/keel-hq/keel/types"
+	"github.com/keel-hq/keel/util/image"
+)
+
+func newMetadataServer(t *testing.T, requests *int) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		*requests++
+		if r.URL.Path != tokenPath {
+			t.Errorf("unexpected path: %s", r.URL.Path)
+		}
+		if r.Header.Get("Metadata-Flavor") != "Google" {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		w.Write([]byte(`{"access_token":"ya29.token","expires_in":3599,"token_type":"Bearer"}`))
+	}))
+}
+
+func TestWorkloadIdentity(t *testing.T) {
+	requests := 0
+	ts := newMetadataServer(t, &requests)
+	defer ts.Close()
+
+	os.Setenv(EnvMetadataHost, strings.TrimPrefix(ts.URL, "http://"))
+	defer os.Unsetenv(EnvMetadataHost)
+
+	ch := New()
+
+	for _, img := range []string{
+		"gcr.io/v2-namespace/hello-world:1.1",
+		"eu.gcr.io/v2-namespace/hello-world:1.1",
+		"europe-west1-docker.pkg.dev/project/repo/hello-world:1.1",
+	} {
+		imgRef, _ := image.Parse(img)
+		creds, err := ch.GetCredentials(&types.TrackedImage{Image: imgRef})
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %s", img, err)
+		}
+		if creds.Username != "oauth2accesstoken" || creds.Password != "ya29.token" {
+			t.Errorf("%s: unexpected credentials: %+v", img, creds)
+		}
+	}
+
+	if requests != 1 {
+		t.Errorf("expected token to be cached, got %d requests", requests)
+	}
+
+	imgRef, _ := image.Parse("gcr.io/v2-namespace/hello-world:1.1")
+	ch.InvalidateCredentials(&types.TrackedImage{Image: imgRef})
+	if _, err := ch.GetCredentials(&types.TrackedImage{Image: imgRef}); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if requests != 2 {
+		t.Errorf("expected token to be refreshed after invalidation, got %d requests", requests)
+	}
+}
+
+func TestUnsupportedRegistry(t *testing.T) {
+	ch := New()
+
+	imgRef, _ := image.Parse("quay.io/foo/bar:1.1")
+	_, err := ch.GetCredentials(&types.TrackedImage{Image: imgRef})
+	if err != credentialshelper.ErrUnsupportedRegistry {
+		t.Errorf("expected unsupported registry error, got: %v", err)
+	}
+}
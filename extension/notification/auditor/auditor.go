@@ -38,6 +38,9 @@ func (a *auditor) Send(event types.EventNotification) error {
 		ResourceKind: event.ResourceKind,
 		Identifier:   event.Identifier,
 		Message:      event.Message,
+		Namespace:    event.Metadata["namespace"],
+		Name:         event.Metadata["name"],
+		Image:        event.Metadata["images"],
 	}
 	al.SetMetadata(event.Metadata)
 	_, err := a.store.CreateAuditLog(al)
@@ -0,0 +1,144 @@
+package notification
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/keel-hq/keel/types"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// digestMaxLines - max notifications listed in a single digest message
+const digestMaxLines = 50
+
+// dedupKey - notifications about the same image version are duplicates even
+// if they are about different resources, so a push updating many deployments
+// sharing the image is notified once. Other notifications have to be identical
+func dedupKey(event types.EventNotification) string {
+	image, version := event.Metadata["image"], event.Metadata["new"]
+	if image != "" && version != "" {
+		return strings.Join([]string{event.Type.String(), event.Level.String(), event.Name, image, event.Metadata["previous"], version}, "|")
+	}
+	return strings.Join([]string{event.Type.String(), event.Level.String(), event.Identifier, event.Name, event.Message}, "|")
+}
+
+// duplicate - whether an identical notification was already sent within the
+// dedup window
+func (m *DefaultNotificationSender) duplicate(event types.EventNotification) bool {
+	if m.config.DedupWindow <= 0 {
+		return false
+	}
+
+	now := time.Now()
+	key := dedupKey(event)
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for k, sent := range m.seen {
+		if now.Sub(sent) >= m.config.DedupWindow {
+			delete(m.seen, k)
+		}
+	}
+
+	if _, ok := m.seen[key]; ok {
+		log.WithFields(log.Fields{
+			logNotiName: event.Name,
+			"window":    m.config.DedupWindow,
+		}).Debug("notification: dropping duplicate notification")
+		return true
+	}
+	m.seen[key] = now
+	return false
+}
+
+// digest - queues notification for the next digest, warnings and errors are
+// never delayed
+func (m *DefaultNotificationSender) digest(event types.EventNotification) bool {
+	if m.config.DigestInterval <= 0 || event.Level >= types.LevelWarn {
+		return false
+	}
+
+	m.mu.Lock()
+	m.pending = append(m.pending, event)
+	m.mu.Unlock()
+	return true
+}
+
+func (m *DefaultNotificationSender) runDigest(interval time.Duration) {
+	for m.stopper.Sleep(interval) {
+		m.flushDigest(interval)
+	}
+	m.flushDigest(interval)
+}
+
+// Flush - sends notifications waiting for the next digest, called on shutdown
+// so they are not lost
+func (m *DefaultNotificationSender) Flush() {
+	if m.config == nil || m.config.DigestInterval <= 0 {
+		return
+	}
+	m.flushDigest(m.config.DigestInterval)
+}
+
+// flushDigest - sends queued notifications as a single message per set of
+// notification channels
+func (m *DefaultNotificationSender) flushDigest(interval time.Duration) {
+	m.mu.Lock()
+	pending := m.pending
+	m.pending = nil
+	m.mu.Unlock()
+
+	groups := make(map[string][]types.EventNotification)
+	var keys []string
+	for _, event := range pending {
+		key := strings.Join(event.Channels, ",")
+		if _, ok := groups[key]; !ok {
+			keys = append(keys, key)
+		}
+		groups[key] = append(groups[key], event)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		event := digestEvent(groups[key], interval)
+		err := m.send(event, func(sender Sender) bool {
+			return !isUnfiltered(sender)
+		})
+		if err != nil {
+			log.WithError(err).Error("notification: failed to send digest")
+		}
+	}
+}
+
+func digestEvent(events []types.EventNotification, interval time.Duration) types.EventNotification {
+	level := types.LevelDebug
+	lines := make([]string, 0, len(events))
+	for i, event := range events {
+		if event.Level > level {
+			level = event.Level
+		}
+		if i == digestMaxLines {
+			lines = append(lines, fmt.Sprintf("... and %d more", len(events)-digestMaxLines))
+			continue
+		}
+		if i < digestMaxLines {
+			lines = append(lines, "- "+event.Message)
+		}
+	}
+
+	return types.EventNotification{
+		Name:      "digest",
+		Message:   fmt.Sprintf("%d notifications in the last %s:\n%s", len(events), interval, strings.Join(lines, "\n")),
+		CreatedAt: time.Now(),
+		Type:      types.NotificationDigest,
+		Level:     level,
+		Channels:  events[0].Channels,
+		Metadata: map[string]string{
+			"count": fmt.Sprintf("%d", len(events)),
+		},
+	}
+}
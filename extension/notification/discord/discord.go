@@ -0,0 +1,168 @@
+package discord
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/keel-hq/keel/constants"
+	"github.com/keel-hq/keel/extension/notification"
+	"github.com/keel-hq/keel/types"
+	"github.com/keel-hq/keel/version"
+
+	log "github.com/sirupsen/logrus"
+)
+
+const timeout = 5 * time.Second
+
+type sender struct {
+	endpoint string
+	username string
+	client   *http.Client
+}
+
+// Config represents the configuration of a Discord Webhook Sender.
+type Config struct {
+	Endpoint string
+	Username string
+}
+
+func init() {
+	notification.RegisterSender("discord", &sender{})
+}
+
+func (s *sender) Configure(config *notification.Config) (bool, error) {
+	// Get configuration
+	var httpConfig Config
+
+	if os.Getenv(constants.EnvDiscordWebhookURL) != "" {
+		httpConfig.Endpoint = os.Getenv(constants.EnvDiscordWebhookURL)
+	} else {
+		return false, nil
+	}
+
+	httpConfig.Username = "keel"
+	if os.Getenv(constants.EnvDiscordUsername) != "" {
+		httpConfig.Username = os.Getenv(constants.EnvDiscordUsername)
+	}
+
+	if _, err := url.ParseRequestURI(httpConfig.Endpoint); err != nil {
+		return false, fmt.Errorf("could not parse endpoint URL: %s", err)
+	}
+	s.endpoint = httpConfig.Endpoint
+	s.username = httpConfig.Username
+
+	// Setup HTTP client.
+	s.client = &http.Client{
+		Transport: http.DefaultTransport,
+		Timeout:   timeout,
+	}
+
+	log.WithFields(log.Fields{
+		"name":     "discord",
+		"username": s.username,
+	}).Info("extension.notification.discord: sender configured")
+
+	return true, nil
+}
+
+type discordMessage struct {
+	Username  string         `json:"username"`
+	AvatarURL string         `json:"avatar_url"`
+	Embeds    []discordEmbed `json:"embeds"`
+}
+
+type discordEmbed struct {
+	Title       string              `json:"title"`
+	Description string              `json:"description"`
+	Color       int64               `json:"color"`
+	Timestamp   string              `json:"timestamp,omitempty"`
+	Fields      []discordEmbedField `json:"fields,omitempty"`
+	Footer      discordEmbedFooter  `json:"footer"`
+}
+
+type discordEmbedField struct {
+	Name   string `json:"name"`
+	Value  string `json:"value"`
+	Inline bool   `json:"inline"`
+}
+
+type discordEmbedFooter struct {
+	Text string `json:"text"`
+}
+
+// embed colors are decimal integers, level colors are "#RRGGBB"
+func levelColor(level types.Level) int64 {
+	color, err := strconv.ParseInt(strings.TrimPrefix(level.Color(), "#"), 16, 64)
+	if err != nil {
+		return 0
+	}
+	return color
+}
+
+func getFields(event types.EventNotification) []discordEmbedField {
+	var fields []discordEmbedField
+
+	if event.Metadata["namespace"] != "" {
+		fields = append(fields, discordEmbedField{Name: "Namespace", Value: event.Metadata["namespace"], Inline: true})
+	}
+	if event.Metadata["name"] != "" {
+		fields = append(fields, discordEmbedField{Name: "Name", Value: event.Metadata["name"], Inline: true})
+	}
+	if event.Metadata["previous"] != "" || event.Metadata["new"] != "" {
+		fields = append(fields, discordEmbedField{Name: "Version", Value: fmt.Sprintf("%s → %s", event.Metadata["previous"], event.Metadata["new"]), Inline: true})
+	}
+	if event.Metadata["policy"] != "" {
+		fields = append(fields, discordEmbedField{Name: "Policy", Value: event.Metadata["policy"], Inline: true})
+	}
+	if event.Metadata["images"] != "" {
+		fields = append(fields, discordEmbedField{Name: "Images", Value: event.Metadata["images"]})
+	}
+
+	return fields
+}
+
+func (s *sender) Send(event types.EventNotification) error {
+	embed := discordEmbed{
+		Title:       event.Type.String(),
+		Description: event.Message,
+		Color:       levelColor(event.Level),
+		Fields:      getFields(event),
+		Footer: discordEmbedFooter{
+			Text: fmt.Sprintf("https://keel.sh %s", version.GetKeelVersion().Version),
+		},
+	}
+	if !event.CreatedAt.IsZero() {
+		embed.Timestamp = event.CreatedAt.Format(time.RFC3339)
+	}
+
+	// Marshal notification.
+	jsonNotification, err := json.Marshal(discordMessage{
+		Username:  s.username,
+		AvatarURL: constants.KeelLogoURL,
+		Embeds:    []discordEmbed{embed},
+	})
+	if err != nil {
+		return fmt.Errorf("could not marshal: %s", err)
+	}
+
+	// Send notification via HTTP POST.
+	resp, err := s.client.Post(s.endpoint, "application/json", bytes.NewBuffer(jsonNotification))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	// discord responds with 204 No Content unless ?wait=true is set
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("got status %d, expected 2xx", resp.StatusCode)
+	}
+
+	return nil
+}
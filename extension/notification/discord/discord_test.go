@@ -0,0 +1,90 @@
+package discord
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/keel-hq/keel/constants"
+	"github.com/keel-hq/keel/types"
+)
+
+func TestDiscordRequest(t *testing.T) {
+	var msg discordMessage
+
+	ts := httptest.NewServer(http.HandlerFunc(func(resp http.ResponseWriter, req *http.Request) {
+		if err := json.NewDecoder(req.Body).Decode(&msg); err != nil {
+			t.Errorf("failed to decode body: %s", err)
+		}
+		resp.WriteHeader(http.StatusNoContent)
+	}))
+	defer ts.Close()
+
+	s := &sender{
+		endpoint: ts.URL,
+		username: "keel",
+		client:   &http.Client{},
+	}
+
+	err := s.Send(types.EventNotification{
+		Name:      "update deployment",
+		Message:   "message here",
+		CreatedAt: time.Now(),
+		Type:      types.NotificationDeploymentUpdate,
+		Level:     types.LevelSuccess,
+		Metadata: map[string]string{
+			"namespace": "default",
+			"name":      "wd",
+			"previous":  "1.0.0",
+			"new":       "1.1.0",
+		},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if msg.Username != "keel" || msg.AvatarURL != constants.KeelLogoURL {
+		t.Errorf("unexpected message author: %s %s", msg.Username, msg.AvatarURL)
+	}
+
+	if len(msg.Embeds) != 1 {
+		t.Fatalf("expected 1 embed, got: %d", len(msg.Embeds))
+	}
+
+	embed := msg.Embeds[0]
+	if embed.Title != types.NotificationDeploymentUpdate.String() {
+		t.Errorf("unexpected title: %s", embed.Title)
+	}
+	if embed.Description != "message here" {
+		t.Errorf("unexpected description: %s", embed.Description)
+	}
+	// #00C853
+	if embed.Color != 51283 {
+		t.Errorf("unexpected color: %d", embed.Color)
+	}
+	if len(embed.Fields) != 3 || embed.Fields[2].Value != "1.0.0 → 1.1.0" {
+		t.Errorf("unexpected fields: %+v", embed.Fields)
+	}
+}
+
+func TestDiscordRequestFailed(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(resp http.ResponseWriter, req *http.Request) {
+		resp.WriteHeader(http.StatusTooManyRequests)
+	}))
+	defer ts.Close()
+
+	s := &sender{
+		endpoint: ts.URL,
+		client:   &http.Client{},
+	}
+
+	err := s.Send(types.EventNotification{
+		Message: "message here",
+		Type:    types.NotificationSystemEvent,
+	})
+	if err == nil {
+		t.Errorf("expected error")
+	}
+}
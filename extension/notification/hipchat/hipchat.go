@@ -73,8 +73,8 @@ func (s *sender) Send(event types.EventNotification) error {
 	}
 
 	channels := s.channels
-	if len(event.Channels) > 0 {
-		channels = event.Channels
+	if names := event.ChannelNames(); len(names) > 0 {
+		channels = names
 	}
 
 	for _, channel := range channels {
@@ -1,9 +1,12 @@
 package mail
 
 import (
+	"crypto/tls"
 	"net/smtp"
 	"os"
 	"strconv"
+	"strings"
+	"text/template"
 
 	"github.com/keel-hq/keel/constants"
 	"github.com/keel-hq/keel/extension/notification"
@@ -12,13 +15,34 @@ import (
 	log "github.com/sirupsen/logrus"
 )
 
+const (
+	defaultSubjectTemplate = "Keel notification"
+	defaultBodyTemplate    = "{{ .CreatedAt }}\n{{ .Level }}-{{ .Type }}\n{{ .Message }}"
+)
+
+var levels = []types.Level{
+	types.LevelDebug,
+	types.LevelInfo,
+	types.LevelSuccess,
+	types.LevelWarn,
+	types.LevelError,
+	types.LevelFatal,
+}
+
 type sender struct {
 	from       string
-	to         string
+	to         []string
 	smtpServer string
 	smtpPort   int
 	smtpUser   string
 	smtpPass   string
+	smtpTLS    bool
+
+	// optional per severity recipients
+	levelTo map[types.Level][]string
+
+	subject *template.Template
+	body    *template.Template
 }
 
 func init() {
@@ -37,11 +61,19 @@ func (s *sender) Configure(config *notification.Config) (bool, error) {
 	} else {
 		return false, nil
 	}
-	if os.Getenv(constants.EnvMailTo) != "" {
-		s.to = os.Getenv(constants.EnvMailTo)
-	} else {
+
+	s.levelTo = make(map[types.Level][]string)
+	for _, level := range levels {
+		if to := parseRecipients(os.Getenv(constants.EnvMailToLevelPrefix + strings.ToUpper(level.String()))); len(to) > 0 {
+			s.levelTo[level] = to
+		}
+	}
+
+	s.to = parseRecipients(os.Getenv(constants.EnvMailTo))
+	if len(s.to) == 0 && len(s.levelTo) == 0 {
 		return false, nil
 	}
+
 	// Port, user and pass are optional
 	if os.Getenv(constants.EnvMailSmtpPort) != "" {
 		port, err := strconv.Atoi(os.Getenv(constants.EnvMailSmtpPort))
@@ -61,22 +93,76 @@ func (s *sender) Configure(config *notification.Config) (bool, error) {
 	if os.Getenv(constants.EnvMailSmtpPass) != "" {
 		s.smtpPass = os.Getenv(constants.EnvMailSmtpPass)
 	}
+	s.smtpTLS = os.Getenv(constants.EnvMailSmtpTLS) == "true"
+
+	var err error
+	s.subject, err = notification.ParseTemplate("subject", os.Getenv(constants.EnvMailSubjectTemplate), defaultSubjectTemplate)
+	if err != nil {
+		return false, err
+	}
+	s.body, err = notification.ParseTemplate("body", os.Getenv(constants.EnvMailBodyTemplate), defaultBodyTemplate)
+	if err != nil {
+		return false, err
+	}
 
 	log.WithFields(log.Fields{
 		"name": "mail",
+		"tls":  s.smtpTLS,
 	}).Info("extension.notification.mail: sender configured")
 
 	return true, nil
 }
 
-func (s *sender) Send(event types.EventNotification) error {
-	body := event.CreatedAt.String() + "\n" + event.Level.String() + "-" +
-		event.Type.String() + "\n" + event.Message
+func parseRecipients(str string) []string {
+	var recipients []string
+	for _, r := range strings.Split(str, ",") {
+		if r = strings.TrimSpace(r); r != "" {
+			recipients = append(recipients, r)
+		}
+	}
+	return recipients
+}
+
+// recipients - severity specific recipients take precedence over default ones
+func (s *sender) recipients(level types.Level) []string {
+	if to, ok := s.levelTo[level]; ok {
+		return to
+	}
+	return s.to
+}
+
+func (s *sender) buildMessage(event types.EventNotification, to []string) ([]byte, error) {
+	subject, err := notification.RenderTemplate(s.subject, event)
+	if err != nil {
+		return nil, err
+	}
+	body, err := notification.RenderTemplate(s.body, event)
+	if err != nil {
+		return nil, err
+	}
+
+	// subject has to stay on a single header line
+	subjectLine := strings.Join(strings.Fields(subject), " ")
+
 	msg := "From: " + s.from + "\n" +
-		"To: " + s.to + "\n" +
-		"Subject: Keel notification\n\n" +
+		"To: " + strings.Join(to, ", ") + "\n" +
+		"Subject: " + subjectLine + "\n\n" +
 		body
 
+	return []byte(msg), nil
+}
+
+func (s *sender) Send(event types.EventNotification) error {
+	to := s.recipients(event.Level)
+	if len(to) == 0 {
+		return nil
+	}
+
+	msg, err := s.buildMessage(event, to)
+	if err != nil {
+		return err
+	}
+
 	// Support only plain auth
 	var auth smtp.Auth = nil
 	if s.smtpUser != "" {
@@ -88,12 +174,65 @@ func (s *sender) Send(event types.EventNotification) error {
 		)
 	}
 
-	err := smtp.SendMail(s.smtpServer+":"+strconv.Itoa(s.smtpPort), auth, s.from, []string{s.to}, []byte(msg))
+	addr := s.smtpServer + ":" + strconv.Itoa(s.smtpPort)
+	if s.smtpTLS {
+		err = s.sendMailTLS(addr, auth, to, msg)
+	} else {
+		// upgrades connection with STARTTLS if server supports it
+		err = smtp.SendMail(addr, auth, s.from, to, msg)
+	}
 	if err != nil {
 		log.WithFields(log.Fields{
 			"error": err,
 		}).Error("extension.notification.mail: failed to send notification")
+		return err
 	}
 
 	return nil
 }
+
+// sendMailTLS - smtp.SendMail equivalent for servers that expect TLS from
+// the start of the connection
+func (s *sender) sendMailTLS(addr string, auth smtp.Auth, to []string, msg []byte) error {
+	conn, err := tls.Dial("tcp", addr, &tls.Config{ServerName: s.smtpServer})
+	if err != nil {
+		return err
+	}
+
+	c, err := smtp.NewClient(conn, s.smtpServer)
+	if err != nil {
+		conn.Close()
+		return err
+	}
+	defer c.Close()
+
+	if auth != nil {
+		if ok, _ := c.Extension("AUTH"); ok {
+			if err = c.Auth(auth); err != nil {
+				return err
+			}
+		}
+	}
+
+	if err = c.Mail(s.from); err != nil {
+		return err
+	}
+	for _, rcpt := range to {
+		if err = c.Rcpt(rcpt); err != nil {
+			return err
+		}
+	}
+
+	w, err := c.Data()
+	if err != nil {
+		return err
+	}
+	if _, err = w.Write(msg); err != nil {
+		return err
+	}
+	if err = w.Close(); err != nil {
+		return err
+	}
+
+	return c.Quit()
+}
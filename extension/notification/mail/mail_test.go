@@ -0,0 +1,79 @@
+package mail
+
+import (
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/keel-hq/keel/constants"
+	"github.com/keel-hq/keel/extension/notification"
+	"github.com/keel-hq/keel/types"
+)
+
+func TestConfigureRecipients(t *testing.T) {
+	os.Setenv(constants.EnvMailSmtpServer, "smtp.example.com")
+	os.Setenv(constants.EnvMailFrom, "keel@example.com")
+	os.Setenv(constants.EnvMailTo, "team@example.com, dev@example.com")
+	os.Setenv(constants.EnvMailToLevelPrefix+"ERROR", "oncall@example.com")
+	defer func() {
+		os.Unsetenv(constants.EnvMailSmtpServer)
+		os.Unsetenv(constants.EnvMailFrom)
+		os.Unsetenv(constants.EnvMailTo)
+		os.Unsetenv(constants.EnvMailToLevelPrefix + "ERROR")
+	}()
+
+	s := &sender{}
+	configured, err := s.Configure(&notification.Config{})
+	if err != nil || !configured {
+		t.Fatalf("expected sender to be configured, err: %v", err)
+	}
+
+	if got := s.recipients(types.LevelSuccess); !reflect.DeepEqual(got, []string{"team@example.com", "dev@example.com"}) {
+		t.Errorf("unexpected default recipients: %v", got)
+	}
+	if got := s.recipients(types.LevelError); !reflect.DeepEqual(got, []string{"oncall@example.com"}) {
+		t.Errorf("unexpected error recipients: %v", got)
+	}
+}
+
+func TestBuildMessage(t *testing.T) {
+	subject, _ := notification.ParseTemplate("subject", "[keel] {{ .Level }}: {{ .Metadata.namespace }}/{{ .Metadata.name }}", defaultSubjectTemplate)
+	body, _ := notification.ParseTemplate("body", "", defaultBodyTemplate)
+
+	s := &sender{
+		from:    "keel@example.com",
+		subject: subject,
+		body:    body,
+	}
+
+	msg, err := s.buildMessage(types.EventNotification{
+		Message: "Successfully updated deployment default/wd 1.0.0->1.1.0",
+		Type:    types.NotificationDeploymentUpdate,
+		Level:   types.LevelSuccess,
+		Metadata: map[string]string{
+			"namespace": "default",
+			"name":      "wd",
+		},
+	}, []string{"team@example.com", "dev@example.com"})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	str := string(msg)
+	for _, expected := range []string{
+		"To: team@example.com, dev@example.com\n",
+		"Subject: [keel] success: default/wd\n",
+		"success-deployment update\nSuccessfully updated deployment default/wd 1.0.0->1.1.0",
+	} {
+		if !strings.Contains(str, expected) {
+			t.Errorf("expected message to contain %q, got: %s", expected, str)
+		}
+	}
+}
+
+func TestInvalidTemplate(t *testing.T) {
+	if _, err := notification.ParseTemplate("subject", "{{ .Level ", defaultSubjectTemplate); err == nil {
+		t.Errorf("expected error for invalid template")
+	}
+}
@@ -3,6 +3,8 @@ package notification
 import (
 	"context"
 	"fmt"
+	"sort"
+	"strings"
 	"sync"
 	"time"
 
@@ -33,7 +35,12 @@ var (
 type Config struct {
 	Attempts int
 	Level    types.Level
-	Params   map[string]interface{} `yaml:",inline"`
+	// DedupWindow - identical notifications sent within the window are dropped
+	DedupWindow time.Duration
+	// DigestInterval - when set, notifications below warning level are
+	// aggregated and sent as a single summary every interval
+	DigestInterval time.Duration
+	Params         map[string]interface{} `yaml:",inline"`
 }
 
 // Sender represents anything that can transmit notifications.
@@ -46,6 +53,13 @@ type Sender interface {
 	Send(event types.EventNotification) error
 }
 
+// UnfilteredSender - optional interface for senders that should receive
+// notifications below the configured minimum level, ie: event streams
+type UnfilteredSender interface {
+	Sender
+	Unfiltered() bool
+}
+
 // RegisterSender makes a Sender available by the provided name.
 //
 // If called twice with the same name, the name is blank, or if the provided
@@ -78,12 +92,17 @@ type DefaultNotificationSender struct {
 	config  *Config
 	stopper *stopper.Stopper
 	level   types.Level
+
+	mu      sync.Mutex
+	seen    map[string]time.Time
+	pending []types.EventNotification
 }
 
 // New - create new sender
 func New(ctx context.Context) *DefaultNotificationSender {
 	return &DefaultNotificationSender{
 		stopper: stopper.NewStopper(ctx),
+		seen:    make(map[string]time.Time),
 	}
 }
 
@@ -102,6 +121,10 @@ func (m *DefaultNotificationSender) Configure(config *Config) (bool, error) {
 		}
 	}
 
+	if config.DigestInterval > 0 {
+		go m.runDigest(config.DigestInterval)
+	}
+
 	return true, nil
 }
 
@@ -120,26 +143,42 @@ func (m *DefaultNotificationSender) Senders() map[string]Sender {
 
 // Send - send notifications through all configured senders
 func (m *DefaultNotificationSender) Send(event types.EventNotification) error {
-	if event.Level < m.config.Level {
-		return nil
+	minLevel := m.config.Level
+	if event.MinLevel != nil {
+		minLevel = *event.MinLevel
 	}
+	belowLevel := event.Level < minLevel
 
-	sendersM.RLock()
-	defer sendersM.RUnlock()
+	// unfiltered senders (audit log, event stream) always get the event,
+	// the rest only once per dedup window or through the digest
+	skipFiltered := belowLevel || m.duplicate(event) || m.digest(event)
+
+	return m.send(event, func(sender Sender) bool {
+		return !skipFiltered || isUnfiltered(sender)
+	})
+}
+
+func (m *DefaultNotificationSender) send(event types.EventNotification, include func(Sender) bool) error {
+	var failed []string
 
 	for senderName, sender := range m.Senders() {
+		if !include(sender) {
+			continue
+		}
 		// TODO: move this into goroutine if we have enough senders
 		var attempts int
 		var backOff time.Duration
 		for {
-			// Max attempts exceeded.
+			// Max attempts exceeded, giving up on this sender but still
+			// notifying the remaining ones
 			if attempts >= m.config.Attempts {
 				log.WithFields(log.Fields{
 					logNotiName:    event.Name,
 					logSenderName:  senderName,
 					"max attempts": m.config.Attempts,
 				}).Info("giving up on sending notification : max attempts exceeded")
-				return fmt.Errorf("failed to send notification, max attempts (%d) reached", m.config.Attempts)
+				failed = append(failed, senderName)
+				break
 			}
 
 			// Backoff
@@ -170,9 +209,19 @@ func (m *DefaultNotificationSender) Send(event types.EventNotification) error {
 		}
 	}
 
+	if len(failed) > 0 {
+		sort.Strings(failed)
+		return fmt.Errorf("failed to send notification via %s, max attempts (%d) reached", strings.Join(failed, ", "), m.config.Attempts)
+	}
+
 	return nil
 }
 
+func isUnfiltered(sender Sender) bool {
+	us, ok := sender.(UnfilteredSender)
+	return ok && us.Unfiltered()
+}
+
 // UnregisterSender removes a Sender with a particular name from the list.
 func (m *DefaultNotificationSender) UnregisterSender(name string) {
 	sendersM.Lock()
@@ -3,7 +3,9 @@ package notification
 import (
 	"context"
 	"fmt"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/keel-hq/keel/types"
 )
@@ -162,3 +164,251 @@ func TestSendLevelNotificationC(t *testing.T) {
 		t.Errorf("unexpected level: %s", fs.sent.Level)
 	}
 }
+
+// failing sender shouldn't prevent other senders from being notified
+func TestSendFailedSender(t *testing.T) {
+	sndr := New(context.Background())
+
+	sndr.Configure(&Config{
+		Level:    types.LevelDebug,
+		Attempts: 1,
+	})
+
+	failing := &fakeSender{
+		shouldConfigure: true,
+		shouldError:     fmt.Errorf("unavailable"),
+	}
+	fs := &fakeSender{
+		shouldConfigure: true,
+		shouldError:     nil,
+	}
+
+	RegisterSender("failingSender", failing)
+	defer sndr.UnregisterSender("failingSender")
+	RegisterSender("fakeSender", fs)
+	defer sndr.UnregisterSender("fakeSender")
+
+	err := sndr.Send(types.EventNotification{
+		Level:   types.LevelInfo,
+		Type:    types.NotificationDeploymentUpdate,
+		Message: "foo",
+	})
+
+	if err == nil {
+		t.Errorf("expected error from failing sender")
+	}
+
+	if fs.sent == nil || fs.sent.Message != "foo" {
+		t.Errorf("expected notification to be sent via remaining sender")
+	}
+}
+
+// per resource level override takes precedence over configured level
+func TestSendMinLevelOverride(t *testing.T) {
+	sndr := New(context.Background())
+
+	sndr.Configure(&Config{
+		Level:    types.LevelInfo,
+		Attempts: 1,
+	})
+
+	fs := &fakeSender{
+		shouldConfigure: true,
+		shouldError:     nil,
+	}
+
+	RegisterSender("fakeSender", fs)
+	defer sndr.UnregisterSender("fakeSender")
+
+	errorLevel := types.LevelError
+	sndr.Send(types.EventNotification{
+		Level:    types.LevelSuccess,
+		MinLevel: &errorLevel,
+		Type:     types.NotificationDeploymentUpdate,
+		Message:  "foo",
+	})
+
+	if fs.sent != nil {
+		t.Errorf("didn't expect event below overridden level to be sent")
+	}
+
+	debugLevel := types.LevelDebug
+	sndr.Send(types.EventNotification{
+		Level:    types.LevelDebug,
+		MinLevel: &debugLevel,
+		Type:     types.NotificationPreDeploymentUpdate,
+		Message:  "bar",
+	})
+
+	if fs.sent == nil || fs.sent.Message != "bar" {
+		t.Errorf("expected debug event to be sent with overridden level")
+	}
+}
+
+type fakeUnfilteredSender struct {
+	fakeSender
+}
+
+func (s *fakeUnfilteredSender) Unfiltered() bool {
+	return true
+}
+
+// unfiltered senders receive events below configured level
+func TestSendUnfilteredSender(t *testing.T) {
+	sndr := New(context.Background())
+
+	sndr.Configure(&Config{
+		Level:    types.LevelInfo,
+		Attempts: 1,
+	})
+
+	fs := &fakeSender{
+		shouldConfigure: true,
+	}
+	us := &fakeUnfilteredSender{
+		fakeSender: fakeSender{shouldConfigure: true},
+	}
+
+	RegisterSender("fakeSender", fs)
+	defer sndr.UnregisterSender("fakeSender")
+	RegisterSender("fakeUnfilteredSender", us)
+	defer sndr.UnregisterSender("fakeUnfilteredSender")
+
+	sndr.Send(types.EventNotification{
+		Level:   types.LevelDebug,
+		Type:    types.NotificationPreDeploymentUpdate,
+		Message: "foo",
+	})
+
+	if fs.sent != nil {
+		t.Errorf("didn't expect debug event to be sent to filtered sender")
+	}
+
+	if us.sent == nil || us.sent.Message != "foo" {
+		t.Errorf("expected debug event to be sent to unfiltered sender")
+	}
+}
+
+type countingSender struct {
+	sent       []types.EventNotification
+	unfiltered bool
+}
+
+func (s *countingSender) Configure(*Config) (bool, error) { return true, nil }
+
+func (s *countingSender) Send(event types.EventNotification) error {
+	s.sent = append(s.sent, event)
+	return nil
+}
+
+func (s *countingSender) Unfiltered() bool { return s.unfiltered }
+
+func TestSendDeduplicated(t *testing.T) {
+	sndr := New(context.Background())
+	sndr.Configure(&Config{
+		Level:       types.LevelInfo,
+		Attempts:    1,
+		DedupWindow: time.Minute,
+	})
+
+	fs := &countingSender{}
+	audit := &countingSender{unfiltered: true}
+	RegisterSender("fakeSender", fs)
+	defer sndr.UnregisterSender("fakeSender")
+	RegisterSender("fakeAuditor", audit)
+	defer sndr.UnregisterSender("fakeAuditor")
+
+	event := types.EventNotification{
+		Level:   types.LevelInfo,
+		Type:    types.NotificationDeploymentUpdate,
+		Message: "foo",
+	}
+	for i := 0; i < 3; i++ {
+		sndr.Send(event)
+	}
+	event.Message = "bar"
+	sndr.Send(event)
+
+	if len(fs.sent) != 2 {
+		t.Errorf("expected 2 notifications, got: %d", len(fs.sent))
+	}
+	if len(audit.sent) != 4 {
+		t.Errorf("expected unfiltered sender to get 4 notifications, got: %d", len(audit.sent))
+	}
+}
+
+func TestSendDigest(t *testing.T) {
+	sndr := New(context.Background())
+	sndr.config = &Config{
+		Level:          types.LevelInfo,
+		Attempts:       1,
+		DigestInterval: time.Hour,
+	}
+
+	fs := &countingSender{}
+	RegisterSender("fakeSender", fs)
+	defer sndr.UnregisterSender("fakeSender")
+
+	for _, msg := range []string{"updated a", "updated b"} {
+		sndr.Send(types.EventNotification{
+			Level:   types.LevelInfo,
+			Type:    types.NotificationDeploymentUpdate,
+			Message: msg,
+		})
+	}
+	sndr.Send(types.EventNotification{
+		Level:   types.LevelError,
+		Type:    types.NotificationDeploymentUpdate,
+		Message: "failed c",
+	})
+
+	if len(fs.sent) != 1 || fs.sent[0].Message != "failed c" {
+		t.Fatalf("expected only the error to be sent immediately, got: %v", fs.sent)
+	}
+
+	sndr.flushDigest(time.Hour)
+
+	if len(fs.sent) != 2 {
+		t.Fatalf("expected digest to be sent, got: %d notifications", len(fs.sent))
+	}
+	digest := fs.sent[1]
+	if digest.Type != types.NotificationDigest {
+		t.Errorf("unexpected type: %s", digest.Type)
+	}
+	if !strings.Contains(digest.Message, "- updated a\n- updated b") {
+		t.Errorf("unexpected digest message: %s", digest.Message)
+	}
+}
+
+func TestFlush(t *testing.T) {
+	sndr := New(context.Background())
+	sndr.config = &Config{
+		Level:          types.LevelInfo,
+		Attempts:       1,
+		DigestInterval: time.Hour,
+	}
+
+	fs := &countingSender{}
+	RegisterSender("fakeSender", fs)
+	defer sndr.UnregisterSender("fakeSender")
+
+	sndr.Send(types.EventNotification{
+		Level:   types.LevelInfo,
+		Type:    types.NotificationDeploymentUpdate,
+		Message: "updated a",
+	})
+	if len(fs.sent) != 0 {
+		t.Fatalf("expected notification to wait for the digest, got: %d", len(fs.sent))
+	}
+
+	sndr.Flush()
+	if len(fs.sent) != 1 || fs.sent[0].Type != types.NotificationDigest {
+		t.Fatalf("expected pending notifications to be sent on flush, got: %v", fs.sent)
+	}
+
+	// nothing left to send
+	sndr.Flush()
+	if len(fs.sent) != 1 {
+		t.Errorf("expected no more notifications, got: %d", len(fs.sent))
+	}
+}
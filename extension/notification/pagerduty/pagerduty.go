@@ -0,0 +1,150 @@
+package pagerduty
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"time"
+
+	"github.com/keel-hq/keel/constants"
+	"github.com/keel-hq/keel/extension/notification"
+	"github.com/keel-hq/keel/types"
+
+	log "github.com/sirupsen/logrus"
+)
+
+const timeout = 5 * time.Second
+
+const defaultEndpoint = "https://events.pagerduty.com/v2/enqueue"
+
+// sender - raises PagerDuty incidents for failed (or rolled back) updates and
+// resolves them once the same resource is updated successfully
+type sender struct {
+	endpoint   string
+	routingKey string
+	client     *http.Client
+}
+
+func init() {
+	notification.RegisterSender("pagerduty", &sender{})
+}
+
+func (s *sender) Configure(config *notification.Config) (bool, error) {
+	s.routingKey = os.Getenv(constants.EnvPagerDutyRoutingKey)
+	if s.routingKey == "" {
+		return false, nil
+	}
+
+	s.endpoint = defaultEndpoint
+	if os.Getenv(constants.EnvPagerDutyEndpoint) != "" {
+		s.endpoint = os.Getenv(constants.EnvPagerDutyEndpoint)
+	}
+	if _, err := url.ParseRequestURI(s.endpoint); err != nil {
+		return false, fmt.Errorf("could not parse endpoint URL: %s", err)
+	}
+
+	s.client = &http.Client{
+		Transport: http.DefaultTransport,
+		Timeout:   timeout,
+	}
+
+	log.WithFields(log.Fields{
+		"name":     "pagerduty",
+		"endpoint": s.endpoint,
+	}).Info("extension.notification.pagerduty: sender configured")
+
+	return true, nil
+}
+
+type pagerDutyEvent struct {
+	RoutingKey  string            `json:"routing_key"`
+	EventAction string            `json:"event_action"`
+	DedupKey    string            `json:"dedup_key"`
+	Payload     *pagerDutyPayload `json:"payload,omitempty"`
+}
+
+type pagerDutyPayload struct {
+	Summary       string            `json:"summary"`
+	Source        string            `json:"source"`
+	Severity      string            `json:"severity"`
+	Timestamp     string            `json:"timestamp,omitempty"`
+	Component     string            `json:"component,omitempty"`
+	Group         string            `json:"group,omitempty"`
+	Class         string            `json:"class,omitempty"`
+	CustomDetails map[string]string `json:"custom_details,omitempty"`
+}
+
+func isUpdate(event types.EventNotification) bool {
+	return event.Type == types.NotificationDeploymentUpdate || event.Type == types.NotificationReleaseUpdate
+}
+
+// dedupKey - one incident per resource, repeated failures are grouped together
+func dedupKey(event types.EventNotification) string {
+	if event.Identifier != "" {
+		return "keel/" + event.Identifier
+	}
+	return fmt.Sprintf("keel/%s/%s", event.Metadata["namespace"], event.Metadata["name"])
+}
+
+func severity(level types.Level) string {
+	switch level {
+	case types.LevelFatal:
+		return "critical"
+	case types.LevelError:
+		return "error"
+	}
+	return "warning"
+}
+
+func (s *sender) Send(event types.EventNotification) error {
+	if !isUpdate(event) {
+		return nil
+	}
+
+	pdEvent := pagerDutyEvent{
+		RoutingKey: s.routingKey,
+		DedupKey:   dedupKey(event),
+	}
+
+	switch {
+	case event.Level >= types.LevelWarn:
+		// failed updates are errors, rollbacks after failed health checks are warnings
+		pdEvent.EventAction = "trigger"
+		pdEvent.Payload = &pagerDutyPayload{
+			Summary:       event.Message,
+			Source:        "keel",
+			Severity:      severity(event.Level),
+			Component:     event.Metadata["name"],
+			Group:         event.Metadata["namespace"],
+			Class:         event.ResourceKind,
+			CustomDetails: event.Metadata,
+		}
+		if !event.CreatedAt.IsZero() {
+			pdEvent.Payload.Timestamp = event.CreatedAt.Format(time.RFC3339)
+		}
+	case event.Level == types.LevelSuccess:
+		pdEvent.EventAction = "resolve"
+	default:
+		return nil
+	}
+
+	jsonEvent, err := json.Marshal(pdEvent)
+	if err != nil {
+		return fmt.Errorf("could not marshal: %s", err)
+	}
+
+	resp, err := s.client.Post(s.endpoint, "application/json", bytes.NewBuffer(jsonEvent))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusAccepted {
+		return fmt.Errorf("got status %d, expected 202", resp.StatusCode)
+	}
+
+	return nil
+}
@@ -0,0 +1,88 @@
+package pagerduty
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/keel-hq/keel/types"
+)
+
+func TestPagerDutyEvents(t *testing.T) {
+	var received []pagerDutyEvent
+
+	ts := httptest.NewServer(http.HandlerFunc(func(resp http.ResponseWriter, req *http.Request) {
+		var event pagerDutyEvent
+		if err := json.NewDecoder(req.Body).Decode(&event); err != nil {
+			t.Errorf("failed to decode body: %s", err)
+		}
+		received = append(received, event)
+		resp.WriteHeader(http.StatusAccepted)
+	}))
+	defer ts.Close()
+
+	s := &sender{
+		endpoint:   ts.URL,
+		routingKey: "routing-key",
+		client:     &http.Client{},
+	}
+
+	metadata := map[string]string{
+		"namespace": "default",
+		"name":      "wd",
+	}
+
+	events := []types.EventNotification{
+		// ignored, not an update result
+		{Type: types.NotificationPreDeploymentUpdate, Level: types.LevelError, Identifier: "deployment/default/wd"},
+		// ignored, informational
+		{Type: types.NotificationDeploymentUpdate, Level: types.LevelInfo, Identifier: "deployment/default/wd"},
+		{
+			Type:         types.NotificationDeploymentUpdate,
+			Level:        types.LevelError,
+			ResourceKind: "deployment",
+			Identifier:   "deployment/default/wd",
+			Message:      "deployment default/wd update 1.0.0->1.1.0 failed",
+			CreatedAt:    time.Now(),
+			Metadata:     metadata,
+		},
+		{Type: types.NotificationDeploymentUpdate, Level: types.LevelSuccess, Identifier: "deployment/default/wd", Metadata: metadata},
+	}
+
+	for _, e := range events {
+		if err := s.Send(e); err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+	}
+
+	if len(received) != 2 {
+		t.Fatalf("expected 2 events, got: %d", len(received))
+	}
+
+	trigger := received[0]
+	if trigger.EventAction != "trigger" || trigger.RoutingKey != "routing-key" {
+		t.Errorf("unexpected trigger event: %+v", trigger)
+	}
+	if trigger.DedupKey != "keel/deployment/default/wd" {
+		t.Errorf("unexpected dedup key: %s", trigger.DedupKey)
+	}
+	if trigger.Payload == nil || trigger.Payload.Severity != "error" || trigger.Payload.Group != "default" || trigger.Payload.Component != "wd" {
+		t.Errorf("unexpected payload: %+v", trigger.Payload)
+	}
+
+	resolve := received[1]
+	if resolve.EventAction != "resolve" || resolve.DedupKey != trigger.DedupKey || resolve.Payload != nil {
+		t.Errorf("unexpected resolve event: %+v", resolve)
+	}
+}
+
+func TestDedupKeyWithoutIdentifier(t *testing.T) {
+	key := dedupKey(types.EventNotification{
+		Metadata: map[string]string{"namespace": "default", "name": "chart"},
+	})
+	if key != "keel/default/chart" {
+		t.Errorf("unexpected dedup key: %s", key)
+	}
+}
@@ -6,6 +6,7 @@ import (
 	"os"
 	"strconv"
 	"strings"
+	"text/template"
 	"time"
 
 	"github.com/nlopes/slack"
@@ -24,6 +25,7 @@ type sender struct {
 	slackClient *slack.Client
 	channels    []string
 	botName     string
+	message     *template.Template
 }
 
 func init() {
@@ -51,6 +53,14 @@ func (s *sender) Configure(config *notification.Config) (bool, error) {
 		s.channels = []string{"general"}
 	}
 
+	if text := os.Getenv(constants.EnvSlackMessageTemplate); text != "" {
+		tmpl, err := notification.ParseTemplate("slack message", text, "")
+		if err != nil {
+			return false, err
+		}
+		s.message = tmpl
+	}
+
 	s.slackClient = slack.New(token)
 
 	log.WithFields(log.Fields{
@@ -87,18 +97,27 @@ func (s *sender) Configure(config *notification.Config) (bool, error) {
 }
 
 func (s *sender) Send(event types.EventNotification) error {
+	text := event.Message
+	if s.message != nil {
+		rendered, err := notification.RenderTemplate(s.message, event)
+		if err != nil {
+			return err
+		}
+		text = rendered
+	}
+
 	params := slack.NewPostMessageParameters()
 	params.Username = s.botName
 	params.IconURL = constants.KeelLogoURL
 
 	attachements := []slack.Attachment{
 		{
-			Fallback: event.Message,
+			Fallback: text,
 			Color:    event.Level.Color(),
 			Fields: []slack.AttachmentField{
 				{
 					Title: event.Type.String(),
-					Value: event.Message,
+					Value: text,
 					Short: false,
 				},
 			},
@@ -108,8 +127,8 @@ func (s *sender) Send(event types.EventNotification) error {
 	}
 
 	chans := s.channels
-	if len(event.Channels) > 0 {
-		chans = event.Channels
+	if names := event.ChannelNames(); len(names) > 0 {
+		chans = names
 	}
 
 	var mgsOpts []slack.MsgOption
@@ -0,0 +1,193 @@
+package slackwebhook
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"text/template"
+	"time"
+
+	"github.com/keel-hq/keel/constants"
+	"github.com/keel-hq/keel/extension/notification"
+	"github.com/keel-hq/keel/types"
+	"github.com/keel-hq/keel/version"
+
+	log "github.com/sirupsen/logrus"
+)
+
+const timeout = 5 * time.Second
+
+// sender - posts update summaries to a Slack incoming webhook. Unlike the
+// slack sender it doesn't need a bot token and only reports applied and
+// failed updates
+type sender struct {
+	endpoint string
+	channel  string
+	level    types.Level
+	message  *template.Template
+	client   *http.Client
+}
+
+func init() {
+	notification.RegisterSender("slackwebhook", &sender{})
+}
+
+func (s *sender) Configure(config *notification.Config) (bool, error) {
+	endpoint := os.Getenv(constants.EnvSlackWebhookURL)
+	if endpoint == "" {
+		return false, nil
+	}
+
+	if _, err := url.ParseRequestURI(endpoint); err != nil {
+		return false, fmt.Errorf("could not parse endpoint URL: %s", err)
+	}
+	s.endpoint = endpoint
+	s.channel = os.Getenv(constants.EnvSlackWebhookChannel)
+
+	s.level = types.LevelInfo
+	if lvl := os.Getenv(constants.EnvSlackWebhookLevel); lvl != "" {
+		level, err := types.ParseLevel(lvl)
+		if err != nil {
+			return false, err
+		}
+		s.level = level
+	}
+
+	if text := os.Getenv(constants.EnvSlackMessageTemplate); text != "" {
+		tmpl, err := notification.ParseTemplate("slack message", text, "")
+		if err != nil {
+			return false, err
+		}
+		s.message = tmpl
+	}
+
+	s.client = &http.Client{
+		Transport: http.DefaultTransport,
+		Timeout:   timeout,
+	}
+
+	log.WithFields(log.Fields{
+		"name":    "slackwebhook",
+		"channel": s.channel,
+		"level":   s.level.String(),
+	}).Info("extension.notification.slackwebhook: sender configured")
+
+	return true, nil
+}
+
+type message struct {
+	Channel     string       `json:"channel,omitempty"`
+	Username    string       `json:"username"`
+	IconURL     string       `json:"icon_url"`
+	Attachments []attachment `json:"attachments"`
+}
+
+type attachment struct {
+	Fallback string  `json:"fallback"`
+	Color    string  `json:"color"`
+	Title    string  `json:"title"`
+	Text     string  `json:"text"`
+	Fields   []field `json:"fields,omitempty"`
+	Footer   string  `json:"footer"`
+	Ts       int64   `json:"ts"`
+}
+
+type field struct {
+	Title string `json:"title"`
+	Value string `json:"value"`
+	Short bool   `json:"short"`
+}
+
+// shouldSend - only resource updates (both successful and failed) are reported
+func (s *sender) shouldSend(event types.EventNotification) bool {
+	if event.Level < s.level {
+		return false
+	}
+	switch event.Type {
+	case types.NotificationDeploymentUpdate, types.NotificationReleaseUpdate:
+		return true
+	}
+	return false
+}
+
+func (s *sender) Send(event types.EventNotification) error {
+	if !s.shouldSend(event) {
+		return nil
+	}
+
+	text := event.Message
+	if s.message != nil {
+		rendered, err := notification.RenderTemplate(s.message, event)
+		if err != nil {
+			return err
+		}
+		text = rendered
+	}
+
+	var fields []field
+	for _, f := range []struct {
+		title string
+		key   string
+		short bool
+	}{
+		{title: "Namespace", key: "namespace", short: true},
+		{title: "Name", key: "name", short: true},
+		{title: "Images", key: "images"},
+	} {
+		if value := event.Metadata[f.key]; value != "" {
+			fields = append(fields, field{Title: f.title, Value: value, Short: f.short})
+		}
+	}
+
+	msg := message{
+		Username: "keel",
+		IconURL:  constants.KeelLogoURL,
+		Attachments: []attachment{
+			{
+				Fallback: text,
+				Color:    event.Level.Color(),
+				Title:    event.Type.String(),
+				Text:     text,
+				Fields:   fields,
+				Footer:   fmt.Sprintf("https://keel.sh %s", version.GetKeelVersion().Version),
+				Ts:       event.CreatedAt.Unix(),
+			},
+		},
+	}
+
+	channels := []string{s.channel}
+	if names := event.ChannelNames(); len(names) > 0 {
+		channels = names
+	}
+
+	for _, channel := range channels {
+		msg.Channel = channel
+		if err := s.post(msg); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (s *sender) post(msg message) error {
+	jsonMessage, err := json.Marshal(msg)
+	if err != nil {
+		return fmt.Errorf("could not marshal: %s", err)
+	}
+
+	resp, err := s.client.Post(s.endpoint, "application/json", bytes.NewBuffer(jsonMessage))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("got status %d, expected 200", resp.StatusCode)
+	}
+
+	return nil
+}
@@ -0,0 +1,73 @@
+package slackwebhook
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/keel-hq/keel/types"
+)
+
+func TestSlackWebhookRequest(t *testing.T) {
+	var received []message
+
+	ts := httptest.NewServer(http.HandlerFunc(func(resp http.ResponseWriter, req *http.Request) {
+		var msg message
+		if err := json.NewDecoder(req.Body).Decode(&msg); err != nil {
+			t.Errorf("failed to decode body: %s", err)
+		}
+		received = append(received, msg)
+	}))
+	defer ts.Close()
+
+	s := &sender{
+		endpoint: ts.URL,
+		channel:  "deployments",
+		level:    types.LevelInfo,
+		client:   &http.Client{},
+	}
+
+	events := []types.EventNotification{
+		// below configured level
+		{Type: types.NotificationDeploymentUpdate, Level: types.LevelDebug, Message: "debug"},
+		// not an update
+		{Type: types.NotificationPreDeploymentUpdate, Level: types.LevelInfo, Message: "preparing"},
+		{
+			Type:      types.NotificationDeploymentUpdate,
+			Level:     types.LevelSuccess,
+			Message:   "Successfully updated deployment default/wd 1.0.0->1.1.0 (karolisr/webhook-demo:1.1.0)",
+			CreatedAt: time.Now(),
+			Metadata: map[string]string{
+				"namespace": "default",
+				"name":      "wd",
+				"images":    "karolisr/webhook-demo:1.1.0",
+			},
+		},
+	}
+
+	for _, e := range events {
+		if err := s.Send(e); err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+	}
+
+	if len(received) != 1 {
+		t.Fatalf("expected 1 message, got: %d", len(received))
+	}
+
+	msg := received[0]
+	if msg.Channel != "deployments" {
+		t.Errorf("unexpected channel: %s", msg.Channel)
+	}
+	if len(msg.Attachments) != 1 || len(msg.Attachments[0].Fields) != 3 {
+		t.Fatalf("unexpected attachments: %+v", msg.Attachments)
+	}
+	if msg.Attachments[0].Fields[0].Value != "default" {
+		t.Errorf("unexpected namespace field: %+v", msg.Attachments[0].Fields[0])
+	}
+	if msg.Attachments[0].Color != types.LevelSuccess.Color() {
+		t.Errorf("unexpected color: %s", msg.Attachments[0].Color)
+	}
+}
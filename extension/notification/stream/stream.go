@@ -0,0 +1,114 @@
+package stream
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/keel-hq/keel/extension/notification"
+	"github.com/keel-hq/keel/types"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// subscriberBuffer - events buffered per subscriber, events for slow
+// subscribers are dropped once the buffer is full
+const subscriberBuffer = 50
+
+// Broadcaster - notification sender that fans out all events to
+// subscribers, used to stream events through the HTTP API
+type Broadcaster struct {
+	mu          sync.RWMutex
+	index       uint32
+	subscribers map[uint32]chan types.EventNotification
+}
+
+// New - create new event broadcaster
+func New() *Broadcaster {
+	return &Broadcaster{
+		subscribers: make(map[uint32]chan types.EventNotification),
+	}
+}
+
+// Configure - broadcaster is always enabled
+func (b *Broadcaster) Configure(config *notification.Config) (bool, error) {
+	log.WithFields(log.Fields{
+		"name": "stream",
+	}).Info("extension.notification.stream: event stream configured")
+
+	return true, nil
+}
+
+// Unfiltered - subscribers get events of all levels
+func (b *Broadcaster) Unfiltered() bool {
+	return true
+}
+
+// Send - publish event to all subscribers, never blocks
+func (b *Broadcaster) Send(event types.EventNotification) error {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	for _, subscriber := range b.subscribers {
+		select {
+		case subscriber <- event:
+		default:
+			log.WithFields(log.Fields{
+				"name": event.Name,
+			}).Warn("extension.notification.stream: subscriber is too slow, dropping event")
+		}
+	}
+	return nil
+}
+
+// Subscribe - subscribe for events, channel is closed once the
+// context is cancelled
+func (b *Broadcaster) Subscribe(ctx context.Context) <-chan types.EventNotification {
+	b.mu.Lock()
+	index := atomic.AddUint32(&b.index, 1)
+	eventsCh := make(chan types.EventNotification, subscriberBuffer)
+	b.subscribers[index] = eventsCh
+	b.mu.Unlock()
+
+	go func() {
+		<-ctx.Done()
+		b.mu.Lock()
+		delete(b.subscribers, index)
+		close(eventsCh)
+		b.mu.Unlock()
+	}()
+
+	return eventsCh
+}
+
+// PublishApprovals - publishes new approval requests, approvals manager
+// doesn't go through notification senders when creating them
+func (b *Broadcaster) PublishApprovals(ctx context.Context, approvals <-chan *types.Approval) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case approval, ok := <-approvals:
+			if !ok {
+				return
+			}
+			b.Send(types.EventNotification{
+				Name:       "approval requested",
+				Message:    fmt.Sprintf("Approval required for %s %s->%s, %d votes needed", approval.Identifier, approval.CurrentVersion, approval.NewVersion, approval.VotesRequired),
+				CreatedAt:  time.Now(),
+				Type:       types.NotificationApprovalRequested,
+				Level:      types.LevelInfo,
+				Identifier: approval.Identifier,
+				Metadata: map[string]string{
+					"provider": approval.Provider.String(),
+					"id":       approval.ID,
+					"previous": approval.CurrentVersion,
+					"new":      approval.NewVersion,
+					"deadline": approval.Deadline.Format(time.RFC3339),
+				},
+			})
+		}
+	}
+}
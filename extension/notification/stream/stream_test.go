@@ -0,0 +1,85 @@
+package stream
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/keel-hq/keel/types"
+)
+
+func TestSubscribe(t *testing.T) {
+	b := New()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	events := b.Subscribe(ctx)
+
+	b.Send(types.EventNotification{Name: "foo"})
+
+	select {
+	case event := <-events:
+		if event.Name != "foo" {
+			t.Errorf("unexpected event: %s", event.Name)
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("didn't receive event")
+	}
+
+	cancel()
+
+	select {
+	case _, ok := <-events:
+		if ok {
+			t.Errorf("expected channel to be closed")
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("channel wasn't closed")
+	}
+}
+
+func TestSendSlowSubscriber(t *testing.T) {
+	b := New()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	events := b.Subscribe(ctx)
+
+	// nothing is reading, sending shouldn't block
+	for i := 0; i < subscriberBuffer+10; i++ {
+		b.Send(types.EventNotification{Name: "foo"})
+	}
+
+	if len(events) != subscriberBuffer {
+		t.Errorf("expected %d buffered events, got: %d", subscriberBuffer, len(events))
+	}
+}
+
+func TestPublishApprovals(t *testing.T) {
+	b := New()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	events := b.Subscribe(ctx)
+
+	approvals := make(chan *types.Approval, 1)
+	go b.PublishApprovals(ctx, approvals)
+
+	approvals <- &types.Approval{
+		Identifier:     "default/foo:1.1.0",
+		CurrentVersion: "1.0.0",
+		NewVersion:     "1.1.0",
+		VotesRequired:  2,
+	}
+
+	select {
+	case event := <-events:
+		if event.Type != types.NotificationApprovalRequested {
+			t.Errorf("unexpected event type: %s", event.Type)
+		}
+		if event.Identifier != "default/foo:1.1.0" {
+			t.Errorf("unexpected identifier: %s", event.Identifier)
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("didn't receive event")
+	}
+}
@@ -102,6 +102,36 @@ func TrimFirstChar(s string) string {
     return ""
 }
 
+// getFacts - resource details (when available) followed by keel version
+func getFacts(event types.EventNotification) []TeamsFact {
+	var facts []TeamsFact
+
+	if event.Metadata["namespace"] != "" {
+		facts = append(facts, TeamsFact{Name: "Namespace", Value: event.Metadata["namespace"]})
+	}
+	if event.Metadata["name"] != "" {
+		name := event.Metadata["name"]
+		if event.ResourceKind != "" {
+			name = fmt.Sprintf("%s %s", event.ResourceKind, name)
+		}
+		facts = append(facts, TeamsFact{Name: "Resource", Value: name})
+	}
+	if event.Metadata["previous"] != "" || event.Metadata["new"] != "" {
+		facts = append(facts, TeamsFact{Name: "Version", Value: fmt.Sprintf("%s → %s", event.Metadata["previous"], event.Metadata["new"])})
+	}
+	if event.Metadata["policy"] != "" {
+		facts = append(facts, TeamsFact{Name: "Policy", Value: event.Metadata["policy"]})
+	}
+	if event.Metadata["images"] != "" {
+		facts = append(facts, TeamsFact{Name: "Images", Value: event.Metadata["images"]})
+	}
+
+	return append(facts, TeamsFact{
+		Name:  "Keel",
+		Value: fmt.Sprintf("[https://keel.sh](https://keel.sh) %s", version.GetKeelVersion().Version),
+	})
+}
+
 func (s *sender) Send(event types.EventNotification) error {
 	// Marshal notification.
 	jsonNotification, err := json.Marshal(SimpleTeamsMessageCard{
@@ -114,12 +144,7 @@ func (s *sender) Send(event types.EventNotification) error {
 				ActivityImage: constants.KeelLogoURL,
 				ActivityText: fmt.Sprintf("*%s*: %s", event.Name, event.Message),
 				ActivityTitle: fmt.Sprintf("**%s**",event.Type.String()),
-				Facts: []TeamsFact{
-					{
-						Name: "Version",
-						Value: fmt.Sprintf("[https://keel.sh](https://keel.sh) %s", version.GetKeelVersion().Version),
-					},
-				},
+				Facts: getFacts(event),
 				Markdown: true,
 			},
 		},
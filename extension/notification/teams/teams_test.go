@@ -77,3 +77,38 @@ func TestTeamsRequest(t *testing.T) {
 		Type:      types.NotificationPreDeploymentUpdate,
 	})
 }
+
+func TestTeamsFacts(t *testing.T) {
+	facts := getFacts(types.EventNotification{
+		ResourceKind: "deployment",
+		Type:         types.NotificationDeploymentUpdate,
+		Metadata: map[string]string{
+			"namespace": "default",
+			"name":      "wd",
+			"previous":  "1.0.0",
+			"new":       "1.1.0",
+			"policy":    "semver",
+			"images":    "karolisr/webhook-demo:1.1.0",
+		},
+	})
+
+	expected := []TeamsFact{
+		{Name: "Namespace", Value: "default"},
+		{Name: "Resource", Value: "deployment wd"},
+		{Name: "Version", Value: "1.0.0 → 1.1.0"},
+		{Name: "Policy", Value: "semver"},
+		{Name: "Images", Value: "karolisr/webhook-demo:1.1.0"},
+	}
+
+	if len(facts) != len(expected)+1 {
+		t.Fatalf("unexpected facts: %+v", facts)
+	}
+	for i, f := range expected {
+		if facts[i] != f {
+			t.Errorf("expected fact %+v, got: %+v", f, facts[i])
+		}
+	}
+	if facts[len(facts)-1].Name != "Keel" {
+		t.Errorf("expected keel version fact last, got: %+v", facts[len(facts)-1])
+	}
+}
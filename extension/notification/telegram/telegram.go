@@ -0,0 +1,88 @@
+package telegram
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/keel-hq/keel/constants"
+	"github.com/keel-hq/keel/extension/notification"
+	"github.com/keel-hq/keel/pkg/telegram"
+	"github.com/keel-hq/keel/types"
+
+	log "github.com/sirupsen/logrus"
+)
+
+const timeout = 5 * time.Second
+
+type messageSender interface {
+	SendMessage(ctx context.Context, chatID, text string, keyboard *telegram.InlineKeyboardMarkup) error
+}
+
+type sender struct {
+	chats  []string
+	client messageSender
+}
+
+func init() {
+	notification.RegisterSender("telegram", &sender{})
+}
+
+func (s *sender) Configure(config *notification.Config) (bool, error) {
+	token := os.Getenv(constants.EnvTelegramToken)
+	if token == "" {
+		return false, nil
+	}
+
+	s.chats = nil
+	for _, chat := range strings.Split(os.Getenv(constants.EnvTelegramChats), ",") {
+		if chat = strings.TrimSpace(chat); chat != "" {
+			s.chats = append(s.chats, chat)
+		}
+	}
+	if len(s.chats) == 0 {
+		return false, nil
+	}
+
+	s.client = telegram.New(token)
+
+	log.WithFields(log.Fields{
+		"name":  "telegram",
+		"chats": s.chats,
+	}).Info("extension.notification.telegram: sender configured")
+
+	return true, nil
+}
+
+func (s *sender) Send(event types.EventNotification) error {
+	chats := s.chats
+	// channel overrides are shared with other senders, ie: slack channel names
+	var overrides []string
+	for _, name := range event.ChannelNames() {
+		if telegram.IsChatID(name) {
+			overrides = append(overrides, name)
+		}
+	}
+	if len(overrides) > 0 {
+		chats = overrides
+	}
+
+	text := fmt.Sprintf("[%s] %s\n%s", strings.ToUpper(event.Level.String()), event.Type.String(), event.Message)
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	for _, chat := range chats {
+		if err := s.client.SendMessage(ctx, chat, text, nil); err != nil {
+			log.WithFields(log.Fields{
+				"error": err,
+				"chat":  chat,
+			}).Error("extension.notification.telegram: failed to send notification")
+			return err
+		}
+	}
+
+	return nil
+}
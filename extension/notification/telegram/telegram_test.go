@@ -0,0 +1,61 @@
+package telegram
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/keel-hq/keel/pkg/telegram"
+	"github.com/keel-hq/keel/types"
+)
+
+type sentMessage struct {
+	chat string
+	text string
+}
+
+type fakeClient struct {
+	sent []sentMessage
+}
+
+func (c *fakeClient) SendMessage(ctx context.Context, chatID, text string, keyboard *telegram.InlineKeyboardMarkup) error {
+	c.sent = append(c.sent, sentMessage{chat: chatID, text: text})
+	return nil
+}
+
+func TestSend(t *testing.T) {
+	fc := &fakeClient{}
+	s := &sender{chats: []string{"-1001"}, client: fc}
+
+	err := s.Send(types.EventNotification{
+		Message: "Successfully updated deployment default/wd 1.1.0->1.2.0",
+		Type:    types.NotificationDeploymentUpdate,
+		Level:   types.LevelSuccess,
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	expected := []sentMessage{{chat: "-1001", text: "[SUCCESS] deployment update\nSuccessfully updated deployment default/wd 1.1.0->1.2.0"}}
+	if !reflect.DeepEqual(fc.sent, expected) {
+		t.Errorf("unexpected messages: %+v", fc.sent)
+	}
+}
+
+func TestSendChannelOverrides(t *testing.T) {
+	fc := &fakeClient{}
+	s := &sender{chats: []string{"-1001"}, client: fc}
+
+	s.Send(types.EventNotification{
+		Message:  "hi",
+		Channels: []string{"devops", "-1002", "@keel_updates", "https://example.com/hook"},
+	})
+
+	var chats []string
+	for _, m := range fc.sent {
+		chats = append(chats, m.chat)
+	}
+	if !reflect.DeepEqual(chats, []string{"-1002", "@keel_updates"}) {
+		t.Errorf("unexpected chats: %v", chats)
+	}
+}
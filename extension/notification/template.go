@@ -0,0 +1,67 @@
+package notification
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+	"text/template"
+
+	"github.com/keel-hq/keel/types"
+)
+
+var commitSHARegexp = regexp.MustCompile(`(?i)(?:^|[^0-9a-f])([0-9a-f]{7,40})(?:$|[^0-9a-f])`)
+
+// TemplateFuncs - functions available in notification templates, ie:
+//
+//	{{ .Message }} {{ with commitSHA .Metadata.new }}https://github.com/org/repo/commit/{{ . }}{{ end }}
+var TemplateFuncs = template.FuncMap{
+	"upper":   strings.ToUpper,
+	"lower":   strings.ToLower,
+	"trim":    strings.TrimSpace,
+	"replace": strings.Replace,
+	"split":   strings.Split,
+	"join":    strings.Join,
+	"default": func(def, value string) string {
+		if value == "" {
+			return def
+		}
+		return value
+	},
+	// json - JSON encodes value, for templates producing JSON payloads
+	"json": func(v interface{}) (string, error) {
+		b, err := json.Marshal(v)
+		return string(b), err
+	},
+	// commitSHA - first commit hash like sequence in a tag, ie: "1.2.0-3f2a9c1"
+	"commitSHA": func(tag string) string {
+		m := commitSHARegexp.FindStringSubmatch(tag)
+		if len(m) < 2 {
+			return ""
+		}
+		return m[1]
+	},
+}
+
+// ParseTemplate - parses notification template with TemplateFuncs, defaultText
+// is used when text is empty
+func ParseTemplate(name, text, defaultText string) (*template.Template, error) {
+	if text == "" {
+		text = defaultText
+	}
+	tmpl, err := template.New(name).Funcs(TemplateFuncs).Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s template: %s", name, err)
+	}
+	return tmpl, nil
+}
+
+// RenderTemplate - executes template with the notification event
+func RenderTemplate(tmpl *template.Template, event types.EventNotification) (string, error) {
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, event); err != nil {
+		return "", fmt.Errorf("failed to render %s template: %s", tmpl.Name(), err)
+	}
+	return buf.String(), nil
+}
@@ -0,0 +1,67 @@
+package notification
+
+import (
+	"testing"
+
+	"github.com/keel-hq/keel/types"
+)
+
+func TestRenderTemplate(t *testing.T) {
+	event := types.EventNotification{
+		Name:    "update resource",
+		Message: "Successfully updated deployment default/wd",
+		Level:   types.LevelSuccess,
+		Metadata: map[string]string{
+			"new": "1.2.0-3f2a9c1",
+		},
+	}
+
+	tests := []struct {
+		name     string
+		template string
+		want     string
+	}{
+		{
+			name:     "fields",
+			template: "{{ .Level }}: {{ .Message }}",
+			want:     "success: Successfully updated deployment default/wd",
+		},
+		{
+			name:     "commit sha",
+			template: "{{ with commitSHA .Metadata.new }}https://github.com/org/repo/commit/{{ . }}{{ end }}",
+			want:     "https://github.com/org/repo/commit/3f2a9c1",
+		},
+		{
+			name:     "no commit sha",
+			template: "{{ commitSHA .Name | default \"none\" }}",
+			want:     "none",
+		},
+		{
+			name:     "json",
+			template: `{"text": {{ json .Message }}}`,
+			want:     `{"text": "Successfully updated deployment default/wd"}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tmpl, err := ParseTemplate(tt.name, tt.template, "")
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			got, err := RenderTemplate(tmpl, event)
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			if got != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseTemplateInvalid(t *testing.T) {
+	if _, err := ParseTemplate("message", "{{ .Message ", ""); err == nil {
+		t.Errorf("expected error for invalid template")
+	}
+}
@@ -7,6 +7,7 @@ import (
 	"net/http"
 	"net/url"
 	"os"
+	"text/template"
 	"time"
 
 	"github.com/keel-hq/keel/constants"
@@ -20,6 +21,7 @@ const timeout = 5 * time.Second
 
 type sender struct {
 	endpoint string
+	payload  *template.Template
 	client   *http.Client
 }
 
@@ -51,6 +53,14 @@ func (s *sender) Configure(config *notification.Config) (bool, error) {
 	}
 	s.endpoint = httpConfig.Endpoint
 
+	if text := os.Getenv(constants.EnvWebhookTemplate); text != "" {
+		tmpl, err := notification.ParseTemplate("webhook payload", text, "")
+		if err != nil {
+			return false, err
+		}
+		s.payload = tmpl
+	}
+
 	// Setup HTTP client.
 	s.client = &http.Client{
 		Transport: http.DefaultTransport,
@@ -70,21 +80,57 @@ type notificationEnvelope struct {
 }
 
 func (s *sender) Send(event types.EventNotification) error {
-	// Marshal notification.
+	body, err := s.body(event)
+	if err != nil {
+		return err
+	}
+
+	// per resource endpoints (keel.sh/notify) replace the default one
+	endpoints := event.Endpoints()
+	if len(endpoints) == 0 {
+		endpoints = []string{s.endpoint}
+	}
+
+	for _, endpoint := range endpoints {
+		err = s.post(endpoint, body)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// body - JSON encoded notification or the rendered payload template
+func (s *sender) body(event types.EventNotification) ([]byte, error) {
+	if s.payload != nil {
+		rendered, err := notification.RenderTemplate(s.payload, event)
+		if err != nil {
+			return nil, err
+		}
+		return []byte(rendered), nil
+	}
+
 	jsonNotification, err := json.Marshal(notificationEnvelope{event})
 	if err != nil {
-		return fmt.Errorf("could not marshal: %s", err)
+		return nil, fmt.Errorf("could not marshal: %s", err)
 	}
+	return jsonNotification, nil
+}
 
+func (s *sender) post(endpoint string, body []byte) error {
 	// Send notification via HTTP POST.
-	resp, err := s.client.Post(s.endpoint, "application/json", bytes.NewBuffer(jsonNotification))
-	if err != nil || resp == nil || (resp.StatusCode != 200 && resp.StatusCode != 201) {
-		if resp != nil {
-			return fmt.Errorf("got status %d, expected 200/201", resp.StatusCode)
-		}
+	resp, err := s.client.Post(endpoint, "application/json", bytes.NewBuffer(body))
+	if err != nil {
 		return err
 	}
 	defer resp.Body.Close()
 
+	// any 2xx response is a successful delivery, everything else is
+	// retried by the notification sender
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("got status %d, expected 2xx", resp.StatusCode)
+	}
+
 	return nil
 }
@@ -8,6 +8,7 @@ import (
 	"testing"
 	"time"
 
+	"github.com/keel-hq/keel/extension/notification"
 	"github.com/keel-hq/keel/types"
 )
 
@@ -57,3 +58,90 @@ func TestWebhookRequest(t *testing.T) {
 		Level:     types.LevelDebug,
 	})
 }
+
+func TestWebhookRequestFailedStatus(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(resp http.ResponseWriter, req *http.Request) {
+		resp.WriteHeader(http.StatusBadGateway)
+	}))
+	defer ts.Close()
+
+	s := &sender{
+		endpoint: ts.URL,
+		client:   &http.Client{},
+	}
+
+	err := s.Send(types.EventNotification{
+		Name:  "update deployment",
+		Type:  types.NotificationDeploymentUpdate,
+		Level: types.LevelError,
+	})
+	if err == nil {
+		t.Errorf("expected error on non-2xx response")
+	}
+}
+
+func TestWebhookRequestResourceEndpoints(t *testing.T) {
+	var defaultCalls, resourceCalls int
+
+	defaultTs := httptest.NewServer(http.HandlerFunc(func(resp http.ResponseWriter, req *http.Request) {
+		defaultCalls++
+	}))
+	defer defaultTs.Close()
+
+	resourceTs := httptest.NewServer(http.HandlerFunc(func(resp http.ResponseWriter, req *http.Request) {
+		resourceCalls++
+	}))
+	defer resourceTs.Close()
+
+	s := &sender{
+		endpoint: defaultTs.URL,
+		client:   &http.Client{},
+	}
+
+	err := s.Send(types.EventNotification{
+		Name:     "update deployment",
+		Type:     types.NotificationDeploymentUpdate,
+		Level:    types.LevelSuccess,
+		Channels: []string{"chan1", resourceTs.URL},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if defaultCalls != 0 || resourceCalls != 1 {
+		t.Errorf("expected notification to be routed to resource endpoint only, default: %d, resource: %d", defaultCalls, resourceCalls)
+	}
+}
+
+func TestWebhookPayloadTemplate(t *testing.T) {
+	var body string
+	ts := httptest.NewServer(http.HandlerFunc(func(resp http.ResponseWriter, req *http.Request) {
+		b, _ := ioutil.ReadAll(req.Body)
+		body = string(b)
+	}))
+	defer ts.Close()
+
+	tmpl, err := notification.ParseTemplate("webhook payload", `{"text": {{ json .Message }}, "dashboard": "https://grafana/d/{{ .Metadata.name }}"}`, "")
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	s := &sender{
+		endpoint: ts.URL,
+		payload:  tmpl,
+		client:   &http.Client{},
+	}
+
+	err = s.Send(types.EventNotification{
+		Message:  "updated \"wd\"",
+		Metadata: map[string]string{"name": "wd"},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	expected := `{"text": "updated \"wd\"", "dashboard": "https://grafana/d/wd"}`
+	if body != expected {
+		t.Errorf("unexpected body: %s", body)
+	}
+}
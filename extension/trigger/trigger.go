@@ -0,0 +1,189 @@
+// Package trigger is the extension point for custom event sources. Compiled-in
+// triggers register themselves with RegisterTrigger (usually from init, like
+// notification senders) and feed events in the generic Event schema. Sidecar
+// plugins submit the same schema over the gRPC admin API (SubmitEvent in
+// pkg/rpc/keel.proto).
+package trigger
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/keel-hq/keel/types"
+	"github.com/keel-hq/keel/util/image"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	log "github.com/sirupsen/logrus"
+)
+
+var customTriggerEventsCounter = prometheus.NewCounterVec(
+	prometheus.CounterOpts{
+		Name: "custom_trigger_events_total",
+		Help: "How many events custom triggers submitted, partitioned by trigger.",
+	},
+	[]string{"trigger"},
+)
+
+func init() {
+	prometheus.MustRegister(customTriggerEventsCounter)
+}
+
+var (
+	triggersM sync.RWMutex
+	triggers  = make(map[string]Trigger)
+)
+
+// Event - generic event schema of custom triggers
+type Event struct {
+	// Image with the new tag, ie: registry.example.com/app:1.4.0
+	Image string `json:"image"`
+	// Digest - optional digest of the new tag
+	Digest string `json:"digest,omitempty"`
+	// Target - optional resource identifier (ie: deployment/default/app),
+	// forces the update of this resource only, regardless of its policy
+	Target string `json:"target,omitempty"`
+}
+
+// ToEvent - converts the generic event into provider event, source is
+// reported as the event trigger
+func (e *Event) ToEvent(source string) (*types.Event, error) {
+	if source == "" {
+		return nil, errors.New("event source cannot be empty")
+	}
+	img := strings.TrimSpace(e.Image)
+	// image without tag would be parsed as latest
+	if !strings.Contains(img[strings.LastIndex(img, "/")+1:], ":") {
+		return nil, fmt.Errorf("image '%s' has no tag", e.Image)
+	}
+	ref, err := image.Parse(img)
+	if err != nil {
+		return nil, fmt.Errorf("invalid image '%s': %s", e.Image, err)
+	}
+
+	event := &types.Event{
+		CreatedAt:   time.Now(),
+		TriggerName: source,
+		Target:      e.Target,
+	}
+	event.Repository.Name = ref.Repository()
+	event.Repository.Tag = ref.Tag()
+	event.Repository.Digest = e.Digest
+	return event, nil
+}
+
+// Submitter - receives converted events, ie: provider.Providers
+type Submitter interface {
+	Submit(event types.Event) error
+}
+
+// SubmitFunc - submits trigger event
+type SubmitFunc func(event *Event) error
+
+// Trigger - compiled-in custom event source
+type Trigger interface {
+	// Configure - returns whether the trigger is enabled, usually based on
+	// environment variables
+	Configure() (bool, error)
+	// Start - submits events until context is cancelled
+	Start(ctx context.Context, submit SubmitFunc) error
+}
+
+// RegisterTrigger makes a Trigger available by the provided name, the name is
+// reported as the trigger of its events.
+//
+// If called twice with the same name, the name is blank, or if the provided
+// Trigger is nil, this function panics.
+func RegisterTrigger(name string, t Trigger) {
+	if name == "" {
+		panic("trigger: could not register a Trigger with an empty name")
+	}
+
+	if t == nil {
+		panic("trigger: could not register a nil Trigger")
+	}
+
+	triggersM.Lock()
+	defer triggersM.Unlock()
+
+	if _, dup := triggers[name]; dup {
+		panic("trigger: RegisterTrigger called twice for " + name)
+	}
+
+	log.WithFields(log.Fields{
+		"name": name,
+	}).Debug("extension.trigger: trigger registered")
+
+	triggers[name] = t
+}
+
+// Triggers - names of registered triggers
+func Triggers() []string {
+	triggersM.RLock()
+	defer triggersM.RUnlock()
+
+	var names []string
+	for name := range triggers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Submit - submits converted custom trigger event
+func Submit(submitter Submitter, event *types.Event) error {
+	customTriggerEventsCounter.With(prometheus.Labels{"trigger": event.TriggerName}).Inc()
+	return submitter.Submit(*event)
+}
+
+// Start - configures registered triggers and starts the enabled ones, returns
+// names of the started triggers
+func Start(ctx context.Context, submitter Submitter) []string {
+	triggersM.RLock()
+	defer triggersM.RUnlock()
+
+	var started []string
+	for name, t := range triggers {
+		configured, err := t.Configure()
+		if err != nil {
+			log.WithFields(log.Fields{
+				"error":   err,
+				"trigger": name,
+			}).Error("extension.trigger: failed to configure trigger")
+			continue
+		}
+		if !configured {
+			continue
+		}
+
+		name, t := name, t
+		submit := func(e *Event) error {
+			event, err := e.ToEvent(name)
+			if err != nil {
+				return err
+			}
+			return Submit(submitter, event)
+		}
+		go func() {
+			err := t.Start(ctx, submit)
+			if err != nil {
+				log.WithFields(log.Fields{
+					"error":   err,
+					"trigger": name,
+				}).Error("extension.trigger: trigger stopped")
+			}
+		}()
+
+		log.WithFields(log.Fields{
+			"trigger": name,
+		}).Info("extension.trigger: trigger started")
+		started = append(started, name)
+	}
+	sort.Strings(started)
+	return started
+}
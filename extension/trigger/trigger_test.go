@@ -0,0 +1,76 @@
+package trigger
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/keel-hq/keel/types"
+)
+
+type fakeSubmitter struct {
+	events chan types.Event
+}
+
+func (s *fakeSubmitter) Submit(event types.Event) error {
+	s.events <- event
+	return nil
+}
+
+type fakeTrigger struct {
+	enabled bool
+	event   *Event
+}
+
+func (t *fakeTrigger) Configure() (bool, error) {
+	return t.enabled, nil
+}
+
+func (t *fakeTrigger) Start(ctx context.Context, submit SubmitFunc) error {
+	return submit(t.event)
+}
+
+func TestToEvent(t *testing.T) {
+	e := &Event{Image: "registry.example.com:5000/team/app:1.4.0", Digest: "sha256:abc", Target: "deployment/default/app"}
+
+	event, err := e.ToEvent("release-bot")
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if event.Repository.Name != "registry.example.com:5000/team/app" || event.Repository.Tag != "1.4.0" || event.Repository.Digest != "sha256:abc" {
+		t.Errorf("unexpected repository: %v", event.Repository)
+	}
+	if event.TriggerName != "release-bot" || event.Target != "deployment/default/app" {
+		t.Errorf("unexpected trigger or target: %s, %s", event.TriggerName, event.Target)
+	}
+
+	for _, img := range []string{"", "registry.example.com:5000/team/app", "app"} {
+		if _, err := (&Event{Image: img}).ToEvent("release-bot"); err == nil {
+			t.Errorf("expected error for image '%s'", img)
+		}
+	}
+
+	if _, err := e.ToEvent(""); err == nil {
+		t.Errorf("expected error for empty source")
+	}
+}
+
+func TestStart(t *testing.T) {
+	RegisterTrigger("test-enabled", &fakeTrigger{enabled: true, event: &Event{Image: "app:1.1.0"}})
+	RegisterTrigger("test-disabled", &fakeTrigger{event: &Event{Image: "app:1.2.0"}})
+
+	submitter := &fakeSubmitter{events: make(chan types.Event, 2)}
+	started := Start(context.Background(), submitter)
+	if len(started) != 1 || started[0] != "test-enabled" {
+		t.Fatalf("unexpected started triggers: %v", started)
+	}
+
+	select {
+	case event := <-submitter.events:
+		if event.TriggerName != "test-enabled" || event.Repository.Tag != "1.1.0" {
+			t.Errorf("unexpected event: %v", event)
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("expected event from enabled trigger")
+	}
+}
@@ -0,0 +1,110 @@
+package history
+
+import (
+	"encoding/json"
+	"sync"
+	"time"
+
+	"github.com/keel-hq/keel/cache"
+	"github.com/keel-hq/keel/types"
+)
+
+// DefaultLimit - number of entries kept per resource
+const DefaultLimit = 20
+
+const keyPrefix = "history/"
+
+// History - keeps last actions keel took on each resource in the cache so
+// they survive restarts
+type History struct {
+	mu    sync.Mutex
+	cache cache.Cache
+	limit int
+}
+
+// New - create new history, limit is the number of entries kept per resource
+func New(c cache.Cache, limit int) *History {
+	if limit <= 0 {
+		limit = DefaultLimit
+	}
+	return &History{
+		cache: c,
+		limit: limit,
+	}
+}
+
+func key(cluster, identifier string) string {
+	if cluster != "" {
+		return keyPrefix + cluster + "/" + identifier
+	}
+	return keyPrefix + identifier
+}
+
+// Record - appends entry to the resource history, oldest entries are dropped
+// once the limit is reached. Entry repeating the last one (same action and
+// versions, ie: update detected again by the next poll) is skipped
+func (h *History) Record(entry *types.HistoryEntry) error {
+	if entry.CreatedAt.IsZero() {
+		entry.CreatedAt = time.Now()
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	k := key(entry.Cluster, entry.Identifier)
+	entries, err := h.get(k)
+	if err != nil {
+		return err
+	}
+
+	if n := len(entries); n > 0 {
+		last := entries[n-1]
+		if last.Action == entry.Action && last.CurrentVersion == entry.CurrentVersion && last.NewVersion == entry.NewVersion {
+			return nil
+		}
+	}
+
+	entries = append(entries, entry)
+	if len(entries) > h.limit {
+		entries = entries[len(entries)-h.limit:]
+	}
+
+	bts, err := json.Marshal(entries)
+	if err != nil {
+		return err
+	}
+	return h.cache.Put(k, bts)
+}
+
+// List - resource history, newest entry first
+func (h *History) List(cluster, identifier string) ([]*types.HistoryEntry, error) {
+	h.mu.Lock()
+	entries, err := h.get(key(cluster, identifier))
+	h.mu.Unlock()
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]*types.HistoryEntry, 0, len(entries))
+	for i := len(entries) - 1; i >= 0; i-- {
+		result = append(result, entries[i])
+	}
+	return result, nil
+}
+
+func (h *History) get(k string) ([]*types.HistoryEntry, error) {
+	bts, err := h.cache.Get(k)
+	if err == cache.ErrNotFound {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var entries []*types.HistoryEntry
+	err = json.Unmarshal(bts, &entries)
+	if err != nil {
+		return nil, err
+	}
+	return entries, nil
+}
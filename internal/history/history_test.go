@@ -0,0 +1,81 @@
+package history
+
+import (
+	"testing"
+
+	"github.com/keel-hq/keel/cache/memory"
+	"github.com/keel-hq/keel/types"
+)
+
+func TestRecordKeepsLastEntries(t *testing.T) {
+	h := New(memory.New(), 2)
+
+	for _, action := range []string{types.HistoryActionDetected, types.HistoryActionApproved, types.HistoryActionApplied} {
+		err := h.Record(&types.HistoryEntry{
+			Action:     action,
+			Identifier: "deployment/default/foo",
+			NewVersion: "1.1.0",
+		})
+		if err != nil {
+			t.Fatalf("failed to record entry: %s", err)
+		}
+	}
+
+	entries, err := h.List("", "deployment/default/foo")
+	if err != nil {
+		t.Fatalf("failed to list entries: %s", err)
+	}
+	if len(entries) != 2 {
+		t.Fatalf("expected 2 entries, got: %d", len(entries))
+	}
+	if entries[0].Action != types.HistoryActionApplied || entries[1].Action != types.HistoryActionApproved {
+		t.Errorf("expected newest entries first, got: %s, %s", entries[0].Action, entries[1].Action)
+	}
+	if entries[0].CreatedAt.IsZero() {
+		t.Errorf("expected creation time to be set")
+	}
+}
+
+func TestListSeparatesClusters(t *testing.T) {
+	h := New(memory.New(), 0)
+
+	h.Record(&types.HistoryEntry{Action: types.HistoryActionApplied, Identifier: "deployment/default/foo"})
+	h.Record(&types.HistoryEntry{Action: types.HistoryActionFailed, Cluster: "eu", Identifier: "deployment/default/foo"})
+
+	entries, err := h.List("eu", "deployment/default/foo")
+	if err != nil {
+		t.Fatalf("failed to list entries: %s", err)
+	}
+	if len(entries) != 1 || entries[0].Action != types.HistoryActionFailed {
+		t.Errorf("unexpected entries: %+v", entries)
+	}
+
+	entries, err = h.List("", "deployment/default/bar")
+	if err != nil {
+		t.Fatalf("failed to list entries: %s", err)
+	}
+	if len(entries) != 0 {
+		t.Errorf("expected no entries, got: %d", len(entries))
+	}
+}
+
+func TestRecordSkipsRepeatedEntry(t *testing.T) {
+	h := New(memory.New(), 0)
+
+	for i := 0; i < 3; i++ {
+		h.Record(&types.HistoryEntry{
+			Action:         types.HistoryActionDetected,
+			Identifier:     "deployment/default/foo",
+			CurrentVersion: "1.0.0",
+			NewVersion:     "1.1.0",
+		})
+	}
+
+	entries, err := h.List("", "deployment/default/foo")
+	if err != nil {
+		t.Fatalf("failed to list entries: %s", err)
+	}
+	if len(entries) != 1 {
+		t.Errorf("expected repeated entries to be skipped, got: %d", len(entries))
+	}
+}
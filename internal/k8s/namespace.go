@@ -0,0 +1,69 @@
+package k8s
+
+import (
+	"reflect"
+	"strings"
+	"sync"
+
+	"k8s.io/api/core/v1"
+)
+
+// NamespaceDefaults - keel.sh/ annotations of namespaces, used as defaults by
+// workloads in the namespace, ie: keel.sh/policy=minor on team-a namespace
+// applies to all team-a workloads that don't set their own policy
+type NamespaceDefaults struct {
+	mu         sync.RWMutex
+	namespaces map[string]map[string]string
+}
+
+// namespaceSettings - keel settings set on the namespace
+func namespaceSettings(ns *v1.Namespace) map[string]string {
+	settings := make(map[string]string)
+	for k, v := range ns.GetAnnotations() {
+		if strings.HasPrefix(k, "keel.sh/") {
+			settings[k] = v
+		}
+	}
+	return settings
+}
+
+// Set stores namespace settings, returns whether they changed
+func (c *NamespaceDefaults) Set(ns *v1.Namespace) bool {
+	settings := namespaceSettings(ns)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	existing := c.namespaces[ns.Name]
+	if len(existing) == 0 && len(settings) == 0 {
+		return false
+	}
+	if reflect.DeepEqual(existing, settings) {
+		return false
+	}
+	if c.namespaces == nil {
+		c.namespaces = make(map[string]map[string]string)
+	}
+	if len(settings) == 0 {
+		delete(c.namespaces, ns.Name)
+	} else {
+		c.namespaces[ns.Name] = settings
+	}
+	return true
+}
+
+// Remove removes namespace settings, returns whether there were any
+func (c *NamespaceDefaults) Remove(name string) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	_, ok := c.namespaces[name]
+	delete(c.namespaces, name)
+	return ok
+}
+
+// Annotations - default settings of workloads in the namespace
+func (c *NamespaceDefaults) Annotations(namespace string) map[string]string {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.namespaces[namespace]
+}
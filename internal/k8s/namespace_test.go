@@ -0,0 +1,96 @@
+package k8s
+
+import (
+	"testing"
+
+	"github.com/sirupsen/logrus"
+	core_v1 "k8s.io/api/core/v1"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func newTestNamespace(name string, annotations map[string]string) *core_v1.Namespace {
+	return &core_v1.Namespace{
+		ObjectMeta: meta_v1.ObjectMeta{
+			Name:        name,
+			Annotations: annotations,
+		},
+	}
+}
+
+func TestTranslatorNamespaceDefaults(t *testing.T) {
+	tr := &Translator{FieldLogger: logrus.New()}
+
+	d := newTestDeployment("gcr.io/v2-namespace/hello-world:1.1.1", 1)
+	d.Labels = map[string]string{"app": "web"}
+	tr.OnAdd(d)
+
+	d2 := newTestDeployment("gcr.io/v2-namespace/hello-world:1.1.1", 1)
+	d2.Name = "dep-2"
+	d2.Labels = map[string]string{"keel.sh/policy": "patch"}
+	tr.OnAdd(d2)
+
+	ns := newTestNamespace("xxxx", map[string]string{
+		"keel.sh/policy":  "minor",
+		"keel.sh/trigger": "poll",
+		"owner":           "team-a",
+	})
+	tr.OnAdd(ns)
+
+	settings := map[string]map[string]string{}
+	for _, gr := range tr.Values() {
+		settings[gr.Name] = gr.GetKeelAnnotations()
+	}
+	if settings["dep-1"]["keel.sh/policy"] != "minor" || settings["dep-1"]["keel.sh/trigger"] != "poll" {
+		t.Errorf("expected namespace defaults, got: %v", settings["dep-1"])
+	}
+	if _, ok := settings["dep-1"]["owner"]; ok {
+		t.Errorf("expected only keel settings from namespace")
+	}
+	if _, ok := settings["dep-2"]["keel.sh/policy"]; ok {
+		t.Errorf("expected resource label to take precedence, got: %v", settings["dep-2"])
+	}
+	if settings["dep-2"]["keel.sh/trigger"] != "poll" {
+		t.Errorf("expected trigger from namespace, got: %v", settings["dep-2"])
+	}
+
+	// KeelPolicy overrides namespace defaults
+	tr.OnAdd(newTestPolicy("prod", map[string]interface{}{
+		"selector": map[string]interface{}{},
+		"policy":   "major",
+	}))
+	for _, gr := range tr.Values() {
+		if gr.Name == "dep-1" && gr.GetKeelAnnotations()["keel.sh/policy"] != "major" {
+			t.Errorf("expected policy from KeelPolicy, got: %s", gr.GetKeelAnnotations()["keel.sh/policy"])
+		}
+	}
+
+	tr.OnDelete(ns)
+	for _, gr := range tr.Values() {
+		if _, ok := gr.GetKeelAnnotations()["keel.sh/trigger"]; ok {
+			t.Errorf("expected namespace defaults to be removed from %s", gr.Identifier)
+		}
+	}
+}
+
+func TestNamespaceDefaultsSet(t *testing.T) {
+	var c NamespaceDefaults
+
+	if c.Set(newTestNamespace("team-a", map[string]string{"owner": "a"})) {
+		t.Errorf("namespace without keel settings shouldn't change defaults")
+	}
+	if !c.Set(newTestNamespace("team-a", map[string]string{"keel.sh/policy": "minor"})) {
+		t.Errorf("expected defaults to change")
+	}
+	if c.Set(newTestNamespace("team-a", map[string]string{"keel.sh/policy": "minor", "owner": "a"})) {
+		t.Errorf("unrelated annotations shouldn't change defaults")
+	}
+	if c.Annotations("team-a")["keel.sh/policy"] != "minor" {
+		t.Errorf("unexpected defaults: %v", c.Annotations("team-a"))
+	}
+	if !c.Set(newTestNamespace("team-a", nil)) {
+		t.Errorf("expected defaults to be removed")
+	}
+	if len(c.Annotations("team-a")) != 0 {
+		t.Errorf("unexpected defaults: %v", c.Annotations("team-a"))
+	}
+}
@@ -0,0 +1,215 @@
+package k8s
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	v1beta1 "k8s.io/api/batch/v1beta1"
+	core_v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	k8s_types "k8s.io/apimachinery/pkg/types"
+)
+
+// ErrNoOriginal - resource wasn't copied from the cache, changes can't be
+// computed and the whole resource has to be updated
+var ErrNoOriginal = errors.New("original resource is not known")
+
+// imageChange - container image changed by keel
+type imageChange struct {
+	field    string // containers or initContainers
+	index    int
+	name     string
+	previous string
+	image    string
+}
+
+// Patch - changes made to the resource since it was read from the cluster.
+// Only container images, labels and annotations are ever patched so fields
+// added by other controllers and admission webhooks are left untouched.
+// Built-in kinds get a strategic merge patch that matches containers by name,
+// custom resources a JSON patch that checks containers didn't change in the
+// meantime. Patch is nil when nothing changed.
+func (r *GenericResource) Patch() (k8s_types.PatchType, []byte, error) {
+	if r.original == nil {
+		return "", nil, ErrNoOriginal
+	}
+	original := &GenericResource{obj: r.original}
+
+	containers, err := imageChanges("containers", original.Containers(), r.Containers())
+	if err != nil {
+		return "", nil, err
+	}
+	initContainers, err := imageChanges("initContainers", original.InitContainers(), r.InitContainers())
+	if err != nil {
+		return "", nil, err
+	}
+	images := append(containers, initContainers...)
+
+	labels := mapChanges(original.GetLabels(), r.GetLabels())
+	annotations := mapChanges(original.GetAnnotations(), r.GetAnnotations())
+	specAnnotations := mapChanges(original.GetSpecAnnotations(), r.GetSpecAnnotations())
+
+	if len(images) == 0 && len(labels) == 0 && len(annotations) == 0 && len(specAnnotations) == 0 {
+		return "", nil, nil
+	}
+
+	if u, ok := r.obj.(*unstructured.Unstructured); ok {
+		ops := jsonPatch(r.original.(*unstructured.Unstructured), u, images, labels, annotations, specAnnotations)
+		data, err := json.Marshal(ops)
+		return k8s_types.JSONPatchType, data, err
+	}
+
+	// CronJob spec annotations are job template annotations
+	podSpecPath := []string{"spec", "template", "spec"}
+	specAnnotationsPath := []string{"spec", "template", "metadata", "annotations"}
+	if _, ok := r.obj.(*v1beta1.CronJob); ok {
+		podSpecPath = []string{"spec", "jobTemplate", "spec", "template", "spec"}
+		specAnnotationsPath = []string{"spec", "jobTemplate", "metadata", "annotations"}
+	}
+
+	patch := make(map[string]interface{})
+	if len(labels) > 0 {
+		setPatchField(patch, labels, "metadata", "labels")
+	}
+	if len(annotations) > 0 {
+		setPatchField(patch, annotations, "metadata", "annotations")
+	}
+	if len(specAnnotations) > 0 {
+		setPatchField(patch, specAnnotations, specAnnotationsPath...)
+	}
+	byField := make(map[string][]interface{})
+	for _, change := range images {
+		byField[change.field] = append(byField[change.field], map[string]interface{}{
+			"name":  change.name,
+			"image": change.image,
+		})
+	}
+	for field, entries := range byField {
+		setPatchField(patch, entries, append(append([]string{}, podSpecPath...), field)...)
+	}
+
+	data, err := json.Marshal(patch)
+	return k8s_types.StrategicMergePatchType, data, err
+}
+
+// imageChanges - containers whose image changed, keel never adds, removes or
+// reorders containers
+func imageChanges(field string, original, modified []core_v1.Container) ([]imageChange, error) {
+	if len(original) != len(modified) {
+		return nil, fmt.Errorf("%s were added or removed, only images can be changed", field)
+	}
+
+	var changes []imageChange
+	for idx := range modified {
+		if original[idx].Name != modified[idx].Name {
+			return nil, fmt.Errorf("%s were renamed or reordered, only images can be changed", field)
+		}
+		if original[idx].Image == modified[idx].Image {
+			continue
+		}
+		changes = append(changes, imageChange{
+			field:    field,
+			index:    idx,
+			name:     modified[idx].Name,
+			previous: original[idx].Image,
+			image:    modified[idx].Image,
+		})
+	}
+	return changes, nil
+}
+
+// mapChanges - added or changed keys, removed keys are set to nil
+func mapChanges(original, modified map[string]string) map[string]interface{} {
+	changes := make(map[string]interface{})
+	for k, v := range modified {
+		if o, ok := original[k]; !ok || o != v {
+			changes[k] = v
+		}
+	}
+	for k := range original {
+		if _, ok := modified[k]; !ok {
+			changes[k] = nil
+		}
+	}
+	return changes
+}
+
+func setPatchField(patch map[string]interface{}, value interface{}, path ...string) {
+	m := patch
+	for _, field := range path[:len(path)-1] {
+		next, ok := m[field].(map[string]interface{})
+		if !ok {
+			next = make(map[string]interface{})
+			m[field] = next
+		}
+		m = next
+	}
+	m[path[len(path)-1]] = value
+}
+
+// jsonPatchOp - RFC 6902 operation
+type jsonPatchOp struct {
+	Op    string      `json:"op"`
+	Path  string      `json:"path"`
+	Value interface{} `json:"value,omitempty"`
+}
+
+func jsonPatch(original, modified *unstructured.Unstructured, images []imageChange, labels, annotations, specAnnotations map[string]interface{}) []jsonPatchOp {
+	var ops []jsonPatchOp
+
+	for _, change := range images {
+		path := fmt.Sprintf("/spec/template/spec/%s/%d", change.field, change.index)
+		if change.name != "" {
+			ops = append(ops, jsonPatchOp{Op: "test", Path: path + "/name", Value: change.name})
+		}
+		ops = append(ops,
+			jsonPatchOp{Op: "test", Path: path + "/image", Value: change.previous},
+			jsonPatchOp{Op: "replace", Path: path + "/image", Value: change.image},
+		)
+	}
+
+	mapOps := func(changes map[string]interface{}, exists bool, modified map[string]string, path string) {
+		if len(changes) == 0 {
+			return
+		}
+		if !exists {
+			ops = append(ops, jsonPatchOp{Op: "add", Path: path, Value: modified})
+			return
+		}
+		keys := make([]string, 0, len(changes))
+		for k := range changes {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			v := changes[k]
+			if v == nil {
+				ops = append(ops, jsonPatchOp{Op: "remove", Path: path + "/" + escapeJSONPointer(k)})
+			} else {
+				ops = append(ops, jsonPatchOp{Op: "add", Path: path + "/" + escapeJSONPointer(k), Value: v})
+			}
+		}
+	}
+
+	mapOps(labels, original.GetLabels() != nil, modified.GetLabels(), "/metadata/labels")
+	mapOps(annotations, original.GetAnnotations() != nil, modified.GetAnnotations(), "/metadata/annotations")
+
+	if len(specAnnotations) > 0 {
+		if _, found, _ := unstructured.NestedMap(original.Object, "spec", "template", "metadata"); !found {
+			ops = append(ops, jsonPatchOp{Op: "add", Path: "/spec/template/metadata", Value: map[string]interface{}{
+				"annotations": getUnstructuredSpecAnnotations(modified),
+			}})
+		} else {
+			mapOps(specAnnotations, getUnstructuredSpecAnnotations(original) != nil, getUnstructuredSpecAnnotations(modified), "/spec/template/metadata/annotations")
+		}
+	}
+
+	return ops
+}
+
+func escapeJSONPointer(s string) string {
+	return strings.Replace(strings.Replace(s, "~", "~0", -1), "/", "~1", -1)
+}
@@ -0,0 +1,165 @@
+package k8s
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	apps_v1 "k8s.io/api/apps/v1"
+	core_v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/strategicpatch"
+)
+
+func TestPatch(t *testing.T) {
+	d := newTestDeployment("gcr.io/v2-namespace/hello-world:1.1.1", 1)
+	d.Spec.Template.Spec.Containers[0].Name = "app"
+
+	gr, err := NewGenericResource(d)
+	if err != nil {
+		t.Fatalf("failed to create generic resource: %s", err)
+	}
+	if _, _, err := gr.Patch(); err != ErrNoOriginal {
+		t.Errorf("expected resource not copied from cache to have no original, got: %v", err)
+	}
+
+	updated := gr.DeepCopy()
+	_, patch, err := updated.Patch()
+	if err != nil {
+		t.Fatalf("failed to get patch: %s", err)
+	}
+	if patch != nil {
+		t.Errorf("expected no patch for unchanged resource, got: %s", patch)
+	}
+
+	updated.UpdateContainer(0, "gcr.io/v2-namespace/hello-world:1.1.2")
+	annotations := updated.GetAnnotations()
+	annotations["kubernetes.io/change-cause"] = "keel automated update"
+	updated.SetAnnotations(annotations)
+
+	patchType, patch, err := updated.Patch()
+	if err != nil {
+		t.Fatalf("failed to get patch: %s", err)
+	}
+	if patchType != "application/strategic-merge-patch+json" {
+		t.Errorf("unexpected patch type: %s", patchType)
+	}
+
+	var p map[string]interface{}
+	if err := json.Unmarshal(patch, &p); err != nil {
+		t.Fatalf("invalid patch: %s", err)
+	}
+	if _, ok := p["status"]; ok {
+		t.Errorf("patch shouldn't contain status: %s", patch)
+	}
+	spec := p["spec"].(map[string]interface{})
+	if _, ok := spec["replicas"]; ok {
+		t.Errorf("patch shouldn't contain replicas: %s", patch)
+	}
+	containers := spec["template"].(map[string]interface{})["spec"].(map[string]interface{})["containers"].([]interface{})
+	container := containers[0].(map[string]interface{})
+	if len(container) != 2 || container["name"] != "app" || container["image"] != "gcr.io/v2-namespace/hello-world:1.1.2" {
+		t.Errorf("unexpected container patch: %v", container)
+	}
+	metadata := p["metadata"].(map[string]interface{})
+	if metadata["annotations"].(map[string]interface{})["kubernetes.io/change-cause"] != "keel automated update" {
+		t.Errorf("unexpected metadata patch: %v", metadata)
+	}
+
+	// copies of copies keep the resource read from the cluster
+	if _, patch, _ = updated.DeepCopy().Patch(); patch == nil {
+		t.Errorf("expected copy to keep changes")
+	}
+}
+
+func TestPatchKeepsConcurrentChanges(t *testing.T) {
+	d := newTestDeployment("gcr.io/v2-namespace/hello-world:1.1.1", 1)
+	d.Spec.Template.Spec.Containers[0].Name = "app"
+	gr, _ := NewGenericResource(d)
+
+	updated := gr.DeepCopy()
+	updated.UpdateContainer(0, "gcr.io/v2-namespace/hello-world:1.1.2")
+	_, patch, err := updated.Patch()
+	if err != nil {
+		t.Fatalf("failed to get patch: %s", err)
+	}
+
+	// admission webhook injected a sidecar and env, HPA scaled the deployment
+	live := d.DeepCopy()
+	live.Spec.Replicas = func(i int32) *int32 { return &i }(5)
+	live.Spec.Template.Spec.Containers[0].Env = []core_v1.EnvVar{{Name: "INJECTED", Value: "1"}}
+	live.Spec.Template.Spec.Containers = append([]core_v1.Container{{Name: "proxy", Image: "envoy:1.0"}}, live.Spec.Template.Spec.Containers...)
+	liveJSON, _ := json.Marshal(live)
+
+	patchedJSON, err := strategicpatch.StrategicMergePatch(liveJSON, patch, apps_v1.Deployment{})
+	if err != nil {
+		t.Fatalf("failed to apply patch: %s", err)
+	}
+	var patched apps_v1.Deployment
+	if err := json.Unmarshal(patchedJSON, &patched); err != nil {
+		t.Fatalf("failed to decode patched deployment: %s", err)
+	}
+
+	if *patched.Spec.Replicas != 5 {
+		t.Errorf("expected replicas to be kept, got: %d", *patched.Spec.Replicas)
+	}
+	containers := patched.Spec.Template.Spec.Containers
+	if len(containers) != 2 || containers[0].Image != "envoy:1.0" {
+		t.Fatalf("expected sidecar to be kept, got: %v", containers)
+	}
+	if containers[1].Image != "gcr.io/v2-namespace/hello-world:1.1.2" || len(containers[1].Env) != 1 {
+		t.Errorf("unexpected app container: %v", containers[1])
+	}
+}
+
+func TestPatchUnstructured(t *testing.T) {
+	gr, err := NewGenericResource(newTestArgoRollout("gcr.io/v2-namespace/hello-world:1.1.1"))
+	if err != nil {
+		t.Fatalf("failed to create generic resource: %s", err)
+	}
+
+	updated := gr.DeepCopy()
+	updated.UpdateContainer(0, "gcr.io/v2-namespace/hello-world:1.1.2")
+	updated.SetAnnotations(map[string]string{"kubernetes.io/change-cause": "keel"})
+
+	patchType, patch, err := updated.Patch()
+	if err != nil {
+		t.Fatalf("failed to get patch: %s", err)
+	}
+	if patchType != "application/json-patch+json" {
+		t.Errorf("unexpected patch type: %s", patchType)
+	}
+
+	var ops []map[string]interface{}
+	if err := json.Unmarshal(patch, &ops); err != nil {
+		t.Fatalf("invalid patch: %s", err)
+	}
+
+	var replaced, tested bool
+	for _, op := range ops {
+		switch {
+		case op["op"] == "test" && op["path"] == "/spec/template/spec/containers/0/image":
+			tested = op["value"] == "gcr.io/v2-namespace/hello-world:1.1.1"
+		case op["op"] == "replace" && op["path"] == "/spec/template/spec/containers/0/image":
+			replaced = op["value"] == "gcr.io/v2-namespace/hello-world:1.1.2"
+		case op["op"] == "test" && op["path"] == "/spec/template/spec/containers/0/name":
+		case op["op"] == "add" && strings.HasPrefix(op["path"].(string), "/metadata/annotations"):
+		default:
+			t.Errorf("unexpected operation: %v", op)
+		}
+	}
+	if !tested || !replaced {
+		t.Errorf("expected image to be tested and replaced, got: %s", patch)
+	}
+}
+
+func TestPatchRejectsContainerChanges(t *testing.T) {
+	gr, _ := NewGenericResource(newTestDeployment("gcr.io/v2-namespace/hello-world:1.1.1", 1))
+
+	updated := gr.DeepCopy()
+	d := updated.GetResource().(*apps_v1.Deployment)
+	d.Spec.Template.Spec.Containers = append(d.Spec.Template.Spec.Containers, core_v1.Container{Name: "extra", Image: "busybox"})
+
+	if _, _, err := updated.Patch(); err == nil {
+		t.Errorf("expected added container to be rejected")
+	}
+}
@@ -0,0 +1,166 @@
+package k8s
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/keel-hq/keel/types"
+
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// KeelPolicyKind - kind of the KeelPolicy custom resource
+const KeelPolicyKind = "KeelPolicy"
+
+// KeelPolicyResource - KeelPolicy custom resource, see deployment/keelpolicy-crd.yaml
+var KeelPolicyResource = schema.GroupVersionResource{
+	Group:    "keel.sh",
+	Version:  "v1alpha1",
+	Resource: "keelpolicies",
+}
+
+// KeelPolicySpec - settings for workloads selected by the policy
+type KeelPolicySpec struct {
+	Selector          *meta_v1.LabelSelector `json:"selector"`
+	Policy            string                 `json:"policy,omitempty"`
+	Trigger           string                 `json:"trigger,omitempty"`
+	PollSchedule      string                 `json:"pollSchedule,omitempty"`
+	Approvals         *int                   `json:"approvals,omitempty"`
+	ApprovalDeadline  string                 `json:"approvalDeadline,omitempty"`
+	ApprovalsChannel  string                 `json:"approvalsChannel,omitempty"`
+	Notify            string                 `json:"notify,omitempty"`
+	NotificationLevel string                 `json:"notificationLevel,omitempty"`
+	// Annotations - any other keel.sh/ settings, ie: keel.sh/updateWindow
+	Annotations map[string]string `json:"annotations,omitempty"`
+}
+
+// KeelPolicy - parsed KeelPolicy custom resource, applies to workloads
+// in its own namespace
+type KeelPolicy struct {
+	Namespace string
+	Name      string
+
+	selector    labels.Selector
+	annotations map[string]string
+}
+
+// NewKeelPolicy - parse KeelPolicy custom resource
+func NewKeelPolicy(obj *unstructured.Unstructured) (*KeelPolicy, error) {
+	var spec KeelPolicySpec
+	content, _ := obj.UnstructuredContent()["spec"].(map[string]interface{})
+	if content == nil {
+		return nil, fmt.Errorf("spec is missing")
+	}
+	err := runtime.DefaultUnstructuredConverter.FromUnstructured(content, &spec)
+	if err != nil {
+		return nil, fmt.Errorf("invalid spec: %s", err)
+	}
+
+	if spec.Selector == nil {
+		return nil, fmt.Errorf("selector is required, use an empty selector to select all workloads")
+	}
+
+	selector, err := meta_v1.LabelSelectorAsSelector(spec.Selector)
+	if err != nil {
+		return nil, fmt.Errorf("invalid selector: %s", err)
+	}
+
+	annotations := make(map[string]string)
+	for k, v := range spec.Annotations {
+		if !strings.HasPrefix(k, "keel.sh/") {
+			return nil, fmt.Errorf("annotation '%s' is not a keel setting", k)
+		}
+		annotations[k] = v
+	}
+
+	set := func(key, value string) {
+		if value != "" {
+			annotations[key] = value
+		}
+	}
+	set(types.KeelPolicyLabel, spec.Policy)
+	set(types.KeelTriggerLabel, spec.Trigger)
+	set(types.KeelPollScheduleAnnotation, spec.PollSchedule)
+	set(types.KeelApprovalDeadlineLabel, spec.ApprovalDeadline)
+	set(types.KeelApprovalsChannelAnnotation, spec.ApprovalsChannel)
+	set(types.KeelNotificationChanAnnotation, spec.Notify)
+	set(types.KeelNotificationLevelAnnotation, spec.NotificationLevel)
+	if spec.Approvals != nil {
+		annotations[types.KeelMinimumApprovalsLabel] = strconv.Itoa(*spec.Approvals)
+	}
+
+	return &KeelPolicy{
+		Namespace:   obj.GetNamespace(),
+		Name:        obj.GetName(),
+		selector:    selector,
+		annotations: annotations,
+	}, nil
+}
+
+// Matches - whether policy applies to the resource
+func (p *KeelPolicy) Matches(gr *GenericResource) bool {
+	return p.Namespace == gr.Namespace && p.selector.Matches(labels.Set(gr.GetLabels()))
+}
+
+// PolicyCache - KeelPolicy resources known to keel
+type PolicyCache struct {
+	mu       sync.RWMutex
+	policies map[string]*KeelPolicy
+}
+
+func policyKey(namespace, name string) string {
+	return namespace + "/" + name
+}
+
+// Add adds or replaces policy
+func (c *PolicyCache) Add(p *KeelPolicy) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.policies == nil {
+		c.policies = make(map[string]*KeelPolicy)
+	}
+	c.policies[policyKey(p.Namespace, p.Name)] = p
+}
+
+// Remove removes policy, no-op if policy is not present
+func (c *PolicyCache) Remove(namespace, name string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.policies, policyKey(namespace, name))
+}
+
+// Annotations - settings of all policies matching the resource, when several
+// policies set the same setting the one with the lowest name wins
+func (c *PolicyCache) Annotations(gr *GenericResource) map[string]string {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	var matching []*KeelPolicy
+	for _, p := range c.policies {
+		if p.Matches(gr) {
+			matching = append(matching, p)
+		}
+	}
+	if len(matching) == 0 {
+		return nil
+	}
+
+	sort.Slice(matching, func(i, j int) bool {
+		return matching[i].Name > matching[j].Name
+	})
+
+	annotations := make(map[string]string)
+	for _, p := range matching {
+		for k, v := range p.annotations {
+			annotations[k] = v
+		}
+	}
+	return annotations
+}
@@ -0,0 +1,172 @@
+package k8s
+
+import (
+	"testing"
+
+	"github.com/sirupsen/logrus"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func newTestPolicy(name string, spec map[string]interface{}) *unstructured.Unstructured {
+	return &unstructured.Unstructured{
+		Object: map[string]interface{}{
+			"apiVersion": "keel.sh/v1alpha1",
+			"kind":       KeelPolicyKind,
+			"metadata": map[string]interface{}{
+				"name":      name,
+				"namespace": "xxxx",
+			},
+			"spec": spec,
+		},
+	}
+}
+
+func TestNewKeelPolicy(t *testing.T) {
+	p, err := NewKeelPolicy(newTestPolicy("prod", map[string]interface{}{
+		"selector": map[string]interface{}{
+			"matchLabels": map[string]interface{}{"app": "web"},
+		},
+		"policy":       "minor",
+		"trigger":      "poll",
+		"pollSchedule": "@every 10m",
+		"approvals":    int64(2),
+		"notify":       "#deployments",
+		"annotations": map[string]interface{}{
+			"keel.sh/updateWindow": "Mon-Fri 09:00-17:00",
+		},
+	}))
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	expected := map[string]string{
+		"keel.sh/policy":       "minor",
+		"keel.sh/trigger":      "poll",
+		"keel.sh/pollSchedule": "@every 10m",
+		"keel.sh/approvals":    "2",
+		"keel.sh/notify":       "#deployments",
+		"keel.sh/updateWindow": "Mon-Fri 09:00-17:00",
+	}
+	if len(p.annotations) != len(expected) {
+		t.Errorf("unexpected annotations: %v", p.annotations)
+	}
+	for k, v := range expected {
+		if p.annotations[k] != v {
+			t.Errorf("expected %s=%s, got: %s", k, v, p.annotations[k])
+		}
+	}
+
+	gr, _ := NewGenericResource(newTestDeployment("gcr.io/v2-namespace/hello-world:1.1.1", 1))
+	if p.Matches(gr) {
+		t.Errorf("didn't expect policy to match deployment without app label")
+	}
+
+	d := newTestDeployment("gcr.io/v2-namespace/hello-world:1.1.1", 1)
+	d.Labels["app"] = "web"
+	gr, _ = NewGenericResource(d)
+	if !p.Matches(gr) {
+		t.Errorf("expected policy to match deployment")
+	}
+
+	d.Namespace = "other"
+	gr, _ = NewGenericResource(d)
+	if p.Matches(gr) {
+		t.Errorf("didn't expect policy to match deployment in another namespace")
+	}
+}
+
+func TestNewKeelPolicyInvalid(t *testing.T) {
+	_, err := NewKeelPolicy(newTestPolicy("no-selector", map[string]interface{}{
+		"policy": "minor",
+	}))
+	if err == nil {
+		t.Errorf("expected error for missing selector")
+	}
+
+	_, err = NewKeelPolicy(newTestPolicy("foreign-annotation", map[string]interface{}{
+		"selector": map[string]interface{}{},
+		"annotations": map[string]interface{}{
+			"kubernetes.io/change-cause": "foo",
+		},
+	}))
+	if err == nil {
+		t.Errorf("expected error for non keel annotation")
+	}
+}
+
+func TestTranslatorPolicies(t *testing.T) {
+	tr := &Translator{FieldLogger: logrus.New()}
+
+	d := newTestDeployment("gcr.io/v2-namespace/hello-world:1.1.1", 1)
+	d.Labels = map[string]string{"app": "web"}
+	d.Annotations = map[string]string{"keel.sh/trigger": "default"}
+	tr.OnAdd(d)
+
+	policy := newTestPolicy("prod", map[string]interface{}{
+		"selector": map[string]interface{}{},
+		"policy":   "major",
+		"trigger":  "poll",
+	})
+	tr.OnAdd(policy)
+
+	values := tr.Values()
+	if len(values) != 1 {
+		t.Fatalf("expected 1 resource, got: %d", len(values))
+	}
+
+	settings := values[0].GetKeelAnnotations()
+	if settings["keel.sh/policy"] != "major" {
+		t.Errorf("expected policy from KeelPolicy, got: %s", settings["keel.sh/policy"])
+	}
+	if settings["keel.sh/trigger"] != "default" {
+		t.Errorf("expected resource annotation to take precedence, got: %s", settings["keel.sh/trigger"])
+	}
+	if _, ok := values[0].GetAnnotations()["keel.sh/policy"]; ok {
+		t.Errorf("policy settings shouldn't be added to resource annotations")
+	}
+
+	// resources added after the policy get its settings too
+	d2 := newTestDeployment("gcr.io/v2-namespace/hello-world:1.1.1", 1)
+	d2.Name = "dep-2"
+	d2.Labels = map[string]string{}
+	tr.OnAdd(d2)
+
+	for _, gr := range tr.Values() {
+		if gr.GetKeelAnnotations()["keel.sh/policy"] != "major" {
+			t.Errorf("expected %s to have policy from KeelPolicy", gr.Identifier)
+		}
+	}
+
+	tr.OnDelete(policy)
+
+	for _, gr := range tr.Values() {
+		if _, ok := gr.GetKeelAnnotations()["keel.sh/policy"]; ok {
+			t.Errorf("expected policy settings to be removed from %s", gr.Identifier)
+		}
+	}
+}
+
+func TestPolicyCacheAnnotationsPrecedence(t *testing.T) {
+	var c PolicyCache
+
+	a, _ := NewKeelPolicy(newTestPolicy("a", map[string]interface{}{
+		"selector": map[string]interface{}{},
+		"policy":   "patch",
+	}))
+	b, _ := NewKeelPolicy(newTestPolicy("b", map[string]interface{}{
+		"selector": map[string]interface{}{},
+		"policy":   "major",
+		"trigger":  "poll",
+	}))
+	c.Add(b)
+	c.Add(a)
+
+	gr, _ := NewGenericResource(newTestDeployment("gcr.io/v2-namespace/hello-world:1.1.1", 1))
+	annotations := c.Annotations(gr)
+	if annotations["keel.sh/policy"] != "patch" {
+		t.Errorf("expected policy with the lowest name to win, got: %s", annotations["keel.sh/policy"])
+	}
+	if annotations["keel.sh/trigger"] != "poll" {
+		t.Errorf("expected settings to be merged, got: %s", annotations["keel.sh/trigger"])
+	}
+}
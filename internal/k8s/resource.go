@@ -8,6 +8,7 @@ import (
 	apps_v1 "k8s.io/api/apps/v1"
 	v1beta1 "k8s.io/api/batch/v1beta1"
 	core_v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 )
 
 // GenericResource - generic resource,
@@ -16,6 +17,14 @@ type GenericResource struct {
 	// original resource
 	obj interface{}
 
+	// resource as read from the cluster, set on copies so changes made to
+	// them can be sent as a patch, see Patch
+	original interface{}
+
+	// settings from matching KeelPolicy resources and namespace defaults,
+	// never written back to the original resource
+	policyAnnotations map[string]string
+
 	Identifier string
 	Namespace  string
 	Name       string
@@ -38,11 +47,15 @@ func (c genericResource) Less(i, j int) bool {
 // NewGenericResource - create new generic k8s resource
 func NewGenericResource(obj interface{}) (*GenericResource, error) {
 
-	switch obj.(type) {
+	switch o := obj.(type) {
 	case *apps_v1.Deployment, *apps_v1.StatefulSet, *apps_v1.DaemonSet:
 		// ok
 	case *v1beta1.CronJob:
 		// ok
+	case *unstructured.Unstructured:
+		if !IsWorkload(o) {
+			return nil, fmt.Errorf("unsupported resource kind: %s", o.GetKind())
+		}
 	default:
 		return nil, fmt.Errorf("unsupported resource type: %v", reflect.TypeOf(obj).Kind())
 	}
@@ -72,6 +85,18 @@ func (r *GenericResource) DeepCopy() *GenericResource {
 	gr.Namespace = r.Namespace
 	gr.Name = r.Name
 
+	gr.original = r.original
+	if gr.original == nil {
+		gr.original = r.obj
+	}
+
+	if r.policyAnnotations != nil {
+		gr.policyAnnotations = make(map[string]string, len(r.policyAnnotations))
+		for k, v := range r.policyAnnotations {
+			gr.policyAnnotations[k] = v
+		}
+	}
+
 	switch obj := r.obj.(type) {
 	case *apps_v1.Deployment:
 		gr.obj = obj.DeepCopy()
@@ -81,6 +106,8 @@ func (r *GenericResource) DeepCopy() *GenericResource {
 		gr.obj = obj.DeepCopy()
 	case *v1beta1.CronJob:
 		gr.obj = obj.DeepCopy()
+	case *unstructured.Unstructured:
+		gr.obj = obj.DeepCopy()
 	}
 
 	return gr
@@ -97,6 +124,8 @@ func (r *GenericResource) GetIdentifier() string {
 		return getDaemonsetSetIdentifier(obj)
 	case *v1beta1.CronJob:
 		return getCronJobIdentifier(obj)
+	case *unstructured.Unstructured:
+		return getUnstructuredIdentifier(obj)
 	}
 	return ""
 }
@@ -112,6 +141,8 @@ func (r *GenericResource) GetName() string {
 		return obj.GetName()
 	case *v1beta1.CronJob:
 		return obj.GetName()
+	case *unstructured.Unstructured:
+		return obj.GetName()
 	}
 	return ""
 }
@@ -127,13 +158,15 @@ func (r *GenericResource) GetNamespace() string {
 		return obj.GetNamespace()
 	case *v1beta1.CronJob:
 		return obj.GetNamespace()
+	case *unstructured.Unstructured:
+		return obj.GetNamespace()
 	}
 	return ""
 }
 
 // Kind returns a type of resource that this structure represents
 func (r *GenericResource) Kind() string {
-	switch r.obj.(type) {
+	switch obj := r.obj.(type) {
 	case *apps_v1.Deployment:
 		return "deployment"
 	case *apps_v1.StatefulSet:
@@ -142,6 +175,8 @@ func (r *GenericResource) Kind() string {
 		return "daemonset"
 	case *v1beta1.CronJob:
 		return "cronjob"
+	case *unstructured.Unstructured:
+		return getUnstructuredKind(obj)
 	}
 	return ""
 }
@@ -162,6 +197,8 @@ func (r *GenericResource) GetLabels() (labels map[string]string) {
 		return getOrInitialise(obj.GetLabels())
 	case *v1beta1.CronJob:
 		return getOrInitialise(obj.GetLabels())
+	case *unstructured.Unstructured:
+		return getOrInitialise(obj.GetLabels())
 	}
 	return
 }
@@ -177,6 +214,8 @@ func (r *GenericResource) SetLabels(labels map[string]string) {
 		obj.SetLabels(labels)
 	case *v1beta1.CronJob:
 		obj.SetLabels(labels)
+	case *unstructured.Unstructured:
+		obj.SetLabels(labels)
 	}
 }
 
@@ -191,6 +230,8 @@ func (r *GenericResource) GetSpecAnnotations() (annotations map[string]string) {
 		return getOrInitialise(obj.Spec.Template.GetAnnotations())
 	case *v1beta1.CronJob:
 		return getOrInitialise(obj.Spec.JobTemplate.GetAnnotations())
+	case *unstructured.Unstructured:
+		return getOrInitialise(getUnstructuredSpecAnnotations(obj))
 	}
 	return
 }
@@ -206,6 +247,8 @@ func (r *GenericResource) SetSpecAnnotations(annotations map[string]string) {
 		obj.Spec.Template.SetAnnotations(annotations)
 	case *v1beta1.CronJob:
 		obj.Spec.JobTemplate.SetAnnotations(annotations)
+	case *unstructured.Unstructured:
+		setUnstructuredSpecAnnotations(obj, annotations)
 	}
 }
 
@@ -227,6 +270,8 @@ func (r *GenericResource) GetAnnotations() (annotations map[string]string) {
 		return getOrInitialise(obj.GetAnnotations())
 	case *v1beta1.CronJob:
 		return getOrInitialise(obj.GetAnnotations())
+	case *unstructured.Unstructured:
+		return getOrInitialise(obj.GetAnnotations())
 	}
 	return
 }
@@ -242,7 +287,39 @@ func (r *GenericResource) SetAnnotations(annotations map[string]string) {
 		obj.SetAnnotations(annotations)
 	case *v1beta1.CronJob:
 		obj.SetAnnotations(annotations)
+	case *unstructured.Unstructured:
+		obj.SetAnnotations(annotations)
+	}
+}
+
+// SetPolicyAnnotations - sets settings provided by KeelPolicy resources and
+// namespace defaults
+func (r *GenericResource) SetPolicyAnnotations(annotations map[string]string) {
+	r.policyAnnotations = annotations
+}
+
+// GetPolicyAnnotations - settings provided by KeelPolicy resources
+func (r *GenericResource) GetPolicyAnnotations() map[string]string {
+	return r.policyAnnotations
+}
+
+// GetKeelAnnotations - resource annotations merged with KeelPolicy settings,
+// labels and annotations set on the resource take precedence. Returned map is
+// a copy, use GetAnnotations/SetAnnotations to modify the resource.
+func (r *GenericResource) GetKeelAnnotations() map[string]string {
+	annotations := r.GetAnnotations()
+	merged := make(map[string]string, len(annotations)+len(r.policyAnnotations))
+	labels := r.GetLabels()
+	for k, v := range r.policyAnnotations {
+		if _, ok := labels[k]; ok {
+			continue
+		}
+		merged[k] = v
 	}
+	for k, v := range annotations {
+		merged[k] = v
+	}
+	return merged
 }
 
 // GetImagePullSecrets - returns secrets from pod spec
@@ -256,10 +333,30 @@ func (r *GenericResource) GetImagePullSecrets() (secrets []string) {
 		return getImagePullSecrets(obj.Spec.Template.Spec.ImagePullSecrets)
 	case *v1beta1.CronJob:
 		return getImagePullSecrets(obj.Spec.JobTemplate.Spec.Template.Spec.ImagePullSecrets)
+	case *unstructured.Unstructured:
+		return getImagePullSecrets(getUnstructuredPodSpec(obj).ImagePullSecrets)
 	}
 	return
 }
 
+// GetServiceAccountName - returns service account pods run as, empty if
+// it's the namespace default
+func (r *GenericResource) GetServiceAccountName() string {
+	switch obj := r.obj.(type) {
+	case *apps_v1.Deployment:
+		return obj.Spec.Template.Spec.ServiceAccountName
+	case *apps_v1.StatefulSet:
+		return obj.Spec.Template.Spec.ServiceAccountName
+	case *apps_v1.DaemonSet:
+		return obj.Spec.Template.Spec.ServiceAccountName
+	case *v1beta1.CronJob:
+		return obj.Spec.JobTemplate.Spec.Template.Spec.ServiceAccountName
+	case *unstructured.Unstructured:
+		return getUnstructuredPodSpec(obj).ServiceAccountName
+	}
+	return ""
+}
+
 // GetImages - returns images used by this resource
 func (r *GenericResource) GetImages() (images []string) {
 	switch obj := r.obj.(type) {
@@ -271,6 +368,8 @@ func (r *GenericResource) GetImages() (images []string) {
 		return getContainerImages(obj.Spec.Template.Spec.Containers)
 	case *v1beta1.CronJob:
 		return getContainerImages(obj.Spec.JobTemplate.Spec.Template.Spec.Containers)
+	case *unstructured.Unstructured:
+		return getContainerImages(getUnstructuredPodSpec(obj).Containers)
 	}
 	return
 }
@@ -286,6 +385,8 @@ func (r *GenericResource) Containers() (containers []core_v1.Container) {
 		return obj.Spec.Template.Spec.Containers
 	case *v1beta1.CronJob:
 		return obj.Spec.JobTemplate.Spec.Template.Spec.Containers
+	case *unstructured.Unstructured:
+		return getUnstructuredPodSpec(obj).Containers
 	}
 	return
 }
@@ -301,6 +402,47 @@ func (r *GenericResource) UpdateContainer(index int, image string) {
 		updateDaemonsetSetContainer(obj, index, image)
 	case *v1beta1.CronJob:
 		updateCronJobContainer(obj, index, image)
+	case *unstructured.Unstructured:
+		updateUnstructuredContainer(obj, "containers", index, image)
+	}
+}
+
+// InitContainers - returns init containers of this resource. Ephemeral containers
+// can't be part of pod templates so there is nothing to manage there
+func (r *GenericResource) InitContainers() (containers []core_v1.Container) {
+	switch obj := r.obj.(type) {
+	case *apps_v1.Deployment:
+		return obj.Spec.Template.Spec.InitContainers
+	case *apps_v1.StatefulSet:
+		return obj.Spec.Template.Spec.InitContainers
+	case *apps_v1.DaemonSet:
+		return obj.Spec.Template.Spec.InitContainers
+	case *v1beta1.CronJob:
+		return obj.Spec.JobTemplate.Spec.Template.Spec.InitContainers
+	case *unstructured.Unstructured:
+		return getUnstructuredPodSpec(obj).InitContainers
+	}
+	return
+}
+
+// GetInitImages - returns images used by init containers of this resource
+func (r *GenericResource) GetInitImages() []string {
+	return getContainerImages(r.InitContainers())
+}
+
+// UpdateInitContainer - updates init container image
+func (r *GenericResource) UpdateInitContainer(index int, image string) {
+	switch obj := r.obj.(type) {
+	case *apps_v1.Deployment:
+		obj.Spec.Template.Spec.InitContainers[index].Image = image
+	case *apps_v1.StatefulSet:
+		obj.Spec.Template.Spec.InitContainers[index].Image = image
+	case *apps_v1.DaemonSet:
+		obj.Spec.Template.Spec.InitContainers[index].Image = image
+	case *v1beta1.CronJob:
+		obj.Spec.JobTemplate.Spec.Template.Spec.InitContainers[index].Image = image
+	case *unstructured.Unstructured:
+		updateUnstructuredContainer(obj, "initContainers", index, image)
 	}
 }
 
@@ -362,6 +504,74 @@ func (r *GenericResource) GetStatus() Status {
 			AvailableReplicas:   0,
 			UnavailableReplicas: 0,
 		}
+	case *unstructured.Unstructured:
+		return getUnstructuredStatus(obj)
 	}
 	return Status{}
 }
+
+// RolloutStarted - whether controller has picked up the latest spec change
+func (r *GenericResource) RolloutStarted() bool {
+	switch obj := r.obj.(type) {
+	case *apps_v1.Deployment:
+		return obj.Status.ObservedGeneration >= obj.Generation
+	case *apps_v1.StatefulSet:
+		return obj.Status.ObservedGeneration >= obj.Generation
+	case *apps_v1.DaemonSet:
+		return obj.Status.ObservedGeneration >= obj.Generation
+	case *unstructured.Unstructured:
+		return unstructuredRolloutStarted(obj)
+	}
+	return true
+}
+
+// RolloutStatus - whether the current spec is fully rolled out and available,
+// failure is set when controller gave up on the rollout (ie: deployment
+// progress deadline exceeded). Resources without rollouts are always done
+func (r *GenericResource) RolloutStatus() (done bool, failure string) {
+	switch obj := r.obj.(type) {
+	case *apps_v1.Deployment:
+		if obj.Status.ObservedGeneration < obj.Generation {
+			return false, ""
+		}
+		for _, c := range obj.Status.Conditions {
+			if c.Type == apps_v1.DeploymentProgressing && c.Status == core_v1.ConditionFalse && c.Reason == "ProgressDeadlineExceeded" {
+				return false, c.Message
+			}
+		}
+		replicas := int32(1)
+		if obj.Spec.Replicas != nil {
+			replicas = *obj.Spec.Replicas
+		}
+		return obj.Status.UpdatedReplicas >= replicas &&
+			obj.Status.Replicas == obj.Status.UpdatedReplicas &&
+			obj.Status.AvailableReplicas >= obj.Status.UpdatedReplicas, ""
+	case *apps_v1.StatefulSet:
+		if obj.Status.ObservedGeneration < obj.Generation {
+			return false, ""
+		}
+		if obj.Spec.UpdateStrategy.Type == apps_v1.OnDeleteStatefulSetStrategyType {
+			// pods are only replaced when deleted manually
+			return true, ""
+		}
+		replicas := int32(1)
+		if obj.Spec.Replicas != nil {
+			replicas = *obj.Spec.Replicas
+		}
+		return obj.Status.UpdateRevision == obj.Status.CurrentRevision &&
+			obj.Status.UpdatedReplicas >= replicas &&
+			obj.Status.ReadyReplicas >= replicas, ""
+	case *apps_v1.DaemonSet:
+		if obj.Status.ObservedGeneration < obj.Generation {
+			return false, ""
+		}
+		if obj.Spec.UpdateStrategy.Type == apps_v1.OnDeleteDaemonSetStrategyType {
+			return true, ""
+		}
+		return obj.Status.UpdatedNumberScheduled >= obj.Status.DesiredNumberScheduled &&
+			obj.Status.NumberAvailable >= obj.Status.DesiredNumberScheduled, ""
+	case *unstructured.Unstructured:
+		return unstructuredRolloutStatus(obj)
+	}
+	return true, ""
+}
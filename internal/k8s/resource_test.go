@@ -185,3 +185,67 @@ func TestDaemonsetlSetMultipleContainers(t *testing.T) {
 		t.Errorf("unexpected image: %s", updated.Spec.Template.Spec.Containers[0].Image)
 	}
 }
+
+func TestDeploymentRolloutStatus(t *testing.T) {
+	replicas := int32(2)
+	tests := []struct {
+		name        string
+		generation  int64
+		status      apps_v1.DeploymentStatus
+		wantDone    bool
+		wantFailure bool
+	}{
+		{
+			name:       "not observed yet",
+			generation: 2,
+			status:     apps_v1.DeploymentStatus{ObservedGeneration: 1, Replicas: 2, UpdatedReplicas: 2, AvailableReplicas: 2},
+		},
+		{
+			name:       "old replicas still running",
+			generation: 2,
+			status:     apps_v1.DeploymentStatus{ObservedGeneration: 2, Replicas: 3, UpdatedReplicas: 2, AvailableReplicas: 2},
+		},
+		{
+			name:       "updated replicas unavailable",
+			generation: 2,
+			status:     apps_v1.DeploymentStatus{ObservedGeneration: 2, Replicas: 2, UpdatedReplicas: 2, AvailableReplicas: 1},
+		},
+		{
+			name:       "progress deadline exceeded",
+			generation: 2,
+			status: apps_v1.DeploymentStatus{ObservedGeneration: 2, Replicas: 3, UpdatedReplicas: 1, AvailableReplicas: 2,
+				Conditions: []apps_v1.DeploymentCondition{
+					{Type: apps_v1.DeploymentProgressing, Status: core_v1.ConditionFalse, Reason: "ProgressDeadlineExceeded", Message: "ReplicaSet has timed out progressing."},
+				},
+			},
+			wantFailure: true,
+		},
+		{
+			name:       "complete",
+			generation: 2,
+			status:     apps_v1.DeploymentStatus{ObservedGeneration: 2, Replicas: 2, UpdatedReplicas: 2, AvailableReplicas: 2},
+			wantDone:   true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gr, err := NewGenericResource(&apps_v1.Deployment{
+				ObjectMeta: meta_v1.ObjectMeta{Name: "dep-1", Namespace: "xxxx", Generation: tt.generation},
+				Spec:       apps_v1.DeploymentSpec{Replicas: &replicas},
+				Status:     tt.status,
+			})
+			if err != nil {
+				t.Fatalf("failed to create generic resource: %s", err)
+			}
+
+			done, failure := gr.RolloutStatus()
+			if done != tt.wantDone {
+				t.Errorf("expected done %t, got %t", tt.wantDone, done)
+			}
+			if (failure != "") != tt.wantFailure {
+				t.Errorf("unexpected failure: %q", failure)
+			}
+		})
+	}
+}
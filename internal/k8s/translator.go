@@ -1,7 +1,11 @@
 package k8s
 
 import (
+	"reflect"
+
 	"github.com/sirupsen/logrus"
+	"k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 )
 
 type Translator struct {
@@ -9,30 +13,71 @@ type Translator struct {
 
 	GenericResourceCache
 
+	// KeelPolicy resources, applied to cached resources
+	Policies PolicyCache
+
+	// keel.sh/ annotations of namespaces, defaults of cached resources
+	Namespaces NamespaceDefaults
+
 	KeelSelector string
 }
 
 func (t *Translator) OnAdd(obj interface{}) {
+	if ns, ok := obj.(*v1.Namespace); ok {
+		t.setNamespace(ns)
+		return
+	}
+	if u, ok := obj.(*unstructured.Unstructured); ok && !IsWorkload(u) {
+		t.addPolicy(u)
+		return
+	}
 	gr, err := NewGenericResource(obj)
 	if err != nil {
 		t.Errorf("OnAdd failed to add resource %T: %#v", obj, obj)
 		return
 	}
 	t.Debugf("added %s %s", gr.Kind(), gr.Name)
+	gr.SetPolicyAnnotations(t.settings(gr))
 	t.GenericResourceCache.Add(gr)
+	t.GenericResourceCache.Notify()
 }
 
 func (t *Translator) OnUpdate(oldObj, newObj interface{}) {
+	if ns, ok := newObj.(*v1.Namespace); ok {
+		t.setNamespace(ns)
+		return
+	}
+	if u, ok := newObj.(*unstructured.Unstructured); ok && !IsWorkload(u) {
+		t.addPolicy(u)
+		return
+	}
 	gr, err := NewGenericResource(newObj)
 	if err != nil {
 		t.Errorf("OnUpdate failed to update resource %T: %#v", newObj, newObj)
 		return
 	}
 	t.Debugf("updated %s %s", gr.Kind(), gr.Name)
+	gr.SetPolicyAnnotations(t.settings(gr))
 	t.GenericResourceCache.Add(gr)
+	if trackingChanged(oldObj, gr) {
+		t.GenericResourceCache.Notify()
+	}
 }
 
 func (t *Translator) OnDelete(obj interface{}) {
+	if ns, ok := obj.(*v1.Namespace); ok {
+		if t.Namespaces.Remove(ns.Name) {
+			t.Debugf("deleted namespace %s defaults", ns.Name)
+			t.applyPolicies()
+		}
+		return
+	}
+	if u, ok := obj.(*unstructured.Unstructured); ok && !IsWorkload(u) {
+		t.Debugf("deleted %s %s/%s", KeelPolicyKind, u.GetNamespace(), u.GetName())
+		t.Policies.Remove(u.GetNamespace(), u.GetName())
+		t.applyPolicies()
+		return
+	}
 	gr, err := NewGenericResource(obj)
 	if err != nil {
 		t.Errorf("OnDelete failed to delete resource %T: %#v", obj, obj)
@@ -40,4 +85,67 @@ func (t *Translator) OnDelete(obj interface{}) {
 	}
 	t.Debugf("deleted %s %s", gr.Kind(), gr.Name)
 	t.GenericResourceCache.Remove(gr.GetIdentifier())
+	t.GenericResourceCache.Notify()
+}
+
+func (t *Translator) addPolicy(u *unstructured.Unstructured) {
+	p, err := NewKeelPolicy(u)
+	if err != nil {
+		t.WithError(err).Errorf("invalid %s %s/%s, ignoring it", KeelPolicyKind, u.GetNamespace(), u.GetName())
+		t.Policies.Remove(u.GetNamespace(), u.GetName())
+	} else {
+		t.Debugf("updated %s %s/%s", KeelPolicyKind, p.Namespace, p.Name)
+		t.Policies.Add(p)
+	}
+	t.applyPolicies()
+}
+
+func (t *Translator) setNamespace(ns *v1.Namespace) {
+	if t.Namespaces.Set(ns) {
+		t.Debugf("updated namespace %s defaults", ns.Name)
+		t.applyPolicies()
+	}
+}
+
+// settings - namespace defaults overridden by KeelPolicy settings
+func (t *Translator) settings(gr *GenericResource) map[string]string {
+	defaults := t.Namespaces.Annotations(gr.Namespace)
+	policies := t.Policies.Annotations(gr)
+	if len(defaults) == 0 {
+		return policies
+	}
+
+	settings := make(map[string]string, len(defaults)+len(policies))
+	for k, v := range defaults {
+		settings[k] = v
+	}
+	for k, v := range policies {
+		settings[k] = v
+	}
+	return settings
+}
+
+// applyPolicies - reconciles policy settings of all cached resources
+// after KeelPolicy or namespace changes
+func (t *Translator) applyPolicies() {
+	grs := t.GenericResourceCache.Values()
+	for _, gr := range grs {
+		gr.SetPolicyAnnotations(t.settings(gr))
+	}
+	t.GenericResourceCache.Add(grs...)
+	t.GenericResourceCache.Notify()
+}
+
+// trackingChanged - whether update affects tracked images, status updates
+// and informer resyncs don't
+func trackingChanged(oldObj interface{}, gr *GenericResource) bool {
+	old, err := NewGenericResource(oldObj)
+	if err != nil {
+		return true
+	}
+	return !reflect.DeepEqual(old.GetLabels(), gr.GetLabels()) ||
+		!reflect.DeepEqual(old.GetAnnotations(), gr.GetAnnotations()) ||
+		!reflect.DeepEqual(old.GetImages(), gr.GetImages()) ||
+		!reflect.DeepEqual(old.GetInitImages(), gr.GetInitImages()) ||
+		!reflect.DeepEqual(old.GetImagePullSecrets(), gr.GetImagePullSecrets())
 }
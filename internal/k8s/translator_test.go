@@ -0,0 +1,74 @@
+package k8s
+
+import (
+	"testing"
+
+	"github.com/sirupsen/logrus"
+	apps_v1 "k8s.io/api/apps/v1"
+	core_v1 "k8s.io/api/core/v1"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func newTestDeployment(image string, replicas int32) *apps_v1.Deployment {
+	return &apps_v1.Deployment{
+		ObjectMeta: meta_v1.ObjectMeta{
+			Name:      "dep-1",
+			Namespace: "xxxx",
+			Labels:    map[string]string{"keel.sh/policy": "all"},
+		},
+		Spec: apps_v1.DeploymentSpec{
+			Template: core_v1.PodTemplateSpec{
+				Spec: core_v1.PodSpec{
+					Containers: []core_v1.Container{
+						{
+							Image: image,
+						},
+					},
+				},
+			},
+		},
+		Status: apps_v1.DeploymentStatus{
+			Replicas: replicas,
+		},
+	}
+}
+
+func TestTranslatorNotify(t *testing.T) {
+	tr := &Translator{FieldLogger: logrus.New()}
+
+	var last int
+	notified := func() bool {
+		ch := make(chan int, 1)
+		tr.Register(ch, last)
+		select {
+		case last = <-ch:
+			return true
+		default:
+			return false
+		}
+	}
+
+	tr.OnAdd(newTestDeployment("gcr.io/v2-namespace/hi-world:1.1.1", 1))
+	if !notified() {
+		t.Errorf("expected add to notify")
+	}
+
+	tr.OnUpdate(newTestDeployment("gcr.io/v2-namespace/hi-world:1.1.1", 1), newTestDeployment("gcr.io/v2-namespace/hi-world:1.1.1", 2))
+	if notified() {
+		t.Errorf("didn't expect status update to notify")
+	}
+
+	tr.OnUpdate(newTestDeployment("gcr.io/v2-namespace/hi-world:1.1.1", 2), newTestDeployment("gcr.io/v2-namespace/hi-world:1.1.2", 2))
+	if !notified() {
+		t.Errorf("expected image update to notify")
+	}
+
+	tr.OnDelete(newTestDeployment("gcr.io/v2-namespace/hi-world:1.1.2", 2))
+	if !notified() {
+		t.Errorf("expected delete to notify")
+	}
+
+	if len(tr.Values()) != 0 {
+		t.Errorf("expected cache to be empty, got: %d", len(tr.Values()))
+	}
+}
@@ -0,0 +1,200 @@
+package k8s
+
+import (
+	"strconv"
+
+	core_v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// ArgoRolloutKind - kind of the Argo Rollouts custom resource
+const ArgoRolloutKind = "Rollout"
+
+// ArgoRolloutResource - Argo Rollouts custom resource, https://argoproj.github.io/argo-rollouts/
+var ArgoRolloutResource = schema.GroupVersionResource{
+	Group:    "argoproj.io",
+	Version:  "v1alpha1",
+	Resource: "rollouts",
+}
+
+// DeploymentConfigKind - kind of the OpenShift DeploymentConfig resource
+const DeploymentConfigKind = "DeploymentConfig"
+
+// DeploymentConfigResource - OpenShift DeploymentConfig resource
+var DeploymentConfigResource = schema.GroupVersionResource{
+	Group:    "apps.openshift.io",
+	Version:  "v1",
+	Resource: "deploymentconfigs",
+}
+
+// Workloads without client-go types are kept as unstructured objects, they all
+// embed a regular pod template in spec.template. Only the fields keel works with
+// are read, everything else is left untouched on updates
+
+// IsArgoRollout - whether unstructured object is an Argo Rollout
+func IsArgoRollout(u *unstructured.Unstructured) bool {
+	gvk := u.GroupVersionKind()
+	return gvk.Group == ArgoRolloutResource.Group && gvk.Kind == ArgoRolloutKind
+}
+
+// IsDeploymentConfig - whether unstructured object is an OpenShift DeploymentConfig
+func IsDeploymentConfig(u *unstructured.Unstructured) bool {
+	gvk := u.GroupVersionKind()
+	return gvk.Group == DeploymentConfigResource.Group && gvk.Kind == DeploymentConfigKind
+}
+
+// IsWorkload - whether unstructured object is a supported workload, other
+// unstructured objects are KeelPolicy resources
+func IsWorkload(u *unstructured.Unstructured) bool {
+	return IsArgoRollout(u) || IsDeploymentConfig(u)
+}
+
+// UnstructuredResource - API resource of the unstructured workload
+func UnstructuredResource(u *unstructured.Unstructured) schema.GroupVersionResource {
+	if IsDeploymentConfig(u) {
+		return DeploymentConfigResource
+	}
+	return ArgoRolloutResource
+}
+
+func getUnstructuredKind(u *unstructured.Unstructured) string {
+	if IsDeploymentConfig(u) {
+		return "deploymentconfig"
+	}
+	return "rollout"
+}
+
+func getUnstructuredIdentifier(u *unstructured.Unstructured) string {
+	return getUnstructuredKind(u) + "/" + u.GetNamespace() + "/" + u.GetName()
+}
+
+func getUnstructuredPodSpec(u *unstructured.Unstructured) core_v1.PodSpec {
+	var spec core_v1.PodSpec
+	content, _, _ := unstructured.NestedMap(u.Object, "spec", "template", "spec")
+	if content == nil {
+		return spec
+	}
+	// invalid fields are rejected by the API server, best effort is good enough here
+	_ = runtime.DefaultUnstructuredConverter.FromUnstructured(content, &spec)
+	return spec
+}
+
+func updateUnstructuredContainer(u *unstructured.Unstructured, field string, index int, image string) {
+	containers, _, _ := unstructured.NestedSlice(u.Object, "spec", "template", "spec", field)
+	if index >= len(containers) {
+		return
+	}
+	container, ok := containers[index].(map[string]interface{})
+	if !ok {
+		return
+	}
+	container["image"] = image
+	_ = unstructured.SetNestedSlice(u.Object, containers, "spec", "template", "spec", field)
+}
+
+func getUnstructuredSpecAnnotations(u *unstructured.Unstructured) map[string]string {
+	annotations, _, _ := unstructured.NestedStringMap(u.Object, "spec", "template", "metadata", "annotations")
+	return annotations
+}
+
+func setUnstructuredSpecAnnotations(u *unstructured.Unstructured, annotations map[string]string) {
+	_ = unstructured.SetNestedStringMap(u.Object, annotations, "spec", "template", "metadata", "annotations")
+}
+
+func unstructuredInt(u *unstructured.Unstructured, fields ...string) int64 {
+	val, found, _ := unstructured.NestedFieldNoCopy(u.Object, fields...)
+	if !found {
+		return 0
+	}
+	switch v := val.(type) {
+	case int64:
+		return v
+	case float64:
+		return int64(v)
+	case string:
+		// older Argo Rollouts versions report observed generation as a string
+		i, _ := strconv.ParseInt(v, 10, 64)
+		return i
+	}
+	return 0
+}
+
+func unstructuredReplicas(u *unstructured.Unstructured) int32 {
+	if _, found, _ := unstructured.NestedFieldNoCopy(u.Object, "spec", "replicas"); !found {
+		return 1
+	}
+	return int32(unstructuredInt(u, "spec", "replicas"))
+}
+
+func getUnstructuredStatus(u *unstructured.Unstructured) Status {
+	return Status{
+		Replicas:            int32(unstructuredInt(u, "status", "replicas")),
+		UpdatedReplicas:     int32(unstructuredInt(u, "status", "updatedReplicas")),
+		ReadyReplicas:       int32(unstructuredInt(u, "status", "readyReplicas")),
+		AvailableReplicas:   int32(unstructuredInt(u, "status", "availableReplicas")),
+		UnavailableReplicas: int32(unstructuredInt(u, "status", "unavailableReplicas")),
+	}
+}
+
+func unstructuredRolloutStarted(u *unstructured.Unstructured) bool {
+	return unstructuredInt(u, "status", "observedGeneration") >= u.GetGeneration()
+}
+
+func unstructuredRolloutStatus(u *unstructured.Unstructured) (done bool, failure string) {
+	if !unstructuredRolloutStarted(u) {
+		return false, ""
+	}
+	if IsArgoRollout(u) {
+		return argoRolloutStatus(u)
+	}
+	return deploymentConfigStatus(u)
+}
+
+// argoRolloutStatus - rollouts report their own phase, canary steps and analysis
+// are left to the Argo Rollouts controller
+func argoRolloutStatus(u *unstructured.Unstructured) (done bool, failure string) {
+	phase, _, _ := unstructured.NestedString(u.Object, "status", "phase")
+	switch phase {
+	case "Healthy":
+		return true, ""
+	case "Degraded":
+		message, _, _ := unstructured.NestedString(u.Object, "status", "message")
+		if message == "" {
+			message = "rollout degraded"
+		}
+		return false, message
+	case "":
+		// phase is only reported since Argo Rollouts v1.0
+		status := getUnstructuredStatus(u)
+		replicas := unstructuredReplicas(u)
+		return status.UpdatedReplicas >= replicas && status.AvailableReplicas >= replicas, ""
+	}
+	return false, ""
+}
+
+// deploymentConfigStatus - same conditions as deployments, progress deadline is
+// reported as ProgressDeadlineExceeded reason of the Progressing condition
+func deploymentConfigStatus(u *unstructured.Unstructured) (done bool, failure string) {
+	conditions, _, _ := unstructured.NestedSlice(u.Object, "status", "conditions")
+	for _, c := range conditions {
+		condition, ok := c.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		if condition["type"] == "Progressing" && condition["status"] == "False" {
+			message, _ := condition["message"].(string)
+			if message == "" {
+				message, _ = condition["reason"].(string)
+			}
+			return false, message
+		}
+	}
+
+	status := getUnstructuredStatus(u)
+	replicas := unstructuredReplicas(u)
+	return status.UpdatedReplicas >= replicas &&
+		status.Replicas == status.UpdatedReplicas &&
+		status.AvailableReplicas >= status.UpdatedReplicas, ""
+}
@@ -0,0 +1,196 @@
+package k8s
+
+import (
+	"testing"
+
+	"github.com/sirupsen/logrus"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func newTestArgoRollout(image string) *unstructured.Unstructured {
+	return &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "argoproj.io/v1alpha1",
+		"kind":       "Rollout",
+		"metadata": map[string]interface{}{
+			"name":       "rollout-1",
+			"namespace":  "xxxx",
+			"generation": int64(2),
+			"labels":     map[string]interface{}{"keel.sh/policy": "all"},
+		},
+		"spec": map[string]interface{}{
+			"replicas": int64(3),
+			"strategy": map[string]interface{}{
+				"canary": map[string]interface{}{
+					"steps": []interface{}{
+						map[string]interface{}{"setWeight": int64(20)},
+					},
+				},
+			},
+			"template": map[string]interface{}{
+				"spec": map[string]interface{}{
+					"imagePullSecrets": []interface{}{
+						map[string]interface{}{"name": "registry"},
+					},
+					"containers": []interface{}{
+						map[string]interface{}{
+							"name":  "app",
+							"image": image,
+							"ports": []interface{}{
+								map[string]interface{}{"containerPort": int64(8080)},
+							},
+						},
+					},
+				},
+			},
+		},
+		"status": map[string]interface{}{
+			"observedGeneration": "1",
+		},
+	}}
+}
+
+func TestArgoRollout(t *testing.T) {
+	gr, err := NewGenericResource(newTestArgoRollout("gcr.io/v2-namespace/hello-world:1.1.1"))
+	if err != nil {
+		t.Fatalf("failed to create generic resource: %s", err)
+	}
+
+	if gr.Identifier != "rollout/xxxx/rollout-1" {
+		t.Errorf("unexpected identifier: %s", gr.Identifier)
+	}
+	if gr.GetImages()[0] != "gcr.io/v2-namespace/hello-world:1.1.1" {
+		t.Errorf("unexpected images: %v", gr.GetImages())
+	}
+	if gr.GetImagePullSecrets()[0] != "registry" {
+		t.Errorf("unexpected image pull secrets: %v", gr.GetImagePullSecrets())
+	}
+
+	gr.UpdateContainer(0, "hey/there")
+	gr.SetSpecAnnotations(map[string]string{"time": "now"})
+
+	updated := gr.GetResource().(*unstructured.Unstructured)
+	containers, _, _ := unstructured.NestedSlice(updated.Object, "spec", "template", "spec", "containers")
+	container := containers[0].(map[string]interface{})
+	if container["image"] != "hey/there" {
+		t.Errorf("unexpected image: %s", container["image"])
+	}
+	if _, ok := container["ports"]; !ok {
+		t.Errorf("expected other container fields to be kept")
+	}
+	if _, found, _ := unstructured.NestedSlice(updated.Object, "spec", "strategy", "canary", "steps"); !found {
+		t.Errorf("expected rollout strategy to be kept")
+	}
+	if gr.GetSpecAnnotations()["time"] != "now" {
+		t.Errorf("unexpected spec annotations: %v", gr.GetSpecAnnotations())
+	}
+
+	if gr.RolloutStarted() {
+		t.Errorf("didn't expect rollout to be started before controller observed it")
+	}
+	unstructured.SetNestedField(updated.Object, "2", "status", "observedGeneration")
+	unstructured.SetNestedField(updated.Object, "Degraded", "status", "phase")
+	unstructured.SetNestedField(updated.Object, "analysis failed", "status", "message")
+	if done, failure := gr.RolloutStatus(); done || failure != "analysis failed" {
+		t.Errorf("unexpected rollout status: %t, %s", done, failure)
+	}
+	unstructured.SetNestedField(updated.Object, "Healthy", "status", "phase")
+	if done, _ := gr.RolloutStatus(); !done {
+		t.Errorf("expected healthy rollout to be done")
+	}
+
+	// copies don't share the object
+	cp := gr.DeepCopy()
+	cp.UpdateContainer(0, "hey/again")
+	if gr.GetImages()[0] != "hey/there" {
+		t.Errorf("expected original to be unchanged, got: %s", gr.GetImages()[0])
+	}
+}
+
+func TestTranslatorArgoRollout(t *testing.T) {
+	tr := &Translator{FieldLogger: logrus.New()}
+
+	tr.OnAdd(newTestArgoRollout("gcr.io/v2-namespace/hello-world:1.1.1"))
+	if len(tr.Values()) != 1 {
+		t.Fatalf("expected rollout to be cached, got: %d", len(tr.Values()))
+	}
+	if len(tr.Policies.Annotations(tr.Values()[0])) != 0 {
+		t.Errorf("didn't expect rollout to be treated as a policy")
+	}
+
+	tr.OnDelete(newTestArgoRollout("gcr.io/v2-namespace/hello-world:1.1.1"))
+	if len(tr.Values()) != 0 {
+		t.Errorf("expected cache to be empty, got: %d", len(tr.Values()))
+	}
+}
+
+func TestDeploymentConfig(t *testing.T) {
+	dc := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "apps.openshift.io/v1",
+		"kind":       "DeploymentConfig",
+		"metadata": map[string]interface{}{
+			"name":       "dc-1",
+			"namespace":  "xxxx",
+			"generation": int64(3),
+		},
+		"spec": map[string]interface{}{
+			"replicas": int64(2),
+			"triggers": []interface{}{
+				map[string]interface{}{"type": "ConfigChange"},
+			},
+			"template": map[string]interface{}{
+				"spec": map[string]interface{}{
+					"containers": []interface{}{
+						map[string]interface{}{
+							"name":  "app",
+							"image": "gcr.io/v2-namespace/hello-world:1.1.1",
+						},
+					},
+				},
+			},
+		},
+		"status": map[string]interface{}{
+			"observedGeneration": int64(3),
+			"replicas":           int64(2),
+			"updatedReplicas":    int64(1),
+			"availableReplicas":  int64(2),
+		},
+	}}
+
+	gr, err := NewGenericResource(dc)
+	if err != nil {
+		t.Fatalf("failed to create generic resource: %s", err)
+	}
+	if gr.Identifier != "deploymentconfig/xxxx/dc-1" || gr.Kind() != "deploymentconfig" {
+		t.Errorf("unexpected identifier: %s, kind: %s", gr.Identifier, gr.Kind())
+	}
+
+	gr.UpdateContainer(0, "hey/there")
+	if gr.GetImages()[0] != "hey/there" {
+		t.Errorf("unexpected image: %s", gr.GetImages()[0])
+	}
+	if _, found, _ := unstructured.NestedSlice(dc.Object, "spec", "triggers"); !found {
+		t.Errorf("expected triggers to be kept")
+	}
+
+	if done, _ := gr.RolloutStatus(); done {
+		t.Errorf("didn't expect rollout to be done with 1/2 updated replicas")
+	}
+	unstructured.SetNestedField(dc.Object, int64(2), "status", "updatedReplicas")
+	if done, _ := gr.RolloutStatus(); !done {
+		t.Errorf("expected rollout to be done")
+	}
+
+	unstructured.SetNestedSlice(dc.Object, []interface{}{
+		map[string]interface{}{"type": "Progressing", "status": "False", "reason": "ProgressDeadlineExceeded", "message": "replication controller dc-1-2 has timed out progressing"},
+	}, "status", "conditions")
+	if _, failure := gr.RolloutStatus(); failure != "replication controller dc-1-2 has timed out progressing" {
+		t.Errorf("unexpected failure: %s", failure)
+	}
+
+	if _, err := NewGenericResource(&unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "keel.sh/v1alpha1",
+		"kind":       "KeelPolicy",
+	}}); err == nil {
+		t.Errorf("expected other unstructured resources to be rejected")
+	}
+}
@@ -10,40 +10,175 @@ import (
 	v1beta1 "k8s.io/api/batch/v1beta1"
 	"k8s.io/api/core/v1"
 
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/fields"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	k8s_watch "k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/dynamic"
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/tools/cache"
 )
 
 // WatchDeployments creates a SharedInformer for apps/v1.Deployments and registers it with g.
-func WatchDeployments(g *workgroup.Group, client *kubernetes.Clientset, log logrus.FieldLogger, rs ...cache.ResourceEventHandler) {
-	watch(g, client.AppsV1().RESTClient(), log, "deployments", new(apps_v1.Deployment), rs...)
+func WatchDeployments(g *workgroup.Group, client *kubernetes.Clientset, log logrus.FieldLogger, filter *Filter, rs ...cache.ResourceEventHandler) {
+	watch(g, client.AppsV1().RESTClient(), log, "deployments", new(apps_v1.Deployment), filter, rs...)
 }
 
 // WatchStatefulSets creates a SharedInformer for apps/v1.StatefulSet and registers it with g.
-func WatchStatefulSets(g *workgroup.Group, client *kubernetes.Clientset, log logrus.FieldLogger, rs ...cache.ResourceEventHandler) {
-	watch(g, client.AppsV1().RESTClient(), log, "statefulsets", new(apps_v1.StatefulSet), rs...)
+func WatchStatefulSets(g *workgroup.Group, client *kubernetes.Clientset, log logrus.FieldLogger, filter *Filter, rs ...cache.ResourceEventHandler) {
+	watch(g, client.AppsV1().RESTClient(), log, "statefulsets", new(apps_v1.StatefulSet), filter, rs...)
 }
 
 // WatchDaemonSets creates a SharedInformer for apps/v1.DaemonSet and registers it with g.
-func WatchDaemonSets(g *workgroup.Group, client *kubernetes.Clientset, log logrus.FieldLogger, rs ...cache.ResourceEventHandler) {
-	watch(g, client.AppsV1().RESTClient(), log, "daemonsets", new(apps_v1.DaemonSet), rs...)
+func WatchDaemonSets(g *workgroup.Group, client *kubernetes.Clientset, log logrus.FieldLogger, filter *Filter, rs ...cache.ResourceEventHandler) {
+	watch(g, client.AppsV1().RESTClient(), log, "daemonsets", new(apps_v1.DaemonSet), filter, rs...)
 }
 
 // WatchCronJobs creates a SharedInformer for v1beta1.CronJob and registers it with g.
-func WatchCronJobs(g *workgroup.Group, client *kubernetes.Clientset, log logrus.FieldLogger, rs ...cache.ResourceEventHandler) {
-	watch(g, client.BatchV1beta1().RESTClient(), log, "cronjobs", new(v1beta1.CronJob), rs...)
+func WatchCronJobs(g *workgroup.Group, client *kubernetes.Clientset, log logrus.FieldLogger, filter *Filter, rs ...cache.ResourceEventHandler) {
+	watch(g, client.BatchV1beta1().RESTClient(), log, "cronjobs", new(v1beta1.CronJob), filter, rs...)
 }
 
-func watch(g *workgroup.Group, c cache.Getter, log logrus.FieldLogger, resource string, objType runtime.Object, rs ...cache.ResourceEventHandler) {
-	lw := cache.NewListWatchFromClient(c, resource, v1.NamespaceAll, fields.Everything())
+// WatchKeelPolicies creates a SharedInformer for keel.sh/v1alpha1.KeelPolicy and registers it with g.
+// Label selector of the filter only applies to workloads.
+func WatchKeelPolicies(g *workgroup.Group, client dynamic.Interface, log logrus.FieldLogger, filter *Filter, rs ...cache.ResourceEventHandler) {
+	for _, ns := range filter.namespaces() {
+		ri := client.Resource(KeelPolicyResource).Namespace(ns)
+		lw := &cache.ListWatch{
+			ListFunc: func(options meta_v1.ListOptions) (runtime.Object, error) {
+				filter.policyListOptions(&options)
+				return ri.List(options)
+			},
+			WatchFunc: func(options meta_v1.ListOptions) (k8s_watch.Interface, error) {
+				filter.policyListOptions(&options)
+				return ri.Watch(options)
+			},
+		}
+		run(g, lw, log, KeelPolicyResource.Resource, ns, new(unstructured.Unstructured), rs...)
+	}
+}
+
+// WatchNamespaces creates a SharedInformer for v1.Namespace and registers it with g.
+// When the filter restricts namespaces only those are watched.
+func WatchNamespaces(g *workgroup.Group, client *kubernetes.Clientset, log logrus.FieldLogger, filter *Filter, rs ...cache.ResourceEventHandler) {
+	c := client.CoreV1().RESTClient()
+	if filter == nil || len(filter.Namespaces) == 0 {
+		lw := cache.NewFilteredListWatchFromClient(c, "namespaces", v1.NamespaceAll, filter.namespaceListOptions(""))
+		run(g, lw, log, "namespaces", v1.NamespaceAll, new(v1.Namespace), rs...)
+		return
+	}
+	for _, ns := range filter.Namespaces {
+		lw := cache.NewFilteredListWatchFromClient(c, "namespaces", v1.NamespaceAll, filter.namespaceListOptions(ns))
+		run(g, lw, log, "namespaces", ns, new(v1.Namespace), rs...)
+	}
+}
+
+// WatchArgoRollouts creates a SharedInformer for argoproj.io/v1alpha1.Rollout and registers it with g.
+func WatchArgoRollouts(g *workgroup.Group, client dynamic.Interface, log logrus.FieldLogger, filter *Filter, rs ...cache.ResourceEventHandler) {
+	watchDynamic(g, client, log, ArgoRolloutResource, filter, rs...)
+}
+
+// WatchDeploymentConfigs creates a SharedInformer for apps.openshift.io/v1.DeploymentConfig and registers it with g.
+func WatchDeploymentConfigs(g *workgroup.Group, client dynamic.Interface, log logrus.FieldLogger, filter *Filter, rs ...cache.ResourceEventHandler) {
+	watchDynamic(g, client, log, DeploymentConfigResource, filter, rs...)
+}
+
+// Filter - restricts watched resources, empty filter watches all namespaces
+type Filter struct {
+	// Namespaces - only watch these namespaces, keel then doesn't need
+	// cluster wide permissions
+	Namespaces        []string
+	ExcludeNamespaces []string
+	LabelSelector     string
+}
+
+func (f *Filter) namespaces() []string {
+	if f == nil || len(f.Namespaces) == 0 {
+		return []string{v1.NamespaceAll}
+	}
+	return f.Namespaces
+}
+
+func (f *Filter) listOptions(options *meta_v1.ListOptions) {
+	if f == nil {
+		return
+	}
+	options.LabelSelector = f.LabelSelector
+	f.excludeNamespaces(options)
+}
+
+func (f *Filter) excludeNamespaces(options *meta_v1.ListOptions) {
+	var selectors []fields.Selector
+	for _, ns := range f.ExcludeNamespaces {
+		selectors = append(selectors, fields.OneTermNotEqualSelector("metadata.namespace", ns))
+	}
+	if len(selectors) > 0 {
+		options.FieldSelector = fields.AndSelectors(selectors...).String()
+	}
+}
+
+func (f *Filter) policyListOptions(options *meta_v1.ListOptions) {
+	if f == nil {
+		return
+	}
+	f.excludeNamespaces(options)
+}
+
+// namespaceListOptions - selects namespace by name, excluded namespaces are
+// skipped
+func (f *Filter) namespaceListOptions(name string) func(options *meta_v1.ListOptions) {
+	return func(options *meta_v1.ListOptions) {
+		var selectors []fields.Selector
+		if name != "" {
+			selectors = append(selectors, fields.OneTermEqualSelector("metadata.name", name))
+		}
+		if f != nil {
+			for _, ns := range f.ExcludeNamespaces {
+				selectors = append(selectors, fields.OneTermNotEqualSelector("metadata.name", ns))
+			}
+		}
+		if len(selectors) > 0 {
+			options.FieldSelector = fields.AndSelectors(selectors...).String()
+		}
+	}
+}
+
+func watch(g *workgroup.Group, c cache.Getter, log logrus.FieldLogger, resource string, objType runtime.Object, filter *Filter, rs ...cache.ResourceEventHandler) {
+	for _, ns := range filter.namespaces() {
+		lw := cache.NewFilteredListWatchFromClient(c, resource, ns, filter.listOptions)
+		run(g, lw, log, resource, ns, objType, rs...)
+	}
+}
+
+func watchDynamic(g *workgroup.Group, client dynamic.Interface, log logrus.FieldLogger, resource schema.GroupVersionResource, filter *Filter, rs ...cache.ResourceEventHandler) {
+	for _, ns := range filter.namespaces() {
+		ri := client.Resource(resource).Namespace(ns)
+		lw := &cache.ListWatch{
+			ListFunc: func(options meta_v1.ListOptions) (runtime.Object, error) {
+				filter.listOptions(&options)
+				return ri.List(options)
+			},
+			WatchFunc: func(options meta_v1.ListOptions) (k8s_watch.Interface, error) {
+				filter.listOptions(&options)
+				return ri.Watch(options)
+			},
+		}
+		run(g, lw, log, resource.Resource, ns, new(unstructured.Unstructured), rs...)
+	}
+}
+
+func run(g *workgroup.Group, lw cache.ListerWatcher, log logrus.FieldLogger, resource, ns string, objType runtime.Object, rs ...cache.ResourceEventHandler) {
 	sw := cache.NewSharedInformer(lw, objType, 30*time.Minute)
 	for _, r := range rs {
 		sw.AddEventHandler(r)
 	}
+	log = log.WithFields(logrus.Fields{
+		"resource":  resource,
+		"namespace": ns,
+	})
 	g.Add(func(stop <-chan struct{}) {
-		log := log.WithField("resource", resource)
 		log.Println("started")
 		defer log.Println("stopped")
 		sw.Run(stop)
@@ -0,0 +1,38 @@
+package k8s
+
+import (
+	"reflect"
+	"testing"
+
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestFilter(t *testing.T) {
+	var empty *Filter
+	if ns := empty.namespaces(); !reflect.DeepEqual(ns, []string{""}) {
+		t.Errorf("expected all namespaces, got: %v", ns)
+	}
+	opts := meta_v1.ListOptions{}
+	empty.listOptions(&opts)
+	if opts.LabelSelector != "" || opts.FieldSelector != "" {
+		t.Errorf("unexpected list options: %+v", opts)
+	}
+
+	f := &Filter{
+		Namespaces:        []string{"team-a", "team-b"},
+		ExcludeNamespaces: []string{"kube-system", "keel"},
+		LabelSelector:     "tier=backend",
+	}
+	if ns := f.namespaces(); !reflect.DeepEqual(ns, []string{"team-a", "team-b"}) {
+		t.Errorf("unexpected namespaces: %v", ns)
+	}
+
+	opts = meta_v1.ListOptions{}
+	f.listOptions(&opts)
+	if opts.LabelSelector != "tier=backend" {
+		t.Errorf("unexpected label selector: %s", opts.LabelSelector)
+	}
+	if opts.FieldSelector != "metadata.namespace!=kube-system,metadata.namespace!=keel" {
+		t.Errorf("unexpected field selector: %s", opts.FieldSelector)
+	}
+}
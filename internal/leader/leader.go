@@ -0,0 +1,184 @@
+package leader
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/leaderelection"
+	"k8s.io/client-go/tools/leaderelection/resourcelock"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// defaults, same as kube-controller-manager
+const (
+	DefaultLeaseName     = "keel"
+	DefaultLeaseDuration = 15 * time.Second
+	DefaultRenewDeadline = 10 * time.Second
+	DefaultRetryPeriod   = 2 * time.Second
+)
+
+// ErrNotLeader - returned by Ready on standby replicas
+var ErrNotLeader = errors.New("not the leader")
+
+const serviceAccountNamespaceFile = "/var/run/secrets/kubernetes.io/serviceaccount/namespace"
+
+// Opts - leader election options
+type Opts struct {
+	Client kubernetes.Interface
+
+	// Namespace - where lease is created, defaults to keel's own namespace
+	Namespace string
+	// Name - lease name, replicas using the same lease elect a single leader
+	Name string
+	// Identity - unique replica identity, defaults to hostname (pod name)
+	Identity string
+
+	LeaseDuration time.Duration
+	RenewDeadline time.Duration
+	RetryPeriod   time.Duration
+}
+
+// Elector - elects a single leader out of keel replicas using a
+// coordination.k8s.io Lease
+type Elector struct {
+	opts    *Opts
+	elector *leaderelection.LeaderElector
+
+	onStartedLeading []func(ctx context.Context)
+}
+
+// New - create new elector
+func New(opts *Opts) (*Elector, error) {
+	if opts.Name == "" {
+		opts.Name = DefaultLeaseName
+	}
+	if opts.Namespace == "" {
+		opts.Namespace = CurrentNamespace()
+	}
+	if opts.Identity == "" {
+		hostname, err := os.Hostname()
+		if err != nil {
+			return nil, fmt.Errorf("failed to get hostname for leader identity: %s", err)
+		}
+		opts.Identity = hostname
+	}
+	if opts.LeaseDuration == 0 {
+		opts.LeaseDuration = DefaultLeaseDuration
+	}
+	if opts.RenewDeadline == 0 {
+		opts.RenewDeadline = DefaultRenewDeadline
+	}
+	if opts.RetryPeriod == 0 {
+		opts.RetryPeriod = DefaultRetryPeriod
+	}
+
+	e := &Elector{
+		opts: opts,
+	}
+
+	elector, err := leaderelection.NewLeaderElector(leaderelection.LeaderElectionConfig{
+		Lock: &resourcelock.LeaseLock{
+			LeaseMeta: metav1.ObjectMeta{
+				Name:      opts.Name,
+				Namespace: opts.Namespace,
+			},
+			Client: opts.Client.CoordinationV1(),
+			LockConfig: resourcelock.ResourceLockConfig{
+				Identity: opts.Identity,
+			},
+		},
+		LeaseDuration:   opts.LeaseDuration,
+		RenewDeadline:   opts.RenewDeadline,
+		RetryPeriod:     opts.RetryPeriod,
+		ReleaseOnCancel: true,
+		Name:            opts.Name,
+		Callbacks: leaderelection.LeaderCallbacks{
+			OnStartedLeading: e.startedLeading,
+			OnStoppedLeading: e.stoppedLeading,
+			OnNewLeader:      e.newLeader,
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+	e.elector = elector
+
+	return e, nil
+}
+
+// OnStartedLeading - registers function that is called once this replica
+// acquires the lease, ctx passed to it is cancelled when leadership is lost.
+// Functions shouldn't block and have to be registered before calling Run
+func (e *Elector) OnStartedLeading(fn func(ctx context.Context)) {
+	e.onStartedLeading = append(e.onStartedLeading, fn)
+}
+
+// Run - campaigns for leadership, blocks until ctx is cancelled or
+// leadership is lost
+func (e *Elector) Run(ctx context.Context) {
+	log.WithFields(log.Fields{
+		"lease":    e.opts.Namespace + "/" + e.opts.Name,
+		"identity": e.opts.Identity,
+	}).Info("leader: waiting for leadership")
+	e.elector.Run(ctx)
+}
+
+// IsLeader - whether this replica currently holds the lease
+func (e *Elector) IsLeader() bool {
+	return e.elector.IsLeader()
+}
+
+// Ready - readiness check, fails on standby replicas so the Service only
+// routes webhooks, approvals and API calls to the leader
+func (e *Elector) Ready() error {
+	if !e.IsLeader() {
+		return ErrNotLeader
+	}
+	return nil
+}
+
+func (e *Elector) startedLeading(ctx context.Context) {
+	log.WithFields(log.Fields{
+		"identity": e.opts.Identity,
+	}).Info("leader: started leading")
+	for _, fn := range e.onStartedLeading {
+		fn(ctx)
+	}
+}
+
+func (e *Elector) stoppedLeading() {
+	log.WithFields(log.Fields{
+		"identity": e.opts.Identity,
+	}).Warn("leader: stopped leading")
+}
+
+func (e *Elector) newLeader(identity string) {
+	if identity == e.opts.Identity {
+		return
+	}
+	log.WithFields(log.Fields{
+		"leader": identity,
+	}).Info("leader: new leader elected")
+}
+
+// CurrentNamespace - namespace keel is running in, falls back to "keel"
+func CurrentNamespace() string {
+	if ns := os.Getenv("POD_NAMESPACE"); ns != "" {
+		return ns
+	}
+	data, err := ioutil.ReadFile(serviceAccountNamespaceFile)
+	if err == nil {
+		if ns := strings.TrimSpace(string(data)); ns != "" {
+			return ns
+		}
+	}
+	return "keel"
+}
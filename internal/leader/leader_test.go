@@ -0,0 +1,63 @@
+package leader
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestElector(t *testing.T) {
+	client := fake.NewSimpleClientset()
+
+	e, err := New(&Opts{
+		Client:        client,
+		Namespace:     "keel",
+		Identity:      "keel-0",
+		LeaseDuration: 2 * time.Second,
+		RenewDeadline: time.Second,
+		RetryPeriod:   100 * time.Millisecond,
+	})
+	if err != nil {
+		t.Fatalf("failed to create elector: %s", err)
+	}
+
+	started := make(chan struct{})
+	e.OnStartedLeading(func(ctx context.Context) {
+		close(started)
+	})
+
+	if e.IsLeader() {
+		t.Fatalf("didn't expect to be a leader before running")
+	}
+	if err := e.Ready(); err != ErrNotLeader {
+		t.Errorf("expected standby to be unready, got: %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go e.Run(ctx)
+
+	select {
+	case <-started:
+	case <-time.After(5 * time.Second):
+		t.Fatalf("expected to acquire leadership")
+	}
+
+	if !e.IsLeader() {
+		t.Errorf("expected to be a leader")
+	}
+	if err := e.Ready(); err != nil {
+		t.Errorf("expected leader to be ready, got: %s", err)
+	}
+
+	lease, err := client.CoordinationV1().Leases("keel").Get(DefaultLeaseName, metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("failed to get lease: %s", err)
+	}
+	if *lease.Spec.HolderIdentity != "keel-0" {
+		t.Errorf("unexpected lease holder: %s", *lease.Spec.HolderIdentity)
+	}
+}
@@ -0,0 +1,111 @@
+package locks
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// ErrTimeout - returned when lock couldn't be acquired before the timeout
+var ErrTimeout = errors.New("timed out while waiting for lock")
+
+// KeyedMutex serializes operations per key (ie: resource identifier). Callers
+// waiting for a busy key give up once the timeout expires and locks held for
+// longer than the timeout are released with a warning, so a hung holder can't
+// block the key forever.
+type KeyedMutex struct {
+	mu      sync.Mutex
+	entries map[string]*entry
+	timeout time.Duration
+}
+
+type entry struct {
+	token chan struct{}
+	// number of holders and waiters, entry is removed once it reaches 0
+	refs int
+}
+
+// New - create new keyed mutex, timeout is a maximum time to wait for the lock
+// and to hold it
+func New(timeout time.Duration) *KeyedMutex {
+	return &KeyedMutex{
+		entries: make(map[string]*entry),
+		timeout: timeout,
+	}
+}
+
+// Lock - acquires lock for the key. Returned context is cancelled once the lock
+// is released, either by calling the returned function or after the timeout,
+// work done under the lock should use it. Returned function releases the lock,
+// it's safe to call it more than once.
+func (m *KeyedMutex) Lock(ctx context.Context, key string) (held context.Context, unlock func(), err error) {
+	m.mu.Lock()
+	e, ok := m.entries[key]
+	if !ok {
+		e = &entry{token: make(chan struct{}, 1)}
+		m.entries[key] = e
+	}
+	e.refs++
+	m.mu.Unlock()
+
+	select {
+	case e.token <- struct{}{}:
+		// acquired straight away
+	default:
+		log.WithFields(log.Fields{
+			"key":     key,
+			"timeout": m.timeout,
+		}).Info("locks: another operation is in progress, waiting for lock")
+
+		timer := time.NewTimer(m.timeout)
+		select {
+		case e.token <- struct{}{}:
+			timer.Stop()
+		case <-timer.C:
+			m.release(key, e)
+			return nil, nil, ErrTimeout
+		case <-ctx.Done():
+			timer.Stop()
+			m.release(key, e)
+			return nil, nil, ctx.Err()
+		}
+	}
+
+	held, cancel := context.WithCancel(ctx)
+
+	var once sync.Once
+	release := func() {
+		once.Do(func() {
+			cancel()
+			<-e.token
+			m.release(key, e)
+		})
+	}
+
+	expired := time.AfterFunc(m.timeout, func() {
+		log.WithFields(log.Fields{
+			"key":     key,
+			"timeout": m.timeout,
+		}).Warn("locks: lock held for too long, releasing it")
+		release()
+	})
+
+	unlock = func() {
+		expired.Stop()
+		release()
+	}
+
+	return held, unlock, nil
+}
+
+func (m *KeyedMutex) release(key string, e *entry) {
+	m.mu.Lock()
+	e.refs--
+	if e.refs == 0 {
+		delete(m.entries, key)
+	}
+	m.mu.Unlock()
+}
@@ -0,0 +1,146 @@
+package locks
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestLockSerializes(t *testing.T) {
+	m := New(time.Second)
+
+	var (
+		wg      sync.WaitGroup
+		mu      sync.Mutex
+		running int
+		maxSeen int
+	)
+
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, unlock, err := m.Lock(context.Background(), "deployment/default/foo")
+			if err != nil {
+				t.Errorf("unexpected error: %s", err)
+				return
+			}
+			defer unlock()
+
+			mu.Lock()
+			running++
+			if running > maxSeen {
+				maxSeen = running
+			}
+			mu.Unlock()
+
+			time.Sleep(5 * time.Millisecond)
+
+			mu.Lock()
+			running--
+			mu.Unlock()
+		}()
+	}
+
+	wg.Wait()
+
+	if maxSeen != 1 {
+		t.Errorf("expected a single holder at a time, got: %d", maxSeen)
+	}
+
+	if len(m.entries) != 0 {
+		t.Errorf("expected entries to be cleaned up, got: %d", len(m.entries))
+	}
+}
+
+func TestLockDifferentKeys(t *testing.T) {
+	m := New(50 * time.Millisecond)
+
+	_, unlockA, err := m.Lock(context.Background(), "deployment/default/a")
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	defer unlockA()
+
+	_, unlockB, err := m.Lock(context.Background(), "deployment/default/b")
+	if err != nil {
+		t.Fatalf("different keys shouldn't block each other, got: %s", err)
+	}
+	unlockB()
+}
+
+func TestLockTimeout(t *testing.T) {
+	m := New(time.Second)
+
+	_, unlock, err := m.Lock(context.Background(), "deployment/default/foo")
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	defer unlock()
+
+	// shorter timeout for the waiter
+	m.timeout = 20 * time.Millisecond
+
+	_, _, err = m.Lock(context.Background(), "deployment/default/foo")
+	if err != ErrTimeout {
+		t.Errorf("expected timeout error, got: %v", err)
+	}
+}
+
+func TestLockWaitCancelled(t *testing.T) {
+	m := New(time.Second)
+
+	_, unlock, err := m.Lock(context.Background(), "deployment/default/foo")
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	defer unlock()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	_, _, err = m.Lock(ctx, "deployment/default/foo")
+	if err != context.DeadlineExceeded {
+		t.Errorf("expected deadline exceeded error, got: %v", err)
+	}
+}
+
+func TestLockReleasedAfterTimeout(t *testing.T) {
+	m := New(20 * time.Millisecond)
+
+	held, unlock, err := m.Lock(context.Background(), "deployment/default/foo")
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	// hung holder, lock is released and its context cancelled
+	select {
+	case <-held.Done():
+	case <-time.After(time.Second):
+		t.Fatalf("expected held context to be cancelled after timeout")
+	}
+
+	heldSecond, second, err := m.Lock(context.Background(), "deployment/default/foo")
+	if err != nil {
+		t.Fatalf("expected lock to be free after timeout, got: %s", err)
+	}
+
+	// late unlock by the hung holder shouldn't affect the new holder
+	unlock()
+
+	m.mu.Lock()
+	refs := m.entries["deployment/default/foo"].refs
+	m.mu.Unlock()
+	if refs != 1 {
+		t.Errorf("expected 1 ref, got: %d", refs)
+	}
+	if heldSecond.Err() != nil {
+		t.Errorf("expected new holder's context to stay active")
+	}
+
+	second()
+	if heldSecond.Err() == nil {
+		t.Errorf("expected context to be cancelled after unlock")
+	}
+}
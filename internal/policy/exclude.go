@@ -0,0 +1,63 @@
+package policy
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/ryanuber/go-glob"
+)
+
+// ExcludeTagsPolicy - wraps a policy, tags matching any of the exclude patterns
+// are never updated to even if the wrapped policy allows them
+type ExcludeTagsPolicy struct {
+	Policy
+
+	globs   []string
+	regexps []*regexp.Regexp
+}
+
+// NewExcludeTagsPolicy - patterns are a comma separated list of globs (ie: *-debug)
+// or regular expressions prefixed with "regexp:" (ie: regexp:^.*-rc[0-9]+$)
+func NewExcludeTagsPolicy(p Policy, patterns string) (*ExcludeTagsPolicy, error) {
+	ep := &ExcludeTagsPolicy{Policy: p}
+	for _, pattern := range strings.Split(patterns, ",") {
+		pattern = strings.TrimSpace(pattern)
+		if pattern == "" {
+			continue
+		}
+		if strings.HasPrefix(pattern, "regexp:") {
+			rx, err := regexp.Compile(strings.TrimPrefix(pattern, "regexp:"))
+			if err != nil {
+				return nil, fmt.Errorf("failed to parse exclude pattern '%s', error: %s", pattern, err)
+			}
+			ep.regexps = append(ep.regexps, rx)
+			continue
+		}
+		ep.globs = append(ep.globs, pattern)
+	}
+
+	return ep, nil
+}
+
+// Excluded - whether tag matches any of the exclude patterns
+func (p *ExcludeTagsPolicy) Excluded(tag string) bool {
+	for _, pattern := range p.globs {
+		if glob.Glob(pattern, tag) {
+			return true
+		}
+	}
+	for _, rx := range p.regexps {
+		if rx.MatchString(tag) {
+			return true
+		}
+	}
+	return false
+}
+
+func (p *ExcludeTagsPolicy) ShouldUpdate(current, new string) (bool, error) {
+	if p.Excluded(new) {
+		return false, nil
+	}
+	return p.Policy.ShouldUpdate(current, new)
+}
@@ -0,0 +1,77 @@
+package policy
+
+import (
+	"testing"
+
+	"github.com/keel-hq/keel/types"
+)
+
+func TestExcludeTagsPolicy_ShouldUpdate(t *testing.T) {
+	p, err := NewExcludeTagsPolicy(NewSemverPolicy(SemverPolicyTypeAll, true), "*-debug, *-rc*,regexp:^2\\.0\\.[0-9]+$")
+	if err != nil {
+		t.Fatalf("failed to create policy: %s", err)
+	}
+
+	tests := []struct {
+		current string
+		new     string
+		want    bool
+	}{
+		{current: "1.0.0", new: "1.1.0", want: true},
+		{current: "1.0.0", new: "1.1.0-debug", want: false},
+		{current: "1.0.0", new: "1.1.0-rc1", want: false},
+		{current: "1.0.0", new: "2.0.1", want: false},
+		{current: "1.0.0", new: "2.1.0", want: true},
+		// not allowed by the wrapped policy
+		{current: "1.1.0", new: "1.0.0", want: false},
+	}
+	for _, tt := range tests {
+		got, err := p.ShouldUpdate(tt.current, tt.new)
+		if err != nil {
+			t.Errorf("%s->%s: unexpected error: %s", tt.current, tt.new, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("%s->%s: got %v, want %v", tt.current, tt.new, got, tt.want)
+		}
+	}
+
+	if p.Type() != PolicyTypeSemver || p.Name() != "all" {
+		t.Errorf("expected type and name of the wrapped policy, got: %v %s", p.Type(), p.Name())
+	}
+}
+
+func TestExcludeTagsInvalidPattern(t *testing.T) {
+	if _, err := NewExcludeTagsPolicy(NewForcePolicy(false), "regexp:(["); err == nil {
+		t.Errorf("expected invalid regexp to be rejected")
+	}
+
+	// not updating at all is safer than updating to excluded tags
+	p := GetPolicyFromLabelsOrAnnotations(map[string]string{}, map[string]string{
+		types.KeelPolicyLabel:           "force",
+		types.KeelExcludeTagsAnnotation: "regexp:([",
+	})
+	if p.Type() != PolicyTypeNone {
+		t.Errorf("expected nil policy, got: %s", p.Name())
+	}
+}
+
+func TestGetPolicyExcludeTags(t *testing.T) {
+	p := GetPolicyFromLabelsOrAnnotations(map[string]string{types.KeelPolicyLabel: "major"}, map[string]string{
+		types.KeelExcludeTagsAnnotation: "*-debug",
+	})
+	if _, ok := p.(*ExcludeTagsPolicy); !ok {
+		t.Fatalf("expected exclude tags policy, got: %T", p)
+	}
+	if update, _ := p.ShouldUpdate("1.0.0", "1.0.1-debug"); update {
+		t.Errorf("expected excluded tag to be rejected")
+	}
+
+	// nothing to exclude from
+	p = GetPolicyFromLabelsOrAnnotations(map[string]string{}, map[string]string{
+		types.KeelExcludeTagsAnnotation: "*-debug",
+	})
+	if p.Type() != PolicyTypeNone {
+		t.Errorf("expected nil policy, got: %s", p.Name())
+	}
+}
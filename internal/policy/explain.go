@@ -0,0 +1,41 @@
+package policy
+
+import (
+	"fmt"
+
+	"github.com/keel-hq/keel/types"
+)
+
+// Explain - why the policy doesn't update current tag to the new one, empty
+// if it does
+func Explain(p Policy, current, new string) string {
+	switch plc := p.(type) {
+	case *ExcludeTagsPolicy:
+		if plc.Excluded(new) {
+			return fmt.Sprintf("tag %s is excluded by %s", new, types.KeelExcludeTagsAnnotation)
+		}
+		return Explain(plc.Policy, current, new)
+	case *SemverPolicy:
+		_, reason, err := plc.check(current, new)
+		if err != nil {
+			return fmt.Sprintf("policy %s failed: %s", plc.Name(), err)
+		}
+		return reason
+	case *ForcePolicy:
+		if plc.matchTag && current != new {
+			return fmt.Sprintf("tag %s doesn't match current tag %s (%s)", new, current, types.KeelForceTagMatchLabel)
+		}
+		return ""
+	case *NilPolicy:
+		return "no policy"
+	}
+
+	update, err := p.ShouldUpdate(current, new)
+	if err != nil {
+		return fmt.Sprintf("policy %s failed: %s", p.Name(), err)
+	}
+	if !update {
+		return fmt.Sprintf("tag %s doesn't match policy %s", new, p.Name())
+	}
+	return ""
+}
@@ -0,0 +1,131 @@
+package policy
+
+import (
+	"testing"
+)
+
+func TestExplain(t *testing.T) {
+	tests := []struct {
+		name    string
+		policy  Policy
+		current string
+		new     string
+		want    string
+	}{
+		{
+			name:    "semver update",
+			policy:  NewSemverPolicy(SemverPolicyTypeMinor, true),
+			current: "1.2.0",
+			new:     "1.3.0",
+			want:    "",
+		},
+		{
+			name:    "semver level",
+			policy:  NewSemverPolicy(SemverPolicyTypeMinor, true),
+			current: "1.2.0",
+			new:     "2.0.0",
+			want:    "2.0.0 is a major update, policy minor allows minor and patch updates",
+		},
+		{
+			name:    "semver patch level",
+			policy:  NewSemverPolicy(SemverPolicyTypePatch, true),
+			current: "1.2.0",
+			new:     "1.3.0",
+			want:    "1.3.0 is not a patch update, policy patch allows patch updates",
+		},
+		{
+			name:    "semver lower version",
+			policy:  NewSemverPolicy(SemverPolicyTypeAll, true),
+			current: "1.2.0",
+			new:     "1.1.0",
+			want:    "1.1.0 is not higher than 1.2.0",
+		},
+		{
+			name:    "semver pre-release",
+			policy:  NewSemverPolicy(SemverPolicyTypeMajor, true),
+			current: "1.2.0",
+			new:     "1.3.0-rc1",
+			want:    "pre-release 'rc1' doesn't match current pre-release ''",
+		},
+		{
+			name:    "semver invalid tag",
+			policy:  NewSemverPolicy(SemverPolicyTypeMajor, true),
+			current: "1.2.0",
+			new:     "latest",
+			want:    "policy major failed: No Major.Minor.Patch elements found",
+		},
+		{
+			name:    "semver build metadata ignored",
+			policy:  NewSemverPolicy(SemverPolicyTypeAll, true),
+			current: "1.2.0+build45",
+			new:     "1.2.0+build46",
+			want:    "1.2.0+build46 differs from 1.2.0+build45 only in build metadata, ordered only with keel.sh/buildMetadata=order",
+		},
+		{
+			name:    "semver version prefix",
+			policy:  withVersionHandling(NewSemverPolicy(SemverPolicyTypeAll, true), VersionPrefixMatch, ""),
+			current: "1.2.0",
+			new:     "v1.3.0",
+			want:    "v prefix of v1.3.0 doesn't match current version 1.2.0 (keel.sh/versionPrefix)",
+		},
+		{
+			name:    "excluded tag",
+			policy:  mustExclude(NewSemverPolicy(SemverPolicyTypeAll, true), "*-debug"),
+			current: "1.2.0",
+			new:     "1.3.0-debug",
+			want:    "tag 1.3.0-debug is excluded by keel.sh/excludeTags",
+		},
+		{
+			name:    "excluded policy rejects",
+			policy:  mustExclude(NewSemverPolicy(SemverPolicyTypePatch, true), "*-debug"),
+			current: "1.2.0",
+			new:     "2.0.0",
+			want:    "2.0.0 is not a patch update, policy patch allows patch updates",
+		},
+		{
+			name:    "force match tag",
+			policy:  NewForcePolicy(true),
+			current: "main",
+			new:     "develop",
+			want:    "tag develop doesn't match current tag main (keel.sh/matchTag)",
+		},
+		{
+			name:    "glob",
+			policy:  mustGlob("glob:release-*"),
+			current: "release-1",
+			new:     "build-2",
+			want:    "tag build-2 doesn't match policy glob:release-*",
+		},
+		{
+			name:    "none",
+			policy:  &NilPolicy{},
+			current: "1.2.0",
+			new:     "1.3.0",
+			want:    "no policy",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Explain(tt.policy, tt.current, tt.new); got != tt.want {
+				t.Errorf("Explain() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func mustExclude(p Policy, patterns string) Policy {
+	ep, err := NewExcludeTagsPolicy(p, patterns)
+	if err != nil {
+		panic(err)
+	}
+	return ep
+}
+
+func mustGlob(policy string) Policy {
+	p, err := NewGlobPolicy(policy)
+	if err != nil {
+		panic(err)
+	}
+	return p
+}
@@ -1,6 +1,8 @@
 package policy
 
 import (
+	"fmt"
+	"sort"
 	"strings"
 
 	"github.com/keel-hq/keel/types"
@@ -16,6 +18,7 @@ const (
 	PolicyTypeForce
 	PolicyTypeGlob
 	PolicyTypeRegexp
+	PolicyTypeRange
 )
 
 type Policy interface {
@@ -35,7 +38,7 @@ func GetPolicyFromLabelsOrAnnotations(labels map[string]string, annotations map[
 
 	policyNameA, ok := getPolicyFromLabels(annotations)
 	if ok {
-		return GetPolicy(policyNameA, &Options{MatchTag: getMatchTag(annotations), MatchPreRelease: getMatchPreRelease(annotations)})
+		return GetPolicy(policyNameA, getOptions(annotations, annotations))
 	}
 
 	policyNameL, ok := getPolicyFromLabels(labels)
@@ -43,53 +46,167 @@ func GetPolicyFromLabelsOrAnnotations(labels map[string]string, annotations map[
 		return &NilPolicy{}
 	}
 
-	return GetPolicy(policyNameL, &Options{MatchTag: getMatchTag(labels), MatchPreRelease: getMatchPreRelease(labels)})
+	return GetPolicy(policyNameL, getOptions(labels, annotations))
+}
+
+// GetContainerPolicy - gets policy for a specific container. Container policy can be
+// overridden with keel.sh/policy.<container name> annotation, otherwise resource
+// policy is used. Containers excluded with keel.sh/ignore-containers or not listed
+// in keel.sh/only-containers are neither polled nor updated
+func GetContainerPolicy(containerName string, resourcePolicy Policy, annotations map[string]string) Policy {
+	if !containerIncluded(containerName, annotations) {
+		return &NilPolicy{}
+	}
+
+	policyName, ok := annotations[types.KeelPolicyLabel+"."+containerName]
+	if !ok {
+		return resourcePolicy
+	}
+	return GetPolicy(policyName, getOptions(annotations, annotations))
+}
+
+func containerIncluded(containerName string, annotations map[string]string) bool {
+	if ignored, ok := annotations[types.KeelIgnoreContainersAnnotation]; ok && containerListed(containerName, ignored) {
+		return false
+	}
+	if only, ok := annotations[types.KeelOnlyContainersAnnotation]; ok {
+		return containerListed(containerName, only)
+	}
+	return true
+}
+
+// containerListed - checks whether container is in comma separated list of names
+func containerListed(containerName, list string) bool {
+	for _, name := range strings.Split(list, ",") {
+		if strings.TrimSpace(name) == containerName {
+			return true
+		}
+	}
+	return false
+}
+
+// HasContainerPolicies - checks whether any per container policy overrides are set
+func HasContainerPolicies(annotations map[string]string) bool {
+	for k := range annotations {
+		if strings.HasPrefix(k, types.KeelPolicyLabel+".") {
+			return true
+		}
+	}
+	return false
 }
 
 // Options - additional options when parsing policy
 type Options struct {
 	MatchTag        bool
 	MatchPreRelease bool
+	// ExcludeTags - comma separated globs or regexp: patterns of tags that
+	// are never updated to
+	ExcludeTags string
+	// VersionPrefix - handling of the "v" prefix of SemVer tags, defaults to
+	// VersionPrefixTolerate
+	VersionPrefix string
+	// BuildMetadata - handling of SemVer build metadata, defaults to
+	// BuildMetadataIgnore
+	BuildMetadata string
+}
+
+// getOptions - policy options from labels (or annotations) the policy was set
+// with, excluded tags are always annotations
+func getOptions(labels map[string]string, annotations map[string]string) *Options {
+	return &Options{
+		MatchTag:        getMatchTag(labels),
+		MatchPreRelease: getMatchPreRelease(labels),
+		ExcludeTags:     annotations[types.KeelExcludeTagsAnnotation],
+		VersionPrefix:   labels[types.KeelVersionPrefixAnnotation],
+		BuildMetadata:   labels[types.KeelBuildMetadataAnnotation],
+	}
 }
 
 // GetPolicy - policy getter used by Helm config
 func GetPolicy(policyName string, options *Options) Policy {
+	p, err := ParsePolicy(policyName, options)
+	if err != nil {
+		log.WithFields(log.Fields{
+			"error":  err,
+			"policy": policyName,
+		}).Error("failed to parse policy, check your deployment configuration")
+		return &NilPolicy{}
+	}
+	return p
+}
 
+// ParsePolicy - same as GetPolicy, but reports invalid or unknown policies
+// and options instead of disabling updates
+func ParsePolicy(policyName string, options *Options) (Policy, error) {
+	p, err := parsePolicy(policyName, options)
+	if err != nil {
+		return nil, err
+	}
+	if options == nil || options.ExcludeTags == "" || p.Type() == PolicyTypeNone {
+		return p, nil
+	}
+
+	ep, err := NewExcludeTagsPolicy(p, options.ExcludeTags)
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s: %s", types.KeelExcludeTagsAnnotation, err)
+	}
+	return ep, nil
+}
+
+func parsePolicy(policyName string, options *Options) (Policy, error) {
 	switch {
 	case strings.HasPrefix(policyName, "glob:"):
-		p, err := NewGlobPolicy(policyName)
-		if err != nil {
-			log.WithFields(log.Fields{
-				"error":  err,
-				"policy": policyName,
-			}).Error("failed to parse glob policy, check your deployment configuration")
-			return &NilPolicy{}
-		}
-		return p
+		return NewGlobPolicy(policyName)
 	case strings.HasPrefix(policyName, "regexp:"):
-		p, err := NewRegexpPolicy(policyName)
-		if err != nil {
-			log.WithFields(log.Fields{
-				"error":  err,
-				"policy": policyName,
-			}).Error("failed to parse regexp policy, check your deployment configuration")
-			return &NilPolicy{}
-		}
-		return p
+		return NewRegexpPolicy(policyName)
+	case strings.HasPrefix(policyName, "range:"):
+		return NewRangePolicy(policyName)
 	}
 
 	switch policyName {
 	case "all", "major", "minor", "patch":
-		return ParseSemverPolicy(policyName, options.MatchPreRelease)
+		p := ParseSemverPolicy(policyName, options.MatchPreRelease).(*SemverPolicy)
+		err := p.SetVersionHandling(options.VersionPrefix, options.BuildMetadata)
+		if err != nil {
+			return nil, err
+		}
+		return p, nil
 	case "force":
-		return NewForcePolicy(options.MatchTag)
-	case "", "never":
-		return &NilPolicy{}
+		return NewForcePolicy(options.MatchTag), nil
+	case "", "never", "none":
+		return &NilPolicy{}, nil
 	}
 
-	log.Infof("policy.GetPolicy: unknown policy '%s', please check your configuration", policyName)
+	return nil, fmt.Errorf("unknown policy '%s'", policyName)
+}
+
+// Validate - errors in policy settings of a resource, resource policy and
+// container overrides (keel.sh/policy.<container name>) are checked
+func Validate(labels map[string]string, annotations map[string]string) []error {
+	var errs []error
+	if name, ok := getPolicyFromLabels(annotations); ok {
+		if _, err := ParsePolicy(name, getOptions(annotations, annotations)); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %s", types.KeelPolicyLabel, err))
+		}
+	} else if name, ok := getPolicyFromLabels(labels); ok {
+		if _, err := ParsePolicy(name, getOptions(labels, annotations)); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %s", types.KeelPolicyLabel, err))
+		}
+	}
 
-	return &NilPolicy{}
+	var overrides []string
+	for k := range annotations {
+		if strings.HasPrefix(k, types.KeelPolicyLabel+".") {
+			overrides = append(overrides, k)
+		}
+	}
+	sort.Strings(overrides)
+	for _, k := range overrides {
+		if _, err := ParsePolicy(annotations[k], getOptions(annotations, annotations)); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %s", k, err))
+		}
+	}
+	return errs
 }
 
 // ParseSemverPolicy - parse policy type
@@ -137,6 +137,30 @@ func TestGetPolicyFromLabelsOrAnnotations(t *testing.T) {
 			},
 			want: NewSemverPolicy(SemverPolicyTypeMinor, false),
 		},
+		{
+			name: "annotation version handling",
+			args: args{
+				labels:      map[string]string{"foo": "bar"},
+				annotations: map[string]string{types.KeelPolicyLabel: "minor", types.KeelVersionPrefixAnnotation: "match", types.KeelBuildMetadataAnnotation: "order"},
+			},
+			want: withVersionHandling(NewSemverPolicy(SemverPolicyTypeMinor, true), VersionPrefixMatch, BuildMetadataOrder),
+		},
+		{
+			name: "default version handling",
+			args: args{
+				labels:      map[string]string{types.KeelPolicyLabel: "minor", types.KeelVersionPrefixAnnotation: "tolerate", types.KeelBuildMetadataAnnotation: "ignore"},
+				annotations: map[string]string{"foo": "bar"},
+			},
+			want: NewSemverPolicy(SemverPolicyTypeMinor, true),
+		},
+		{
+			name: "invalid version handling",
+			args: args{
+				labels:      map[string]string{"foo": "bar"},
+				annotations: map[string]string{types.KeelPolicyLabel: "minor", types.KeelBuildMetadataAnnotation: "sort"},
+			},
+			want: &NilPolicy{},
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -146,3 +170,132 @@ func TestGetPolicyFromLabelsOrAnnotations(t *testing.T) {
 		})
 	}
 }
+
+func withVersionHandling(p *SemverPolicy, versionPrefix, buildMetadata string) *SemverPolicy {
+	err := p.SetVersionHandling(versionPrefix, buildMetadata)
+	if err != nil {
+		panic(err)
+	}
+	return p
+}
+
+func TestGetContainerPolicy(t *testing.T) {
+	resourcePolicy := NewSemverPolicy(SemverPolicyTypeMinor, true)
+
+	tests := []struct {
+		name          string
+		containerName string
+		annotations   map[string]string
+		want          Policy
+	}{
+		{
+			name:          "no override",
+			containerName: "app",
+			annotations:   map[string]string{types.KeelPolicyLabel: "minor"},
+			want:          resourcePolicy,
+		},
+		{
+			name:          "sidecar disabled",
+			containerName: "envoy",
+			annotations:   map[string]string{types.KeelPolicyLabel: "minor", types.KeelPolicyLabel + ".envoy": "none"},
+			want:          &NilPolicy{},
+		},
+		{
+			name:          "other container override",
+			containerName: "app",
+			annotations:   map[string]string{types.KeelPolicyLabel: "minor", types.KeelPolicyLabel + ".envoy": "none"},
+			want:          resourcePolicy,
+		},
+		{
+			name:          "override",
+			containerName: "app",
+			annotations:   map[string]string{types.KeelPolicyLabel + ".app": "patch"},
+			want:          NewSemverPolicy(SemverPolicyTypePatch, true),
+		},
+		{
+			name:          "ignored container",
+			containerName: "istio-proxy",
+			annotations:   map[string]string{types.KeelPolicyLabel: "minor", types.KeelIgnoreContainersAnnotation: "cloudsql-proxy, istio-proxy"},
+			want:          &NilPolicy{},
+		},
+		{
+			name:          "not ignored container",
+			containerName: "app",
+			annotations:   map[string]string{types.KeelPolicyLabel: "minor", types.KeelIgnoreContainersAnnotation: "cloudsql-proxy,istio-proxy"},
+			want:          resourcePolicy,
+		},
+		{
+			name:          "only listed container",
+			containerName: "app",
+			annotations:   map[string]string{types.KeelPolicyLabel: "minor", types.KeelOnlyContainersAnnotation: "app"},
+			want:          resourcePolicy,
+		},
+		{
+			name:          "not in only containers",
+			containerName: "istio-proxy",
+			annotations:   map[string]string{types.KeelPolicyLabel: "minor", types.KeelOnlyContainersAnnotation: "app"},
+			want:          &NilPolicy{},
+		},
+		{
+			name:          "ignore takes precedence over override",
+			containerName: "app",
+			annotations:   map[string]string{types.KeelPolicyLabel + ".app": "patch", types.KeelIgnoreContainersAnnotation: "app"},
+			want:          &NilPolicy{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := GetContainerPolicy(tt.containerName, resourcePolicy, tt.annotations); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("GetContainerPolicy() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name        string
+		labels      map[string]string
+		annotations map[string]string
+		want        []string
+	}{
+		{
+			name:        "valid",
+			labels:      map[string]string{types.KeelPolicyLabel: "minor"},
+			annotations: map[string]string{types.KeelPolicyLabel + ".envoy": "glob:1.*", types.KeelExcludeTagsAnnotation: "*-debug"},
+		},
+		{
+			name: "no policy",
+		},
+		{
+			name:        "unknown policy",
+			annotations: map[string]string{types.KeelPolicyLabel: "minro"},
+			want:        []string{"keel.sh/policy: unknown policy 'minro'"},
+		},
+		{
+			name:        "invalid options and override",
+			labels:      map[string]string{types.KeelPolicyLabel: "all", types.KeelBuildMetadataAnnotation: "sort"},
+			annotations: map[string]string{types.KeelPolicyLabel + ".app": "range:abc", types.KeelPolicyLabel + ".envoy": "force"},
+			want: []string{
+				"keel.sh/policy: unknown build metadata handling 'sort', expected ignore or order",
+				"keel.sh/policy.app: failed to parse range constraint, error: improper constraint: abc",
+			},
+		},
+		{
+			name:        "invalid excluded tags",
+			annotations: map[string]string{types.KeelPolicyLabel: "patch", types.KeelExcludeTagsAnnotation: "regexp:("},
+			want:        []string{"keel.sh/policy: invalid keel.sh/excludeTags: failed to parse exclude pattern 'regexp:(', error: error parsing regexp: missing closing ): `(`"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []string
+			for _, err := range Validate(tt.labels, tt.annotations) {
+				got = append(got, err.Error())
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Validate() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
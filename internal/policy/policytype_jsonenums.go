@@ -14,6 +14,7 @@ var (
 		"PolicyTypeForce":  PolicyTypeForce,
 		"PolicyTypeGlob":   PolicyTypeGlob,
 		"PolicyTypeRegexp": PolicyTypeRegexp,
+		"PolicyTypeRange":  PolicyTypeRange,
 	}
 
 	_PolicyTypeValueToName = map[PolicyType]string{
@@ -22,6 +23,7 @@ var (
 		PolicyTypeForce:  "PolicyTypeForce",
 		PolicyTypeGlob:   "PolicyTypeGlob",
 		PolicyTypeRegexp: "PolicyTypeRegexp",
+		PolicyTypeRange:  "PolicyTypeRange",
 	}
 )
 
@@ -34,6 +36,7 @@ func init() {
 			interface{}(PolicyTypeForce).(fmt.Stringer).String():  PolicyTypeForce,
 			interface{}(PolicyTypeGlob).(fmt.Stringer).String():   PolicyTypeGlob,
 			interface{}(PolicyTypeRegexp).(fmt.Stringer).String(): PolicyTypeRegexp,
+			interface{}(PolicyTypeRange).(fmt.Stringer).String():  PolicyTypeRange,
 		}
 	}
 }
@@ -0,0 +1,84 @@
+package policy
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/Masterminds/semver"
+)
+
+// RangePolicy - semver constraint based policy, ie: range:>=1.4.0 <2.0.0
+type RangePolicy struct {
+	policy     string // original string
+	constraint *semver.Constraints
+}
+
+func NewRangePolicy(policy string) (*RangePolicy, error) {
+	parts := strings.SplitN(policy, ":", 2)
+	if len(parts) != 2 || strings.TrimSpace(parts[1]) == "" {
+		return nil, fmt.Errorf("invalid range policy: %s", policy)
+	}
+
+	constraint, err := semver.NewConstraint(normalizeConstraint(parts[1]))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse range constraint, error: %s", err)
+	}
+
+	return &RangePolicy{
+		policy:     policy,
+		constraint: constraint,
+	}, nil
+}
+
+// ShouldUpdate - new version has to satisfy the constraint and be higher than
+// the current one
+func (p *RangePolicy) ShouldUpdate(current, new string) (bool, error) {
+	newVersion, err := semver.NewVersion(new)
+	if err != nil {
+		return false, fmt.Errorf("failed to parse new version: %s", err)
+	}
+
+	if !p.constraint.Check(newVersion) {
+		return false, nil
+	}
+
+	if current == "latest" {
+		return true, nil
+	}
+
+	currentVersion, err := semver.NewVersion(current)
+	if err != nil {
+		return false, fmt.Errorf("failed to parse current version: %s", err)
+	}
+
+	return currentVersion.LessThan(newVersion), nil
+}
+
+func (p *RangePolicy) Name() string     { return p.policy }
+func (p *RangePolicy) Type() PolicyType { return PolicyTypeRange }
+
+// normalizeConstraint - semver library expects comma separated AND constraints,
+// this allows space separated ones as well (>=1.4.0 <2.0.0)
+func normalizeConstraint(c string) string {
+	ors := strings.Split(c, "||")
+	for i, or := range ors {
+		var ands []string
+		fields := strings.Fields(strings.Replace(or, ",", " ", -1))
+		for j := 0; j < len(fields); j++ {
+			f := fields[j]
+			switch {
+			case strings.Trim(f, "=<>!~^") == "" && j+1 < len(fields):
+				// operator separated from the version by a space
+				f += fields[j+1]
+				j++
+			case j+2 < len(fields) && fields[j+1] == "-":
+				// hyphen range, 1.2 - 1.4
+				f = f + " - " + fields[j+2]
+				j += 2
+			}
+			ands = append(ands, f)
+		}
+		ors[i] = strings.Join(ands, ", ")
+	}
+	return strings.Join(ors, " || ")
+}
@@ -0,0 +1,62 @@
+package policy
+
+import "testing"
+
+func TestNewRangePolicy(t *testing.T) {
+	tests := []struct {
+		name    string
+		policy  string
+		wantErr bool
+	}{
+		{name: "space separated", policy: "range:>=1.4.0 <2.0.0"},
+		{name: "comma separated", policy: "range:>=1.4.0, <2.0.0"},
+		{name: "or", policy: "range:~1.4 || >=2.1"},
+		{name: "hyphen", policy: "range:1.4 - 1.6"},
+		{name: "empty", policy: "range:", wantErr: true},
+		{name: "invalid", policy: "range:>=foo", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewRangePolicy(tt.policy)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("NewRangePolicy() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestRangePolicy_ShouldUpdate(t *testing.T) {
+	tests := []struct {
+		name    string
+		policy  string
+		current string
+		new     string
+		want    bool
+		wantErr bool
+	}{
+		{name: "in range", policy: "range:>=1.4.0 <2.0.0", current: "1.4.0", new: "1.9.3", want: true},
+		{name: "above range", policy: "range:>=1.4.0 <2.0.0", current: "1.4.0", new: "2.0.0", want: false},
+		{name: "below range", policy: "range:>=1.4.0 <2.0.0", current: "1.2.0", new: "1.3.0", want: false},
+		{name: "downgrade", policy: "range:>=1.4.0 <2.0.0", current: "1.6.0", new: "1.5.0", want: false},
+		{name: "same version", policy: "range:>=1.4.0 <2.0.0", current: "1.6.0", new: "1.6.0", want: false},
+		{name: "latest", policy: "range:>=1.4.0 <2.0.0", current: "latest", new: "1.6.0", want: true},
+		{name: "or", policy: "range:~1.4 || >=2.1", current: "1.4.0", new: "2.1.0", want: true},
+		{name: "non semver tag", policy: "range:>=1.4.0", current: "1.4.0", new: "foo", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, err := NewRangePolicy(tt.policy)
+			if err != nil {
+				t.Fatalf("failed to parse policy: %s", err)
+			}
+			got, err := p.ShouldUpdate(tt.current, tt.new)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("RangePolicy.ShouldUpdate() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if got != tt.want {
+				t.Errorf("RangePolicy.ShouldUpdate() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -14,8 +14,9 @@ type RegexpPolicy struct {
 
 func NewRegexpPolicy(policy string) (*RegexpPolicy, error) {
 	if strings.Contains(policy, ":") {
-		parts := strings.Split(policy, ":")
-		if len(parts) == 2 {
+		// pattern itself may contain colons, ie: (?:...) groups
+		parts := strings.SplitN(policy, ":", 2)
+		if len(parts) == 2 && parts[1] != "" {
 
 			rx, err := regexp.Compile(parts[1])
 			if err != nil {
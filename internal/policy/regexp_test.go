@@ -0,0 +1,54 @@
+package policy
+
+import "testing"
+
+func TestNewRegexpPolicy(t *testing.T) {
+	tests := []struct {
+		name    string
+		policy  string
+		wantErr bool
+	}{
+		{name: "simple", policy: `regexp:^prod-\d+$`},
+		{name: "pattern with colons", policy: `regexp:^v(?:\d+)\.(?:\d+)$`},
+		{name: "invalid pattern", policy: `regexp:^prod-(\d+$`, wantErr: true},
+		{name: "empty pattern", policy: `regexp:`, wantErr: true},
+		{name: "missing prefix", policy: `^prod-\d+$`, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewRegexpPolicy(tt.policy)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("NewRegexpPolicy() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestRegexpPolicy_ShouldUpdate(t *testing.T) {
+	tests := []struct {
+		name   string
+		policy string
+		new    string
+		want   bool
+	}{
+		{name: "matching tag", policy: `regexp:^prod-\d+$`, new: "prod-12", want: true},
+		{name: "non matching tag", policy: `regexp:^prod-\d+$`, new: "staging-12", want: false},
+		{name: "partial match", policy: `regexp:^prod-\d+$`, new: "prod-12-rc", want: false},
+		{name: "pattern with colons", policy: `regexp:^v(?:\d+)\.(?:\d+)$`, new: "v1.2", want: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, err := NewRegexpPolicy(tt.policy)
+			if err != nil {
+				t.Fatalf("failed to parse policy: %s", err)
+			}
+			got, err := p.ShouldUpdate("prod-1", tt.new)
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			if got != tt.want {
+				t.Errorf("RegexpPolicy.ShouldUpdate() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -6,6 +6,8 @@ import (
 	"strings"
 
 	"github.com/Masterminds/semver"
+
+	"github.com/keel-hq/keel/types"
 )
 
 // SemverPolicyType - policy type
@@ -41,6 +43,27 @@ func (t SemverPolicyType) String() string {
 	}
 }
 
+// handling of the "v" prefix (v1.2.3), set via keel.sh/versionPrefix
+const (
+	// VersionPrefixTolerate - v1.2.3 and 1.2.3 are the same version, default
+	VersionPrefixTolerate = "tolerate"
+	// VersionPrefixMatch - new tag has to have the prefix if and only if the
+	// current tag has it
+	VersionPrefixMatch = "match"
+	// VersionPrefixReject - tags with the prefix are never updated to
+	VersionPrefixReject = "reject"
+)
+
+// handling of build metadata (1.2.3+build45), set via keel.sh/buildMetadata
+const (
+	// BuildMetadataIgnore - versions differing only in build metadata are
+	// the same version, default
+	BuildMetadataIgnore = "ignore"
+	// BuildMetadataOrder - build metadata orders versions that are otherwise
+	// the same, ie: 1.2.3 < 1.2.3+build45 < 1.2.3+build100
+	BuildMetadataOrder = "order"
+)
+
 func NewSemverPolicy(spt SemverPolicyType, matchPreRelease bool) *SemverPolicy {
 	return &SemverPolicy{
 		spt:             spt,
@@ -51,10 +74,39 @@ func NewSemverPolicy(spt SemverPolicyType, matchPreRelease bool) *SemverPolicy {
 type SemverPolicy struct {
 	spt             SemverPolicyType
 	matchPreRelease bool
+	// empty values are the defaults, tolerate and ignore
+	versionPrefix string
+	buildMetadata string
+}
+
+// SetVersionHandling - sets handling of the "v" prefix and build metadata,
+// empty values keep the defaults
+func (sp *SemverPolicy) SetVersionHandling(versionPrefix, buildMetadata string) error {
+	switch versionPrefix {
+	case "", VersionPrefixTolerate, VersionPrefixMatch, VersionPrefixReject:
+	default:
+		return fmt.Errorf("unknown version prefix handling '%s', expected %s, %s or %s", versionPrefix, VersionPrefixTolerate, VersionPrefixMatch, VersionPrefixReject)
+	}
+	switch buildMetadata {
+	case "", BuildMetadataIgnore, BuildMetadataOrder:
+	default:
+		return fmt.Errorf("unknown build metadata handling '%s', expected %s or %s", buildMetadata, BuildMetadataIgnore, BuildMetadataOrder)
+	}
+
+	if versionPrefix == VersionPrefixTolerate {
+		versionPrefix = ""
+	}
+	if buildMetadata == BuildMetadataIgnore {
+		buildMetadata = ""
+	}
+	sp.versionPrefix = versionPrefix
+	sp.buildMetadata = buildMetadata
+	return nil
 }
 
 func (sp *SemverPolicy) ShouldUpdate(current, new string) (bool, error) {
-	return shouldUpdate(sp.spt, sp.matchPreRelease, current, new)
+	update, _, err := sp.check(current, new)
+	return update, err
 }
 
 func (sp *SemverPolicy) Name() string {
@@ -64,44 +116,152 @@ func (sp *SemverPolicy) Name() string {
 func (sp *SemverPolicy) Type() PolicyType { return PolicyTypeSemver }
 
 func shouldUpdate(spt SemverPolicyType, matchPreRelease bool, current, new string) (bool, error) {
+	return NewSemverPolicy(spt, matchPreRelease).ShouldUpdate(current, new)
+}
+
+// check - reason explains why the new version isn't an update
+func (sp *SemverPolicy) check(current, new string) (update bool, reason string, err error) {
+	spt := sp.spt
 	if current == "latest" {
-		return true, nil
+		return true, "", nil
 	}
 
 	parts := strings.SplitN(new, ".", 3)
 	if len(parts) != 2 && len(parts) != 3 {
-		return false, ErrNoMajorMinorPatchElementsFound
+		return false, "", ErrNoMajorMinorPatchElementsFound
+	}
+
+	switch sp.versionPrefix {
+	case VersionPrefixReject:
+		if hasVersionPrefix(current) {
+			return false, "", fmt.Errorf("current version %s has a v prefix, rejected by %s", current, types.KeelVersionPrefixAnnotation)
+		}
+		if hasVersionPrefix(new) {
+			return false, fmt.Sprintf("%s has a v prefix, rejected by %s", new, types.KeelVersionPrefixAnnotation), nil
+		}
+	case VersionPrefixMatch:
+		if hasVersionPrefix(current) != hasVersionPrefix(new) {
+			return false, fmt.Sprintf("v prefix of %s doesn't match current version %s (%s)", new, current, types.KeelVersionPrefixAnnotation), nil
+		}
 	}
 
 	currentVersion, err := semver.NewVersion(current)
 	if err != nil {
-		return false, fmt.Errorf("failed to parse current version: %s", err)
+		return false, "", fmt.Errorf("failed to parse current version: %s", err)
 	}
 
 	newVersion, err := semver.NewVersion(new)
 	if err != nil {
-		return false, fmt.Errorf("failed to parse new version: %s", err)
+		return false, "", fmt.Errorf("failed to parse new version: %s", err)
 	}
 
 	// Do not enforce pre-release match when either:
 	// - All policy
 	// - matchPreRelease set to false
-	if currentVersion.Prerelease() != newVersion.Prerelease() && spt != SemverPolicyTypeAll && matchPreRelease {
-		return false, nil
+	if currentVersion.Prerelease() != newVersion.Prerelease() && spt != SemverPolicyTypeAll && sp.matchPreRelease {
+		return false, fmt.Sprintf("pre-release '%s' doesn't match current pre-release '%s'", newVersion.Prerelease(), currentVersion.Prerelease()), nil
 	}
 
 	// new version is not higher than current - do nothing
 	if !currentVersion.LessThan(newVersion) {
-		return false, nil
+		if !currentVersion.Equal(newVersion) || currentVersion.Metadata() == newVersion.Metadata() {
+			return false, fmt.Sprintf("%s is not higher than %s", new, current), nil
+		}
+		if sp.buildMetadata != BuildMetadataOrder {
+			return false, fmt.Sprintf("%s differs from %s only in build metadata, ordered only with %s=%s", new, current, types.KeelBuildMetadataAnnotation, BuildMetadataOrder), nil
+		}
+		if CompareBuildMetadata(currentVersion.Metadata(), newVersion.Metadata()) >= 0 {
+			return false, fmt.Sprintf("build metadata of %s is not higher than %s", new, current), nil
+		}
 	}
 
 	switch spt {
 	case SemverPolicyTypeAll, SemverPolicyTypeMajor:
-		return true, nil
+		return true, "", nil
 	case SemverPolicyTypeMinor:
-		return newVersion.Major() == currentVersion.Major(), nil
+		if newVersion.Major() != currentVersion.Major() {
+			return false, fmt.Sprintf("%s is a major update, policy %s allows minor and patch updates", new, spt), nil
+		}
+		return true, "", nil
 	case SemverPolicyTypePatch:
-		return newVersion.Major() == currentVersion.Major() && newVersion.Minor() == currentVersion.Minor(), nil
+		if newVersion.Major() != currentVersion.Major() || newVersion.Minor() != currentVersion.Minor() {
+			return false, fmt.Sprintf("%s is not a patch update, policy %s allows patch updates", new, spt), nil
+		}
+		return true, "", nil
+	}
+	return false, fmt.Sprintf("policy %s allows no updates", spt), nil
+}
+
+func hasVersionPrefix(tag string) bool {
+	return strings.HasPrefix(tag, "v")
+}
+
+// CompareBuildMetadata - compares build metadata of versions, dot separated
+// identifiers are compared in order with digit runs compared numerically
+// (build9 < build10), no metadata is lower than any metadata. Returns -1, 0
+// or 1
+func CompareBuildMetadata(a, b string) int {
+	if a == b {
+		return 0
+	}
+	if a == "" {
+		return -1
+	}
+	if b == "" {
+		return 1
+	}
+
+	ai, bi := strings.Split(a, "."), strings.Split(b, ".")
+	for i := 0; i < len(ai) && i < len(bi); i++ {
+		if c := compareNatural(ai[i], bi[i]); c != 0 {
+			return c
+		}
 	}
-	return false, nil
+	switch {
+	case len(ai) < len(bi):
+		return -1
+	case len(ai) > len(bi):
+		return 1
+	}
+	return 0
+}
+
+// compareNatural - compares strings with digit runs compared numerically
+func compareNatural(a, b string) int {
+	for a != "" && b != "" {
+		ar, br := leadingRun(a), leadingRun(b)
+		a, b = a[len(ar):], b[len(br):]
+		if ar == br {
+			continue
+		}
+		if isDigit(ar[0]) && isDigit(br[0]) {
+			an, bn := strings.TrimLeft(ar, "0"), strings.TrimLeft(br, "0")
+			if len(an) != len(bn) {
+				if len(an) < len(bn) {
+					return -1
+				}
+				return 1
+			}
+			if an != bn {
+				return strings.Compare(an, bn)
+			}
+			continue
+		}
+		return strings.Compare(ar, br)
+	}
+	return strings.Compare(a, b)
+}
+
+// leadingRun - leading digits or leading non digits of the string
+func leadingRun(s string) string {
+	digits := isDigit(s[0])
+	i := 1
+	for i < len(s) && isDigit(s[i]) == digits {
+		i++
+	}
+	return s[:i]
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
 }
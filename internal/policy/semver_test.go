@@ -277,3 +277,79 @@ func Test_shouldUpdate(t *testing.T) {
 		})
 	}
 }
+
+func TestSemverVersionHandling(t *testing.T) {
+	tests := []struct {
+		name          string
+		versionPrefix string
+		buildMetadata string
+		current       string
+		new           string
+		want          bool
+		wantErr       bool
+	}{
+		{name: "prefix tolerated", current: "1.2.3", new: "v1.2.4", want: true},
+		{name: "prefix tolerated, same version", current: "v1.2.3", new: "1.2.3", want: false},
+		{name: "prefix match, both prefixed", versionPrefix: VersionPrefixMatch, current: "v1.2.3", new: "v1.2.4", want: true},
+		{name: "prefix match, new prefixed", versionPrefix: VersionPrefixMatch, current: "1.2.3", new: "v1.2.4", want: false},
+		{name: "prefix match, current prefixed", versionPrefix: VersionPrefixMatch, current: "v1.2.3", new: "1.2.4", want: false},
+		{name: "prefix rejected", versionPrefix: VersionPrefixReject, current: "1.2.3", new: "v1.2.4", want: false},
+		{name: "prefix rejected, unprefixed", versionPrefix: VersionPrefixReject, current: "1.2.3", new: "1.2.4", want: true},
+		{name: "prefix rejected, current prefixed", versionPrefix: VersionPrefixReject, current: "v1.2.3", new: "1.2.4", wantErr: true},
+		{name: "metadata ignored", current: "1.2.3+build45", new: "1.2.3+build46", want: false},
+		{name: "metadata ignored, higher version", current: "1.2.3+build45", new: "1.2.4+build1", want: true},
+		{name: "metadata ordered", buildMetadata: BuildMetadataOrder, current: "1.2.3+build45", new: "1.2.3+build46", want: true},
+		{name: "metadata ordered, numerically", buildMetadata: BuildMetadataOrder, current: "1.2.3+build99", new: "1.2.3+build100", want: true},
+		{name: "metadata ordered, lower", buildMetadata: BuildMetadataOrder, current: "1.2.3+build46", new: "1.2.3+build45", want: false},
+		{name: "metadata ordered, no metadata", buildMetadata: BuildMetadataOrder, current: "1.2.3", new: "1.2.3+build1", want: true},
+		{name: "metadata ordered, lower version", buildMetadata: BuildMetadataOrder, current: "1.2.3+build1", new: "1.2.2+build2", want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := NewSemverPolicy(SemverPolicyTypePatch, true)
+			err := p.SetVersionHandling(tt.versionPrefix, tt.buildMetadata)
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			got, err := p.ShouldUpdate(tt.current, tt.new)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ShouldUpdate() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if got != tt.want {
+				t.Errorf("ShouldUpdate() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	err := NewSemverPolicy(SemverPolicyTypeAll, true).SetVersionHandling("strip", "")
+	if err == nil {
+		t.Errorf("expected error for unknown version prefix handling")
+	}
+	err = NewSemverPolicy(SemverPolicyTypeAll, true).SetVersionHandling("", "sort")
+	if err == nil {
+		t.Errorf("expected error for unknown build metadata handling")
+	}
+}
+
+func TestCompareBuildMetadata(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{"", "", 0},
+		{"", "build1", -1},
+		{"build45", "build46", -1},
+		{"build100", "build99", 1},
+		{"build007", "build7", 0},
+		{"20200101.1", "20200101.2", -1},
+		{"20200101", "20200101.1", -1},
+		{"abc", "abd", -1},
+		{"b2", "b10.x", -1},
+	}
+	for _, tt := range tests {
+		if got := CompareBuildMetadata(tt.a, tt.b); got != tt.want {
+			t.Errorf("CompareBuildMetadata(%s, %s) = %d, want %d", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
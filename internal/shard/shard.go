@@ -0,0 +1,228 @@
+package shard
+
+import (
+	"context"
+	"fmt"
+	"hash/fnv"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	coordinationv1 "k8s.io/api/coordination/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+
+	"github.com/keel-hq/keel/internal/leader"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// defaults
+const (
+	DefaultGroup         = "keel"
+	DefaultLeaseDuration = 15 * time.Second
+	DefaultRenewInterval = 5 * time.Second
+)
+
+// GroupLabel - label of member leases, set to the group name
+const GroupLabel = "keel.sh/shard-group"
+
+// Opts - membership options
+type Opts struct {
+	Client kubernetes.Interface
+
+	// Namespace - where member leases are created, defaults to keel's own namespace
+	Namespace string
+	// Group - replicas in the same group split the work between them
+	Group string
+	// Identity - unique replica identity, defaults to hostname (pod name)
+	Identity string
+
+	// LeaseDuration - members that haven't renewed their lease for this long
+	// are considered gone and their share is taken over by the rest
+	LeaseDuration time.Duration
+	RenewInterval time.Duration
+}
+
+// Membership - tracks live keel replicas through a coordination.k8s.io Lease
+// per replica and splits keys between them with rendezvous hashing, so only
+// keys of the replicas that come and go move
+type Membership struct {
+	opts *Opts
+
+	mu      sync.RWMutex
+	members []string
+}
+
+// New - create new membership, replica owns every key until Run syncs
+// the members
+func New(opts *Opts) (*Membership, error) {
+	if opts.Group == "" {
+		opts.Group = DefaultGroup
+	}
+	if opts.Namespace == "" {
+		opts.Namespace = leader.CurrentNamespace()
+	}
+	if opts.Identity == "" {
+		hostname, err := os.Hostname()
+		if err != nil {
+			return nil, fmt.Errorf("failed to get hostname for shard identity: %s", err)
+		}
+		opts.Identity = hostname
+	}
+	if opts.LeaseDuration == 0 {
+		opts.LeaseDuration = DefaultLeaseDuration
+	}
+	if opts.RenewInterval == 0 {
+		opts.RenewInterval = DefaultRenewInterval
+	}
+
+	return &Membership{
+		opts:    opts,
+		members: []string{opts.Identity},
+	}, nil
+}
+
+// Run - renews this replica's lease and refreshes members until ctx is
+// cancelled, the lease is then deleted so others take over right away
+func (m *Membership) Run(ctx context.Context) {
+	log.WithFields(log.Fields{
+		"group":    m.opts.Namespace + "/" + m.opts.Group,
+		"identity": m.opts.Identity,
+	}).Info("shard: joining group")
+
+	ticker := time.NewTicker(m.opts.RenewInterval)
+	defer ticker.Stop()
+
+	for {
+		err := m.sync()
+		if err != nil {
+			log.WithFields(log.Fields{
+				"error": err,
+				"group": m.opts.Group,
+			}).Error("shard: failed to sync members")
+		}
+
+		select {
+		case <-ctx.Done():
+			m.leave()
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Owns - whether key is assigned to this replica
+func (m *Membership) Owns(key string) bool {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	return owner(m.members, key) == m.opts.Identity
+}
+
+// Members - identities of live replicas, sorted
+func (m *Membership) Members() []string {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	members := make([]string, len(m.members))
+	copy(members, m.members)
+	return members
+}
+
+func (m *Membership) leaseName() string {
+	return m.opts.Group + "-" + strings.ToLower(m.opts.Identity)
+}
+
+// sync - renews own lease and lists leases of other members
+func (m *Membership) sync() error {
+	leases := m.opts.Client.CoordinationV1().Leases(m.opts.Namespace)
+	now := metav1.NewMicroTime(time.Now())
+	durationSeconds := int32(m.opts.LeaseDuration.Seconds())
+
+	lease, err := leases.Get(m.leaseName(), metav1.GetOptions{})
+	switch {
+	case errors.IsNotFound(err):
+		_, err = leases.Create(&coordinationv1.Lease{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      m.leaseName(),
+				Namespace: m.opts.Namespace,
+				Labels:    map[string]string{GroupLabel: m.opts.Group},
+			},
+			Spec: coordinationv1.LeaseSpec{
+				HolderIdentity:       &m.opts.Identity,
+				LeaseDurationSeconds: &durationSeconds,
+				AcquireTime:          &now,
+				RenewTime:            &now,
+			},
+		})
+	case err == nil:
+		lease.Spec.RenewTime = &now
+		lease.Spec.LeaseDurationSeconds = &durationSeconds
+		_, err = leases.Update(lease)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to renew lease: %s", err)
+	}
+
+	list, err := leases.List(metav1.ListOptions{
+		LabelSelector: GroupLabel + "=" + m.opts.Group,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to list leases: %s", err)
+	}
+
+	members := []string{m.opts.Identity}
+	for _, l := range list.Items {
+		if l.Spec.HolderIdentity == nil || *l.Spec.HolderIdentity == m.opts.Identity || l.Spec.RenewTime == nil {
+			continue
+		}
+		if time.Since(l.Spec.RenewTime.Time) > m.opts.LeaseDuration {
+			continue
+		}
+		members = append(members, *l.Spec.HolderIdentity)
+	}
+	sort.Strings(members)
+
+	m.mu.Lock()
+	changed := strings.Join(members, ",") != strings.Join(m.members, ",")
+	m.members = members
+	m.mu.Unlock()
+
+	if changed {
+		log.WithFields(log.Fields{
+			"group":   m.opts.Group,
+			"members": members,
+		}).Info("shard: group members changed")
+	}
+
+	return nil
+}
+
+func (m *Membership) leave() {
+	err := m.opts.Client.CoordinationV1().Leases(m.opts.Namespace).Delete(m.leaseName(), &metav1.DeleteOptions{})
+	if err != nil && !errors.IsNotFound(err) {
+		log.WithFields(log.Fields{
+			"error": err,
+			"group": m.opts.Group,
+		}).Warn("shard: failed to delete lease")
+	}
+}
+
+// owner - member with the highest hash of member and key
+func owner(members []string, key string) string {
+	var selected string
+	var highest uint64
+	for _, member := range members {
+		h := fnv.New64a()
+		h.Write([]byte(member + "/" + key))
+		if sum := h.Sum64(); selected == "" || sum > highest {
+			selected = member
+			highest = sum
+		}
+	}
+	return selected
+}
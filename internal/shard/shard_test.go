@@ -0,0 +1,119 @@
+package shard
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestMembership(t *testing.T) {
+	client := fake.NewSimpleClientset()
+
+	newMember := func(identity string) *Membership {
+		m, err := New(&Opts{
+			Client:    client,
+			Namespace: "keel",
+			Identity:  identity,
+		})
+		if err != nil {
+			t.Fatalf("failed to create membership: %s", err)
+		}
+		return m
+	}
+
+	first := newMember("keel-0")
+	second := newMember("keel-1")
+
+	if !first.Owns("index.docker.io/karolisr/keel") {
+		t.Errorf("expected single member to own every key")
+	}
+
+	for _, m := range []*Membership{first, second, first} {
+		if err := m.sync(); err != nil {
+			t.Fatalf("failed to sync: %s", err)
+		}
+	}
+
+	if members := first.Members(); len(members) != 2 || members[0] != "keel-0" || members[1] != "keel-1" {
+		t.Fatalf("unexpected members: %v", members)
+	}
+
+	for i := 0; i < 100; i++ {
+		key := fmt.Sprintf("index.docker.io/karolisr/keel-%d", i)
+		if first.Owns(key) == second.Owns(key) {
+			t.Errorf("expected key %s to be owned by exactly one member", key)
+		}
+	}
+
+	lease, err := client.CoordinationV1().Leases("keel").Get("keel-keel-0", metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("failed to get lease: %s", err)
+	}
+	if lease.Labels[GroupLabel] != DefaultGroup {
+		t.Errorf("unexpected lease labels: %v", lease.Labels)
+	}
+}
+
+func TestMembershipExpired(t *testing.T) {
+	client := fake.NewSimpleClientset()
+
+	gone, err := New(&Opts{Client: client, Namespace: "keel", Identity: "keel-0"})
+	if err != nil {
+		t.Fatalf("failed to create membership: %s", err)
+	}
+	if err := gone.sync(); err != nil {
+		t.Fatalf("failed to sync: %s", err)
+	}
+
+	m, err := New(&Opts{Client: client, Namespace: "keel", Identity: "keel-1", LeaseDuration: time.Nanosecond})
+	if err != nil {
+		t.Fatalf("failed to create membership: %s", err)
+	}
+	if err := m.sync(); err != nil {
+		t.Fatalf("failed to sync: %s", err)
+	}
+
+	if members := m.Members(); len(members) != 1 || members[0] != "keel-1" {
+		t.Errorf("expected expired member to be ignored, got: %v", members)
+	}
+}
+
+func TestMembershipLeave(t *testing.T) {
+	client := fake.NewSimpleClientset()
+
+	m, err := New(&Opts{Client: client, Namespace: "keel", Identity: "keel-0", RenewInterval: time.Hour})
+	if err != nil {
+		t.Fatalf("failed to create membership: %s", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		m.Run(ctx)
+		close(done)
+	}()
+
+	// waiting for the lease to be created
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		_, err := client.CoordinationV1().Leases("keel").Get("keel-keel-0", metav1.GetOptions{})
+		if err == nil {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("expected lease to be created")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	cancel()
+	<-done
+
+	if _, err := client.CoordinationV1().Leases("keel").Get("keel-keel-0", metav1.GetOptions{}); err == nil {
+		t.Errorf("expected lease to be deleted on leave")
+	}
+}
@@ -1,6 +1,7 @@
 package auth
 
 import (
+	"crypto/subtle"
 	"errors"
 	"fmt"
 	"math/rand"
@@ -56,6 +57,14 @@ type Opts struct {
 	Username string
 	Password string
 
+	// Basic auth of users that can only list resources, approvals and history
+	ReadOnlyUsername string
+	ReadOnlyPassword string
+
+	// Static bearer tokens, ie: for scripts and CI
+	AdminTokens    []string
+	ReadOnlyTokens []string
+
 	// Secret used to sign JWT tokens
 	Secret []byte
 }
@@ -71,13 +80,18 @@ var (
 )
 
 func (a *DefaultAuthenticator) Enabled() bool {
-	return a.opts.Username != "" && a.opts.Password != ""
+	return (a.opts.Username != "" && a.opts.Password != "") ||
+		(a.opts.ReadOnlyUsername != "" && a.opts.ReadOnlyPassword != "") ||
+		len(a.opts.AdminTokens) > 0 || len(a.opts.ReadOnlyTokens) > 0
 }
 
 func (a *DefaultAuthenticator) Authenticate(req *AuthRequest) (*AuthResponse, error) {
 
 	switch req.AuthType {
 	case AuthTypeToken:
+		if role, ok := a.staticTokenRole(req.Token); ok {
+			return &AuthResponse{Token: req.Token, User: User{Username: "token", Role: role}}, nil
+		}
 		user, err := a.parseToken(req.Token)
 		if err != nil {
 			return nil, err
@@ -89,25 +103,68 @@ func (a *DefaultAuthenticator) Authenticate(req *AuthRequest) (*AuthResponse, er
 		return nil, fmt.Errorf("unknown auth type")
 	}
 
-	if a.opts.Username == "" && a.opts.Password == "" {
+	if !a.Enabled() {
 		// if basic auth not set - authenticating as guest
-		return a.GenerateToken(User{Username: "guest"})
+		return a.GenerateToken(User{Username: "guest", Role: RoleAdmin})
 	}
 
-	if req.Username != a.opts.Username || req.Password != a.opts.Password {
-		return nil, ErrUnauthorized
+	if a.opts.Username != "" && equal(req.Username, a.opts.Username) && equal(req.Password, a.opts.Password) {
+		return a.GenerateToken(User{Username: req.Username, Role: RoleAdmin})
 	}
 
-	return a.GenerateToken(User{Username: req.Username})
+	if a.opts.ReadOnlyUsername != "" && equal(req.Username, a.opts.ReadOnlyUsername) && equal(req.Password, a.opts.ReadOnlyPassword) {
+		return a.GenerateToken(User{Username: req.Username, Role: RoleReadOnly})
+	}
+
+	return nil, ErrUnauthorized
 }
 
+func (a *DefaultAuthenticator) staticTokenRole(token string) (Role, bool) {
+	if token == "" {
+		return "", false
+	}
+	for _, t := range a.opts.AdminTokens {
+		if equal(token, t) {
+			return RoleAdmin, true
+		}
+	}
+	for _, t := range a.opts.ReadOnlyTokens {
+		if equal(token, t) {
+			return RoleReadOnly, true
+		}
+	}
+	return "", false
+}
+
+// equal - constant time comparison of credentials
+func equal(a, b string) bool {
+	return subtle.ConstantTimeCompare([]byte(a), []byte(b)) == 1
+}
+
+// Role - what authenticated user is allowed to do
+type Role string
+
+const (
+	// RoleAdmin - full access, including approvals, policy changes and pausing updates
+	RoleAdmin Role = "admin"
+	// RoleReadOnly - can only list resources, approvals and history
+	RoleReadOnly Role = "read-only"
+)
+
 type User struct {
 	Username string
+	Role     Role
+}
+
+// IsAdmin - whether user is allowed to change anything
+func (u *User) IsAdmin() bool {
+	return u.Role == RoleAdmin
 }
 
 func (a *DefaultAuthenticator) GenerateToken(u User) (*AuthResponse, error) {
 	token := jwt.NewWithClaims(jwt.SigningMethodHS512, jwt.MapClaims{
-		"username": "admin",
+		"username": u.Username,
+		"role":     string(u.Role),
 		"exp":      time.Now().Add(expirationDelta).Unix(),
 		"iat":      time.Now().Unix(),
 	})
@@ -120,6 +177,7 @@ func (a *DefaultAuthenticator) GenerateToken(u User) (*AuthResponse, error) {
 
 	return &AuthResponse{
 		Token: tokenString,
+		User:  u,
 	}, nil
 }
 
@@ -138,6 +196,11 @@ func (a *DefaultAuthenticator) parseToken(tokenString string) (*User, error) {
 	if claims, ok := token.Claims.(jwt.MapClaims); ok && token.Valid {
 		user := &User{}
 		user.Username = parseString(claims, "username")
+		user.Role = Role(parseString(claims, "role"))
+		if user.Role == "" {
+			// issued before roles were introduced, only admins could log in
+			user.Role = RoleAdmin
+		}
 		if user.Username == "" {
 			log.WithFields(log.Fields{
 				"token": tokenString,
@@ -22,3 +22,8 @@ func GetAccountFromCtx(ctx context.Context) *User {
 	}
 	return nil
 }
+
+// SetAccountInCtx - sets user details in the context, used by non HTTP APIs
+func SetAccountInCtx(ctx context.Context, u *User) context.Context {
+	return context.WithValue(ctx, authenticationAccountObjectContextKey, u)
+}
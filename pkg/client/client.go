@@ -0,0 +1,297 @@
+// Package client is a Go client for the keel admin API, used by keelctl and
+// usable from other tools that need to drive keel (ie: CI pipelines).
+package client
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/keel-hq/keel/types"
+)
+
+// DefaultTimeout - timeout of regular (non streaming) requests
+const DefaultTimeout = 30 * time.Second
+
+// Opts - client configuration, either basic auth credentials or a token
+// can be set
+type Opts struct {
+	// keel address, ie: http://keel.keel.svc:9300
+	URL string
+
+	Username string
+	Password string
+	Token    string
+
+	Timeout time.Duration
+}
+
+// Client - keel admin API client
+type Client struct {
+	baseURL  string
+	username string
+	password string
+	token    string
+
+	// client for regular requests, streams use the timeout-less one
+	client       *http.Client
+	streamClient *http.Client
+}
+
+// APIError - non 2xx API response
+type APIError struct {
+	StatusCode int
+	Message    string
+}
+
+func (e *APIError) Error() string {
+	if e.Message == "" {
+		return fmt.Sprintf("keel API returned status %d", e.StatusCode)
+	}
+	return fmt.Sprintf("keel API returned status %d: %s", e.StatusCode, e.Message)
+}
+
+// TrackedImage - image tracked by keel, see /v1/tracked
+type TrackedImage struct {
+	Image        string            `json:"image"`
+	Trigger      string            `json:"trigger"`
+	PollSchedule string            `json:"pollSchedule"`
+	Provider     string            `json:"provider"`
+	Cluster      string            `json:"cluster,omitempty"`
+	Namespace    string            `json:"namespace"`
+	Policy       string            `json:"policy"`
+	Registry     string            `json:"registry"`
+	Resources    []string          `json:"resources"`
+	Watch        *types.WatchState `json:"watch,omitempty"`
+}
+
+// New - creates a new API client
+func New(opts *Opts) (*Client, error) {
+	u, err := url.Parse(opts.URL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid keel URL: %s", err)
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return nil, fmt.Errorf("invalid keel URL '%s', expected http or https scheme", opts.URL)
+	}
+
+	timeout := opts.Timeout
+	if timeout == 0 {
+		timeout = DefaultTimeout
+	}
+
+	return &Client{
+		baseURL:      strings.TrimSuffix(opts.URL, "/"),
+		username:     opts.Username,
+		password:     opts.Password,
+		token:        opts.Token,
+		client:       &http.Client{Timeout: timeout},
+		streamClient: &http.Client{},
+	}, nil
+}
+
+// TrackedImages - lists images tracked by keel
+func (c *Client) TrackedImages(ctx context.Context) ([]*TrackedImage, error) {
+	var images []*TrackedImage
+	err := c.do(ctx, "GET", "/v1/tracked", nil, &images)
+	return images, err
+}
+
+// Approvals - lists approvals, status filters them (pending, archived or
+// rejected), empty status returns all approvals
+func (c *Client) Approvals(ctx context.Context, status string) ([]*types.Approval, error) {
+	path := "/v1/approvals"
+	if status != "" {
+		path += "?status=" + url.QueryEscape(status)
+	}
+	var approvals []*types.Approval
+	err := c.do(ctx, "GET", path, nil, &approvals)
+	return approvals, err
+}
+
+type approveRequest struct {
+	Identifier string `json:"identifier"`
+	Voter      string `json:"voter,omitempty"`
+	Action     string `json:"action"`
+}
+
+// Approve - votes for an approval, voter defaults to the authenticated user
+func (c *Client) Approve(ctx context.Context, identifier, voter string) (*types.Approval, error) {
+	var approval types.Approval
+	err := c.do(ctx, "POST", "/v1/approvals", &approveRequest{
+		Identifier: identifier,
+		Voter:      voter,
+		Action:     "approve",
+	}, &approval)
+	if err != nil {
+		return nil, err
+	}
+	return &approval, nil
+}
+
+// Reject - rejects an approval
+func (c *Client) Reject(ctx context.Context, identifier string) (*types.Approval, error) {
+	var approval types.Approval
+	err := c.do(ctx, "POST", "/v1/approvals", &approveRequest{
+		Identifier: identifier,
+		Action:     "reject",
+	}, &approval)
+	if err != nil {
+		return nil, err
+	}
+	return &approval, nil
+}
+
+type resourcePauseRequest struct {
+	Identifier string `json:"identifier"`
+	Paused     bool   `json:"paused"`
+	Cluster    string `json:"cluster,omitempty"`
+}
+
+// PauseResource - pauses or resumes updates of a single resource, identifier
+// is in the "<kind>/<namespace>/<name>" format, ie: deployment/default/wd
+func (c *Client) PauseResource(ctx context.Context, cluster, identifier string, paused bool) error {
+	return c.do(ctx, "PUT", "/v1/resources/pause", &resourcePauseRequest{
+		Identifier: identifier,
+		Paused:     paused,
+		Cluster:    cluster,
+	}, nil)
+}
+
+// Pause - suspends all updates
+func (c *Client) Pause(ctx context.Context) error {
+	return c.do(ctx, "POST", "/v1/pause", nil, nil)
+}
+
+// Resume - resumes all updates, queued events are applied
+func (c *Client) Resume(ctx context.Context) error {
+	return c.do(ctx, "POST", "/v1/resume", nil, nil)
+}
+
+// Stream - subscribes to /v1/stream and calls fn for each event until the
+// context is cancelled, fn error or the server closes the stream
+func (c *Client) Stream(ctx context.Context, fn func(event types.EventNotification) error) error {
+	req, err := c.newRequest(ctx, "GET", "/v1/stream", nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "text/event-stream")
+
+	resp, err := c.streamClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if err := checkResponse(resp); err != nil {
+		return err
+	}
+
+	return readEvents(resp.Body, fn)
+}
+
+// readEvents - parses server-sent events, only data fields are used as
+// event names are included in the notification
+func readEvents(r io.Reader, fn func(event types.EventNotification) error) error {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+
+	var data []string
+	for scanner.Scan() {
+		line := scanner.Text()
+		switch {
+		case line == "":
+			if len(data) == 0 {
+				continue
+			}
+			var event types.EventNotification
+			err := json.Unmarshal([]byte(strings.Join(data, "\n")), &event)
+			data = data[:0]
+			if err != nil {
+				return fmt.Errorf("failed to decode event: %s", err)
+			}
+			if err := fn(event); err != nil {
+				return err
+			}
+		case strings.HasPrefix(line, ":"):
+			// comment, ie: keep-alive
+		case strings.HasPrefix(line, "data:"):
+			data = append(data, strings.TrimPrefix(strings.TrimPrefix(line, "data:"), " "))
+		}
+	}
+
+	return scanner.Err()
+}
+
+func (c *Client) newRequest(ctx context.Context, method, path string, body interface{}) (*http.Request, error) {
+	var reader io.Reader
+	if body != nil {
+		bts, err := json.Marshal(body)
+		if err != nil {
+			return nil, err
+		}
+		reader = bytes.NewReader(bts)
+	}
+
+	req, err := http.NewRequest(method, c.baseURL+path, reader)
+	if err != nil {
+		return nil, err
+	}
+	req = req.WithContext(ctx)
+
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	switch {
+	case c.token != "":
+		req.Header.Set("Authorization", "Bearer "+c.token)
+	case c.username != "":
+		req.SetBasicAuth(c.username, c.password)
+	}
+
+	return req, nil
+}
+
+func (c *Client) do(ctx context.Context, method, path string, body, result interface{}) error {
+	req, err := c.newRequest(ctx, method, path, body)
+	if err != nil {
+		return err
+	}
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if err := checkResponse(resp); err != nil {
+		return err
+	}
+
+	if result == nil {
+		return nil
+	}
+
+	return json.NewDecoder(resp.Body).Decode(result)
+}
+
+func checkResponse(resp *http.Response) error {
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return nil
+	}
+
+	bts, _ := ioutil.ReadAll(io.LimitReader(resp.Body, 4096))
+	return &APIError{
+		StatusCode: resp.StatusCode,
+		Message:    strings.TrimSpace(string(bts)),
+	}
+}
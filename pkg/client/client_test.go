@@ -0,0 +1,135 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/keel-hq/keel/types"
+)
+
+func TestNewInvalidURL(t *testing.T) {
+	if _, err := New(&Opts{URL: "keel:9300"}); err == nil {
+		t.Errorf("expected an error for URL without scheme")
+	}
+}
+
+func TestApprovals(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if user, pass, ok := r.BasicAuth(); !ok || user != "admin" || pass != "secret" {
+			http.Error(w, http.StatusText(http.StatusUnauthorized), http.StatusUnauthorized)
+			return
+		}
+		if r.URL.Path != "/v1/approvals" || r.URL.Query().Get("status") != "pending" {
+			t.Errorf("unexpected request: %s", r.URL)
+		}
+		fmt.Fprint(w, `[{"id": "1", "identifier": "default/wd:1.1.0", "votesRequired": 2}]`)
+	}))
+	defer srv.Close()
+
+	c, err := New(&Opts{URL: srv.URL + "/", Username: "admin", Password: "secret"})
+	if err != nil {
+		t.Fatalf("failed to create client: %s", err)
+	}
+
+	approvals, err := c.Approvals(context.Background(), "pending")
+	if err != nil {
+		t.Fatalf("failed to list approvals: %s", err)
+	}
+	if len(approvals) != 1 || approvals[0].Identifier != "default/wd:1.1.0" || approvals[0].VotesRequired != 2 {
+		t.Errorf("unexpected approvals: %+v", approvals)
+	}
+}
+
+func TestApprove(t *testing.T) {
+	var got approveRequest
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer tkn" {
+			http.Error(w, http.StatusText(http.StatusUnauthorized), http.StatusUnauthorized)
+			return
+		}
+		if err := json.NewDecoder(r.Body).Decode(&got); err != nil {
+			t.Fatalf("failed to decode request: %s", err)
+		}
+		fmt.Fprintf(w, `{"identifier": "%s", "votesReceived": 1}`, got.Identifier)
+	}))
+	defer srv.Close()
+
+	c, err := New(&Opts{URL: srv.URL, Token: "tkn"})
+	if err != nil {
+		t.Fatalf("failed to create client: %s", err)
+	}
+
+	approval, err := c.Approve(context.Background(), "default/wd:1.1.0", "ci")
+	if err != nil {
+		t.Fatalf("failed to approve: %s", err)
+	}
+	if got.Action != "approve" || got.Voter != "ci" {
+		t.Errorf("unexpected request: %+v", got)
+	}
+	if approval.VotesReceived != 1 {
+		t.Errorf("unexpected approval: %+v", approval)
+	}
+
+	if _, err := c.Reject(context.Background(), "default/wd:1.1.0"); err != nil {
+		t.Fatalf("failed to reject: %s", err)
+	}
+	if got.Action != "reject" {
+		t.Errorf("unexpected action: %s", got.Action)
+	}
+}
+
+func TestAPIError(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		if !strings.Contains(string(body), `"paused":true`) {
+			t.Errorf("unexpected body: %s", body)
+		}
+		http.Error(w, "resource with identifier 'deployment/default/wd' not found", http.StatusNotFound)
+	}))
+	defer srv.Close()
+
+	c, err := New(&Opts{URL: srv.URL})
+	if err != nil {
+		t.Fatalf("failed to create client: %s", err)
+	}
+
+	err = c.PauseResource(context.Background(), "", "deployment/default/wd", true)
+	apiErr, ok := err.(*APIError)
+	if !ok {
+		t.Fatalf("expected API error, got: %v", err)
+	}
+	if apiErr.StatusCode != http.StatusNotFound || apiErr.Message != "resource with identifier 'deployment/default/wd' not found" {
+		t.Errorf("unexpected error: %s", apiErr)
+	}
+}
+
+func TestReadEvents(t *testing.T) {
+	stream := ": keep-alive\n\n" +
+		"event: deployment update\ndata: {\"name\": \"update\", \"message\": \"first\"}\n\n" +
+		"event: deployment update\ndata: {\"name\": \"update\", \"message\": \"second\"}\n\n" +
+		"event: deployment update\ndata: {\"name\": \"update\", \"message\": \"third\"}\n\n"
+
+	stop := errors.New("stop")
+	var messages []string
+	err := readEvents(strings.NewReader(stream), func(event types.EventNotification) error {
+		messages = append(messages, event.Message)
+		if len(messages) == 2 {
+			return stop
+		}
+		return nil
+	})
+	if err != stop {
+		t.Errorf("expected callback error, got: %v", err)
+	}
+
+	if strings.Join(messages, ",") != "first,second" {
+		t.Errorf("unexpected messages: %v", messages)
+	}
+}
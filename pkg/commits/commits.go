@@ -0,0 +1,214 @@
+// Package commits looks up Git commits referenced by image tags (ie:
+// 1.4.0-3f2c9a1) in GitHub and GitLab so notifications and approval requests
+// can show what is being shipped.
+package commits
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/keel-hq/keel/types"
+)
+
+// DefaultTagPattern - matches abbreviated or full commit SHA at the end of the
+// tag, ie: 3f2c9a1, 1.4.0-3f2c9a1 or main.3f2c9a1e
+const DefaultTagPattern = `(?:^|[^0-9a-f])([0-9a-f]{7,40})$`
+
+// maxCached - number of looked up commits kept in memory
+const maxCached = 500
+
+// Opts - resolver options, tokens are optional for public repositories
+type Opts struct {
+	// TagPattern - regexp matching commit SHA in tags, first capture group
+	// (or the whole match) is the SHA, defaults to DefaultTagPattern
+	TagPattern  string
+	GithubToken string
+	GitlabToken string
+}
+
+// Resolver - resolves image tags to commits
+type Resolver struct {
+	pattern     *regexp.Regexp
+	githubToken string
+	gitlabToken string
+	client      *http.Client
+
+	mu    sync.Mutex
+	cache map[string]*types.Commit
+}
+
+// New - create new resolver
+func New(opts *Opts) (*Resolver, error) {
+	pattern := opts.TagPattern
+	if pattern == "" {
+		pattern = DefaultTagPattern
+	}
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, fmt.Errorf("invalid commit tag pattern '%s': %s", pattern, err)
+	}
+
+	return &Resolver{
+		pattern:     re,
+		githubToken: opts.GithubToken,
+		gitlabToken: opts.GitlabToken,
+		client:      &http.Client{Timeout: 10 * time.Second},
+		cache:       make(map[string]*types.Commit),
+	}, nil
+}
+
+// SHA - commit SHA referenced by the tag, empty if tag doesn't reference one
+func (r *Resolver) SHA(tag string) string {
+	match := r.pattern.FindStringSubmatch(tag)
+	if match == nil {
+		return ""
+	}
+	if len(match) > 1 && match[1] != "" {
+		return match[1]
+	}
+	return match[0]
+}
+
+// Commit - looks up commit referenced by the tag in the repository (ie:
+// github.com/acme/app or gitlab.example.com/group/app), returns nil if tag
+// doesn't reference a commit
+func (r *Resolver) Commit(repo, tag string) (*types.Commit, error) {
+	sha := r.SHA(tag)
+	if sha == "" || repo == "" {
+		return nil, nil
+	}
+
+	key := repo + "@" + sha
+	r.mu.Lock()
+	cached, ok := r.cache[key]
+	r.mu.Unlock()
+	if ok {
+		return cached, nil
+	}
+
+	host, path, err := parseRepo(repo)
+	if err != nil {
+		return nil, err
+	}
+
+	var commit *types.Commit
+	if strings.Contains(host, "github") {
+		commit, err = r.githubCommit(host, path, sha)
+	} else {
+		commit, err = r.gitlabCommit(host, path, sha)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	r.mu.Lock()
+	if len(r.cache) >= maxCached {
+		r.cache = make(map[string]*types.Commit)
+	}
+	r.cache[key] = commit
+	r.mu.Unlock()
+
+	return commit, nil
+}
+
+// parseRepo - splits repository into host and path, scheme and .git suffix
+// are optional
+func parseRepo(repo string) (host, path string, err error) {
+	repo = strings.TrimSuffix(strings.TrimSpace(repo), ".git")
+	if i := strings.Index(repo, "://"); i >= 0 {
+		repo = repo[i+3:]
+	}
+	parts := strings.SplitN(repo, "/", 2)
+	if len(parts) != 2 || parts[0] == "" || !strings.Contains(parts[1], "/") {
+		return "", "", fmt.Errorf("invalid source repository '%s', expected host/owner/name", repo)
+	}
+	return parts[0], strings.Trim(parts[1], "/"), nil
+}
+
+func (r *Resolver) githubCommit(host, path, sha string) (*types.Commit, error) {
+	apiURL := "https://api.github.com"
+	if host != "github.com" {
+		// GitHub Enterprise
+		apiURL = "https://" + host + "/api/v3"
+	}
+
+	req, err := http.NewRequest(http.MethodGet, fmt.Sprintf("%s/repos/%s/commits/%s", apiURL, path, sha), nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/vnd.github.v3+json")
+	if r.githubToken != "" {
+		req.Header.Set("Authorization", "token "+r.githubToken)
+	}
+
+	var resp struct {
+		SHA     string `json:"sha"`
+		HTMLURL string `json:"html_url"`
+		Commit  struct {
+			Message string `json:"message"`
+			Author  struct {
+				Name string `json:"name"`
+			} `json:"author"`
+		} `json:"commit"`
+	}
+	err = r.do(req, &resp)
+	if err != nil {
+		return nil, err
+	}
+
+	return &types.Commit{
+		SHA:     resp.SHA,
+		Message: resp.Commit.Message,
+		Author:  resp.Commit.Author.Name,
+		URL:     resp.HTMLURL,
+	}, nil
+}
+
+func (r *Resolver) gitlabCommit(host, path, sha string) (*types.Commit, error) {
+	req, err := http.NewRequest(http.MethodGet, fmt.Sprintf("https://%s/api/v4/projects/%s/repository/commits/%s", host, url.PathEscape(path), sha), nil)
+	if err != nil {
+		return nil, err
+	}
+	if r.gitlabToken != "" {
+		req.Header.Set("PRIVATE-TOKEN", r.gitlabToken)
+	}
+
+	var resp struct {
+		ID         string `json:"id"`
+		Message    string `json:"message"`
+		AuthorName string `json:"author_name"`
+		WebURL     string `json:"web_url"`
+	}
+	err = r.do(req, &resp)
+	if err != nil {
+		return nil, err
+	}
+
+	return &types.Commit{
+		SHA:     resp.ID,
+		Message: resp.Message,
+		Author:  resp.AuthorName,
+		URL:     resp.WebURL,
+	}, nil
+}
+
+func (r *Resolver) do(req *http.Request, out interface{}) error {
+	resp, err := r.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := ioutil.ReadAll(resp.Body)
+		return fmt.Errorf("%s %s: unexpected status code %d: %s", req.Method, req.URL, resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
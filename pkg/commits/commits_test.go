@@ -0,0 +1,145 @@
+package commits
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+)
+
+// rewriteTransport - sends all requests to the test server
+type rewriteTransport struct {
+	target *url.URL
+	hosts  []string
+}
+
+func (t *rewriteTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	t.hosts = append(t.hosts, req.URL.Host)
+	req.URL.Scheme = t.target.Scheme
+	req.URL.Host = t.target.Host
+	return http.DefaultTransport.RoundTrip(req)
+}
+
+func TestSHA(t *testing.T) {
+	r, err := New(&Opts{})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	tests := map[string]string{
+		"3f2c9a1":          "3f2c9a1",
+		"1.4.0-3f2c9a1":    "3f2c9a1",
+		"main.3f2c9a1e5b6": "3f2c9a1e5b6",
+		"1.4.0":            "",
+		"latest":           "",
+		"release-abc":      "",
+	}
+	for tag, expected := range tests {
+		if sha := r.SHA(tag); sha != expected {
+			t.Errorf("tag %s: expected '%s', got '%s'", tag, expected, sha)
+		}
+	}
+
+	r, err = New(&Opts{TagPattern: `^build-\d+-g([0-9a-f]+)$`})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if sha := r.SHA("build-42-gdeadbeef"); sha != "deadbeef" {
+		t.Errorf("unexpected sha: %s", sha)
+	}
+
+	_, err = New(&Opts{TagPattern: "("})
+	if err == nil {
+		t.Errorf("expected error for invalid pattern")
+	}
+}
+
+func TestGithubCommit(t *testing.T) {
+	var requests int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		requests++
+		if req.URL.Path != "/repos/acme/app/commits/3f2c9a1" {
+			t.Errorf("unexpected path: %s", req.URL.Path)
+		}
+		if req.Header.Get("Authorization") != "token secret" {
+			t.Errorf("unexpected authorization: %s", req.Header.Get("Authorization"))
+		}
+		w.Write([]byte(`{"sha": "3f2c9a1e5b6d", "html_url": "https://github.com/acme/app/commit/3f2c9a1e5b6d", "commit": {"message": "Fix login redirect\n\nDetails", "author": {"name": "Jane Doe"}}}`))
+	}))
+	defer srv.Close()
+
+	target, _ := url.Parse(srv.URL)
+	transport := &rewriteTransport{target: target}
+	r, _ := New(&Opts{GithubToken: "secret"})
+	r.client = &http.Client{Transport: transport}
+
+	commit, err := r.Commit("https://github.com/acme/app.git", "1.4.0-3f2c9a1")
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if transport.hosts[0] != "api.github.com" {
+		t.Errorf("unexpected API host: %s", transport.hosts[0])
+	}
+	if commit.String() != "3f2c9a1 Fix login redirect (Jane Doe) https://github.com/acme/app/commit/3f2c9a1e5b6d" {
+		t.Errorf("unexpected commit: %s", commit)
+	}
+
+	// cached
+	_, err = r.Commit("https://github.com/acme/app.git", "1.4.0-3f2c9a1")
+	if err != nil || requests != 1 {
+		t.Errorf("expected cached commit, requests: %d, err: %v", requests, err)
+	}
+
+	// tag without commit
+	commit, err = r.Commit("github.com/acme/app", "1.4.0")
+	if err != nil || commit != nil {
+		t.Errorf("expected no commit, got: %v, err: %v", commit, err)
+	}
+}
+
+func TestGitlabCommit(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if req.URL.EscapedPath() != "/api/v4/projects/group%2Fsub%2Fapp/repository/commits/3f2c9a1" {
+			t.Errorf("unexpected path: %s", req.URL.EscapedPath())
+		}
+		if req.Header.Get("PRIVATE-TOKEN") != "secret" {
+			t.Errorf("unexpected token: %s", req.Header.Get("PRIVATE-TOKEN"))
+		}
+		w.Write([]byte(`{"id": "3f2c9a1e5b6d", "message": "Add payments retry", "author_name": "John Roe", "web_url": "https://gitlab.example.com/group/sub/app/-/commit/3f2c9a1e5b6d"}`))
+	}))
+	defer srv.Close()
+
+	target, _ := url.Parse(srv.URL)
+	transport := &rewriteTransport{target: target}
+	r, _ := New(&Opts{GitlabToken: "secret"})
+	r.client = &http.Client{Transport: transport}
+
+	commit, err := r.Commit("gitlab.example.com/group/sub/app", "3f2c9a1")
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if transport.hosts[0] != "gitlab.example.com" {
+		t.Errorf("unexpected API host: %s", transport.hosts[0])
+	}
+	if commit.Author != "John Roe" || commit.Title() != "Add payments retry" {
+		t.Errorf("unexpected commit: %v", commit)
+	}
+}
+
+func TestCommitErrors(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		http.Error(w, "not found", http.StatusNotFound)
+	}))
+	defer srv.Close()
+
+	target, _ := url.Parse(srv.URL)
+	r, _ := New(&Opts{})
+	r.client = &http.Client{Transport: &rewriteTransport{target: target}}
+
+	if _, err := r.Commit("github.com/acme/app", "3f2c9a1"); err == nil {
+		t.Errorf("expected error for missing commit")
+	}
+	if _, err := r.Commit("acme/app", "3f2c9a1"); err == nil {
+		t.Errorf("expected error for repository without host")
+	}
+}
@@ -0,0 +1,109 @@
+// Package cosign verifies image signatures with the cosign binary, which has to
+// be available in PATH. Registry credentials are picked up by cosign itself
+// (ie: from DOCKER_CONFIG).
+package cosign
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// DefaultTimeout - max duration of a single cosign invocation
+const DefaultTimeout = 2 * time.Minute
+
+// Opts - verification configuration, either public keys or keyless identity
+// has to be set
+type Opts struct {
+	// public key references accepted by cosign --key, ie: file paths,
+	// env://VAR, k8s://namespace/secret or KMS URIs. Image is valid if
+	// signed by any of them
+	PublicKeys []string
+
+	// keyless signatures, certificate subject and the issuer that authenticated it
+	Identity   string
+	OIDCIssuer string
+
+	// cosign binary, defaults to cosign
+	Binary  string
+	Timeout time.Duration
+}
+
+// Verifier - verifies image signatures
+type Verifier struct {
+	opts Opts
+}
+
+// New - validates options and creates a new verifier
+func New(opts Opts) (*Verifier, error) {
+	if len(opts.PublicKeys) == 0 && opts.Identity == "" {
+		return nil, fmt.Errorf("either public keys or keyless identity is required")
+	}
+	if opts.Identity != "" && opts.OIDCIssuer == "" {
+		return nil, fmt.Errorf("OIDC issuer is required for keyless verification")
+	}
+	if opts.Binary == "" {
+		opts.Binary = "cosign"
+	}
+	if opts.Timeout == 0 {
+		opts.Timeout = DefaultTimeout
+	}
+	if _, err := exec.LookPath(opts.Binary); err != nil {
+		return nil, fmt.Errorf("cosign binary not found: %s", err)
+	}
+	return &Verifier{opts: opts}, nil
+}
+
+// Verify - checks that image is signed by one of the configured keys or the
+// keyless identity, returned error describes why verification failed
+func (v *Verifier) Verify(ctx context.Context, image string) error {
+	var failures []string
+
+	for _, key := range v.opts.PublicKeys {
+		err := v.run(ctx, "verify", "--key", key, image)
+		if err == nil {
+			return nil
+		}
+		log.WithFields(log.Fields{
+			"image": image,
+			"key":   key,
+			"error": err,
+		}).Debug("cosign: signature not verified with key")
+		failures = append(failures, err.Error())
+	}
+
+	if v.opts.Identity != "" {
+		err := v.run(ctx, "verify", "--certificate-identity", v.opts.Identity, "--certificate-oidc-issuer", v.opts.OIDCIssuer, image)
+		if err == nil {
+			return nil
+		}
+		failures = append(failures, err.Error())
+	}
+
+	return fmt.Errorf("no valid signature found for %s: %s", image, strings.Join(failures, "; "))
+}
+
+func (v *Verifier) run(ctx context.Context, args ...string) error {
+	ctx, cancel := context.WithTimeout(ctx, v.opts.Timeout)
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, v.opts.Binary, args...)
+	// keyless verification would otherwise ask to confirm transparency log uploads
+	cmd.Env = append(os.Environ(), "COSIGN_YES=true")
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("%s: %s", err, lastLine(string(out)))
+	}
+	return nil
+}
+
+// lastLine - cosign prints the reason of failure last
+func lastLine(out string) string {
+	lines := strings.Split(strings.TrimSpace(out), "\n")
+	return strings.TrimSpace(lines[len(lines)-1])
+}
@@ -0,0 +1,71 @@
+package cosign
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// fakeCosign - accepts signatures of "good.pub" key only
+const fakeCosign = `#!/bin/sh
+if [ "$3" = "good.pub" ]; then
+  echo "Verification for $4 -- The following checks were performed"
+  exit 0
+fi
+echo "Error: no matching signatures:" >&2
+echo "main.go:74: error during command execution: no matching signatures" >&2
+exit 1
+`
+
+func newFakeVerifier(t *testing.T, keys ...string) (*Verifier, func()) {
+	dir, err := ioutil.TempDir("", "keel-cosign")
+	if err != nil {
+		t.Fatalf("failed to create temp dir: %s", err)
+	}
+	binary := filepath.Join(dir, "cosign")
+	err = ioutil.WriteFile(binary, []byte(fakeCosign), 0755)
+	if err != nil {
+		t.Fatalf("failed to write fake cosign: %s", err)
+	}
+
+	v, err := New(Opts{PublicKeys: keys, Binary: binary})
+	if err != nil {
+		t.Fatalf("failed to create verifier: %s", err)
+	}
+	return v, func() { os.RemoveAll(dir) }
+}
+
+func TestVerify(t *testing.T) {
+	v, teardown := newFakeVerifier(t, "other.pub", "good.pub")
+	defer teardown()
+
+	err := v.Verify(context.Background(), "gcr.io/v2-namespace/hello-world:1.1.1")
+	if err != nil {
+		t.Errorf("expected image signed by one of the keys to be valid, got: %s", err)
+	}
+}
+
+func TestVerifyInvalid(t *testing.T) {
+	v, teardown := newFakeVerifier(t, "other.pub")
+	defer teardown()
+
+	err := v.Verify(context.Background(), "gcr.io/v2-namespace/hello-world:1.1.1")
+	if err == nil {
+		t.Fatalf("expected verification to fail")
+	}
+	if !strings.Contains(err.Error(), "error during command execution: no matching signatures") {
+		t.Errorf("unexpected error: %s", err)
+	}
+}
+
+func TestNewRequiresKeysOrIdentity(t *testing.T) {
+	if _, err := New(Opts{}); err == nil {
+		t.Errorf("expected error without keys or identity")
+	}
+	if _, err := New(Opts{Identity: "ci@example.com"}); err == nil {
+		t.Errorf("expected error without OIDC issuer")
+	}
+}
@@ -0,0 +1,399 @@
+// Package gitops writes image updates back to manifests stored in a Git
+// repository so clusters reconciled by Flux or Argo CD don't revert them.
+// Repository is managed with the git binary which has to be available in PATH.
+package gitops
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// Opts - git write-back configuration
+type Opts struct {
+	// repository URL, ie: https://github.com/org/manifests.git
+	URL string
+	// branch manifests are read from and committed to
+	Branch string
+	// manifests directory relative to repository root, whole repository if empty
+	Path string
+
+	// HTTPS credentials, token is also used to open pull requests
+	Username string
+	Token    string
+
+	AuthorName  string
+	AuthorEmail string
+
+	// push updates to a new branch and open a pull request against Branch
+	// instead of committing to it directly (GitHub only)
+	PullRequest bool
+
+	// local clone location
+	WorkDir string
+}
+
+// ImageChange - image reference replaced by the update
+type ImageChange struct {
+	Previous string
+	New      string
+}
+
+// Change - updated resource
+type Change struct {
+	Kind      string
+	Namespace string
+	Name      string
+	Images    []ImageChange
+}
+
+func (c *Change) String() string {
+	var images []string
+	for _, img := range c.Images {
+		images = append(images, fmt.Sprintf("%s -> %s", img.Previous, img.New))
+	}
+	return fmt.Sprintf("%s %s/%s: %s", c.Kind, c.Namespace, c.Name, strings.Join(images, ", "))
+}
+
+// Writer - commits changes to the repository, writes are serialized as they
+// share the same clone
+type Writer struct {
+	opts Opts
+
+	mu     sync.Mutex
+	cloned bool
+
+	// GitHub API base URL, overridden in tests
+	apiURL string
+	client *http.Client
+}
+
+// New - validates options and creates a new writer, repository is cloned on first write
+func New(opts Opts) (*Writer, error) {
+	if opts.URL == "" {
+		return nil, fmt.Errorf("repository URL is required")
+	}
+	if opts.Branch == "" {
+		opts.Branch = "master"
+	}
+	if opts.AuthorName == "" {
+		opts.AuthorName = "keel"
+	}
+	if opts.AuthorEmail == "" {
+		opts.AuthorEmail = "keel@keel.sh"
+	}
+	if opts.WorkDir == "" {
+		opts.WorkDir = filepath.Join(os.TempDir(), "keel-gitops")
+	}
+	if opts.PullRequest && opts.Token == "" {
+		return nil, fmt.Errorf("token is required to open pull requests")
+	}
+	if _, err := exec.LookPath("git"); err != nil {
+		return nil, fmt.Errorf("git binary not found: %s", err)
+	}
+
+	return &Writer{
+		opts:   opts,
+		apiURL: "https://api.github.com",
+		client: &http.Client{Timeout: 30 * time.Second},
+	}, nil
+}
+
+// Write - replaces previous images of the resource in manifests, commits and
+// pushes the change. Nothing is committed if manifests already use new images
+func (w *Writer) Write(ctx context.Context, change *Change) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	err := w.sync(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to sync repository: %s", err)
+	}
+
+	files, err := w.updateManifests(change)
+	if err != nil {
+		return err
+	}
+	if len(files) == 0 {
+		log.WithFields(log.Fields{
+			"kind":      change.Kind,
+			"namespace": change.Namespace,
+			"name":      change.Name,
+		}).Warn("gitops: no manifests found referencing previous images, nothing to commit")
+		return nil
+	}
+
+	branch := w.opts.Branch
+	if w.opts.PullRequest {
+		branch = prBranch(change)
+		if _, err := w.git(ctx, "checkout", "-B", branch); err != nil {
+			return err
+		}
+	}
+
+	title := "Update " + change.String()
+	args := append([]string{"add", "--"}, files...)
+	if _, err := w.git(ctx, args...); err != nil {
+		return err
+	}
+	if _, err := w.git(ctx, "-c", "user.name="+w.opts.AuthorName, "-c", "user.email="+w.opts.AuthorEmail, "commit", "-m", title); err != nil {
+		return err
+	}
+	if _, err := w.git(ctx, "push", "--force-with-lease", "origin", "HEAD:refs/heads/"+branch); err != nil {
+		return err
+	}
+
+	log.WithFields(log.Fields{
+		"kind":      change.Kind,
+		"namespace": change.Namespace,
+		"name":      change.Name,
+		"branch":    branch,
+		"files":     strings.Join(files, ", "),
+	}).Info("gitops: update committed")
+
+	if !w.opts.PullRequest {
+		return nil
+	}
+	return w.openPullRequest(ctx, branch, title)
+}
+
+// sync - clones repository or resets the clone to the latest remote branch
+func (w *Writer) sync(ctx context.Context) error {
+	if !w.cloned {
+		err := os.RemoveAll(w.opts.WorkDir)
+		if err != nil {
+			return err
+		}
+		remote, err := w.remoteURL()
+		if err != nil {
+			return err
+		}
+		_, err = w.run(ctx, "", "clone", "--branch", w.opts.Branch, remote, w.opts.WorkDir)
+		if err != nil {
+			return err
+		}
+		w.cloned = true
+		return nil
+	}
+
+	if _, err := w.git(ctx, "fetch", "origin", w.opts.Branch); err != nil {
+		return err
+	}
+	if _, err := w.git(ctx, "checkout", "-B", w.opts.Branch, "origin/"+w.opts.Branch); err != nil {
+		return err
+	}
+	_, err := w.git(ctx, "reset", "--hard", "origin/"+w.opts.Branch)
+	return err
+}
+
+func (w *Writer) remoteURL() (string, error) {
+	if w.opts.Token == "" {
+		return w.opts.URL, nil
+	}
+	u, err := url.Parse(w.opts.URL)
+	if err != nil {
+		return "", fmt.Errorf("invalid repository URL: %s", err)
+	}
+	if u.Scheme != "https" && u.Scheme != "http" {
+		// ssh remotes use their own keys
+		return w.opts.URL, nil
+	}
+	username := w.opts.Username
+	if username == "" {
+		username = "keel"
+	}
+	u.User = url.UserPassword(username, w.opts.Token)
+	return u.String(), nil
+}
+
+// updateManifests - returns changed files relative to repository root
+func (w *Writer) updateManifests(change *Change) ([]string, error) {
+	var changed []string
+
+	root := filepath.Join(w.opts.WorkDir, w.opts.Path)
+	err := filepath.Walk(root, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() {
+			if info.Name() == ".git" {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		switch strings.ToLower(filepath.Ext(path)) {
+		case ".yaml", ".yml", ".json":
+		default:
+			return nil
+		}
+
+		contents, err := ioutil.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		updated, ok := updateManifest(contents, change)
+		if !ok {
+			return nil
+		}
+		err = ioutil.WriteFile(path, updated, info.Mode())
+		if err != nil {
+			return err
+		}
+
+		rel, err := filepath.Rel(w.opts.WorkDir, path)
+		if err != nil {
+			return err
+		}
+		changed = append(changed, rel)
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to update manifests: %s", err)
+	}
+	return changed, nil
+}
+
+var documentSeparator = regexp.MustCompile(`(?m)^---\s*$`)
+
+// updateManifest - replaces previous images in documents describing the resource,
+// other resources using the same images are left unchanged
+func updateManifest(contents []byte, change *Change) ([]byte, bool) {
+	separators := documentSeparator.FindAllIndex(contents, -1)
+
+	var out bytes.Buffer
+	changed := false
+	start := 0
+	for i := 0; i <= len(separators); i++ {
+		end := len(contents)
+		if i < len(separators) {
+			end = separators[i][0]
+		}
+		doc := contents[start:end]
+		if describes(doc, change) {
+			for _, img := range change.Images {
+				updated := replaceImage(doc, img)
+				if !bytes.Equal(updated, doc) {
+					doc = updated
+					changed = true
+				}
+			}
+		}
+		out.Write(doc)
+		if i < len(separators) {
+			out.Write(contents[separators[i][0]:separators[i][1]])
+			start = separators[i][1]
+		}
+	}
+
+	return out.Bytes(), changed
+}
+
+// describes - checks whether manifest document is of the resource kind and name
+func describes(doc []byte, change *Change) bool {
+	kind := regexp.MustCompile(`(?mi)^\s*"?kind"?\s*:\s*"?` + regexp.QuoteMeta(change.Kind) + `"?\s*,?\s*$`)
+	name := regexp.MustCompile(`(?m)^\s*"?name"?\s*:\s*"?` + regexp.QuoteMeta(change.Name) + `"?\s*,?\s*$`)
+	return kind.Match(doc) && name.Match(doc)
+}
+
+// replaceImage - replaces whole image references only, "app:1.0" doesn't match "app:1.0.1"
+func replaceImage(doc []byte, img ImageChange) []byte {
+	re := regexp.MustCompile(`(image"?\s*:\s*"?)` + regexp.QuoteMeta(img.Previous) + `("?\s*,?\s*)$`)
+	lines := bytes.Split(doc, []byte("\n"))
+	for i, line := range lines {
+		lines[i] = re.ReplaceAll(line, []byte("${1}"+img.New+"${2}"))
+	}
+	return bytes.Join(lines, []byte("\n"))
+}
+
+var invalidBranchChars = regexp.MustCompile(`[^a-zA-Z0-9._-]+`)
+
+func prBranch(change *Change) string {
+	var tags []string
+	for _, img := range change.Images {
+		ref := img.New
+		if idx := strings.LastIndex(ref, ":"); idx > strings.LastIndex(ref, "/") {
+			ref = ref[idx+1:]
+		}
+		tags = append(tags, ref)
+	}
+	name := fmt.Sprintf("%s-%s-%s", change.Namespace, change.Name, strings.Join(tags, "-"))
+	return "keel/" + strings.Trim(invalidBranchChars.ReplaceAllString(name, "-"), "-")
+}
+
+var githubRepo = regexp.MustCompile(`github\.com[:/]([^/]+)/([^/]+?)(\.git)?/?$`)
+
+func (w *Writer) openPullRequest(ctx context.Context, branch, title string) error {
+	matches := githubRepo.FindStringSubmatch(w.opts.URL)
+	if matches == nil {
+		return fmt.Errorf("pull requests are only supported for GitHub repositories, change pushed to branch %s", branch)
+	}
+
+	body, err := json.Marshal(map[string]string{
+		"title": title,
+		"head":  branch,
+		"base":  w.opts.Branch,
+		"body":  "Automated image update by Keel.",
+	})
+	if err != nil {
+		return err
+	}
+
+	endpoint := fmt.Sprintf("%s/repos/%s/%s/pulls", w.apiURL, matches[1], matches[2])
+	req, err := http.NewRequest(http.MethodPost, endpoint, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req = req.WithContext(ctx)
+	req.Header.Set("Authorization", "token "+w.opts.Token)
+	req.Header.Set("Accept", "application/vnd.github.v3+json")
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := w.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to open pull request: %s", err)
+	}
+	defer resp.Body.Close()
+
+	// 422 - pull request for the branch is already open, it was updated by the push
+	if resp.StatusCode == http.StatusUnprocessableEntity {
+		return nil
+	}
+	if resp.StatusCode != http.StatusCreated {
+		respBody, _ := ioutil.ReadAll(resp.Body)
+		return fmt.Errorf("failed to open pull request, status: %d, response: %s", resp.StatusCode, string(respBody))
+	}
+	return nil
+}
+
+func (w *Writer) git(ctx context.Context, args ...string) (string, error) {
+	return w.run(ctx, w.opts.WorkDir, args...)
+}
+
+func (w *Writer) run(ctx context.Context, dir string, args ...string) (string, error) {
+	cmd := exec.CommandContext(ctx, "git", args...)
+	cmd.Dir = dir
+	cmd.Env = append(os.Environ(), "GIT_TERMINAL_PROMPT=0")
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		// output and arguments may contain the token
+		msg := string(out)
+		if w.opts.Token != "" {
+			msg = strings.Replace(msg, w.opts.Token, "******", -1)
+		}
+		return "", fmt.Errorf("git %s failed: %s: %s", args[0], err, strings.TrimSpace(msg))
+	}
+	return string(out), nil
+}
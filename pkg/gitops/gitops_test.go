@@ -0,0 +1,142 @@
+package gitops
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestUpdateManifest(t *testing.T) {
+	manifest := `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: frontend
+spec:
+  template:
+    spec:
+      containers:
+        - name: frontend
+          image: "gcr.io/v2-namespace/app:1.0"
+---
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: backend
+spec:
+  template:
+    spec:
+      containers:
+        - name: backend
+          image: gcr.io/v2-namespace/app:1.0
+        - name: sidecar
+          image: gcr.io/v2-namespace/app:1.0.1
+`
+	change := &Change{
+		Kind:      "deployment",
+		Namespace: "default",
+		Name:      "backend",
+		Images:    []ImageChange{{Previous: "gcr.io/v2-namespace/app:1.0", New: "gcr.io/v2-namespace/app:1.1"}},
+	}
+
+	updated, ok := updateManifest([]byte(manifest), change)
+	if !ok {
+		t.Fatalf("expected manifest to be updated")
+	}
+
+	expected := strings.Replace(manifest, "          image: gcr.io/v2-namespace/app:1.0\n", "          image: gcr.io/v2-namespace/app:1.1\n", 1)
+	if string(updated) != expected {
+		t.Errorf("unexpected manifest:\n%s", string(updated))
+	}
+
+	change.Name = "other"
+	if _, ok := updateManifest([]byte(manifest), change); ok {
+		t.Errorf("expected manifests of other resources to be left unchanged")
+	}
+}
+
+func TestPRBranch(t *testing.T) {
+	branch := prBranch(&Change{
+		Namespace: "default",
+		Name:      "backend",
+		Images:    []ImageChange{{Previous: "localhost:5000/app:1.0", New: "localhost:5000/app:1.1"}},
+	})
+	if branch != "keel/default-backend-1.1" {
+		t.Errorf("unexpected branch: %s", branch)
+	}
+}
+
+func git(t *testing.T, dir string, args ...string) {
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		t.Fatalf("git %s failed: %s: %s", strings.Join(args, " "), err, out)
+	}
+}
+
+func TestWrite(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git binary not found")
+	}
+
+	dir, err := ioutil.TempDir("", "keel-gitops")
+	if err != nil {
+		t.Fatalf("failed to create temp dir: %s", err)
+	}
+	defer os.RemoveAll(dir)
+
+	// repository with a single manifest, pushed to a bare remote
+	remote := filepath.Join(dir, "remote.git")
+	src := filepath.Join(dir, "src")
+	git(t, dir, "init", "--bare", remote)
+	git(t, dir, "init", src)
+	err = os.MkdirAll(filepath.Join(src, "apps"), 0755)
+	if err != nil {
+		t.Fatalf("failed to create manifests dir: %s", err)
+	}
+	manifest := "kind: Deployment\nmetadata:\n  name: backend\nspec:\n  template:\n    spec:\n      containers:\n        - image: app:1.0\n"
+	err = ioutil.WriteFile(filepath.Join(src, "apps", "backend.yaml"), []byte(manifest), 0644)
+	if err != nil {
+		t.Fatalf("failed to write manifest: %s", err)
+	}
+	git(t, src, "add", ".")
+	git(t, src, "-c", "user.name=test", "-c", "user.email=test@test", "commit", "-m", "init")
+	git(t, src, "push", remote, "HEAD:refs/heads/master")
+
+	w, err := New(Opts{
+		URL:     remote,
+		Branch:  "master",
+		Path:    "apps",
+		WorkDir: filepath.Join(dir, "work"),
+	})
+	if err != nil {
+		t.Fatalf("failed to create writer: %s", err)
+	}
+
+	change := &Change{
+		Kind:      "deployment",
+		Namespace: "default",
+		Name:      "backend",
+		Images:    []ImageChange{{Previous: "app:1.0", New: "app:1.1"}},
+	}
+	for i := 0; i < 2; i++ {
+		// second write finds nothing to change
+		err = w.Write(context.Background(), change)
+		if err != nil {
+			t.Fatalf("failed to write change: %s", err)
+		}
+	}
+
+	git(t, src, "pull", remote, "master")
+	contents, err := ioutil.ReadFile(filepath.Join(src, "apps", "backend.yaml"))
+	if err != nil {
+		t.Fatalf("failed to read manifest: %s", err)
+	}
+	if !strings.Contains(string(contents), "- image: app:1.1\n") {
+		t.Errorf("expected image to be updated, got:\n%s", string(contents))
+	}
+}
@@ -6,6 +6,8 @@ import (
 	"net/http"
 	"strconv"
 
+	"github.com/keel-hq/keel/approvals"
+	"github.com/keel-hq/keel/pkg/auth"
 	"github.com/keel-hq/keel/pkg/store"
 	"github.com/keel-hq/keel/types"
 )
@@ -25,34 +27,65 @@ const (
 	actionArchive = "archive"
 )
 
+// approval list filters, ie: /v1/approvals?status=pending
+const (
+	approvalStatusPending  = "pending"
+	approvalStatusArchived = "archived"
+	approvalStatusRejected = "rejected"
+)
+
 func (s *TriggerServer) approvalsHandler(resp http.ResponseWriter, req *http.Request) {
 
 	// lists all (both archived)
-	approvals, err := s.store.ListApprovals(&types.GetApprovalQuery{})
+	all, err := s.store.ListApprovals(&types.GetApprovalQuery{})
 	if err != nil {
-		fmt.Fprintf(resp, "%s", err)
 		resp.WriteHeader(http.StatusInternalServerError)
+		fmt.Fprintf(resp, "%s", err)
 		return
 	}
 
-	if len(approvals) == 0 {
-		approvals = make([]*types.Approval, 0)
+	status := req.URL.Query().Get("status")
+	switch status {
+	case "", approvalStatusPending, approvalStatusArchived, approvalStatusRejected:
+	default:
+		http.Error(resp, fmt.Sprintf("unknown status '%s', expected pending, archived or rejected", status), http.StatusBadRequest)
+		return
+	}
+
+	approvals := make([]*types.Approval, 0, len(all))
+	for _, a := range all {
+		if approvalHasStatus(a, status) {
+			approvals = append(approvals, a)
+		}
 	}
 
 	bts, err := json.Marshal(&approvals)
 	if err != nil {
-		fmt.Fprintf(resp, "%s", err)
 		resp.WriteHeader(http.StatusInternalServerError)
+		fmt.Fprintf(resp, "%s", err)
 		return
 	}
 
 	resp.Write(bts)
 }
 
+func approvalHasStatus(a *types.Approval, status string) bool {
+	switch status {
+	case approvalStatusPending:
+		return !a.Archived && !a.Rejected && !a.Expired()
+	case approvalStatusArchived:
+		return a.Archived
+	case approvalStatusRejected:
+		return a.Rejected
+	}
+	return true
+}
+
 type resourceApprovalsUpdateRequest struct {
 	Identifier    string `json:"identifier"`
 	Provider      string `json:"provider"`
 	VotesRequired int    `json:"votesRequired"`
+	Cluster       string `json:"cluster,omitempty"`
 }
 
 // approvalSetHandler allows to set/remove approvals for resources
@@ -87,23 +120,20 @@ func (s *TriggerServer) approvalSetHandler(resp http.ResponseWriter, req *http.R
 		return
 	}
 
-	for _, v := range s.grc.Values() {
-		if v.Identifier == approvalUpdateRequest.Identifier {
+	if v, ok := s.findResource(approvalUpdateRequest.Cluster, approvalUpdateRequest.Identifier); ok {
+		labels := v.GetLabels()
+		delete(labels, types.KeelMinimumApprovalsLabel)
+		v.SetLabels(labels)
 
-			labels := v.GetLabels()
-			delete(labels, types.KeelMinimumApprovalsLabel)
-			v.SetLabels(labels)
+		ann := v.GetAnnotations()
+		ann[types.KeelMinimumApprovalsLabel] = strconv.Itoa(approvalUpdateRequest.VotesRequired)
 
-			ann := v.GetAnnotations()
-			ann[types.KeelMinimumApprovalsLabel] = strconv.Itoa(approvalUpdateRequest.VotesRequired)
+		v.SetAnnotations(ann)
 
-			v.SetAnnotations(ann)
+		err := v.client.Update(v.GenericResource)
 
-			err := s.kubernetesClient.Update(v)
-
-			response(&APIResponse{Status: "updated"}, 200, err, resp, req)
-			return
-		}
+		response(&APIResponse{Status: "updated"}, 200, err, resp, req)
+		return
 	}
 
 	resp.WriteHeader(http.StatusNotFound)
@@ -133,12 +163,21 @@ func (s *TriggerServer) approvalApproveHandler(resp http.ResponseWriter, req *ht
 	// checking action
 	switch ar.Action {
 	case actionReject:
-		approval, err = s.approvalsManager.Reject(ar.Identifier)
+		if ar.Voter == "" {
+			if user := auth.GetAccountFromCtx(req.Context()); user != nil {
+				ar.Voter = user.Username
+			}
+		}
+		approval, err = s.approvalsManager.Reject(ar.Identifier, ar.Voter)
 		if err != nil {
 			if err == store.ErrRecordNotFound {
 				http.Error(resp, fmt.Sprintf("approval '%s' not found", ar.Identifier), http.StatusNotFound)
 				return
 			}
+			if err == approvals.ErrNotApprover {
+				http.Error(resp, err.Error(), http.StatusForbidden)
+				return
+			}
 			resp.WriteHeader(http.StatusInternalServerError)
 			fmt.Fprintf(resp, "%s", err)
 			return
@@ -150,13 +189,21 @@ func (s *TriggerServer) approvalApproveHandler(resp http.ResponseWriter, req *ht
 				ar.ID = existing.ID
 			}
 		}
+		if ar.ID == "" {
+			http.Error(resp, fmt.Sprintf("approval '%s' not found", ar.Identifier), http.StatusNotFound)
+			return
+		}
 		// deleting it
 		err := s.approvalsManager.Delete(&types.Approval{
 			ID: ar.ID,
 		})
 		if err != nil {
-			fmt.Fprintf(resp, "%s", err)
+			if err == store.ErrRecordNotFound {
+				http.Error(resp, fmt.Sprintf("approval '%s' not found", ar.Identifier), http.StatusNotFound)
+				return
+			}
 			resp.WriteHeader(http.StatusInternalServerError)
+			fmt.Fprintf(resp, "%s", err)
 			return
 		}
 	case actionArchive:
@@ -176,19 +223,29 @@ func (s *TriggerServer) approvalApproveHandler(resp http.ResponseWriter, req *ht
 		// deleting it
 		err := s.approvalsManager.Archive(ar.Identifier)
 		if err != nil {
-			fmt.Fprintf(resp, "%s", err)
 			resp.WriteHeader(http.StatusInternalServerError)
+			fmt.Fprintf(resp, "%s", err)
 			return
 		}
 
 	default:
-		// "" or "approve"
+		// "" or "approve", votes are attributed to the authenticated
+		// user unless voter is set
+		if ar.Voter == "" {
+			if user := auth.GetAccountFromCtx(req.Context()); user != nil {
+				ar.Voter = user.Username
+			}
+		}
 		approval, err = s.approvalsManager.Approve(ar.Identifier, ar.Voter)
 		if err != nil {
 			if err == store.ErrRecordNotFound {
 				http.Error(resp, fmt.Sprintf("approval '%s' not found", ar.Identifier), http.StatusNotFound)
 				return
 			}
+			if err == approvals.ErrNotApprover {
+				http.Error(resp, err.Error(), http.StatusForbidden)
+				return
+			}
 			resp.WriteHeader(http.StatusInternalServerError)
 			fmt.Fprintf(resp, "%s", err)
 			return
@@ -486,6 +486,76 @@ func TestReject(t *testing.T) {
 
 }
 
+func TestRejectNotApprover(t *testing.T) {
+	fp := &fakeProvider{}
+	store, teardown := NewTestingUtils()
+	defer teardown()
+
+	am := approvals.New(&approvals.Opts{
+		Store:  store,
+		Groups: approvals.StaticGroups{"team-payments": {"alice"}},
+	})
+	authenticator := auth.New(&auth.Opts{
+		Username: "admin",
+		Password: "pass",
+	})
+
+	providers := provider.New([]provider.Provider{fp}, am)
+	srv := NewTriggerServer(&Opts{
+		Providers:       providers,
+		ApprovalManager: am,
+		Authenticator:   authenticator,
+		Store:           store,
+	})
+	srv.registerRoutes(srv.router)
+
+	err := am.Create(&types.Approval{
+		Identifier:     "dev/12345",
+		VotesRequired:  1,
+		NewVersion:     "2.0.0",
+		CurrentVersion: "1.0.0",
+		Approvers:      "team-payments",
+	})
+	if err != nil {
+		t.Fatalf("failed to create approval: %s", err)
+	}
+
+	// vote is attributed to the authenticated user
+	req, err := http.NewRequest("POST", "/v1/approvals", bytes.NewBufferString(`{"action": "reject", "identifier":"dev/12345"}`))
+	if err != nil {
+		t.Fatalf("failed to create req: %s", err)
+	}
+	req.SetBasicAuth("admin", "pass")
+
+	rec := httptest.NewRecorder()
+	srv.router.ServeHTTP(rec, req)
+	if rec.Code != http.StatusForbidden {
+		t.Errorf("unexpected status code: %d", rec.Code)
+		t.Log(rec.Body.String())
+	}
+
+	req, err = http.NewRequest("POST", "/v1/approvals", bytes.NewBufferString(`{"voter": "alice", "action": "reject", "identifier":"dev/12345"}`))
+	if err != nil {
+		t.Fatalf("failed to create req: %s", err)
+	}
+	req.SetBasicAuth("admin", "pass")
+
+	rec = httptest.NewRecorder()
+	srv.router.ServeHTTP(rec, req)
+	if rec.Code != 200 {
+		t.Errorf("unexpected status code: %d", rec.Code)
+		t.Log(rec.Body.String())
+	}
+
+	rejected, err := am.Get("dev/12345")
+	if err != nil {
+		t.Fatalf("failed to get approval: %s", err)
+	}
+	if !rejected.Rejected {
+		t.Errorf("expected to find approval rejected")
+	}
+}
+
 func TestAuthListApprovalsA(t *testing.T) {
 
 	fp := &fakeProvider{}
@@ -609,3 +679,179 @@ func TestAuthListApprovalsB(t *testing.T) {
 		t.Errorf("unexpected current version: %s", approvals[0].CurrentVersion)
 	}
 }
+
+func TestListPendingApprovals(t *testing.T) {
+	fp := &fakeProvider{}
+	store, teardown := NewTestingUtils()
+	defer teardown()
+
+	am := approvals.New(&approvals.Opts{
+		Store: store,
+	})
+
+	authenticator := auth.New(&auth.Opts{
+		Username: "admin",
+		Password: "pass",
+	})
+
+	providers := provider.New([]provider.Provider{fp}, am)
+	srv := NewTriggerServer(&Opts{
+		Providers:       providers,
+		ApprovalManager: am,
+		Authenticator:   authenticator,
+		Store:           store,
+	})
+	srv.registerRoutes(srv.router)
+
+	for _, a := range []*types.Approval{
+		{Identifier: "pending", VotesRequired: 1, Deadline: time.Now().Add(time.Hour)},
+		{Identifier: "rejected", VotesRequired: 1, Deadline: time.Now().Add(time.Hour)},
+		{Identifier: "expired", VotesRequired: 1, Deadline: time.Now().Add(-time.Hour)},
+	} {
+		if err := am.Create(a); err != nil {
+			t.Fatalf("failed to create approval: %s", err)
+		}
+	}
+	if _, err := am.Reject("rejected", ""); err != nil {
+		t.Fatalf("failed to reject approval: %s", err)
+	}
+
+	list := func(query string) (int, []*types.Approval) {
+		req, err := http.NewRequest("GET", "/v1/approvals"+query, nil)
+		if err != nil {
+			t.Fatalf("failed to create req: %s", err)
+		}
+		req.SetBasicAuth("admin", "pass")
+
+		rec := httptest.NewRecorder()
+		srv.router.ServeHTTP(rec, req)
+
+		var approvals []*types.Approval
+		if rec.Code == 200 {
+			if err := json.Unmarshal(rec.Body.Bytes(), &approvals); err != nil {
+				t.Fatalf("failed to unmarshal response into approvals: %s", err)
+			}
+		}
+		return rec.Code, approvals
+	}
+
+	if _, all := list(""); len(all) != 3 {
+		t.Errorf("expected to find 3 approvals but found: %d", len(all))
+	}
+
+	_, pending := list("?status=pending")
+	if len(pending) != 1 || pending[0].Identifier != "pending" {
+		t.Errorf("unexpected pending approvals: %v", pending)
+	}
+
+	_, rejected := list("?status=rejected")
+	if len(rejected) != 1 || rejected[0].Identifier != "rejected" {
+		t.Errorf("unexpected rejected approvals: %v", rejected)
+	}
+
+	if code, _ := list("?status=whatever"); code != http.StatusBadRequest {
+		t.Errorf("unexpected status code for unknown status: %d", code)
+	}
+}
+
+func TestApproveDefaultVoter(t *testing.T) {
+	fp := &fakeProvider{}
+	store, teardown := NewTestingUtils()
+	defer teardown()
+
+	am := approvals.New(&approvals.Opts{
+		Store: store,
+	})
+	authenticator := auth.New(&auth.Opts{
+		Username: "admin",
+		Password: "pass",
+	})
+
+	providers := provider.New([]provider.Provider{fp}, am)
+	srv := NewTriggerServer(&Opts{
+		Providers:       providers,
+		ApprovalManager: am,
+		Authenticator:   authenticator,
+		Store:           store,
+	})
+	srv.registerRoutes(srv.router)
+
+	err := am.Create(&types.Approval{
+		Identifier:     "dev/whd-dev:0.0.15",
+		VotesRequired:  5,
+		NewVersion:     "2.0.0",
+		CurrentVersion: "1.0.0",
+	})
+	if err != nil {
+		t.Fatalf("failed to create approval: %s", err)
+	}
+
+	req, err := http.NewRequest("POST", "/v1/approvals", bytes.NewBufferString(`{"identifier": "dev/whd-dev:0.0.15"}`))
+	if err != nil {
+		t.Fatalf("failed to create req: %s", err)
+	}
+	req.SetBasicAuth("admin", "pass")
+
+	rec := httptest.NewRecorder()
+	srv.router.ServeHTTP(rec, req)
+	if rec.Code != 200 {
+		t.Fatalf("unexpected status code: %d", rec.Code)
+	}
+
+	approved, err := am.Get("dev/whd-dev:0.0.15")
+	if err != nil {
+		t.Fatalf("failed to get approval: %s", err)
+	}
+
+	voters := approved.GetVoters()
+	if len(voters) != 1 || voters[0] != "admin" {
+		t.Errorf("expected vote from authenticated user, got: %v", voters)
+	}
+}
+
+func TestDeleteApprovalNotFound(t *testing.T) {
+	fp := &fakeProvider{}
+	store, teardown := NewTestingUtils()
+	defer teardown()
+
+	am := approvals.New(&approvals.Opts{
+		Store: store,
+	})
+	authenticator := auth.New(&auth.Opts{
+		Username: "admin",
+		Password: "pass",
+	})
+
+	providers := provider.New([]provider.Provider{fp}, am)
+	srv := NewTriggerServer(&Opts{
+		Providers:       providers,
+		ApprovalManager: am,
+		Authenticator:   authenticator,
+		Store:           store,
+	})
+	srv.registerRoutes(srv.router)
+
+	err := am.Create(&types.Approval{
+		Identifier:    "dev/whd-dev:0.0.15",
+		VotesRequired: 5,
+	})
+	if err != nil {
+		t.Fatalf("failed to create approval: %s", err)
+	}
+
+	req, err := http.NewRequest("POST", "/v1/approvals", bytes.NewBufferString(`{"action": "delete","identifier": "dev/other:0.0.1"}`))
+	if err != nil {
+		t.Fatalf("failed to create req: %s", err)
+	}
+	req.SetBasicAuth("admin", "pass")
+
+	rec := httptest.NewRecorder()
+	srv.router.ServeHTTP(rec, req)
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("unexpected status code: %d", rec.Code)
+	}
+
+	if _, err := am.Get("dev/whd-dev:0.0.15"); err != nil {
+		t.Errorf("unrelated approval shouldn't be deleted: %s", err)
+	}
+}
@@ -0,0 +1,109 @@
+package http
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/keel-hq/keel/types"
+	"github.com/prometheus/client_golang/prometheus"
+
+	log "github.com/sirupsen/logrus"
+)
+
+var newArtifactoryWebhooksCounter = prometheus.NewCounterVec(
+	prometheus.CounterOpts{
+		Name: "artifactory_webhook_requests_total",
+		Help: "How many /v1/webhooks/artifactory requests processed, partitioned by image.",
+	},
+	[]string{"image"},
+)
+
+func init() {
+	prometheus.MustRegister(newArtifactoryWebhooksCounter)
+}
+
+// Example of artifactory docker trigger
+// {
+//   "domain": "docker",
+//   "event_type": "pushed",
+//   "data": {
+//     "repo_key": "docker-local",
+//     "event_type": "pushed",
+//     "path": "library/alpine/3.12/manifest.json",
+//     "name": "manifest.json",
+//     "sha256": "a15790640a6690aa1730c38cf0a440e2aa44aaca9b0e8931a9f2b0d7cc90fd65",
+//     "size": 1576,
+//     "image_name": "library/alpine",
+//     "tag": "3.12"
+//   },
+//   "subscription_key": "keel",
+//   "jpd_origin": "https://example.jfrog.io",
+//   "source": "jfrog/user"
+// }
+
+type artifactoryWebhook struct {
+	Domain    string `json:"domain"`
+	EventType string `json:"event_type"`
+	Data      struct {
+		RepoKey   string `json:"repo_key"`
+		Path      string `json:"path"`
+		Sha256    string `json:"sha256"`
+		ImageName string `json:"image_name"`
+		Tag       string `json:"tag"`
+	} `json:"data"`
+	JPDOrigin string `json:"jpd_origin"`
+}
+
+// artifactoryHandler - handles docker push events. By default images are
+// expected to be pulled using repository path method (<host>/<repo key>/<image>).
+// Images pulled through virtual repositories or subdomain/port methods can
+// set "prefix" query parameter, ie: /v1/webhooks/artifactory?prefix=example.jfrog.io/docker-virtual
+// or /v1/webhooks/artifactory?prefix=docker.example.com
+func (s *TriggerServer) artifactoryHandler(resp http.ResponseWriter, req *http.Request) {
+	aw := artifactoryWebhook{}
+	if err := json.NewDecoder(req.Body).Decode(&aw); err != nil {
+		log.WithFields(log.Fields{
+			"error": err,
+		}).Error("trigger.artifactoryHandler: failed to decode request")
+		resp.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	if aw.Domain != "docker" || aw.EventType != "pushed" {
+		log.WithFields(log.Fields{
+			"domain":     aw.Domain,
+			"event_type": aw.EventType,
+		}).Debug("trigger.artifactoryHandler: ignoring non docker push event")
+		resp.WriteHeader(http.StatusOK)
+		return
+	}
+
+	if aw.Data.ImageName == "" || aw.Data.Tag == "" {
+		resp.WriteHeader(http.StatusBadRequest)
+		fmt.Fprintf(resp, "image_name and tag cannot be empty")
+		return
+	}
+
+	prefix := strings.TrimSpace(req.URL.Query().Get("prefix"))
+	if prefix == "" {
+		host := strings.TrimPrefix(strings.TrimPrefix(aw.JPDOrigin, "https://"), "http://")
+		prefix = strings.TrimSuffix(host, "/") + "/" + aw.Data.RepoKey
+	}
+
+	event := types.Event{}
+	event.CreatedAt = time.Now()
+	event.TriggerName = "artifactory"
+	event.Repository.Name = strings.TrimSuffix(prefix, "/") + "/" + aw.Data.ImageName
+	event.Repository.Tag = aw.Data.Tag
+	if aw.Data.Sha256 != "" {
+		event.Repository.Digest = "sha256:" + aw.Data.Sha256
+	}
+
+	s.trigger(req.Context(), event)
+	newArtifactoryWebhooksCounter.With(prometheus.Labels{"image": event.Repository.Name}).Inc()
+
+	resp.WriteHeader(http.StatusOK)
+}
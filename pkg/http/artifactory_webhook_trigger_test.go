@@ -0,0 +1,96 @@
+package http
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+var fakeArtifactoryWebhook = `{
+  "domain": "docker",
+  "event_type": "pushed",
+  "data": {
+    "repo_key": "docker-local",
+    "event_type": "pushed",
+    "path": "library/alpine/3.12/manifest.json",
+    "name": "manifest.json",
+    "sha256": "a15790640a6690aa1730c38cf0a440e2aa44aaca9b0e8931a9f2b0d7cc90fd65",
+    "size": 1576,
+    "image_name": "library/alpine",
+    "tag": "3.12"
+  },
+  "subscription_key": "keel",
+  "jpd_origin": "https://example.jfrog.io",
+  "source": "jfrog/user"
+}
+`
+
+func TestArtifactoryWebhookHandler(t *testing.T) {
+	tests := []struct {
+		name     string
+		query    string
+		wantName string
+	}{
+		{name: "repository path", query: "", wantName: "example.jfrog.io/docker-local/library/alpine"},
+		{name: "virtual repository", query: "?prefix=example.jfrog.io/docker-virtual", wantName: "example.jfrog.io/docker-virtual/library/alpine"},
+		{name: "subdomain", query: "?prefix=docker.example.com/", wantName: "docker.example.com/library/alpine"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fp := &fakeProvider{}
+			srv, teardown := NewTestingServer(fp)
+			defer teardown()
+
+			req, err := http.NewRequest("POST", "/v1/webhooks/artifactory"+tt.query, bytes.NewBuffer([]byte(fakeArtifactoryWebhook)))
+			if err != nil {
+				t.Fatalf("failed to create req: %s", err)
+			}
+
+			rec := httptest.NewRecorder()
+			srv.router.ServeHTTP(rec, req)
+			if rec.Code != 200 {
+				t.Fatalf("unexpected status code: %d, body: %s", rec.Code, rec.Body.String())
+			}
+
+			if len(fp.submitted) != 1 {
+				t.Fatalf("unexpected number of events submitted: %d", len(fp.submitted))
+			}
+
+			if fp.submitted[0].Repository.Name != tt.wantName {
+				t.Errorf("expected %s but got %s", tt.wantName, fp.submitted[0].Repository.Name)
+			}
+
+			if fp.submitted[0].Repository.Tag != "3.12" {
+				t.Errorf("expected 3.12 but got %s", fp.submitted[0].Repository.Tag)
+			}
+
+			if fp.submitted[0].Repository.Digest != "sha256:a15790640a6690aa1730c38cf0a440e2aa44aaca9b0e8931a9f2b0d7cc90fd65" {
+				t.Errorf("unexpected digest: %s", fp.submitted[0].Repository.Digest)
+			}
+		})
+	}
+}
+
+func TestArtifactoryWebhookHandlerIgnoresOtherEvents(t *testing.T) {
+	fp := &fakeProvider{}
+	srv, teardown := NewTestingServer(fp)
+	defer teardown()
+
+	body := `{"domain": "docker", "event_type": "deleted", "data": {"repo_key": "docker-local", "image_name": "library/alpine", "tag": "3.12"}}`
+	req, err := http.NewRequest("POST", "/v1/webhooks/artifactory", bytes.NewBuffer([]byte(body)))
+	if err != nil {
+		t.Fatalf("failed to create req: %s", err)
+	}
+
+	rec := httptest.NewRecorder()
+	srv.router.ServeHTTP(rec, req)
+	if rec.Code != 200 {
+		t.Errorf("unexpected status code: %d", rec.Code)
+	}
+
+	if len(fp.submitted) != 0 {
+		t.Errorf("unexpected number of events submitted: %d", len(fp.submitted))
+	}
+}
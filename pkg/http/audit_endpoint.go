@@ -1,13 +1,17 @@
 package http
 
 import (
+	"fmt"
 	"net/http"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/keel-hq/keel/types"
 )
 
+// adminAuditLogHandler - returns paginated audit logs, can be filtered by
+// resource kind, action, user email, namespace, name, image and time range
 func (s *TriggerServer) adminAuditLogHandler(resp http.ResponseWriter, req *http.Request) {
 
 	query := &types.AuditLogQuery{}
@@ -38,6 +42,28 @@ func (s *TriggerServer) adminAuditLogHandler(resp http.ResponseWriter, req *http
 		query.Email = strings.TrimSpace(emailFilter)
 	}
 
+	actionFilter := req.URL.Query().Get("action")
+	if actionFilter != "" {
+		query.ActionFilter = strings.Split(actionFilter, ",")
+	}
+
+	query.Namespace = strings.TrimSpace(req.URL.Query().Get("namespace"))
+	query.Name = strings.TrimSpace(req.URL.Query().Get("name"))
+	query.Image = strings.TrimSpace(req.URL.Query().Get("image"))
+
+	var err error
+	query.From, err = parseHistoryTime(req.URL.Query().Get("from"))
+	if err != nil {
+		http.Error(resp, fmt.Sprintf("invalid from: %s", err), http.StatusBadRequest)
+		return
+	}
+
+	query.To, err = parseHistoryTime(req.URL.Query().Get("to"))
+	if err != nil {
+		http.Error(resp, fmt.Sprintf("invalid to: %s", err), http.StatusBadRequest)
+		return
+	}
+
 	entries, err := s.store.GetAuditLogs(query)
 	if err != nil {
 		response(nil, 500, err, resp, req)
@@ -58,6 +84,91 @@ func (s *TriggerServer) adminAuditLogHandler(resp http.ResponseWriter, req *http
 	response(result, http.StatusOK, err, resp, req)
 }
 
+const defaultHistoryLimit = 100
+
+// adminHistoryHandler - returns paginated update history, can be filtered by
+// namespace, name, image and time range
+func (s *TriggerServer) adminHistoryHandler(resp http.ResponseWriter, req *http.Request) {
+	q := req.URL.Query()
+
+	query := &types.AuditLogQuery{
+		ResourceKindFilter: []string{"*"},
+		ActionFilter: []string{
+			types.NotificationDeploymentUpdate.String(),
+			types.NotificationReleaseUpdate.String(),
+		},
+		Namespace: strings.TrimSpace(q.Get("namespace")),
+		Name:      strings.TrimSpace(q.Get("name")),
+		Image:     strings.TrimSpace(q.Get("image")),
+	}
+
+	var err error
+	if limitS := q.Get("limit"); limitS != "" {
+		query.Limit, err = strconv.Atoi(limitS)
+		if err != nil {
+			http.Error(resp, fmt.Sprintf("invalid limit: %s", err), http.StatusBadRequest)
+			return
+		}
+	}
+
+	if offsetS := q.Get("offset"); offsetS != "" {
+		query.Offset, err = strconv.Atoi(offsetS)
+		if err != nil {
+			http.Error(resp, fmt.Sprintf("invalid offset: %s", err), http.StatusBadRequest)
+			return
+		}
+	}
+
+	if query.Limit == 0 {
+		query.Limit = defaultHistoryLimit
+	}
+
+	query.From, err = parseHistoryTime(q.Get("from"))
+	if err != nil {
+		http.Error(resp, fmt.Sprintf("invalid from: %s", err), http.StatusBadRequest)
+		return
+	}
+
+	query.To, err = parseHistoryTime(q.Get("to"))
+	if err != nil {
+		http.Error(resp, fmt.Sprintf("invalid to: %s", err), http.StatusBadRequest)
+		return
+	}
+
+	entries, err := s.store.GetAuditLogs(query)
+	if err != nil {
+		response(nil, 500, err, resp, req)
+		return
+	}
+
+	result := auditLogsResponse{
+		Data:   entries,
+		Offset: query.Offset,
+		Limit:  query.Limit,
+	}
+
+	count, err := s.store.AuditLogsCount(query)
+	if err == nil {
+		result.Total = count
+	}
+
+	response(result, http.StatusOK, err, resp, req)
+}
+
+// parseHistoryTime - accepts either RFC3339 timestamp or a duration relative
+// to now (ie: 24h for the last 24 hours)
+func parseHistoryTime(val string) (time.Time, error) {
+	if val == "" {
+		return time.Time{}, nil
+	}
+
+	if d, err := time.ParseDuration(val); err == nil {
+		return time.Now().Add(-d), nil
+	}
+
+	return time.Parse(time.RFC3339, val)
+}
+
 type auditLogsResponse struct {
 	Data   []*types.AuditLog `json:"data"`
 	Total  int               `json:"total"`
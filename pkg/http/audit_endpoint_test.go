@@ -0,0 +1,205 @@
+package http
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/keel-hq/keel/types"
+)
+
+func TestHistoryFilters(t *testing.T) {
+	srv, teardown := NewTestingServer(&fakeProvider{})
+	defer teardown()
+
+	entries := []*types.AuditLog{
+		{
+			Action:       types.NotificationDeploymentUpdate.String(),
+			ResourceKind: "deployment",
+			Identifier:   "deployment/default/foo",
+			Namespace:    "default",
+			Name:         "foo",
+			Image:        "gcr.io/v2-namespace/foo:1.1.2",
+		},
+		{
+			Action:       types.NotificationDeploymentUpdate.String(),
+			ResourceKind: "deployment",
+			Identifier:   "deployment/staging/foo",
+			Namespace:    "staging",
+			Name:         "foo",
+			Image:        "gcr.io/v2-namespace/foo:1.1.3",
+		},
+		{
+			Action:       types.NotificationDeploymentUpdate.String(),
+			ResourceKind: "deployment",
+			Identifier:   "deployment/default/bar",
+			Namespace:    "default",
+			Name:         "bar",
+			Image:        "gcr.io/v2-namespace/bar:2.0.0",
+		},
+		{
+			// not an update, shouldn't be part of the history
+			Action:       types.AuditActionApprovalApproved,
+			ResourceKind: types.AuditResourceKindApproval,
+			Identifier:   "deployment/default/foo:1.1.2",
+			Namespace:    "default",
+		},
+	}
+	for _, e := range entries {
+		if _, err := srv.store.CreateAuditLog(e); err != nil {
+			t.Fatalf("failed to create audit log: %s", err)
+		}
+	}
+
+	tests := []struct {
+		query     string
+		wantTotal int
+	}{
+		{query: "", wantTotal: 3},
+		{query: "?namespace=default", wantTotal: 2},
+		{query: "?namespace=default&name=foo", wantTotal: 1},
+		{query: "?image=gcr.io/v2-namespace/foo", wantTotal: 2},
+		{query: "?from=1h", wantTotal: 3},
+		{query: "?to=2000-01-01T00:00:00Z", wantTotal: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			req, err := http.NewRequest("GET", "/v1/history"+tt.query, nil)
+			if err != nil {
+				t.Fatalf("failed to create req: %s", err)
+			}
+			req.SetBasicAuth("user-1", "secret")
+
+			rec := httptest.NewRecorder()
+			srv.router.ServeHTTP(rec, req)
+			if rec.Code != 200 {
+				t.Fatalf("unexpected status code: %d, body: %s", rec.Code, rec.Body.String())
+			}
+
+			var result auditLogsResponse
+			err = json.Unmarshal(rec.Body.Bytes(), &result)
+			if err != nil {
+				t.Fatalf("failed to unmarshal response: %s", err)
+			}
+
+			if result.Total != tt.wantTotal {
+				t.Errorf("expected %d entries, got: %d", tt.wantTotal, result.Total)
+			}
+			if len(result.Data) != tt.wantTotal {
+				t.Errorf("expected %d entries in data, got: %d", tt.wantTotal, len(result.Data))
+			}
+		})
+	}
+}
+
+func TestHistoryInvalidRange(t *testing.T) {
+	srv, teardown := NewTestingServer(&fakeProvider{})
+	defer teardown()
+
+	req, err := http.NewRequest("GET", "/v1/history?from=yesterday", nil)
+	if err != nil {
+		t.Fatalf("failed to create req: %s", err)
+	}
+	req.SetBasicAuth("user-1", "secret")
+
+	rec := httptest.NewRecorder()
+	srv.router.ServeHTTP(rec, req)
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("unexpected status code: %d", rec.Code)
+	}
+}
+
+func TestAuditFilters(t *testing.T) {
+	srv, teardown := NewTestingServer(&fakeProvider{})
+	defer teardown()
+
+	entries := []*types.AuditLog{
+		{
+			Action:       types.NotificationDeploymentUpdate.String(),
+			ResourceKind: "deployment",
+			Identifier:   "deployment/default/foo",
+			Namespace:    "default",
+			Name:         "foo",
+			Image:        "gcr.io/v2-namespace/foo:1.1.2, gcr.io/v2-namespace/sidecar@sha256:aaa",
+		},
+		{
+			Action:       types.NotificationDeploymentUpdate.String(),
+			ResourceKind: "deployment",
+			Identifier:   "deployment/default/foo-worker",
+			Namespace:    "staging",
+			Name:         "foo-worker",
+			Image:        "gcr.io/v2-namespace/foo-worker:1.1.2",
+		},
+		{
+			Action:       types.AuditActionApprovalApproved,
+			ResourceKind: types.AuditResourceKindApproval,
+			Identifier:   "deployment/default/foo:1.1.2",
+			Namespace:    "default",
+			Username:     "admin",
+		},
+		{
+			Action:       types.AuditActionApprovalApproved,
+			ResourceKind: types.AuditResourceKindApproval,
+			Identifier:   "deployment/staging/foo:1.1.3",
+			Namespace:    "staging",
+			Username:     "admin",
+		},
+	}
+	for _, e := range entries {
+		if _, err := srv.store.CreateAuditLog(e); err != nil {
+			t.Fatalf("failed to create audit log: %s", err)
+		}
+	}
+
+	tests := []struct {
+		query     string
+		wantCode  int
+		wantTotal int
+	}{
+		{query: "?filter=*", wantCode: 200, wantTotal: 4},
+		{query: "?filter=*&namespace=default", wantCode: 200, wantTotal: 2},
+		{query: "?filter=*&action=approved", wantCode: 200, wantTotal: 2},
+		{query: "?filter=approval&namespace=staging", wantCode: 200, wantTotal: 1},
+		{query: "?filter=*&image=gcr.io/v2-namespace/foo", wantCode: 200, wantTotal: 1},
+		{query: "?filter=*&image=gcr.io/v2-namespace/foo:1.1.2", wantCode: 200, wantTotal: 1},
+		{query: "?filter=*&image=gcr.io/v2-namespace/sidecar", wantCode: 200, wantTotal: 1},
+		{query: "?filter=*&image=gcr.io/v2-namespace/foo-worker", wantCode: 200, wantTotal: 1},
+		{query: "?filter=*&image=gcr.io/v2-namespace/fo", wantCode: 200, wantTotal: 0},
+		{query: "?filter=*&image=v2-namespace/foo", wantCode: 200, wantTotal: 0},
+		{query: "?filter=*&image=gcr.io/v2-namespace/fo_", wantCode: 200, wantTotal: 0},
+		{query: "?filter=*&from=1h", wantCode: 200, wantTotal: 4},
+		{query: "?filter=*&to=2000-01-01T00:00:00Z", wantCode: 200, wantTotal: 0},
+		{query: "?filter=*&from=yesterday", wantCode: http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			req, err := http.NewRequest("GET", "/v1/audit"+tt.query, nil)
+			if err != nil {
+				t.Fatalf("failed to create req: %s", err)
+			}
+			req.SetBasicAuth("user-1", "secret")
+
+			rec := httptest.NewRecorder()
+			srv.router.ServeHTTP(rec, req)
+			if rec.Code != tt.wantCode {
+				t.Fatalf("unexpected status code: %d, body: %s", rec.Code, rec.Body.String())
+			}
+			if tt.wantCode != 200 {
+				return
+			}
+
+			var result auditLogsResponse
+			err = json.Unmarshal(rec.Body.Bytes(), &result)
+			if err != nil {
+				t.Fatalf("failed to unmarshal response: %s", err)
+			}
+
+			if result.Total != tt.wantTotal {
+				t.Errorf("expected %d entries, got: %d", tt.wantTotal, result.Total)
+			}
+		})
+	}
+}
@@ -21,7 +21,18 @@ func authHeadersMiddleware(rw http.ResponseWriter, r *http.Request, next http.Ha
 	next(rw, r)
 }
 
+// requireAdminAuthorization - only admins can access the handler, ie: approving
+// updates or changing policies
 func (s *TriggerServer) requireAdminAuthorization(next http.HandlerFunc) http.HandlerFunc {
+	return s.requireAuthorization(next, true)
+}
+
+// requireReadAuthorization - read-only users can access the handler as well
+func (s *TriggerServer) requireReadAuthorization(next http.HandlerFunc) http.HandlerFunc {
+	return s.requireAuthorization(next, false)
+}
+
+func (s *TriggerServer) requireAuthorization(next http.HandlerFunc, admin bool) http.HandlerFunc {
 	return func(rw http.ResponseWriter, r *http.Request) {
 
 		// rw.Header().Set("Access-Control-Expose-Headers", "Authorization")
@@ -32,49 +43,59 @@ func (s *TriggerServer) requireAdminAuthorization(next http.HandlerFunc) http.Ha
 			return
 		}
 
-		username, password, ok := r.BasicAuth()
-		if ok {
-			resp, err := s.authenticator.Authenticate(&auth.AuthRequest{
-				Username: username,
-				Password: password,
-				AuthType: auth.AuthTypeBasic,
-			})
-
-			if err != nil {
-				log.WithFields(log.Fields{
-					"error": err,
-					"user":  username,
-					"pas":   password,
-				}).Error("failed uath")
-				// rw.Header().Set("WWW-Authenticate", `Basic realm="Restricted"`)
-				http.Error(rw, http.StatusText(http.StatusUnauthorized), http.StatusUnauthorized)
-				return
-			}
-
-			r = auth.SetAuthenticationDetails(r, &resp.User)
-
-			next(rw, r)
+		user, ok := s.authenticate(r)
+		if !ok {
+			// rw.Header().Set("WWW-Authenticate", `Basic realm="Restricted"`)
+			http.Error(rw, http.StatusText(http.StatusUnauthorized), http.StatusUnauthorized)
 			return
 		}
 
-		// authenticating via token
+		if admin && !user.IsAdmin() {
+			log.WithFields(log.Fields{
+				"user": user.Username,
+				"path": r.URL.Path,
+			}).Warn("admin access denied to read-only user")
+			http.Error(rw, http.StatusText(http.StatusForbidden), http.StatusForbidden)
+			return
+		}
+
+		r = auth.SetAuthenticationDetails(r, user)
+
+		next(rw, r)
+	}
+}
 
+// authenticate - authenticates request with basic auth or bearer token
+func (s *TriggerServer) authenticate(r *http.Request) (*auth.User, bool) {
+	username, password, ok := r.BasicAuth()
+	if ok {
 		resp, err := s.authenticator.Authenticate(&auth.AuthRequest{
-			Token:    extractToken(r),
-			AuthType: auth.AuthTypeToken,
+			Username: username,
+			Password: password,
+			AuthType: auth.AuthTypeBasic,
 		})
-
 		if err != nil {
-			// rw.Header().Set("WWW-Authenticate", `Basic realm="Restricted"`)
-
-			log.Warnf("authentication by token failed, token: %s, err: %s", extractToken(r), err)
-			http.Error(rw, http.StatusText(http.StatusUnauthorized), http.StatusUnauthorized)
-			return
+			log.WithFields(log.Fields{
+				"error": err,
+				"user":  username,
+			}).Error("basic authentication failed")
+			return nil, false
 		}
-		r = auth.SetAuthenticationDetails(r, &resp.User)
+		return &resp.User, true
+	}
 
-		next(rw, r)
+	// authenticating via token
+	resp, err := s.authenticator.Authenticate(&auth.AuthRequest{
+		Token:    extractToken(r),
+		AuthType: auth.AuthTypeToken,
+	})
+	if err != nil {
+		log.WithFields(log.Fields{
+			"error": err,
+		}).Warn("authentication by token failed")
+		return nil, false
 	}
+	return &resp.User, true
 }
 
 func extractToken(req *http.Request) string {
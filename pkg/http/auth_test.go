@@ -0,0 +1,64 @@
+package http
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/keel-hq/keel/pkg/auth"
+)
+
+func TestAdminAPIRoles(t *testing.T) {
+	fp := &fakeProvider{}
+	srv, teardown := NewTestingServer(fp)
+	defer teardown()
+
+	srv.authenticator = auth.New(&auth.Opts{
+		Username:         "user-1",
+		Password:         "secret",
+		ReadOnlyUsername: "viewer",
+		ReadOnlyPassword: "view",
+		AdminTokens:      []string{"admin-token"},
+		ReadOnlyTokens:   []string{"read-token"},
+	})
+
+	tests := []struct {
+		name       string
+		method     string
+		path       string
+		basic      []string
+		token      string
+		wantStatus int
+	}{
+		{name: "anonymous read", method: "GET", path: "/v1/approvals", wantStatus: http.StatusUnauthorized},
+		{name: "admin read", method: "GET", path: "/v1/approvals", basic: []string{"user-1", "secret"}, wantStatus: http.StatusOK},
+		{name: "read-only read", method: "GET", path: "/v1/approvals", basic: []string{"viewer", "view"}, wantStatus: http.StatusOK},
+		{name: "read-only wrong password", method: "GET", path: "/v1/approvals", basic: []string{"viewer", "secret"}, wantStatus: http.StatusUnauthorized},
+		{name: "read-only pause", method: "POST", path: "/v1/pause", basic: []string{"viewer", "view"}, wantStatus: http.StatusForbidden},
+		{name: "read-only token read", method: "GET", path: "/v1/approvals", token: "read-token", wantStatus: http.StatusOK},
+		{name: "read-only token pause", method: "POST", path: "/v1/pause", token: "read-token", wantStatus: http.StatusForbidden},
+		{name: "admin token pause", method: "POST", path: "/v1/pause", token: "admin-token", wantStatus: http.StatusOK},
+		{name: "unknown token", method: "GET", path: "/v1/approvals", token: "other", wantStatus: http.StatusUnauthorized},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, err := http.NewRequest(tt.method, tt.path, nil)
+			if err != nil {
+				t.Fatalf("failed to create req: %s", err)
+			}
+			if tt.basic != nil {
+				req.SetBasicAuth(tt.basic[0], tt.basic[1])
+			}
+			if tt.token != "" {
+				req.Header.Set("Authorization", "Bearer "+tt.token)
+			}
+
+			rec := httptest.NewRecorder()
+			srv.router.ServeHTTP(rec, req)
+			if rec.Code != tt.wantStatus {
+				t.Errorf("unexpected status code: %d, want: %d", rec.Code, tt.wantStatus)
+			}
+		})
+	}
+}
@@ -46,6 +46,7 @@ func init() {
 //}
 
 type azureWebhook struct {
+	Action string `json:"action"`
 	Target struct {
 		Repository string `json:"repository"`
 		Tag        string `json:"tag"`
@@ -66,6 +67,16 @@ func (s *TriggerServer) azureHandler(resp http.ResponseWriter, req *http.Request
 		return
 	}
 
+	// ping is sent when webhook is created or tested from the portal,
+	// other actions such as delete are not relevant for updates
+	if aw.Action != "" && aw.Action != "push" {
+		log.WithFields(log.Fields{
+			"action": aw.Action,
+		}).Debug("trigger.azureHandler: ignoring non push action")
+		resp.WriteHeader(http.StatusOK)
+		return
+	}
+
 	if aw.Target.Tag == "" {
 		resp.WriteHeader(http.StatusBadRequest)
 		fmt.Fprintf(resp, "tag cannot be empty")
@@ -80,7 +91,7 @@ func (s *TriggerServer) azureHandler(resp http.ResponseWriter, req *http.Request
 	event.Repository.Name = DockerURL // need to build this url..
 	event.Repository.Tag = aw.Target.Tag
 	event.Repository.Digest = aw.Target.Digest
-	s.trigger(event)
+	s.trigger(req.Context(), event)
 	newAzureWebhooksCounter.With(prometheus.Labels{"image": event.Repository.Name}).Inc()
 
 	resp.WriteHeader(http.StatusOK)
@@ -66,3 +66,41 @@ func TestAzureWebhookHandler(t *testing.T) {
 		t.Errorf("expected sha256:80f0d5c8786bb9e621a45ece0db56d11cdc624ad20da9fe62e9d25490f331d7d but got %s", fp.submitted[0].Repository.Digest)
 	}
 }
+
+var fakeAzurePingWebhook = `{
+  "id": "0d799b14-404b-4859-b2f6-50c5ee2a2c3a",
+  "timestamp": "2017-11-17T16:50:23.2314421Z",
+  "action": "ping",
+  "request": {
+    "id": "",
+    "host": "myregistry.azurecr.io",
+    "method": "",
+    "useragent": ""
+  }
+}
+`
+
+func TestAzureWebhookHandlerPing(t *testing.T) {
+
+	fp := &fakeProvider{}
+	srv, teardown := NewTestingServer(fp)
+	defer teardown()
+
+	req, err := http.NewRequest("POST", "/v1/webhooks/azure", bytes.NewBuffer([]byte(fakeAzurePingWebhook)))
+	if err != nil {
+		t.Fatalf("failed to create req: %s", err)
+	}
+
+	rec := httptest.NewRecorder()
+
+	srv.router.ServeHTTP(rec, req)
+	if rec.Code != 200 {
+		t.Errorf("unexpected status code: %d", rec.Code)
+
+		t.Log(rec.Body.String())
+	}
+
+	if len(fp.submitted) != 0 {
+		t.Fatalf("unexpected number of events submitted: %d", len(fp.submitted))
+	}
+}
@@ -0,0 +1,49 @@
+package http
+
+import (
+	"github.com/keel-hq/keel/internal/k8s"
+	"github.com/keel-hq/keel/provider/kubernetes"
+)
+
+// Cluster - cluster managed by keel, only needed when keel manages several
+// clusters, otherwise GRC and KubernetesClient options are used
+type Cluster struct {
+	Name             string
+	GRC              *k8s.GenericResourceCache
+	KubernetesClient kubernetes.Implementer
+}
+
+// clusterResource - cached resource and the client of its cluster
+type clusterResource struct {
+	*k8s.GenericResource
+	cluster string
+	client  kubernetes.Implementer
+}
+
+// resources - resources of all clusters
+func (s *TriggerServer) resources() []clusterResource {
+	var resources []clusterResource
+	for _, c := range s.clusters {
+		if c.GRC == nil {
+			continue
+		}
+		for _, gr := range c.GRC.Values() {
+			resources = append(resources, clusterResource{GenericResource: gr, cluster: c.Name, client: c.KubernetesClient})
+		}
+	}
+	return resources
+}
+
+// findResource - resource with the identifier, any cluster matches if cluster is empty
+func (s *TriggerServer) findResource(cluster, identifier string) (clusterResource, bool) {
+	for _, r := range s.resources() {
+		if r.Identifier != identifier {
+			continue
+		}
+		if cluster != "" && r.cluster != cluster {
+			continue
+		}
+		return r, true
+	}
+	return clusterResource{}, false
+}
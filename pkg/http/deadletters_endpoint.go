@@ -0,0 +1,38 @@
+package http
+
+import (
+	"net/http"
+
+	"github.com/keel-hq/keel/pkg/store"
+	"github.com/keel-hq/keel/provider"
+	"github.com/keel-hq/keel/types"
+)
+
+// deadLettersHandler - lists events that couldn't be applied
+func (s *TriggerServer) deadLettersHandler(resp http.ResponseWriter, req *http.Request) {
+	deadLetters, err := s.store.ListDeadLetters()
+	if deadLetters == nil {
+		deadLetters = []*types.DeadLetter{}
+	}
+	response(deadLetters, http.StatusOK, err, resp, req)
+}
+
+// deadLetterReplayHandler - submits stored event again
+func (s *TriggerServer) deadLetterReplayHandler(resp http.ResponseWriter, req *http.Request) {
+	deadLetter, err := provider.ReplayDeadLetter(s.store, s.providers, getID(req))
+	if err == store.ErrRecordNotFound {
+		http.Error(resp, "dead letter not found", http.StatusNotFound)
+		return
+	}
+	response(deadLetter, http.StatusOK, err, resp, req)
+}
+
+// deadLetterDiscardHandler - removes stored event without applying it
+func (s *TriggerServer) deadLetterDiscardHandler(resp http.ResponseWriter, req *http.Request) {
+	deadLetter, err := provider.DiscardDeadLetter(s.store, getID(req))
+	if err == store.ErrRecordNotFound {
+		http.Error(resp, "dead letter not found", http.StatusNotFound)
+		return
+	}
+	response(deadLetter, http.StatusOK, err, resp, req)
+}
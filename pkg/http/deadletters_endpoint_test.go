@@ -0,0 +1,99 @@
+package http
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/keel-hq/keel/types"
+)
+
+func TestDeadLetters(t *testing.T) {
+	fp := &fakeProvider{}
+	srv, teardown := NewTestingServer(fp)
+	defer teardown()
+
+	replayed, err := srv.store.CreateDeadLetter(&types.DeadLetter{
+		Provider:   "kubernetes",
+		Identifier: "deployment/default/wd",
+		Event: &types.Event{
+			Repository:  types.Repository{Name: "karolisr/webhook-demo", Tag: "0.0.15"},
+			TriggerName: "dockerhub",
+			Target:      "deployment/default/wd",
+		},
+		Error:    "the server is currently unable to handle the request",
+		Attempts: 5,
+	})
+	if err != nil {
+		t.Fatalf("failed to create dead letter: %s", err)
+	}
+	discarded, err := srv.store.CreateDeadLetter(&types.DeadLetter{
+		Provider: "kubernetes",
+		Event:    &types.Event{Repository: types.Repository{Name: "karolisr/webhook-demo", Tag: "0.0.16"}},
+		Error:    "connection refused",
+		Attempts: 1,
+	})
+	if err != nil {
+		t.Fatalf("failed to create dead letter: %s", err)
+	}
+
+	do := func(method, path string) *httptest.ResponseRecorder {
+		req, err := http.NewRequest(method, path, nil)
+		if err != nil {
+			t.Fatalf("failed to create req: %s", err)
+		}
+		req.SetBasicAuth("user-1", "secret")
+		rec := httptest.NewRecorder()
+		srv.router.ServeHTTP(rec, req)
+		return rec
+	}
+
+	rec := do("GET", "/v1/deadletters")
+	if rec.Code != 200 {
+		t.Fatalf("unexpected status code: %d, body: %s", rec.Code, rec.Body.String())
+	}
+	var deadLetters []*types.DeadLetter
+	err = json.Unmarshal(rec.Body.Bytes(), &deadLetters)
+	if err != nil {
+		t.Fatalf("failed to unmarshal response: %s", err)
+	}
+	if len(deadLetters) != 2 {
+		t.Fatalf("expected 2 dead letters, got: %d", len(deadLetters))
+	}
+
+	rec = do("POST", "/v1/deadletters/"+replayed.ID+"/replay")
+	if rec.Code != 200 {
+		t.Fatalf("unexpected status code: %d, body: %s", rec.Code, rec.Body.String())
+	}
+	if len(fp.submitted) != 1 {
+		t.Fatalf("expected 1 submitted event, got: %d", len(fp.submitted))
+	}
+	if fp.submitted[0].TriggerName != types.TriggerNameReplay {
+		t.Errorf("unexpected trigger: %s", fp.submitted[0].TriggerName)
+	}
+	if fp.submitted[0].Target != "deployment/default/wd" || fp.submitted[0].Repository.Tag != "0.0.15" {
+		t.Errorf("unexpected event: %+v", fp.submitted[0])
+	}
+
+	rec = do("DELETE", "/v1/deadletters/"+discarded.ID)
+	if rec.Code != 200 {
+		t.Fatalf("unexpected status code: %d, body: %s", rec.Code, rec.Body.String())
+	}
+	if len(fp.submitted) != 1 {
+		t.Errorf("discarded event shouldn't be submitted")
+	}
+
+	rec = do("DELETE", "/v1/deadletters/"+discarded.ID)
+	if rec.Code != 404 {
+		t.Errorf("expected dead letter to be removed, got status code: %d", rec.Code)
+	}
+
+	remaining, err := srv.store.ListDeadLetters()
+	if err != nil {
+		t.Fatalf("failed to list dead letters: %s", err)
+	}
+	if len(remaining) != 0 {
+		t.Errorf("expected no dead letters, got: %d", len(remaining))
+	}
+}
@@ -0,0 +1,35 @@
+package http
+
+import (
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"github.com/keel-hq/keel/types"
+)
+
+// ResourceHistory - actions keel took on resources, see internal/history
+type ResourceHistory interface {
+	List(cluster, identifier string) ([]*types.HistoryEntry, error)
+}
+
+// deploymentHistoryHandler - actions taken on the deployment, newest first.
+// Other kinds can be requested via ?kind= (ie: statefulset)
+func (s *TriggerServer) deploymentHistoryHandler(resp http.ResponseWriter, req *http.Request) {
+	if s.history == nil {
+		http.Error(resp, "history is disabled", http.StatusNotFound)
+		return
+	}
+
+	kind := req.URL.Query().Get("kind")
+	if kind == "" {
+		kind = "deployment"
+	}
+	vars := mux.Vars(req)
+	identifier := kind + "/" + vars["namespace"] + "/" + vars["name"]
+
+	entries, err := s.history.List(req.URL.Query().Get("cluster"), identifier)
+	if entries == nil {
+		entries = []*types.HistoryEntry{}
+	}
+	response(entries, http.StatusOK, err, resp, req)
+}
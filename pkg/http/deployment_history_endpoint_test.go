@@ -0,0 +1,61 @@
+package http
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/keel-hq/keel/cache/memory"
+	"github.com/keel-hq/keel/internal/history"
+	"github.com/keel-hq/keel/types"
+)
+
+func TestDeploymentHistory(t *testing.T) {
+	fp := &fakeProvider{}
+	srv, teardown := NewTestingServer(fp)
+	defer teardown()
+
+	do := func(path string) *httptest.ResponseRecorder {
+		req, err := http.NewRequest("GET", path, nil)
+		if err != nil {
+			t.Fatalf("failed to create req: %s", err)
+		}
+		req.SetBasicAuth("user-1", "secret")
+		rec := httptest.NewRecorder()
+		srv.router.ServeHTTP(rec, req)
+		return rec
+	}
+
+	rec := do("/v1/deployments/default/wd/history")
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("expected disabled history to return 404, got: %d", rec.Code)
+	}
+
+	h := history.New(memory.New(), 0)
+	h.Record(&types.HistoryEntry{Action: types.HistoryActionDetected, Identifier: "deployment/default/wd", NewVersion: "0.0.15"})
+	h.Record(&types.HistoryEntry{Action: types.HistoryActionApplied, Identifier: "deployment/default/wd", NewVersion: "0.0.15"})
+	h.Record(&types.HistoryEntry{Action: types.HistoryActionApplied, Identifier: "statefulset/default/wd", NewVersion: "0.0.16"})
+	srv.history = h
+
+	rec = do("/v1/deployments/default/wd/history")
+	if rec.Code != 200 {
+		t.Fatalf("unexpected status code: %d, body: %s", rec.Code, rec.Body.String())
+	}
+	var entries []*types.HistoryEntry
+	if err := json.Unmarshal(rec.Body.Bytes(), &entries); err != nil {
+		t.Fatalf("failed to unmarshal response: %s", err)
+	}
+	if len(entries) != 2 || entries[0].Action != types.HistoryActionApplied {
+		t.Errorf("unexpected entries: %+v", entries)
+	}
+
+	rec = do("/v1/deployments/default/wd/history?kind=statefulset")
+	entries = nil
+	if err := json.Unmarshal(rec.Body.Bytes(), &entries); err != nil {
+		t.Fatalf("failed to unmarshal response: %s", err)
+	}
+	if len(entries) != 1 || entries[0].NewVersion != "0.0.16" {
+		t.Errorf("unexpected entries: %+v", entries)
+	}
+}
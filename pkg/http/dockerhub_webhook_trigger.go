@@ -109,7 +109,7 @@ func (s *TriggerServer) dockerHubHandler(resp http.ResponseWriter, req *http.Req
 	event.Repository.Name = dw.Repository.RepoName
 	event.Repository.Tag = dw.PushData.Tag
 
-	s.trigger(event)
+	s.trigger(req.Context(), event)
 
 	resp.WriteHeader(http.StatusOK)
 
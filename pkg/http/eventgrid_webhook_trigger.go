@@ -0,0 +1,134 @@
+package http
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/keel-hq/keel/types"
+	"github.com/prometheus/client_golang/prometheus"
+
+	log "github.com/sirupsen/logrus"
+)
+
+var newEventGridWebhooksCounter = prometheus.NewCounterVec(
+	prometheus.CounterOpts{
+		Name: "eventgrid_webhook_requests_total",
+		Help: "How many /v1/webhooks/eventgrid requests processed, partitioned by image.",
+	},
+	[]string{"image"},
+)
+
+func init() {
+	prometheus.MustRegister(newEventGridWebhooksCounter)
+}
+
+const (
+	eventGridSubscriptionValidationEvent = "Microsoft.EventGrid.SubscriptionValidationEvent"
+	eventGridImagePushedEvent            = "Microsoft.ContainerRegistry.ImagePushed"
+)
+
+// Example of Event Grid ACR push event
+// [{
+//   "id": "831e1650-001e-001b-66ab-eeb76e069631",
+//   "topic": "/subscriptions/<subscription-id>/resourceGroups/<group>/providers/Microsoft.ContainerRegistry/registries/myregistry",
+//   "subject": "hello-world:v1",
+//   "eventType": "Microsoft.ContainerRegistry.ImagePushed",
+//   "eventTime": "2018-04-25T21:39:47.6549614Z",
+//   "data": {
+//     "id": "31c51664-e5bd-416a-a5df-e5206bc47ed0",
+//     "timestamp": "2018-04-25T21:39:47.276585742Z",
+//     "action": "push",
+//     "target": {
+//       "mediaType": "application/vnd.docker.distribution.manifest.v2+json",
+//       "size": 3023,
+//       "digest": "sha256:213bbc182920ab41e18edc2001e06abcca6735d87782d9cef68abd83941cf0e5",
+//       "length": 3023,
+//       "repository": "hello-world",
+//       "tag": "v1"
+//     },
+//     "request": {
+//       "id": "7c66f28b-de19-40a4-821c-6f5f6c0003a4",
+//       "host": "myregistry.azurecr.io",
+//       "method": "PUT",
+//       "useragent": "docker/18.03.0-ce go/go1.9.4 git-commit/0520e24 os/windows arch/amd64"
+//     }
+//   },
+//   "dataVersion": "1.0",
+//   "metadataVersion": "1"
+// }]
+
+type eventGridEvent struct {
+	ID        string          `json:"id"`
+	Subject   string          `json:"subject"`
+	EventType string          `json:"eventType"`
+	Data      json.RawMessage `json:"data"`
+}
+
+type eventGridValidationData struct {
+	ValidationCode string `json:"validationCode"`
+}
+
+type eventGridValidationResponse struct {
+	ValidationResponse string `json:"validationResponse"`
+}
+
+// eventGridHandler - handles Azure Event Grid subscription validation handshake and
+// ACR image pushed events
+func (s *TriggerServer) eventGridHandler(resp http.ResponseWriter, req *http.Request) {
+	var events []eventGridEvent
+	if err := json.NewDecoder(req.Body).Decode(&events); err != nil {
+		log.WithFields(log.Fields{
+			"error": err,
+		}).Error("trigger.eventGridHandler: failed to decode request")
+		resp.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	for _, e := range events {
+		switch e.EventType {
+		case eventGridSubscriptionValidationEvent:
+			var data eventGridValidationData
+			if err := json.Unmarshal(e.Data, &data); err != nil || data.ValidationCode == "" {
+				resp.WriteHeader(http.StatusBadRequest)
+				return
+			}
+
+			log.WithFields(log.Fields{
+				"id": e.ID,
+			}).Info("trigger.eventGridHandler: validating event grid subscription")
+
+			response(&eventGridValidationResponse{ValidationResponse: data.ValidationCode}, http.StatusOK, nil, resp, req)
+			return
+		case eventGridImagePushedEvent:
+			var data azureWebhook
+			if err := json.Unmarshal(e.Data, &data); err != nil {
+				log.WithFields(log.Fields{
+					"error": err,
+					"id":    e.ID,
+				}).Error("trigger.eventGridHandler: failed to decode event data")
+				continue
+			}
+
+			if data.Target.Tag == "" {
+				continue
+			}
+
+			event := types.Event{}
+			event.CreatedAt = time.Now()
+			event.TriggerName = "eventgrid"
+			event.Repository.Name = data.Request.Host + "/" + data.Target.Repository
+			event.Repository.Tag = data.Target.Tag
+			event.Repository.Digest = data.Target.Digest
+
+			s.trigger(req.Context(), event)
+			newEventGridWebhooksCounter.With(prometheus.Labels{"image": event.Repository.Name}).Inc()
+		default:
+			log.WithFields(log.Fields{
+				"event_type": e.EventType,
+			}).Debug("trigger.eventGridHandler: ignoring event")
+		}
+	}
+
+	resp.WriteHeader(http.StatusOK)
+}
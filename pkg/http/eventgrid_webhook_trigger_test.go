@@ -0,0 +1,122 @@
+package http
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+var fakeEventGridValidation = `[{
+  "id": "2d1781af-3a4c-4d7c-bd0c-e34b19da4e66",
+  "topic": "/subscriptions/xx/resourceGroups/group/providers/Microsoft.ContainerRegistry/registries/myregistry",
+  "subject": "",
+  "data": {
+    "validationCode": "512d38b6-c7b8-40c8-89fe-f46f9e9622b6",
+    "validationUrl": "https://rp-eastus2.eventgrid.azure.net:553/eventsubscriptions/estest/validate?id=512d38b6-c7b8-40c8-89fe-f46f9e9622b6&t=2018-04-26T20:30:54.4538837Z"
+  },
+  "eventType": "Microsoft.EventGrid.SubscriptionValidationEvent",
+  "eventTime": "2018-01-25T22:12:19.4556811Z",
+  "metadataVersion": "1",
+  "dataVersion": "1"
+}]`
+
+var fakeEventGridImagePushed = `[{
+  "id": "831e1650-001e-001b-66ab-eeb76e069631",
+  "topic": "/subscriptions/xx/resourceGroups/group/providers/Microsoft.ContainerRegistry/registries/myregistry",
+  "subject": "hello-world:v1",
+  "eventType": "Microsoft.ContainerRegistry.ImagePushed",
+  "eventTime": "2018-04-25T21:39:47.6549614Z",
+  "data": {
+    "id": "31c51664-e5bd-416a-a5df-e5206bc47ed0",
+    "timestamp": "2018-04-25T21:39:47.276585742Z",
+    "action": "push",
+    "target": {
+      "mediaType": "application/vnd.docker.distribution.manifest.v2+json",
+      "size": 3023,
+      "digest": "sha256:213bbc182920ab41e18edc2001e06abcca6735d87782d9cef68abd83941cf0e5",
+      "length": 3023,
+      "repository": "hello-world",
+      "tag": "v1"
+    },
+    "request": {
+      "id": "7c66f28b-de19-40a4-821c-6f5f6c0003a4",
+      "host": "myregistry.azurecr.io",
+      "method": "PUT",
+      "useragent": "docker/18.03.0-ce go/go1.9.4 git-commit/0520e24 os/windows arch/amd64"
+    }
+  },
+  "dataVersion": "1.0",
+  "metadataVersion": "1"
+}]`
+
+func TestEventGridValidation(t *testing.T) {
+
+	fp := &fakeProvider{}
+	srv, teardown := NewTestingServer(fp)
+	defer teardown()
+
+	req, err := http.NewRequest("POST", "/v1/webhooks/eventgrid", bytes.NewBuffer([]byte(fakeEventGridValidation)))
+	if err != nil {
+		t.Fatalf("failed to create req: %s", err)
+	}
+	req.Header.Set("aeg-event-type", "SubscriptionValidation")
+
+	rec := httptest.NewRecorder()
+
+	srv.router.ServeHTTP(rec, req)
+	if rec.Code != 200 {
+		t.Fatalf("unexpected status code: %d", rec.Code)
+	}
+
+	var vr eventGridValidationResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &vr); err != nil {
+		t.Fatalf("failed to decode response: %s", err)
+	}
+
+	if vr.ValidationResponse != "512d38b6-c7b8-40c8-89fe-f46f9e9622b6" {
+		t.Errorf("unexpected validation response: %s", vr.ValidationResponse)
+	}
+
+	if len(fp.submitted) != 0 {
+		t.Errorf("unexpected number of events submitted: %d", len(fp.submitted))
+	}
+}
+
+func TestEventGridImagePushed(t *testing.T) {
+
+	fp := &fakeProvider{}
+	srv, teardown := NewTestingServer(fp)
+	defer teardown()
+
+	req, err := http.NewRequest("POST", "/v1/webhooks/eventgrid", bytes.NewBuffer([]byte(fakeEventGridImagePushed)))
+	if err != nil {
+		t.Fatalf("failed to create req: %s", err)
+	}
+
+	rec := httptest.NewRecorder()
+
+	srv.router.ServeHTTP(rec, req)
+	if rec.Code != 200 {
+		t.Errorf("unexpected status code: %d", rec.Code)
+
+		t.Log(rec.Body.String())
+	}
+
+	if len(fp.submitted) != 1 {
+		t.Fatalf("unexpected number of events submitted: %d", len(fp.submitted))
+	}
+
+	if fp.submitted[0].Repository.Name != "myregistry.azurecr.io/hello-world" {
+		t.Errorf("expected myregistry.azurecr.io/hello-world but got %s", fp.submitted[0].Repository.Name)
+	}
+
+	if fp.submitted[0].Repository.Tag != "v1" {
+		t.Errorf("expected v1 but got %s", fp.submitted[0].Repository.Tag)
+	}
+
+	if fp.submitted[0].Repository.Digest != "sha256:213bbc182920ab41e18edc2001e06abcca6735d87782d9cef68abd83941cf0e5" {
+		t.Errorf("unexpected digest: %s", fp.submitted[0].Repository.Digest)
+	}
+}
@@ -0,0 +1,121 @@
+package http
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/keel-hq/keel/types"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	log "github.com/sirupsen/logrus"
+)
+
+var newGiteaWebhooksCounter = prometheus.NewCounterVec(
+	prometheus.CounterOpts{
+		Name: "gitea_webhook_requests_total",
+		Help: "How many /v1/webhooks/gitea requests processed, partitioned by image.",
+	},
+	[]string{"image"},
+)
+
+func init() {
+	prometheus.MustRegister(newGiteaWebhooksCounter)
+}
+
+// Example of Gitea/Forgejo package trigger (X-Gitea-Event: package)
+// {
+//   "action": "created",
+//   "package": {
+//     "id": 12,
+//     "owner": {
+//       "login": "acme"
+//     },
+//     "type": "container",
+//     "name": "app",
+//     "version": "1.4.0",
+//     "html_url": "https://gitea.example.com/acme/-/packages/container/app/1.4.0",
+//     "created_at": "2023-05-10T12:03:41Z"
+//   },
+//   "sender": {
+//     "login": "ci"
+//   }
+// }
+
+type giteaWebhook struct {
+	Action  string `json:"action"`
+	Package struct {
+		Owner struct {
+			Login string `json:"login"`
+		} `json:"owner"`
+		Type    string `json:"type"`
+		Name    string `json:"name"`
+		Version string `json:"version"`
+		HTMLURL string `json:"html_url"`
+	} `json:"package"`
+}
+
+// giteaHandler - handles Gitea and Forgejo container package webhooks. Images
+// are expected to be pulled from the Gitea host (<host>/<owner>/<image>),
+// registries served from another host can set "registry" query parameter,
+// ie: /v1/webhooks/gitea?registry=registry.example.com
+func (s *TriggerServer) giteaHandler(resp http.ResponseWriter, req *http.Request) {
+	gw := giteaWebhook{}
+	if err := json.NewDecoder(req.Body).Decode(&gw); err != nil {
+		log.WithFields(log.Fields{
+			"error": err,
+		}).Error("trigger.giteaHandler: failed to decode request")
+		resp.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	// untagged manifests of multi-arch images are published with digest as
+	// the version, the tag itself is published separately
+	if gw.Action != "created" || gw.Package.Type != "container" || strings.HasPrefix(gw.Package.Version, "sha256:") {
+		log.WithFields(log.Fields{
+			"action":  gw.Action,
+			"type":    gw.Package.Type,
+			"version": gw.Package.Version,
+		}).Debug("trigger.giteaHandler: ignoring non container push event")
+		resp.WriteHeader(http.StatusOK)
+		return
+	}
+
+	if gw.Package.Owner.Login == "" || gw.Package.Name == "" || gw.Package.Version == "" {
+		resp.WriteHeader(http.StatusBadRequest)
+		fmt.Fprintf(resp, "package owner, name and version cannot be empty")
+		return
+	}
+
+	registry := strings.TrimSpace(req.URL.Query().Get("registry"))
+	if registry == "" {
+		u, err := url.Parse(gw.Package.HTMLURL)
+		if err != nil || u.Host == "" {
+			resp.WriteHeader(http.StatusBadRequest)
+			fmt.Fprintf(resp, "failed to get registry from package url '%s', set registry query parameter", gw.Package.HTMLURL)
+			return
+		}
+		registry = u.Host
+	}
+
+	event := types.Event{}
+	event.CreatedAt = time.Now()
+	event.TriggerName = "gitea"
+	event.Repository.Name = strings.TrimSuffix(registry, "/") + "/" + strings.ToLower(gw.Package.Owner.Login) + "/" + gw.Package.Name
+	event.Repository.Tag = gw.Package.Version
+
+	log.WithFields(log.Fields{
+		"action":     gw.Action,
+		"tag":        event.Repository.Tag,
+		"repository": event.Repository.Name,
+	}).Debug("trigger.giteaHandler: got package notification, processing")
+
+	s.trigger(req.Context(), event)
+	newGiteaWebhooksCounter.With(prometheus.Labels{"image": event.Repository.Name}).Inc()
+
+	resp.WriteHeader(http.StatusOK)
+}
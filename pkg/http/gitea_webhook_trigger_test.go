@@ -0,0 +1,100 @@
+package http
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+var fakeGiteaWebhook = `{
+  "action": "created",
+  "package": {
+    "id": 12,
+    "owner": {
+      "id": 2,
+      "login": "Acme"
+    },
+    "type": "container",
+    "name": "app",
+    "version": "1.4.0",
+    "html_url": "https://gitea.example.com/Acme/-/packages/container/app/1.4.0",
+    "created_at": "2023-05-10T12:03:41Z"
+  },
+  "sender": {
+    "id": 3,
+    "login": "ci"
+  }
+}
+`
+
+func TestGiteaWebhookHandler(t *testing.T) {
+	tests := []struct {
+		name     string
+		query    string
+		wantName string
+	}{
+		{name: "gitea host", query: "", wantName: "gitea.example.com/acme/app"},
+		{name: "registry host", query: "?registry=registry.example.com", wantName: "registry.example.com/acme/app"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fp := &fakeProvider{}
+			srv, teardown := NewTestingServer(fp)
+			defer teardown()
+
+			req, err := http.NewRequest("POST", "/v1/webhooks/gitea"+tt.query, bytes.NewBuffer([]byte(fakeGiteaWebhook)))
+			if err != nil {
+				t.Fatalf("failed to create req: %s", err)
+			}
+
+			rec := httptest.NewRecorder()
+			srv.router.ServeHTTP(rec, req)
+			if rec.Code != 200 {
+				t.Fatalf("unexpected status code: %d, body: %s", rec.Code, rec.Body.String())
+			}
+
+			if len(fp.submitted) != 1 {
+				t.Fatalf("unexpected number of events submitted: %d", len(fp.submitted))
+			}
+
+			if fp.submitted[0].Repository.Name != tt.wantName {
+				t.Errorf("expected %s but got %s", tt.wantName, fp.submitted[0].Repository.Name)
+			}
+
+			if fp.submitted[0].Repository.Tag != "1.4.0" {
+				t.Errorf("expected 1.4.0 but got %s", fp.submitted[0].Repository.Tag)
+			}
+		})
+	}
+}
+
+func TestGiteaWebhookHandlerIgnoresOtherEvents(t *testing.T) {
+	bodies := []string{
+		`{"action": "deleted", "package": {"owner": {"login": "acme"}, "type": "container", "name": "app", "version": "1.4.0"}}`,
+		`{"action": "created", "package": {"owner": {"login": "acme"}, "type": "npm", "name": "app", "version": "1.4.0"}}`,
+		`{"action": "created", "package": {"owner": {"login": "acme"}, "type": "container", "name": "app", "version": "sha256:a15790640a6690aa1730c38cf0a440e2aa44aaca9b0e8931a9f2b0d7cc90fd65"}}`,
+	}
+
+	for _, body := range bodies {
+		fp := &fakeProvider{}
+		srv, teardown := NewTestingServer(fp)
+
+		req, err := http.NewRequest("POST", "/v1/webhooks/gitea", bytes.NewBuffer([]byte(body)))
+		if err != nil {
+			t.Fatalf("failed to create req: %s", err)
+		}
+
+		rec := httptest.NewRecorder()
+		srv.router.ServeHTTP(rec, req)
+		teardown()
+		if rec.Code != 200 {
+			t.Errorf("unexpected status code: %d", rec.Code)
+		}
+
+		if len(fp.submitted) != 0 {
+			t.Errorf("unexpected number of events submitted for %s: %d", body, len(fp.submitted))
+		}
+	}
+}
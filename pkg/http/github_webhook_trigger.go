@@ -3,6 +3,7 @@ package http
 import (
 	"encoding/json"
 	"fmt"
+	"io/ioutil"
 	"net/http"
 	"strings"
 	"time"
@@ -27,7 +28,27 @@ func init() {
 }
 
 type githubWebhook struct {
-	Action          string `json:"action"`
+	Action string `json:"action"`
+
+	// Package is set for "package" events, used by GitHub Container Registry (ghcr.io)
+	Package struct {
+		Name           string `json:"name"`
+		Namespace      string `json:"namespace"`
+		PackageType    string `json:"package_type"`
+		PackageVersion struct {
+			Version           string `json:"version"`
+			ContainerMetadata struct {
+				Tag struct {
+					Name   string `json:"name"`
+					Digest string `json:"digest"`
+				} `json:"tag"`
+			} `json:"container_metadata"`
+		} `json:"package_version"`
+		Registry struct {
+			URL string `json:"url"`
+		} `json:"registry"`
+	} `json:"package"`
+
 	RegistryPackage struct {
 		CreatedAt string `json:"created_at"`
 		HTMLURL   string `json:"html_url"`
@@ -229,8 +250,17 @@ type githubWebhook struct {
 
 // githubHandler - used to react to github webhooks
 func (s *TriggerServer) githubHandler(resp http.ResponseWriter, req *http.Request) {
+	body, err := ioutil.ReadAll(req.Body)
+	if err != nil {
+		log.WithFields(log.Fields{
+			"error": err,
+		}).Error("trigger.githubHandler: failed to read request")
+		resp.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
 	gw := githubWebhook{}
-	if err := json.NewDecoder(req.Body).Decode(&gw); err != nil {
+	if err := json.Unmarshal(body, &gw); err != nil {
 		log.WithFields(log.Fields{
 			"error": err,
 		}).Error("trigger.githubHandler: failed to decode request")
@@ -238,6 +268,11 @@ func (s *TriggerServer) githubHandler(resp http.ResponseWriter, req *http.Reques
 		return
 	}
 
+	if gw.Package.Name != "" {
+		s.githubPackageHandler(gw, resp, req)
+		return
+	}
+
 	if gw.RegistryPackage.PackageType != "docker" {
 		resp.WriteHeader(http.StatusBadRequest)
 		fmt.Fprintf(resp, "registry package type was not docker")
@@ -270,7 +305,47 @@ func (s *TriggerServer) githubHandler(resp http.ResponseWriter, req *http.Reques
 	)
 	event.Repository.Tag = gw.RegistryPackage.PackageVersion.Version
 
-	s.trigger(event)
+	s.trigger(req.Context(), event)
+
+	resp.WriteHeader(http.StatusOK)
+
+	newGithubWebhooksCounter.With(prometheus.Labels{"image": event.Repository.Name}).Inc()
+}
+
+// githubPackageHandler - handles "package" events sent for GitHub Container Registry
+func (s *TriggerServer) githubPackageHandler(gw githubWebhook, resp http.ResponseWriter, req *http.Request) {
+	if gw.Package.PackageType != "container" && gw.Package.PackageType != "CONTAINER" {
+		log.WithFields(log.Fields{
+			"package_type": gw.Package.PackageType,
+		}).Debug("trigger.githubHandler: ignoring non container package")
+		resp.WriteHeader(http.StatusOK)
+		return
+	}
+
+	tag := gw.Package.PackageVersion.ContainerMetadata.Tag
+	if tag.Name == "" {
+		// untagged versions (ie: multi-arch image manifests) are not relevant
+		resp.WriteHeader(http.StatusOK)
+		return
+	}
+
+	registry := "ghcr.io"
+	if gw.Package.Registry.URL != "" {
+		registry = strings.TrimSuffix(strings.TrimPrefix(strings.TrimPrefix(gw.Package.Registry.URL, "https://"), "http://"), "/")
+	}
+
+	event := types.Event{}
+	event.CreatedAt = time.Now()
+	event.TriggerName = "github"
+	// image names on ghcr.io are always lowercase
+	event.Repository.Name = strings.ToLower(strings.Join(
+		[]string{registry, gw.Package.Namespace, gw.Package.Name},
+		"/",
+	))
+	event.Repository.Tag = tag.Name
+	event.Repository.Digest = tag.Digest
+
+	s.trigger(req.Context(), event)
 
 	resp.WriteHeader(http.StatusOK)
 
@@ -2,6 +2,9 @@ package http
 
 import (
 	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
 	"net/http"
 
 	"net/http/httptest"
@@ -272,3 +275,108 @@ func TestGithubWebhookHandler(t *testing.T) {
 		t.Errorf("expected 1.2.3 but got %s", fp.submitted[0].Repository.Tag)
 	}
 }
+
+var fakeGithubPackageWebhook = `{
+  "action": "published",
+  "package": {
+    "id": 1234,
+    "name": "Keel",
+    "namespace": "Keel-HQ",
+    "package_type": "CONTAINER",
+    "package_version": {
+      "id": 5678,
+      "version": "sha256:3da13d1d1d7e1f1d8e9d6f0fce0fd1e8d0b8e6a8b3f5f3ac39ea6d8d32cc0b87",
+      "container_metadata": {
+        "tag": {
+          "name": "0.17.0",
+          "digest": "sha256:3da13d1d1d7e1f1d8e9d6f0fce0fd1e8d0b8e6a8b3f5f3ac39ea6d8d32cc0b87"
+        }
+      }
+    },
+    "registry": {
+      "about_url": "https://docs.github.com/packages/learn-github-packages/introduction-to-github-packages",
+      "name": "GitHub CONTAINER registry",
+      "type": "CONTAINER",
+      "url": "https://ghcr.io",
+      "vendor": "GitHub Inc"
+    }
+  }
+}`
+
+func TestGithubPackageWebhookHandler(t *testing.T) {
+
+	fp := &fakeProvider{}
+	srv, teardown := NewTestingServer(fp)
+	defer teardown()
+
+	req, err := http.NewRequest("POST", "/v1/webhooks/github", bytes.NewBuffer([]byte(fakeGithubPackageWebhook)))
+	if err != nil {
+		t.Fatalf("failed to create req: %s", err)
+	}
+
+	rec := httptest.NewRecorder()
+
+	srv.router.ServeHTTP(rec, req)
+	if rec.Code != 200 {
+		t.Errorf("unexpected status code: %d", rec.Code)
+
+		t.Log(rec.Body.String())
+	}
+
+	if len(fp.submitted) != 1 {
+		t.Fatalf("unexpected number of events submitted: %d", len(fp.submitted))
+	}
+
+	if fp.submitted[0].Repository.Name != "ghcr.io/keel-hq/keel" {
+		t.Errorf("expected ghcr.io/keel-hq/keel but got %s", fp.submitted[0].Repository.Name)
+	}
+
+	if fp.submitted[0].Repository.Tag != "0.17.0" {
+		t.Errorf("expected 0.17.0 but got %s", fp.submitted[0].Repository.Tag)
+	}
+
+	if fp.submitted[0].Repository.Digest != "sha256:3da13d1d1d7e1f1d8e9d6f0fce0fd1e8d0b8e6a8b3f5f3ac39ea6d8d32cc0b87" {
+		t.Errorf("unexpected digest: %s", fp.submitted[0].Repository.Digest)
+	}
+}
+
+func TestGithubWebhookHandlerSignature(t *testing.T) {
+	sign := func(secret, body string) string {
+		mac := hmac.New(sha256.New, []byte(secret))
+		mac.Write([]byte(body))
+		return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+	}
+
+	tests := []struct {
+		name       string
+		signature  string
+		wantStatus int
+	}{
+		{name: "missing signature", wantStatus: http.StatusUnauthorized},
+		{name: "wrong secret", signature: sign("other", fakeGithubPackageWebhook), wantStatus: http.StatusUnauthorized},
+		{name: "valid signature", signature: sign("very-secret", fakeGithubPackageWebhook), wantStatus: http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fp := &fakeProvider{}
+			srv, teardown := NewTestingServer(fp)
+			defer teardown()
+			srv.webhookSecrets = map[string]string{"github": "very-secret"}
+
+			req, err := http.NewRequest("POST", "/v1/webhooks/github", bytes.NewBuffer([]byte(fakeGithubPackageWebhook)))
+			if err != nil {
+				t.Fatalf("failed to create req: %s", err)
+			}
+			if tt.signature != "" {
+				req.Header.Set("X-Hub-Signature-256", tt.signature)
+			}
+
+			rec := httptest.NewRecorder()
+			srv.router.ServeHTTP(rec, req)
+			if rec.Code != tt.wantStatus {
+				t.Errorf("unexpected status code: %d, want: %d", rec.Code, tt.wantStatus)
+			}
+		})
+	}
+}
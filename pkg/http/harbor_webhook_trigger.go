@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"net/http"
+	"strings"
 	"time"
 
 	"github.com/keel-hq/keel/types"
@@ -81,10 +82,19 @@ func (s *TriggerServer) harborHandler(resp http.ResponseWriter, req *http.Reques
 		"event": hn,
 	}).Debug("harborHandler: received event, looking for a pushImage tag")
 
-	if hn.Type == "pushImage" || hn.Type == "PUSH_ARTIFACT" { 
+	if hn.Type == "pushImage" || hn.Type == "PUSH_ARTIFACT" {
 		// go trough all the ressource items
 		for _, e := range hn.EventData.Resources {
-			imageRepo, err := image.Parse(e.ResourceURL)
+			// resource url can reference artifact by digest, ie: <url>/<namespace>/<repo>@sha256:...
+			resourceURL := e.ResourceURL
+			if idx := strings.LastIndex(resourceURL, "@"); idx != -1 {
+				resourceURL = resourceURL[:idx]
+				if e.Tag != "" && !strings.Contains(resourceURL[strings.LastIndex(resourceURL, "/")+1:], ":") {
+					resourceURL = resourceURL + ":" + e.Tag
+				}
+			}
+
+			imageRepo, err := image.Parse(resourceURL)
 			if err != nil {
 				log.WithFields(log.Fields{
 					"error":      err,
@@ -102,6 +112,7 @@ func (s *TriggerServer) harborHandler(resp http.ResponseWriter, req *http.Reques
 			event.TriggerName = "harbor"
 			event.Repository.Name = imageRepo.Repository()
 			event.Repository.Tag = imageRepo.Tag()
+			event.Repository.Digest = e.Digest
 
 			log.WithFields(log.Fields{
 				"action":     hn.Type,
@@ -110,7 +121,7 @@ func (s *TriggerServer) harborHandler(resp http.ResponseWriter, req *http.Reques
 				"digest":     e.Digest,
 			}).Debug("harborHandler: got registry notification, processing")
 
-			s.trigger(event)
+			s.trigger(req.Context(), event)
 			newHarborWebhooksCounter.With(prometheus.Labels{"image": event.Repository.Name}).Inc()
 		}
 	}
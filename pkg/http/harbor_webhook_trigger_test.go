@@ -6,6 +6,8 @@ import (
 
 	"net/http/httptest"
 	"testing"
+
+	"github.com/keel-hq/keel/types"
 )
 
 var fakeHarborWebhook = ` {
@@ -179,3 +181,72 @@ func TestHarborWebhookHandlerMalformed(t *testing.T) {
 		t.Errorf("expected latest but got %s", fp.submitted[0].Repository.Tag)
 	}
 }
+
+var fakeHarborPushArtifactWebhook = `{
+	"type": "PUSH_ARTIFACT",
+	"occur_at": 1582640688,
+	"operator": "user",
+	"event_data": {
+		"resources": [
+			{
+				"digest": "sha256:b4758aaed11c155a476b9857e1178f157759c99cb04c907a04993f5481eff848",
+				"tag": "1.2.3",
+				"resource_url": "harbor.example.com/mynamespace/repository:1.2.3"
+			},
+			{
+				"digest": "sha256:0604af35299dd37ff23937d115d103532948b568a9dd8197d14c256a8ab8b0bb",
+				"tag": "1.2.4",
+				"resource_url": "harbor.example.com/mynamespace/repository@sha256:0604af35299dd37ff23937d115d103532948b568a9dd8197d14c256a8ab8b0bb"
+			}
+		],
+		"repository": {
+			"date_created": 1582634337,
+			"name": "repository",
+			"namespace": "mynamespace",
+			"repo_full_name": "mynamespace/repository",
+			"repo_type": "private"
+		}
+	}
+}`
+
+func TestHarborWebhookHandlerPushArtifact(t *testing.T) {
+
+	fp := &fakeProvider{}
+	srv, teardown := NewTestingServer(fp)
+	defer teardown()
+
+	req, err := http.NewRequest("POST", "/v1/webhooks/harbor", bytes.NewBuffer([]byte(fakeHarborPushArtifactWebhook)))
+	if err != nil {
+		t.Fatalf("failed to create req: %s", err)
+	}
+
+	rec := httptest.NewRecorder()
+
+	srv.router.ServeHTTP(rec, req)
+	if rec.Code != 200 {
+		t.Fatalf("unexpected status code: %d, body: %s", rec.Code, rec.Body.String())
+	}
+
+	if len(fp.submitted) != 2 {
+		t.Fatalf("unexpected number of events submitted: %d", len(fp.submitted))
+	}
+
+	expected := []types.Repository{
+		{
+			Name:   "harbor.example.com/mynamespace/repository",
+			Tag:    "1.2.3",
+			Digest: "sha256:b4758aaed11c155a476b9857e1178f157759c99cb04c907a04993f5481eff848",
+		},
+		{
+			Name:   "harbor.example.com/mynamespace/repository",
+			Tag:    "1.2.4",
+			Digest: "sha256:0604af35299dd37ff23937d115d103532948b568a9dd8197d14c256a8ab8b0bb",
+		},
+	}
+
+	for i, repo := range expected {
+		if fp.submitted[i].Repository != repo {
+			t.Errorf("expected %+v but got %+v", repo, fp.submitted[i].Repository)
+		}
+	}
+}
@@ -0,0 +1,98 @@
+package http
+
+import (
+	"net/http"
+	"sort"
+	"sync"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// Check - health check, returns error if component is not functioning
+type Check func() error
+
+type checks struct {
+	mu     sync.RWMutex
+	checks map[string]Check
+}
+
+func newChecks() *checks {
+	return &checks{checks: make(map[string]Check)}
+}
+
+func (c *checks) add(name string, check Check) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.checks[name] = check
+}
+
+// run - runs all checks, returns check results and whether all of them passed
+func (c *checks) run() (map[string]string, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	if len(c.checks) == 0 {
+		return nil, true
+	}
+
+	names := make([]string, 0, len(c.checks))
+	for name := range c.checks {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	results := make(map[string]string, len(names))
+	ok := true
+	for _, name := range names {
+		if err := c.checks[name](); err != nil {
+			log.WithFields(log.Fields{
+				"check": name,
+				"error": err,
+			}).Warn("trigger.http: health check failed")
+			results[name] = err.Error()
+			ok = false
+			continue
+		}
+		results[name] = "ok"
+	}
+
+	return results, ok
+}
+
+// AddLivenessCheck - registers check that fails /healthz, should only fail
+// when restarting keel can fix it (ie: wedged poll loop)
+func (s *TriggerServer) AddLivenessCheck(name string, check Check) {
+	s.livenessChecks.add(name, check)
+}
+
+// AddReadinessCheck - registers check that fails /readyz
+func (s *TriggerServer) AddReadinessCheck(name string, check Check) {
+	s.readinessChecks.add(name, check)
+}
+
+type readyResponse struct {
+	Ready  bool              `json:"ready"`
+	Checks map[string]string `json:"checks,omitempty"`
+}
+
+func (s *TriggerServer) readyzHandler(resp http.ResponseWriter, req *http.Request) {
+	// liveness failures make keel unready as well
+	liveness, livenessOK := s.livenessChecks.run()
+	readiness, readinessOK := s.readinessChecks.run()
+
+	results := make(map[string]string, len(liveness)+len(readiness))
+	for name, result := range liveness {
+		results[name] = result
+	}
+	for name, result := range readiness {
+		results[name] = result
+	}
+
+	ready := livenessOK && readinessOK
+	status := http.StatusOK
+	if !ready {
+		status = http.StatusServiceUnavailable
+	}
+
+	response(&readyResponse{Ready: ready, Checks: results}, status, nil, resp, req)
+}
@@ -0,0 +1,70 @@
+package http
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestHealthAndReadiness(t *testing.T) {
+	fp := &fakeProvider{}
+	srv, teardown := NewTestingServer(fp)
+	defer teardown()
+
+	get := func(path string) *httptest.ResponseRecorder {
+		req, err := http.NewRequest("GET", path, nil)
+		if err != nil {
+			t.Fatalf("failed to create req: %s", err)
+		}
+		rec := httptest.NewRecorder()
+		srv.router.ServeHTTP(rec, req)
+		return rec
+	}
+
+	if rec := get("/readyz"); rec.Code != http.StatusOK {
+		t.Fatalf("expected ready without checks, got: %d", rec.Code)
+	}
+
+	var pollErr error
+	srv.AddLivenessCheck("poll", func() error { return pollErr })
+
+	var k8sErr error
+	srv.AddReadinessCheck("kubernetes", func() error { return k8sErr })
+
+	if rec := get("/healthz"); rec.Code != http.StatusOK {
+		t.Errorf("expected healthy, got: %d", rec.Code)
+	}
+	if rec := get("/readyz"); rec.Code != http.StatusOK {
+		t.Errorf("expected ready, got: %d", rec.Code)
+	}
+
+	// readiness failure shouldn't make keel unhealthy
+	k8sErr = fmt.Errorf("connection refused")
+	if rec := get("/healthz"); rec.Code != http.StatusOK {
+		t.Errorf("expected healthy, got: %d", rec.Code)
+	}
+
+	rec := get("/readyz")
+	if rec.Code != http.StatusServiceUnavailable {
+		t.Fatalf("expected unready, got: %d", rec.Code)
+	}
+	var rr readyResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &rr); err != nil {
+		t.Fatalf("failed to unmarshal ready response: %s", err)
+	}
+	if rr.Ready || rr.Checks["kubernetes"] != "connection refused" || rr.Checks["poll"] != "ok" {
+		t.Errorf("unexpected ready response: %+v", rr)
+	}
+
+	// liveness failure fails both
+	k8sErr = nil
+	pollErr = fmt.Errorf("poll loop stalled")
+	if rec := get("/healthz"); rec.Code != http.StatusServiceUnavailable {
+		t.Errorf("expected unhealthy, got: %d", rec.Code)
+	}
+	if rec := get("/readyz"); rec.Code != http.StatusServiceUnavailable {
+		t.Errorf("expected unready, got: %d", rec.Code)
+	}
+}
@@ -2,6 +2,7 @@ package http
 
 import (
 	"context"
+	"crypto/tls"
 	"encoding/json"
 	"fmt"
 	"io"
@@ -9,16 +10,21 @@ import (
 	"net/http"
 	"os"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/gorilla/mux"
+	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
 	"github.com/urfave/negroni"
+	"go.opentelemetry.io/otel/attribute"
 
 	"github.com/keel-hq/keel/approvals"
+	"github.com/keel-hq/keel/extension/notification"
 	"github.com/keel-hq/keel/internal/k8s"
 	"github.com/keel-hq/keel/pkg/auth"
 	"github.com/keel-hq/keel/pkg/store"
+	"github.com/keel-hq/keel/pkg/tracing"
 	"github.com/keel-hq/keel/provider"
 	"github.com/keel-hq/keel/provider/kubernetes"
 	"github.com/keel-hq/keel/types"
@@ -27,6 +33,18 @@ import (
 	log "github.com/sirupsen/logrus"
 )
 
+var webhookEventsCounter = prometheus.NewCounterVec(
+	prometheus.CounterOpts{
+		Name: "webhook_events_total",
+		Help: "How many events were received through webhooks, partitioned by trigger type.",
+	},
+	[]string{"trigger"},
+)
+
+func init() {
+	prometheus.MustRegister(webhookEventsCounter)
+}
+
 // Opts - http server options
 type Opts struct {
 	Port int
@@ -42,17 +60,50 @@ type Opts struct {
 
 	KubernetesClient kubernetes.Implementer
 
+	// all managed clusters when keel manages more than its own cluster
+	Clusters []Cluster
+
 	Store store.Store
 
 	UIDir string
 
 	AuthenticatedWebhooks bool
+
+	// webhook endpoints served by name (ie: dockerhub), nil serves all of
+	// them, empty disables webhook triggers
+	Webhooks []string
+
+	// optional secret token for registry notifications
+	RegistryNotificationToken string
+
+	// optional shared secrets of webhook endpoints, by endpoint name (ie: dockerhub),
+	// "*" applies to endpoints without their own secret
+	WebhookSecrets map[string]string
+
+	// optional sender to notify about pause/resume
+	Sender notification.Sender
+
+	// optional event source for /v1/stream
+	Events EventStream
+
+	// optional actions taken on resources, served per deployment
+	History ResourceHistory
+
+	// serve HTTPS, certificate is reloaded when files change
+	TLSCertFile string
+	TLSKeyFile  string
+
+	// webhook requests per second allowed from a single source (token or IP),
+	// 0 disables rate limiting
+	WebhookRateLimit float64
+	WebhookRateBurst int
+	// defaults to DefaultMaxWebhookBodySize
+	MaxWebhookBodySize int64
 }
 
 // TriggerServer - webhook trigger & healthcheck server
 type TriggerServer struct {
-	grc              *k8s.GenericResourceCache
-	kubernetesClient kubernetes.Implementer
+	clusters []Cluster
 
 	providers        provider.Providers
 	approvalsManager approvals.Manager
@@ -66,14 +117,34 @@ type TriggerServer struct {
 	uiDir string
 
 	authenticatedWebhooks bool
+	// served webhook endpoints, nil if all are served
+	webhooks map[string]bool
+
+	registryNotificationToken string
+	webhookSecrets            map[string]string
+
+	sender  notification.Sender
+	events  EventStream
+	history ResourceHistory
+
+	tlsCertFile string
+	tlsKeyFile  string
+
+	limiter            *rateLimiter
+	maxWebhookBodySize int64
+
+	livenessChecks  *checks
+	readinessChecks *checks
+
+	// optional, set once poll trigger is started
+	watchStatesMu sync.RWMutex
+	watchStates   WatchStates
 }
 
 // NewTriggerServer - create new HTTP trigger based server
 func NewTriggerServer(opts *Opts) *TriggerServer {
-	return &TriggerServer{
+	s := &TriggerServer{
 		port:                  opts.Port,
-		grc:                   opts.GRC,
-		kubernetesClient:      opts.KubernetesClient,
 		providers:             opts.Providers,
 		approvalsManager:      opts.ApprovalManager,
 		router:                mux.NewRouter(),
@@ -81,7 +152,64 @@ func NewTriggerServer(opts *Opts) *TriggerServer {
 		store:                 opts.Store,
 		uiDir:                 opts.UIDir,
 		authenticatedWebhooks: opts.AuthenticatedWebhooks,
+		sender:                opts.Sender,
+		events:                opts.Events,
+		history:               opts.History,
+		tlsCertFile:           opts.TLSCertFile,
+		tlsKeyFile:            opts.TLSKeyFile,
+
+		registryNotificationToken: opts.RegistryNotificationToken,
+		webhookSecrets:            opts.WebhookSecrets,
+
+		livenessChecks:  newChecks(),
+		readinessChecks: newChecks(),
+	}
+
+	if opts.KubernetesClient != nil {
+		s.AddReadinessCheck("kubernetes", func() error {
+			_, err := opts.KubernetesClient.Namespaces()
+			return err
+		})
+	}
+
+	if opts.Webhooks != nil {
+		s.webhooks = make(map[string]bool)
+		parsers := s.webhookParsers()
+		for _, name := range opts.Webhooks {
+			if _, ok := parsers[name]; !ok {
+				log.WithFields(log.Fields{
+					"webhook": name,
+				}).Error("trigger server: unknown webhook endpoint, ignoring")
+				continue
+			}
+			s.webhooks[name] = true
+		}
+	}
+
+	s.maxWebhookBodySize = opts.MaxWebhookBodySize
+	if s.maxWebhookBodySize <= 0 {
+		s.maxWebhookBodySize = DefaultMaxWebhookBodySize
+	}
+	if opts.WebhookRateLimit > 0 {
+		s.limiter = newRateLimiter(opts.WebhookRateLimit, opts.WebhookRateBurst)
 	}
+
+	s.clusters = opts.Clusters
+	if len(s.clusters) == 0 {
+		s.clusters = []Cluster{{GRC: opts.GRC, KubernetesClient: opts.KubernetesClient}}
+	}
+	for _, c := range s.clusters {
+		client := c.KubernetesClient
+		if c.Name == "" || client == nil || client == opts.KubernetesClient {
+			continue
+		}
+		s.AddReadinessCheck("kubernetes/"+c.Name, func() error {
+			_, err := client.Namespaces()
+			return err
+		})
+	}
+
+	return s
 }
 
 // Start - start server
@@ -91,6 +219,8 @@ func (s *TriggerServer) Start() error {
 
 	n := negroni.New(negroni.NewRecovery())
 	n.Use(negroni.HandlerFunc(corsHeadersMiddleware))
+	n.Use(negroni.HandlerFunc(s.webhookLimitsMiddleware))
+	n.Use(negroni.HandlerFunc(tracingMiddleware))
 	n.UseHandler(s.router)
 
 	s.server = &http.Server{
@@ -98,6 +228,24 @@ func (s *TriggerServer) Start() error {
 		Handler: n,
 	}
 
+	if s.tlsCertFile != "" || s.tlsKeyFile != "" {
+		reloader, err := newCertReloader(s.tlsCertFile, s.tlsKeyFile)
+		if err != nil {
+			return err
+		}
+		s.server.TLSConfig = &tls.Config{
+			MinVersion:     tls.VersionTLS12,
+			GetCertificate: reloader.GetCertificate,
+		}
+
+		log.WithFields(log.Fields{
+			"port": s.port,
+			"cert": s.tlsCertFile,
+		}).Info("webhook trigger server starting with TLS...")
+
+		return s.server.ListenAndServeTLS("", "")
+	}
+
 	log.WithFields(log.Fields{
 		"port": s.port,
 	}).Info("webhook trigger server starting...")
@@ -126,6 +274,7 @@ func (s *TriggerServer) registerRoutes(mux *mux.Router) {
 
 	// health endpoint for k8s to be happy
 	mux.HandleFunc("/healthz", s.healthHandler).Methods("GET", "OPTIONS")
+	mux.HandleFunc("/readyz", s.readyzHandler).Methods("GET", "OPTIONS")
 	// version handler
 	mux.HandleFunc("/version", s.versionHandler).Methods("GET", "OPTIONS")
 
@@ -135,30 +284,62 @@ func (s *TriggerServer) registerRoutes(mux *mux.Router) {
 		log.Info("authentication enabled, setting up admin HTTP handlers")
 		// auth
 		mux.HandleFunc("/v1/auth/login", s.loginHandler).Methods("POST", "OPTIONS")
-		mux.HandleFunc("/v1/auth/info", s.requireAdminAuthorization(s.userInfoHandler)).Methods("GET", "OPTIONS")
-		mux.HandleFunc("/v1/auth/user", s.requireAdminAuthorization(s.userInfoHandler)).Methods("GET", "OPTIONS")
-		mux.HandleFunc("/v1/auth/logout", s.requireAdminAuthorization(s.logoutHandler)).Methods("POST", "GET", "OPTIONS")
-		mux.HandleFunc("/v1/auth/refresh", s.requireAdminAuthorization(s.refreshHandler)).Methods("GET", "OPTIONS")
+		mux.HandleFunc("/v1/auth/info", s.requireReadAuthorization(s.userInfoHandler)).Methods("GET", "OPTIONS")
+		mux.HandleFunc("/v1/auth/user", s.requireReadAuthorization(s.userInfoHandler)).Methods("GET", "OPTIONS")
+		mux.HandleFunc("/v1/auth/logout", s.requireReadAuthorization(s.logoutHandler)).Methods("POST", "GET", "OPTIONS")
+		mux.HandleFunc("/v1/auth/refresh", s.requireReadAuthorization(s.refreshHandler)).Methods("GET", "OPTIONS")
 
 		// approvals
-		mux.HandleFunc("/v1/approvals", s.requireAdminAuthorization(s.approvalsHandler)).Methods("GET", "OPTIONS")
+		mux.HandleFunc("/v1/approvals", s.requireReadAuthorization(s.approvalsHandler)).Methods("GET", "OPTIONS")
 		// approving/rejecting
 		mux.HandleFunc("/v1/approvals", s.requireAdminAuthorization(s.approvalApproveHandler)).Methods("POST", "OPTIONS")
 		// updating required approvals count
 		mux.HandleFunc("/v1/approvals", s.requireAdminAuthorization(s.approvalSetHandler)).Methods("PUT", "OPTIONS")
 
 		// available resources
-		mux.HandleFunc("/v1/resources", s.requireAdminAuthorization(s.resourcesHandler)).Methods("GET", "OPTIONS")
+		mux.HandleFunc("/v1/resources", s.requireReadAuthorization(s.resourcesHandler)).Methods("GET", "OPTIONS")
 
 		mux.HandleFunc("/v1/policies", s.requireAdminAuthorization(s.policyUpdateHandler)).Methods("PUT", "OPTIONS")
+		// why resources would or wouldn't be updated to the image
+		mux.HandleFunc("/v1/policies/evaluate", s.requireReadAuthorization(s.policyEvaluateHandler)).Methods("GET", "OPTIONS")
+		mux.HandleFunc("/v1/resources/pause", s.requireAdminAuthorization(s.resourcePauseHandler)).Methods("PUT", "OPTIONS")
 
 		// tracked images
-		mux.HandleFunc("/v1/tracked", s.requireAdminAuthorization(s.trackedHandler)).Methods("GET", "OPTIONS")
+		mux.HandleFunc("/v1/tracked", s.requireReadAuthorization(s.trackedHandler)).Methods("GET", "OPTIONS")
 		mux.HandleFunc("/v1/tracked", s.requireAdminAuthorization(s.trackSetHandler)).Methods("PUT", "OPTIONS")
 
 		// status
-		mux.HandleFunc("/v1/audit", s.requireAdminAuthorization(s.adminAuditLogHandler)).Methods("GET", "OPTIONS")
-		mux.HandleFunc("/v1/stats", s.requireAdminAuthorization(s.statsHandler)).Methods("GET", "OPTIONS")
+		mux.HandleFunc("/v1/audit", s.requireReadAuthorization(s.adminAuditLogHandler)).Methods("GET", "OPTIONS")
+		mux.HandleFunc("/v1/history", s.requireReadAuthorization(s.adminHistoryHandler)).Methods("GET", "OPTIONS")
+		mux.HandleFunc("/v1/stats", s.requireReadAuthorization(s.statsHandler)).Methods("GET", "OPTIONS")
+		// tracked resources, watched images and policy errors
+		mux.HandleFunc("/v1/status", s.requireReadAuthorization(s.statusHandler)).Methods("GET", "OPTIONS")
+		// actions keel took on a single deployment
+		mux.HandleFunc("/v1/deployments/{namespace}/{name}/history", s.requireReadAuthorization(s.deploymentHistoryHandler)).Methods("GET", "OPTIONS")
+
+		// updates waiting for resource update windows
+		mux.HandleFunc("/v1/queued", s.requireReadAuthorization(s.queuedHandler)).Methods("GET", "OPTIONS")
+
+		// events that couldn't be applied
+		mux.HandleFunc("/v1/deadletters", s.requireReadAuthorization(s.deadLettersHandler)).Methods("GET", "OPTIONS")
+		mux.HandleFunc("/v1/deadletters/{id}/replay", s.requireAdminAuthorization(s.deadLetterReplayHandler)).Methods("POST", "OPTIONS")
+		mux.HandleFunc("/v1/deadletters/{id}", s.requireAdminAuthorization(s.deadLetterDiscardHandler)).Methods("DELETE", "OPTIONS")
+
+		// parses webhook payload and reports what would be updated
+		mux.HandleFunc("/v1/webhooks/test", s.requireAdminAuthorization(s.webhookTestHandler)).Methods("POST", "OPTIONS")
+
+		// real time events, server-sent events
+		mux.HandleFunc("/v1/stream", s.requireReadAuthorization(s.streamHandler)).Methods("GET", "OPTIONS")
+
+		// break-glass switch to suspend all updates
+		mux.HandleFunc("/v1/pause", s.requireAdminAuthorization(s.pauseHandler)).Methods("POST", "OPTIONS")
+		mux.HandleFunc("/v1/resume", s.requireAdminAuthorization(s.resumeHandler)).Methods("POST", "OPTIONS")
+		mux.HandleFunc("/v1/config/pause", s.requireReadAuthorization(s.pauseConfigHandler)).Methods("GET", "OPTIONS")
+		mux.HandleFunc("/v1/config/pause", s.requireAdminAuthorization(s.pauseConfigSetHandler)).Methods("PUT", "OPTIONS")
+
+		// log verbosity, changed without a restart
+		mux.HandleFunc("/v1/config/loglevel", s.requireReadAuthorization(s.logLevelHandler)).Methods("GET", "OPTIONS")
+		mux.HandleFunc("/v1/config/loglevel", s.requireAdminAuthorization(s.logLevelSetHandler)).Methods("PUT", "OPTIONS")
 
 		if s.uiDir != "" {
 			// Serve static assets directly.
@@ -177,36 +358,41 @@ func (s *TriggerServer) registerRoutes(mux *mux.Router) {
 }
 
 func (s *TriggerServer) registerWebhookRoutes(mux *mux.Router) {
+	for name, handler := range s.webhookParsers() {
+		if s.webhooks != nil && !s.webhooks[name] {
+			log.WithFields(log.Fields{
+				"webhook": name,
+			}).Debug("trigger server: webhook endpoint disabled")
+			continue
+		}
 
-	if s.authenticatedWebhooks {
-		mux.HandleFunc("/v1/webhooks/native", s.requireAdminAuthorization(s.nativeHandler)).Methods("POST", "OPTIONS")
-		mux.HandleFunc("/v1/webhooks/dockerhub", s.requireAdminAuthorization(s.dockerHubHandler)).Methods("POST", "OPTIONS")
-		mux.HandleFunc("/v1/webhooks/quay", s.requireAdminAuthorization(s.quayHandler)).Methods("POST", "OPTIONS")
-		mux.HandleFunc("/v1/webhooks/azure", s.requireAdminAuthorization(s.azureHandler)).Methods("POST", "OPTIONS")
-		mux.HandleFunc("/v1/webhooks/github", s.requireAdminAuthorization(s.githubHandler)).Methods("POST", "OPTIONS")
-		mux.HandleFunc("/v1/webhooks/harbor", s.requireAdminAuthorization(s.harborHandler)).Methods("POST", "OPTIONS")
-
-		// Docker registry notifications, used by Docker, Gitlab, Harbor
-		// https://docs.docker.com/registry/notifications/
-		//https://docs.gitlab.com/ee/administration/container_registry.html#configure-container-registry-notifications
-		mux.HandleFunc("/v1/webhooks/registry", s.registryNotificationHandler).Methods("POST", "OPTIONS")
-	} else {
-		mux.HandleFunc("/v1/webhooks/native", s.nativeHandler).Methods("POST", "OPTIONS")
-		mux.HandleFunc("/v1/webhooks/dockerhub", s.dockerHubHandler).Methods("POST", "OPTIONS")
-		mux.HandleFunc("/v1/webhooks/quay", s.quayHandler).Methods("POST", "OPTIONS")
-		mux.HandleFunc("/v1/webhooks/azure", s.azureHandler).Methods("POST", "OPTIONS")
-		mux.HandleFunc("/v1/webhooks/github", s.githubHandler).Methods("POST", "OPTIONS")
-		mux.HandleFunc("/v1/webhooks/harbor", s.harborHandler).Methods("POST", "OPTIONS")
-
-		// Docker registry notifications, used by Docker, Gitlab, Harbor
+		// Docker registry notifications, used by Docker, Gitlab, Harbor, are
+		// authenticated with the registry notification token instead
 		// https://docs.docker.com/registry/notifications/
-		//https://docs.gitlab.com/ee/administration/container_registry.html#configure-container-registry-notifications
-		mux.HandleFunc("/v1/webhooks/registry", s.registryNotificationHandler).Methods("POST", "OPTIONS")
+		// https://docs.gitlab.com/ee/administration/container_registry.html#configure-container-registry-notifications
+		if s.authenticatedWebhooks && name != "registry" {
+			handler = s.requireAdminAuthorization(handler)
+		}
+		mux.HandleFunc("/v1/webhooks/"+name, s.verifyWebhook(name, handler)).Methods("POST", "OPTIONS")
+	}
+
+	if s.webhooks != nil && len(s.webhooks) == 0 {
+		log.Info("trigger server: webhook triggers are disabled")
 	}
 }
 
 func (s *TriggerServer) healthHandler(resp http.ResponseWriter, req *http.Request) {
-	resp.WriteHeader(http.StatusOK)
+	checks, ok := s.livenessChecks.run()
+	status := http.StatusOK
+	if !ok {
+		status = http.StatusServiceUnavailable
+	}
+	response(&healthResponse{Paused: s.providers.Paused(), Checks: checks}, status, nil, resp, req)
+}
+
+type healthResponse struct {
+	Paused bool              `json:"paused"`
+	Checks map[string]string `json:"checks,omitempty"`
 }
 
 func (s *TriggerServer) versionHandler(resp http.ResponseWriter, req *http.Request) {
@@ -223,7 +409,13 @@ func (s *TriggerServer) versionHandler(resp http.ResponseWriter, req *http.Reque
 	resp.Write(encoded)
 }
 
-func (s *TriggerServer) trigger(event types.Event) error {
+func (s *TriggerServer) trigger(ctx context.Context, event types.Event) error {
+	if captured := getCapturedEvents(ctx); captured != nil {
+		captured.add(event)
+		return nil
+	}
+	webhookEventsCounter.With(prometheus.Labels{"trigger": event.TriggerName}).Inc()
+	event.TraceContext = tracing.Inject(ctx)
 	return s.providers.Submit(event)
 }
 
@@ -267,6 +459,21 @@ func response(obj interface{}, statusCode int, err error, resp http.ResponseWrit
 	}
 }
 
+// tracingMiddleware - starts a span for each webhook request, continuing the
+// trace if the caller propagated one
+func tracingMiddleware(rw http.ResponseWriter, r *http.Request, next http.HandlerFunc) {
+	if !strings.HasPrefix(r.URL.Path, "/v1/webhooks/") {
+		next(rw, r)
+		return
+	}
+
+	ctx := tracing.ExtractHTTP(r.Context(), r.Header)
+	ctx, span := tracing.Start(ctx, "http.webhook", attribute.String("http.target", r.URL.Path))
+	defer span.End()
+
+	next(rw, r.WithContext(ctx))
+}
+
 // corsHeadersMiddleware - cors middleware
 func corsHeadersMiddleware(rw http.ResponseWriter, r *http.Request, next http.HandlerFunc) {
 	rw.Header().Set("Access-Control-Allow-Origin", "*")
@@ -307,7 +514,7 @@ func (s *TriggerServer) userInfoHandler(resp http.ResponseWriter, req *http.Requ
 		Status:        1,
 		LastLoginIP:   "",
 		LastLoginTime: time.Now().Unix(),
-		RoleID:        "admin",
+		RoleID:        string(user.Role),
 	}
 
 	response(&ui, 200, nil, resp, req)
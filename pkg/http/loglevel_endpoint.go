@@ -0,0 +1,52 @@
+package http
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	log "github.com/sirupsen/logrus"
+)
+
+type logLevelRequest struct {
+	Level string `json:"level"`
+}
+
+type logLevelResponse struct {
+	Level string `json:"level"`
+}
+
+// logLevelHandler - returns current log level
+func (s *TriggerServer) logLevelHandler(resp http.ResponseWriter, req *http.Request) {
+	response(&logLevelResponse{Level: log.GetLevel().String()}, http.StatusOK, nil, resp, req)
+}
+
+// logLevelSetHandler - changes log level at runtime, ie: {"level": "debug"}
+func (s *TriggerServer) logLevelSetHandler(resp http.ResponseWriter, req *http.Request) {
+	var lr logLevelRequest
+	dec := json.NewDecoder(req.Body)
+	defer req.Body.Close()
+
+	err := dec.Decode(&lr)
+	if err != nil {
+		resp.WriteHeader(http.StatusBadRequest)
+		fmt.Fprintf(resp, "%s", err)
+		return
+	}
+
+	level, err := log.ParseLevel(lr.Level)
+	if err != nil {
+		http.Error(resp, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if level != log.GetLevel() {
+		log.WithFields(log.Fields{
+			"previous": log.GetLevel().String(),
+			"level":    level.String(),
+		}).Info("log level changed")
+		log.SetLevel(level)
+	}
+
+	response(&logLevelResponse{Level: level.String()}, http.StatusOK, nil, resp, req)
+}
@@ -0,0 +1,60 @@
+package http
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	log "github.com/sirupsen/logrus"
+)
+
+func TestConfigLogLevel(t *testing.T) {
+	fp := &fakeProvider{}
+	srv, teardown := NewTestingServer(fp)
+	defer teardown()
+
+	defer log.SetLevel(log.GetLevel())
+	log.SetLevel(log.InfoLevel)
+
+	do := func(method string, body []byte, admin bool) *httptest.ResponseRecorder {
+		req, err := http.NewRequest(method, "/v1/config/loglevel", bytes.NewBuffer(body))
+		if err != nil {
+			t.Fatalf("failed to create req: %s", err)
+		}
+		if admin {
+			req.SetBasicAuth("user-1", "secret")
+		}
+		rec := httptest.NewRecorder()
+		srv.router.ServeHTTP(rec, req)
+		return rec
+	}
+
+	if rec := do("PUT", []byte(`{"level": "debug"}`), false); rec.Code != http.StatusUnauthorized {
+		t.Errorf("expected log level change to require authentication, got: %d", rec.Code)
+	}
+
+	if rec := do("PUT", []byte(`{"level": "verbose"}`), true); rec.Code != http.StatusBadRequest {
+		t.Errorf("expected invalid level to be rejected, got: %d", rec.Code)
+	}
+
+	if rec := do("PUT", []byte(`{"level": "debug"}`), true); rec.Code != 200 {
+		t.Fatalf("unexpected status code: %d", rec.Code)
+	}
+	if log.GetLevel() != log.DebugLevel {
+		t.Errorf("expected debug level, got: %s", log.GetLevel())
+	}
+
+	rec := do("GET", nil, true)
+	if rec.Code != 200 {
+		t.Fatalf("unexpected status code: %d", rec.Code)
+	}
+	var lr logLevelResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &lr); err != nil {
+		t.Fatalf("failed to unmarshal response: %s", err)
+	}
+	if lr.Level != "debug" {
+		t.Errorf("expected debug level, got: %s", lr.Level)
+	}
+}
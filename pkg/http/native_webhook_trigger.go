@@ -52,7 +52,7 @@ func (s *TriggerServer) nativeHandler(resp http.ResponseWriter, req *http.Reques
 	event.Repository = repo
 	event.CreatedAt = time.Now()
 	event.TriggerName = "native"
-	s.trigger(event)
+	s.trigger(req.Context(), event)
 
 	resp.WriteHeader(http.StatusOK)
 
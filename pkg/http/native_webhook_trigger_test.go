@@ -88,6 +88,15 @@ func (p *fakeProvider) Stop() {
 func (p *fakeProvider) GetName() string {
 	return "fp"
 }
+func (p *fakeProvider) Explain(event types.Event) []*types.UpdateDecision {
+	return []*types.UpdateDecision{{
+		Provider:   "fp",
+		Identifier: "deployment/default/wd",
+		Image:      event.Repository.Name + ":0.0.1",
+		Update:     true,
+		NewImage:   event.Repository.Name + ":" + event.Repository.Tag,
+	}}
+}
 func TestNativeWebhookHandler(t *testing.T) {
 
 	fp := &fakeProvider{}
@@ -136,3 +145,47 @@ func TestNativeWebhookHandlerNoRepoName(t *testing.T) {
 	}
 
 }
+
+func TestWebhooksDisabled(t *testing.T) {
+	store, teardown := NewTestingUtils()
+	defer teardown()
+
+	am := approvals.New(&approvals.Opts{
+		Store: store,
+	})
+
+	for _, tc := range []struct {
+		webhooks []string
+		served   map[string]bool
+	}{
+		{webhooks: nil, served: map[string]bool{"native": true, "dockerhub": true}},
+		{webhooks: []string{"dockerhub", "unknown"}, served: map[string]bool{"native": false, "dockerhub": true}},
+		{webhooks: []string{}, served: map[string]bool{"native": false, "dockerhub": false}},
+	} {
+		fp := &fakeProvider{}
+		srv := NewTriggerServer(&Opts{
+			Providers:       provider.New([]provider.Provider{fp}, am),
+			ApprovalManager: am,
+			Store:           store,
+			Authenticator:   auth.New(&auth.Opts{}),
+			Webhooks:        tc.webhooks,
+		})
+		srv.registerRoutes(srv.router)
+
+		for name, served := range tc.served {
+			req, err := http.NewRequest("POST", "/v1/webhooks/"+name, bytes.NewBuffer([]byte(`{}`)))
+			if err != nil {
+				t.Fatalf("failed to create req: %s", err)
+			}
+			rec := httptest.NewRecorder()
+			srv.router.ServeHTTP(rec, req)
+
+			if served && rec.Code == http.StatusNotFound {
+				t.Errorf("webhooks %v: expected %s to be served", tc.webhooks, name)
+			}
+			if !served && rec.Code != http.StatusNotFound {
+				t.Errorf("webhooks %v: expected %s to be disabled, got status code: %d", tc.webhooks, name, rec.Code)
+			}
+		}
+	}
+}
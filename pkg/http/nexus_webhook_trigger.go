@@ -0,0 +1,109 @@
+package http
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/keel-hq/keel/types"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	log "github.com/sirupsen/logrus"
+)
+
+var newNexusWebhooksCounter = prometheus.NewCounterVec(
+	prometheus.CounterOpts{
+		Name: "nexus_webhook_requests_total",
+		Help: "How many /v1/webhooks/nexus requests processed, partitioned by image.",
+	},
+	[]string{"image"},
+)
+
+func init() {
+	prometheus.MustRegister(newNexusWebhooksCounter)
+}
+
+// Example of Nexus repository component trigger (rm:repository:component)
+// {
+//   "timestamp": "2023-05-10T12:03:41.664+0000",
+//   "nodeId": "52905B51-4CD1-4E3A-9B6D-6B5D7B8B2B06",
+//   "initiator": "ci/10.0.0.12",
+//   "repositoryName": "docker-hosted",
+//   "action": "CREATED",
+//   "component": {
+//     "id": "08909bf0c86cf6c9600aade89e1c5e25",
+//     "componentId": "ZG9ja2VyLWhvc3RlZDowODkwOWJmMGM4NmNmNmM5NjAwYWFkZTg5ZTFjNWUyNQ",
+//     "format": "docker",
+//     "name": "library/alpine",
+//     "group": null,
+//     "version": "3.12"
+//   }
+// }
+
+type nexusWebhook struct {
+	RepositoryName string `json:"repositoryName"`
+	Action         string `json:"action"`
+	Component      struct {
+		Format  string `json:"format"`
+		Name    string `json:"name"`
+		Version string `json:"version"`
+	} `json:"component"`
+}
+
+// nexusHandler - handles Nexus repository component webhooks of docker
+// repositories. Nexus serves docker repositories on their own connector
+// host or port, so it has to be set via "registry" query parameter, ie:
+// /v1/webhooks/nexus?registry=nexus.example.com:8082
+func (s *TriggerServer) nexusHandler(resp http.ResponseWriter, req *http.Request) {
+	nw := nexusWebhook{}
+	if err := json.NewDecoder(req.Body).Decode(&nw); err != nil {
+		log.WithFields(log.Fields{
+			"error": err,
+		}).Error("trigger.nexusHandler: failed to decode request")
+		resp.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	// pushing a new tag of an existing image updates the component
+	if (nw.Action != "CREATED" && nw.Action != "UPDATED") || nw.Component.Format != "docker" {
+		log.WithFields(log.Fields{
+			"action": nw.Action,
+			"format": nw.Component.Format,
+		}).Debug("trigger.nexusHandler: ignoring non docker push event")
+		resp.WriteHeader(http.StatusOK)
+		return
+	}
+
+	if nw.Component.Name == "" || nw.Component.Version == "" {
+		resp.WriteHeader(http.StatusBadRequest)
+		fmt.Fprintf(resp, "component name and version cannot be empty")
+		return
+	}
+
+	registry := strings.TrimSpace(req.URL.Query().Get("registry"))
+	if registry == "" {
+		resp.WriteHeader(http.StatusBadRequest)
+		fmt.Fprintf(resp, "registry query parameter is required (docker connector host of repository %s)", nw.RepositoryName)
+		return
+	}
+
+	event := types.Event{}
+	event.CreatedAt = time.Now()
+	event.TriggerName = "nexus"
+	event.Repository.Name = strings.TrimSuffix(registry, "/") + "/" + nw.Component.Name
+	event.Repository.Tag = nw.Component.Version
+
+	log.WithFields(log.Fields{
+		"action":     nw.Action,
+		"tag":        event.Repository.Tag,
+		"repository": event.Repository.Name,
+	}).Debug("trigger.nexusHandler: got component notification, processing")
+
+	s.trigger(req.Context(), event)
+	newNexusWebhooksCounter.With(prometheus.Labels{"image": event.Repository.Name}).Inc()
+
+	resp.WriteHeader(http.StatusOK)
+}
@@ -0,0 +1,97 @@
+package http
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+var fakeNexusWebhook = `{
+  "timestamp": "2023-05-10T12:03:41.664+0000",
+  "nodeId": "52905B51-4CD1-4E3A-9B6D-6B5D7B8B2B06",
+  "initiator": "ci/10.0.0.12",
+  "repositoryName": "docker-hosted",
+  "action": "CREATED",
+  "component": {
+    "id": "08909bf0c86cf6c9600aade89e1c5e25",
+    "componentId": "ZG9ja2VyLWhvc3RlZDowODkwOWJmMGM4NmNmNmM5NjAwYWFkZTg5ZTFjNWUyNQ",
+    "format": "docker",
+    "name": "library/alpine",
+    "group": null,
+    "version": "3.12"
+  }
+}
+`
+
+func TestNexusWebhookHandler(t *testing.T) {
+	fp := &fakeProvider{}
+	srv, teardown := NewTestingServer(fp)
+	defer teardown()
+
+	req, err := http.NewRequest("POST", "/v1/webhooks/nexus?registry=nexus.example.com:8082", bytes.NewBuffer([]byte(fakeNexusWebhook)))
+	if err != nil {
+		t.Fatalf("failed to create req: %s", err)
+	}
+
+	rec := httptest.NewRecorder()
+	srv.router.ServeHTTP(rec, req)
+	if rec.Code != 200 {
+		t.Fatalf("unexpected status code: %d, body: %s", rec.Code, rec.Body.String())
+	}
+
+	if len(fp.submitted) != 1 {
+		t.Fatalf("unexpected number of events submitted: %d", len(fp.submitted))
+	}
+
+	if fp.submitted[0].Repository.Name != "nexus.example.com:8082/library/alpine" {
+		t.Errorf("unexpected repository: %s", fp.submitted[0].Repository.Name)
+	}
+
+	if fp.submitted[0].Repository.Tag != "3.12" {
+		t.Errorf("expected 3.12 but got %s", fp.submitted[0].Repository.Tag)
+	}
+}
+
+func TestNexusWebhookHandlerRequiresRegistry(t *testing.T) {
+	fp := &fakeProvider{}
+	srv, teardown := NewTestingServer(fp)
+	defer teardown()
+
+	req, err := http.NewRequest("POST", "/v1/webhooks/nexus", bytes.NewBuffer([]byte(fakeNexusWebhook)))
+	if err != nil {
+		t.Fatalf("failed to create req: %s", err)
+	}
+
+	rec := httptest.NewRecorder()
+	srv.router.ServeHTTP(rec, req)
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("unexpected status code: %d", rec.Code)
+	}
+
+	if len(fp.submitted) != 0 {
+		t.Errorf("unexpected number of events submitted: %d", len(fp.submitted))
+	}
+}
+
+func TestNexusWebhookHandlerIgnoresOtherEvents(t *testing.T) {
+	fp := &fakeProvider{}
+	srv, teardown := NewTestingServer(fp)
+	defer teardown()
+
+	body := `{"repositoryName": "npm-hosted", "action": "CREATED", "component": {"format": "npm", "name": "left-pad", "version": "1.3.0"}}`
+	req, err := http.NewRequest("POST", "/v1/webhooks/nexus?registry=nexus.example.com:8082", bytes.NewBuffer([]byte(body)))
+	if err != nil {
+		t.Fatalf("failed to create req: %s", err)
+	}
+
+	rec := httptest.NewRecorder()
+	srv.router.ServeHTTP(rec, req)
+	if rec.Code != 200 {
+		t.Errorf("unexpected status code: %d", rec.Code)
+	}
+
+	if len(fp.submitted) != 0 {
+		t.Errorf("unexpected number of events submitted: %d", len(fp.submitted))
+	}
+}
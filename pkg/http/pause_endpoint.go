@@ -0,0 +1,143 @@
+package http
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/keel-hq/keel/pkg/auth"
+	"github.com/keel-hq/keel/types"
+
+	log "github.com/sirupsen/logrus"
+)
+
+type pauseResponse struct {
+	Paused bool `json:"paused"`
+}
+
+func (s *TriggerServer) pauseHandler(resp http.ResponseWriter, req *http.Request) {
+	s.providers.Pause()
+	s.notifyPause(req, true)
+
+	response(&pauseResponse{Paused: true}, http.StatusOK, nil, resp, req)
+}
+
+func (s *TriggerServer) resumeHandler(resp http.ResponseWriter, req *http.Request) {
+	s.providers.Resume()
+	s.notifyPause(req, false)
+
+	response(&pauseResponse{Paused: false}, http.StatusOK, nil, resp, req)
+}
+
+type pauseRequest struct {
+	Paused *bool `json:"paused"`
+}
+
+// pauseConfigHandler - returns whether updates are paused
+func (s *TriggerServer) pauseConfigHandler(resp http.ResponseWriter, req *http.Request) {
+	response(&pauseResponse{Paused: s.providers.Paused()}, http.StatusOK, nil, resp, req)
+}
+
+// pauseConfigSetHandler - pauses or resumes updates, ie: {"paused": true}
+func (s *TriggerServer) pauseConfigSetHandler(resp http.ResponseWriter, req *http.Request) {
+	var pr pauseRequest
+	dec := json.NewDecoder(req.Body)
+	defer req.Body.Close()
+
+	err := dec.Decode(&pr)
+	if err != nil {
+		resp.WriteHeader(http.StatusBadRequest)
+		fmt.Fprintf(resp, "%s", err)
+		return
+	}
+
+	if pr.Paused == nil {
+		http.Error(resp, "paused is required", http.StatusBadRequest)
+		return
+	}
+
+	if *pr.Paused == s.providers.Paused() {
+		response(&pauseResponse{Paused: *pr.Paused}, http.StatusOK, nil, resp, req)
+		return
+	}
+
+	if *pr.Paused {
+		s.pauseHandler(resp, req)
+		return
+	}
+	s.resumeHandler(resp, req)
+}
+
+type resourcePauseRequest struct {
+	Identifier string `json:"identifier"`
+	Paused     bool   `json:"paused"`
+	Cluster    string `json:"cluster,omitempty"`
+}
+
+// resourcePauseHandler - pauses or resumes updates of a single resource by
+// setting or removing keel.sh/paused
+func (s *TriggerServer) resourcePauseHandler(resp http.ResponseWriter, req *http.Request) {
+	var pr resourcePauseRequest
+	dec := json.NewDecoder(req.Body)
+	defer req.Body.Close()
+
+	err := dec.Decode(&pr)
+	if err != nil {
+		resp.WriteHeader(http.StatusBadRequest)
+		fmt.Fprintf(resp, "%s", err)
+		return
+	}
+
+	if pr.Identifier == "" {
+		http.Error(resp, "identifier cannot be empty", http.StatusBadRequest)
+		return
+	}
+
+	if v, ok := s.findResource(pr.Cluster, pr.Identifier); ok {
+		ann := v.GetAnnotations()
+		if pr.Paused {
+			ann[types.KeelPausedAnnotation] = "true"
+		} else {
+			delete(ann, types.KeelPausedAnnotation)
+		}
+		v.SetAnnotations(ann)
+
+		err := v.client.Update(v.GenericResource)
+
+		response(&APIResponse{Status: "updated"}, 200, err, resp, req)
+		return
+	}
+
+	resp.WriteHeader(http.StatusNotFound)
+	fmt.Fprintf(resp, "resource with identifier '%s' not found", pr.Identifier)
+}
+
+func (s *TriggerServer) notifyPause(req *http.Request, paused bool) {
+	username := "unknown"
+	if user := auth.GetAccountFromCtx(req.Context()); user != nil {
+		username = user.Username
+	}
+
+	log.WithFields(log.Fields{
+		"paused": paused,
+		"user":   username,
+	}).Warn("http: updates pause state changed")
+
+	if s.sender == nil {
+		return
+	}
+
+	name, level := "updates resumed", types.LevelInfo
+	if paused {
+		name, level = "updates paused", types.LevelWarn
+	}
+
+	s.sender.Send(types.EventNotification{
+		Name:      name,
+		Message:   fmt.Sprintf("Keel %s by %s", name, username),
+		CreatedAt: time.Now(),
+		Type:      types.NotificationSystemEvent,
+		Level:     level,
+	})
+}
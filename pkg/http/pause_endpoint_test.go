@@ -0,0 +1,120 @@
+package http
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestPauseResume(t *testing.T) {
+	fp := &fakeProvider{}
+	srv, teardown := NewTestingServer(fp)
+	defer teardown()
+
+	do := func(method, path string, body []byte, admin bool) *httptest.ResponseRecorder {
+		req, err := http.NewRequest(method, path, bytes.NewBuffer(body))
+		if err != nil {
+			t.Fatalf("failed to create req: %s", err)
+		}
+		if admin {
+			req.SetBasicAuth("user-1", "secret")
+		}
+		rec := httptest.NewRecorder()
+		srv.router.ServeHTTP(rec, req)
+		return rec
+	}
+
+	paused := func() bool {
+		rec := do("GET", "/healthz", nil, false)
+		if rec.Code != 200 {
+			t.Fatalf("unexpected health status code: %d", rec.Code)
+		}
+		var hr healthResponse
+		if err := json.Unmarshal(rec.Body.Bytes(), &hr); err != nil {
+			t.Fatalf("failed to unmarshal health response: %s", err)
+		}
+		return hr.Paused
+	}
+
+	if rec := do("POST", "/v1/pause", nil, false); rec.Code != http.StatusUnauthorized {
+		t.Errorf("expected pause to require authentication, got: %d", rec.Code)
+	}
+
+	if rec := do("POST", "/v1/pause", nil, true); rec.Code != 200 {
+		t.Fatalf("unexpected status code: %d", rec.Code)
+	}
+	if !paused() {
+		t.Errorf("expected health endpoint to report paused state")
+	}
+
+	rec := do("POST", "/v1/webhooks/native", []byte(`{"name": "gcr.io/v2-namespace/hello-world", "tag": "1.1.1"}`), false)
+	if rec.Code != 200 {
+		t.Fatalf("unexpected webhook status code: %d", rec.Code)
+	}
+	if len(fp.submitted) != 0 {
+		t.Fatalf("expected event to be queued while paused, got %d submitted", len(fp.submitted))
+	}
+
+	if rec := do("POST", "/v1/resume", nil, true); rec.Code != 200 {
+		t.Fatalf("unexpected status code: %d", rec.Code)
+	}
+	if paused() {
+		t.Errorf("expected health endpoint to report resumed state")
+	}
+	if len(fp.submitted) != 1 {
+		t.Errorf("expected queued event to be submitted on resume, got: %d", len(fp.submitted))
+	}
+}
+
+func TestConfigPause(t *testing.T) {
+	fp := &fakeProvider{}
+	srv, teardown := NewTestingServer(fp)
+	defer teardown()
+
+	do := func(method string, body []byte) *httptest.ResponseRecorder {
+		req, err := http.NewRequest(method, "/v1/config/pause", bytes.NewBuffer(body))
+		if err != nil {
+			t.Fatalf("failed to create req: %s", err)
+		}
+		req.SetBasicAuth("user-1", "secret")
+		rec := httptest.NewRecorder()
+		srv.router.ServeHTTP(rec, req)
+		return rec
+	}
+
+	paused := func() bool {
+		rec := do("GET", nil)
+		if rec.Code != 200 {
+			t.Fatalf("unexpected status code: %d", rec.Code)
+		}
+		var pr pauseResponse
+		if err := json.Unmarshal(rec.Body.Bytes(), &pr); err != nil {
+			t.Fatalf("failed to unmarshal response: %s", err)
+		}
+		return pr.Paused
+	}
+
+	if paused() {
+		t.Fatalf("didn't expect updates to be paused")
+	}
+
+	if rec := do("PUT", []byte(`{"paused": true}`)); rec.Code != 200 {
+		t.Fatalf("unexpected status code: %d", rec.Code)
+	}
+	if !paused() {
+		t.Errorf("expected updates to be paused")
+	}
+
+	if rec := do("PUT", []byte(`{}`)); rec.Code != http.StatusBadRequest {
+		t.Errorf("expected missing paused to be rejected, got: %d", rec.Code)
+	}
+
+	if rec := do("PUT", []byte(`{"paused": false}`)); rec.Code != 200 {
+		t.Fatalf("unexpected status code: %d", rec.Code)
+	}
+	if paused() {
+		t.Errorf("expected updates to be resumed")
+	}
+}
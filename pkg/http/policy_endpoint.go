@@ -6,12 +6,14 @@ import (
 	"net/http"
 
 	"github.com/keel-hq/keel/types"
+	"github.com/keel-hq/keel/util/image"
 )
 
 type resourcePolicyUpdateRequest struct {
 	Policy     string `json:"policy"`
 	Identifier string `json:"identifier"`
 	Provider   string `json:"provider"`
+	Cluster    string `json:"cluster,omitempty"`
 }
 
 func (s *TriggerServer) policyUpdateHandler(resp http.ResponseWriter, req *http.Request) {
@@ -31,26 +33,49 @@ func (s *TriggerServer) policyUpdateHandler(resp http.ResponseWriter, req *http.
 		return
 	}
 
-	for _, v := range s.grc.Values() {
-		if v.Identifier == policyRequest.Identifier {
+	if v, ok := s.findResource(policyRequest.Cluster, policyRequest.Identifier); ok {
+		labels := v.GetLabels()
+		delete(labels, types.KeelPolicyLabel)
+		v.SetLabels(labels)
 
-			labels := v.GetLabels()
-			delete(labels, types.KeelPolicyLabel)
-			v.SetLabels(labels)
+		ann := v.GetAnnotations()
+		ann[types.KeelPolicyLabel] = policyRequest.Policy
 
-			ann := v.GetAnnotations()
-			ann[types.KeelPolicyLabel] = policyRequest.Policy
+		v.SetAnnotations(ann)
 
-			v.SetAnnotations(ann)
+		err := v.client.Update(v.GenericResource)
 
-			err := s.kubernetesClient.Update(v)
-
-			response(&APIResponse{Status: "updated"}, 200, err, resp, req)
-			return
-		}
+		response(&APIResponse{Status: "updated"}, 200, err, resp, req)
+		return
 	}
 
 	resp.WriteHeader(http.StatusNotFound)
 	fmt.Fprintf(resp, "resource with identifier '%s' not found", policyRequest.Identifier)
 	return
 }
+
+// policyEvaluateHandler - evaluates ?image=repo:tag against policies of all
+// resources using the image, nothing is applied
+func (s *TriggerServer) policyEvaluateHandler(resp http.ResponseWriter, req *http.Request) {
+	img := req.URL.Query().Get("image")
+	if img == "" {
+		resp.WriteHeader(http.StatusBadRequest)
+		fmt.Fprintf(resp, "image cannot be empty")
+		return
+	}
+
+	ref, err := image.Parse(img)
+	if err != nil {
+		resp.WriteHeader(http.StatusBadRequest)
+		fmt.Fprintf(resp, "invalid image: %s", err)
+		return
+	}
+
+	event := types.Event{
+		Repository: types.Repository{
+			Name: ref.Repository(),
+			Tag:  ref.Tag(),
+		},
+	}
+	response(s.providers.Explain(event), http.StatusOK, nil, resp, req)
+}
@@ -0,0 +1,47 @@
+package http
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/keel-hq/keel/types"
+)
+
+func TestPolicyEvaluate(t *testing.T) {
+	fp := &fakeProvider{}
+	srv, teardown := NewTestingServer(fp)
+	defer teardown()
+
+	do := func(path string) *httptest.ResponseRecorder {
+		req, err := http.NewRequest("GET", path, nil)
+		if err != nil {
+			t.Fatalf("failed to create req: %s", err)
+		}
+		req.SetBasicAuth("user-1", "secret")
+		rec := httptest.NewRecorder()
+		srv.router.ServeHTTP(rec, req)
+		return rec
+	}
+
+	rec := do("/v1/policies/evaluate?image=karolisr/webhook-demo:0.0.15")
+	if rec.Code != 200 {
+		t.Fatalf("unexpected status code: %d, body: %s", rec.Code, rec.Body.String())
+	}
+	var decisions []*types.UpdateDecision
+	if err := json.Unmarshal(rec.Body.Bytes(), &decisions); err != nil {
+		t.Fatalf("failed to unmarshal response: %s", err)
+	}
+	if len(decisions) != 1 || decisions[0].NewImage != "index.docker.io/karolisr/webhook-demo:0.0.15" {
+		t.Errorf("unexpected decisions: %+v", decisions)
+	}
+	if len(fp.submitted) != 0 {
+		t.Errorf("expected evaluation not to submit events, got: %d", len(fp.submitted))
+	}
+
+	rec = do("/v1/policies/evaluate")
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("expected missing image to be rejected, got: %d", rec.Code)
+	}
+}
@@ -75,7 +75,7 @@ func (s *TriggerServer) quayHandler(resp http.ResponseWriter, req *http.Request)
 		event.Repository.Name = qw.DockerURL
 		event.Repository.Tag = tag
 
-		s.trigger(event)
+		s.trigger(req.Context(), event)
 		newQuayWebhooksCounter.With(prometheus.Labels{"image": event.Repository.Name}).Inc()
 	}
 
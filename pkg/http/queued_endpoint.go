@@ -0,0 +1,9 @@
+package http
+
+import (
+	"net/http"
+)
+
+func (s *TriggerServer) queuedHandler(resp http.ResponseWriter, req *http.Request) {
+	response(s.providers.QueuedUpdates(), http.StatusOK, nil, resp, req)
+}
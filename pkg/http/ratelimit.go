@@ -0,0 +1,134 @@
+package http
+
+import (
+	"fmt"
+	"math"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// DefaultMaxWebhookBodySize - max size of webhook request bodies, registry
+// payloads are a few kilobytes
+const DefaultMaxWebhookBodySize = 1 << 20
+
+// limiterIdleTimeout - buckets of sources that were idle for this long are dropped
+const limiterIdleTimeout = 10 * time.Minute
+
+var webhooksRejectedCounter = prometheus.NewCounterVec(
+	prometheus.CounterOpts{
+		Name: "webhook_requests_rejected_total",
+		Help: "Webhook requests rejected due to rate or size limits.",
+	},
+	[]string{"reason"},
+)
+
+func init() {
+	prometheus.MustRegister(webhooksRejectedCounter)
+}
+
+// rateLimiter - token bucket per request source
+type rateLimiter struct {
+	rate  float64 // tokens per second
+	burst float64
+
+	mu      sync.Mutex
+	buckets map[string]*bucket
+	swept   time.Time
+}
+
+type bucket struct {
+	tokens float64
+	last   time.Time
+}
+
+func newRateLimiter(rate float64, burst int) *rateLimiter {
+	if burst < 1 {
+		burst = int(math.Ceil(rate))
+	}
+	return &rateLimiter{
+		rate:    rate,
+		burst:   float64(burst),
+		buckets: make(map[string]*bucket),
+	}
+}
+
+// allow - takes a token from source bucket, when empty returns how long until
+// the next one is available
+func (l *rateLimiter) allow(source string, now time.Time) (bool, time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if now.Sub(l.swept) > limiterIdleTimeout {
+		for k, b := range l.buckets {
+			if now.Sub(b.last) > limiterIdleTimeout {
+				delete(l.buckets, k)
+			}
+		}
+		l.swept = now
+	}
+
+	b, ok := l.buckets[source]
+	if !ok {
+		b = &bucket{tokens: l.burst, last: now}
+		l.buckets[source] = b
+	}
+
+	b.tokens = math.Min(l.burst, b.tokens+now.Sub(b.last).Seconds()*l.rate)
+	b.last = now
+
+	if b.tokens < 1 {
+		return false, time.Duration((1 - b.tokens) / l.rate * float64(time.Second))
+	}
+	b.tokens--
+	return true, 0
+}
+
+// requestSource - source IP of the request. Tokens aren't verified yet when
+// requests are limited, keying on them would let callers get a fresh bucket
+// with every random token
+func requestSource(req *http.Request) string {
+	host, _, err := net.SplitHostPort(req.RemoteAddr)
+	if err != nil {
+		host = req.RemoteAddr
+	}
+	return "ip:" + host
+}
+
+// webhookLimitsMiddleware - limits request rate and body size of webhook endpoints
+func (s *TriggerServer) webhookLimitsMiddleware(rw http.ResponseWriter, r *http.Request, next http.HandlerFunc) {
+	if !strings.HasPrefix(r.URL.Path, "/v1/webhooks/") || r.Method == "OPTIONS" {
+		next(rw, r)
+		return
+	}
+
+	if s.limiter != nil {
+		source := requestSource(r)
+		if ok, wait := s.limiter.allow(source, time.Now()); !ok {
+			webhooksRejectedCounter.With(prometheus.Labels{"reason": "rate"}).Inc()
+			log.WithFields(log.Fields{
+				"source": source,
+				"path":   r.URL.Path,
+			}).Warn("trigger.http: webhook rate limit exceeded")
+			rw.Header().Set("Retry-After", fmt.Sprintf("%d", int(math.Ceil(wait.Seconds()))))
+			http.Error(rw, http.StatusText(http.StatusTooManyRequests), http.StatusTooManyRequests)
+			return
+		}
+	}
+
+	if r.ContentLength > s.maxWebhookBodySize {
+		webhooksRejectedCounter.With(prometheus.Labels{"reason": "size"}).Inc()
+		http.Error(rw, http.StatusText(http.StatusRequestEntityTooLarge), http.StatusRequestEntityTooLarge)
+		return
+	}
+	// chunked requests fail when reading past the limit
+	r.Body = http.MaxBytesReader(rw, r.Body, s.maxWebhookBodySize)
+
+	next(rw, r)
+}
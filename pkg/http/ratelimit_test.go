@@ -0,0 +1,87 @@
+package http
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRateLimiter(t *testing.T) {
+	l := newRateLimiter(1, 2)
+	now := time.Now()
+
+	for i := 0; i < 2; i++ {
+		if ok, _ := l.allow("ip:10.0.0.1", now); !ok {
+			t.Fatalf("expected request %d to be allowed", i)
+		}
+	}
+	ok, wait := l.allow("ip:10.0.0.1", now)
+	if ok {
+		t.Fatalf("expected request over burst to be rejected")
+	}
+	if wait <= 0 || wait > time.Second {
+		t.Errorf("unexpected wait: %s", wait)
+	}
+
+	if ok, _ := l.allow("ip:10.0.0.2", now); !ok {
+		t.Errorf("expected other source to be allowed")
+	}
+
+	if ok, _ := l.allow("ip:10.0.0.1", now.Add(time.Second)); !ok {
+		t.Errorf("expected request to be allowed after refill")
+	}
+}
+
+func TestWebhookLimitsMiddleware(t *testing.T) {
+	fp := &fakeProvider{}
+	srv, teardown := NewTestingServer(fp)
+	defer teardown()
+	srv.limiter = newRateLimiter(1, 2)
+	srv.maxWebhookBodySize = 128
+
+	do := func(body string) *httptest.ResponseRecorder {
+		req, err := http.NewRequest("POST", "/v1/webhooks/native", bytes.NewBufferString(body))
+		if err != nil {
+			t.Fatalf("failed to create req: %s", err)
+		}
+		req.RemoteAddr = "10.0.0.1:4321"
+		rec := httptest.NewRecorder()
+		srv.webhookLimitsMiddleware(rec, req, srv.router.ServeHTTP)
+		return rec
+	}
+
+	if rec := do(strings.Repeat("x", 256)); rec.Code != http.StatusRequestEntityTooLarge {
+		t.Errorf("expected oversized body to be rejected, got: %d", rec.Code)
+	}
+
+	if rec := do(`{"name": "gcr.io/v2-namespace/hello-world", "tag": "1.1.1"}`); rec.Code != http.StatusOK {
+		t.Errorf("unexpected status code: %d", rec.Code)
+	}
+
+	rec := do(`{"name": "gcr.io/v2-namespace/hello-world", "tag": "1.1.2"}`)
+	if rec.Code != http.StatusTooManyRequests {
+		t.Fatalf("expected rate limited request, got: %d", rec.Code)
+	}
+	if rec.Header().Get("Retry-After") == "" {
+		t.Errorf("expected Retry-After header")
+	}
+	if len(fp.submitted) != 1 {
+		t.Errorf("expected 1 submitted event, got: %d", len(fp.submitted))
+	}
+
+	// unverified tokens don't get their own bucket
+	req, err := http.NewRequest("POST", "/v1/webhooks/native?token=random", bytes.NewBufferString(`{}`))
+	if err != nil {
+		t.Fatalf("failed to create req: %s", err)
+	}
+	req.RemoteAddr = "10.0.0.1:4322"
+	req.Header.Set("X-Gitlab-Token", "random")
+	rec = httptest.NewRecorder()
+	srv.webhookLimitsMiddleware(rec, req, srv.router.ServeHTTP)
+	if rec.Code != http.StatusTooManyRequests {
+		t.Errorf("expected request with a new token to be rate limited, got: %d", rec.Code)
+	}
+}
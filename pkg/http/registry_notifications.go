@@ -1,8 +1,10 @@
 package http
 
 import (
+	"crypto/subtle"
 	"encoding/json"
 	"net/http"
+	"strings"
 	"time"
 
 	"github.com/keel-hq/keel/types"
@@ -88,7 +90,30 @@ type registryNotification struct {
 	} `json:"events"`
 }
 
+// validRegistryNotificationToken - checks secret token when it's configured. GitLab sends it
+// in X-Gitlab-Token header, Docker registry can be configured to send Authorization header
+func (s *TriggerServer) validRegistryNotificationToken(req *http.Request) bool {
+	if s.registryNotificationToken == "" {
+		return true
+	}
+
+	token := req.Header.Get("X-Gitlab-Token")
+	if token == "" {
+		token = strings.TrimPrefix(req.Header.Get("Authorization"), "Bearer ")
+	}
+
+	return subtle.ConstantTimeCompare([]byte(token), []byte(s.registryNotificationToken)) == 1
+}
+
 func (s *TriggerServer) registryNotificationHandler(resp http.ResponseWriter, req *http.Request) {
+	if !s.validRegistryNotificationToken(req) {
+		log.WithFields(log.Fields{
+			"remote_addr": req.RemoteAddr,
+		}).Warn("trigger.registryNotificationHandler: invalid or missing notification token")
+		resp.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
 	rn := registryNotification{}
 	if err := json.NewDecoder(req.Body).Decode(&rn); err != nil {
 		log.WithFields(log.Fields{
@@ -128,7 +153,7 @@ func (s *TriggerServer) registryNotificationHandler(resp http.ResponseWriter, re
 			"digest":     e.Target.Digest,
 		}).Debug("registryNotificationHandler: got registry notification, processing")
 
-		s.trigger(event)
+		s.trigger(req.Context(), event)
 
 		newRegistryNotificationWebhooksCounter.With(prometheus.Labels{"image": event.Repository.Name}).Inc()
 	}
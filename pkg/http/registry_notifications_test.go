@@ -74,3 +74,47 @@ func TestRegistryNotificationsHandler(t *testing.T) {
 		t.Errorf("expected 1.6.1 but got %s", fp.submitted[0].Repository.Tag)
 	}
 }
+
+func TestRegistryNotificationsHandlerToken(t *testing.T) {
+	tests := []struct {
+		name       string
+		headers    map[string]string
+		wantStatus int
+	}{
+		{name: "missing token", wantStatus: http.StatusUnauthorized},
+		{name: "wrong token", headers: map[string]string{"X-Gitlab-Token": "nope"}, wantStatus: http.StatusUnauthorized},
+		{name: "gitlab token", headers: map[string]string{"X-Gitlab-Token": "very-secret"}, wantStatus: http.StatusOK},
+		{name: "authorization header", headers: map[string]string{"Authorization": "Bearer very-secret"}, wantStatus: http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fp := &fakeProvider{}
+			srv, teardown := NewTestingServer(fp)
+			defer teardown()
+			srv.registryNotificationToken = "very-secret"
+
+			req, err := http.NewRequest("POST", "/v1/webhooks/registry", bytes.NewBuffer([]byte(fakeRegistryNotificationWebhook)))
+			if err != nil {
+				t.Fatalf("failed to create req: %s", err)
+			}
+			for k, v := range tt.headers {
+				req.Header.Set(k, v)
+			}
+
+			rec := httptest.NewRecorder()
+			srv.router.ServeHTTP(rec, req)
+			if rec.Code != tt.wantStatus {
+				t.Errorf("unexpected status code: %d, want: %d", rec.Code, tt.wantStatus)
+			}
+
+			wantSubmitted := 0
+			if tt.wantStatus == http.StatusOK {
+				wantSubmitted = 1
+			}
+			if len(fp.submitted) != wantSubmitted {
+				t.Errorf("unexpected number of events submitted: %d", len(fp.submitted))
+			}
+		})
+	}
+}
@@ -9,6 +9,7 @@ import (
 
 type resource struct {
 	Provider    string            `json:"provider"`
+	Cluster     string            `json:"cluster,omitempty"`
 	Identifier  string            `json:"identifier"`
 	Name        string            `json:"name"`
 	Namespace   string            `json:"namespace"`
@@ -22,16 +23,15 @@ type resource struct {
 
 func (s *TriggerServer) resourcesHandler(resp http.ResponseWriter, req *http.Request) {
 
-	vals := s.grc.Values()
-
 	var res []resource
 
-	for _, v := range vals {
+	for _, v := range s.resources() {
 
-		p := policy.GetPolicyFromLabelsOrAnnotations(v.GetLabels(), v.GetAnnotations())
+		p := policy.GetPolicyFromLabelsOrAnnotations(v.GetLabels(), v.GetKeelAnnotations())
 
 		res = append(res, resource{
 			Provider:    "kubernetes",
+			Cluster:     v.cluster,
 			Identifier:  v.Identifier,
 			Name:        v.Name,
 			Namespace:   v.Namespace,
@@ -0,0 +1,126 @@
+package http
+
+import (
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/keel-hq/keel/internal/policy"
+)
+
+// StatusReport - what keel tracks and watches, logged on startup after the
+// initial scan, misconfigured resources are listed in Errors
+type StatusReport struct {
+	GeneratedAt time.Time `json:"generatedAt"`
+
+	// Resources - kubernetes resources in all clusters
+	Resources int `json:"resources"`
+	// Tracked - resources (and releases) with tracked images
+	Tracked int `json:"tracked"`
+	// Policies - tracked resources by policy
+	Policies map[string]int `json:"policies"`
+	// Triggers - tracked resources by trigger
+	Triggers map[string]int `json:"triggers"`
+
+	Images []*WatchedImage `json:"images"`
+	Errors []*PolicyError  `json:"errors"`
+}
+
+// WatchedImage - image with its trigger and poll schedule, resources are
+// prefixed with their cluster when keel manages more than one
+type WatchedImage struct {
+	Image     string   `json:"image"`
+	Trigger   string   `json:"trigger"`
+	Schedule  string   `json:"schedule,omitempty"`
+	Resources []string `json:"resources"`
+}
+
+// PolicyError - invalid policy settings of a resource, its updates are
+// disabled
+type PolicyError struct {
+	Cluster    string `json:"cluster,omitempty"`
+	Identifier string `json:"identifier"`
+	Error      string `json:"error"`
+}
+
+// StatusReport - builds status report from tracked images and resources
+func (s *TriggerServer) StatusReport() (*StatusReport, error) {
+	trackedImages, err := s.providers.TrackedImages()
+	if err != nil {
+		return nil, err
+	}
+
+	report := &StatusReport{
+		GeneratedAt: time.Now(),
+		Policies:    make(map[string]int),
+		Triggers:    make(map[string]int),
+		Images:      []*WatchedImage{},
+		Errors:      []*PolicyError{},
+	}
+
+	tracked := make(map[string]bool)
+	triggers := make(map[string]bool)
+	images := make(map[string]*WatchedImage)
+	for _, img := range trackedImages {
+		resource := img.Identifier
+		if img.Cluster != "" {
+			resource = img.Cluster + "/" + img.Identifier
+		}
+		key := img.Provider + "/" + resource
+
+		if !tracked[key] {
+			tracked[key] = true
+			report.Tracked++
+			if img.Policy != nil {
+				report.Policies[img.Policy.Name()]++
+			}
+		}
+		if !triggers[key+"/"+img.Trigger.String()] {
+			triggers[key+"/"+img.Trigger.String()] = true
+			report.Triggers[img.Trigger.String()]++
+		}
+
+		imageKey := img.Image.Remote() + "/" + img.Trigger.String() + "/" + img.PollSchedule
+		watched, ok := images[imageKey]
+		if !ok {
+			watched = &WatchedImage{
+				Image:    img.Image.Remote(),
+				Trigger:  img.Trigger.String(),
+				Schedule: img.PollSchedule,
+			}
+			images[imageKey] = watched
+			report.Images = append(report.Images, watched)
+		}
+		watched.Resources = append(watched.Resources, resource)
+	}
+	sort.Slice(report.Images, func(i, j int) bool {
+		if report.Images[i].Image != report.Images[j].Image {
+			return report.Images[i].Image < report.Images[j].Image
+		}
+		return report.Images[i].Trigger < report.Images[j].Trigger
+	})
+
+	for _, r := range s.resources() {
+		report.Resources++
+		for _, err := range policy.Validate(r.GetLabels(), r.GetKeelAnnotations()) {
+			report.Errors = append(report.Errors, &PolicyError{
+				Cluster:    r.cluster,
+				Identifier: r.Identifier,
+				Error:      err.Error(),
+			})
+		}
+	}
+	sort.Slice(report.Errors, func(i, j int) bool {
+		if report.Errors[i].Cluster != report.Errors[j].Cluster {
+			return report.Errors[i].Cluster < report.Errors[j].Cluster
+		}
+		return report.Errors[i].Identifier < report.Errors[j].Identifier
+	})
+
+	return report, nil
+}
+
+func (s *TriggerServer) statusHandler(resp http.ResponseWriter, req *http.Request) {
+	report, err := s.StatusReport()
+	response(report, 200, err, resp, req)
+}
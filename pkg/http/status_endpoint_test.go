@@ -0,0 +1,102 @@
+package http
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/keel-hq/keel/internal/k8s"
+	"github.com/keel-hq/keel/internal/policy"
+	"github.com/keel-hq/keel/types"
+	"github.com/keel-hq/keel/util/image"
+
+	apps_v1 "k8s.io/api/apps/v1"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestStatusReport(t *testing.T) {
+	img := func(ref, identifier, schedule string, trigger types.TriggerType, plc policy.Policy) *types.TrackedImage {
+		parsed, _ := image.Parse(ref)
+		return &types.TrackedImage{
+			Image:        parsed,
+			Identifier:   identifier,
+			Provider:     "kubernetes",
+			Namespace:    "default",
+			Trigger:      trigger,
+			PollSchedule: schedule,
+			Policy:       plc,
+		}
+	}
+	minor := policy.NewSemverPolicy(policy.SemverPolicyTypeMinor, true)
+	fp := &fakeProvider{images: []*types.TrackedImage{
+		img("karolisr/webhook-demo:0.0.15", "deployment/default/wd", "@every 5m", types.TriggerTypePoll, minor),
+		img("redis:5.0.0", "deployment/default/wd", "@every 5m", types.TriggerTypePoll, minor),
+		img("karolisr/webhook-demo:0.0.15", "deployment/default/wd-2", "@every 5m", types.TriggerTypePoll, minor),
+		img("nginx:1.19.0", "statefulset/default/web", "", types.TriggerTypeDefault, policy.NewForcePolicy(false)),
+	}}
+	srv, teardown := NewTestingServer(fp)
+	defer teardown()
+
+	deployment := func(name string, annotations map[string]string) *k8s.GenericResource {
+		gr, err := k8s.NewGenericResource(&apps_v1.Deployment{
+			ObjectMeta: meta_v1.ObjectMeta{Name: name, Namespace: "default", Annotations: annotations},
+		})
+		if err != nil {
+			t.Fatalf("failed to create resource: %s", err)
+		}
+		return gr
+	}
+	grc := &k8s.GenericResourceCache{}
+	grc.Add(
+		deployment("wd", map[string]string{types.KeelPolicyLabel: "minor"}),
+		deployment("typo", map[string]string{types.KeelPolicyLabel: "minro"}),
+		deployment("untracked", nil),
+	)
+	srv.clusters = []Cluster{{GRC: grc}}
+
+	req, err := http.NewRequest("GET", "/v1/status", nil)
+	if err != nil {
+		t.Fatalf("failed to create req: %s", err)
+	}
+	req.SetBasicAuth("user-1", "secret")
+	rec := httptest.NewRecorder()
+	srv.router.ServeHTTP(rec, req)
+	if rec.Code != 200 {
+		t.Fatalf("unexpected status code: %d, body: %s", rec.Code, rec.Body.String())
+	}
+
+	var report StatusReport
+	if err := json.Unmarshal(rec.Body.Bytes(), &report); err != nil {
+		t.Fatalf("failed to unmarshal response: %s", err)
+	}
+
+	if report.Resources != 3 || report.Tracked != 3 {
+		t.Errorf("unexpected resources: %d, tracked: %d", report.Resources, report.Tracked)
+	}
+	if !reflect.DeepEqual(report.Policies, map[string]int{"minor": 2, "force": 1}) {
+		t.Errorf("unexpected policies: %v", report.Policies)
+	}
+	if !reflect.DeepEqual(report.Triggers, map[string]int{"poll": 2, "default": 1}) {
+		t.Errorf("unexpected triggers: %v", report.Triggers)
+	}
+
+	if len(report.Images) != 3 {
+		t.Fatalf("expected 3 watched images, got: %d", len(report.Images))
+	}
+	watched := report.Images[0]
+	if watched.Image != "index.docker.io/karolisr/webhook-demo:0.0.15" || watched.Schedule != "@every 5m" {
+		t.Errorf("unexpected watched image: %+v", watched)
+	}
+	if !reflect.DeepEqual(watched.Resources, []string{"deployment/default/wd", "deployment/default/wd-2"}) {
+		t.Errorf("unexpected resources of watched image: %v", watched.Resources)
+	}
+
+	if len(report.Errors) != 1 {
+		t.Fatalf("expected 1 policy error, got: %+v", report.Errors)
+	}
+	if report.Errors[0].Identifier != "deployment/default/typo" || report.Errors[0].Error != "keel.sh/policy: unknown policy 'minro'" {
+		t.Errorf("unexpected policy error: %+v", report.Errors[0])
+	}
+}
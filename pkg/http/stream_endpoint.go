@@ -0,0 +1,76 @@
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/keel-hq/keel/types"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// streamKeepAliveInterval - how often comments are written to idle streams
+// so proxies don't close the connection
+var streamKeepAliveInterval = 15 * time.Second
+
+// EventStream - source of events for /v1/stream subscribers
+type EventStream interface {
+	Subscribe(ctx context.Context) <-chan types.EventNotification
+}
+
+// streamHandler - streams events as server-sent events, each event is
+// sent with its type as the event name and JSON encoded notification as data
+func (s *TriggerServer) streamHandler(resp http.ResponseWriter, req *http.Request) {
+	if s.events == nil {
+		http.Error(resp, "event stream is not configured", http.StatusNotImplemented)
+		return
+	}
+
+	flusher, ok := resp.(http.Flusher)
+	if !ok {
+		http.Error(resp, "streaming is not supported", http.StatusInternalServerError)
+		return
+	}
+
+	ctx, cancel := context.WithCancel(req.Context())
+	defer cancel()
+
+	events := s.events.Subscribe(ctx)
+
+	resp.Header().Set("Content-Type", "text/event-stream")
+	resp.Header().Set("Cache-Control", "no-cache")
+	resp.Header().Set("Connection", "keep-alive")
+	resp.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	keepAlive := time.NewTicker(streamKeepAliveInterval)
+	defer keepAlive.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-keepAlive.C:
+			if _, err := fmt.Fprint(resp, ": keep-alive\n\n"); err != nil {
+				return
+			}
+			flusher.Flush()
+		case event, ok := <-events:
+			if !ok {
+				return
+			}
+			data, err := json.Marshal(&event)
+			if err != nil {
+				log.WithError(err).Error("http.streamHandler: failed to marshal event")
+				continue
+			}
+			if _, err := fmt.Fprintf(resp, "event: %s\ndata: %s\n\n", event.Type, data); err != nil {
+				return
+			}
+			flusher.Flush()
+		}
+	}
+}
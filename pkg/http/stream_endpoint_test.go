@@ -0,0 +1,142 @@
+package http
+
+import (
+	"bufio"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/keel-hq/keel/approvals"
+	"github.com/keel-hq/keel/extension/notification/stream"
+	"github.com/keel-hq/keel/pkg/auth"
+	"github.com/keel-hq/keel/provider"
+	"github.com/keel-hq/keel/types"
+)
+
+func TestStreamEvents(t *testing.T) {
+
+	fp := &fakeProvider{}
+	store, teardown := NewTestingUtils()
+	defer teardown()
+
+	am := approvals.New(&approvals.Opts{
+		Store: store,
+	})
+
+	authenticator := auth.New(&auth.Opts{
+		Username: "admin",
+		Password: "pass",
+	})
+
+	events := stream.New()
+
+	providers := provider.New([]provider.Provider{fp}, am)
+	srv := NewTriggerServer(&Opts{
+		Providers:       providers,
+		ApprovalManager: am,
+		Authenticator:   authenticator,
+		Store:           store,
+		Events:          events,
+	})
+	srv.registerRoutes(srv.router)
+
+	ts := httptest.NewServer(srv.router)
+	defer ts.Close()
+
+	req, err := http.NewRequest("GET", ts.URL+"/v1/stream", nil)
+	if err != nil {
+		t.Fatalf("failed to create req: %s", err)
+	}
+	req.SetBasicAuth("admin", "pass")
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("failed to connect: %s", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != 200 {
+		t.Fatalf("unexpected status code: %d", resp.StatusCode)
+	}
+
+	if resp.Header.Get("Content-Type") != "text/event-stream" {
+		t.Errorf("unexpected content type: %s", resp.Header.Get("Content-Type"))
+	}
+
+	events.Send(types.EventNotification{
+		Name:       "update resource",
+		Message:    "updated",
+		Type:       types.NotificationDeploymentUpdate,
+		Level:      types.LevelSuccess,
+		Identifier: "deployment/default/foo",
+	})
+
+	reader := bufio.NewReader(resp.Body)
+
+	line, err := reader.ReadString('\n')
+	if err != nil {
+		t.Fatalf("failed to read event: %s", err)
+	}
+	if line != "event: deployment update\n" {
+		t.Errorf("unexpected event line: %q", line)
+	}
+
+	line, err = reader.ReadString('\n')
+	if err != nil {
+		t.Fatalf("failed to read event data: %s", err)
+	}
+
+	var event types.EventNotification
+	err = json.Unmarshal([]byte(strings.TrimPrefix(line, "data: ")), &event)
+	if err != nil {
+		t.Fatalf("failed to decode event data: %s", err)
+	}
+
+	if event.Identifier != "deployment/default/foo" {
+		t.Errorf("unexpected identifier: %s", event.Identifier)
+	}
+	if event.Level != types.LevelSuccess {
+		t.Errorf("unexpected level: %s", event.Level)
+	}
+}
+
+func TestStreamNotConfigured(t *testing.T) {
+
+	fp := &fakeProvider{}
+	store, teardown := NewTestingUtils()
+	defer teardown()
+
+	am := approvals.New(&approvals.Opts{
+		Store: store,
+	})
+
+	authenticator := auth.New(&auth.Opts{
+		Username: "admin",
+		Password: "pass",
+	})
+
+	providers := provider.New([]provider.Provider{fp}, am)
+	srv := NewTriggerServer(&Opts{
+		Providers:       providers,
+		ApprovalManager: am,
+		Authenticator:   authenticator,
+		Store:           store,
+	})
+	srv.registerRoutes(srv.router)
+
+	req, err := http.NewRequest("GET", "/v1/stream", nil)
+	if err != nil {
+		t.Fatalf("failed to create req: %s", err)
+	}
+	req.SetBasicAuth("admin", "pass")
+
+	rec := httptest.NewRecorder()
+
+	srv.router.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusNotImplemented {
+		t.Errorf("unexpected status code: %d", rec.Code)
+	}
+}
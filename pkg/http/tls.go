@@ -0,0 +1,93 @@
+package http
+
+import (
+	"crypto/tls"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// certReloadInterval - how often certificate files are checked for changes
+const certReloadInterval = 10 * time.Second
+
+// certReloader - serves the certificate from disk, reloading it when files
+// change, ie: when cert-manager rotates the mounted secret
+type certReloader struct {
+	certFile string
+	keyFile  string
+
+	mu        sync.Mutex
+	cert      *tls.Certificate
+	modTime   time.Time
+	checkedAt time.Time
+}
+
+func newCertReloader(certFile, keyFile string) (*certReloader, error) {
+	r := &certReloader{certFile: certFile, keyFile: keyFile}
+	if err := r.load(); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// latestModTime - newest modification time of the certificate and key files
+func (r *certReloader) latestModTime() (time.Time, error) {
+	var latest time.Time
+	for _, path := range []string{r.certFile, r.keyFile} {
+		info, err := os.Stat(path)
+		if err != nil {
+			return latest, err
+		}
+		if info.ModTime().After(latest) {
+			latest = info.ModTime()
+		}
+	}
+	return latest, nil
+}
+
+func (r *certReloader) load() error {
+	modTime, err := r.latestModTime()
+	if err != nil {
+		return err
+	}
+	cert, err := tls.LoadX509KeyPair(r.certFile, r.keyFile)
+	if err != nil {
+		return fmt.Errorf("failed to load TLS certificate: %s", err)
+	}
+	r.cert = &cert
+	r.modTime = modTime
+	return nil
+}
+
+// GetCertificate - tls.Config callback, falls back to the loaded certificate if
+// new files can't be loaded (ie: only one of them was updated so far)
+func (r *certReloader) GetCertificate(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if time.Since(r.checkedAt) < certReloadInterval {
+		return r.cert, nil
+	}
+	r.checkedAt = time.Now()
+
+	modTime, err := r.latestModTime()
+	if err != nil || !modTime.After(r.modTime) {
+		return r.cert, nil
+	}
+
+	if err := r.load(); err != nil {
+		log.WithFields(log.Fields{
+			"error": err,
+			"cert":  r.certFile,
+		}).Warn("trigger.http: failed to reload TLS certificate, using previous one")
+		return r.cert, nil
+	}
+
+	log.WithFields(log.Fields{
+		"cert": r.certFile,
+	}).Info("trigger.http: TLS certificate reloaded")
+	return r.cert, nil
+}
@@ -0,0 +1,96 @@
+package http
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io/ioutil"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func writeTestCertificate(t *testing.T, dir, commonName string) (certFile, keyFile string) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("failed to generate key: %s", err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: commonName},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatalf("failed to create certificate: %s", err)
+	}
+	keyDer, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatalf("failed to marshal key: %s", err)
+	}
+
+	certFile = filepath.Join(dir, "tls.crt")
+	keyFile = filepath.Join(dir, "tls.key")
+	err = ioutil.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0600)
+	if err != nil {
+		t.Fatalf("failed to write certificate: %s", err)
+	}
+	err = ioutil.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDer}), 0600)
+	if err != nil {
+		t.Fatalf("failed to write key: %s", err)
+	}
+	return certFile, keyFile
+}
+
+func TestCertReloader(t *testing.T) {
+	dir, err := ioutil.TempDir("", "keel-tls")
+	if err != nil {
+		t.Fatalf("failed to create temp dir: %s", err)
+	}
+	defer os.RemoveAll(dir)
+
+	commonName := func(r *certReloader) string {
+		cert, err := r.GetCertificate(nil)
+		if err != nil {
+			t.Fatalf("failed to get certificate: %s", err)
+		}
+		parsed, err := x509.ParseCertificate(cert.Certificate[0])
+		if err != nil {
+			t.Fatalf("failed to parse certificate: %s", err)
+		}
+		return parsed.Subject.CommonName
+	}
+
+	certFile, keyFile := writeTestCertificate(t, dir, "first")
+	reloader, err := newCertReloader(certFile, keyFile)
+	if err != nil {
+		t.Fatalf("failed to load certificate: %s", err)
+	}
+	if cn := commonName(reloader); cn != "first" {
+		t.Errorf("expected first certificate, got: %s", cn)
+	}
+
+	writeTestCertificate(t, dir, "second")
+	later := time.Now().Add(time.Minute)
+	os.Chtimes(certFile, later, later)
+
+	// checked at most once per reload interval
+	if cn := commonName(reloader); cn != "first" {
+		t.Errorf("expected first certificate before reload interval passed, got: %s", cn)
+	}
+
+	reloader.checkedAt = time.Time{}
+	if cn := commonName(reloader); cn != "second" {
+		t.Errorf("expected reloaded certificate, got: %s", cn)
+	}
+
+	if _, err := newCertReloader(filepath.Join(dir, "missing.crt"), keyFile); err == nil {
+		t.Errorf("expected error for missing certificate")
+	}
+}
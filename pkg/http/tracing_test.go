@@ -0,0 +1,63 @@
+package http
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/keel-hq/keel/pkg/tracing"
+
+	"go.opentelemetry.io/otel"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestWebhookTracePropagation(t *testing.T) {
+	exporter := tracetest.NewInMemoryExporter()
+	previous := otel.GetTracerProvider()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter)))
+	defer otel.SetTracerProvider(previous)
+
+	fp := &fakeProvider{}
+	srv, teardown := NewTestingServer(fp)
+	defer teardown()
+
+	req, err := http.NewRequest("POST", "/v1/webhooks/native", bytes.NewBufferString(`{"name": "gcr.io/v2-namespace/hello-world", "tag": "1.1.1"}`))
+	if err != nil {
+		t.Fatalf("failed to create req: %s", err)
+	}
+	req.Header.Set("traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+
+	rec := httptest.NewRecorder()
+	tracingMiddleware(rec, req, srv.router.ServeHTTP)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("unexpected status code: %d", rec.Code)
+	}
+	if len(fp.submitted) != 1 {
+		t.Fatalf("expected 1 submitted event, got: %d", len(fp.submitted))
+	}
+
+	// providers continue the trace from the event
+	_, span := tracing.Start(tracing.Extract(context.Background(), fp.submitted[0].TraceContext), "provider")
+	span.End()
+
+	spans := exporter.GetSpans()
+	if len(spans) != 2 {
+		t.Fatalf("expected 2 spans, got: %d", len(spans))
+	}
+	webhook, provider := spans[0], spans[1]
+	if webhook.Name != "http.webhook" {
+		t.Fatalf("unexpected webhook span: %s", webhook.Name)
+	}
+	if webhook.Parent.SpanID().String() != "00f067aa0ba902b7" {
+		t.Errorf("expected webhook span to continue caller's trace, parent: %s", webhook.Parent.SpanID())
+	}
+	if provider.SpanContext.TraceID() != webhook.SpanContext.TraceID() {
+		t.Errorf("expected provider span in trace %s, got: %s", webhook.SpanContext.TraceID(), provider.SpanContext.TraceID())
+	}
+	if provider.Parent.SpanID() != webhook.SpanContext.SpanID() {
+		t.Errorf("expected provider span parent %s, got: %s", webhook.SpanContext.SpanID(), provider.Parent.SpanID())
+	}
+}
@@ -7,43 +7,96 @@ import (
 	"time"
 
 	"github.com/keel-hq/keel/types"
+	"github.com/keel-hq/keel/util/image"
 )
 
+// WatchStates - poll trigger watch state lookup
+type WatchStates interface {
+	WatchState(ref *image.Reference) (*types.WatchState, bool)
+}
+
+// SetWatchStates - exposes poll trigger state through the tracked images API
+func (s *TriggerServer) SetWatchStates(ws WatchStates) {
+	s.watchStatesMu.Lock()
+	defer s.watchStatesMu.Unlock()
+	s.watchStates = ws
+}
+
 type trackedImage struct {
 	Image        string `json:"image"`
 	Trigger      string `json:"trigger"`
 	PollSchedule string `json:"pollSchedule"`
 	Provider     string `json:"provider"`
+	Cluster      string `json:"cluster,omitempty"`
 	Namespace    string `json:"namespace"`
 	Policy       string `json:"policy"`
 	Registry     string `json:"registry"`
+
+	// resources in the namespace that use the repository
+	Resources []string `json:"resources"`
+
+	// poll trigger state, only set for watched images
+	Watch *types.WatchState `json:"watch,omitempty"`
 }
 
 func (s *TriggerServer) trackedHandler(resp http.ResponseWriter, req *http.Request) {
 	trackedImages, err := s.providers.TrackedImages()
 
+	s.watchStatesMu.RLock()
+	watchStates := s.watchStates
+	s.watchStatesMu.RUnlock()
+
+	resources := s.resources()
+
 	var imgs []trackedImage
 
 	for _, img := range trackedImages {
-		imgs = append(imgs, trackedImage{
+		ti := trackedImage{
 			Image:        img.Image.Name(),
 			Trigger:      img.Trigger.String(),
 			PollSchedule: img.PollSchedule,
 			Provider:     img.Provider,
+			Cluster:      img.Cluster,
 			Namespace:    img.Namespace,
 			Policy:       img.Policy.Name(),
 			Registry:     img.Image.Registry(),
-		})
+			Resources:    referencingResources(img, resources),
+		}
+		if watchStates != nil && img.Trigger == types.TriggerTypePoll {
+			if state, ok := watchStates.WatchState(img.Image); ok {
+				ti.Watch = state
+			}
+		}
+		imgs = append(imgs, ti)
 	}
 
 	response(&imgs, 200, err, resp, req)
 }
 
+// referencingResources - identifiers of resources with containers using tracked image repository
+func referencingResources(img *types.TrackedImage, resources []clusterResource) []string {
+	identifiers := []string{}
+	for _, gr := range resources {
+		if gr.cluster != img.Cluster || gr.Namespace != img.Namespace {
+			continue
+		}
+		for _, c := range gr.Containers() {
+			ref, err := image.Parse(c.Image)
+			if err == nil && ref.Repository() == img.Image.Repository() {
+				identifiers = append(identifiers, gr.Identifier)
+				break
+			}
+		}
+	}
+	return identifiers
+}
+
 type trackRequest struct {
 	Provider   string `json:"provider"`
 	Identifier string `json:"identifier"`
 	Trigger    string `json:"trigger"`
 	Schedule   string `json:"schedule"`
+	Cluster    string `json:"cluster,omitempty"`
 }
 
 func (s *TriggerServer) trackSetHandler(resp http.ResponseWriter, req *http.Request) {
@@ -86,24 +139,21 @@ func (s *TriggerServer) trackSetHandler(resp http.ResponseWriter, req *http.Requ
 		trackReq.Schedule = types.KeelPollDefaultSchedule
 	}
 
-	for _, v := range s.grc.Values() {
-		if v.Identifier == trackReq.Identifier {
+	if v, ok := s.findResource(trackReq.Cluster, trackReq.Identifier); ok {
+		labels := v.GetLabels()
+		delete(labels, types.KeelTriggerLabel)
+		v.SetLabels(labels)
 
-			labels := v.GetLabels()
-			delete(labels, types.KeelTriggerLabel)
-			v.SetLabels(labels)
+		ann := v.GetAnnotations()
+		ann[types.KeelTriggerLabel] = trackReq.Trigger
+		ann[types.KeelPollScheduleAnnotation] = trackReq.Schedule
 
-			ann := v.GetAnnotations()
-			ann[types.KeelTriggerLabel] = trackReq.Trigger
-			ann[types.KeelPollScheduleAnnotation] = trackReq.Schedule
+		v.SetAnnotations(ann)
 
-			v.SetAnnotations(ann)
+		err := v.client.Update(v.GenericResource)
 
-			err := s.kubernetesClient.Update(v)
-
-			response(&APIResponse{Status: "updated"}, 200, err, resp, req)
-			return
-		}
+		response(&APIResponse{Status: "updated"}, 200, err, resp, req)
+		return
 	}
 
 	resp.WriteHeader(http.StatusNotFound)
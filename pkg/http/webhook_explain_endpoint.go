@@ -0,0 +1,104 @@
+package http
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/keel-hq/keel/types"
+)
+
+type capturedEventsKey struct{}
+
+// capturedEvents - events parsed from a test webhook, collected instead of
+// being submitted to providers
+type capturedEvents struct {
+	mu     sync.Mutex
+	events []types.Event
+}
+
+func (c *capturedEvents) add(event types.Event) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.events = append(c.events, event)
+}
+
+func getCapturedEvents(ctx context.Context) *capturedEvents {
+	c, _ := ctx.Value(capturedEventsKey{}).(*capturedEvents)
+	return c
+}
+
+// webhookParsers - webhook handlers by endpoint name
+func (s *TriggerServer) webhookParsers() map[string]http.HandlerFunc {
+	return map[string]http.HandlerFunc{
+		"native":      s.nativeHandler,
+		"dockerhub":   s.dockerHubHandler,
+		"quay":        s.quayHandler,
+		"azure":       s.azureHandler,
+		"github":      s.githubHandler,
+		"harbor":      s.harborHandler,
+		"artifactory": s.artifactoryHandler,
+		"eventgrid":   s.eventGridHandler,
+		"gitea":       s.giteaHandler,
+		"nexus":       s.nexusHandler,
+		"registry":    s.registryNotificationHandler,
+	}
+}
+
+type webhookTestResponse struct {
+	Webhook string `json:"webhook"`
+	// Status - status code the webhook endpoint would respond with
+	Status int `json:"status"`
+	// Message - webhook endpoint response when payload was rejected
+	Message string              `json:"message,omitempty"`
+	Events  []*webhookTestEvent `json:"events"`
+}
+
+type webhookTestEvent struct {
+	Event     types.Event             `json:"event"`
+	Decisions []*types.UpdateDecision `json:"decisions"`
+}
+
+// webhookTestHandler - parses payload of the webhook set via ?webhook= (ie:
+// dockerhub) and reports what would be updated, nothing is applied
+func (s *TriggerServer) webhookTestHandler(resp http.ResponseWriter, req *http.Request) {
+	parsers := s.webhookParsers()
+
+	name := req.URL.Query().Get("webhook")
+	parse, ok := parsers[name]
+	if !ok {
+		var names []string
+		for n := range parsers {
+			names = append(names, n)
+		}
+		sort.Strings(names)
+		resp.WriteHeader(http.StatusBadRequest)
+		fmt.Fprintf(resp, "unknown webhook '%s', expected one of: %s", name, strings.Join(names, ", "))
+		return
+	}
+
+	captured := &capturedEvents{}
+	rec := httptest.NewRecorder()
+	parse(rec, req.WithContext(context.WithValue(req.Context(), capturedEventsKey{}, captured)))
+
+	result := &webhookTestResponse{
+		Webhook: name,
+		Status:  rec.Code,
+		Events:  []*webhookTestEvent{},
+	}
+	if rec.Code < 200 || rec.Code > 299 {
+		result.Message = rec.Body.String()
+	}
+	for _, event := range captured.events {
+		result.Events = append(result.Events, &webhookTestEvent{
+			Event:     event,
+			Decisions: s.providers.Explain(event),
+		})
+	}
+
+	response(result, http.StatusOK, nil, resp, req)
+}
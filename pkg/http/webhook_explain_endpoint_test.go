@@ -0,0 +1,67 @@
+package http
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestWebhookTest(t *testing.T) {
+	fp := &fakeProvider{}
+	srv, teardown := NewTestingServer(fp)
+	defer teardown()
+
+	do := func(webhook, body string) *httptest.ResponseRecorder {
+		req, err := http.NewRequest("POST", "/v1/webhooks/test?webhook="+webhook, bytes.NewBufferString(body))
+		if err != nil {
+			t.Fatalf("failed to create req: %s", err)
+		}
+		req.SetBasicAuth("user-1", "secret")
+		rec := httptest.NewRecorder()
+		srv.router.ServeHTTP(rec, req)
+		return rec
+	}
+
+	rec := do("dockerhub", `{"push_data": {"tag": "0.0.15"}, "repository": {"repo_name": "karolisr/webhook-demo"}}`)
+	if rec.Code != 200 {
+		t.Fatalf("unexpected status code: %d, body: %s", rec.Code, rec.Body.String())
+	}
+	if len(fp.submitted) != 0 {
+		t.Errorf("expected test webhook not to submit events, got: %d", len(fp.submitted))
+	}
+
+	var result webhookTestResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &result); err != nil {
+		t.Fatalf("failed to unmarshal response: %s", err)
+	}
+	if result.Status != 200 || len(result.Events) != 1 {
+		t.Fatalf("unexpected result: %+v", result)
+	}
+	event := result.Events[0]
+	if event.Event.Repository.Name != "karolisr/webhook-demo" || event.Event.Repository.Tag != "0.0.15" || event.Event.TriggerName != "dockerhub" {
+		t.Errorf("unexpected event: %+v", event.Event)
+	}
+	if len(event.Decisions) != 1 || !event.Decisions[0].Update || event.Decisions[0].NewImage != "karolisr/webhook-demo:0.0.15" {
+		t.Errorf("unexpected decisions: %+v", event.Decisions)
+	}
+
+	// rejected payload is reported with webhook response
+	rec = do("dockerhub", `{"push_data": {"tag": "0.0.15"}}`)
+	if rec.Code != 200 {
+		t.Fatalf("unexpected status code: %d, body: %s", rec.Code, rec.Body.String())
+	}
+	result = webhookTestResponse{}
+	if err := json.Unmarshal(rec.Body.Bytes(), &result); err != nil {
+		t.Fatalf("failed to unmarshal response: %s", err)
+	}
+	if result.Status != http.StatusBadRequest || result.Message != "repository name cannot be empty" || len(result.Events) != 0 {
+		t.Errorf("unexpected result: %+v", result)
+	}
+
+	rec = do("unknown", `{}`)
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("expected unknown webhook to be rejected, got: %d", rec.Code)
+	}
+}
@@ -0,0 +1,107 @@
+package http
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"hash"
+	"io/ioutil"
+	"net/http"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// WebhookSecretHeader - generic HMAC signature header, sha256=<hex encoded HMAC of the body>
+const WebhookSecretHeader = "X-Keel-Signature"
+
+// webhookSecret - shared secret of the webhook endpoint, endpoint specific
+// secret takes precedence over the default one
+func (s *TriggerServer) webhookSecret(endpoint string) string {
+	if secret, ok := s.webhookSecrets[endpoint]; ok {
+		return secret
+	}
+	return s.webhookSecrets["*"]
+}
+
+// verifyWebhook - rejects requests to the webhook endpoint that aren't signed
+// with its shared secret, requests are accepted as is if no secret is configured
+func (s *TriggerServer) verifyWebhook(endpoint string, next http.HandlerFunc) http.HandlerFunc {
+	return func(resp http.ResponseWriter, req *http.Request) {
+		secret := s.webhookSecret(endpoint)
+		if secret == "" || req.Method == "OPTIONS" {
+			next(resp, req)
+			return
+		}
+
+		body, err := ioutil.ReadAll(req.Body)
+		if err != nil {
+			resp.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		req.Body = ioutil.NopCloser(bytes.NewReader(body))
+
+		if !validWebhookSignature(secret, req, body) {
+			log.WithFields(log.Fields{
+				"endpoint":    endpoint,
+				"remote_addr": req.RemoteAddr,
+			}).Warn("trigger.webhook: invalid or missing webhook signature")
+			resp.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+
+		next(resp, req)
+	}
+}
+
+// validWebhookSignature - accepts any of the common webhook authentication schemes:
+// GitHub style HMAC signatures, Gitea/Forgejo and Nexus HMAC signatures, GitLab token header,
+// generic HMAC signature header and, for registries that can't set headers (ie: Docker Hub), token query parameter
+func validWebhookSignature(secret string, req *http.Request, body []byte) bool {
+	if signature := req.Header.Get("X-Hub-Signature-256"); signature != "" {
+		return validHMAC(sha256.New, secret, "sha256=", signature, body)
+	}
+	if signature := req.Header.Get("X-Hub-Signature"); signature != "" {
+		return validHMAC(sha1.New, secret, "sha1=", signature, body)
+	}
+	if signature := req.Header.Get("X-Gitea-Signature"); signature != "" {
+		return validHMAC(sha256.New, secret, "", signature, body)
+	}
+	if signature := req.Header.Get("X-Forgejo-Signature"); signature != "" {
+		return validHMAC(sha256.New, secret, "", signature, body)
+	}
+	if signature := req.Header.Get("X-Nexus-Webhook-Signature"); signature != "" {
+		return validHMAC(sha1.New, secret, "", signature, body)
+	}
+	if signature := req.Header.Get(WebhookSecretHeader); signature != "" {
+		return validHMAC(sha256.New, secret, "sha256=", signature, body)
+	}
+
+	token := req.Header.Get("X-Gitlab-Token")
+	if token == "" {
+		token = req.URL.Query().Get("token")
+	}
+	if token == "" {
+		return false
+	}
+	return subtle.ConstantTimeCompare([]byte(token), []byte(secret)) == 1
+}
+
+func validHMAC(h func() hash.Hash, secret, prefix, signature string, body []byte) bool {
+	if !strings.HasPrefix(signature, prefix) {
+		return false
+	}
+
+	expected, err := hex.DecodeString(strings.TrimPrefix(signature, prefix))
+	if err != nil {
+		return false
+	}
+
+	mac := hmac.New(h, []byte(secret))
+	mac.Write(body)
+
+	return hmac.Equal(mac.Sum(nil), expected)
+}
@@ -0,0 +1,72 @@
+package http
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha1"
+	"crypto/sha256"
+	"encoding/hex"
+	"hash"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestWebhookSignature(t *testing.T) {
+	body := `{"name": "gcr.io/v2-namespace/hello-world", "tag": "1.1.1"}`
+	sign := func(h func() hash.Hash, prefix, secret string) string {
+		mac := hmac.New(h, []byte(secret))
+		mac.Write([]byte(body))
+		return prefix + hex.EncodeToString(mac.Sum(nil))
+	}
+
+	tests := []struct {
+		name       string
+		secrets    map[string]string
+		url        string
+		header     string
+		value      string
+		wantStatus int
+	}{
+		{name: "no secret", wantStatus: http.StatusOK},
+		{name: "missing signature", secrets: map[string]string{"*": "very-secret"}, wantStatus: http.StatusUnauthorized},
+		{name: "github sha256", secrets: map[string]string{"*": "very-secret"}, header: "X-Hub-Signature-256", value: sign(sha256.New, "sha256=", "very-secret"), wantStatus: http.StatusOK},
+		{name: "github sha1", secrets: map[string]string{"*": "very-secret"}, header: "X-Hub-Signature", value: sign(sha1.New, "sha1=", "very-secret"), wantStatus: http.StatusOK},
+		{name: "gitea hmac", secrets: map[string]string{"*": "very-secret"}, header: "X-Gitea-Signature", value: sign(sha256.New, "", "very-secret"), wantStatus: http.StatusOK},
+		{name: "forgejo hmac", secrets: map[string]string{"*": "very-secret"}, header: "X-Forgejo-Signature", value: sign(sha256.New, "", "very-secret"), wantStatus: http.StatusOK},
+		{name: "nexus hmac", secrets: map[string]string{"*": "very-secret"}, header: "X-Nexus-Webhook-Signature", value: sign(sha1.New, "", "very-secret"), wantStatus: http.StatusOK},
+		{name: "generic hmac", secrets: map[string]string{"*": "very-secret"}, header: WebhookSecretHeader, value: sign(sha256.New, "sha256=", "very-secret"), wantStatus: http.StatusOK},
+		{name: "wrong hmac", secrets: map[string]string{"*": "very-secret"}, header: WebhookSecretHeader, value: sign(sha256.New, "sha256=", "other"), wantStatus: http.StatusUnauthorized},
+		{name: "gitlab token", secrets: map[string]string{"*": "very-secret"}, header: "X-Gitlab-Token", value: "very-secret", wantStatus: http.StatusOK},
+		{name: "wrong gitlab token", secrets: map[string]string{"*": "very-secret"}, header: "X-Gitlab-Token", value: "other", wantStatus: http.StatusUnauthorized},
+		{name: "query token", secrets: map[string]string{"*": "very-secret"}, url: "?token=very-secret", wantStatus: http.StatusOK},
+		{name: "endpoint secret", secrets: map[string]string{"*": "very-secret", "native": "native-secret"}, header: "X-Gitlab-Token", value: "native-secret", wantStatus: http.StatusOK},
+		{name: "other endpoint secret", secrets: map[string]string{"dockerhub": "very-secret"}, wantStatus: http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fp := &fakeProvider{}
+			srv, teardown := NewTestingServer(fp)
+			defer teardown()
+			srv.webhookSecrets = tt.secrets
+
+			req, err := http.NewRequest("POST", "/v1/webhooks/native"+tt.url, bytes.NewBuffer([]byte(body)))
+			if err != nil {
+				t.Fatalf("failed to create req: %s", err)
+			}
+			if tt.header != "" {
+				req.Header.Set(tt.header, tt.value)
+			}
+
+			rec := httptest.NewRecorder()
+			srv.router.ServeHTTP(rec, req)
+			if rec.Code != tt.wantStatus {
+				t.Errorf("unexpected status code: %d, want: %d", rec.Code, tt.wantStatus)
+			}
+			if tt.wantStatus == http.StatusOK && len(fp.submitted) != 1 {
+				t.Errorf("expected event to be submitted, got: %d", len(fp.submitted))
+			}
+		})
+	}
+}
@@ -0,0 +1,144 @@
+// Package notary checks Docker Content Trust signatures of image tags with the
+// notary binary, which has to be available in PATH (keel images ship it). Only
+// repositories listed in the trust configuration are checked, others are accepted as is.
+package notary
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/ghodss/yaml"
+	"github.com/keel-hq/keel/util/image"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// DefaultTimeout - max duration of a single notary invocation
+const DefaultTimeout = time.Minute
+
+// Repository - trust settings of repositories matching the prefix
+type Repository struct {
+	// repository prefix, ie: registry.example.com/team/
+	Repository string `json:"repository"`
+	// notary server, ie: https://notary.docker.io
+	Server string `json:"server"`
+	// CA certificate of the notary server if it isn't publicly trusted
+	TLSCACert string `json:"tlsCACert,omitempty"`
+	// notary client config with root key pinning (trust_pinning), without it
+	// the root is trusted on first use and pinned in the trust directory
+	Config string `json:"config,omitempty"`
+}
+
+// Config - trust configuration file
+type Config struct {
+	// local TUF metadata cache, defaults to <tmp>/keel-notary
+	TrustDir     string       `json:"trustDir,omitempty"`
+	Repositories []Repository `json:"repositories"`
+}
+
+// LoadConfig - reads YAML or JSON trust configuration
+func LoadConfig(path string) (*Config, error) {
+	contents, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var cfg Config
+	err = yaml.Unmarshal(contents, &cfg)
+	if err != nil {
+		return nil, fmt.Errorf("invalid notary config: %s", err)
+	}
+	for _, repo := range cfg.Repositories {
+		if repo.Repository == "" || repo.Server == "" {
+			return nil, fmt.Errorf("invalid notary config: repository and server are required")
+		}
+	}
+	return &cfg, nil
+}
+
+// Verifier - checks that tags are signed
+type Verifier struct {
+	cfg     *Config
+	binary  string
+	timeout time.Duration
+}
+
+// New - creates verifier for configured repositories
+func New(cfg *Config) (*Verifier, error) {
+	if len(cfg.Repositories) == 0 {
+		return nil, fmt.Errorf("no repositories configured")
+	}
+	if cfg.TrustDir == "" {
+		cfg.TrustDir = filepath.Join(os.TempDir(), "keel-notary")
+	}
+	if _, err := exec.LookPath("notary"); err != nil {
+		return nil, fmt.Errorf("notary binary not found: %s", err)
+	}
+	return &Verifier{cfg: cfg, binary: "notary", timeout: DefaultTimeout}, nil
+}
+
+// repository - most specific trust settings for the repository, nil if not configured
+func (v *Verifier) repository(name string) *Repository {
+	var match *Repository
+	for i, repo := range v.cfg.Repositories {
+		if !strings.HasPrefix(name, repo.Repository) {
+			continue
+		}
+		if match == nil || len(repo.Repository) > len(match.Repository) {
+			match = &v.cfg.Repositories[i]
+		}
+	}
+	return match
+}
+
+// globallyUniqueName - notary name of the repository, Docker Hub images are
+// signed as docker.io/<name>
+func globallyUniqueName(ref *image.Reference) string {
+	if ref.Registry() == image.DefaultRegistryHostname {
+		return "docker.io/" + ref.ShortName()
+	}
+	return ref.Repository()
+}
+
+// Verify - checks that image tag has a valid content trust signature
+func (v *Verifier) Verify(ctx context.Context, img string) error {
+	ref, err := image.Parse(img)
+	if err != nil {
+		return err
+	}
+
+	gun := globallyUniqueName(ref)
+	repo := v.repository(gun)
+	if repo == nil {
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, v.timeout)
+	defer cancel()
+
+	args := []string{"-s", repo.Server, "-d", v.cfg.TrustDir}
+	if repo.TLSCACert != "" {
+		args = append(args, "--tlscacert", repo.TLSCACert)
+	}
+	if repo.Config != "" {
+		args = append(args, "-c", repo.Config)
+	}
+	args = append(args, "lookup", gun, ref.Tag())
+
+	out, err := exec.CommandContext(ctx, v.binary, args...).CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("no valid trust data for %s: %s", img, strings.TrimSpace(string(out)))
+	}
+
+	log.WithFields(log.Fields{
+		"image":  img,
+		"server": repo.Server,
+		"target": strings.TrimSpace(string(out)),
+	}).Debug("notary: tag signature verified")
+	return nil
+}
@@ -0,0 +1,72 @@
+package notary
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// fakeNotary - only 1.0.0 tag is signed, prints arguments on failure
+const fakeNotary = `#!/bin/sh
+for last; do true; done
+if [ "$last" = "1.0.0" ]; then
+  echo "1.0.0 4f2c2a1a0b2c0f7e5b1c6f0a5f6c4e1d2b3a4c5d6e7f8091a2b3c4d5e6f70819 1234"
+  exit 0
+fi
+echo "$@"
+exit 1
+`
+
+func TestVerify(t *testing.T) {
+	dir, err := ioutil.TempDir("", "keel-notary")
+	if err != nil {
+		t.Fatalf("failed to create temp dir: %s", err)
+	}
+	defer os.RemoveAll(dir)
+
+	binary := filepath.Join(dir, "notary")
+	err = ioutil.WriteFile(binary, []byte(fakeNotary), 0755)
+	if err != nil {
+		t.Fatalf("failed to write fake notary: %s", err)
+	}
+
+	cfgPath := filepath.Join(dir, "config.yaml")
+	err = ioutil.WriteFile(cfgPath, []byte(`
+trustDir: /tmp/trust
+repositories:
+  - repository: docker.io/keelhq/
+    server: https://notary.docker.io
+  - repository: registry.example.com/team/
+    server: https://notary.example.com
+    tlsCACert: /etc/notary/ca.crt
+`), 0644)
+	if err != nil {
+		t.Fatalf("failed to write config: %s", err)
+	}
+	cfg, err := LoadConfig(cfgPath)
+	if err != nil {
+		t.Fatalf("failed to load config: %s", err)
+	}
+
+	v := &Verifier{cfg: cfg, binary: binary, timeout: DefaultTimeout}
+
+	if err := v.Verify(context.Background(), "keelhq/keel:1.0.0"); err != nil {
+		t.Errorf("expected signed tag to be valid, got: %s", err)
+	}
+
+	err = v.Verify(context.Background(), "registry.example.com/team/app:1.1.0")
+	if err == nil {
+		t.Fatalf("expected unsigned tag to be rejected")
+	}
+	expected := "no valid trust data for registry.example.com/team/app:1.1.0: -s https://notary.example.com -d /tmp/trust --tlscacert /etc/notary/ca.crt lookup registry.example.com/team/app 1.1.0"
+	if err.Error() != expected {
+		t.Errorf("unexpected error: %s", err)
+	}
+
+	// repositories without trust configuration aren't checked
+	if err := v.Verify(context.Background(), "gcr.io/v2-namespace/hello-world:1.1.0"); err != nil {
+		t.Errorf("expected image from other repository to be accepted, got: %s", err)
+	}
+}
@@ -0,0 +1,137 @@
+// Package promquery runs instant PromQL queries against the Prometheus HTTP API,
+// used to check health of updated workloads with their own metrics.
+package promquery
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DefaultTimeout - max duration of a single query
+const DefaultTimeout = 10 * time.Second
+
+// Opts - client configuration
+type Opts struct {
+	// Prometheus base URL, ie: http://prometheus.monitoring:9090
+	URL string
+
+	Timeout time.Duration
+}
+
+// Client - Prometheus HTTP API client
+type Client struct {
+	url    string
+	client *http.Client
+}
+
+// New - creates a new client
+func New(opts Opts) (*Client, error) {
+	u, err := url.Parse(opts.URL)
+	if err != nil || u.Scheme == "" || u.Host == "" {
+		return nil, fmt.Errorf("invalid Prometheus URL '%s'", opts.URL)
+	}
+	if opts.Timeout == 0 {
+		opts.Timeout = DefaultTimeout
+	}
+	return &Client{
+		url:    strings.TrimSuffix(opts.URL, "/"),
+		client: &http.Client{Timeout: opts.Timeout},
+	}, nil
+}
+
+type response struct {
+	Status    string `json:"status"`
+	ErrorType string `json:"errorType"`
+	Error     string `json:"error"`
+	Data      struct {
+		ResultType string          `json:"resultType"`
+		Result     json.RawMessage `json:"result"`
+	} `json:"data"`
+}
+
+type sample struct {
+	Value []interface{} `json:"value"`
+}
+
+// Query - runs instant query, returns values of all returned samples. Empty
+// result (ie: comparison that doesn't match) returns no values
+func (c *Client) Query(ctx context.Context, query string) ([]float64, error) {
+	req, err := http.NewRequest(http.MethodGet, c.url+"/api/v1/query?"+url.Values{"query": {query}}.Encode(), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := c.client.Do(req.WithContext(ctx))
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	return parseResponse(resp.StatusCode, body)
+}
+
+func parseResponse(statusCode int, body []byte) ([]float64, error) {
+	var r response
+	if err := json.Unmarshal(body, &r); err != nil {
+		if statusCode < 200 || statusCode > 299 {
+			return nil, fmt.Errorf("query returned status code %d", statusCode)
+		}
+		return nil, fmt.Errorf("failed to decode query response: %s", err)
+	}
+	if r.Status != "success" {
+		return nil, fmt.Errorf("query failed: %s: %s", r.ErrorType, r.Error)
+	}
+
+	switch r.Data.ResultType {
+	case "scalar":
+		var value []interface{}
+		if err := json.Unmarshal(r.Data.Result, &value); err != nil {
+			return nil, fmt.Errorf("failed to decode scalar: %s", err)
+		}
+		v, err := sampleValue(value)
+		if err != nil {
+			return nil, err
+		}
+		return []float64{v}, nil
+	case "vector":
+		var samples []sample
+		if err := json.Unmarshal(r.Data.Result, &samples); err != nil {
+			return nil, fmt.Errorf("failed to decode vector: %s", err)
+		}
+		values := make([]float64, 0, len(samples))
+		for _, s := range samples {
+			v, err := sampleValue(s.Value)
+			if err != nil {
+				return nil, err
+			}
+			values = append(values, v)
+		}
+		return values, nil
+	default:
+		return nil, fmt.Errorf("unsupported result type '%s', expected scalar or vector", r.Data.ResultType)
+	}
+}
+
+// sampleValue - value of [<timestamp>, "<value>"] pair
+func sampleValue(value []interface{}) (float64, error) {
+	if len(value) != 2 {
+		return 0, fmt.Errorf("unexpected sample: %v", value)
+	}
+	s, ok := value[1].(string)
+	if !ok {
+		return 0, fmt.Errorf("unexpected sample value: %v", value[1])
+	}
+	return strconv.ParseFloat(s, 64)
+}
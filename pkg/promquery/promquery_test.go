@@ -0,0 +1,80 @@
+package promquery
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestParseResponse(t *testing.T) {
+	values, err := parseResponse(200, []byte(`{"status":"success","data":{"resultType":"vector","result":[
+		{"metric":{"pod":"app-1"},"value":[1600000000.1,"0.5"]},
+		{"metric":{"pod":"app-2"},"value":[1600000000.1,"0.02"]}
+	]}}`))
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if len(values) != 2 || values[0] != 0.5 || values[1] != 0.02 {
+		t.Errorf("unexpected values: %v", values)
+	}
+
+	values, err = parseResponse(200, []byte(`{"status":"success","data":{"resultType":"scalar","result":[1600000000.1,"1"]}}`))
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if len(values) != 1 || values[0] != 1 {
+		t.Errorf("unexpected values: %v", values)
+	}
+
+	values, err = parseResponse(200, []byte(`{"status":"success","data":{"resultType":"vector","result":[]}}`))
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if len(values) != 0 {
+		t.Errorf("expected no values, got: %v", values)
+	}
+
+	_, err = parseResponse(400, []byte(`{"status":"error","errorType":"bad_data","error":"parse error"}`))
+	if err == nil {
+		t.Errorf("expected query error")
+	}
+
+	_, err = parseResponse(200, []byte(`{"status":"success","data":{"resultType":"matrix","result":[]}}`))
+	if err == nil {
+		t.Errorf("expected range vectors to be rejected")
+	}
+}
+
+func TestQuery(t *testing.T) {
+	var query string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/v1/query" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		query = r.URL.Query().Get("query")
+		w.Write([]byte(`{"status":"success","data":{"resultType":"vector","result":[{"metric":{},"value":[1600000000.1,"3"]}]}}`))
+	}))
+	defer srv.Close()
+
+	c, err := New(Opts{URL: srv.URL + "/"})
+	if err != nil {
+		t.Fatalf("failed to create client: %s", err)
+	}
+
+	values, err := c.Query(context.Background(), `sum(rate(http_requests_total{code=~"5.."}[5m]))`)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if query != `sum(rate(http_requests_total{code=~"5.."}[5m]))` {
+		t.Errorf("unexpected query: %s", query)
+	}
+	if len(values) != 1 || values[0] != 3 {
+		t.Errorf("unexpected values: %v", values)
+	}
+
+	if _, err := New(Opts{URL: "prometheus:9090"}); err == nil {
+		t.Errorf("expected URL without scheme to be rejected")
+	}
+}
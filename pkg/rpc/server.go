@@ -0,0 +1,536 @@
+// Package rpc serves the keel admin API over gRPC, see keel.proto. It exposes
+// the same data as the REST API (tracked images, resources, approvals, pause
+// switches and the event stream) with the same credentials.
+package rpc
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/golang/protobuf/ptypes"
+	"github.com/golang/protobuf/ptypes/timestamp"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+
+	"github.com/keel-hq/keel/approvals"
+	"github.com/keel-hq/keel/extension/notification"
+	"github.com/keel-hq/keel/extension/trigger"
+	"github.com/keel-hq/keel/internal/k8s"
+	"github.com/keel-hq/keel/internal/policy"
+	"github.com/keel-hq/keel/pkg/auth"
+	"github.com/keel-hq/keel/pkg/http"
+	"github.com/keel-hq/keel/pkg/store"
+	"github.com/keel-hq/keel/provider"
+	"github.com/keel-hq/keel/provider/kubernetes"
+	"github.com/keel-hq/keel/types"
+	"github.com/keel-hq/keel/util/image"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// adminMethods - methods that change state, read-only users can't call them
+var adminMethods = map[string]bool{
+	"/" + ServiceName + "/Approve":           true,
+	"/" + ServiceName + "/Reject":            true,
+	"/" + ServiceName + "/SetResourcePaused": true,
+	"/" + ServiceName + "/SetPaused":         true,
+	"/" + ServiceName + "/SubmitEvent":       true,
+}
+
+// Opts - gRPC server options, resources, clusters and events are shared with
+// the REST API
+type Opts struct {
+	Port int
+
+	Providers       provider.Providers
+	ApprovalManager approvals.Manager
+	Authenticator   auth.Authenticator
+	Store           store.Store
+
+	GRC              *k8s.GenericResourceCache
+	KubernetesClient kubernetes.Implementer
+
+	// all managed clusters when keel manages more than its own cluster
+	Clusters []http.Cluster
+
+	// optional sender to notify about pause/resume
+	Sender notification.Sender
+
+	// optional event source for StreamEvents
+	Events http.EventStream
+
+	TLSCertFile string
+	TLSKeyFile  string
+}
+
+// Server - gRPC API server
+type Server struct {
+	opts     *Opts
+	clusters []http.Cluster
+	server   *grpc.Server
+}
+
+// NewServer - creates gRPC API server
+func NewServer(opts *Opts) *Server {
+	clusters := opts.Clusters
+	if len(clusters) == 0 {
+		clusters = []http.Cluster{{GRC: opts.GRC, KubernetesClient: opts.KubernetesClient}}
+	}
+	return &Server{opts: opts, clusters: clusters}
+}
+
+// clusterResource - cached resource and the client of its cluster
+type clusterResource struct {
+	resource *k8s.GenericResource
+	cluster  string
+	client   kubernetes.Implementer
+}
+
+// resources - resources of all clusters
+func (s *Server) resources() []clusterResource {
+	var resources []clusterResource
+	for _, c := range s.clusters {
+		if c.GRC == nil {
+			continue
+		}
+		for _, gr := range c.GRC.Values() {
+			resources = append(resources, clusterResource{resource: gr, cluster: c.Name, client: c.KubernetesClient})
+		}
+	}
+	return resources
+}
+
+func (s *Server) newGRPCServer() (*grpc.Server, error) {
+	serverOpts := []grpc.ServerOption{
+		grpc.UnaryInterceptor(s.unaryAuthInterceptor),
+		grpc.StreamInterceptor(s.streamAuthInterceptor),
+	}
+	if s.opts.TLSCertFile != "" || s.opts.TLSKeyFile != "" {
+		creds, err := credentials.NewServerTLSFromFile(s.opts.TLSCertFile, s.opts.TLSKeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load TLS certificate: %s", err)
+		}
+		serverOpts = append(serverOpts, grpc.Creds(creds))
+	}
+
+	server := grpc.NewServer(serverOpts...)
+	RegisterKeelServer(server, s)
+	return server, nil
+}
+
+// Start - starts serving, blocks until the server is stopped
+func (s *Server) Start() error {
+	server, err := s.newGRPCServer()
+	if err != nil {
+		return err
+	}
+	s.server = server
+
+	lis, err := net.Listen("tcp", fmt.Sprintf(":%d", s.opts.Port))
+	if err != nil {
+		return err
+	}
+
+	log.WithFields(log.Fields{
+		"port": s.opts.Port,
+		"tls":  s.opts.TLSCertFile != "",
+	}).Info("rpc: gRPC API server starting")
+
+	return s.server.Serve(lis)
+}
+
+// Stop - stops the server, open streams are closed
+func (s *Server) Stop() {
+	if s.server != nil {
+		s.server.Stop()
+	}
+}
+
+func (s *Server) unaryAuthInterceptor(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	ctx, err := s.authorize(ctx, info.FullMethod)
+	if err != nil {
+		return nil, err
+	}
+	return handler(ctx, req)
+}
+
+func (s *Server) streamAuthInterceptor(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	if _, err := s.authorize(ss.Context(), info.FullMethod); err != nil {
+		return err
+	}
+	return handler(srv, ss)
+}
+
+// authorize - authenticates the request with "authorization" metadata, same
+// as the REST API either basic auth or a bearer token
+func (s *Server) authorize(ctx context.Context, method string) (context.Context, error) {
+	var authorization string
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		if values := md.Get("authorization"); len(values) > 0 {
+			authorization = values[0]
+		}
+	}
+
+	req := &auth.AuthRequest{AuthType: auth.AuthTypeToken}
+	switch {
+	case strings.HasPrefix(authorization, "Basic "):
+		decoded, err := base64.StdEncoding.DecodeString(strings.TrimPrefix(authorization, "Basic "))
+		if err != nil {
+			return nil, status.Error(codes.Unauthenticated, "invalid basic auth credentials")
+		}
+		parts := strings.SplitN(string(decoded), ":", 2)
+		if len(parts) != 2 {
+			return nil, status.Error(codes.Unauthenticated, "invalid basic auth credentials")
+		}
+		req = &auth.AuthRequest{Username: parts[0], Password: parts[1], AuthType: auth.AuthTypeBasic}
+	case strings.HasPrefix(authorization, "Bearer "):
+		req.Token = strings.TrimPrefix(authorization, "Bearer ")
+	}
+
+	resp, err := s.opts.Authenticator.Authenticate(req)
+	if err != nil {
+		log.WithFields(log.Fields{
+			"error":  err,
+			"method": method,
+		}).Warn("rpc: authentication failed")
+		return nil, status.Error(codes.Unauthenticated, "unauthenticated")
+	}
+
+	if adminMethods[method] && !resp.User.IsAdmin() {
+		log.WithFields(log.Fields{
+			"user":   resp.User.Username,
+			"method": method,
+		}).Warn("rpc: admin access denied to read-only user")
+		return nil, status.Error(codes.PermissionDenied, "admin access required")
+	}
+
+	return auth.SetAccountInCtx(ctx, &resp.User), nil
+}
+
+// ListTrackedImages - images tracked by the providers
+func (s *Server) ListTrackedImages(ctx context.Context, req *ListTrackedImagesRequest) (*ListTrackedImagesResponse, error) {
+	trackedImages, err := s.opts.Providers.TrackedImages()
+	if err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+
+	resources := s.resources()
+
+	resp := &ListTrackedImagesResponse{}
+	for _, img := range trackedImages {
+		ti := &TrackedImage{
+			Image:        img.Image.Name(),
+			Trigger:      img.Trigger.String(),
+			PollSchedule: img.PollSchedule,
+			Provider:     img.Provider,
+			Cluster:      img.Cluster,
+			Namespace:    img.Namespace,
+			Policy:       img.Policy.Name(),
+			Registry:     img.Image.Registry(),
+		}
+		for _, r := range resources {
+			if r.cluster != img.Cluster || r.resource.Namespace != img.Namespace {
+				continue
+			}
+			for _, c := range r.resource.Containers() {
+				ref, err := image.Parse(c.Image)
+				if err == nil && ref.Repository() == img.Image.Repository() {
+					ti.Resources = append(ti.Resources, r.resource.Identifier)
+					break
+				}
+			}
+		}
+		resp.Images = append(resp.Images, ti)
+	}
+
+	return resp, nil
+}
+
+// ListResources - resources of all managed clusters
+func (s *Server) ListResources(ctx context.Context, req *ListResourcesRequest) (*ListResourcesResponse, error) {
+	resp := &ListResourcesResponse{}
+	for _, r := range s.resources() {
+		gr := r.resource
+		p := policy.GetPolicyFromLabelsOrAnnotations(gr.GetLabels(), gr.GetKeelAnnotations())
+		paused, _ := strconv.ParseBool(strings.TrimSpace(gr.GetKeelAnnotations()[types.KeelPausedAnnotation]))
+
+		resp.Resources = append(resp.Resources, &Resource{
+			Provider:    types.ProviderTypeKubernetes.String(),
+			Cluster:     r.cluster,
+			Identifier:  gr.Identifier,
+			Name:        gr.Name,
+			Namespace:   gr.Namespace,
+			Kind:        gr.Kind(),
+			Policy:      p.Name(),
+			Images:      gr.GetImages(),
+			Labels:      gr.GetLabels(),
+			Annotations: gr.GetAnnotations(),
+			Paused:      paused,
+		})
+	}
+	return resp, nil
+}
+
+// ListApprovals - approvals, optionally filtered by status
+func (s *Server) ListApprovals(ctx context.Context, req *ListApprovalsRequest) (*ListApprovalsResponse, error) {
+	switch req.Status {
+	case "", "pending", "archived", "rejected":
+	default:
+		return nil, status.Errorf(codes.InvalidArgument, "unknown status '%s', expected pending, archived or rejected", req.Status)
+	}
+
+	all, err := s.opts.Store.ListApprovals(&types.GetApprovalQuery{})
+	if err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+
+	resp := &ListApprovalsResponse{}
+	for _, a := range all {
+		switch req.Status {
+		case "pending":
+			if a.Archived || a.Rejected || a.Expired() {
+				continue
+			}
+		case "archived":
+			if !a.Archived {
+				continue
+			}
+		case "rejected":
+			if !a.Rejected {
+				continue
+			}
+		}
+		resp.Approvals = append(resp.Approvals, approvalMessage(a))
+	}
+	return resp, nil
+}
+
+// Approve - votes for an approval
+func (s *Server) Approve(ctx context.Context, req *ApproveRequest) (*Approval, error) {
+	if req.Identifier == "" {
+		return nil, status.Error(codes.InvalidArgument, "identifier cannot be empty")
+	}
+
+	voter := req.Voter
+	if voter == "" {
+		if user := auth.GetAccountFromCtx(ctx); user != nil {
+			voter = user.Username
+		}
+	}
+
+	approval, err := s.opts.ApprovalManager.Approve(req.Identifier, voter)
+	if err != nil {
+		return nil, approvalError(req.Identifier, err)
+	}
+	return approvalMessage(approval), nil
+}
+
+// Reject - rejects an approval
+func (s *Server) Reject(ctx context.Context, req *RejectRequest) (*Approval, error) {
+	if req.Identifier == "" {
+		return nil, status.Error(codes.InvalidArgument, "identifier cannot be empty")
+	}
+
+	voter := req.Voter
+	if voter == "" {
+		if user := auth.GetAccountFromCtx(ctx); user != nil {
+			voter = user.Username
+		}
+	}
+
+	approval, err := s.opts.ApprovalManager.Reject(req.Identifier, voter)
+	if err != nil {
+		return nil, approvalError(req.Identifier, err)
+	}
+	return approvalMessage(approval), nil
+}
+
+// SubmitEvent - submits image event of a sidecar trigger plugin to providers
+func (s *Server) SubmitEvent(ctx context.Context, req *SubmitEventRequest) (*SubmitEventResponse, error) {
+	e := &trigger.Event{
+		Image:  req.Image,
+		Digest: req.Digest,
+		Target: req.Target,
+	}
+	event, err := e.ToEvent(req.Source)
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	err = trigger.Submit(s.opts.Providers, event)
+	if err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+
+	log.WithFields(log.Fields{
+		"source": req.Source,
+		"image":  req.Image,
+	}).Debug("rpc: plugin event submitted")
+
+	return &SubmitEventResponse{}, nil
+}
+
+// SetResourcePaused - sets or removes keel.sh/paused on a resource
+func (s *Server) SetResourcePaused(ctx context.Context, req *SetResourcePausedRequest) (*SetResourcePausedResponse, error) {
+	if req.Identifier == "" {
+		return nil, status.Error(codes.InvalidArgument, "identifier cannot be empty")
+	}
+
+	for _, r := range s.resources() {
+		if r.resource.Identifier != req.Identifier || (req.Cluster != "" && r.cluster != req.Cluster) {
+			continue
+		}
+
+		ann := r.resource.GetAnnotations()
+		if req.Paused {
+			ann[types.KeelPausedAnnotation] = "true"
+		} else {
+			delete(ann, types.KeelPausedAnnotation)
+		}
+		r.resource.SetAnnotations(ann)
+
+		if err := r.client.Update(r.resource); err != nil {
+			return nil, status.Error(codes.Internal, err.Error())
+		}
+		return &SetResourcePausedResponse{}, nil
+	}
+
+	return nil, status.Errorf(codes.NotFound, "resource with identifier '%s' not found", req.Identifier)
+}
+
+// SetPaused - pauses or resumes all updates
+func (s *Server) SetPaused(ctx context.Context, req *SetPausedRequest) (*PausedResponse, error) {
+	if req.Paused == s.opts.Providers.Paused() {
+		return &PausedResponse{Paused: req.Paused}, nil
+	}
+
+	if req.Paused {
+		s.opts.Providers.Pause()
+	} else {
+		s.opts.Providers.Resume()
+	}
+	s.notifyPause(ctx, req.Paused)
+
+	return &PausedResponse{Paused: req.Paused}, nil
+}
+
+// GetPaused - whether updates are paused
+func (s *Server) GetPaused(ctx context.Context, req *GetPausedRequest) (*PausedResponse, error) {
+	return &PausedResponse{Paused: s.opts.Providers.Paused()}, nil
+}
+
+// StreamEvents - streams events until the client goes away
+func (s *Server) StreamEvents(req *StreamEventsRequest, stream Keel_StreamEventsServer) error {
+	if s.opts.Events == nil {
+		return status.Error(codes.Unimplemented, "event stream is not configured")
+	}
+
+	filter := map[string]bool{}
+	for _, t := range req.Types {
+		filter[t] = true
+	}
+
+	ctx, cancel := context.WithCancel(stream.Context())
+	defer cancel()
+
+	for event := range s.opts.Events.Subscribe(ctx) {
+		if len(filter) > 0 && !filter[event.Type.String()] {
+			continue
+		}
+		if err := stream.Send(eventMessage(event)); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (s *Server) notifyPause(ctx context.Context, paused bool) {
+	username := "unknown"
+	if user := auth.GetAccountFromCtx(ctx); user != nil {
+		username = user.Username
+	}
+
+	log.WithFields(log.Fields{
+		"paused": paused,
+		"user":   username,
+	}).Warn("rpc: updates pause state changed")
+
+	if s.opts.Sender == nil {
+		return
+	}
+
+	name, level := "updates resumed", types.LevelInfo
+	if paused {
+		name, level = "updates paused", types.LevelWarn
+	}
+
+	s.opts.Sender.Send(types.EventNotification{
+		Name:      name,
+		Message:   fmt.Sprintf("Keel %s by %s", name, username),
+		CreatedAt: time.Now(),
+		Type:      types.NotificationSystemEvent,
+		Level:     level,
+	})
+}
+
+func approvalError(identifier string, err error) error {
+	if err == store.ErrRecordNotFound {
+		return status.Errorf(codes.NotFound, "approval '%s' not found", identifier)
+	}
+	if err == approvals.ErrNotApprover {
+		return status.Error(codes.PermissionDenied, err.Error())
+	}
+	return status.Error(codes.Internal, err.Error())
+}
+
+func approvalMessage(a *types.Approval) *Approval {
+	return &Approval{
+		Id:             a.ID,
+		Provider:       a.Provider.String(),
+		Identifier:     a.Identifier,
+		Message:        a.Message,
+		CurrentVersion: a.CurrentVersion,
+		NewVersion:     a.NewVersion,
+		VotesRequired:  int32(a.VotesRequired),
+		VotesReceived:  int32(a.VotesReceived),
+		Voters:         a.GetVoters(),
+		Rejected:       a.Rejected,
+		Archived:       a.Archived,
+		Deadline:       timestampProto(a.Deadline),
+		CreatedAt:      timestampProto(a.CreatedAt),
+		UpdatedAt:      timestampProto(a.UpdatedAt),
+	}
+}
+
+func eventMessage(e types.EventNotification) *Event {
+	return &Event{
+		Name:         e.Name,
+		Message:      e.Message,
+		CreatedAt:    timestampProto(e.CreatedAt),
+		Type:         e.Type.String(),
+		Level:        e.Level.String(),
+		Metadata:     e.Metadata,
+		ResourceKind: e.ResourceKind,
+		Identifier:   e.Identifier,
+	}
+}
+
+func timestampProto(t time.Time) *timestamp.Timestamp {
+	if t.IsZero() {
+		return nil
+	}
+	ts, err := ptypes.TimestampProto(t)
+	if err != nil {
+		return nil
+	}
+	return ts
+}
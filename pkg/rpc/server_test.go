@@ -0,0 +1,231 @@
+package rpc
+
+import (
+	"context"
+	"encoding/base64"
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+
+	"github.com/keel-hq/keel/approvals"
+	"github.com/keel-hq/keel/pkg/auth"
+	"github.com/keel-hq/keel/pkg/store/sql"
+	"github.com/keel-hq/keel/provider"
+	"github.com/keel-hq/keel/types"
+)
+
+type fakeProvider struct {
+	submitted []types.Event
+}
+
+func (p *fakeProvider) Submit(event types.Event) error {
+	p.submitted = append(p.submitted, event)
+	return nil
+}
+
+func (p *fakeProvider) TrackedImages() ([]*types.TrackedImage, error) {
+	return nil, nil
+}
+
+func (p *fakeProvider) GetName() string {
+	return "fakeprovider"
+}
+
+func (p *fakeProvider) Stop() {}
+
+type fakeEvents struct {
+	events []types.EventNotification
+}
+
+func (e *fakeEvents) Subscribe(ctx context.Context) <-chan types.EventNotification {
+	ch := make(chan types.EventNotification, len(e.events))
+	for _, event := range e.events {
+		ch <- event
+	}
+	close(ch)
+	return ch
+}
+
+func newTestingClient(t *testing.T, events *fakeEvents) (KeelClient, approvals.Manager, func()) {
+	dir, err := ioutil.TempDir("", "rpcstoretest")
+	if err != nil {
+		t.Fatal(err)
+	}
+	store, err := sql.New(sql.Opts{DatabaseType: "sqlite3", URI: filepath.Join(dir, "gorm.db")})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	am := approvals.New(&approvals.Opts{Store: store})
+	srv := NewServer(&Opts{
+		Providers:       provider.New([]provider.Provider{&fakeProvider{}}, am),
+		ApprovalManager: am,
+		Authenticator: auth.New(&auth.Opts{
+			Username:         "user-1",
+			Password:         "secret",
+			ReadOnlyUsername: "viewer",
+			ReadOnlyPassword: "secret",
+		}),
+		Store:  store,
+		Events: events,
+	})
+
+	server, err := srv.newGRPCServer()
+	if err != nil {
+		t.Fatalf("failed to create server: %s", err)
+	}
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to listen: %s", err)
+	}
+	go server.Serve(lis)
+
+	conn, err := grpc.Dial(lis.Addr().String(), grpc.WithInsecure())
+	if err != nil {
+		t.Fatalf("failed to dial: %s", err)
+	}
+
+	return NewKeelClient(conn), am, func() {
+		conn.Close()
+		server.Stop()
+		os.RemoveAll(dir)
+	}
+}
+
+func withBasicAuth(username, password string) context.Context {
+	credentials := base64.StdEncoding.EncodeToString([]byte(username + ":" + password))
+	return metadata.AppendToOutgoingContext(context.Background(), "authorization", "Basic "+credentials)
+}
+
+func TestApprovals(t *testing.T) {
+	client, am, teardown := newTestingClient(t, &fakeEvents{})
+	defer teardown()
+
+	err := am.Create(&types.Approval{
+		Provider:       types.ProviderTypeKubernetes,
+		Identifier:     "default/wd:1.1.0",
+		CurrentVersion: "1.0.0",
+		NewVersion:     "1.1.0",
+		VotesRequired:  2,
+		Deadline:       time.Now().Add(time.Hour),
+	})
+	if err != nil {
+		t.Fatalf("failed to create approval: %s", err)
+	}
+
+	_, err = client.ListApprovals(context.Background(), &ListApprovalsRequest{})
+	if status.Code(err) != codes.Unauthenticated {
+		t.Errorf("expected unauthenticated error, got: %v", err)
+	}
+
+	resp, err := client.ListApprovals(withBasicAuth("viewer", "secret"), &ListApprovalsRequest{Status: "pending"})
+	if err != nil {
+		t.Fatalf("failed to list approvals: %s", err)
+	}
+	if len(resp.Approvals) != 1 || resp.Approvals[0].Identifier != "default/wd:1.1.0" || resp.Approvals[0].VotesRequired != 2 {
+		t.Fatalf("unexpected approvals: %v", resp.Approvals)
+	}
+	if resp.Approvals[0].Deadline == nil {
+		t.Errorf("expected deadline to be set")
+	}
+
+	_, err = client.Approve(withBasicAuth("viewer", "secret"), &ApproveRequest{Identifier: "default/wd:1.1.0"})
+	if status.Code(err) != codes.PermissionDenied {
+		t.Errorf("expected permission denied error, got: %v", err)
+	}
+
+	approval, err := client.Approve(withBasicAuth("user-1", "secret"), &ApproveRequest{Identifier: "default/wd:1.1.0"})
+	if err != nil {
+		t.Fatalf("failed to approve: %s", err)
+	}
+	if approval.VotesReceived != 1 || len(approval.Voters) != 1 || approval.Voters[0] != "user-1" {
+		t.Errorf("unexpected approval: %v", approval)
+	}
+
+	_, err = client.Reject(withBasicAuth("user-1", "secret"), &RejectRequest{Identifier: "default/missing:1.1.0"})
+	if status.Code(err) != codes.NotFound {
+		t.Errorf("expected not found error, got: %v", err)
+	}
+}
+
+func TestSetPaused(t *testing.T) {
+	client, _, teardown := newTestingClient(t, &fakeEvents{})
+	defer teardown()
+
+	ctx := withBasicAuth("user-1", "secret")
+	resp, err := client.SetPaused(ctx, &SetPausedRequest{Paused: true})
+	if err != nil {
+		t.Fatalf("failed to pause: %s", err)
+	}
+	if !resp.Paused {
+		t.Errorf("expected updates to be paused")
+	}
+
+	resp, err = client.GetPaused(ctx, &GetPausedRequest{})
+	if err != nil {
+		t.Fatalf("failed to get paused state: %s", err)
+	}
+	if !resp.Paused {
+		t.Errorf("expected updates to be paused")
+	}
+}
+
+func TestSubmitEvent(t *testing.T) {
+	client, _, teardown := newTestingClient(t, &fakeEvents{})
+	defer teardown()
+
+	req := &SubmitEventRequest{Source: "release-bot", Image: "registry.example.com/app:1.4.0"}
+
+	_, err := client.SubmitEvent(withBasicAuth("viewer", "secret"), req)
+	if status.Code(err) != codes.PermissionDenied {
+		t.Errorf("expected permission denied error, got: %v", err)
+	}
+
+	_, err = client.SubmitEvent(withBasicAuth("user-1", "secret"), &SubmitEventRequest{Source: "release-bot", Image: "registry.example.com/app"})
+	if status.Code(err) != codes.InvalidArgument {
+		t.Errorf("expected invalid argument error for image without tag, got: %v", err)
+	}
+
+	_, err = client.SubmitEvent(withBasicAuth("user-1", "secret"), req)
+	if err != nil {
+		t.Errorf("failed to submit event: %s", err)
+	}
+}
+
+func TestStreamEvents(t *testing.T) {
+	client, _, teardown := newTestingClient(t, &fakeEvents{
+		events: []types.EventNotification{
+			{Name: "update", Message: "first", Type: types.NotificationDeploymentUpdate, Level: types.LevelSuccess, CreatedAt: time.Now()},
+			{Name: "system", Message: "second", Type: types.NotificationSystemEvent, Level: types.LevelInfo},
+		},
+	})
+	defer teardown()
+
+	stream, err := client.StreamEvents(withBasicAuth("viewer", "secret"), &StreamEventsRequest{
+		Types: []string{types.NotificationDeploymentUpdate.String()},
+	})
+	if err != nil {
+		t.Fatalf("failed to stream events: %s", err)
+	}
+
+	event, err := stream.Recv()
+	if err != nil {
+		t.Fatalf("failed to receive event: %s", err)
+	}
+	if event.Message != "first" || event.Level != "success" || event.CreatedAt == nil {
+		t.Errorf("unexpected event: %v", event)
+	}
+
+	// filtered out event is skipped and the stream ends
+	if _, err := stream.Recv(); err == nil {
+		t.Errorf("expected stream to end")
+	}
+}
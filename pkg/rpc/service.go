@@ -0,0 +1,223 @@
+package rpc
+
+//go:generate protoc --go_out=. --go_opt=paths=source_relative keel.proto
+
+import (
+	"context"
+
+	"google.golang.org/grpc"
+)
+
+// ServiceName - full name of the Keel service in keel.proto
+const ServiceName = "keel.v1.Keel"
+
+// KeelServer - server side of the Keel service
+type KeelServer interface {
+	ListTrackedImages(context.Context, *ListTrackedImagesRequest) (*ListTrackedImagesResponse, error)
+	ListResources(context.Context, *ListResourcesRequest) (*ListResourcesResponse, error)
+	ListApprovals(context.Context, *ListApprovalsRequest) (*ListApprovalsResponse, error)
+	Approve(context.Context, *ApproveRequest) (*Approval, error)
+	Reject(context.Context, *RejectRequest) (*Approval, error)
+	SetResourcePaused(context.Context, *SetResourcePausedRequest) (*SetResourcePausedResponse, error)
+	SetPaused(context.Context, *SetPausedRequest) (*PausedResponse, error)
+	GetPaused(context.Context, *GetPausedRequest) (*PausedResponse, error)
+	SubmitEvent(context.Context, *SubmitEventRequest) (*SubmitEventResponse, error)
+	StreamEvents(*StreamEventsRequest, Keel_StreamEventsServer) error
+}
+
+// Keel_StreamEventsServer - server side of the events stream
+type Keel_StreamEventsServer interface {
+	Send(*Event) error
+	grpc.ServerStream
+}
+
+type keelStreamEventsServer struct {
+	grpc.ServerStream
+}
+
+func (x *keelStreamEventsServer) Send(m *Event) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+// RegisterKeelServer - registers Keel service implementation
+func RegisterKeelServer(s *grpc.Server, srv KeelServer) {
+	s.RegisterService(&keelServiceDesc, srv)
+}
+
+// unaryHandler - decodes the request into a new message and passes it to
+// the method through the interceptor
+func unaryHandler(method string, newRequest func() interface{}, call func(srv KeelServer, ctx context.Context, req interface{}) (interface{}, error)) grpc.MethodDesc {
+	return grpc.MethodDesc{
+		MethodName: method,
+		Handler: func(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+			in := newRequest()
+			if err := dec(in); err != nil {
+				return nil, err
+			}
+			if interceptor == nil {
+				return call(srv.(KeelServer), ctx, in)
+			}
+			info := &grpc.UnaryServerInfo{
+				Server:     srv,
+				FullMethod: "/" + ServiceName + "/" + method,
+			}
+			handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+				return call(srv.(KeelServer), ctx, req)
+			}
+			return interceptor(ctx, in, info, handler)
+		},
+	}
+}
+
+var keelServiceDesc = grpc.ServiceDesc{
+	ServiceName: ServiceName,
+	HandlerType: (*KeelServer)(nil),
+	Methods: []grpc.MethodDesc{
+		unaryHandler("ListTrackedImages", func() interface{} { return new(ListTrackedImagesRequest) }, func(srv KeelServer, ctx context.Context, req interface{}) (interface{}, error) {
+			return srv.ListTrackedImages(ctx, req.(*ListTrackedImagesRequest))
+		}),
+		unaryHandler("ListResources", func() interface{} { return new(ListResourcesRequest) }, func(srv KeelServer, ctx context.Context, req interface{}) (interface{}, error) {
+			return srv.ListResources(ctx, req.(*ListResourcesRequest))
+		}),
+		unaryHandler("ListApprovals", func() interface{} { return new(ListApprovalsRequest) }, func(srv KeelServer, ctx context.Context, req interface{}) (interface{}, error) {
+			return srv.ListApprovals(ctx, req.(*ListApprovalsRequest))
+		}),
+		unaryHandler("Approve", func() interface{} { return new(ApproveRequest) }, func(srv KeelServer, ctx context.Context, req interface{}) (interface{}, error) {
+			return srv.Approve(ctx, req.(*ApproveRequest))
+		}),
+		unaryHandler("Reject", func() interface{} { return new(RejectRequest) }, func(srv KeelServer, ctx context.Context, req interface{}) (interface{}, error) {
+			return srv.Reject(ctx, req.(*RejectRequest))
+		}),
+		unaryHandler("SetResourcePaused", func() interface{} { return new(SetResourcePausedRequest) }, func(srv KeelServer, ctx context.Context, req interface{}) (interface{}, error) {
+			return srv.SetResourcePaused(ctx, req.(*SetResourcePausedRequest))
+		}),
+		unaryHandler("SetPaused", func() interface{} { return new(SetPausedRequest) }, func(srv KeelServer, ctx context.Context, req interface{}) (interface{}, error) {
+			return srv.SetPaused(ctx, req.(*SetPausedRequest))
+		}),
+		unaryHandler("GetPaused", func() interface{} { return new(GetPausedRequest) }, func(srv KeelServer, ctx context.Context, req interface{}) (interface{}, error) {
+			return srv.GetPaused(ctx, req.(*GetPausedRequest))
+		}),
+		unaryHandler("SubmitEvent", func() interface{} { return new(SubmitEventRequest) }, func(srv KeelServer, ctx context.Context, req interface{}) (interface{}, error) {
+			return srv.SubmitEvent(ctx, req.(*SubmitEventRequest))
+		}),
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName: "StreamEvents",
+			Handler: func(srv interface{}, stream grpc.ServerStream) error {
+				m := new(StreamEventsRequest)
+				if err := stream.RecvMsg(m); err != nil {
+					return err
+				}
+				return srv.(KeelServer).StreamEvents(m, &keelStreamEventsServer{stream})
+			},
+			ServerStreams: true,
+		},
+	},
+	Metadata: "keel.proto",
+}
+
+// KeelClient - client of the Keel service
+type KeelClient interface {
+	ListTrackedImages(ctx context.Context, in *ListTrackedImagesRequest, opts ...grpc.CallOption) (*ListTrackedImagesResponse, error)
+	ListResources(ctx context.Context, in *ListResourcesRequest, opts ...grpc.CallOption) (*ListResourcesResponse, error)
+	ListApprovals(ctx context.Context, in *ListApprovalsRequest, opts ...grpc.CallOption) (*ListApprovalsResponse, error)
+	Approve(ctx context.Context, in *ApproveRequest, opts ...grpc.CallOption) (*Approval, error)
+	Reject(ctx context.Context, in *RejectRequest, opts ...grpc.CallOption) (*Approval, error)
+	SetResourcePaused(ctx context.Context, in *SetResourcePausedRequest, opts ...grpc.CallOption) (*SetResourcePausedResponse, error)
+	SetPaused(ctx context.Context, in *SetPausedRequest, opts ...grpc.CallOption) (*PausedResponse, error)
+	GetPaused(ctx context.Context, in *GetPausedRequest, opts ...grpc.CallOption) (*PausedResponse, error)
+	SubmitEvent(ctx context.Context, in *SubmitEventRequest, opts ...grpc.CallOption) (*SubmitEventResponse, error)
+	StreamEvents(ctx context.Context, in *StreamEventsRequest, opts ...grpc.CallOption) (Keel_StreamEventsClient, error)
+}
+
+// Keel_StreamEventsClient - client side of the events stream
+type Keel_StreamEventsClient interface {
+	Recv() (*Event, error)
+	grpc.ClientStream
+}
+
+type keelClient struct {
+	cc *grpc.ClientConn
+}
+
+// NewKeelClient - creates Keel service client
+func NewKeelClient(cc *grpc.ClientConn) KeelClient {
+	return &keelClient{cc}
+}
+
+func (c *keelClient) invoke(ctx context.Context, method string, in, out interface{}, opts ...grpc.CallOption) error {
+	return c.cc.Invoke(ctx, "/"+ServiceName+"/"+method, in, out, opts...)
+}
+
+func (c *keelClient) ListTrackedImages(ctx context.Context, in *ListTrackedImagesRequest, opts ...grpc.CallOption) (*ListTrackedImagesResponse, error) {
+	out := new(ListTrackedImagesResponse)
+	return out, c.invoke(ctx, "ListTrackedImages", in, out, opts...)
+}
+
+func (c *keelClient) ListResources(ctx context.Context, in *ListResourcesRequest, opts ...grpc.CallOption) (*ListResourcesResponse, error) {
+	out := new(ListResourcesResponse)
+	return out, c.invoke(ctx, "ListResources", in, out, opts...)
+}
+
+func (c *keelClient) ListApprovals(ctx context.Context, in *ListApprovalsRequest, opts ...grpc.CallOption) (*ListApprovalsResponse, error) {
+	out := new(ListApprovalsResponse)
+	return out, c.invoke(ctx, "ListApprovals", in, out, opts...)
+}
+
+func (c *keelClient) Approve(ctx context.Context, in *ApproveRequest, opts ...grpc.CallOption) (*Approval, error) {
+	out := new(Approval)
+	return out, c.invoke(ctx, "Approve", in, out, opts...)
+}
+
+func (c *keelClient) Reject(ctx context.Context, in *RejectRequest, opts ...grpc.CallOption) (*Approval, error) {
+	out := new(Approval)
+	return out, c.invoke(ctx, "Reject", in, out, opts...)
+}
+
+func (c *keelClient) SetResourcePaused(ctx context.Context, in *SetResourcePausedRequest, opts ...grpc.CallOption) (*SetResourcePausedResponse, error) {
+	out := new(SetResourcePausedResponse)
+	return out, c.invoke(ctx, "SetResourcePaused", in, out, opts...)
+}
+
+func (c *keelClient) SetPaused(ctx context.Context, in *SetPausedRequest, opts ...grpc.CallOption) (*PausedResponse, error) {
+	out := new(PausedResponse)
+	return out, c.invoke(ctx, "SetPaused", in, out, opts...)
+}
+
+func (c *keelClient) GetPaused(ctx context.Context, in *GetPausedRequest, opts ...grpc.CallOption) (*PausedResponse, error) {
+	out := new(PausedResponse)
+	return out, c.invoke(ctx, "GetPaused", in, out, opts...)
+}
+
+func (c *keelClient) SubmitEvent(ctx context.Context, in *SubmitEventRequest, opts ...grpc.CallOption) (*SubmitEventResponse, error) {
+	out := new(SubmitEventResponse)
+	return out, c.invoke(ctx, "SubmitEvent", in, out, opts...)
+}
+
+func (c *keelClient) StreamEvents(ctx context.Context, in *StreamEventsRequest, opts ...grpc.CallOption) (Keel_StreamEventsClient, error) {
+	stream, err := c.cc.NewStream(ctx, &keelServiceDesc.Streams[0], "/"+ServiceName+"/StreamEvents", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &keelStreamEventsClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type keelStreamEventsClient struct {
+	grpc.ClientStream
+}
+
+func (x *keelStreamEventsClient) Recv() (*Event, error) {
+	m := new(Event)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
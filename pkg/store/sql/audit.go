@@ -2,9 +2,11 @@ package sql
 
 import (
 	"fmt"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/jinzhu/gorm"
 	"github.com/keel-hq/keel/types"
 )
 
@@ -19,6 +21,12 @@ func (s *SQLStore) CreateAuditLog(entry *types.AuditLog) (id string, err error)
 		tx.Rollback()
 		return "", err
 	}
+	for _, image := range auditLogImages(entry) {
+		if err := tx.Create(image).Error; err != nil {
+			tx.Rollback()
+			return "", err
+		}
+	}
 
 	tx.Commit()
 
@@ -41,29 +49,101 @@ func (s *SQLStore) GetAuditLogs(query *types.AuditLogQuery) (logs []*types.Audit
 		query.Order = "created_at desc"
 	}
 
-	if len(query.ResourceKindFilter) == 1 && query.ResourceKindFilter[0] == "*" {
-		err = s.db.Order(query.Order).Limit(query.Limit).Offset(query.Offset).Find(&logs).Error
-	} else if query.Username != "" {
-		err = s.db.Order(query.Order).Where("resource_kind in (?)", query.ResourceKindFilter).Limit(query.Limit).Offset(query.Offset).Where("username = ?", query.Username).Find(&logs).Error
-	} else {
-		err = s.db.Order(query.Order).Where("resource_kind in (?)", query.ResourceKindFilter).Limit(query.Limit).Offset(query.Offset).Find(&logs).Error
-	}
+	err = s.auditLogsQuery(query).Order(query.Order).Limit(query.Limit).Offset(query.Offset).Find(&logs).Error
 
 	return logs, err
 }
 
 func (s *SQLStore) AuditLogsCount(query *types.AuditLogQuery) (int, error) {
-	var err error
 	var count int
+	err := s.auditLogsQuery(query).Model(&types.AuditLog{}).Count(&count).Error
+	return count, err
+}
 
-	if len(query.ResourceKindFilter) == 1 && query.ResourceKindFilter[0] == "*" {
-		err = s.db.Model(&types.AuditLog{}).Count(&count).Error
-	} else if query.Username != "" {
-		err = s.db.Model(&types.AuditLog{}).Where("resource_kind in (?)", query.ResourceKindFilter).Where("username = ?", query.Username).Count(&count).Error
-	} else {
-		err = s.db.Model(&types.AuditLog{}).Where("resource_kind in (?)", query.ResourceKindFilter).Count(&count).Error
+// auditLogsQuery - applies query filters, shared by listing and counting
+func (s *SQLStore) auditLogsQuery(query *types.AuditLogQuery) *gorm.DB {
+	db := s.db
+
+	// username is only applied when filtering by resource kind
+	if !(len(query.ResourceKindFilter) == 1 && query.ResourceKindFilter[0] == "*") {
+		db = db.Where("resource_kind in (?)", query.ResourceKindFilter)
+		if query.Username != "" {
+			db = db.Where("username = ?", query.Username)
+		}
 	}
-	return count, err
+	if len(query.ActionFilter) > 0 {
+		db = db.Where("action in (?)", query.ActionFilter)
+	}
+	if query.Namespace != "" {
+		db = db.Where("namespace = ?", query.Namespace)
+	}
+	if query.Name != "" {
+		db = db.Where("name = ?", query.Name)
+	}
+	if query.Image != "" {
+		images := s.db.Model(&types.AuditLogImage{}).Select("audit_log_id").
+			Where("image = ? OR repository = ?", query.Image, query.Image)
+		db = db.Where("id IN (?)", images.QueryExpr())
+	}
+	if !query.From.IsZero() {
+		db = db.Where("created_at >= ?", query.From)
+	}
+	if !query.To.IsZero() {
+		db = db.Where("created_at <= ?", query.To)
+	}
+
+	return db
+}
+
+// auditLogImages - splits comma separated entry images into rows
+func auditLogImages(entry *types.AuditLog) []*types.AuditLogImage {
+	var images []*types.AuditLogImage
+	seen := make(map[string]bool)
+	for _, image := range strings.Split(entry.Image, ",") {
+		image = strings.TrimSpace(image)
+		if image == "" || seen[image] {
+			continue
+		}
+		seen[image] = true
+		images = append(images, &types.AuditLogImage{
+			AuditLogID: entry.ID,
+			Image:      image,
+			Repository: imageRepository(image),
+		})
+	}
+	return images
+}
+
+// imageRepository - strips tag and digest from the image
+func imageRepository(image string) string {
+	if i := strings.Index(image, "@"); i >= 0 {
+		image = image[:i]
+	}
+	if i := strings.LastIndex(image, ":"); i > strings.LastIndex(image, "/") {
+		image = image[:i]
+	}
+	return image
+}
+
+// backfillAuditLogImages - creates image rows for entries written before
+// images got their own table
+func (s *SQLStore) backfillAuditLogImages() error {
+	var logs []*types.AuditLog
+	err := s.db.Where("image <> ''").
+		Where("id NOT IN (?)", s.db.Model(&types.AuditLogImage{}).Select("audit_log_id").QueryExpr()).
+		Find(&logs).Error
+	if err != nil {
+		return err
+	}
+
+	for _, l := range logs {
+		for _, image := range auditLogImages(l) {
+			if err := s.db.Create(image).Error; err != nil {
+				return err
+			}
+		}
+	}
+	return nil
 }
 
 var logsWeeklyStats = `SELECT day, COALESCE(updates, 0) AS updates, COALESCE(approved, 0) as approved
@@ -0,0 +1,50 @@
+package sql
+
+import (
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/jinzhu/gorm"
+
+	"github.com/keel-hq/keel/pkg/store"
+	"github.com/keel-hq/keel/types"
+)
+
+// CreateDeadLetter - stores event that couldn't be applied
+func (s *SQLStore) CreateDeadLetter(deadLetter *types.DeadLetter) (*types.DeadLetter, error) {
+	if deadLetter.ID == "" {
+		deadLetter.ID = uuid.New().String()
+	}
+
+	if err := s.db.Create(deadLetter).Error; err != nil {
+		return nil, err
+	}
+
+	return deadLetter, nil
+}
+
+// GetDeadLetter - get stored event by ID
+func (s *SQLStore) GetDeadLetter(id string) (*types.DeadLetter, error) {
+	var result types.DeadLetter
+	err := s.db.Where("id = ?", id).First(&result).Error
+	if err == gorm.ErrRecordNotFound {
+		return nil, store.ErrRecordNotFound
+	}
+
+	return &result, err
+}
+
+// ListDeadLetters - lists stored events, newest first
+func (s *SQLStore) ListDeadLetters() ([]*types.DeadLetter, error) {
+	var deadLetters []*types.DeadLetter
+	err := s.db.Order("created_at desc").Find(&deadLetters).Error
+	return deadLetters, err
+}
+
+// DeleteDeadLetter - removes stored event
+func (s *SQLStore) DeleteDeadLetter(deadLetter *types.DeadLetter) error {
+	if deadLetter.ID == "" {
+		return fmt.Errorf("ID not specified")
+	}
+	return s.db.Delete(deadLetter).Error
+}
@@ -34,6 +34,8 @@ func New(opts Opts) (*SQLStore, error) {
 	err = db.AutoMigrate(
 		&types.Approval{},
 		&types.AuditLog{},
+		&types.AuditLogImage{},
+		&types.DeadLetter{},
 	).Error
 	if err != nil {
 		log.WithFields(log.Fields{
@@ -42,9 +44,19 @@ func New(opts Opts) (*SQLStore, error) {
 		return nil, err
 	}
 
-	return &SQLStore{
+	s := &SQLStore{
 		db: db,
-	}, nil
+	}
+
+	err = s.backfillAuditLogImages()
+	if err != nil {
+		log.WithFields(log.Fields{
+			"error": err,
+		}).Error("audit log images migration failed")
+		return nil, err
+	}
+
+	return s, nil
 }
 
 // Close - closes database connection
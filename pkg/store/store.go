@@ -18,6 +18,11 @@ type Store interface {
 	ListApprovals(q *types.GetApprovalQuery) ([]*types.Approval, error)
 	DeleteApproval(approval *types.Approval) error
 
+	CreateDeadLetter(deadLetter *types.DeadLetter) (*types.DeadLetter, error)
+	GetDeadLetter(id string) (*types.DeadLetter, error)
+	ListDeadLetters() ([]*types.DeadLetter, error)
+	DeleteDeadLetter(deadLetter *types.DeadLetter) error
+
 	OK() bool
 	Close() error
 }
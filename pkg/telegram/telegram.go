@@ -0,0 +1,215 @@
+// Package telegram is a minimal Telegram Bot API client covering what keel
+// needs: sending messages with inline keyboards and long polling for updates.
+package telegram
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DefaultBaseURL - Telegram Bot API endpoint
+const DefaultBaseURL = "https://api.telegram.org"
+
+// MaxMessageLength - Telegram rejects longer messages
+const MaxMessageLength = 4096
+
+// MaxCallbackDataLength - max size of inline button callback data in bytes
+const MaxCallbackDataLength = 64
+
+// IsChatID - whether channel is a numeric chat ID or a @channel username
+func IsChatID(channel string) bool {
+	if strings.HasPrefix(channel, "@") {
+		return len(channel) > 1
+	}
+	_, err := strconv.ParseInt(channel, 10, 64)
+	return err == nil
+}
+
+// User - Telegram user or bot
+type User struct {
+	ID        int64  `json:"id"`
+	IsBot     bool   `json:"is_bot"`
+	FirstName string `json:"first_name"`
+	Username  string `json:"username"`
+}
+
+// Name - username if set, first name otherwise
+func (u *User) Name() string {
+	if u.Username != "" {
+		return u.Username
+	}
+	return u.FirstName
+}
+
+// Chat - private chat, group or channel
+type Chat struct {
+	ID    int64  `json:"id"`
+	Type  string `json:"type"`
+	Title string `json:"title"`
+}
+
+// Message - received message
+type Message struct {
+	MessageID int64  `json:"message_id"`
+	From      *User  `json:"from"`
+	Chat      Chat   `json:"chat"`
+	Text      string `json:"text"`
+}
+
+// CallbackQuery - inline keyboard button press
+type CallbackQuery struct {
+	ID      string   `json:"id"`
+	From    User     `json:"from"`
+	Message *Message `json:"message"`
+	Data    string   `json:"data"`
+}
+
+// Update - incoming update, only one of the optional fields is set
+type Update struct {
+	UpdateID      int64          `json:"update_id"`
+	Message       *Message       `json:"message"`
+	CallbackQuery *CallbackQuery `json:"callback_query"`
+}
+
+// InlineKeyboardButton - button sending callback data back to the bot
+type InlineKeyboardButton struct {
+	Text         string `json:"text"`
+	CallbackData string `json:"callback_data"`
+}
+
+// InlineKeyboardMarkup - buttons attached to a message
+type InlineKeyboardMarkup struct {
+	InlineKeyboard [][]InlineKeyboardButton `json:"inline_keyboard"`
+}
+
+type sendMessageRequest struct {
+	ChatID      string                `json:"chat_id"`
+	Text        string                `json:"text"`
+	ReplyMarkup *InlineKeyboardMarkup `json:"reply_markup,omitempty"`
+}
+
+type getUpdatesRequest struct {
+	Offset         int64    `json:"offset,omitempty"`
+	Timeout        int      `json:"timeout"`
+	AllowedUpdates []string `json:"allowed_updates"`
+}
+
+type answerCallbackQueryRequest struct {
+	CallbackQueryID string `json:"callback_query_id"`
+	Text            string `json:"text,omitempty"`
+}
+
+type response struct {
+	OK          bool            `json:"ok"`
+	Description string          `json:"description"`
+	Result      json.RawMessage `json:"result"`
+}
+
+// Client - Telegram Bot API client
+type Client struct {
+	token   string
+	baseURL string
+	client  *http.Client
+}
+
+// New - creates a new client authenticated with bot token
+func New(token string) *Client {
+	return NewWithURL(token, DefaultBaseURL)
+}
+
+// NewWithURL - creates a new client for a Bot API server (ie: local server)
+func NewWithURL(token, baseURL string) *Client {
+	return &Client{
+		token:   token,
+		baseURL: baseURL,
+		// has to outlive long polling requests
+		client: &http.Client{Timeout: 90 * time.Second},
+	}
+}
+
+// GetMe - bot user
+func (c *Client) GetMe(ctx context.Context) (*User, error) {
+	var user User
+	err := c.call(ctx, "getMe", struct{}{}, &user)
+	if err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+// SendMessage - sends plain text message, keyboard is optional
+func (c *Client) SendMessage(ctx context.Context, chatID, text string, keyboard *InlineKeyboardMarkup) error {
+	if len(text) > MaxMessageLength {
+		text = text[:MaxMessageLength-3] + "..."
+	}
+	return c.call(ctx, "sendMessage", sendMessageRequest{
+		ChatID:      chatID,
+		Text:        text,
+		ReplyMarkup: keyboard,
+	}, nil)
+}
+
+// GetUpdates - long polls for updates after offset, waiting up to timeout
+func (c *Client) GetUpdates(ctx context.Context, offset int64, timeout time.Duration) ([]Update, error) {
+	var updates []Update
+	err := c.call(ctx, "getUpdates", getUpdatesRequest{
+		Offset:         offset,
+		Timeout:        int(timeout.Seconds()),
+		AllowedUpdates: []string{"message", "callback_query"},
+	}, &updates)
+	return updates, err
+}
+
+// AnswerCallbackQuery - acknowledges button press, text is shown to the user
+func (c *Client) AnswerCallbackQuery(ctx context.Context, id, text string) error {
+	return c.call(ctx, "answerCallbackQuery", answerCallbackQueryRequest{
+		CallbackQueryID: id,
+		Text:            text,
+	}, nil)
+}
+
+func (c *Client) call(ctx context.Context, method string, params, result interface{}) error {
+	body, err := json.Marshal(params)
+	if err != nil {
+		return fmt.Errorf("failed to marshal %s request: %s", method, err)
+	}
+
+	req, err := http.NewRequest(http.MethodPost, fmt.Sprintf("%s/bot%s/%s", c.baseURL, c.token, method), bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req = req.WithContext(ctx)
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		// url contains the token
+		if urlErr, ok := err.(*url.Error); ok {
+			err = urlErr.Err
+		}
+		return fmt.Errorf("telegram %s request failed: %s", method, err)
+	}
+	defer resp.Body.Close()
+
+	var r response
+	if err := json.NewDecoder(resp.Body).Decode(&r); err != nil {
+		return fmt.Errorf("failed to decode telegram %s response (status %d): %s", method, resp.StatusCode, err)
+	}
+	if !r.OK {
+		return fmt.Errorf("telegram %s failed: %s", method, r.Description)
+	}
+
+	if result != nil {
+		if err := json.Unmarshal(r.Result, result); err != nil {
+			return fmt.Errorf("failed to decode telegram %s result: %s", method, err)
+		}
+	}
+	return nil
+}
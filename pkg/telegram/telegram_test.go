@@ -0,0 +1,82 @@
+package telegram
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSendMessage(t *testing.T) {
+	var path string
+	var req sendMessageRequest
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		path = r.URL.Path
+		body, _ := ioutil.ReadAll(r.Body)
+		json.Unmarshal(body, &req)
+		w.Write([]byte(`{"ok": true, "result": {"message_id": 1}}`))
+	}))
+	defer ts.Close()
+
+	c := NewWithURL("123:abc", ts.URL)
+	err := c.SendMessage(context.Background(), "-100", "hello", &InlineKeyboardMarkup{
+		InlineKeyboard: [][]InlineKeyboardButton{{{Text: "Approve", CallbackData: "approve x"}}},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if path != "/bot123:abc/sendMessage" {
+		t.Errorf("unexpected path: %s", path)
+	}
+	if req.ChatID != "-100" || req.Text != "hello" {
+		t.Errorf("unexpected request: %+v", req)
+	}
+	if req.ReplyMarkup == nil || req.ReplyMarkup.InlineKeyboard[0][0].CallbackData != "approve x" {
+		t.Errorf("missing keyboard: %+v", req.ReplyMarkup)
+	}
+}
+
+func TestGetUpdates(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"ok": true, "result": [
+			{"update_id": 10, "message": {"message_id": 1, "from": {"id": 1, "username": "alice"}, "chat": {"id": -100, "type": "group"}, "text": "/approvals"}},
+			{"update_id": 11, "callback_query": {"id": "cb", "from": {"id": 2, "first_name": "Bob"}, "data": "approve default/wd:1.2.0"}}
+		]}`))
+	}))
+	defer ts.Close()
+
+	updates, err := NewWithURL("t", ts.URL).GetUpdates(context.Background(), 0, time.Second)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if len(updates) != 2 {
+		t.Fatalf("expected 2 updates, got: %d", len(updates))
+	}
+	if updates[0].Message.From.Name() != "alice" || updates[0].Message.Chat.ID != -100 {
+		t.Errorf("unexpected message: %+v", updates[0].Message)
+	}
+	if updates[1].CallbackQuery.From.Name() != "Bob" || updates[1].CallbackQuery.Data != "approve default/wd:1.2.0" {
+		t.Errorf("unexpected callback query: %+v", updates[1].CallbackQuery)
+	}
+}
+
+func TestCallError(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusUnauthorized)
+		w.Write([]byte(`{"ok": false, "error_code": 401, "description": "Unauthorized"}`))
+	}))
+	defer ts.Close()
+
+	err := NewWithURL("secret-token", ts.URL).SendMessage(context.Background(), "1", "hi", nil)
+	if err == nil || !strings.Contains(err.Error(), "Unauthorized") {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if strings.Contains(err.Error(), "secret-token") {
+		t.Errorf("token leaked in error: %s", err)
+	}
+}
@@ -0,0 +1,131 @@
+// Package tracing sets up OpenTelemetry tracing. Spans are exported via OTLP
+// configured through the standard OTEL_EXPORTER_OTLP_* environment variables,
+// when these are not set - tracing is a no-op.
+package tracing
+
+import (
+	"context"
+	"net/http"
+	"os"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp"
+	"go.opentelemetry.io/otel/exporters/otlp/otlpgrpc"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/semconv"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/keel-hq/keel/types"
+	"github.com/keel-hq/keel/version"
+)
+
+// standard OTLP exporter env variables, tracing is enabled
+// when either of them is set
+const (
+	EnvOTLPEndpoint       = "OTEL_EXPORTER_OTLP_ENDPOINT"
+	EnvOTLPTracesEndpoint = "OTEL_EXPORTER_OTLP_TRACES_ENDPOINT"
+)
+
+const instrumentationName = "github.com/keel-hq/keel"
+
+func init() {
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
+}
+
+// Enabled - returns true if OTLP exporter is configured
+func Enabled() bool {
+	return os.Getenv(EnvOTLPEndpoint) != "" || os.Getenv(EnvOTLPTracesEndpoint) != ""
+}
+
+// Setup - registers global tracer provider that exports spans via OTLP. Returned
+// shutdown function flushes remaining spans.
+func Setup(ctx context.Context) (shutdown func(context.Context) error, err error) {
+	exporter, err := otlp.NewExporter(ctx, otlpgrpc.NewDriver())
+	if err != nil {
+		return nil, err
+	}
+
+	tp := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(resource.NewWithAttributes(
+			semconv.ServiceNameKey.String("keel"),
+			semconv.ServiceVersionKey.String(version.GetKeelVersion().Version),
+		)),
+	)
+	otel.SetTracerProvider(tp)
+
+	return tp.Shutdown, nil
+}
+
+// Start - starts a new span, if tracing is not configured - span is a no-op
+func Start(ctx context.Context, name string, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	return otel.Tracer(instrumentationName).Start(ctx, name, trace.WithAttributes(attrs...))
+}
+
+// StartEvent - starts a span for processing the event, continuing the trace of
+// the trigger that created it
+func StartEvent(name string, event *types.Event) (context.Context, trace.Span) {
+	return Start(Extract(context.Background(), event.TraceContext), name,
+		attribute.String("image", event.Repository.Name),
+		attribute.String("tag", event.Repository.Tag),
+		attribute.String("trigger", event.TriggerName),
+	)
+}
+
+// End - records error (if any) and ends the span
+func End(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
+
+// Inject - serializes span context so it can travel together with events
+func Inject(ctx context.Context) map[string]string {
+	carrier := Carrier{}
+	otel.GetTextMapPropagator().Inject(ctx, carrier)
+	if len(carrier) == 0 {
+		return nil
+	}
+	return carrier
+}
+
+// Extract - restores span context that was injected into the event
+func Extract(ctx context.Context, traceContext map[string]string) context.Context {
+	if len(traceContext) == 0 {
+		return ctx
+	}
+	return otel.GetTextMapPropagator().Extract(ctx, Carrier(traceContext))
+}
+
+// ExtractHTTP - restores span context propagated by the caller via request headers
+func ExtractHTTP(ctx context.Context, header http.Header) context.Context {
+	return otel.GetTextMapPropagator().Extract(ctx, propagation.HeaderCarrier(header))
+}
+
+// Carrier - map based propagation carrier
+type Carrier map[string]string
+
+// Get returns the value associated with the passed key.
+func (c Carrier) Get(key string) string {
+	return c[key]
+}
+
+// Set stores the key-value pair.
+func (c Carrier) Set(key, value string) {
+	c[key] = value
+}
+
+// Keys lists the keys stored in this carrier.
+func (c Carrier) Keys() []string {
+	keys := make([]string, 0, len(c))
+	for k := range c {
+		keys = append(keys, k)
+	}
+	return keys
+}
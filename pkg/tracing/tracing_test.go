@@ -0,0 +1,114 @@
+package tracing
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/keel-hq/keel/types"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func recordSpans(t *testing.T) *tracetest.InMemoryExporter {
+	exporter := tracetest.NewInMemoryExporter()
+	previous := otel.GetTracerProvider()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter)))
+	t.Cleanup(func() { otel.SetTracerProvider(previous) })
+	return exporter
+}
+
+func TestInjectExtract(t *testing.T) {
+	recordSpans(t)
+
+	ctx, span := Start(context.Background(), "trigger")
+	defer span.End()
+
+	traceContext := Inject(ctx)
+	if traceContext["traceparent"] == "" {
+		t.Fatalf("expected traceparent to be injected, got: %v", traceContext)
+	}
+
+	sc := trace.SpanContextFromContext(Extract(context.Background(), traceContext))
+	if !sc.IsRemote() {
+		t.Errorf("expected extracted span context to be remote")
+	}
+	if sc.TraceID() != span.SpanContext().TraceID() {
+		t.Errorf("expected trace ID %s, got: %s", span.SpanContext().TraceID(), sc.TraceID())
+	}
+	if sc.SpanID() != span.SpanContext().SpanID() {
+		t.Errorf("expected span ID %s, got: %s", span.SpanContext().SpanID(), sc.SpanID())
+	}
+}
+
+func TestInjectWithoutSpan(t *testing.T) {
+	recordSpans(t)
+
+	if traceContext := Inject(context.Background()); traceContext != nil {
+		t.Errorf("expected no trace context, got: %v", traceContext)
+	}
+
+	ctx := context.Background()
+	if Extract(ctx, nil) != ctx {
+		t.Errorf("expected context to be returned as is")
+	}
+}
+
+func TestExtractHTTP(t *testing.T) {
+	exporter := recordSpans(t)
+
+	header := http.Header{}
+	header.Set("traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+
+	_, span := Start(ExtractHTTP(context.Background(), header), "webhook")
+	End(span, errors.New("boom"))
+
+	spans := exporter.GetSpans()
+	if len(spans) != 1 {
+		t.Fatalf("expected 1 span, got: %d", len(spans))
+	}
+	if spans[0].Parent.TraceID().String() != "4bf92f3577b34da6a3ce929d0e0e4736" {
+		t.Errorf("unexpected trace ID: %s", spans[0].Parent.TraceID())
+	}
+	if spans[0].Parent.SpanID().String() != "00f067aa0ba902b7" {
+		t.Errorf("unexpected parent span ID: %s", spans[0].Parent.SpanID())
+	}
+	if spans[0].StatusCode != codes.Error {
+		t.Errorf("expected error status, got: %s", spans[0].StatusCode)
+	}
+}
+
+func TestStartEvent(t *testing.T) {
+	exporter := recordSpans(t)
+
+	ctx, trigger := Start(context.Background(), "poll.watch_tag")
+	trigger.End()
+
+	_, span := StartEvent("provider.process_event", &types.Event{
+		Repository:   types.Repository{Name: "gcr.io/v2-namespace/hello-world", Tag: "1.1.0"},
+		TriggerName:  "poll",
+		TraceContext: Inject(ctx),
+	})
+	span.End()
+
+	spans := exporter.GetSpans()
+	if len(spans) != 2 {
+		t.Fatalf("expected 2 spans, got: %d", len(spans))
+	}
+	if spans[1].Parent.SpanID() != trigger.SpanContext().SpanID() {
+		t.Errorf("expected event span to be a child of the trigger span")
+	}
+
+	attrs := map[string]string{}
+	for _, kv := range spans[1].Attributes {
+		attrs[string(kv.Key)] = kv.Value.AsString()
+	}
+	if attrs["image"] != "gcr.io/v2-namespace/hello-world" || attrs["tag"] != "1.1.0" || attrs["trigger"] != "poll" {
+		t.Errorf("unexpected span attributes: %v", attrs)
+	}
+}
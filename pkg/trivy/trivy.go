@@ -0,0 +1,183 @@
+// Package trivy scans images for vulnerabilities with the trivy binary, which
+// has to be available in PATH. Scans either run locally (embedded vulnerability
+// database) or against a trivy server.
+package trivy
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os/exec"
+	"sort"
+	"strings"
+	"time"
+)
+
+// DefaultTimeout - max duration of a single scan
+const DefaultTimeout = 5 * time.Minute
+
+// Severities - trivy severities, lowest first
+var Severities = []string{"UNKNOWN", "LOW", "MEDIUM", "HIGH", "CRITICAL"}
+
+func severityRank(severity string) int {
+	for i, s := range Severities {
+		if s == strings.ToUpper(severity) {
+			return i
+		}
+	}
+	return 0
+}
+
+// Opts - scanner configuration
+type Opts struct {
+	// trivy server URL, scans run locally if empty
+	Server string
+	// vulnerabilities of this or higher severity block updates, defaults to HIGH
+	Severity string
+	// ignore vulnerabilities without a fix available
+	IgnoreUnfixed bool
+
+	Timeout time.Duration
+}
+
+// Vulnerability - single finding
+type Vulnerability struct {
+	VulnerabilityID  string `json:"VulnerabilityID"`
+	PkgName          string `json:"PkgName"`
+	InstalledVersion string `json:"InstalledVersion"`
+	FixedVersion     string `json:"FixedVersion"`
+	Severity         string `json:"Severity"`
+	Title            string `json:"Title"`
+}
+
+type result struct {
+	Target          string          `json:"Target"`
+	Vulnerabilities []Vulnerability `json:"Vulnerabilities"`
+}
+
+type output struct {
+	Results []result `json:"Results"`
+}
+
+// Report - vulnerabilities at or above the configured severity, most severe first
+type Report struct {
+	Image           string
+	Severity        string
+	Vulnerabilities []Vulnerability
+}
+
+// Passed - whether image has no blocking vulnerabilities
+func (r *Report) Passed() bool {
+	return len(r.Vulnerabilities) == 0
+}
+
+// Summary - vulnerability counts by severity, ie: "CRITICAL: 1, HIGH: 3"
+func (r *Report) Summary() string {
+	counts := make(map[string]int)
+	for _, v := range r.Vulnerabilities {
+		counts[v.Severity]++
+	}
+	var parts []string
+	for i := len(Severities) - 1; i >= 0; i-- {
+		if counts[Severities[i]] > 0 {
+			parts = append(parts, fmt.Sprintf("%s: %d", Severities[i], counts[Severities[i]]))
+		}
+	}
+	return strings.Join(parts, ", ")
+}
+
+// Details - up to max vulnerabilities, one per line
+func (r *Report) Details(max int) string {
+	var lines []string
+	for i, v := range r.Vulnerabilities {
+		if i == max {
+			lines = append(lines, fmt.Sprintf("... and %d more", len(r.Vulnerabilities)-max))
+			break
+		}
+		fixed := v.FixedVersion
+		if fixed == "" {
+			fixed = "no fix"
+		}
+		lines = append(lines, fmt.Sprintf("%s %s %s %s (%s)", v.Severity, v.VulnerabilityID, v.PkgName, v.InstalledVersion, fixed))
+	}
+	return strings.Join(lines, "\n")
+}
+
+// Scanner - scans images with trivy
+type Scanner struct {
+	opts   Opts
+	binary string
+}
+
+// New - creates a new scanner
+func New(opts Opts) (*Scanner, error) {
+	if opts.Severity == "" {
+		opts.Severity = "HIGH"
+	}
+	opts.Severity = strings.ToUpper(opts.Severity)
+	if severityRank(opts.Severity) == 0 && opts.Severity != "UNKNOWN" {
+		return nil, fmt.Errorf("invalid severity '%s', expected one of %s", opts.Severity, strings.Join(Severities, ", "))
+	}
+	if opts.Timeout == 0 {
+		opts.Timeout = DefaultTimeout
+	}
+	if _, err := exec.LookPath("trivy"); err != nil {
+		return nil, fmt.Errorf("trivy binary not found: %s", err)
+	}
+	return &Scanner{opts: opts, binary: "trivy"}, nil
+}
+
+// Scan - scans image, error is only returned if the scan couldn't be completed
+func (s *Scanner) Scan(ctx context.Context, image string) (*Report, error) {
+	ctx, cancel := context.WithTimeout(ctx, s.opts.Timeout)
+	defer cancel()
+
+	severities := Severities[severityRank(s.opts.Severity):]
+	args := []string{"image", "--quiet", "--format", "json", "--severity", strings.Join(severities, ",")}
+	if s.opts.Server != "" {
+		args = append(args, "--server", s.opts.Server)
+	}
+	if s.opts.IgnoreUnfixed {
+		args = append(args, "--ignore-unfixed")
+	}
+	args = append(args, image)
+
+	cmd := exec.CommandContext(ctx, s.binary, args...)
+	out, err := cmd.Output()
+	if err != nil {
+		if exitErr, ok := err.(*exec.ExitError); ok {
+			return nil, fmt.Errorf("trivy scan failed: %s: %s", err, strings.TrimSpace(string(exitErr.Stderr)))
+		}
+		return nil, fmt.Errorf("trivy scan failed: %s", err)
+	}
+
+	return parseReport(image, s.opts.Severity, out)
+}
+
+func parseReport(image, severity string, out []byte) (*Report, error) {
+	var parsed output
+	var err error
+	if trimmed := strings.TrimSpace(string(out)); strings.HasPrefix(trimmed, "[") {
+		// trivy before v0.20 reports a list of results
+		err = json.Unmarshal(out, &parsed.Results)
+	} else {
+		err = json.Unmarshal(out, &parsed)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse trivy output: %s", err)
+	}
+
+	report := &Report{Image: image, Severity: severity}
+	min := severityRank(severity)
+	for _, r := range parsed.Results {
+		for _, v := range r.Vulnerabilities {
+			if severityRank(v.Severity) >= min {
+				report.Vulnerabilities = append(report.Vulnerabilities, v)
+			}
+		}
+	}
+	sort.SliceStable(report.Vulnerabilities, func(i, j int) bool {
+		return severityRank(report.Vulnerabilities[i].Severity) > severityRank(report.Vulnerabilities[j].Severity)
+	})
+	return report, nil
+}
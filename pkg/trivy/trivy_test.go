@@ -0,0 +1,57 @@
+package trivy
+
+import (
+	"strings"
+	"testing"
+)
+
+const trivyOutput = `{
+  "SchemaVersion": 2,
+  "ArtifactName": "gcr.io/v2-namespace/hello-world:1.1.1",
+  "Results": [
+    {
+      "Target": "gcr.io/v2-namespace/hello-world:1.1.1 (alpine 3.12.0)",
+      "Vulnerabilities": [
+        {"VulnerabilityID": "CVE-2021-0001", "PkgName": "musl", "InstalledVersion": "1.1.24-r8", "FixedVersion": "1.1.24-r9", "Severity": "HIGH"},
+        {"VulnerabilityID": "CVE-2021-0002", "PkgName": "busybox", "InstalledVersion": "1.31.1-r16", "Severity": "MEDIUM"},
+        {"VulnerabilityID": "CVE-2021-0003", "PkgName": "openssl", "InstalledVersion": "1.1.1g-r0", "FixedVersion": "1.1.1i-r0", "Severity": "CRITICAL"}
+      ]
+    },
+    {
+      "Target": "app/go.sum"
+    }
+  ]
+}`
+
+func TestParseReport(t *testing.T) {
+	report, err := parseReport("gcr.io/v2-namespace/hello-world:1.1.1", "HIGH", []byte(trivyOutput))
+	if err != nil {
+		t.Fatalf("failed to parse report: %s", err)
+	}
+
+	if report.Passed() {
+		t.Fatalf("expected report with vulnerabilities to fail")
+	}
+	if report.Summary() != "CRITICAL: 1, HIGH: 1" {
+		t.Errorf("unexpected summary: %s", report.Summary())
+	}
+
+	details := strings.Split(report.Details(1), "\n")
+	if len(details) != 2 {
+		t.Fatalf("expected 1 vulnerability and a count of the rest, got: %v", details)
+	}
+	if details[0] != "CRITICAL CVE-2021-0003 openssl 1.1.1g-r0 (1.1.1i-r0)" {
+		t.Errorf("expected most severe vulnerability first, got: %s", details[0])
+	}
+	if details[1] != "... and 1 more" {
+		t.Errorf("unexpected details: %s", details[1])
+	}
+
+	report, err = parseReport("gcr.io/v2-namespace/hello-world:1.1.1", "CRITICAL", []byte(`[{"Target": "x", "Vulnerabilities": null}]`))
+	if err != nil {
+		t.Fatalf("failed to parse legacy report: %s", err)
+	}
+	if !report.Passed() {
+		t.Errorf("expected image without vulnerabilities to pass")
+	}
+}
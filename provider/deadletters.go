@@ -0,0 +1,59 @@
+package provider
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/keel-hq/keel/types"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// DeadLetterStore - keeps events that couldn't be applied
+type DeadLetterStore interface {
+	CreateDeadLetter(deadLetter *types.DeadLetter) (*types.DeadLetter, error)
+	GetDeadLetter(id string) (*types.DeadLetter, error)
+	ListDeadLetters() ([]*types.DeadLetter, error)
+	DeleteDeadLetter(deadLetter *types.DeadLetter) error
+}
+
+// ReplayDeadLetter - submits stored event again and removes it from the store,
+// previous failures of the resource don't hold the replayed update back
+func ReplayDeadLetter(store DeadLetterStore, providers Providers, id string) (*types.DeadLetter, error) {
+	deadLetter, err := store.GetDeadLetter(id)
+	if err != nil {
+		return nil, err
+	}
+	if deadLetter.Event == nil {
+		return nil, fmt.Errorf("dead letter %s has no event", id)
+	}
+
+	event := *deadLetter.Event
+	event.TriggerName = types.TriggerNameReplay
+	event.CreatedAt = time.Now()
+
+	err = providers.Submit(event)
+	if err != nil {
+		return nil, err
+	}
+
+	err = store.DeleteDeadLetter(deadLetter)
+	if err != nil {
+		log.WithFields(log.Fields{
+			"error": err,
+			"id":    id,
+		}).Error("provider.ReplayDeadLetter: event replayed but failed to remove it from the store")
+	}
+
+	return deadLetter, nil
+}
+
+// DiscardDeadLetter - removes stored event without applying it
+func DiscardDeadLetter(store DeadLetterStore, id string) (*types.DeadLetter, error) {
+	deadLetter, err := store.GetDeadLetter(id)
+	if err != nil {
+		return nil, err
+	}
+
+	return deadLetter, store.DeleteDeadLetter(deadLetter)
+}
@@ -0,0 +1,472 @@
+// Package executor lets external update executors (ie: Nomad, ECS or custom
+// CRDs) receive policy evaluated updates from keel. Executors implement the
+// Executor gRPC service (see executor.proto, ExecutorServer and
+// RegisterExecutorServer for Go implementations), report the resources they
+// manage with keel.sh/* labels and annotations and apply updates keel sends
+// them. Keel takes care of triggers, policies, approvals and notifications.
+package executor
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/rusenask/cron"
+
+	"github.com/keel-hq/keel/approvals"
+	"github.com/keel-hq/keel/extension/notification"
+	"github.com/keel-hq/keel/internal/policy"
+	"github.com/keel-hq/keel/pkg/store"
+	"github.com/keel-hq/keel/types"
+	"github.com/keel-hq/keel/util/image"
+	"github.com/keel-hq/keel/util/policies"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	log "github.com/sirupsen/logrus"
+)
+
+var executorUpdatesCounter = prometheus.NewCounterVec(
+	prometheus.CounterOpts{
+		Name: "executor_updates_total",
+		Help: "How many updates were sent to external executors, partitioned by executor and result.",
+	},
+	[]string{"executor", "result"},
+)
+
+func init() {
+	prometheus.MustRegister(executorUpdatesCounter)
+}
+
+// ProviderName - executor providers are registered as executor/<name>
+const ProviderName = "executor"
+
+// Executor call timeouts
+const (
+	ListTimeout   = 30 * time.Second
+	UpdateTimeout = 10 * time.Minute
+)
+
+// Provider - forwards updates of the resources reported by an external
+// executor
+type Provider struct {
+	name   string
+	client ExecutorClient
+
+	sender notification.Sender
+
+	approvalManager approvals.Manager
+
+	// updates are only reported, see SetDryRun
+	dryRun bool
+
+	events chan *types.Event
+	stop   chan struct{}
+	// held while an event is processed, Stop waits for it
+	processing sync.Mutex
+}
+
+// NewProvider - create new executor provider, name identifies the executor in
+// provider names, approvals and notifications
+func NewProvider(name string, client ExecutorClient, sender notification.Sender, approvalManager approvals.Manager) *Provider {
+	return &Provider{
+		name:            name,
+		client:          client,
+		sender:          sender,
+		approvalManager: approvalManager,
+		events:          make(chan *types.Event, 100),
+		stop:            make(chan struct{}),
+	}
+}
+
+// SetDryRun - when enabled, updates are reported but never sent to the executor
+func (p *Provider) SetDryRun(dryRun bool) {
+	p.dryRun = dryRun
+}
+
+// GetName - get provider name
+func (p *Provider) GetName() string {
+	return ProviderName + "/" + p.name
+}
+
+// Submit - submit event to provider
+func (p *Provider) Submit(event types.Event) error {
+	p.events <- &event
+	return nil
+}
+
+// Start - starts executor provider, waits for events
+func (p *Provider) Start() error {
+	for {
+		select {
+		case event := <-p.events:
+			p.processing.Lock()
+			select {
+			case <-p.stop:
+				p.processing.Unlock()
+				log.WithField("executor", p.name).Info("provider.executor: got shutdown signal, stopping...")
+				return nil
+			default:
+			}
+			err := p.processEvent(event)
+			p.processing.Unlock()
+			if err != nil {
+				log.WithFields(log.Fields{
+					"error":    err,
+					"executor": p.name,
+					"image":    event.Repository.Name,
+					"tag":      event.Repository.Tag,
+				}).Error("provider.executor: failed to process event")
+			}
+		case <-p.stop:
+			log.WithField("executor", p.name).Info("provider.executor: got shutdown signal, stopping...")
+			return nil
+		}
+	}
+}
+
+// Stop - stops executor provider, waits for the update in progress
+func (p *Provider) Stop() {
+	close(p.stop)
+
+	p.processing.Lock()
+	defer p.processing.Unlock()
+}
+
+func (p *Provider) resources() ([]*Resource, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), ListTimeout)
+	defer cancel()
+
+	resp, err := p.client.ListResources(ctx, &ListResourcesRequest{})
+	if err != nil {
+		return nil, err
+	}
+	return resp.Resources, nil
+}
+
+// TrackedImages - returns images of the executor resources that have a policy
+func (p *Provider) TrackedImages() ([]*types.TrackedImage, error) {
+	resources, err := p.resources()
+	if err != nil {
+		return nil, err
+	}
+
+	var trackedImages []*types.TrackedImage
+	for _, resource := range resources {
+		plc := policy.GetPolicyFromLabelsOrAnnotations(resource.Labels, resource.Annotations)
+		if plc.Type() == policy.PolicyTypeNone {
+			continue
+		}
+
+		schedule, ok := resource.Annotations[types.KeelPollScheduleAnnotation]
+		if ok {
+			if _, err := cron.Parse(schedule); err != nil {
+				log.WithFields(log.Fields{
+					"error":      err,
+					"schedule":   schedule,
+					"executor":   p.name,
+					"identifier": resource.Identifier,
+				}).Error("provider.executor: failed to parse poll schedule, setting default schedule")
+				schedule = types.KeelPollDefaultSchedule
+			}
+		} else {
+			schedule = types.KeelPollDefaultSchedule
+		}
+
+		trigger := policies.GetTriggerPolicy(resource.Labels, resource.Annotations)
+
+		for _, img := range resource.Images {
+			ref, err := image.Parse(img)
+			if err != nil {
+				log.WithFields(log.Fields{
+					"error":      err,
+					"image":      img,
+					"executor":   p.name,
+					"identifier": resource.Identifier,
+				}).Error("provider.executor: failed to parse image")
+				continue
+			}
+
+			trackedImages = append(trackedImages, &types.TrackedImage{
+				Image:           ref,
+				PollSchedule:    schedule,
+				Trigger:         trigger,
+				Provider:        ProviderName,
+				Namespace:       resource.Namespace,
+				Identifier:      resource.Identifier,
+				Secrets:         resource.ImagePullSecrets,
+				PollCredentials: resource.Annotations[types.KeelPollCredentialsAnnotation],
+				Meta:            map[string]string{"executor": p.name},
+				Policy:          plc,
+				TagOrder:        resource.Annotations[types.KeelTagOrderAnnotation],
+			})
+		}
+	}
+
+	return trackedImages, nil
+}
+
+// updatePlan - update of a single executor resource
+type updatePlan struct {
+	resource       *Resource
+	images         []*ImageUpdate
+	currentVersion string
+	newVersion     string
+	// set once approval was requested, archived after the update
+	approval string
+}
+
+func (p *Provider) processEvent(event *types.Event) error {
+	// forced updates and chart events target kubernetes resources and
+	// helm releases
+	if event.Target != "" || event.Chart != "" {
+		return nil
+	}
+
+	resources, err := p.resources()
+	if err != nil {
+		return fmt.Errorf("failed to list executor resources: %s", err)
+	}
+
+	plans := p.createUpdatePlans(&event.Repository, resources)
+	for _, plan := range plans {
+		approved, err := p.isApproved(event, plan)
+		if err != nil {
+			log.WithFields(log.Fields{
+				"error":      err,
+				"executor":   p.name,
+				"identifier": plan.resource.Identifier,
+				"version":    plan.newVersion,
+			}).Error("provider.executor: failed to check approval status")
+			continue
+		}
+		if !approved {
+			continue
+		}
+		p.update(event, plan)
+	}
+	return nil
+}
+
+func (p *Provider) createUpdatePlans(repo *types.Repository, resources []*Resource) []*updatePlan {
+	eventRef, err := image.Parse(repo.String())
+	if err != nil {
+		log.WithFields(log.Fields{
+			"error":      err,
+			"repository": repo.Name,
+		}).Error("provider.executor: failed to parse event repository name")
+		return nil
+	}
+
+	var plans []*updatePlan
+	for _, resource := range resources {
+		plc := policy.GetPolicyFromLabelsOrAnnotations(resource.Labels, resource.Annotations)
+		if plc.Type() == policy.PolicyTypeNone {
+			continue
+		}
+
+		plan := &updatePlan{resource: resource, newVersion: eventRef.Tag()}
+		for _, img := range resource.Images {
+			ref, err := image.Parse(img)
+			if err != nil || ref.Repository() != eventRef.Repository() {
+				continue
+			}
+
+			shouldUpdate, err := plc.ShouldUpdate(ref.Tag(), eventRef.Tag())
+			if err != nil {
+				log.WithFields(log.Fields{
+					"error":      err,
+					"executor":   p.name,
+					"identifier": resource.Identifier,
+					"image":      img,
+					"policy":     plc.Name(),
+				}).Error("provider.executor: failed to check whether image should be updated")
+				continue
+			}
+			if !shouldUpdate {
+				continue
+			}
+
+			newImage := ref.Repository() + ":" + eventRef.Tag()
+			if ref.Registry() == image.DefaultRegistryHostname {
+				newImage = ref.ShortName() + ":" + eventRef.Tag()
+			}
+			plan.images = append(plan.images, &ImageUpdate{Current: img, New: newImage})
+			plan.currentVersion = ref.Tag()
+		}
+
+		if len(plan.images) > 0 {
+			plans = append(plans, plan)
+		}
+	}
+	return plans
+}
+
+// identifier - <executor provider>/<resource identifier>:<version>
+func (p *Provider) identifier(plan *updatePlan) string {
+	return fmt.Sprintf("%s/%s:%s", p.GetName(), plan.resource.Identifier, plan.newVersion)
+}
+
+func (p *Provider) isApproved(event *types.Event, plan *updatePlan) (bool, error) {
+	resource := plan.resource
+	minApprovals := 0
+	if val, ok := resource.Annotations[types.KeelMinimumApprovalsLabel]; ok {
+		n, err := strconv.Atoi(val)
+		if err != nil {
+			return false, fmt.Errorf("invalid minimum approvals '%s'", val)
+		}
+		minApprovals = n
+	}
+	if minApprovals == 0 || p.approvalManager == nil {
+		return true, nil
+	}
+
+	identifier := p.identifier(plan)
+	plan.approval = identifier
+	existing, err := p.approvalManager.Get(identifier)
+	if err == nil {
+		return existing.Status() == types.ApprovalStatusApproved, nil
+	}
+	if err != store.ErrRecordNotFound {
+		return false, err
+	}
+
+	// approval fulfillment for an approval that doesn't exist anymore,
+	// requesting it again would loop
+	if event.TriggerName == types.TriggerTypeApproval.String() {
+		return false, nil
+	}
+
+	deadline := time.Duration(types.KeelApprovalDeadlineDefault) * time.Hour
+	if val, ok := resource.Annotations[types.KeelApprovalDeadlineLabel]; ok {
+		if hours, err := strconv.Atoi(val); err == nil {
+			deadline = time.Duration(hours) * time.Hour
+		} else if d, err := time.ParseDuration(val); err == nil && d > 0 {
+			deadline = d
+		}
+	}
+
+	approval := &types.Approval{
+		Provider:       types.ProviderTypeExecutor,
+		Identifier:     identifier,
+		Event:          event,
+		CurrentVersion: plan.currentVersion,
+		NewVersion:     plan.newVersion,
+		VotesRequired:  minApprovals,
+		Deadline:       time.Now().Add(deadline),
+		Channel:        resource.Annotations[types.KeelApprovalsChannelAnnotation],
+		Approvers:      resource.Annotations[types.KeelApproversAnnotation],
+	}
+	approval.Message = fmt.Sprintf("New image is available for %s %s (%s).", p.name, resource.Identifier, approval.Delta())
+
+	return false, p.approvalManager.Create(approval)
+}
+
+func (p *Provider) update(event *types.Event, plan *updatePlan) {
+	resource := plan.resource
+	var images []string
+	for _, img := range plan.images {
+		images = append(images, img.New)
+	}
+
+	notificationChannels := types.ParseEventNotificationChannelsFromLabelsOrAnnotations(resource.Labels, resource.Annotations)
+	notificationLevel := types.ParseEventNotificationLevel(resource.Labels, resource.Annotations)
+	metadata := map[string]string{
+		"provider":  p.GetName(),
+		"namespace": resource.Namespace,
+		"name":      resource.Name,
+	}
+
+	if p.dryRun {
+		log.WithFields(log.Fields{
+			"executor":   p.name,
+			"identifier": resource.Identifier,
+			"update":     fmt.Sprintf("%s->%s", plan.currentVersion, plan.newVersion),
+			"images":     images,
+		}).Info("provider.executor: dry run, update not sent to the executor")
+		return
+	}
+
+	p.sender.Send(types.EventNotification{
+		ResourceKind: resource.Kind,
+		Identifier:   resource.Identifier,
+		Name:         "preparing to update resource",
+		Message:      fmt.Sprintf("Preparing to update %s %s %s->%s (%s)", p.name, resource.Identifier, plan.currentVersion, plan.newVersion, strings.Join(images, ", ")),
+		CreatedAt:    time.Now(),
+		Type:         types.NotificationPreDeploymentUpdate,
+		Level:        types.LevelDebug,
+		Channels:     notificationChannels,
+		MinLevel:     notificationLevel,
+		Metadata:     metadata,
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), UpdateTimeout)
+	defer cancel()
+	resp, err := p.client.Update(ctx, &UpdateRequest{
+		Resource:       resource,
+		Images:         plan.images,
+		CurrentVersion: plan.currentVersion,
+		NewVersion:     plan.newVersion,
+		Trigger:        event.TriggerName,
+	})
+	if err != nil {
+		executorUpdatesCounter.With(prometheus.Labels{"executor": p.name, "result": "failed"}).Inc()
+		log.WithFields(log.Fields{
+			"error":      err,
+			"executor":   p.name,
+			"identifier": resource.Identifier,
+			"update":     fmt.Sprintf("%s->%s", plan.currentVersion, plan.newVersion),
+		}).Error("provider.executor: executor failed to update resource")
+
+		p.sender.Send(types.EventNotification{
+			ResourceKind: resource.Kind,
+			Identifier:   resource.Identifier,
+			Name:         "update resource",
+			Message:      fmt.Sprintf("%s %s update %s->%s failed, error: %s", p.name, resource.Identifier, plan.currentVersion, plan.newVersion, err),
+			CreatedAt:    time.Now(),
+			Type:         types.NotificationDeploymentUpdate,
+			Level:        types.LevelError,
+			Channels:     notificationChannels,
+			MinLevel:     notificationLevel,
+			Metadata:     metadata,
+		})
+		return
+	}
+	executorUpdatesCounter.With(prometheus.Labels{"executor": p.name, "result": "updated"}).Inc()
+
+	if plan.approval != "" {
+		if err := p.approvalManager.Archive(plan.approval); err != nil {
+			log.WithFields(log.Fields{
+				"error":      err,
+				"executor":   p.name,
+				"identifier": resource.Identifier,
+			}).Warn("provider.executor: failed to archive approval")
+		}
+	}
+
+	msg := fmt.Sprintf("Successfully updated %s %s %s->%s (%s)", p.name, resource.Identifier, plan.currentVersion, plan.newVersion, strings.Join(images, ", "))
+	if resp.Message != "" {
+		msg = msg + ". " + resp.Message
+	}
+	log.WithFields(log.Fields{
+		"executor":   p.name,
+		"identifier": resource.Identifier,
+		"update":     fmt.Sprintf("%s->%s", plan.currentVersion, plan.newVersion),
+	}).Info("provider.executor: resource updated")
+
+	p.sender.Send(types.EventNotification{
+		ResourceKind: resource.Kind,
+		Identifier:   resource.Identifier,
+		Name:         "update resource",
+		Message:      msg,
+		CreatedAt:    time.Now(),
+		Type:         types.NotificationDeploymentUpdate,
+		Level:        types.LevelSuccess,
+		Channels:     notificationChannels,
+		MinLevel:     notificationLevel,
+		Metadata:     metadata,
+	})
+}
@@ -0,0 +1,279 @@
+package executor
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"log"
+	"net"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"google.golang.org/grpc"
+
+	"github.com/keel-hq/keel/approvals"
+	"github.com/keel-hq/keel/extension/notification"
+	"github.com/keel-hq/keel/pkg/store/sql"
+	"github.com/keel-hq/keel/types"
+)
+
+func newTestingUtils() (*sql.SQLStore, func()) {
+	dir, err := ioutil.TempDir("", "executorstoretest")
+	if err != nil {
+		log.Fatal(err)
+	}
+	tmpfn := filepath.Join(dir, "gorm.db")
+	store, err := sql.New(sql.Opts{DatabaseType: "sqlite3", URI: tmpfn})
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	teardown := func() {
+		os.RemoveAll(dir)
+	}
+
+	return store, teardown
+}
+
+type fakeSender struct {
+	sent []types.EventNotification
+}
+
+func (s *fakeSender) Configure(cfg *notification.Config) (bool, error) {
+	return true, nil
+}
+
+func (s *fakeSender) Send(event types.EventNotification) error {
+	s.sent = append(s.sent, event)
+	return nil
+}
+
+type fakeExecutor struct {
+	mu        sync.Mutex
+	resources []*Resource
+	updates   []*UpdateRequest
+	updateErr error
+}
+
+func (e *fakeExecutor) ListResources(ctx context.Context, req *ListResourcesRequest) (*ListResourcesResponse, error) {
+	return &ListResourcesResponse{Resources: e.resources}, nil
+}
+
+func (e *fakeExecutor) Update(ctx context.Context, req *UpdateRequest) (*UpdateResponse, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if e.updateErr != nil {
+		return nil, e.updateErr
+	}
+	e.updates = append(e.updates, req)
+	return &UpdateResponse{Message: "job re-planned"}, nil
+}
+
+// serve - runs executor over gRPC, updates go through the same
+// marshalling as with external executors
+func serve(t *testing.T, srv ExecutorServer) (ExecutorClient, func()) {
+	server := grpc.NewServer()
+	RegisterExecutorServer(server, srv)
+
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to listen: %s", err)
+	}
+	go server.Serve(lis)
+
+	conn, err := grpc.Dial(lis.Addr().String(), grpc.WithInsecure())
+	if err != nil {
+		t.Fatalf("failed to dial: %s", err)
+	}
+	return NewExecutorClient(conn), func() {
+		conn.Close()
+		server.Stop()
+	}
+}
+
+func nomadJob(annotations map[string]string) *Resource {
+	return &Resource{
+		Identifier:  "job/default/api",
+		Namespace:   "default",
+		Name:        "api",
+		Kind:        "job",
+		Images:      []string{"registry.example.com/api:1.4.0", "redis:5.0.0"},
+		Annotations: annotations,
+	}
+}
+
+func TestTrackedImages(t *testing.T) {
+	fe := &fakeExecutor{resources: []*Resource{
+		nomadJob(map[string]string{
+			types.KeelPolicyLabel:            "minor",
+			types.KeelTriggerLabel:           "poll",
+			types.KeelPollScheduleAnnotation: "@every 5m",
+		}),
+		{Identifier: "job/default/untracked", Images: []string{"nginx:1.19.0"}},
+	}}
+	client, teardown := serve(t, fe)
+	defer teardown()
+
+	p := NewProvider("nomad", client, &fakeSender{}, nil)
+
+	images, err := p.TrackedImages()
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if len(images) != 2 {
+		t.Fatalf("expected 2 tracked images, got: %d", len(images))
+	}
+	if images[0].Image.Remote() != "registry.example.com/api:1.4.0" {
+		t.Errorf("unexpected image: %s", images[0].Image.Remote())
+	}
+	if images[0].Trigger != types.TriggerTypePoll {
+		t.Errorf("unexpected trigger: %s", images[0].Trigger)
+	}
+	if images[0].PollSchedule != "@every 5m" {
+		t.Errorf("unexpected schedule: %s", images[0].PollSchedule)
+	}
+	if images[0].Identifier != "job/default/api" || images[0].Meta["executor"] != "nomad" {
+		t.Errorf("unexpected identifier or meta: %s, %v", images[0].Identifier, images[0].Meta)
+	}
+}
+
+func TestProcessEvent(t *testing.T) {
+	fe := &fakeExecutor{resources: []*Resource{
+		nomadJob(map[string]string{types.KeelPolicyLabel: "minor"}),
+	}}
+	client, teardown := serve(t, fe)
+	defer teardown()
+
+	sender := &fakeSender{}
+	p := NewProvider("nomad", client, sender, nil)
+
+	// major version is not allowed by the policy
+	err := p.processEvent(&types.Event{Repository: types.Repository{Name: "registry.example.com/api", Tag: "2.0.0"}})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if len(fe.updates) != 0 {
+		t.Fatalf("expected no updates, got: %d", len(fe.updates))
+	}
+
+	err = p.processEvent(&types.Event{Repository: types.Repository{Name: "registry.example.com/api", Tag: "1.5.0"}, TriggerName: "poll"})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if len(fe.updates) != 1 {
+		t.Fatalf("expected 1 update, got: %d", len(fe.updates))
+	}
+
+	update := fe.updates[0]
+	if update.Resource.Identifier != "job/default/api" {
+		t.Errorf("unexpected resource: %s", update.Resource.Identifier)
+	}
+	if update.CurrentVersion != "1.4.0" || update.NewVersion != "1.5.0" || update.Trigger != "poll" {
+		t.Errorf("unexpected update: %s->%s (%s)", update.CurrentVersion, update.NewVersion, update.Trigger)
+	}
+	if len(update.Images) != 1 || update.Images[0].Current != "registry.example.com/api:1.4.0" || update.Images[0].New != "registry.example.com/api:1.5.0" {
+		t.Errorf("unexpected images: %v", update.Images)
+	}
+
+	last := sender.sent[len(sender.sent)-1]
+	if last.Level != types.LevelSuccess || last.Metadata["provider"] != "executor/nomad" {
+		t.Errorf("unexpected notification: %s, %v", last.Level, last.Metadata)
+	}
+}
+
+func TestProcessEventFailed(t *testing.T) {
+	fe := &fakeExecutor{
+		resources: []*Resource{nomadJob(map[string]string{types.KeelPolicyLabel: "all"})},
+		updateErr: errors.New("allocation failed"),
+	}
+	client, teardown := serve(t, fe)
+	defer teardown()
+
+	sender := &fakeSender{}
+	p := NewProvider("nomad", client, sender, nil)
+
+	err := p.processEvent(&types.Event{Repository: types.Repository{Name: "registry.example.com/api", Tag: "1.5.0"}})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	last := sender.sent[len(sender.sent)-1]
+	if last.Level != types.LevelError {
+		t.Errorf("expected failed update notification, got: %s", last.Level)
+	}
+}
+
+func TestProcessEventApprovals(t *testing.T) {
+	fe := &fakeExecutor{resources: []*Resource{
+		nomadJob(map[string]string{
+			types.KeelPolicyLabel:           "all",
+			types.KeelMinimumApprovalsLabel: "1",
+		}),
+	}}
+	client, teardown := serve(t, fe)
+	defer teardown()
+
+	store, storeTeardown := newTestingUtils()
+	defer storeTeardown()
+	am := approvals.New(&approvals.Opts{Store: store})
+
+	p := NewProvider("nomad", client, &fakeSender{}, am)
+
+	event := &types.Event{Repository: types.Repository{Name: "registry.example.com/api", Tag: "1.5.0"}}
+	err := p.processEvent(event)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if len(fe.updates) != 0 {
+		t.Fatalf("expected update to wait for approval")
+	}
+
+	identifier := "executor/nomad/job/default/api:1.5.0"
+	approval, err := am.Get(identifier)
+	if err != nil {
+		t.Fatalf("failed to get approval: %s", err)
+	}
+	if approval.Provider != types.ProviderTypeExecutor || approval.VotesRequired != 1 {
+		t.Errorf("unexpected approval: %s, %d", approval.Provider, approval.VotesRequired)
+	}
+
+	_, err = am.Approve(identifier, "alice")
+	if err != nil {
+		t.Fatalf("failed to approve: %s", err)
+	}
+
+	event.TriggerName = types.TriggerTypeApproval.String()
+	err = p.processEvent(event)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if len(fe.updates) != 1 {
+		t.Fatalf("expected approved update, got: %d", len(fe.updates))
+	}
+
+	_, err = am.Get(identifier)
+	if err == nil {
+		t.Errorf("expected approval to be archived")
+	}
+}
+
+func TestProcessEventDryRun(t *testing.T) {
+	fe := &fakeExecutor{resources: []*Resource{
+		nomadJob(map[string]string{types.KeelPolicyLabel: "all"}),
+	}}
+	client, teardown := serve(t, fe)
+	defer teardown()
+
+	p := NewProvider("nomad", client, &fakeSender{}, nil)
+	p.SetDryRun(true)
+
+	err := p.processEvent(&types.Event{Repository: types.Repository{Name: "redis", Tag: "5.0.1"}})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if len(fe.updates) != 0 {
+		t.Errorf("expected no updates in dry run, got: %d", len(fe.updates))
+	}
+}
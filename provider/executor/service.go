@@ -0,0 +1,93 @@
+package executor
+
+//go:generate protoc --go_out=. --go_opt=paths=source_relative executor.proto
+
+import (
+	"context"
+
+	"google.golang.org/grpc"
+)
+
+// ServiceName - full name of the Executor service in executor.proto
+const ServiceName = "keel.executor.v1.Executor"
+
+// ExecutorServer - server side of the Executor service, implemented by
+// external executors written in Go
+type ExecutorServer interface {
+	ListResources(context.Context, *ListResourcesRequest) (*ListResourcesResponse, error)
+	Update(context.Context, *UpdateRequest) (*UpdateResponse, error)
+}
+
+// RegisterExecutorServer - registers Executor service implementation
+func RegisterExecutorServer(s *grpc.Server, srv ExecutorServer) {
+	s.RegisterService(&executorServiceDesc, srv)
+}
+
+// unaryHandler - decodes the request into a new message and passes it to
+// the method through the interceptor
+func unaryHandler(method string, newRequest func() interface{}, call func(srv ExecutorServer, ctx context.Context, req interface{}) (interface{}, error)) grpc.MethodDesc {
+	return grpc.MethodDesc{
+		MethodName: method,
+		Handler: func(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+			in := newRequest()
+			if err := dec(in); err != nil {
+				return nil, err
+			}
+			if interceptor == nil {
+				return call(srv.(ExecutorServer), ctx, in)
+			}
+			info := &grpc.UnaryServerInfo{
+				Server:     srv,
+				FullMethod: "/" + ServiceName + "/" + method,
+			}
+			handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+				return call(srv.(ExecutorServer), ctx, req)
+			}
+			return interceptor(ctx, in, info, handler)
+		},
+	}
+}
+
+var executorServiceDesc = grpc.ServiceDesc{
+	ServiceName: ServiceName,
+	HandlerType: (*ExecutorServer)(nil),
+	Methods: []grpc.MethodDesc{
+		unaryHandler("ListResources", func() interface{} { return new(ListResourcesRequest) }, func(srv ExecutorServer, ctx context.Context, req interface{}) (interface{}, error) {
+			return srv.ListResources(ctx, req.(*ListResourcesRequest))
+		}),
+		unaryHandler("Update", func() interface{} { return new(UpdateRequest) }, func(srv ExecutorServer, ctx context.Context, req interface{}) (interface{}, error) {
+			return srv.Update(ctx, req.(*UpdateRequest))
+		}),
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "executor.proto",
+}
+
+// ExecutorClient - client of the Executor service, used by the provider
+type ExecutorClient interface {
+	ListResources(ctx context.Context, in *ListResourcesRequest, opts ...grpc.CallOption) (*ListResourcesResponse, error)
+	Update(ctx context.Context, in *UpdateRequest, opts ...grpc.CallOption) (*UpdateResponse, error)
+}
+
+type executorClient struct {
+	cc *grpc.ClientConn
+}
+
+// NewExecutorClient - creates Executor service client
+func NewExecutorClient(cc *grpc.ClientConn) ExecutorClient {
+	return &executorClient{cc}
+}
+
+func (c *executorClient) invoke(ctx context.Context, method string, in, out interface{}, opts ...grpc.CallOption) error {
+	return c.cc.Invoke(ctx, "/"+ServiceName+"/"+method, in, out, opts...)
+}
+
+func (c *executorClient) ListResources(ctx context.Context, in *ListResourcesRequest, opts ...grpc.CallOption) (*ListResourcesResponse, error) {
+	out := new(ListResourcesResponse)
+	return out, c.invoke(ctx, "ListResources", in, out, opts...)
+}
+
+func (c *executorClient) Update(ctx context.Context, in *UpdateRequest, opts ...grpc.CallOption) (*UpdateResponse, error) {
+	out := new(UpdateResponse)
+	return out, c.invoke(ctx, "Update", in, out, opts...)
+}
@@ -111,10 +111,26 @@ type KeelChartConfig struct {
 	ApprovalDeadline     int               `json:"approvalDeadline"` // Deadline in hours
 	Images               []ImageDetails    `json:"images"`
 	NotificationChannels []string          `json:"notificationChannels"` // optional notification channels
+	NotificationLevel    string            `json:"notificationLevel"`    // optional minimum notification level
+	ExcludeTags          string            `json:"excludeTags"`          // optional globs or regexp: patterns of tags never updated to
+	VersionPrefix        string            `json:"versionPrefix"`        // optional "v" prefix handling: tolerate, match or reject
+	BuildMetadata        string            `json:"buildMetadata"`        // optional build metadata handling: ignore or order
 
 	Plc policy.Policy `json:"-"`
 }
 
+// notificationLevel - optional override of configured minimum notification level
+func (c *KeelChartConfig) notificationLevel() *types.Level {
+	if c.NotificationLevel == "" {
+		return nil
+	}
+	level, err := types.ParseLevel(c.NotificationLevel)
+	if err != nil {
+		return nil
+	}
+	return &level
+}
+
 // ImageDetails - image details
 type ImageDetails struct {
 	RepositoryPath  string `json:"repository"`
@@ -132,6 +148,9 @@ type Provider struct {
 
 	approvalManager approvals.Manager
 
+	// releases are only reported, see SetDryRun
+	dryRun bool
+
 	events chan *types.Event
 	stop   chan struct{}
 }
@@ -147,6 +166,11 @@ func NewProvider(implementer Implementer, sender notification.Sender, approvalMa
 	}
 }
 
+// SetDryRun - when enabled, release updates are reported but never applied
+func (p *Provider) SetDryRun(dryRun bool) {
+	p.dryRun = dryRun
+}
+
 // GetName - get provider name
 func (p *Provider) GetName() string {
 	return ProviderName
@@ -228,6 +252,7 @@ func (p *Provider) TrackedImages() ([]*types.TrackedImage, error) {
 			}
 			img.Namespace = release.Namespace
 			img.Provider = ProviderName
+			img.Identifier = fmt.Sprintf("%s/%s/%s", "chart", release.Namespace, release.Name)
 			trackedImages = append(trackedImages, img)
 		}
 
@@ -256,6 +281,11 @@ func (p *Provider) startInternal() error {
 }
 
 func (p *Provider) processEvent(event *types.Event) (err error) {
+	// chart updates are only supported by helm3 provider
+	if event.Chart != "" {
+		return nil
+	}
+
 	plans, err := p.createUpdatePlans(event)
 	if err != nil {
 		return err
@@ -309,6 +339,7 @@ func (p *Provider) applyPlans(plans []*UpdatePlan) error {
 			Type:         types.NotificationPreReleaseUpdate,
 			Level:        types.LevelDebug,
 			Channels:     plan.Config.NotificationChannels,
+			MinLevel:     plan.Config.notificationLevel(),
 			Metadata: map[string]string{
 				"provider":  p.GetName(),
 				"namespace": plan.Namespace,
@@ -316,6 +347,35 @@ func (p *Provider) applyPlans(plans []*UpdatePlan) error {
 			},
 		})
 
+		if p.dryRun {
+			log.WithFields(log.Fields{
+				"name":      plan.Name,
+				"namespace": plan.Namespace,
+				"update":    fmt.Sprintf("%s->%s", plan.CurrentVersion, plan.NewVersion),
+			}).Info("provider.helm: dry run, release not updated")
+
+			p.sender.Send(types.EventNotification{
+				ResourceKind: "chart",
+				Identifier:   fmt.Sprintf("%s/%s/%s", "chart", plan.Namespace, plan.Name),
+				Name:         "dry run",
+				Message:      fmt.Sprintf("Dry run: would update release %s/%s %s->%s (%s)", plan.Namespace, plan.Name, plan.CurrentVersion, plan.NewVersion, strings.Join(mapToSlice(plan.Values), ", ")),
+				CreatedAt:    time.Now(),
+				Type:         types.NotificationReleaseUpdate,
+				Level:        types.LevelInfo,
+				Channels:     plan.Config.NotificationChannels,
+				MinLevel:     plan.Config.notificationLevel(),
+				Metadata: map[string]string{
+					"provider":  p.GetName(),
+					"namespace": plan.Namespace,
+					"name":      plan.Name,
+					"previous":  plan.CurrentVersion,
+					"new":       plan.NewVersion,
+					"dryRun":    "true",
+				},
+			})
+			continue
+		}
+
 		err := updateHelmRelease(p.implementer, plan.Name, plan.Chart, plan.Values)
 		if err != nil {
 			log.WithFields(log.Fields{
@@ -333,6 +393,7 @@ func (p *Provider) applyPlans(plans []*UpdatePlan) error {
 				Type:         types.NotificationReleaseUpdate,
 				Level:        types.LevelError,
 				Channels:     plan.Config.NotificationChannels,
+				MinLevel:     plan.Config.notificationLevel(),
 				Metadata: map[string]string{
 					"provider":  p.GetName(),
 					"namespace": plan.Namespace,
@@ -367,6 +428,7 @@ func (p *Provider) applyPlans(plans []*UpdatePlan) error {
 			Type:         types.NotificationReleaseUpdate,
 			Level:        types.LevelSuccess,
 			Channels:     plan.Config.NotificationChannels,
+			MinLevel:     plan.Config.notificationLevel(),
 			Metadata: map[string]string{
 				"provider":  p.GetName(),
 				"namespace": plan.Namespace,
@@ -466,7 +528,7 @@ func getKeelConfig(vals chartutil.Values) (*KeelChartConfig, error) {
 
 	cfg := r.Keel
 
-	cfg.Plc = policy.GetPolicy(cfg.Policy, &policy.Options{MatchTag: cfg.MatchTag, MatchPreRelease: cfg.MatchPreRelease})
+	cfg.Plc = policy.GetPolicy(cfg.Policy, &policy.Options{MatchTag: cfg.MatchTag, MatchPreRelease: cfg.MatchPreRelease, ExcludeTags: cfg.ExcludeTags, VersionPrefix: cfg.VersionPrefix, BuildMetadata: cfg.BuildMetadata})
 
 	return &cfg, nil
 }
@@ -81,6 +81,13 @@ func (p *Provider) isApproved(event *types.Event, plan *UpdatePlan) (bool, error
 				plan.Name,
 				approval.Delta(),
 			)
+			if event.Chart != "" {
+				approval.Message = fmt.Sprintf("New chart version is available for release %s/%s (%s).",
+					plan.Namespace,
+					plan.Name,
+					approval.Delta(),
+				)
+			}
 
 			return false, p.approvalManager.Create(approval)
 		}
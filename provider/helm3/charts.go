@@ -0,0 +1,287 @@
+package helm3
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/keel-hq/keel/internal/policy"
+	"github.com/keel-hq/keel/provider"
+	"github.com/keel-hq/keel/types"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	log "github.com/sirupsen/logrus"
+	"sigs.k8s.io/yaml"
+
+	hapi_chart "helm.sh/helm/v3/pkg/chart"
+	"helm.sh/helm/v3/pkg/chart/loader"
+	"helm.sh/helm/v3/pkg/repo"
+)
+
+// keel:
+//   policy: minor
+//   # optional, upgrade the release when a new chart version is published
+//   chart:
+//     repository: https://charts.example.com
+//     # optional, defaults to keel policy
+//     policy: patch
+
+// ChartDetails - chart repository that is polled for new versions of the
+// release chart
+type ChartDetails struct {
+	Repository string `json:"repository"`
+	Policy     string `json:"policy"`
+}
+
+// ChartIndex - reads chart repositories
+type ChartIndex interface {
+	Index(repository string) (*repo.IndexFile, error)
+	Load(repository string, version *repo.ChartVersion) (*hapi_chart.Chart, error)
+}
+
+const chartIndexTimeout = 30 * time.Second
+
+// HTTPChartIndex - reads chart repositories served over http
+type HTTPChartIndex struct {
+	client *http.Client
+}
+
+// NewHTTPChartIndex - create new http chart repository reader
+func NewHTTPChartIndex() *HTTPChartIndex {
+	return &HTTPChartIndex{
+		client: &http.Client{Timeout: chartIndexTimeout},
+	}
+}
+
+// Index - get repository index, chart versions are sorted newest first
+func (c *HTTPChartIndex) Index(repository string) (*repo.IndexFile, error) {
+	body, err := c.get(strings.TrimSuffix(repository, "/") + "/index.yaml")
+	if err != nil {
+		return nil, err
+	}
+
+	var index repo.IndexFile
+	err = yaml.Unmarshal(body, &index)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse index of chart repository %s: %s", repository, err)
+	}
+	index.SortEntries()
+
+	return &index, nil
+}
+
+// Load - download and load chart archive
+func (c *HTTPChartIndex) Load(repository string, version *repo.ChartVersion) (*hapi_chart.Chart, error) {
+	if len(version.URLs) == 0 {
+		return nil, fmt.Errorf("chart %s-%s has no download URLs", version.Name, version.Version)
+	}
+
+	chartURL, err := repo.ResolveReferenceURL(repository, version.URLs[0])
+	if err != nil {
+		return nil, err
+	}
+
+	body, err := c.get(chartURL)
+	if err != nil {
+		return nil, err
+	}
+
+	return loader.LoadArchive(bytes.NewReader(body))
+}
+
+func (c *HTTPChartIndex) get(url string) ([]byte, error) {
+	resp, err := c.client.Get(url)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to get %s, status code: %d", url, resp.StatusCode)
+	}
+
+	return ioutil.ReadAll(resp.Body)
+}
+
+// chartPolicy - policy of chart versions, keel policy unless overridden
+func chartPolicy(cfg *KeelChartConfig) policy.Policy {
+	if cfg.Chart.Policy == "" {
+		return cfg.Plc
+	}
+	return policy.GetPolicy(cfg.Chart.Policy, &policy.Options{MatchPreRelease: cfg.MatchPreRelease, VersionPrefix: cfg.VersionPrefix, BuildMetadata: cfg.BuildMetadata})
+}
+
+func sameRepository(a, b string) bool {
+	return strings.TrimSuffix(a, "/") == strings.TrimSuffix(b, "/")
+}
+
+// WatchCharts - polls repositories of releases that track their chart every
+// interval and submits an event once a newer chart version allowed by the
+// policy is published, blocks until the provider is stopped
+func (p *Provider) WatchCharts(providers provider.Providers, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		p.checkCharts(providers)
+
+		select {
+		case <-p.stop:
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// checkCharts - submits an event for the newest allowed chart version of
+// every tracked release
+func (p *Provider) checkCharts(providers provider.Providers) {
+	releases, err := p.implementer.ListReleases()
+	if err != nil {
+		log.WithFields(log.Fields{
+			"error": err,
+		}).Error("provider.helm3: failed to list releases for chart updates")
+		return
+	}
+
+	indexes := make(map[string]*repo.IndexFile)
+	submitted := make(map[string]bool)
+
+	for _, release := range releases {
+		vals, err := values(release.Chart, release.Config)
+		if err != nil {
+			continue
+		}
+		cfg, err := getKeelConfig(vals)
+		if err != nil || cfg.Chart.Repository == "" {
+			continue
+		}
+
+		index, ok := indexes[cfg.Chart.Repository]
+		if !ok {
+			index, err = p.charts.Index(cfg.Chart.Repository)
+			if err != nil {
+				log.WithFields(log.Fields{
+					"error":      err,
+					"repository": cfg.Chart.Repository,
+				}).Error("provider.helm3: failed to get chart repository index")
+				continue
+			}
+			indexes[cfg.Chart.Repository] = index
+		}
+
+		name := release.Chart.Metadata.Name
+		current := release.Chart.Metadata.Version
+		plc := chartPolicy(cfg)
+
+		for _, version := range index.Entries[name] {
+			update, err := plc.ShouldUpdate(current, version.Version)
+			if err != nil || !update {
+				continue
+			}
+
+			key := cfg.Chart.Repository + "/" + name + ":" + version.Version
+			if submitted[key] {
+				break
+			}
+			submitted[key] = true
+
+			log.WithFields(log.Fields{
+				"chart":     name,
+				"release":   release.Name,
+				"namespace": release.Namespace,
+				"update":    fmt.Sprintf("%s->%s", current, version.Version),
+			}).Info("provider.helm3: new chart version available")
+
+			err = providers.Submit(types.Event{
+				Repository: types.Repository{
+					Name: name,
+					Tag:  version.Version,
+				},
+				Chart:       cfg.Chart.Repository,
+				CreatedAt:   time.Now(),
+				TriggerName: types.TriggerTypePoll.String(),
+			})
+			if err != nil {
+				log.WithFields(log.Fields{
+					"error": err,
+					"chart": name,
+				}).Error("provider.helm3: failed to submit chart event")
+			}
+			// versions are sorted, first allowed one is the newest
+			break
+		}
+	}
+}
+
+// createChartUpdatePlans - releases of the event chart that are upgraded to
+// the published chart version
+func (p *Provider) createChartUpdatePlans(event *types.Event) ([]*UpdatePlan, error) {
+	var plans []*UpdatePlan
+
+	releases, err := p.implementer.ListReleases()
+	if err != nil {
+		return nil, err
+	}
+
+	var newChart *hapi_chart.Chart
+	for _, release := range releases {
+		if release.Chart.Metadata.Name != event.Repository.Name {
+			continue
+		}
+
+		vals, err := values(release.Chart, release.Config)
+		if err != nil {
+			continue
+		}
+		cfg, err := getKeelConfig(vals)
+		if err != nil || !sameRepository(cfg.Chart.Repository, event.Chart) {
+			continue
+		}
+
+		current := release.Chart.Metadata.Version
+		update, err := chartPolicy(cfg).ShouldUpdate(current, event.Repository.Tag)
+		if err != nil || !update {
+			continue
+		}
+
+		if newChart == nil {
+			newChart, err = p.loadChart(event)
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		helm3VersionedUpdatesCounter.With(prometheus.Labels{"chart": fmt.Sprintf("%s/%s", release.Namespace, release.Name)}).Inc()
+		plans = append(plans, &UpdatePlan{
+			Namespace:      release.Namespace,
+			Name:           release.Name,
+			Config:         cfg,
+			Chart:          newChart,
+			Values:         make(map[string]string),
+			CurrentVersion: current,
+			NewVersion:     event.Repository.Tag,
+			EmptyConfig:    release.Config == nil,
+		})
+	}
+
+	return plans, nil
+}
+
+func (p *Provider) loadChart(event *types.Event) (*hapi_chart.Chart, error) {
+	index, err := p.charts.Index(event.Chart)
+	if err != nil {
+		return nil, err
+	}
+
+	version, err := index.Get(event.Repository.Name, event.Repository.Tag)
+	if err != nil {
+		return nil, fmt.Errorf("chart %s-%s not found in repository %s: %s", event.Repository.Name, event.Repository.Tag, event.Chart, err)
+	}
+
+	return p.charts.Load(event.Chart, version)
+}
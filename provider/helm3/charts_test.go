@@ -0,0 +1,142 @@
+package helm3
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/keel-hq/keel/provider"
+
+	"helm.sh/helm/v3/pkg/chart"
+	"helm.sh/helm/v3/pkg/release"
+	"helm.sh/helm/v3/pkg/repo"
+)
+
+type fakeChartIndex struct {
+	index  *repo.IndexFile
+	charts map[string]*chart.Chart
+
+	loaded []string
+}
+
+func (i *fakeChartIndex) Index(repository string) (*repo.IndexFile, error) {
+	return i.index, nil
+}
+
+func (i *fakeChartIndex) Load(repository string, version *repo.ChartVersion) (*chart.Chart, error) {
+	i.loaded = append(i.loaded, version.Version)
+	c, ok := i.charts[version.Version]
+	if !ok {
+		return nil, fmt.Errorf("chart version %s not found", version.Version)
+	}
+	return c, nil
+}
+
+func newFakeChartIndex(name string, versions ...string) *fakeChartIndex {
+	index := repo.NewIndexFile()
+	charts := make(map[string]*chart.Chart)
+	for _, v := range versions {
+		index.Add(&chart.Metadata{Name: name, Version: v, APIVersion: chart.APIVersionV2}, name+"-"+v+".tgz", "https://charts.example.com", "")
+		charts[v] = &chart.Chart{Metadata: &chart.Metadata{Name: name, Version: v}}
+	}
+	index.SortEntries()
+	return &fakeChartIndex{index: index, charts: charts}
+}
+
+func TestChartUpdate(t *testing.T) {
+	chartVals := `
+image:
+  repository: karolisr/webhook-demo
+  tag: 0.0.10
+
+keel:
+  policy: minor
+  chart:
+    repository: https://charts.example.com/
+  images:
+    - repository: image.repository
+      tag: image.tag
+`
+
+	myChart, err := testingStringToChart(chartVals)
+	if err != nil {
+		t.Fatalf("chartutil.ReadValues error = %v", err)
+	}
+	myChart.Metadata.Version = "1.1.0"
+
+	fakeImpl := &fakeImplementer{
+		listReleasesResponse: []*release.Release{
+			{
+				Name:      "release-1",
+				Namespace: "default",
+				Chart:     myChart,
+				Config:    make(map[string]interface{}),
+			},
+		},
+	}
+
+	approver, teardown := approver()
+	defer teardown()
+	p := NewProvider(fakeImpl, &fakeSender{}, approver)
+	charts := newFakeChartIndex("app-x", "1.0.0", "1.1.0", "1.2.0", "1.3.1", "2.0.0")
+	p.charts = charts
+
+	providers := provider.New([]provider.Provider{p}, approver)
+	defer providers.Stop()
+
+	p.checkCharts(providers)
+
+	var event = <-p.events
+	if event.Repository.Name != "app-x" || event.Repository.Tag != "1.3.1" {
+		t.Fatalf("unexpected chart event: %s:%s", event.Repository.Name, event.Repository.Tag)
+	}
+	if event.Chart != "https://charts.example.com/" {
+		t.Errorf("unexpected chart repository: %s", event.Chart)
+	}
+
+	err = p.processEvent(event)
+	if err != nil {
+		t.Fatalf("failed to process event, error: %s", err)
+	}
+
+	if fakeImpl.updatedRlsName != "release-1" {
+		t.Errorf("unexpected release updated: %s", fakeImpl.updatedRlsName)
+	}
+	if fakeImpl.updatedChart == nil || fakeImpl.updatedChart.Metadata.Version != "1.3.1" {
+		t.Errorf("expected release to be upgraded to chart 1.3.1")
+	}
+	if len(charts.loaded) != 1 {
+		t.Errorf("expected chart to be loaded once, got: %v", charts.loaded)
+	}
+}
+
+func TestChartUpdateNotTracked(t *testing.T) {
+	myChart, err := testingStringToChart(pollingValues)
+	if err != nil {
+		t.Fatalf("chartutil.ReadValues error = %v", err)
+	}
+	myChart.Metadata.Version = "1.1.0"
+
+	fakeImpl := &fakeImplementer{
+		listReleasesResponse: []*release.Release{
+			{
+				Name:   "release-1",
+				Chart:  myChart,
+				Config: make(map[string]interface{}),
+			},
+		},
+	}
+
+	approver, teardown := approver()
+	defer teardown()
+	p := NewProvider(fakeImpl, &fakeSender{}, approver)
+	p.charts = newFakeChartIndex("app-x", "1.2.0")
+
+	providers := provider.New([]provider.Provider{p}, approver)
+	defer providers.Stop()
+
+	p.checkCharts(providers)
+
+	if len(p.events) != 0 {
+		t.Errorf("expected no chart events for releases without chart repository")
+	}
+}
@@ -1,17 +1,21 @@
 package helm3
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/keel-hq/keel/approvals"
 	"github.com/keel-hq/keel/internal/policy"
+	"github.com/keel-hq/keel/pkg/tracing"
 	"github.com/keel-hq/keel/types"
 	"github.com/keel-hq/keel/util/image"
 
 	"github.com/prometheus/client_golang/prometheus"
+	"go.opentelemetry.io/otel/attribute"
 
 	"github.com/keel-hq/keel/extension/notification"
 
@@ -43,9 +47,18 @@ var helm3UnversionedUpdatesCounter = prometheus.NewCounterVec(
 	[]string{"chart"},
 )
 
+var helm3FailedUpdatesCounter = prometheus.NewCounterVec(
+	prometheus.CounterOpts{
+		Name: "helm3_failed_updates_total",
+		Help: "How many helm3 release updates failed, partitioned by chart name.",
+	},
+	[]string{"chart"},
+)
+
 func init() {
 	prometheus.MustRegister(helm3VersionedUpdatesCounter)
 	prometheus.MustRegister(helm3UnversionedUpdatesCounter)
+	prometheus.MustRegister(helm3FailedUpdatesCounter)
 }
 
 // ErrPolicyNotSpecified helm related errors
@@ -117,10 +130,27 @@ type KeelChartConfig struct {
 	ApprovalDeadline     int               `json:"approvalDeadline"` // Deadline in hours
 	Images               []ImageDetails    `json:"images"`
 	NotificationChannels []string          `json:"notificationChannels"` // optional notification channels
+	NotificationLevel    string            `json:"notificationLevel"`    // optional minimum notification level
+	ExcludeTags          string            `json:"excludeTags"`          // optional globs or regexp: patterns of tags never updated to
+	VersionPrefix        string            `json:"versionPrefix"`        // optional "v" prefix handling: tolerate, match or reject
+	BuildMetadata        string            `json:"buildMetadata"`        // optional build metadata handling: ignore or order
+	Chart                ChartDetails      `json:"chart"`                // optional chart repository polled for new chart versions
 
 	Plc policy.Policy `json:"-"`
 }
 
+// notificationLevel - optional override of configured minimum notification level
+func (c *KeelChartConfig) notificationLevel() *types.Level {
+	if c.NotificationLevel == "" {
+		return nil
+	}
+	level, err := types.ParseLevel(c.NotificationLevel)
+	if err != nil {
+		return nil
+	}
+	return &level
+}
+
 // ImageDetails - image details
 type ImageDetails struct {
 	RepositoryPath  string `json:"repository"`
@@ -138,8 +168,16 @@ type Provider struct {
 
 	approvalManager approvals.Manager
 
+	// releases are only reported, see SetDryRun
+	dryRun bool
+
+	// chart repositories of releases tracking their chart, see WatchCharts
+	charts ChartIndex
+
 	events chan *types.Event
 	stop   chan struct{}
+	// held while an event is processed, Stop waits for it
+	processing sync.Mutex
 }
 
 // NewProvider - create new Helm provider
@@ -148,11 +186,17 @@ func NewProvider(implementer Implementer, sender notification.Sender, approvalMa
 		implementer:     implementer,
 		approvalManager: approvalManager,
 		sender:          sender,
+		charts:          NewHTTPChartIndex(),
 		events:          make(chan *types.Event, 100),
 		stop:            make(chan struct{}),
 	}
 }
 
+// SetDryRun - when enabled, release updates are reported but never applied
+func (p *Provider) SetDryRun(dryRun bool) {
+	p.dryRun = dryRun
+}
+
 // GetName - get provider name
 func (p *Provider) GetName() string {
 	return ProviderName
@@ -172,6 +216,10 @@ func (p *Provider) Start() error {
 // Stop - stops kubernetes provider
 func (p *Provider) Stop() {
 	close(p.stop)
+
+	// waiting for the release update in progress
+	p.processing.Lock()
+	defer p.processing.Unlock()
 }
 
 // TrackedImages - returns tracked images from all releases that have keel configuration
@@ -228,6 +276,7 @@ func (p *Provider) TrackedImages() ([]*types.TrackedImage, error) {
 			}
 			img.Namespace = release.Namespace
 			img.Provider = ProviderName
+			img.Identifier = fmt.Sprintf("%s/%s/%s", "chart", release.Namespace, release.Name)
 			trackedImages = append(trackedImages, img)
 		}
 
@@ -240,7 +289,16 @@ func (p *Provider) startInternal() error {
 	for {
 		select {
 		case event := <-p.events:
+			p.processing.Lock()
+			select {
+			case <-p.stop:
+				p.processing.Unlock()
+				log.Info("provider.helm3: got shutdown signal, stopping...")
+				return nil
+			default:
+			}
 			err := p.processEvent(event)
+			p.processing.Unlock()
 			if err != nil {
 				log.WithFields(log.Fields{
 					"error": err,
@@ -256,14 +314,30 @@ func (p *Provider) startInternal() error {
 }
 
 func (p *Provider) processEvent(event *types.Event) (err error) {
-	plans, err := p.createUpdatePlans(event)
+	// forced updates target kubernetes resources
+	if event.Target != "" {
+		return nil
+	}
+
+	ctx, span := tracing.StartEvent("provider.helm3.process_event", event)
+	defer func() { tracing.End(span, err) }()
+
+	var plans []*UpdatePlan
+	_, planSpan := tracing.Start(ctx, "provider.helm3.evaluate_policies")
+	if event.Chart != "" {
+		plans, err = p.createChartUpdatePlans(event)
+	} else {
+		plans, err = p.createUpdatePlans(event)
+	}
+	planSpan.SetAttributes(attribute.Int("plans", len(plans)))
+	tracing.End(planSpan, err)
 	if err != nil {
 		return err
 	}
 
 	approved := p.checkForApprovals(event, plans)
 
-	return p.applyPlans(approved)
+	return p.applyPlans(ctx, approved)
 }
 
 func (p *Provider) createUpdatePlans(event *types.Event) ([]*UpdatePlan, error) {
@@ -295,7 +369,7 @@ func (p *Provider) createUpdatePlans(event *types.Event) ([]*UpdatePlan, error)
 	return plans, nil
 }
 
-func (p *Provider) applyPlans(plans []*UpdatePlan) error {
+func (p *Provider) applyPlans(ctx context.Context, plans []*UpdatePlan) error {
 	for _, plan := range plans {
 
 		p.sender.Send(types.EventNotification{
@@ -307,16 +381,56 @@ func (p *Provider) applyPlans(plans []*UpdatePlan) error {
 			Type:         types.NotificationPreReleaseUpdate,
 			Level:        types.LevelDebug,
 			Channels:     plan.Config.NotificationChannels,
+			MinLevel:     plan.Config.notificationLevel(),
 			Metadata: map[string]string{
 				"provider":  p.GetName(),
 				"namespace": plan.Namespace,
 				"name":      plan.Name,
+				"previous":  plan.CurrentVersion,
+				"new":       plan.NewVersion,
+				"policy":    plan.Config.Policy,
 			},
 		})
 
+		if p.dryRun {
+			log.WithFields(log.Fields{
+				"name":      plan.Name,
+				"namespace": plan.Namespace,
+				"update":    fmt.Sprintf("%s->%s", plan.CurrentVersion, plan.NewVersion),
+			}).Info("provider.helm3: dry run, release not updated")
+
+			p.sender.Send(types.EventNotification{
+				ResourceKind: "chart",
+				Identifier:   fmt.Sprintf("%s/%s/%s", "chart", plan.Namespace, plan.Name),
+				Name:         "dry run",
+				Message:      fmt.Sprintf("Dry run: would update release %s/%s %s->%s (%s)", plan.Namespace, plan.Name, plan.CurrentVersion, plan.NewVersion, strings.Join(mapToSlice(plan.Values), ", ")),
+				CreatedAt:    time.Now(),
+				Type:         types.NotificationReleaseUpdate,
+				Level:        types.LevelInfo,
+				Channels:     plan.Config.NotificationChannels,
+				MinLevel:     plan.Config.notificationLevel(),
+				Metadata: map[string]string{
+					"provider":  p.GetName(),
+					"namespace": plan.Namespace,
+					"name":      plan.Name,
+					"previous":  plan.CurrentVersion,
+					"new":       plan.NewVersion,
+					"dryRun":    "true",
+				},
+			})
+			continue
+		}
+
 		// err := updateHelmRelease(p.implementer, plan.Name, plan.Chart, plan.Values)
+		_, span := tracing.Start(ctx, "provider.helm3.update",
+			attribute.String("namespace", plan.Namespace),
+			attribute.String("name", plan.Name),
+			attribute.String("update", fmt.Sprintf("%s->%s", plan.CurrentVersion, plan.NewVersion)),
+		)
 		err := updateHelmRelease(p.implementer, plan.Name, plan.Chart, plan.Values, plan.Namespace, plan.EmptyConfig)
+		tracing.End(span, err)
 		if err != nil {
+			helm3FailedUpdatesCounter.With(prometheus.Labels{"chart": fmt.Sprintf("%s/%s", plan.Namespace, plan.Name)}).Inc()
 			log.WithFields(log.Fields{
 				"error":     err,
 				"name":      plan.Name,
@@ -332,10 +446,14 @@ func (p *Provider) applyPlans(plans []*UpdatePlan) error {
 				Type:         types.NotificationReleaseUpdate,
 				Level:        types.LevelError,
 				Channels:     plan.Config.NotificationChannels,
+				MinLevel:     plan.Config.notificationLevel(),
 				Metadata: map[string]string{
 					"provider":  p.GetName(),
 					"namespace": plan.Namespace,
 					"name":      plan.Name,
+					"previous":  plan.CurrentVersion,
+					"new":       plan.NewVersion,
+					"policy":    plan.Config.Policy,
 				},
 			})
 			continue
@@ -366,10 +484,14 @@ func (p *Provider) applyPlans(plans []*UpdatePlan) error {
 			Type:         types.NotificationReleaseUpdate,
 			Level:        types.LevelSuccess,
 			Channels:     plan.Config.NotificationChannels,
+			MinLevel:     plan.Config.notificationLevel(),
 			Metadata: map[string]string{
 				"provider":  p.GetName(),
 				"namespace": plan.Namespace,
 				"name":      plan.Name,
+				"previous":  plan.CurrentVersion,
+				"new":       plan.NewVersion,
+				"policy":    plan.Config.Policy,
 			},
 		})
 
@@ -456,7 +578,7 @@ func getKeelConfig(vals chartutil.Values) (*KeelChartConfig, error) {
 
 	cfg := r.Keel
 
-	cfg.Plc = policy.GetPolicy(cfg.Policy, &policy.Options{MatchTag: cfg.MatchTag, MatchPreRelease: cfg.MatchPreRelease})
+	cfg.Plc = policy.GetPolicy(cfg.Policy, &policy.Options{MatchTag: cfg.MatchTag, MatchPreRelease: cfg.MatchPreRelease, ExcludeTags: cfg.ExcludeTags, VersionPrefix: cfg.VersionPrefix, BuildMetadata: cfg.BuildMetadata})
 
 	return &cfg, nil
 }
@@ -8,6 +8,8 @@ import (
 	"github.com/keel-hq/keel/pkg/store"
 	"github.com/keel-hq/keel/types"
 
+	v1 "k8s.io/api/core/v1"
+
 	log "github.com/sirupsen/logrus"
 )
 
@@ -37,7 +39,7 @@ func (p *Provider) checkForApprovals(event *types.Event, plans []*UpdatePlan) (a
 
 // updateComplete is called after we successfully update resource
 func (p *Provider) updateComplete(plan *UpdatePlan) error {
-	return p.approvalManager.Archive(getApprovalIdentifier(plan.Resource.Identifier, plan.NewVersion))
+	return p.approvalManager.Archive(p.approvalIdentifier(plan))
 }
 
 func getInt(key string, labels map[string]string, annotations map[string]string) (int, error) {
@@ -68,9 +70,30 @@ func getInt(key string, labels map[string]string, annotations map[string]string)
 	return 0, nil
 }
 
+// getApprovalDeadline - deadline is either a number of hours or a duration (ie: 30m)
+func getApprovalDeadline(labels map[string]string, annotations map[string]string) (time.Duration, error) {
+	valStr, ok := labels[types.KeelApprovalDeadlineLabel]
+	if !ok {
+		valStr, ok = annotations[types.KeelApprovalDeadlineLabel]
+	}
+	if !ok {
+		return 0, nil
+	}
+
+	if hours, err := strconv.Atoi(valStr); err == nil {
+		return time.Duration(hours) * time.Hour, nil
+	}
+
+	d, err := time.ParseDuration(valStr)
+	if err != nil || d < 0 {
+		return 0, fmt.Errorf("invalid approval deadline '%s'", valStr)
+	}
+	return d, nil
+}
+
 func (p *Provider) isApproved(event *types.Event, plan *UpdatePlan) (bool, error) {
 
-	minApprovals, err := getInt(types.KeelMinimumApprovalsLabel, plan.Resource.GetLabels(), plan.Resource.GetAnnotations())
+	minApprovals, err := getInt(types.KeelMinimumApprovalsLabel, plan.Resource.GetLabels(), plan.Resource.GetKeelAnnotations())
 	if err != nil {
 		return false, err
 	}
@@ -80,8 +103,8 @@ func (p *Provider) isApproved(event *types.Event, plan *UpdatePlan) (bool, error
 	}
 
 	// deadline
-	deadline := types.KeelApprovalDeadlineDefault
-	d, err := getInt(types.KeelApprovalDeadlineLabel, plan.Resource.GetLabels(), plan.Resource.GetAnnotations())
+	deadline := time.Duration(types.KeelApprovalDeadlineDefault) * time.Hour
+	d, err := getApprovalDeadline(plan.Resource.GetLabels(), plan.Resource.GetKeelAnnotations())
 	if err != nil {
 		log.WithFields(log.Fields{
 			"error":    err,
@@ -91,7 +114,7 @@ func (p *Provider) isApproved(event *types.Event, plan *UpdatePlan) (bool, error
 		deadline = d
 	}
 
-	identifier := getApprovalIdentifier(plan.Resource.Identifier, plan.NewVersion)
+	identifier := p.approvalIdentifier(plan)
 
 	// checking for existing approval
 	existing, err := p.approvalManager.Get(identifier)
@@ -115,7 +138,9 @@ func (p *Provider) isApproved(event *types.Event, plan *UpdatePlan) (bool, error
 				VotesRequired:  minApprovals,
 				VotesReceived:  0,
 				Rejected:       false,
-				Deadline:       time.Now().Add(time.Duration(deadline) * time.Hour),
+				Deadline:       time.Now().Add(deadline),
+				Channel:        plan.Resource.GetKeelAnnotations()[types.KeelApprovalsChannelAnnotation],
+				Approvers:      plan.Resource.GetKeelAnnotations()[types.KeelApproversAnnotation],
 			}
 
 			approval.Message = fmt.Sprintf("New image is available for resource %s/%s (%s).",
@@ -123,8 +148,15 @@ func (p *Provider) isApproved(event *types.Event, plan *UpdatePlan) (bool, error
 				plan.Resource.Name,
 				approval.Delta(),
 			)
+			if commit := p.commit(plan); commit != nil {
+				approval.Message = fmt.Sprintf("%s Commit: %s", approval.Message, commit)
+			}
 
-			return false, p.approvalManager.Create(approval)
+			err = p.approvalManager.Create(approval)
+			if err == nil {
+				p.recordEvent(plan.Resource, v1.EventTypeNormal, EventReasonUpdateSkipped, fmt.Sprintf("Update %s->%s is waiting for %d approvals", plan.CurrentVersion, plan.NewVersion, minApprovals))
+			}
+			return false, err
 		}
 
 		return false, err
@@ -142,5 +174,9 @@ func (p *Provider) isApproved(event *types.Event, plan *UpdatePlan) (bool, error
 	// 	"new":      event.Repository.Digest,
 	// }).Info("digests match")
 
-	return existing.Status() == types.ApprovalStatusApproved, nil
+	if existing.Status() != types.ApprovalStatusApproved {
+		return false, nil
+	}
+	p.recordHistory(types.HistoryActionApproved, plan, fmt.Sprintf("received %d/%d approvals", existing.VotesReceived, existing.VotesRequired))
+	return true, nil
 }
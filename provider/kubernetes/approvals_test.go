@@ -107,6 +107,7 @@ func TestCheckRequestedApprovalAnnotation(t *testing.T) {
 					types.KeelPolicyLabel:           "all",
 					types.KeelMinimumApprovalsLabel: "3",
 					types.KeelApprovalDeadlineLabel: "20",
+					types.KeelApproversAnnotation:   "team-payments,sre",
 				},
 			},
 			apps_v1.DeploymentSpec{
@@ -165,6 +166,9 @@ func TestCheckRequestedApprovalAnnotation(t *testing.T) {
 	if approval.Deadline.Before(time.Now().Add(19 * time.Hour)) {
 		t.Errorf("unexpected deadline: %s", approval.Deadline)
 	}
+	if approval.Approvers != "team-payments,sre" {
+		t.Errorf("unexpected approvers: %s", approval.Approvers)
+	}
 }
 
 func TestApprovedCheck(t *testing.T) {
@@ -340,3 +344,30 @@ func TestApprovalsCleanup(t *testing.T) {
 		t.Logf("approval status: %v, identifier: %s", approvals[0].Archived, approvals[0].Identifier)
 	}
 }
+
+func TestGetApprovalDeadline(t *testing.T) {
+	tests := []struct {
+		name        string
+		labels      map[string]string
+		annotations map[string]string
+		want        time.Duration
+		wantErr     bool
+	}{
+		{name: "not set", want: 0},
+		{name: "hours label", labels: map[string]string{types.KeelApprovalDeadlineLabel: "20"}, want: 20 * time.Hour},
+		{name: "duration annotation", annotations: map[string]string{types.KeelApprovalDeadlineLabel: "30m"}, want: 30 * time.Minute},
+		{name: "invalid", annotations: map[string]string{types.KeelApprovalDeadlineLabel: "tomorrow"}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := getApprovalDeadline(tt.labels, tt.annotations)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("getApprovalDeadline() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("getApprovalDeadline() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,194 @@
+package kubernetes
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/keel-hq/keel/types"
+	"github.com/keel-hq/keel/util/timeutil"
+
+	v1 "k8s.io/api/core/v1"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// EnvUpdateMaxRetries - how many times failing update of the same version is
+// attempted before keel gives up, "0" retries forever
+const EnvUpdateMaxRetries = "UPDATE_MAX_RETRIES"
+
+// EnvUpdateBackoff - delay after the first failed update, doubled after every
+// consecutive failure
+const EnvUpdateBackoff = "UPDATE_BACKOFF"
+
+const (
+	DefaultUpdateMaxRetries = 5
+	DefaultUpdateBackoff    = 30 * time.Second
+	MaxUpdateBackoff        = 30 * time.Minute
+)
+
+// updateFailure - consecutive failed attempts to update resource to a version
+type updateFailure struct {
+	version  string
+	failures int
+	retryAt  time.Time
+}
+
+func getUpdateMaxRetries() int {
+	val := os.Getenv(EnvUpdateMaxRetries)
+	if val == "" {
+		return DefaultUpdateMaxRetries
+	}
+
+	retries, err := strconv.Atoi(val)
+	if err != nil || retries < 0 {
+		log.WithFields(log.Fields{
+			"error":       err,
+			"max_retries": val,
+		}).Warnf("provider.kubernetes: invalid %s, using default %d", EnvUpdateMaxRetries, DefaultUpdateMaxRetries)
+		return DefaultUpdateMaxRetries
+	}
+	return retries
+}
+
+func getUpdateBackoff() time.Duration {
+	val := os.Getenv(EnvUpdateBackoff)
+	if val == "" {
+		return DefaultUpdateBackoff
+	}
+
+	backoff, err := time.ParseDuration(val)
+	if err != nil || backoff <= 0 {
+		log.WithFields(log.Fields{
+			"error":   err,
+			"backoff": val,
+		}).Warnf("provider.kubernetes: invalid %s, using default %s", EnvUpdateBackoff, DefaultUpdateBackoff)
+		return DefaultUpdateBackoff
+	}
+	return backoff
+}
+
+// holdFailing - skips plans whose previous attempts failed until their backoff
+// expires, versions that reached max retries are not attempted anymore unless
+// replayed from the dead-letter store
+func (p *Provider) holdFailing(plans []*UpdatePlan) []*UpdatePlan {
+	var ready []*UpdatePlan
+	now := timeutil.Now()
+
+	p.failuresMu.Lock()
+	defer p.failuresMu.Unlock()
+
+	for _, plan := range plans {
+		resource := plan.Resource
+		failure, ok := p.failures[resource.Identifier]
+		if !ok || failure.version != plan.NewVersion || plan.Trigger == types.TriggerNameReplay {
+			delete(p.failures, resource.Identifier)
+			ready = append(ready, plan)
+			continue
+		}
+
+		if p.maxRetries > 0 && failure.failures >= p.maxRetries {
+			log.WithFields(log.Fields{
+				"name":      resource.Name,
+				"kind":      resource.Kind(),
+				"namespace": resource.Namespace,
+				"update":    fmt.Sprintf("%s->%s", plan.CurrentVersion, plan.NewVersion),
+				"failures":  failure.failures,
+			}).Debug("provider.kubernetes: update failed too many times, skipping")
+			continue
+		}
+
+		if now.Before(failure.retryAt) {
+			log.WithFields(log.Fields{
+				"name":      resource.Name,
+				"kind":      resource.Kind(),
+				"namespace": resource.Namespace,
+				"update":    fmt.Sprintf("%s->%s", plan.CurrentVersion, plan.NewVersion),
+				"retry_at":  failure.retryAt,
+			}).Debug("provider.kubernetes: previous update failed, backing off")
+			continue
+		}
+
+		ready = append(ready, plan)
+	}
+
+	return ready
+}
+
+// updateFailed - schedules next attempt, once max retries is reached the update
+// is abandoned and reported as requiring manual intervention
+func (p *Provider) updateFailed(plan *UpdatePlan, updateErr error) {
+	resource := plan.Resource
+
+	p.failuresMu.Lock()
+	failure, ok := p.failures[resource.Identifier]
+	if !ok || failure.version != plan.NewVersion {
+		failure = &updateFailure{version: plan.NewVersion}
+		p.failures[resource.Identifier] = failure
+	}
+	failure.failures++
+
+	backoff := p.backoff
+	for i := 1; i < failure.failures && backoff < MaxUpdateBackoff; i++ {
+		backoff *= 2
+	}
+	if backoff > MaxUpdateBackoff {
+		backoff = MaxUpdateBackoff
+	}
+	failure.retryAt = timeutil.Now().Add(backoff)
+	failures := failure.failures
+	p.failuresMu.Unlock()
+
+	p.recordHistory(types.HistoryActionFailed, plan, updateErr.Error())
+
+	if p.maxRetries == 0 || failures < p.maxRetries {
+		return
+	}
+
+	msg := fmt.Sprintf("Update %s->%s failed %d times, requires manual intervention: %s", plan.CurrentVersion, plan.NewVersion, failures, updateErr)
+
+	log.WithFields(log.Fields{
+		"error":     updateErr,
+		"name":      resource.Name,
+		"kind":      resource.Kind(),
+		"namespace": resource.Namespace,
+		"update":    fmt.Sprintf("%s->%s", plan.CurrentVersion, plan.NewVersion),
+		"failures":  failures,
+	}).Error("provider.kubernetes: giving up on update, requires manual intervention")
+
+	p.recordEvent(resource, v1.EventTypeWarning, EventReasonUpdateFailed, msg)
+	p.deadLetter(plan.event, resource.Identifier, failures, updateErr)
+
+	settings := resource.GetKeelAnnotations()
+	p.sender.Send(types.EventNotification{
+		ResourceKind: resource.Kind(),
+		Identifier:   resource.Identifier,
+		Name:         "update requires manual intervention",
+		Message:      fmt.Sprintf("%s %s/%s: %s (%s)", resource.Kind(), resource.Namespace, resource.Name, msg, strings.Join(resource.GetImages(), ", ")),
+		CreatedAt:    time.Now(),
+		Type:         types.NotificationDeploymentUpdate,
+		Level:        types.LevelError,
+		Channels:     types.ParseEventNotificationChannelsFromLabelsOrAnnotations(resource.GetLabels(), settings),
+		MinLevel:     types.ParseEventNotificationLevel(resource.GetLabels(), settings),
+		Metadata: map[string]string{
+			"provider":  p.GetName(),
+			"namespace": resource.GetNamespace(),
+			"name":      resource.GetName(),
+			"previous":  plan.CurrentVersion,
+			"new":       plan.NewVersion,
+			"failures":  strconv.Itoa(failures),
+			"trigger":   plan.Trigger,
+		},
+	})
+}
+
+// updateSucceeded - clears failures of the resource
+func (p *Provider) updateSucceeded(plan *UpdatePlan) {
+	p.failuresMu.Lock()
+	delete(p.failures, plan.Resource.Identifier)
+	p.failuresMu.Unlock()
+
+	p.recordHistory(types.HistoryActionApplied, plan, strings.Join(plan.Resource.GetImages(), ", "))
+}
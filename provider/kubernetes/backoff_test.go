@@ -0,0 +1,95 @@
+package kubernetes
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/keel-hq/keel/internal/k8s"
+	"github.com/keel-hq/keel/types"
+	"github.com/keel-hq/keel/util/timeutil"
+
+	apps_v1 "k8s.io/api/apps/v1"
+	v1 "k8s.io/api/core/v1"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestUpdateBackoff(t *testing.T) {
+	now := time.Date(2021, 3, 1, 20, 0, 0, 0, time.UTC)
+	timeutil.Now = func() time.Time {
+		return now
+	}
+	defer func() { timeutil.Now = time.Now }()
+
+	fp := &fakeImplementer{updateErr: fmt.Errorf("admission webhook denied the request")}
+	grc := &k8s.GenericResourceCache{}
+	grc.Add(MustParseGR(&apps_v1.Deployment{
+		meta_v1.TypeMeta{},
+		meta_v1.ObjectMeta{
+			Name:      "deployment-1",
+			Namespace: "xxxx",
+			Labels:    map[string]string{types.KeelPolicyLabel: "all"},
+		},
+		apps_v1.DeploymentSpec{
+			Template: v1.PodTemplateSpec{
+				Spec: v1.PodSpec{
+					Containers: []v1.Container{
+						{
+							Image: "gcr.io/v2-namespace/hello-world:10.0.0",
+						},
+					},
+				},
+			},
+		},
+		apps_v1.DeploymentStatus{},
+	}))
+
+	fs := &fakeSender{}
+	approver, teardown := approver()
+	defer teardown()
+	provider, err := NewProvider(fp, fs, approver, grc)
+	if err != nil {
+		t.Fatalf("failed to get provider: %s", err)
+	}
+	provider.maxRetries = 3
+	provider.backoff = time.Minute
+
+	process := func(tag string) {
+		_, err := provider.processEvent(&types.Event{Repository: types.Repository{
+			Name: "gcr.io/v2-namespace/hello-world",
+			Tag:  tag,
+		}})
+		if err != nil {
+			t.Fatalf("got error while processing event: %s", err)
+		}
+	}
+
+	steps := []struct {
+		after    time.Duration
+		attempts int
+	}{
+		{after: 0, attempts: 1},
+		{after: 30 * time.Second, attempts: 1}, // backing off for 1m
+		{after: 30 * time.Second, attempts: 2},
+		{after: time.Minute, attempts: 2}, // backing off for 2m
+		{after: time.Minute, attempts: 3},
+		{after: time.Hour, attempts: 3}, // max retries reached
+	}
+	for i, step := range steps {
+		now = now.Add(step.after)
+		process("11.0.0")
+		if fp.updateAttempts != step.attempts {
+			t.Fatalf("step %d: expected %d update attempts, got: %d", i, step.attempts, fp.updateAttempts)
+		}
+	}
+
+	if fs.sentEvent.Name != "update requires manual intervention" {
+		t.Errorf("expected manual intervention notification, got: %s", fs.sentEvent.Name)
+	}
+
+	// new version is attempted straight away
+	process("12.0.0")
+	if fp.updateAttempts != 4 {
+		t.Errorf("expected new version to be attempted, got %d attempts", fp.updateAttempts)
+	}
+}
@@ -0,0 +1,41 @@
+package kubernetes
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/keel-hq/keel/internal/k8s"
+	"github.com/keel-hq/keel/types"
+)
+
+// updateRecord - value of keel.sh/update annotation
+type updateRecord struct {
+	Trigger  string `json:"trigger,omitempty"`
+	Previous string `json:"previous"`
+	New      string `json:"new"`
+	Time     string `json:"time"`
+}
+
+// setChangeCause - attributes the resource revision to keel, kubernetes.io/change-cause
+// is copied to the new ReplicaSet and shown by kubectl rollout history
+func setChangeCause(resource *k8s.GenericResource, action, trigger, previous, new string) {
+	timestamp := time.Now().Format(time.RFC3339)
+
+	annotations := resource.GetAnnotations()
+	if trigger != "" {
+		annotations["kubernetes.io/change-cause"] = fmt.Sprintf("keel automated %s (%s), version %s -> %s [%s]", action, trigger, previous, new, timestamp)
+	} else {
+		annotations["kubernetes.io/change-cause"] = fmt.Sprintf("keel automated %s, version %s -> %s [%s]", action, previous, new, timestamp)
+	}
+
+	record, _ := json.Marshal(updateRecord{
+		Trigger:  trigger,
+		Previous: previous,
+		New:      new,
+		Time:     timestamp,
+	})
+	annotations[types.KeelUpdateAnnotation] = string(record)
+
+	resource.SetAnnotations(annotations)
+}
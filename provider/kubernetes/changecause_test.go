@@ -0,0 +1,75 @@
+package kubernetes
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/keel-hq/keel/internal/k8s"
+	"github.com/keel-hq/keel/types"
+
+	apps_v1 "k8s.io/api/apps/v1"
+	v1 "k8s.io/api/core/v1"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestUpdateAnnotations(t *testing.T) {
+	fp := &fakeImplementer{}
+	grc := &k8s.GenericResourceCache{}
+	grc.Add(MustParseGR(&apps_v1.Deployment{
+		meta_v1.TypeMeta{},
+		meta_v1.ObjectMeta{
+			Name:        "deployment-1",
+			Namespace:   "xxxx",
+			Labels:      map[string]string{types.KeelPolicyLabel: "all"},
+			Annotations: map[string]string{},
+		},
+		apps_v1.DeploymentSpec{
+			Template: v1.PodTemplateSpec{
+				Spec: v1.PodSpec{
+					Containers: []v1.Container{
+						{
+							Image: "gcr.io/v2-namespace/hello-world:10.0.0",
+						},
+					},
+				},
+			},
+		},
+		apps_v1.DeploymentStatus{},
+	}))
+
+	approver, teardown := approver()
+	defer teardown()
+	provider, err := NewProvider(fp, &fakeSender{}, approver, grc)
+	if err != nil {
+		t.Fatalf("failed to get provider: %s", err)
+	}
+
+	_, err = provider.processEvent(&types.Event{
+		Repository: types.Repository{
+			Name: "gcr.io/v2-namespace/hello-world",
+			Tag:  "11.0.0",
+		},
+		TriggerName: types.TriggerTypePoll.String(),
+	})
+	if err != nil {
+		t.Fatalf("got error while processing event: %s", err)
+	}
+	if fp.updated == nil {
+		t.Fatalf("expected deployment to be updated")
+	}
+
+	annotations := fp.updated.GetAnnotations()
+	cause := annotations["kubernetes.io/change-cause"]
+	if !strings.HasPrefix(cause, "keel automated update (poll), version 10.0.0 -> 11.0.0") {
+		t.Errorf("unexpected change cause: %s", cause)
+	}
+
+	var record updateRecord
+	if err := json.Unmarshal([]byte(annotations[types.KeelUpdateAnnotation]), &record); err != nil {
+		t.Fatalf("failed to parse %s annotation: %s", types.KeelUpdateAnnotation, err)
+	}
+	if record.Trigger != "poll" || record.Previous != "10.0.0" || record.New != "11.0.0" || record.Time == "" {
+		t.Errorf("unexpected update record: %+v", record)
+	}
+}
@@ -0,0 +1,44 @@
+package kubernetes
+
+import (
+	"github.com/keel-hq/keel/extension/notification"
+	"github.com/keel-hq/keel/types"
+)
+
+// SetCluster - names the cluster this provider updates, required when keel
+// manages several clusters. Cluster name is added to the provider name,
+// approval identifiers and notification metadata
+func (p *Provider) SetCluster(name string) {
+	p.cluster = name
+	if name != "" {
+		p.sender = &clusterSender{Sender: p.sender, cluster: name}
+	}
+}
+
+// Cluster - name of the cluster, empty if keel only manages its own cluster
+func (p *Provider) Cluster() string {
+	return p.cluster
+}
+
+// clusterSender - adds cluster name to notifications
+type clusterSender struct {
+	notification.Sender
+	cluster string
+}
+
+func (s *clusterSender) Send(event types.EventNotification) error {
+	metadata := make(map[string]string, len(event.Metadata)+1)
+	for k, v := range event.Metadata {
+		metadata[k] = v
+	}
+	metadata["cluster"] = s.cluster
+	event.Metadata = metadata
+	return s.Sender.Send(event)
+}
+
+func (p *Provider) approvalIdentifier(plan *UpdatePlan) string {
+	if p.cluster != "" {
+		return getApprovalIdentifier(p.cluster+"/"+plan.Resource.Identifier, plan.NewVersion)
+	}
+	return getApprovalIdentifier(plan.Resource.Identifier, plan.NewVersion)
+}
@@ -0,0 +1,78 @@
+package kubernetes
+
+import (
+	"testing"
+
+	"github.com/keel-hq/keel/internal/k8s"
+	"github.com/keel-hq/keel/types"
+
+	apps_v1 "k8s.io/api/apps/v1"
+	v1 "k8s.io/api/core/v1"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestCluster(t *testing.T) {
+	fp := &fakeImplementer{}
+	grc := &k8s.GenericResourceCache{}
+	grc.Add(MustParseGR(&apps_v1.Deployment{
+		meta_v1.TypeMeta{},
+		meta_v1.ObjectMeta{
+			Name:        "deployment-1",
+			Namespace:   "xxxx",
+			Labels:      map[string]string{types.KeelPolicyLabel: "all"},
+			Annotations: map[string]string{},
+		},
+		apps_v1.DeploymentSpec{
+			Template: v1.PodTemplateSpec{
+				Spec: v1.PodSpec{
+					Containers: []v1.Container{
+						{
+							Image: "gcr.io/v2-namespace/hello-world:10.0.0",
+						},
+					},
+				},
+			},
+		},
+		apps_v1.DeploymentStatus{},
+	}))
+
+	fs := &fakeSender{}
+	approver, teardown := approver()
+	defer teardown()
+	provider, err := NewProvider(fp, fs, approver, grc)
+	if err != nil {
+		t.Fatalf("failed to get provider: %s", err)
+	}
+	provider.SetCluster("prod")
+
+	if provider.GetName() != "kubernetes/prod" {
+		t.Errorf("unexpected provider name: %s", provider.GetName())
+	}
+
+	tracked, err := provider.TrackedImages()
+	if err != nil {
+		t.Fatalf("failed to get tracked images: %s", err)
+	}
+	if len(tracked) != 1 || tracked[0].Cluster != "prod" {
+		t.Errorf("expected tracked image of cluster prod, got: %+v", tracked)
+	}
+
+	_, err = provider.processEvent(&types.Event{Repository: types.Repository{
+		Name: "gcr.io/v2-namespace/hello-world",
+		Tag:  "11.0.0",
+	}})
+	if err != nil {
+		t.Fatalf("got error while processing event: %s", err)
+	}
+	if fp.updated == nil {
+		t.Fatalf("expected deployment to be updated")
+	}
+	if fs.sentEvent.Metadata["cluster"] != "prod" {
+		t.Errorf("expected cluster in notification metadata, got: %v", fs.sentEvent.Metadata)
+	}
+
+	plan := &UpdatePlan{Resource: fp.updated, NewVersion: "11.0.0"}
+	if id := provider.approvalIdentifier(plan); id != "prod/deployment/xxxx/deployment-1:11.0.0" {
+		t.Errorf("unexpected approval identifier: %s", id)
+	}
+}
@@ -0,0 +1,40 @@
+package kubernetes
+
+import (
+	"github.com/keel-hq/keel/types"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// CommitResolver - looks up Git commit referenced by the image tag, returns
+// nil if the tag doesn't reference one
+type CommitResolver interface {
+	Commit(repo, tag string) (*types.Commit, error)
+}
+
+// SetCommitResolver - commits of new versions of resources with
+// keel.sh/sourceRepo are included in notifications and approval requests
+func (p *Provider) SetCommitResolver(resolver CommitResolver) {
+	p.commits = resolver
+}
+
+// commit - commit of the plan new version, lookup failures are only logged
+func (p *Provider) commit(plan *UpdatePlan) *types.Commit {
+	repo := plan.Resource.GetKeelAnnotations()[types.KeelSourceRepoAnnotation]
+	if p.commits == nil || repo == "" {
+		return nil
+	}
+
+	commit, err := p.commits.Commit(repo, plan.NewVersion)
+	if err != nil {
+		log.WithFields(log.Fields{
+			"error":     err,
+			"name":      plan.Resource.Name,
+			"namespace": plan.Resource.Namespace,
+			"repo":      repo,
+			"tag":       plan.NewVersion,
+		}).Warn("provider.kubernetes: failed to look up commit of the new version")
+		return nil
+	}
+	return commit
+}
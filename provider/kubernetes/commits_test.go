@@ -0,0 +1,86 @@
+package kubernetes
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/keel-hq/keel/internal/k8s"
+	"github.com/keel-hq/keel/types"
+	apps_v1 "k8s.io/api/apps/v1"
+	v1 "k8s.io/api/core/v1"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+type fakeCommitResolver struct {
+	repo string
+	tag  string
+}
+
+func (r *fakeCommitResolver) Commit(repo, tag string) (*types.Commit, error) {
+	r.repo, r.tag = repo, tag
+	return &types.Commit{SHA: "3f2c9a1e5b6d", Message: "Fix login redirect\n\nDetails", Author: "Jane Doe"}, nil
+}
+
+func TestApprovalCommit(t *testing.T) {
+	fp := &fakeImplementer{}
+	deployments := []*apps_v1.Deployment{
+		{
+			meta_v1.TypeMeta{},
+			meta_v1.ObjectMeta{
+				Name:      "dep-1",
+				Namespace: "xxxx",
+				Labels:    map[string]string{},
+				Annotations: map[string]string{
+					types.KeelPolicyLabel:           "force",
+					types.KeelMinimumApprovalsLabel: "1",
+					types.KeelSourceRepoAnnotation:  "github.com/acme/app",
+				},
+			},
+			apps_v1.DeploymentSpec{
+				Template: v1.PodTemplateSpec{
+					Spec: v1.PodSpec{
+						Containers: []v1.Container{
+							{
+								Image: "gcr.io/v2-namespace/hello-world:1.1.1-1a2b3c4",
+							},
+						},
+					},
+				},
+			},
+			apps_v1.DeploymentStatus{},
+		},
+	}
+
+	grs := MustParseGRS(deployments)
+	grc := &k8s.GenericResourceCache{}
+	grc.Add(grs...)
+
+	approver, teardown := approver()
+	defer teardown()
+	provider, err := NewProvider(fp, &fakeSender{}, approver, grc)
+	if err != nil {
+		t.Fatalf("failed to get provider: %s", err)
+	}
+	resolver := &fakeCommitResolver{}
+	provider.SetCommitResolver(resolver)
+
+	_, err = provider.processEvent(&types.Event{Repository: types.Repository{
+		Name: "gcr.io/v2-namespace/hello-world",
+		Tag:  "1.1.2-3f2c9a1",
+	}})
+	if err != nil {
+		t.Fatalf("failed to process event: %s", err)
+	}
+
+	if resolver.repo != "github.com/acme/app" || resolver.tag != "1.1.2-3f2c9a1" {
+		t.Errorf("unexpected lookup: %s %s", resolver.repo, resolver.tag)
+	}
+
+	approval, err := provider.approvalManager.Get("deployment/xxxx/dep-1:1.1.2-3f2c9a1")
+	if err != nil {
+		t.Fatalf("failed to find approval, err: %s", err)
+	}
+	if !strings.Contains(approval.Message, "Commit: 3f2c9a1 Fix login redirect (Jane Doe)") {
+		t.Errorf("expected commit in approval message: %s", approval.Message)
+	}
+}
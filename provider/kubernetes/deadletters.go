@@ -0,0 +1,56 @@
+package kubernetes
+
+import (
+	"github.com/keel-hq/keel/types"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// DeadLetterStore - keeps events that couldn't be applied so they can be
+// replayed later, see provider.ReplayDeadLetter
+type DeadLetterStore interface {
+	CreateDeadLetter(deadLetter *types.DeadLetter) (*types.DeadLetter, error)
+}
+
+// SetDeadLetterStore - stores events that failed to process and updates
+// abandoned after max retries instead of dropping them
+func (p *Provider) SetDeadLetterStore(store DeadLetterStore) {
+	p.deadLetters = store
+}
+
+// deadLetter - stores event, identifier is set when a single resource failed
+// to update and the stored event then targets only that resource
+func (p *Provider) deadLetter(event *types.Event, identifier string, attempts int, cause error) {
+	if p.deadLetters == nil || event == nil {
+		return
+	}
+
+	stored := *event
+	if identifier != "" {
+		stored.Target = identifier
+	}
+
+	deadLetter, err := p.deadLetters.CreateDeadLetter(&types.DeadLetter{
+		Provider:   p.GetName(),
+		Identifier: identifier,
+		Event:      &stored,
+		Error:      cause.Error(),
+		Attempts:   attempts,
+	})
+	if err != nil {
+		log.WithFields(log.Fields{
+			"error":      err,
+			"image":      event.Repository.Name,
+			"tag":        event.Repository.Tag,
+			"identifier": identifier,
+		}).Error("provider.kubernetes: failed to store dead letter, event is lost")
+		return
+	}
+
+	log.WithFields(log.Fields{
+		"id":         deadLetter.ID,
+		"image":      event.Repository.Name,
+		"tag":        event.Repository.Tag,
+		"identifier": identifier,
+	}).Warn("provider.kubernetes: event stored as dead letter, it can be replayed or discarded")
+}
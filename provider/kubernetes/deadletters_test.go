@@ -0,0 +1,107 @@
+package kubernetes
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/keel-hq/keel/internal/k8s"
+	"github.com/keel-hq/keel/types"
+
+	apps_v1 "k8s.io/api/apps/v1"
+	v1 "k8s.io/api/core/v1"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+type fakeDeadLetterStore struct {
+	stored []*types.DeadLetter
+}
+
+func (s *fakeDeadLetterStore) CreateDeadLetter(deadLetter *types.DeadLetter) (*types.DeadLetter, error) {
+	deadLetter.ID = fmt.Sprintf("dl-%d", len(s.stored)+1)
+	s.stored = append(s.stored, deadLetter)
+	return deadLetter, nil
+}
+
+func TestDeadLetterAfterMaxRetries(t *testing.T) {
+	fp := &fakeImplementer{updateErr: fmt.Errorf("the server is currently unable to handle the request")}
+	grc := &k8s.GenericResourceCache{}
+	grc.Add(MustParseGR(&apps_v1.Deployment{
+		meta_v1.TypeMeta{},
+		meta_v1.ObjectMeta{
+			Name:      "deployment-1",
+			Namespace: "xxxx",
+			Labels:    map[string]string{types.KeelPolicyLabel: "all"},
+		},
+		apps_v1.DeploymentSpec{
+			Template: v1.PodTemplateSpec{
+				Spec: v1.PodSpec{
+					Containers: []v1.Container{
+						{
+							Image: "gcr.io/v2-namespace/hello-world:10.0.0",
+						},
+					},
+				},
+			},
+		},
+		apps_v1.DeploymentStatus{},
+	}))
+
+	approver, teardown := approver()
+	defer teardown()
+	provider, err := NewProvider(fp, &fakeSender{}, approver, grc)
+	if err != nil {
+		t.Fatalf("failed to get provider: %s", err)
+	}
+	store := &fakeDeadLetterStore{}
+	provider.SetDeadLetterStore(store)
+	provider.maxRetries = 1
+	provider.backoff = time.Hour
+
+	event := &types.Event{
+		Repository:  types.Repository{Name: "gcr.io/v2-namespace/hello-world", Tag: "11.0.0"},
+		TriggerName: "dockerhub",
+	}
+	_, err = provider.processEvent(event)
+	if err != nil {
+		t.Fatalf("got error while processing event: %s", err)
+	}
+
+	if len(store.stored) != 1 {
+		t.Fatalf("expected 1 dead letter, got: %d", len(store.stored))
+	}
+	dl := store.stored[0]
+	if dl.Identifier != "deployment/xxxx/deployment-1" || dl.Attempts != 1 {
+		t.Errorf("unexpected dead letter: %+v", dl)
+	}
+	if dl.Event.Target != "deployment/xxxx/deployment-1" || dl.Event.Repository.Tag != "11.0.0" {
+		t.Errorf("unexpected dead letter event: %+v", dl.Event)
+	}
+	if event.Target != "" {
+		t.Errorf("original event shouldn't be modified")
+	}
+
+	// abandoned version isn't attempted again
+	_, err = provider.processEvent(event)
+	if err != nil {
+		t.Fatalf("got error while processing event: %s", err)
+	}
+	if fp.updateAttempts != 1 {
+		t.Errorf("expected 1 update attempt, got: %d", fp.updateAttempts)
+	}
+
+	// replayed event is applied regardless of previous failures
+	fp.updateErr = nil
+	replayed := *dl.Event
+	replayed.TriggerName = types.TriggerNameReplay
+	_, err = provider.processEvent(&replayed)
+	if err != nil {
+		t.Fatalf("got error while processing event: %s", err)
+	}
+	if fp.updateAttempts != 2 {
+		t.Errorf("expected replayed event to be applied, got %d attempts", fp.updateAttempts)
+	}
+	if fp.updated == nil || fp.updated.Containers()[0].Image != "gcr.io/v2-namespace/hello-world:11.0.0" {
+		t.Errorf("unexpected update: %v", fp.updated)
+	}
+}
@@ -0,0 +1,71 @@
+package kubernetes
+
+import (
+	"github.com/keel-hq/keel/internal/k8s"
+	"github.com/keel-hq/keel/types"
+	"github.com/keel-hq/keel/util/image"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// DigestResolver - looks up digest of the image tag in the registry
+type DigestResolver interface {
+	Digest(image *types.TrackedImage) (string, error)
+}
+
+// SetDigestResolver - tags are resolved to digests at update time when
+// resources pin images by digest and the event doesn't carry one (ie: webhooks
+// and tag polling report tags only)
+func (p *Provider) SetDigestResolver(resolver DigestResolver) {
+	p.digests = resolver
+}
+
+// resolveDigest - returns event repository with digest of the new tag set if
+// any of the resource containers is pinned by digest, repository is returned
+// unchanged when the digest isn't needed or can't be resolved
+func (p *Provider) resolveDigest(repo *types.Repository, resource *k8s.GenericResource) *types.Repository {
+	if repo.Digest != "" || p.digests == nil {
+		return repo
+	}
+
+	digestPin := getDigestPin(resource.GetLabels(), resource.GetKeelAnnotations())
+	if digestPin != DigestPinAlways && digestPin != DigestPinUpdate {
+		return repo
+	}
+
+	ref, err := image.Parse(repo.String())
+	if err != nil {
+		return repo
+	}
+
+	pinned := false
+	for _, c := range trackedContainers(resource) {
+		imageRef, pinnedDigest := image.SplitTagDigest(c.Image)
+		containerRef, err := image.Parse(imageRef)
+		if err != nil || containerRef.Repository() != ref.Repository() {
+			continue
+		}
+		if pinDigest(digestPin, pinnedDigest) {
+			pinned = true
+			break
+		}
+	}
+	if !pinned {
+		return repo
+	}
+
+	digest, err := p.digests.Digest(trackedImage(ref, resource, p.cluster))
+	if err != nil {
+		log.WithFields(log.Fields{
+			"error":     err,
+			"name":      resource.Name,
+			"namespace": resource.Namespace,
+			"image":     ref.Remote(),
+		}).Error("provider.kubernetes: failed to resolve image digest")
+		return repo
+	}
+
+	resolved := *repo
+	resolved.Digest = digest
+	return &resolved
+}
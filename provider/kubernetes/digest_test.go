@@ -0,0 +1,116 @@
+package kubernetes
+
+import (
+	"testing"
+
+	"github.com/keel-hq/keel/internal/k8s"
+	"github.com/keel-hq/keel/types"
+
+	apps_v1 "k8s.io/api/apps/v1"
+	v1 "k8s.io/api/core/v1"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+type fakeDigestResolver struct {
+	digest   string
+	resolved []*types.TrackedImage
+}
+
+func (r *fakeDigestResolver) Digest(image *types.TrackedImage) (string, error) {
+	r.resolved = append(r.resolved, image)
+	return r.digest, nil
+}
+
+func TestDigestResolvedAtUpdateTime(t *testing.T) {
+	const digest = "sha256:1c14a8b4ca83bc8f6cb1ec9a1f1a8a6a66d3f2b7cf3a1d4f2e1a7a4c9a2e6d11"
+
+	fp := &fakeImplementer{}
+	grc := &k8s.GenericResourceCache{}
+	grc.Add(MustParseGR(&apps_v1.Deployment{
+		meta_v1.TypeMeta{},
+		meta_v1.ObjectMeta{
+			Name:      "deployment-1",
+			Namespace: "xxxx",
+			Labels:    map[string]string{types.KeelPolicyLabel: "all"},
+			Annotations: map[string]string{
+				types.KeelDigestAnnotation:          "true",
+				types.KeelImagePullSecretAnnotation: "registry-creds",
+			},
+		},
+		apps_v1.DeploymentSpec{
+			Template: v1.PodTemplateSpec{
+				Spec: v1.PodSpec{
+					Containers: []v1.Container{
+						{
+							Image: "gcr.io/v2-namespace/hello-world:1.1.1",
+						},
+					},
+				},
+			},
+		},
+		apps_v1.DeploymentStatus{},
+	}))
+	grc.Add(MustParseGR(&apps_v1.Deployment{
+		meta_v1.TypeMeta{},
+		meta_v1.ObjectMeta{
+			Name:      "deployment-2",
+			Namespace: "xxxx",
+			Labels:    map[string]string{types.KeelPolicyLabel: "all"},
+		},
+		apps_v1.DeploymentSpec{
+			Template: v1.PodTemplateSpec{
+				Spec: v1.PodSpec{
+					Containers: []v1.Container{
+						{
+							Image: "gcr.io/v2-namespace/hello-world:1.1.1",
+						},
+					},
+				},
+			},
+		},
+		apps_v1.DeploymentStatus{},
+	}))
+
+	approver, teardown := approver()
+	defer teardown()
+	provider, err := NewProvider(fp, &fakeSender{}, approver, grc)
+	if err != nil {
+		t.Fatalf("failed to get provider: %s", err)
+	}
+	resolver := &fakeDigestResolver{digest: digest}
+	provider.SetDigestResolver(resolver)
+
+	plans, err := provider.createUpdatePlans(&types.Repository{
+		Name: "gcr.io/v2-namespace/hello-world",
+		Tag:  "1.1.2",
+	})
+	if err != nil {
+		t.Fatalf("failed to create update plans: %s", err)
+	}
+	if len(plans) != 2 {
+		t.Fatalf("expected 2 plans, got %d", len(plans))
+	}
+
+	images := map[string]string{}
+	for _, plan := range plans {
+		images[plan.Resource.Name] = plan.Resource.Containers()[0].Image
+	}
+	if images["deployment-1"] != "gcr.io/v2-namespace/hello-world:1.1.2@"+digest {
+		t.Errorf("expected image to be pinned by digest, got: %s", images["deployment-1"])
+	}
+	if images["deployment-2"] != "gcr.io/v2-namespace/hello-world:1.1.2" {
+		t.Errorf("expected image to be updated by tag, got: %s", images["deployment-2"])
+	}
+
+	// only resources pinning by digest need the lookup
+	if len(resolver.resolved) != 1 {
+		t.Fatalf("expected digest to be resolved once, got %d", len(resolver.resolved))
+	}
+	resolved := resolver.resolved[0]
+	if resolved.Image.Tag() != "1.1.2" || resolved.Namespace != "xxxx" {
+		t.Errorf("unexpected image resolved: %s in %s", resolved.Image.Remote(), resolved.Namespace)
+	}
+	if len(resolved.Secrets) != 1 || resolved.Secrets[0] != "registry-creds" {
+		t.Errorf("expected image pull secret to be used, got: %v", resolved.Secrets)
+	}
+}
@@ -0,0 +1,79 @@
+package kubernetes
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/keel-hq/keel/internal/k8s"
+	"github.com/keel-hq/keel/types"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// SetDryRun - when enabled, updates are detected, evaluated against policies and
+// approvals and reported but never applied to the cluster
+func (p *Provider) SetDryRun(dryRun bool) {
+	p.dryRun = dryRun
+}
+
+// isDryRun - whether resource changes should only be reported, either globally
+// or through keel.sh/dryRun on the resource
+func (p *Provider) isDryRun(resource *k8s.GenericResource) bool {
+	if p.dryRun {
+		return true
+	}
+
+	val, ok := resource.GetKeelAnnotations()[types.KeelDryRunAnnotation]
+	if !ok {
+		return false
+	}
+	dryRun, err := strconv.ParseBool(strings.TrimSpace(val))
+	if err != nil {
+		log.WithFields(log.Fields{
+			"error":     err,
+			"name":      resource.Name,
+			"namespace": resource.Namespace,
+		}).Errorf("provider.kubernetes: invalid %s value, ignoring it", types.KeelDryRunAnnotation)
+		return false
+	}
+	return dryRun
+}
+
+// reportDryRun - reports update that would have been applied to the resource
+func (p *Provider) reportDryRun(plan *UpdatePlan, policyName string) {
+	resource := plan.Resource
+
+	log.WithFields(log.Fields{
+		"name":      resource.Name,
+		"kind":      resource.Kind(),
+		"namespace": resource.Namespace,
+		"update":    fmt.Sprintf("%s->%s", plan.CurrentVersion, plan.NewVersion),
+		"images":    strings.Join(resource.GetImages(), ", "),
+	}).Info("provider.kubernetes: dry run, resource not updated")
+
+	settings := resource.GetKeelAnnotations()
+	p.sender.Send(types.EventNotification{
+		ResourceKind: resource.Kind(),
+		Identifier:   resource.Identifier,
+		Name:         "dry run",
+		Message:      fmt.Sprintf("Dry run: would update %s %s/%s %s->%s (%s)", resource.Kind(), resource.Namespace, resource.Name, plan.CurrentVersion, plan.NewVersion, strings.Join(resource.GetImages(), ", ")),
+		CreatedAt:    time.Now(),
+		Type:         types.NotificationDeploymentUpdate,
+		Level:        types.LevelInfo,
+		Channels:     types.ParseEventNotificationChannelsFromLabelsOrAnnotations(resource.GetLabels(), settings),
+		MinLevel:     types.ParseEventNotificationLevel(resource.GetLabels(), settings),
+		Metadata: map[string]string{
+			"provider":  p.GetName(),
+			"namespace": resource.GetNamespace(),
+			"name":      resource.GetName(),
+			"previous":  plan.CurrentVersion,
+			"new":       plan.NewVersion,
+			"policy":    policyName,
+			"images":    strings.Join(resource.GetImages(), ", "),
+			"trigger":   plan.Trigger,
+			"dryRun":    "true",
+		},
+	})
+}
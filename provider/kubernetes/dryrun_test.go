@@ -0,0 +1,129 @@
+package kubernetes
+
+import (
+	"testing"
+
+	"github.com/keel-hq/keel/internal/k8s"
+	"github.com/keel-hq/keel/types"
+
+	apps_v1 "k8s.io/api/apps/v1"
+	v1 "k8s.io/api/core/v1"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func newDryRunDeployments(annotations map[string]string) []*apps_v1.Deployment {
+	return []*apps_v1.Deployment{
+		{
+			meta_v1.TypeMeta{},
+			meta_v1.ObjectMeta{
+				Name:        "deployment-1",
+				Namespace:   "xxxx",
+				Labels:      map[string]string{types.KeelPolicyLabel: "all"},
+				Annotations: annotations,
+			},
+			apps_v1.DeploymentSpec{
+				Template: v1.PodTemplateSpec{
+					Spec: v1.PodSpec{
+						Containers: []v1.Container{
+							{
+								Image: "gcr.io/v2-namespace/hello-world:10.0.0",
+							},
+						},
+					},
+				},
+			},
+			apps_v1.DeploymentStatus{},
+		},
+	}
+}
+
+func TestDryRunAnnotation(t *testing.T) {
+	fp := &fakeImplementer{client: fake.NewSimpleClientset()}
+
+	grc := &k8s.GenericResourceCache{}
+	grc.Add(MustParseGRS(newDryRunDeployments(map[string]string{
+		types.KeelDryRunAnnotation: "true",
+	}))...)
+
+	fs := &fakeSender{}
+	approver, teardown := approver()
+	defer teardown()
+	provider, err := NewProvider(fp, fs, approver, grc)
+	if err != nil {
+		t.Fatalf("failed to get provider: %s", err)
+	}
+
+	updated, err := provider.processEvent(&types.Event{Repository: types.Repository{
+		Name: "gcr.io/v2-namespace/hello-world",
+		Tag:  "11.0.0",
+	}})
+	if err != nil {
+		t.Fatalf("got error while processing event: %s", err)
+	}
+
+	if fp.updated != nil || len(updated) != 0 {
+		t.Fatalf("didn't expect resource to be updated in dry run")
+	}
+
+	if fs.sentEvent.Name != "dry run" {
+		t.Errorf("expected dry run update to be reported, got: %s", fs.sentEvent.Name)
+	}
+	if fs.sentEvent.Message != "Dry run: would update deployment xxxx/deployment-1 10.0.0->11.0.0 (gcr.io/v2-namespace/hello-world:11.0.0)" {
+		t.Errorf("unexpected message: %s", fs.sentEvent.Message)
+	}
+
+	events, err := fp.client.CoreV1().Events("xxxx").List(meta_v1.ListOptions{})
+	if err != nil {
+		t.Fatalf("failed to list events: %s", err)
+	}
+	if len(events.Items) != 0 {
+		t.Errorf("didn't expect events to be recorded in dry run, got: %d", len(events.Items))
+	}
+}
+
+func TestDryRunProvider(t *testing.T) {
+	fp := &fakeImplementer{client: fake.NewSimpleClientset()}
+
+	grc := &k8s.GenericResourceCache{}
+	grc.Add(MustParseGRS(newDryRunDeployments(map[string]string{}))...)
+
+	fs := &fakeSender{}
+	approver, teardown := approver()
+	defer teardown()
+	provider, err := NewProvider(fp, fs, approver, grc)
+	if err != nil {
+		t.Fatalf("failed to get provider: %s", err)
+	}
+	provider.SetDryRun(true)
+
+	_, err = provider.processEvent(&types.Event{Repository: types.Repository{
+		Name: "gcr.io/v2-namespace/hello-world",
+		Tag:  "11.0.0",
+	}})
+	if err != nil {
+		t.Fatalf("got error while processing event: %s", err)
+	}
+
+	if fp.updated != nil {
+		t.Fatalf("didn't expect resource to be updated in dry run")
+	}
+	if fs.sentEvent.Name != "dry run" {
+		t.Errorf("expected dry run update to be reported, got: %s", fs.sentEvent.Name)
+	}
+
+	// annotation can't opt out of global dry run
+	grc.Add(MustParseGRS(newDryRunDeployments(map[string]string{
+		types.KeelDryRunAnnotation: "false",
+	}))...)
+	_, err = provider.processEvent(&types.Event{Repository: types.Repository{
+		Name: "gcr.io/v2-namespace/hello-world",
+		Tag:  "11.0.0",
+	}})
+	if err != nil {
+		t.Fatalf("got error while processing event: %s", err)
+	}
+	if fp.updated != nil {
+		t.Errorf("didn't expect resource to be updated in dry run")
+	}
+}
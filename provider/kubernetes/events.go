@@ -0,0 +1,98 @@
+package kubernetes
+
+import (
+	"fmt"
+
+	"github.com/keel-hq/keel/internal/k8s"
+	"github.com/keel-hq/keel/util/timeutil"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// reasons of Kubernetes events recorded on managed resources
+const (
+	EventReasonUpdated       = "KeelUpdated"
+	EventReasonUpdateFailed  = "KeelUpdateFailed"
+	EventReasonUpdateSkipped = "KeelUpdateSkipped"
+	EventReasonRolledBack    = "KeelRolledBack"
+)
+
+// eventSource - component shown as the source of recorded events
+const eventSource = "keel"
+
+// recordEvent - records Kubernetes event on the resource so kubectl describe
+// shows what keel did, failures are only logged. Nothing is recorded in dry run
+func (p *Provider) recordEvent(resource *k8s.GenericResource, eventType, reason, message string) {
+	if p.isDryRun(resource) {
+		return
+	}
+
+	accessor, err := meta.Accessor(resource.GetResource())
+	if err != nil {
+		log.WithFields(log.Fields{
+			"error":    err,
+			"resource": resource.Identifier,
+		}).Error("provider.kubernetes: failed to get resource metadata, event not recorded")
+		return
+	}
+
+	apiVersion := "apps/v1"
+	if resource.Kind() == "cronjob" {
+		apiVersion = "batch/v1beta1"
+	}
+
+	now := meta_v1.NewTime(timeutil.Now())
+	event := &v1.Event{
+		ObjectMeta: meta_v1.ObjectMeta{
+			Name:      fmt.Sprintf("%s.%x", resource.Name, now.UnixNano()),
+			Namespace: resource.Namespace,
+		},
+		InvolvedObject: v1.ObjectReference{
+			Kind:            kindName(resource.Kind()),
+			APIVersion:      apiVersion,
+			Namespace:       resource.Namespace,
+			Name:            resource.Name,
+			UID:             accessor.GetUID(),
+			ResourceVersion: accessor.GetResourceVersion(),
+		},
+		Reason:         reason,
+		Message:        message,
+		Type:           eventType,
+		Count:          1,
+		FirstTimestamp: now,
+		LastTimestamp:  now,
+		Source: v1.EventSource{
+			Component: eventSource,
+		},
+	}
+
+	_, err = p.implementer.Events(resource.Namespace).Create(event)
+	if err != nil {
+		log.WithFields(log.Fields{
+			"error":     err,
+			"name":      resource.Name,
+			"kind":      resource.Kind(),
+			"namespace": resource.Namespace,
+			"reason":    reason,
+		}).Warn("provider.kubernetes: failed to record event")
+	}
+}
+
+// kindName - API kind of generic resource kind, ie: deployment -> Deployment
+func kindName(kind string) string {
+	switch kind {
+	case "deployment":
+		return "Deployment"
+	case "statefulset":
+		return "StatefulSet"
+	case "daemonset":
+		return "DaemonSet"
+	case "cronjob":
+		return "CronJob"
+	}
+	return kind
+}
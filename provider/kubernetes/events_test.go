@@ -0,0 +1,149 @@
+package kubernetes
+
+import (
+	"testing"
+
+	"github.com/keel-hq/keel/internal/k8s"
+	"github.com/keel-hq/keel/types"
+
+	apps_v1 "k8s.io/api/apps/v1"
+	v1 "k8s.io/api/core/v1"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestUpdatedEventRecorded(t *testing.T) {
+	fp := &fakeImplementer{client: fake.NewSimpleClientset()}
+	deps := []*apps_v1.Deployment{
+		{
+			meta_v1.TypeMeta{},
+			meta_v1.ObjectMeta{
+				Name:        "deployment-1",
+				Namespace:   "xxxx",
+				UID:         "1234",
+				Labels:      map[string]string{types.KeelPolicyLabel: "all"},
+				Annotations: map[string]string{},
+			},
+			apps_v1.DeploymentSpec{
+				Template: v1.PodTemplateSpec{
+					Spec: v1.PodSpec{
+						Containers: []v1.Container{
+							{
+								Image: "gcr.io/v2-namespace/hello-world:10.0.0",
+							},
+						},
+					},
+				},
+			},
+			apps_v1.DeploymentStatus{},
+		},
+	}
+
+	grc := &k8s.GenericResourceCache{}
+	grc.Add(MustParseGRS(deps)...)
+
+	approver, teardown := approver()
+	defer teardown()
+	provider, err := NewProvider(fp, &fakeSender{}, approver, grc)
+	if err != nil {
+		t.Fatalf("failed to get provider: %s", err)
+	}
+
+	_, err = provider.processEvent(&types.Event{Repository: types.Repository{
+		Name: "gcr.io/v2-namespace/hello-world",
+		Tag:  "11.0.0",
+	}})
+	if err != nil {
+		t.Fatalf("got error while processing event: %s", err)
+	}
+
+	events, err := fp.client.CoreV1().Events("xxxx").List(meta_v1.ListOptions{})
+	if err != nil {
+		t.Fatalf("failed to list events: %s", err)
+	}
+
+	if len(events.Items) != 1 {
+		t.Fatalf("expected 1 event, got: %d", len(events.Items))
+	}
+
+	event := events.Items[0]
+	if event.Reason != EventReasonUpdated {
+		t.Errorf("unexpected reason: %s", event.Reason)
+	}
+	if event.Type != v1.EventTypeNormal {
+		t.Errorf("unexpected type: %s", event.Type)
+	}
+	if event.InvolvedObject.Kind != "Deployment" || event.InvolvedObject.Name != "deployment-1" || event.InvolvedObject.UID != "1234" {
+		t.Errorf("unexpected involved object: %+v", event.InvolvedObject)
+	}
+	if event.Message != "Updated 10.0.0->11.0.0 (gcr.io/v2-namespace/hello-world:11.0.0)" {
+		t.Errorf("unexpected message: %s", event.Message)
+	}
+}
+
+func TestApprovalSkippedEventRecorded(t *testing.T) {
+	fp := &fakeImplementer{client: fake.NewSimpleClientset()}
+	deps := []*apps_v1.Deployment{
+		{
+			meta_v1.TypeMeta{},
+			meta_v1.ObjectMeta{
+				Name:      "deployment-1",
+				Namespace: "xxxx",
+				Labels:    map[string]string{types.KeelPolicyLabel: "all"},
+				Annotations: map[string]string{
+					types.KeelMinimumApprovalsLabel: "2",
+				},
+			},
+			apps_v1.DeploymentSpec{
+				Template: v1.PodTemplateSpec{
+					Spec: v1.PodSpec{
+						Containers: []v1.Container{
+							{
+								Image: "gcr.io/v2-namespace/hello-world:10.0.0",
+							},
+						},
+					},
+				},
+			},
+			apps_v1.DeploymentStatus{},
+		},
+	}
+
+	grc := &k8s.GenericResourceCache{}
+	grc.Add(MustParseGRS(deps)...)
+
+	approver, teardown := approver()
+	defer teardown()
+	provider, err := NewProvider(fp, &fakeSender{}, approver, grc)
+	if err != nil {
+		t.Fatalf("failed to get provider: %s", err)
+	}
+
+	_, err = provider.processEvent(&types.Event{Repository: types.Repository{
+		Name: "gcr.io/v2-namespace/hello-world",
+		Tag:  "11.0.0",
+	}})
+	if err != nil {
+		t.Fatalf("got error while processing event: %s", err)
+	}
+
+	if fp.updated != nil {
+		t.Errorf("didn't expect resource to be updated without approvals")
+	}
+
+	events, err := fp.client.CoreV1().Events("xxxx").List(meta_v1.ListOptions{})
+	if err != nil {
+		t.Fatalf("failed to list events: %s", err)
+	}
+
+	if len(events.Items) != 1 {
+		t.Fatalf("expected 1 event, got: %d", len(events.Items))
+	}
+
+	if events.Items[0].Reason != EventReasonUpdateSkipped {
+		t.Errorf("unexpected reason: %s", events.Items[0].Reason)
+	}
+	if events.Items[0].Message != "Update 10.0.0->11.0.0 is waiting for 2 approvals" {
+		t.Errorf("unexpected message: %s", events.Items[0].Message)
+	}
+}
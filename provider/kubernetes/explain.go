@@ -0,0 +1,60 @@
+package kubernetes
+
+import (
+	"github.com/keel-hq/keel/internal/policy"
+	"github.com/keel-hq/keel/types"
+	"github.com/keel-hq/keel/util/image"
+)
+
+// Explain - policy decisions for every container using the event image,
+// nothing is applied. Approvals, pauses and update windows aren't evaluated
+func (p *Provider) Explain(event types.Event) []*types.UpdateDecision {
+	decisions := []*types.UpdateDecision{}
+	if event.Chart != "" {
+		return decisions
+	}
+
+	eventRepoRef, err := image.Parse(event.Repository.String())
+	if err != nil {
+		return decisions
+	}
+
+	for _, resource := range p.cache.Values() {
+		if event.Target != "" && resource.Identifier != event.Target {
+			continue
+		}
+
+		annotations := resource.GetKeelAnnotations()
+		plc := policy.GetPolicyFromLabelsOrAnnotations(resource.GetLabels(), annotations)
+		if event.Target != "" {
+			plc = policy.NewForcePolicy(false)
+		}
+		digestPin := getDigestPin(resource.GetLabels(), annotations)
+		repo := p.resolveDigest(&event.Repository, resource)
+
+		for _, c := range trackedContainers(resource) {
+			imageRef, _ := image.SplitTagDigest(c.Image)
+			ref, err := image.Parse(imageRef)
+			if err != nil || ref.Repository() != eventRepoRef.Repository() {
+				continue
+			}
+
+			_, newImage, ok, reason := checkContainer(plc, repo, eventRepoRef, resource, c, annotations, digestPin)
+			decisions = append(decisions, &types.UpdateDecision{
+				Provider:   p.GetName(),
+				Identifier: resource.Identifier,
+				Kind:       resource.Kind(),
+				Namespace:  resource.Namespace,
+				Name:       resource.Name,
+				Container:  c.Name,
+				Image:      c.Image,
+				Policy:     policy.GetContainerPolicy(c.Name, plc, annotations).Name(),
+				Update:     ok,
+				NewImage:   newImage,
+				Reason:     reason,
+			})
+		}
+	}
+
+	return decisions
+}
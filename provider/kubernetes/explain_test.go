@@ -0,0 +1,53 @@
+package kubernetes
+
+import (
+	"testing"
+
+	"github.com/keel-hq/keel/internal/k8s"
+	"github.com/keel-hq/keel/types"
+)
+
+func TestExplain(t *testing.T) {
+	grc := &k8s.GenericResourceCache{}
+	updated := healthCheckDeployment("gcr.io/v2-namespace/hello-world:1.1.1")
+	grc.Add(updated)
+	minor := healthCheckDeployment("gcr.io/v2-namespace/hello-world:1.1.1")
+	minor.Name = "dep-2"
+	minor.Identifier = "deployment/xxxx/dep-2"
+	minor.SetLabels(map[string]string{types.KeelPolicyLabel: "minor"})
+	grc.Add(minor)
+	other := healthCheckDeployment("gcr.io/v2-namespace/other:1.1.1")
+	other.Name = "dep-3"
+	other.Identifier = "deployment/xxxx/dep-3"
+	grc.Add(other)
+
+	fi := &fakeImplementer{}
+	approver, teardown := approver()
+	defer teardown()
+	provider, err := NewProvider(fi, &fakeSender{}, approver, grc)
+	if err != nil {
+		t.Fatalf("failed to get provider: %s", err)
+	}
+
+	decisions := provider.Explain(types.Event{Repository: types.Repository{
+		Name: "gcr.io/v2-namespace/hello-world",
+		Tag:  "2.0.0",
+	}})
+	if len(decisions) != 2 {
+		t.Fatalf("expected decisions for resources using the image, got: %d", len(decisions))
+	}
+
+	byName := map[string]*types.UpdateDecision{}
+	for _, d := range decisions {
+		byName[d.Name] = d
+	}
+	if d := byName["dep-1"]; d == nil || !d.Update || d.NewImage != "gcr.io/v2-namespace/hello-world:2.0.0" || d.Policy != "all" {
+		t.Errorf("unexpected decision: %+v", d)
+	}
+	if d := byName["dep-2"]; d == nil || d.Update || d.Reason != "2.0.0 is a major update, policy minor allows minor and patch updates" {
+		t.Errorf("unexpected decision: %+v", d)
+	}
+	if fi.updated != nil {
+		t.Errorf("explain shouldn't update resources")
+	}
+}
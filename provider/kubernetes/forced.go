@@ -0,0 +1,38 @@
+package kubernetes
+
+import (
+	"fmt"
+
+	"github.com/keel-hq/keel/internal/policy"
+	"github.com/keel-hq/keel/types"
+)
+
+// createForcedUpdatePlans - plans update of the event target to the event tag
+// regardless of the resource update policy, so specific versions (including
+// older ones) can be rolled out on demand. Approvals, pauses and update
+// windows still apply
+func (p *Provider) createForcedUpdatePlans(event *types.Event) ([]*UpdatePlan, error) {
+	for _, resource := range p.cache.Values() {
+		if resource.Identifier != event.Target {
+			continue
+		}
+
+		previousImages := resource.GetImages()
+		previousInitImages := resource.GetInitImages()
+
+		updated, shouldUpdate, err := checkForUpdate(policy.NewForcePolicy(false), p.resolveDigest(&event.Repository, resource), resource)
+		if err != nil {
+			return nil, err
+		}
+		if !shouldUpdate {
+			return nil, fmt.Errorf("%s has no containers using image %s", event.Target, event.Repository.Name)
+		}
+
+		updated.PreviousImages = previousImages
+		updated.PreviousInitImages = previousInitImages
+		return []*UpdatePlan{updated}, nil
+	}
+
+	// target might be managed by a provider of another cluster
+	return nil, nil
+}
@@ -0,0 +1,117 @@
+package kubernetes
+
+import (
+	"testing"
+
+	"github.com/keel-hq/keel/internal/k8s"
+	"github.com/keel-hq/keel/types"
+
+	apps_v1 "k8s.io/api/apps/v1"
+	v1 "k8s.io/api/core/v1"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func forcedTestDeployment(name string, labels map[string]string) *apps_v1.Deployment {
+	return &apps_v1.Deployment{
+		ObjectMeta: meta_v1.ObjectMeta{
+			Name:        name,
+			Namespace:   "xxxx",
+			Labels:      labels,
+			Annotations: map[string]string{},
+		},
+		Spec: apps_v1.DeploymentSpec{
+			Template: v1.PodTemplateSpec{
+				Spec: v1.PodSpec{
+					Containers: []v1.Container{
+						{
+							Image: "gcr.io/v2-namespace/hello-world:1.1.1",
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+func TestProcessEventTarget(t *testing.T) {
+	fp := &fakeImplementer{}
+	deps := []*apps_v1.Deployment{
+		// no keel policy, forced updates don't need one
+		forcedTestDeployment("deployment-1", map[string]string{}),
+		forcedTestDeployment("deployment-2", map[string]string{types.KeelPolicyLabel: "all"}),
+	}
+
+	grs := MustParseGRS(deps)
+	grc := &k8s.GenericResourceCache{}
+	grc.Add(grs...)
+	approver, teardown := approver()
+	defer teardown()
+	provider, err := NewProvider(fp, &fakeSender{}, approver, grc)
+	if err != nil {
+		t.Fatalf("failed to get provider: %s", err)
+	}
+
+	// downgrade, not allowed by any semver policy
+	event := &types.Event{
+		Repository: types.Repository{
+			Name: "gcr.io/v2-namespace/hello-world",
+			Tag:  "1.0.0",
+		},
+		Target: "deployment/xxxx/deployment-1",
+	}
+
+	updated, err := provider.processEvent(event)
+	if err != nil {
+		t.Fatalf("got error while processing event: %s", err)
+	}
+
+	if len(updated) != 1 {
+		t.Fatalf("expected 1 updated resource, got: %d", len(updated))
+	}
+
+	if updated[0].Identifier != event.Target {
+		t.Errorf("unexpected resource updated: %s", updated[0].Identifier)
+	}
+
+	if fp.updated == nil {
+		t.Fatalf("resource was not updated")
+	}
+
+	if fp.updated.Containers()[0].Image != "gcr.io/v2-namespace/hello-world:1.0.0" {
+		t.Errorf("unexpected image: %s", fp.updated.Containers()[0].Image)
+	}
+}
+
+func TestProcessEventTargetImageMismatch(t *testing.T) {
+	fp := &fakeImplementer{}
+	deps := []*apps_v1.Deployment{
+		forcedTestDeployment("deployment-1", map[string]string{}),
+	}
+
+	grs := MustParseGRS(deps)
+	grc := &k8s.GenericResourceCache{}
+	grc.Add(grs...)
+	approver, teardown := approver()
+	defer teardown()
+	provider, err := NewProvider(fp, &fakeSender{}, approver, grc)
+	if err != nil {
+		t.Fatalf("failed to get provider: %s", err)
+	}
+
+	event := &types.Event{
+		Repository: types.Repository{
+			Name: "gcr.io/v2-namespace/bye-world",
+			Tag:  "1.0.0",
+		},
+		Target: "deployment/xxxx/deployment-1",
+	}
+
+	_, err = provider.processEvent(event)
+	if err == nil {
+		t.Fatalf("expected an error for an image that isn't used by the target")
+	}
+
+	if fp.updated != nil {
+		t.Errorf("resource should not have been updated")
+	}
+}
@@ -0,0 +1,102 @@
+package kubernetes
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/keel-hq/keel/pkg/gitops"
+	"github.com/keel-hq/keel/types"
+
+	v1 "k8s.io/api/core/v1"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// GitWriter - writes updates back to manifests in a Git repository, see pkg/gitops
+type GitWriter interface {
+	Write(ctx context.Context, change *gitops.Change) error
+}
+
+// SetGitWriter - commits updates to Git before they are applied, with patchCluster
+// disabled the cluster is left to be updated by Flux or Argo CD from the repository
+func (p *Provider) SetGitWriter(writer GitWriter, patchCluster bool) {
+	p.gitWriter = writer
+	p.gitPatchCluster = patchCluster
+}
+
+func gitChange(plan *UpdatePlan) *gitops.Change {
+	resource := plan.Resource
+	change := &gitops.Change{
+		Kind:      resource.Kind(),
+		Namespace: resource.Namespace,
+		Name:      resource.Name,
+	}
+
+	add := func(previous, current []string) {
+		for idx, image := range current {
+			if idx < len(previous) && previous[idx] != image {
+				change.Images = append(change.Images, gitops.ImageChange{Previous: previous[idx], New: image})
+			}
+		}
+	}
+	add(plan.PreviousImages, resource.GetImages())
+	add(plan.PreviousInitImages, resource.GetInitImages())
+
+	return change
+}
+
+// writeToGit - commits the update, failures are handled like failed cluster updates
+func (p *Provider) writeToGit(ctx context.Context, plan *UpdatePlan, policyName string) error {
+	resource := plan.Resource
+
+	err := p.gitWriter.Write(ctx, gitChange(plan))
+	if err != nil {
+		log.WithFields(log.Fields{
+			"error":     err,
+			"name":      resource.Name,
+			"kind":      resource.Kind(),
+			"namespace": resource.Namespace,
+			"update":    fmt.Sprintf("%s->%s", plan.CurrentVersion, plan.NewVersion),
+		}).Error("provider.kubernetes: failed to commit update to git")
+
+		p.notifyGit(plan, policyName, types.LevelError, fmt.Sprintf("Failed to commit %s %s/%s update %s->%s to git, error: %s", resource.Kind(), resource.Namespace, resource.Name, plan.CurrentVersion, plan.NewVersion, err))
+		p.recordEvent(resource, v1.EventTypeWarning, EventReasonUpdateFailed, fmt.Sprintf("Committing update %s->%s to git failed: %s", plan.CurrentVersion, plan.NewVersion, err))
+		p.updateFailed(plan, err)
+		return err
+	}
+
+	if !p.gitPatchCluster {
+		p.updateSucceeded(plan)
+		p.notifyGit(plan, policyName, types.LevelSuccess, fmt.Sprintf("Committed %s %s/%s update %s->%s to git (%s)", resource.Kind(), resource.Namespace, resource.Name, plan.CurrentVersion, plan.NewVersion, strings.Join(resource.GetImages(), ", ")))
+		p.recordEvent(resource, v1.EventTypeNormal, EventReasonUpdated, fmt.Sprintf("Committed %s->%s to git (%s)", plan.CurrentVersion, plan.NewVersion, strings.Join(resource.GetImages(), ", ")))
+	}
+	return nil
+}
+
+func (p *Provider) notifyGit(plan *UpdatePlan, policyName string, level types.Level, msg string) {
+	resource := plan.Resource
+	settings := resource.GetKeelAnnotations()
+	p.sender.Send(types.EventNotification{
+		ResourceKind: resource.Kind(),
+		Identifier:   resource.Identifier,
+		Name:         "commit update",
+		Message:      msg,
+		CreatedAt:    time.Now(),
+		Type:         types.NotificationDeploymentUpdate,
+		Level:        level,
+		Channels:     types.ParseEventNotificationChannelsFromLabelsOrAnnotations(resource.GetLabels(), settings),
+		MinLevel:     types.ParseEventNotificationLevel(resource.GetLabels(), settings),
+		Metadata: map[string]string{
+			"provider":  p.GetName(),
+			"namespace": resource.GetNamespace(),
+			"name":      resource.GetName(),
+			"previous":  plan.CurrentVersion,
+			"new":       plan.NewVersion,
+			"policy":    policyName,
+			"images":    strings.Join(resource.GetImages(), ", "),
+			"trigger":   plan.Trigger,
+		},
+	})
+}
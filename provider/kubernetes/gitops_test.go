@@ -0,0 +1,101 @@
+package kubernetes
+
+import (
+	"context"
+	"testing"
+
+	"github.com/keel-hq/keel/internal/k8s"
+	"github.com/keel-hq/keel/pkg/gitops"
+	"github.com/keel-hq/keel/types"
+
+	apps_v1 "k8s.io/api/apps/v1"
+	v1 "k8s.io/api/core/v1"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+type fakeGitWriter struct {
+	changes []*gitops.Change
+}
+
+func (w *fakeGitWriter) Write(ctx context.Context, change *gitops.Change) error {
+	w.changes = append(w.changes, change)
+	return nil
+}
+
+func TestGitWriteBack(t *testing.T) {
+	fp := &fakeImplementer{}
+	grc := &k8s.GenericResourceCache{}
+	grc.Add(MustParseGR(&apps_v1.Deployment{
+		meta_v1.TypeMeta{},
+		meta_v1.ObjectMeta{
+			Name:      "deployment-1",
+			Namespace: "xxxx",
+			Labels:    map[string]string{types.KeelPolicyLabel: "all"},
+		},
+		apps_v1.DeploymentSpec{
+			Template: v1.PodTemplateSpec{
+				Spec: v1.PodSpec{
+					Containers: []v1.Container{
+						{
+							Image: "gcr.io/v2-namespace/hello-world:10.0.0",
+						},
+						{
+							Image: "gcr.io/v2-namespace/sidecar:1.0.0",
+						},
+					},
+				},
+			},
+		},
+		apps_v1.DeploymentStatus{},
+	}))
+
+	approver, teardown := approver()
+	defer teardown()
+	provider, err := NewProvider(fp, &fakeSender{}, approver, grc)
+	if err != nil {
+		t.Fatalf("failed to get provider: %s", err)
+	}
+	writer := &fakeGitWriter{}
+	provider.SetGitWriter(writer, false)
+
+	updated, err := provider.processEvent(&types.Event{Repository: types.Repository{
+		Name: "gcr.io/v2-namespace/hello-world",
+		Tag:  "11.0.0",
+	}})
+	if err != nil {
+		t.Fatalf("got error while processing event: %s", err)
+	}
+	if len(updated) != 1 {
+		t.Errorf("expected committed update to be counted, got %d updated", len(updated))
+	}
+
+	if fp.updateAttempts != 0 {
+		t.Errorf("expected cluster not to be patched, got %d updates", fp.updateAttempts)
+	}
+	if len(writer.changes) != 1 {
+		t.Fatalf("expected 1 change committed, got: %d", len(writer.changes))
+	}
+
+	change := writer.changes[0]
+	if change.Name != "deployment-1" || change.Namespace != "xxxx" {
+		t.Errorf("unexpected change resource: %s/%s", change.Namespace, change.Name)
+	}
+	if len(change.Images) != 1 {
+		t.Fatalf("expected only updated image in change, got: %v", change.Images)
+	}
+	if change.Images[0].Previous != "gcr.io/v2-namespace/hello-world:10.0.0" || change.Images[0].New != "gcr.io/v2-namespace/hello-world:11.0.0" {
+		t.Errorf("unexpected image change: %v", change.Images[0])
+	}
+
+	provider.SetGitWriter(writer, true)
+	_, err = provider.processEvent(&types.Event{Repository: types.Repository{
+		Name: "gcr.io/v2-namespace/hello-world",
+		Tag:  "12.0.0",
+	}})
+	if err != nil {
+		t.Fatalf("got error while processing event: %s", err)
+	}
+	if fp.updateAttempts != 1 || len(writer.changes) != 2 {
+		t.Errorf("expected update committed and applied, got %d updates and %d changes", fp.updateAttempts, len(writer.changes))
+	}
+}
@@ -0,0 +1,166 @@
+package kubernetes
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/keel-hq/keel/internal/k8s"
+	"github.com/keel-hq/keel/internal/policy"
+	"github.com/keel-hq/keel/types"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// groupedUpdate - pending update of a group member, events by repository so
+// members using several updated images get all of them
+type groupedUpdate struct {
+	events map[string]types.Event
+}
+
+func getGroup(resource *k8s.GenericResource) string {
+	return strings.TrimSpace(resource.GetKeelAnnotations()[types.KeelGroupAnnotation])
+}
+
+// groupMembers - identifiers of resources in the group, sorted
+func (p *Provider) groupMembers(namespace, group string) []string {
+	var members []string
+	for _, gr := range p.cache.Values() {
+		if gr.Namespace == namespace && getGroup(gr) == group {
+			members = append(members, gr.Identifier)
+		}
+	}
+	sort.Strings(members)
+	return members
+}
+
+// holdGrouped - holds plans of grouped resources until all group members have
+// updates ready, then returns updates of the whole group
+func (p *Provider) holdGrouped(event *types.Event, plans []*UpdatePlan) []*UpdatePlan {
+	var ready []*UpdatePlan
+
+	p.groupsMu.Lock()
+	defer p.groupsMu.Unlock()
+
+	// groups touched by this event, in order
+	var touched []*k8s.GenericResource
+	seen := map[string]bool{}
+	for _, plan := range plans {
+		resource := plan.Resource
+		group := getGroup(resource)
+		if group == "" {
+			ready = append(ready, plan)
+			continue
+		}
+
+		key := resource.Namespace + "/" + group
+		if p.groups[key] == nil {
+			p.groups[key] = make(map[string]*groupedUpdate)
+		}
+		if !seen[key] {
+			seen[key] = true
+			touched = append(touched, resource)
+		}
+		pending, ok := p.groups[key][resource.Identifier]
+		if !ok {
+			pending = &groupedUpdate{events: make(map[string]types.Event)}
+			p.groups[key][resource.Identifier] = pending
+		}
+		pending.events[event.Repository.Name] = *event
+	}
+
+	for _, resource := range touched {
+		group := getGroup(resource)
+		key := resource.Namespace + "/" + group
+		members := p.groupMembers(resource.Namespace, group)
+		var waiting []string
+		for _, member := range members {
+			if _, ok := p.groups[key][member]; !ok {
+				waiting = append(waiting, member)
+			}
+		}
+		if len(waiting) > 0 {
+			log.WithFields(log.Fields{
+				"namespace": resource.Namespace,
+				"group":     group,
+				"waiting":   strings.Join(waiting, ", "),
+			}).Info("provider.kubernetes: update held until all group members have updates")
+			continue
+		}
+
+		ready = append(ready, p.releaseGroup(key, members)...)
+		delete(p.groups, key)
+	}
+
+	return ready
+}
+
+// releaseGroup - plans updates of all group members against their latest
+// version. Members with several updated images get a single plan, its versions
+// list versions of all updated images ordered by repository so retries and
+// backoff see the same plan every time
+func (p *Provider) releaseGroup(key string, members []string) []*UpdatePlan {
+	var plans []*UpdatePlan
+
+	for _, identifier := range members {
+		pending := p.groups[key][identifier]
+		resource := p.currentResource(identifier)
+		if resource == nil {
+			continue
+		}
+
+		previousImages := resource.GetImages()
+		previousInitImages := resource.GetInitImages()
+		plc := policy.GetPolicyFromLabelsOrAnnotations(resource.GetLabels(), resource.GetKeelAnnotations())
+
+		repositories := make([]string, 0, len(pending.events))
+		for name := range pending.events {
+			repositories = append(repositories, name)
+		}
+		sort.Strings(repositories)
+
+		var plan *UpdatePlan
+		var currentVersions, newVersions []string
+		for _, name := range repositories {
+			event := pending.events[name]
+			repo := event.Repository
+			updated, shouldUpdate, err := checkForUpdate(plc, p.resolveDigest(&repo, resource), resource)
+			if err != nil {
+				log.WithFields(log.Fields{
+					"error":     err,
+					"name":      resource.Name,
+					"kind":      resource.Kind(),
+					"namespace": resource.Namespace,
+				}).Error("provider.kubernetes: got error while checking grouped resource")
+				continue
+			}
+			if !shouldUpdate {
+				continue
+			}
+			currentVersions = append(currentVersions, updated.CurrentVersion)
+			newVersions = append(newVersions, updated.NewVersion)
+			if plan == nil {
+				// resource is updated in place, first plan carries all images
+				updated.Trigger = event.TriggerName
+				updated.event = &event
+				plan = updated
+			}
+		}
+		if plan == nil {
+			// already up to date
+			continue
+		}
+
+		plan.CurrentVersion = strings.Join(currentVersions, ", ")
+		plan.NewVersion = strings.Join(newVersions, ", ")
+		plan.PreviousImages = previousImages
+		plan.PreviousInitImages = previousInitImages
+		plans = append(plans, plan)
+	}
+
+	log.WithFields(log.Fields{
+		"group":   key,
+		"members": strings.Join(members, ", "),
+	}).Info("provider.kubernetes: all group members have updates, updating group")
+
+	return plans
+}
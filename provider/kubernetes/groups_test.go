@@ -0,0 +1,155 @@
+package kubernetes
+
+import (
+	"testing"
+
+	"github.com/keel-hq/keel/internal/k8s"
+	"github.com/keel-hq/keel/types"
+
+	apps_v1 "k8s.io/api/apps/v1"
+	v1 "k8s.io/api/core/v1"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestGroupedUpdates(t *testing.T) {
+	fp := &fakeImplementer{}
+	var deps []*apps_v1.Deployment
+	for name, image := range map[string]string{
+		"frontend": "gcr.io/v2-namespace/frontend:1.0.0",
+		"backend":  "gcr.io/v2-namespace/backend:1.0.0",
+	} {
+		deps = append(deps, &apps_v1.Deployment{
+			meta_v1.TypeMeta{},
+			meta_v1.ObjectMeta{
+				Name:        name,
+				Namespace:   "xxxx",
+				Labels:      map[string]string{types.KeelPolicyLabel: "all"},
+				Annotations: map[string]string{types.KeelGroupAnnotation: "shop"},
+			},
+			apps_v1.DeploymentSpec{
+				Template: v1.PodTemplateSpec{
+					Spec: v1.PodSpec{
+						Containers: []v1.Container{
+							{
+								Image: image,
+							},
+						},
+					},
+				},
+			},
+			apps_v1.DeploymentStatus{},
+		})
+	}
+
+	grc := &k8s.GenericResourceCache{}
+	grc.Add(MustParseGRS(deps)...)
+
+	approver, teardown := approver()
+	defer teardown()
+	provider, err := NewProvider(fp, &fakeSender{}, approver, grc)
+	if err != nil {
+		t.Fatalf("failed to get provider: %s", err)
+	}
+
+	updated, err := provider.processEvent(&types.Event{Repository: types.Repository{
+		Name: "gcr.io/v2-namespace/frontend",
+		Tag:  "1.1.0",
+	}})
+	if err != nil {
+		t.Fatalf("got error while processing event: %s", err)
+	}
+	if len(updated) != 0 || fp.updateAttempts != 0 {
+		t.Fatalf("expected frontend update to wait for backend, got %d updates", fp.updateAttempts)
+	}
+
+	updated, err = provider.processEvent(&types.Event{Repository: types.Repository{
+		Name: "gcr.io/v2-namespace/backend",
+		Tag:  "1.1.0",
+	}})
+	if err != nil {
+		t.Fatalf("got error while processing event: %s", err)
+	}
+	if len(updated) != 2 {
+		t.Fatalf("expected both group members to be updated, got: %d", len(updated))
+	}
+
+	images := map[string]string{}
+	for _, gr := range updated {
+		images[gr.Name] = gr.GetImages()[0]
+	}
+	if images["frontend"] != "gcr.io/v2-namespace/frontend:1.1.0" {
+		t.Errorf("unexpected frontend image: %s", images["frontend"])
+	}
+	if images["backend"] != "gcr.io/v2-namespace/backend:1.1.0" {
+		t.Errorf("unexpected backend image: %s", images["backend"])
+	}
+}
+
+func TestReleaseGroupSeveralImages(t *testing.T) {
+	dep := &apps_v1.Deployment{
+		meta_v1.TypeMeta{},
+		meta_v1.ObjectMeta{
+			Name:        "frontend",
+			Namespace:   "xxxx",
+			Labels:      map[string]string{types.KeelPolicyLabel: "all"},
+			Annotations: map[string]string{types.KeelGroupAnnotation: "shop"},
+		},
+		apps_v1.DeploymentSpec{
+			Template: v1.PodTemplateSpec{
+				Spec: v1.PodSpec{
+					Containers: []v1.Container{
+						{Image: "gcr.io/v2-namespace/frontend:1.0.0"},
+						{Image: "gcr.io/v2-namespace/sidecar:2.0.0"},
+					},
+				},
+			},
+		},
+		apps_v1.DeploymentStatus{},
+	}
+
+	grc := &k8s.GenericResourceCache{}
+	grc.Add(MustParseGRS([]*apps_v1.Deployment{dep})...)
+
+	approver, teardown := approver()
+	defer teardown()
+	provider, err := NewProvider(&fakeImplementer{}, &fakeSender{}, approver, grc)
+	if err != nil {
+		t.Fatalf("failed to get provider: %s", err)
+	}
+
+	identifier := grc.Values()[0].Identifier
+	provider.groups["xxxx/shop"] = map[string]*groupedUpdate{
+		identifier: {events: map[string]types.Event{
+			"gcr.io/v2-namespace/sidecar": {
+				Repository:  types.Repository{Name: "gcr.io/v2-namespace/sidecar", Tag: "2.1.0"},
+				TriggerName: "poll",
+			},
+			"gcr.io/v2-namespace/frontend": {
+				Repository:  types.Repository{Name: "gcr.io/v2-namespace/frontend", Tag: "1.1.0"},
+				TriggerName: "webhook",
+			},
+		}},
+	}
+
+	// map iteration order is random, plans have to be the same every time
+	for i := 0; i < 20; i++ {
+		plans := provider.releaseGroup("xxxx/shop", []string{identifier})
+		if len(plans) != 1 {
+			t.Fatalf("expected 1 plan, got: %d", len(plans))
+		}
+		plan := plans[0]
+		if plan.CurrentVersion != "1.0.0, 2.0.0" || plan.NewVersion != "1.1.0, 2.1.0" {
+			t.Fatalf("unexpected versions: %s->%s", plan.CurrentVersion, plan.NewVersion)
+		}
+		if plan.Trigger != "webhook" {
+			t.Fatalf("unexpected trigger: %s", plan.Trigger)
+		}
+		if plan.Image() != "gcr.io/v2-namespace/frontend" {
+			t.Fatalf("unexpected image: %s", plan.Image())
+		}
+		images := plan.Resource.GetImages()
+		if images[0] != "gcr.io/v2-namespace/frontend:1.1.0" || images[1] != "gcr.io/v2-namespace/sidecar:2.1.0" {
+			t.Fatalf("unexpected images: %v", images)
+		}
+	}
+}
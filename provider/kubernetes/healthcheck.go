@@ -0,0 +1,239 @@
+package kubernetes
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/keel-hq/keel/internal/k8s"
+	"github.com/keel-hq/keel/types"
+
+	v1 "k8s.io/api/core/v1"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// default post-update health check settings
+const (
+	DefaultHealthCheckPeriod           = 5 * time.Minute
+	DefaultHealthCheckInterval         = 30 * time.Second
+	DefaultHealthCheckFailureThreshold = 3
+)
+
+var healthCheckClient = &http.Client{
+	Timeout: 10 * time.Second,
+}
+
+// healthCheck - external health check that has to keep passing for the
+// whole period after an update, otherwise the update is rolled back
+type healthCheck struct {
+	url              string
+	period           time.Duration
+	interval         time.Duration
+	failureThreshold int
+}
+
+// getHealthCheck - parses health check configuration from resource annotations,
+// returns nil if health check is not configured
+func getHealthCheck(annotations map[string]string) (*healthCheck, error) {
+	url := strings.TrimSpace(annotations[types.KeelHealthCheckURLAnnotation])
+	if url == "" {
+		return nil, nil
+	}
+
+	hc := &healthCheck{
+		url:              url,
+		period:           DefaultHealthCheckPeriod,
+		interval:         DefaultHealthCheckInterval,
+		failureThreshold: DefaultHealthCheckFailureThreshold,
+	}
+
+	if val, ok := annotations[types.KeelHealthCheckPeriodAnnotation]; ok {
+		period, err := time.ParseDuration(val)
+		if err != nil || period <= 0 {
+			return nil, fmt.Errorf("invalid health check period '%s'", val)
+		}
+		hc.period = period
+	}
+
+	if val, ok := annotations[types.KeelHealthCheckIntervalAnnotation]; ok {
+		interval, err := time.ParseDuration(val)
+		if err != nil || interval <= 0 {
+			return nil, fmt.Errorf("invalid health check interval '%s'", val)
+		}
+		hc.interval = interval
+	}
+
+	if val, ok := annotations[types.KeelHealthCheckFailureThresholdAnnotation]; ok {
+		threshold, err := strconv.Atoi(val)
+		if err != nil || threshold <= 0 {
+			return nil, fmt.Errorf("invalid health check failure threshold '%s'", val)
+		}
+		hc.failureThreshold = threshold
+	}
+
+	return hc, nil
+}
+
+// checkURL - probes URL, any 2xx status code passes
+func checkURL(url string) error {
+	resp, err := healthCheckClient.Get(url)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("health check returned status code %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// checkHealth - keeps checking external health endpoint for the configured period,
+// fails once the failure threshold is reached
+func (v *updateVerifier) checkHealth(hc *healthCheck) error {
+	resource := v.plan.Resource
+
+	deadline := time.NewTimer(hc.period)
+	defer deadline.Stop()
+	ticker := time.NewTicker(hc.interval)
+	defer ticker.Stop()
+
+	failures := 0
+	for {
+		select {
+		case <-v.provider.stop:
+			return errStopped
+		case <-v.failed:
+			return errStopped
+		case <-deadline.C:
+			log.WithFields(log.Fields{
+				"name":      resource.Name,
+				"namespace": resource.Namespace,
+				"kind":      resource.Kind(),
+				"url":       hc.url,
+			}).Info("provider.kubernetes: health check passed, update verified")
+			return nil
+		case <-ticker.C:
+			err := checkURL(hc.url)
+			if err == nil {
+				failures = 0
+				continue
+			}
+
+			failures++
+			log.WithFields(log.Fields{
+				"error":     err,
+				"name":      resource.Name,
+				"namespace": resource.Namespace,
+				"kind":      resource.Kind(),
+				"url":       hc.url,
+				"failures":  failures,
+			}).Warn("provider.kubernetes: health check failed")
+
+			if failures < hc.failureThreshold {
+				continue
+			}
+			return fmt.Errorf("health check failed: %s", err)
+		}
+	}
+}
+
+// superseded - resource was updated again since the plan was applied, cache
+// might not have caught up with the update yet so previous images still match
+func superseded(plan *UpdatePlan, current *k8s.GenericResource) bool {
+	images := strings.Join(current.GetImages(), ",")
+	return images != strings.Join(plan.Resource.GetImages(), ",") &&
+		images != strings.Join(plan.PreviousImages, ",")
+}
+
+// rollback - restores images that were used before the update. Resource lock is
+// held while rolling back, resource isn't rolled back if it was updated again
+func (p *Provider) rollback(plan *UpdatePlan, reason error) error {
+	if len(plan.PreviousImages) == 0 {
+		return fmt.Errorf("previous images of %s are unknown", plan.Resource.Identifier)
+	}
+
+	_, unlock, err := p.locks.Lock(context.Background(), plan.Resource.Identifier)
+	if err != nil {
+		return fmt.Errorf("failed to acquire lock for %s: %s", plan.Resource.Identifier, err)
+	}
+	defer unlock()
+
+	// using latest known version of the resource so we don't revert
+	// unrelated changes
+	resource := plan.Resource
+	if current := p.currentResource(plan.Resource.Identifier); current != nil {
+		if superseded(plan, current) {
+			log.WithFields(log.Fields{
+				"name":      resource.Name,
+				"namespace": resource.Namespace,
+				"kind":      resource.Kind(),
+				"reason":    reason,
+			}).Info("provider.kubernetes: resource was updated again, skipping rollback")
+			return nil
+		}
+		resource = current
+	}
+
+	for idx := range resource.Containers() {
+		if idx < len(plan.PreviousImages) {
+			resource.UpdateContainer(idx, plan.PreviousImages[idx])
+		}
+	}
+	for idx := range resource.InitContainers() {
+		if idx < len(plan.PreviousInitImages) {
+			resource.UpdateInitContainer(idx, plan.PreviousInitImages[idx])
+		}
+	}
+
+	setChangeCause(resource, "rollback", "rollback", plan.NewVersion, plan.CurrentVersion)
+	setUpdateTime(resource)
+
+	err = p.update(resource)
+
+	notificationChannels := types.ParseEventNotificationChannelsFromLabelsOrAnnotations(resource.GetLabels(), resource.GetKeelAnnotations())
+	notificationLevel := types.ParseEventNotificationLevel(resource.GetLabels(), resource.GetKeelAnnotations())
+	metadata := map[string]string{
+		"provider":  p.GetName(),
+		"namespace": resource.GetNamespace(),
+		"name":      resource.GetName(),
+	}
+	if err != nil {
+		p.sender.Send(types.EventNotification{
+			ResourceKind: resource.Kind(),
+			Identifier:   resource.Identifier,
+			Name:         "rollback resource",
+			Message:      fmt.Sprintf("%s %s/%s rollback %s->%s failed, error: %s", resource.Kind(), resource.Namespace, resource.Name, plan.NewVersion, plan.CurrentVersion, err),
+			CreatedAt:    time.Now(),
+			Type:         types.NotificationDeploymentUpdate,
+			Level:        types.LevelError,
+			Channels:     notificationChannels,
+			MinLevel:     notificationLevel,
+			Metadata:     metadata,
+		})
+		p.recordEvent(resource, v1.EventTypeWarning, EventReasonUpdateFailed, fmt.Sprintf("Rollback %s->%s failed: %s", plan.NewVersion, plan.CurrentVersion, err))
+		p.recordHistory(types.HistoryActionFailed, plan, fmt.Sprintf("rollback failed: %s", err))
+		return err
+	}
+
+	p.sender.Send(types.EventNotification{
+		ResourceKind: resource.Kind(),
+		Identifier:   resource.Identifier,
+		Name:         "rollback resource",
+		Message:      fmt.Sprintf("Rolled back %s %s/%s %s->%s, %s", resource.Kind(), resource.Namespace, resource.Name, plan.NewVersion, plan.CurrentVersion, reason),
+		CreatedAt:    time.Now(),
+		Type:         types.NotificationDeploymentUpdate,
+		Level:        types.LevelWarn,
+		Channels:     notificationChannels,
+		MinLevel:     notificationLevel,
+		Metadata:     metadata,
+	})
+	p.recordEvent(resource, v1.EventTypeWarning, EventReasonRolledBack, fmt.Sprintf("Rolled back %s->%s: %s", plan.NewVersion, plan.CurrentVersion, reason))
+	p.recordHistory(types.HistoryActionRolledBack, plan, reason.Error())
+
+	return nil
+}
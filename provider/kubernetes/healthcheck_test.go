@@ -0,0 +1,258 @@
+package kubernetes
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/keel-hq/keel/internal/k8s"
+	"github.com/keel-hq/keel/types"
+
+	apps_v1 "k8s.io/api/apps/v1"
+	v1 "k8s.io/api/core/v1"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestGetHealthCheck(t *testing.T) {
+	tests := []struct {
+		name        string
+		annotations map[string]string
+		want        *healthCheck
+		wantErr     bool
+	}{
+		{
+			name:        "not configured",
+			annotations: map[string]string{},
+			want:        nil,
+		},
+		{
+			name:        "defaults",
+			annotations: map[string]string{types.KeelHealthCheckURLAnnotation: "http://example.com/health"},
+			want: &healthCheck{
+				url:              "http://example.com/health",
+				period:           DefaultHealthCheckPeriod,
+				interval:         DefaultHealthCheckInterval,
+				failureThreshold: DefaultHealthCheckFailureThreshold,
+			},
+		},
+		{
+			name: "custom",
+			annotations: map[string]string{
+				types.KeelHealthCheckURLAnnotation:              "http://example.com/health",
+				types.KeelHealthCheckPeriodAnnotation:           "10m",
+				types.KeelHealthCheckIntervalAnnotation:         "5s",
+				types.KeelHealthCheckFailureThresholdAnnotation: "1",
+			},
+			want: &healthCheck{
+				url:              "http://example.com/health",
+				period:           10 * time.Minute,
+				interval:         5 * time.Second,
+				failureThreshold: 1,
+			},
+		},
+		{
+			name: "invalid period",
+			annotations: map[string]string{
+				types.KeelHealthCheckURLAnnotation:    "http://example.com/health",
+				types.KeelHealthCheckPeriodAnnotation: "forever",
+			},
+			wantErr: true,
+		},
+		{
+			name: "invalid threshold",
+			annotations: map[string]string{
+				types.KeelHealthCheckURLAnnotation:              "http://example.com/health",
+				types.KeelHealthCheckFailureThresholdAnnotation: "0",
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := getHealthCheck(tt.annotations)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("getHealthCheck() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.want == nil && got != nil {
+				t.Fatalf("getHealthCheck() expected nil, got %+v", got)
+			}
+			if tt.want != nil && *got != *tt.want {
+				t.Errorf("getHealthCheck() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func healthCheckDeployment(img string) *k8s.GenericResource {
+	return MustParseGR(&apps_v1.Deployment{
+		meta_v1.TypeMeta{},
+		meta_v1.ObjectMeta{
+			Name:        "dep-1",
+			Namespace:   "xxxx",
+			Annotations: map[string]string{},
+			Labels:      map[string]string{types.KeelPolicyLabel: "all"},
+		},
+		apps_v1.DeploymentSpec{
+			Template: v1.PodTemplateSpec{
+				ObjectMeta: meta_v1.ObjectMeta{
+					Annotations: map[string]string{},
+				},
+				Spec: v1.PodSpec{
+					Containers: []v1.Container{
+						{
+							Image: img,
+						},
+					},
+				},
+			},
+		},
+		apps_v1.DeploymentStatus{},
+	})
+}
+
+func TestVerifyUpdateRollback(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer ts.Close()
+
+	grc := &k8s.GenericResourceCache{}
+	grc.Add(healthCheckDeployment("gcr.io/v2-namespace/hello-world:1.1.1"))
+
+	fi := &fakeImplementer{}
+	fs := &fakeSender{}
+	approver, teardown := approver()
+	defer teardown()
+	provider, err := NewProvider(fi, fs, approver, grc)
+	if err != nil {
+		t.Fatalf("failed to get provider: %s", err)
+	}
+
+	plan := &UpdatePlan{
+		Resource:       healthCheckDeployment("gcr.io/v2-namespace/hello-world:1.1.2"),
+		CurrentVersion: "1.1.1",
+		NewVersion:     "1.1.2",
+		PreviousImages: []string{"gcr.io/v2-namespace/hello-world:1.1.1"},
+	}
+
+	v := newUpdateVerifier(provider, plan)
+	v.run(func() error {
+		return v.checkHealth(&healthCheck{
+			url:              ts.URL,
+			period:           time.Second,
+			interval:         5 * time.Millisecond,
+			failureThreshold: 2,
+		})
+	})
+	v.wait()
+
+	if fi.updated == nil {
+		t.Fatalf("expected resource to be rolled back")
+	}
+
+	if fi.updated.GetImages()[0] != "gcr.io/v2-namespace/hello-world:1.1.1" {
+		t.Errorf("unexpected image after rollback: %s", fi.updated.GetImages()[0])
+	}
+
+	if fs.sentEvent.Level != types.LevelWarn {
+		t.Errorf("expected rollback notification, got: %+v", fs.sentEvent)
+	}
+}
+
+func TestVerifyUpdateHealthy(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer ts.Close()
+
+	fi := &fakeImplementer{}
+	approver, teardown := approver()
+	defer teardown()
+	provider, err := NewProvider(fi, &fakeSender{}, approver, &k8s.GenericResourceCache{})
+	if err != nil {
+		t.Fatalf("failed to get provider: %s", err)
+	}
+
+	plan := &UpdatePlan{
+		Resource:       healthCheckDeployment("gcr.io/v2-namespace/hello-world:1.1.2"),
+		CurrentVersion: "1.1.1",
+		NewVersion:     "1.1.2",
+		PreviousImages: []string{"gcr.io/v2-namespace/hello-world:1.1.1"},
+	}
+
+	v := newUpdateVerifier(provider, plan)
+	v.run(func() error {
+		return v.checkHealth(&healthCheck{
+			url:              ts.URL,
+			period:           50 * time.Millisecond,
+			interval:         5 * time.Millisecond,
+			failureThreshold: 1,
+		})
+	})
+	v.wait()
+
+	if fi.updated != nil {
+		t.Errorf("healthy resource shouldn't be rolled back")
+	}
+}
+
+func TestRollbackSuperseded(t *testing.T) {
+	grc := &k8s.GenericResourceCache{}
+	grc.Add(healthCheckDeployment("gcr.io/v2-namespace/hello-world:1.1.3"))
+
+	fi := &fakeImplementer{}
+	approver, teardown := approver()
+	defer teardown()
+	provider, err := NewProvider(fi, &fakeSender{}, approver, grc)
+	if err != nil {
+		t.Fatalf("failed to get provider: %s", err)
+	}
+
+	plan := &UpdatePlan{
+		Resource:       healthCheckDeployment("gcr.io/v2-namespace/hello-world:1.1.2"),
+		CurrentVersion: "1.1.1",
+		NewVersion:     "1.1.2",
+		PreviousImages: []string{"gcr.io/v2-namespace/hello-world:1.1.1"},
+	}
+
+	err = provider.rollback(plan, fmt.Errorf("health check failed"))
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if fi.updated != nil {
+		t.Errorf("resource updated to 1.1.3 shouldn't be rolled back")
+	}
+}
+
+func TestUpdateDeploymentOutdatedPlan(t *testing.T) {
+	grc := &k8s.GenericResourceCache{}
+	grc.Add(healthCheckDeployment("gcr.io/v2-namespace/hello-world:1.1.2"))
+
+	fi := &fakeImplementer{}
+	approver, teardown := approver()
+	defer teardown()
+	provider, err := NewProvider(fi, &fakeSender{}, approver, grc)
+	if err != nil {
+		t.Fatalf("failed to get provider: %s", err)
+	}
+
+	// planned before another trigger updated the resource to 1.1.2
+	plan := &UpdatePlan{
+		Resource:       healthCheckDeployment("gcr.io/v2-namespace/hello-world:1.1.3"),
+		CurrentVersion: "1.1.1",
+		NewVersion:     "1.1.3",
+		PreviousImages: []string{"gcr.io/v2-namespace/hello-world:1.1.1"},
+	}
+
+	updated, err := provider.updateDeployments(context.Background(), []*UpdatePlan{plan})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if len(updated) != 0 || fi.updated != nil {
+		t.Errorf("outdated plan shouldn't be applied")
+	}
+}
@@ -0,0 +1,194 @@
+package kubernetes
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/keel-hq/keel/types"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// default post-update health query settings
+const (
+	DefaultHealthQueryPeriod   = 5 * time.Minute
+	DefaultHealthQueryInterval = 30 * time.Second
+)
+
+// MetricsQuerier - evaluates PromQL expressions, see pkg/promquery
+type MetricsQuerier interface {
+	Query(ctx context.Context, query string) ([]float64, error)
+}
+
+// SetMetricsQuerier - enables health queries set via keel.sh/healthQuery,
+// updates are rolled back when the query breaches its threshold during the
+// soak window
+func (p *Provider) SetMetricsQuerier(querier MetricsQuerier) {
+	p.metrics = querier
+}
+
+// healthQuery - PromQL expression that must not breach the threshold for the
+// whole period after an update, otherwise the update is rolled back
+type healthQuery struct {
+	query    string
+	period   time.Duration
+	interval time.Duration
+
+	// comparison breaching values match, any value breaches if op is empty
+	op        string
+	threshold float64
+}
+
+// getHealthQuery - parses health query configuration from resource annotations,
+// returns nil if health query is not configured
+func getHealthQuery(annotations map[string]string) (*healthQuery, error) {
+	query := strings.TrimSpace(annotations[types.KeelHealthQueryAnnotation])
+	if query == "" {
+		return nil, nil
+	}
+
+	hq := &healthQuery{
+		query:    query,
+		period:   DefaultHealthQueryPeriod,
+		interval: DefaultHealthQueryInterval,
+	}
+
+	if val, ok := annotations[types.KeelHealthQueryThresholdAnnotation]; ok {
+		op, threshold, err := parseThreshold(val)
+		if err != nil {
+			return nil, err
+		}
+		hq.op = op
+		hq.threshold = threshold
+	}
+
+	if val, ok := annotations[types.KeelHealthQueryPeriodAnnotation]; ok {
+		period, err := time.ParseDuration(val)
+		if err != nil || period <= 0 {
+			return nil, fmt.Errorf("invalid health query period '%s'", val)
+		}
+		hq.period = period
+	}
+
+	if val, ok := annotations[types.KeelHealthQueryIntervalAnnotation]; ok {
+		interval, err := time.ParseDuration(val)
+		if err != nil || interval <= 0 {
+			return nil, fmt.Errorf("invalid health query interval '%s'", val)
+		}
+		hq.interval = interval
+	}
+
+	return hq, nil
+}
+
+// parseThreshold - parses "<op> <value>", op defaults to ">"
+func parseThreshold(val string) (op string, threshold float64, err error) {
+	s := strings.TrimSpace(val)
+	op = ">"
+	for _, candidate := range []string{">=", "<=", "==", "!=", ">", "<"} {
+		if strings.HasPrefix(s, candidate) {
+			op = candidate
+			s = strings.TrimSpace(strings.TrimPrefix(s, candidate))
+			break
+		}
+	}
+
+	threshold, err = strconv.ParseFloat(s, 64)
+	if err != nil {
+		return "", 0, fmt.Errorf("invalid health query threshold '%s'", val)
+	}
+	return op, threshold, nil
+}
+
+// breached - returns first value breaching the threshold
+func (hq *healthQuery) breached(values []float64) (float64, bool) {
+	for _, v := range values {
+		var breach bool
+		switch hq.op {
+		case "":
+			breach = true
+		case ">":
+			breach = v > hq.threshold
+		case ">=":
+			breach = v >= hq.threshold
+		case "<":
+			breach = v < hq.threshold
+		case "<=":
+			breach = v <= hq.threshold
+		case "==":
+			breach = v == hq.threshold
+		case "!=":
+			breach = v != hq.threshold
+		}
+		if breach {
+			return v, true
+		}
+	}
+	return 0, false
+}
+
+func (hq *healthQuery) String() string {
+	if hq.op == "" {
+		return hq.query
+	}
+	return fmt.Sprintf("%s %s %s", hq.query, hq.op, strconv.FormatFloat(hq.threshold, 'g', -1, 64))
+}
+
+// checkHealthQuery - keeps evaluating health query for the configured period,
+// fails once it breaches the threshold. Query errors (ie: Prometheus
+// unavailable) don't fail the update
+func (v *updateVerifier) checkHealthQuery(hq *healthQuery) error {
+	p := v.provider
+	resource := v.plan.Resource
+	images := strings.Join(resource.GetImages(), ",")
+
+	deadline := time.NewTimer(hq.period)
+	defer deadline.Stop()
+	ticker := time.NewTicker(hq.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-p.stop:
+			return errStopped
+		case <-v.failed:
+			return errStopped
+		case <-deadline.C:
+			log.WithFields(log.Fields{
+				"name":      resource.Name,
+				"namespace": resource.Namespace,
+				"kind":      resource.Kind(),
+				"query":     hq.query,
+			}).Info("provider.kubernetes: health query passed, update verified")
+			return nil
+		case <-ticker.C:
+			if current := p.currentResource(resource.Identifier); current != nil && strings.Join(current.GetImages(), ",") != images {
+				// updated again, new update is verified on its own
+				return nil
+			}
+
+			ctx, cancel := context.WithTimeout(context.Background(), hq.interval)
+			values, err := p.metrics.Query(ctx, hq.query)
+			cancel()
+			if err != nil {
+				log.WithFields(log.Fields{
+					"error":     err,
+					"name":      resource.Name,
+					"namespace": resource.Namespace,
+					"kind":      resource.Kind(),
+					"query":     hq.query,
+				}).Warn("provider.kubernetes: failed to evaluate health query")
+				continue
+			}
+
+			value, breached := hq.breached(values)
+			if !breached {
+				continue
+			}
+			return fmt.Errorf("health query %s breached with value %s", hq, strconv.FormatFloat(value, 'g', -1, 64))
+		}
+	}
+}
@@ -0,0 +1,209 @@
+package kubernetes
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/keel-hq/keel/internal/k8s"
+	"github.com/keel-hq/keel/types"
+)
+
+type fakeMetricsQuerier struct {
+	values  []float64
+	queries int
+}
+
+func (q *fakeMetricsQuerier) Query(ctx context.Context, query string) ([]float64, error) {
+	q.queries++
+	return q.values, nil
+}
+
+func TestGetHealthQuery(t *testing.T) {
+	tests := []struct {
+		name        string
+		annotations map[string]string
+		want        *healthQuery
+		wantErr     bool
+	}{
+		{
+			name:        "not configured",
+			annotations: map[string]string{},
+			want:        nil,
+		},
+		{
+			name:        "defaults",
+			annotations: map[string]string{types.KeelHealthQueryAnnotation: "up == 0"},
+			want: &healthQuery{
+				query:    "up == 0",
+				period:   DefaultHealthQueryPeriod,
+				interval: DefaultHealthQueryInterval,
+			},
+		},
+		{
+			name: "custom",
+			annotations: map[string]string{
+				types.KeelHealthQueryAnnotation:          "sum(rate(errors_total[1m]))",
+				types.KeelHealthQueryThresholdAnnotation: ">= 0.05",
+				types.KeelHealthQueryPeriodAnnotation:    "10m",
+				types.KeelHealthQueryIntervalAnnotation:  "5s",
+			},
+			want: &healthQuery{
+				query:     "sum(rate(errors_total[1m]))",
+				period:    10 * time.Minute,
+				interval:  5 * time.Second,
+				op:        ">=",
+				threshold: 0.05,
+			},
+		},
+		{
+			name: "threshold defaults to greater than",
+			annotations: map[string]string{
+				types.KeelHealthQueryAnnotation:          "sum(rate(errors_total[1m]))",
+				types.KeelHealthQueryThresholdAnnotation: "3",
+			},
+			want: &healthQuery{
+				query:     "sum(rate(errors_total[1m]))",
+				period:    DefaultHealthQueryPeriod,
+				interval:  DefaultHealthQueryInterval,
+				op:        ">",
+				threshold: 3,
+			},
+		},
+		{
+			name: "invalid threshold",
+			annotations: map[string]string{
+				types.KeelHealthQueryAnnotation:          "up",
+				types.KeelHealthQueryThresholdAnnotation: "> lots",
+			},
+			wantErr: true,
+		},
+		{
+			name: "invalid period",
+			annotations: map[string]string{
+				types.KeelHealthQueryAnnotation:       "up",
+				types.KeelHealthQueryPeriodAnnotation: "forever",
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := getHealthQuery(tt.annotations)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("getHealthQuery() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.want == nil && got != nil {
+				t.Fatalf("getHealthQuery() expected nil, got %+v", got)
+			}
+			if tt.want != nil && *got != *tt.want {
+				t.Errorf("getHealthQuery() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestHealthQueryBreached(t *testing.T) {
+	hq := &healthQuery{op: "<", threshold: 0.99}
+	if _, breached := hq.breached([]float64{0.999, 1}); breached {
+		t.Errorf("expected values above threshold to pass")
+	}
+	if v, breached := hq.breached([]float64{1, 0.5}); !breached || v != 0.5 {
+		t.Errorf("expected 0.5 to breach, got %v %v", v, breached)
+	}
+
+	// alerting rule style, any sample is a breach
+	hq = &healthQuery{}
+	if _, breached := hq.breached(nil); breached {
+		t.Errorf("expected empty result to pass")
+	}
+	if _, breached := hq.breached([]float64{0}); !breached {
+		t.Errorf("expected any sample to breach")
+	}
+}
+
+func TestVerifyHealthQueryRollback(t *testing.T) {
+	grc := &k8s.GenericResourceCache{}
+	grc.Add(healthCheckDeployment("gcr.io/v2-namespace/hello-world:1.1.2"))
+
+	fi := &fakeImplementer{}
+	fs := &fakeSender{}
+	approver, teardown := approver()
+	defer teardown()
+	provider, err := NewProvider(fi, fs, approver, grc)
+	if err != nil {
+		t.Fatalf("failed to get provider: %s", err)
+	}
+	provider.SetMetricsQuerier(&fakeMetricsQuerier{values: []float64{0.2}})
+
+	plan := &UpdatePlan{
+		Resource:       healthCheckDeployment("gcr.io/v2-namespace/hello-world:1.1.2"),
+		CurrentVersion: "1.1.1",
+		NewVersion:     "1.1.2",
+		PreviousImages: []string{"gcr.io/v2-namespace/hello-world:1.1.1"},
+	}
+
+	v := newUpdateVerifier(provider, plan)
+	v.run(func() error {
+		return v.checkHealthQuery(&healthQuery{
+			query:     "error_ratio",
+			period:    time.Second,
+			interval:  5 * time.Millisecond,
+			op:        ">",
+			threshold: 0.1,
+		})
+	})
+	v.wait()
+
+	if fi.updated == nil {
+		t.Fatalf("expected resource to be rolled back")
+	}
+	if fi.updated.GetImages()[0] != "gcr.io/v2-namespace/hello-world:1.1.1" {
+		t.Errorf("unexpected image after rollback: %s", fi.updated.GetImages()[0])
+	}
+	if fs.sentEvent.Level != types.LevelWarn {
+		t.Errorf("expected rollback notification, got: %+v", fs.sentEvent)
+	}
+}
+
+func TestVerifyHealthQueryHealthy(t *testing.T) {
+	grc := &k8s.GenericResourceCache{}
+	grc.Add(healthCheckDeployment("gcr.io/v2-namespace/hello-world:1.1.2"))
+
+	fi := &fakeImplementer{}
+	approver, teardown := approver()
+	defer teardown()
+	provider, err := NewProvider(fi, &fakeSender{}, approver, grc)
+	if err != nil {
+		t.Fatalf("failed to get provider: %s", err)
+	}
+	querier := &fakeMetricsQuerier{values: []float64{0.01}}
+	provider.SetMetricsQuerier(querier)
+
+	plan := &UpdatePlan{
+		Resource:       healthCheckDeployment("gcr.io/v2-namespace/hello-world:1.1.2"),
+		CurrentVersion: "1.1.1",
+		NewVersion:     "1.1.2",
+		PreviousImages: []string{"gcr.io/v2-namespace/hello-world:1.1.1"},
+	}
+
+	v := newUpdateVerifier(provider, plan)
+	v.run(func() error {
+		return v.checkHealthQuery(&healthQuery{
+			query:     "error_ratio",
+			period:    50 * time.Millisecond,
+			interval:  5 * time.Millisecond,
+			op:        ">",
+			threshold: 0.1,
+		})
+	})
+	v.wait()
+
+	if fi.updated != nil {
+		t.Errorf("healthy resource shouldn't be rolled back")
+	}
+	if querier.queries == 0 {
+		t.Errorf("expected health query to be evaluated")
+	}
+}
@@ -0,0 +1,47 @@
+package kubernetes
+
+import (
+	"github.com/keel-hq/keel/types"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// HistoryRecorder - keeps actions taken on resources, see internal/history
+type HistoryRecorder interface {
+	Record(entry *types.HistoryEntry) error
+}
+
+// SetHistory - records detected, approved, applied, failed and rolled back
+// updates per resource
+func (p *Provider) SetHistory(history HistoryRecorder) {
+	p.history = history
+}
+
+// recordHistory - stores plan action, history is best effort so errors are
+// only logged
+func (p *Provider) recordHistory(action string, plan *UpdatePlan, message string) {
+	if p.history == nil {
+		return
+	}
+
+	resource := plan.Resource
+	err := p.history.Record(&types.HistoryEntry{
+		Action:         action,
+		Cluster:        p.cluster,
+		Identifier:     resource.Identifier,
+		Kind:           resource.Kind(),
+		Namespace:      resource.Namespace,
+		Name:           resource.Name,
+		CurrentVersion: plan.CurrentVersion,
+		NewVersion:     plan.NewVersion,
+		Trigger:        plan.Trigger,
+		Message:        message,
+	})
+	if err != nil {
+		log.WithFields(log.Fields{
+			"error":      err,
+			"identifier": resource.Identifier,
+			"action":     action,
+		}).Warn("provider.kubernetes: failed to record resource history")
+	}
+}
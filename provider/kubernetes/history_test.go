@@ -0,0 +1,47 @@
+package kubernetes
+
+import (
+	"testing"
+
+	"github.com/keel-hq/keel/cache/memory"
+	"github.com/keel-hq/keel/internal/history"
+	"github.com/keel-hq/keel/internal/k8s"
+	"github.com/keel-hq/keel/types"
+)
+
+func TestHistoryRecorded(t *testing.T) {
+	grc := &k8s.GenericResourceCache{}
+	grc.Add(healthCheckDeployment("gcr.io/v2-namespace/hello-world:1.1.1"))
+
+	fi := &fakeImplementer{}
+	approver, teardown := approver()
+	defer teardown()
+	provider, err := NewProvider(fi, &fakeSender{}, approver, grc)
+	if err != nil {
+		t.Fatalf("failed to get provider: %s", err)
+	}
+	h := history.New(memory.New(), 0)
+	provider.SetHistory(h)
+
+	_, err = provider.processEvent(&types.Event{
+		Repository:  types.Repository{Name: "gcr.io/v2-namespace/hello-world", Tag: "1.1.2"},
+		TriggerName: "poll",
+	})
+	if err != nil {
+		t.Fatalf("failed to process event: %s", err)
+	}
+
+	entries, err := h.List("", "deployment/xxxx/dep-1")
+	if err != nil {
+		t.Fatalf("failed to list history: %s", err)
+	}
+	if len(entries) != 2 {
+		t.Fatalf("expected 2 entries, got: %+v", entries)
+	}
+	if entries[0].Action != types.HistoryActionApplied || entries[1].Action != types.HistoryActionDetected {
+		t.Errorf("unexpected actions: %s, %s", entries[0].Action, entries[1].Action)
+	}
+	if entries[0].CurrentVersion != "1.1.1" || entries[0].NewVersion != "1.1.2" || entries[0].Trigger != "poll" {
+		t.Errorf("unexpected entry: %+v", entries[0])
+	}
+}
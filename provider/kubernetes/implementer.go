@@ -9,11 +9,17 @@ import (
 	v1beta1 "k8s.io/api/batch/v1beta1"
 	v1 "k8s.io/api/core/v1"
 	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/dynamic"
 	"k8s.io/client-go/kubernetes"
+	batch_v1 "k8s.io/client-go/kubernetes/typed/batch/v1"
+	batch_v1beta1 "k8s.io/client-go/kubernetes/typed/batch/v1beta1"
 	core_v1 "k8s.io/client-go/kubernetes/typed/core/v1"
 
 	"k8s.io/client-go/rest"
 	"k8s.io/client-go/tools/clientcmd"
+	"k8s.io/client-go/util/retry"
 
 	log "github.com/sirupsen/logrus"
 )
@@ -25,9 +31,13 @@ type Implementer interface {
 	Update(obj *k8s.GenericResource) error
 	Secret(namespace, name string) (*v1.Secret, error)
 	Pods(namespace, labelSelector string) (*v1.PodList, error)
+	ServiceAccount(namespace, name string) (*v1.ServiceAccount, error)
 	DeletePod(namespace, name string, opts *meta_v1.DeleteOptions) error
 
 	ConfigMaps(namespace string) core_v1.ConfigMapInterface
+	Jobs(namespace string) batch_v1.JobInterface
+	CronJobs(namespace string) batch_v1beta1.CronJobInterface
+	Events(namespace string) core_v1.EventInterface
 }
 
 // KubernetesImplementer - default kubernetes client implementer, uses
@@ -35,6 +45,9 @@ type Implementer interface {
 type KubernetesImplementer struct {
 	cfg    *rest.Config
 	client *kubernetes.Clientset
+
+	// resources without typed clients, ie: Argo Rollouts
+	dynamic dynamic.Interface
 }
 
 // Opts - implementer options, usually for k8s deployments
@@ -44,6 +57,8 @@ type Opts struct {
 	InCluster  bool
 	ConfigPath string
 	Master     string
+	// kubeconfig context, current context is used if empty
+	Context string
 }
 
 // NewKubernetesImplementer - create new k8s implementer
@@ -62,13 +77,21 @@ func NewKubernetesImplementer(opts *Opts) (*KubernetesImplementer, error) {
 		log.Info("provider.kubernetes: using in-cluster configuration")
 	} else if opts.ConfigPath != "" {
 		var err error
-		cfg, err = clientcmd.BuildConfigFromFlags("", opts.ConfigPath)
+		cfg, err = clientcmd.NewNonInteractiveDeferredLoadingClientConfig(
+			&clientcmd.ClientConfigLoadingRules{ExplicitPath: opts.ConfigPath},
+			&clientcmd.ConfigOverrides{CurrentContext: opts.Context},
+		).ClientConfig()
 		if err != nil {
 			log.WithFields(log.Fields{
 				"error": err,
 			}).Error("provider.kubernetes: failed to get cmd kubernetes config")
 			return nil, err
 		}
+		log.WithFields(log.Fields{
+			"config":  opts.ConfigPath,
+			"context": opts.Context,
+			"host":    cfg.Host,
+		}).Info("provider.kubernetes: using kubeconfig")
 	} else {
 		return nil, fmt.Errorf("kubernetes config is missing")
 	}
@@ -81,7 +104,15 @@ func NewKubernetesImplementer(opts *Opts) (*KubernetesImplementer, error) {
 		return nil, err
 	}
 
-	return &KubernetesImplementer{client: client, cfg: cfg}, nil
+	dynamicClient, err := dynamic.NewForConfig(cfg)
+	if err != nil {
+		log.WithFields(log.Fields{
+			"error": err,
+		}).Error("provider.kubernetes: failed to create dynamic kubernetes client")
+		return nil, err
+	}
+
+	return &KubernetesImplementer{client: client, cfg: cfg, dynamic: dynamicClient}, nil
 }
 
 func (i *KubernetesImplementer) Client() *kubernetes.Clientset {
@@ -92,6 +123,11 @@ func (i *KubernetesImplementer) Config() *rest.Config {
 	return i.cfg
 }
 
+// Dynamic - client for custom resources
+func (i *KubernetesImplementer) Dynamic() dynamic.Interface {
+	return i.dynamic
+}
+
 // Namespaces - get all namespaces
 func (i *KubernetesImplementer) Namespaces() (*v1.NamespaceList, error) {
 	namespaces := i.client.CoreV1().Namespaces()
@@ -111,16 +147,47 @@ func (i *KubernetesImplementer) Deployments(namespace string) (*apps_v1.Deployme
 	return l, err
 }
 
-// Update converts generic resource into specific kubernetes type and updates it
+// Update applies changes made to the resource, resources copied from the cache
+// are patched so fields changed by other controllers (ie: replicas set by HPA)
+// are kept and concurrent changes don't fail the update
 func (i *KubernetesImplementer) Update(obj *k8s.GenericResource) error {
-	// retryErr := retry.RetryOnConflict(retry.DefaultRetry, func() error {
-	// 	// Retrieve the latest version of Deployment before attempting update
-	// 	// RetryOnConflict uses exponential backoff to avoid exhausting the apiserver
-	// 	_, updateErr := i.client.Extensions().Deployments(deployment.Namespace).Update(deployment)
-	// 	return updateErr
-	// })
-	// return retryErr
+	patchType, patch, err := obj.Patch()
+	if err == k8s.ErrNoOriginal {
+		return i.update(obj)
+	}
+	if err != nil {
+		return err
+	}
+	if patch == nil {
+		return nil
+	}
 
+	return retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		return i.patch(obj, patchType, patch)
+	})
+}
+
+func (i *KubernetesImplementer) patch(obj *k8s.GenericResource, patchType types.PatchType, patch []byte) error {
+	var err error
+	switch resource := obj.GetResource().(type) {
+	case *apps_v1.Deployment:
+		_, err = i.client.AppsV1().Deployments(resource.Namespace).Patch(resource.Name, patchType, patch)
+	case *apps_v1.StatefulSet:
+		_, err = i.client.AppsV1().StatefulSets(resource.Namespace).Patch(resource.Name, patchType, patch)
+	case *apps_v1.DaemonSet:
+		_, err = i.client.AppsV1().DaemonSets(resource.Namespace).Patch(resource.Name, patchType, patch)
+	case *v1beta1.CronJob:
+		_, err = i.client.BatchV1beta1().CronJobs(resource.Namespace).Patch(resource.Name, patchType, patch)
+	case *unstructured.Unstructured:
+		_, err = i.dynamic.Resource(k8s.UnstructuredResource(resource)).Namespace(resource.GetNamespace()).Patch(resource.GetName(), patchType, patch, meta_v1.PatchOptions{})
+	default:
+		return fmt.Errorf("unsupported object type")
+	}
+	return err
+}
+
+// update converts generic resource into specific kubernetes type and updates it
+func (i *KubernetesImplementer) update(obj *k8s.GenericResource) error {
 	switch resource := obj.GetResource().(type) {
 	case *apps_v1.Deployment:
 		_, err := i.client.AppsV1().Deployments(resource.Namespace).Update(resource)
@@ -142,6 +209,11 @@ func (i *KubernetesImplementer) Update(obj *k8s.GenericResource) error {
 		if err != nil {
 			return err
 		}
+	case *unstructured.Unstructured:
+		_, err := i.dynamic.Resource(k8s.UnstructuredResource(resource)).Namespace(resource.GetNamespace()).Update(resource, meta_v1.UpdateOptions{})
+		if err != nil {
+			return err
+		}
 	default:
 		return fmt.Errorf("unsupported object type")
 	}
@@ -158,6 +230,11 @@ func (i *KubernetesImplementer) Pods(namespace, labelSelector string) (*v1.PodLi
 	return i.client.CoreV1().Pods(namespace).List(meta_v1.ListOptions{LabelSelector: labelSelector})
 }
 
+// ServiceAccount - get service account
+func (i *KubernetesImplementer) ServiceAccount(namespace, name string) (*v1.ServiceAccount, error) {
+	return i.client.CoreV1().ServiceAccounts(namespace).Get(name, meta_v1.GetOptions{})
+}
+
 // DeletePod - delete pod by name
 func (i *KubernetesImplementer) DeletePod(namespace, name string, opts *meta_v1.DeleteOptions) error {
 	return i.client.CoreV1().Pods(namespace).Delete(name, opts)
@@ -167,3 +244,18 @@ func (i *KubernetesImplementer) DeletePod(namespace, name string, opts *meta_v1.
 func (i *KubernetesImplementer) ConfigMaps(namespace string) core_v1.ConfigMapInterface {
 	return i.client.CoreV1().ConfigMaps(namespace)
 }
+
+// Jobs - returns an interface to jobs for a specified namespace
+func (i *KubernetesImplementer) Jobs(namespace string) batch_v1.JobInterface {
+	return i.client.BatchV1().Jobs(namespace)
+}
+
+// CronJobs - returns an interface to cron jobs for a specified namespace
+func (i *KubernetesImplementer) CronJobs(namespace string) batch_v1beta1.CronJobInterface {
+	return i.client.BatchV1beta1().CronJobs(namespace)
+}
+
+// Events - returns an interface to events for a specified namespace
+func (i *KubernetesImplementer) Events(namespace string) core_v1.EventInterface {
+	return i.client.CoreV1().Events(namespace)
+}
@@ -1,9 +1,12 @@
 package kubernetes
 
 import (
+	"context"
 	"fmt"
+	"os"
 	"regexp"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/Masterminds/semver"
@@ -12,11 +15,14 @@ import (
 	v1 "k8s.io/api/core/v1"
 
 	"github.com/prometheus/client_golang/prometheus"
+	"go.opentelemetry.io/otel/attribute"
 
 	"github.com/keel-hq/keel/approvals"
 	"github.com/keel-hq/keel/extension/notification"
 	"github.com/keel-hq/keel/internal/k8s"
+	"github.com/keel-hq/keel/internal/locks"
 	"github.com/keel-hq/keel/internal/policy"
+	"github.com/keel-hq/keel/pkg/tracing"
 	"github.com/keel-hq/keel/types"
 	"github.com/keel-hq/keel/util/image"
 	"github.com/keel-hq/keel/util/policies"
@@ -40,14 +46,38 @@ var kubernetesUnversionedUpdatesCounter = prometheus.NewCounterVec(
 	[]string{"kubernetes"},
 )
 
+var kubernetesFailedUpdatesCounter = prometheus.NewCounterVec(
+	prometheus.CounterOpts{
+		Name: "kubernetes_failed_updates_total",
+		Help: "How many resource updates failed, partitioned by deployment name.",
+	},
+	[]string{"kubernetes"},
+)
+
+var kubernetesResourcesScannedCounter = prometheus.NewCounter(
+	prometheus.CounterOpts{
+		Name: "kubernetes_resources_scanned_total",
+		Help: "How many tracked resources were checked against new images.",
+	},
+)
+
 func init() {
 	prometheus.MustRegister(kubernetesVersionedUpdatesCounter)
 	prometheus.MustRegister(kubernetesUnversionedUpdatesCounter)
+	prometheus.MustRegister(kubernetesFailedUpdatesCounter)
+	prometheus.MustRegister(kubernetesResourcesScannedCounter)
 }
 
 // ProviderName - provider name
 const ProviderName = "kubernetes"
 
+// EnvUpdateLockTimeout - how long an update or rollback can wait for a per
+// resource lock and hold it, defaults to DefaultUpdateLockTimeout
+const EnvUpdateLockTimeout = "UPDATE_LOCK_TIMEOUT"
+
+// DefaultUpdateLockTimeout - default per resource lock timeout
+const DefaultUpdateLockTimeout = 5 * time.Minute
+
 var versionreg = regexp.MustCompile(`:[^:]*$`)
 
 // GenericResourceCache an interface for generic resource cache.
@@ -70,6 +100,26 @@ type UpdatePlan struct {
 	CurrentVersion string
 	// New version that's already in the deployment
 	NewVersion string
+
+	// Images used before the update, used for rollbacks
+	PreviousImages []string
+	// PreviousInitImages - init container images used before the update
+	PreviousInitImages []string
+
+	// Trigger - name of the trigger that detected new version
+	Trigger string
+
+	// event the plan was created for
+	event *types.Event
+}
+
+// Image - image the plan updates to a new version, empty if the plan wasn't
+// created for an event
+func (p *UpdatePlan) Image() string {
+	if p.event == nil {
+		return ""
+	}
+	return p.event.Repository.Name
 }
 
 func (p *UpdatePlan) String() string {
@@ -89,8 +139,79 @@ type Provider struct {
 
 	cache GenericResourceCache
 
+	// locks serialize updates of the same resource, regardless of
+	// the trigger that initiated them
+	locks *locks.KeyedMutex
+
+	// updates waiting for resource update window, by resource identifier
+	queuedMu sync.Mutex
+	queued   map[string]*types.QueuedUpdate
+
+	// last reported version of paused resources, by resource identifier
+	pausedMu       sync.Mutex
+	pausedReported map[string]string
+
+	// updates are only reported, see SetDryRun
+	dryRun bool
+
+	// consecutive failed updates, by resource identifier
+	failuresMu sync.Mutex
+	failures   map[string]*updateFailure
+	maxRetries int
+	backoff    time.Duration
+
+	// pending updates of grouped resources, by namespace/group and resource identifier
+	groupsMu sync.Mutex
+	groups   map[string]map[string]*groupedUpdate
+
+	// production updates waiting for staging rollouts, by resource identifier,
+	// and since when staging rollouts are healthy, by resource identifier and image
+	stagedMu     sync.Mutex
+	staged       map[string]*stagedUpdate
+	stageHealthy map[string]time.Time
+	stagePass    int
+
+	// updates are committed to git first, see SetGitWriter
+	gitWriter       GitWriter
+	gitPatchCluster bool
+
+	// updates to images without a valid signature are rejected
+	verifiers []SignatureVerifier
+
+	// updates to vulnerable images are parked, see SetVulnerabilityScanner
+	scanner  VulnerabilityScanner
+	parkedMu sync.Mutex
+	parked   map[string]string
+
+	// limits rollouts happening at the same time, nil if unlimited
+	rollouts *rolloutSlots
+
+	// cluster name when keel manages several clusters, see SetCluster
+	cluster string
+
+	// failed events are kept for replay, see SetDeadLetterStore
+	deadLetters DeadLetterStore
+
+	// resolves tags of events without digest, see SetDigestResolver
+	digests DigestResolver
+
+	// creation time of new versions held by keel.sh/minAge, see SetCreatedResolver
+	created   CreatedResolver
+	firstSeen map[string]versionSeen
+
+	// commits referenced by new versions, see SetCommitResolver
+	commits CommitResolver
+
+	// evaluates health queries after updates, see SetMetricsQuerier
+	metrics MetricsQuerier
+
+	// actions taken on resources, see SetHistory
+	history HistoryRecorder
+
 	events chan *types.Event
 	stop   chan struct{}
+	// held while an event is processed, Stop waits for it
+	processing sync.Mutex
 }
 
 // NewProvider - create new kubernetes based provider
@@ -99,12 +220,41 @@ func NewProvider(implementer Implementer, sender notification.Sender, approvalMa
 		implementer:     implementer,
 		cache:           cache,
 		approvalManager: approvalManager,
+		locks:           locks.New(getUpdateLockTimeout()),
+		queued:          make(map[string]*types.QueuedUpdate),
+		pausedReported:  make(map[string]string),
+		failures:        make(map[string]*updateFailure),
+		maxRetries:      getUpdateMaxRetries(),
+		backoff:         getUpdateBackoff(),
+		groups:          make(map[string]map[string]*groupedUpdate),
+		staged:          make(map[string]*stagedUpdate),
+		stageHealthy:    make(map[string]time.Time),
+		parked:          make(map[string]string),
+		rollouts:        newRolloutSlots(getMaxParallelRollouts(), getRolloutWaitHealthy()),
 		events:          make(chan *types.Event, 100),
 		stop:            make(chan struct{}),
 		sender:          sender,
 	}, nil
 }
 
+func getUpdateLockTimeout() time.Duration {
+	timeoutStr := os.Getenv(EnvUpdateLockTimeout)
+	if timeoutStr == "" {
+		return DefaultUpdateLockTimeout
+	}
+
+	timeout, err := time.ParseDuration(timeoutStr)
+	if err != nil || timeout <= 0 {
+		log.WithFields(log.Fields{
+			"error":   err,
+			"timeout": timeoutStr,
+		}).Warnf("provider.kubernetes: invalid %s, using default %s", EnvUpdateLockTimeout, DefaultUpdateLockTimeout)
+		return DefaultUpdateLockTimeout
+	}
+
+	return timeout
+}
+
 // Submit - submit event to provider
 func (p *Provider) Submit(event types.Event) error {
 	p.events <- &event
@@ -113,6 +263,9 @@ func (p *Provider) Submit(event types.Event) error {
 
 // GetName - get provider name
 func (p *Provider) GetName() string {
+	if p.cluster != "" {
+		return ProviderName + "/" + p.cluster
+	}
 	return ProviderName
 }
 
@@ -121,9 +274,45 @@ func (p *Provider) Start() error {
 	return p.startInternal()
 }
 
-// Stop - stops kubernetes provider
+// Stop - stops kubernetes provider, waits for the update in progress to
+// finish. Events that weren't processed yet are dropped, triggers report
+// them again after restart.
 func (p *Provider) Stop() {
 	close(p.stop)
+
+	p.processing.Lock()
+	defer p.processing.Unlock()
+
+	if pending := len(p.events); pending > 0 {
+		log.WithFields(log.Fields{
+			"events": pending,
+		}).Warn("provider.kubernetes: stopped with unprocessed events")
+	}
+	if p.rollouts != nil {
+		if pending := p.rollouts.pending(); pending > 0 {
+			log.WithFields(log.Fields{
+				"updates": pending,
+			}).Warn("provider.kubernetes: stopped with queued updates")
+		}
+	}
+}
+
+// imagePullSecrets - secret set via keel.sh/imagePullSecret followed by
+// image pull secrets of the pod spec
+func imagePullSecrets(gr *k8s.GenericResource) []string {
+	var secrets []string
+	specifiedSecret := getImagePullSecretFromMeta(gr.GetLabels(), gr.GetKeelAnnotations())
+	if specifiedSecret != "" {
+		secrets = append(secrets, specifiedSecret)
+	}
+	return append(secrets, gr.GetImagePullSecrets()...)
+}
+
+func serviceAccountName(gr *k8s.GenericResource) string {
+	if name := gr.GetServiceAccountName(); name != "" {
+		return name
+	}
+	return "default"
 }
 
 func getImagePullSecretFromMeta(labels map[string]string, annotations map[string]string) string {
@@ -151,11 +340,11 @@ func (p *Provider) TrackedImages() ([]*types.TrackedImage, error) {
 
 	for _, gr := range p.cache.Values() {
 		labels := gr.GetLabels()
-		annotations := gr.GetAnnotations()
+		annotations := gr.GetKeelAnnotations()
 
 		// ignoring unlabelled deployments
 		plc := policy.GetPolicyFromLabelsOrAnnotations(labels, annotations)
-		if plc.Type() == policy.PolicyTypeNone {
+		if plc.Type() == policy.PolicyTypeNone && !policy.HasContainerPolicies(annotations) {
 			continue
 		}
 
@@ -178,17 +367,30 @@ func (p *Provider) TrackedImages() ([]*types.TrackedImage, error) {
 		// trigger type, we only care for "poll" type triggers
 		trigger := policies.GetTriggerPolicy(labels, annotations)
 
-		// getting image pull secrets
-		var secrets []string
-		specifiedSecret := getImagePullSecretFromMeta(labels, annotations)
-		if specifiedSecret != "" {
-			secrets = append(secrets, specifiedSecret)
-		}
-		secrets = append(secrets, gr.GetImagePullSecrets()...)
+		secrets := imagePullSecrets(gr)
+
+		pollCredentials := annotations[types.KeelPollCredentialsAnnotation]
+
+		digestPin := getDigestPin(labels, annotations)
+		tagOrder := annotations[types.KeelTagOrderAnnotation]
 
-		images := gr.GetImages()
-		for _, img := range images {
-			ref, err := image.Parse(img)
+		// service account image pull secrets are looked up when polling
+		serviceAccount := serviceAccountName(gr)
+
+		for _, c := range trackedContainers(gr) {
+			containerPlc := policy.GetContainerPolicy(c.Name, plc, annotations)
+			if containerPlc.Type() == policy.PolicyTypeNone {
+				continue
+			}
+
+			img := c.Image
+			imageRef, pinnedDigest := image.SplitTagDigest(img)
+			if pinnedDigest != "" && digestPin == DigestPinRespect {
+				// pinned images are immutable, nothing to poll for
+				continue
+			}
+
+			ref, err := image.Parse(imageRef)
 			if err != nil {
 				log.WithFields(log.Fields{
 					"error":     err,
@@ -208,14 +410,18 @@ func (p *Provider) TrackedImages() ([]*types.TrackedImage, error) {
 			}
 
 			trackedImages = append(trackedImages, &types.TrackedImage{
-				Image:        ref,
-				PollSchedule: schedule,
-				Trigger:      trigger,
-				Provider:     ProviderName,
-				Namespace:    gr.Namespace,
-				Secrets:      secrets,
-				Meta:         make(map[string]string),
-				Policy:       plc,
+				Image:           ref,
+				PollSchedule:    schedule,
+				Trigger:         trigger,
+				Provider:        ProviderName,
+				Cluster:         p.cluster,
+				Namespace:       gr.Namespace,
+				Identifier:      gr.Identifier,
+				Secrets:         secrets,
+				PollCredentials: pollCredentials,
+				Meta:            map[string]string{"serviceAccount": serviceAccount},
+				Policy:          containerPlc,
+				TagOrder:        tagOrder,
 			})
 		}
 	}
@@ -224,17 +430,30 @@ func (p *Provider) TrackedImages() ([]*types.TrackedImage, error) {
 }
 
 func (p *Provider) startInternal() error {
+	windowTicker := time.NewTicker(DefaultUpdateWindowCheckInterval)
+	defer windowTicker.Stop()
+
+	if p.rollouts != nil {
+		go p.runRollouts()
+	}
+
 	for {
 		select {
 		case event := <-p.events:
-			_, err := p.processEvent(event)
-			if err != nil {
-				log.WithFields(log.Fields{
-					"error": err,
-					"image": event.Repository.Name,
-					"tag":   event.Repository.Tag,
-				}).Error("provider.kubernetes: failed to process event")
-			}
+			p.inFlight(func() {
+				_, err := p.processEvent(event)
+				if err != nil {
+					log.WithFields(log.Fields{
+						"error": err,
+						"image": event.Repository.Name,
+						"tag":   event.Repository.Tag,
+					}).Error("provider.kubernetes: failed to process event")
+					p.deadLetter(event, "", 1, err)
+				}
+			})
+		case <-windowTicker.C:
+			p.inFlight(p.processQueued)
+			p.inFlight(p.processStaged)
 		case <-p.stop:
 			log.Info("provider.kubernetes: got shutdown signal, stopping...")
 			return nil
@@ -242,8 +461,37 @@ func (p *Provider) startInternal() error {
 	}
 }
 
+// inFlight - runs fn unless the provider is stopping, Stop waits for it to return
+func (p *Provider) inFlight(fn func()) {
+	p.processing.Lock()
+	defer p.processing.Unlock()
+
+	select {
+	case <-p.stop:
+		return
+	default:
+	}
+	fn()
+}
+
 func (p *Provider) processEvent(event *types.Event) (updated []*k8s.GenericResource, err error) {
-	plans, err := p.createUpdatePlans(&event.Repository)
+	// chart updates target helm releases
+	if event.Chart != "" {
+		return nil, nil
+	}
+
+	ctx, span := tracing.StartEvent("provider.kubernetes.process_event", event)
+	defer func() { tracing.End(span, err) }()
+
+	var plans []*UpdatePlan
+	_, planSpan := tracing.Start(ctx, "provider.kubernetes.evaluate_policies")
+	if event.Target != "" {
+		plans, err = p.createForcedUpdatePlans(event)
+	} else {
+		plans, err = p.createUpdatePlans(&event.Repository)
+	}
+	planSpan.SetAttributes(attribute.Int("plans", len(plans)))
+	tracing.End(planSpan, err)
 	if err != nil {
 		return nil, err
 	}
@@ -256,127 +504,298 @@ func (p *Provider) processEvent(event *types.Event) (updated []*k8s.GenericResou
 		return
 	}
 
-	approvedPlans := p.checkForApprovals(event, plans)
+	for _, plan := range plans {
+		plan.Trigger = event.TriggerName
+		plan.event = event
+		p.recordHistory(types.HistoryActionDetected, plan, "")
+	}
 
-	return p.updateDeployments(approvedPlans)
-}
+	plans = p.holdPaused(plans)
 
-func (p *Provider) updateDeployments(plans []*UpdatePlan) (updated []*k8s.GenericResource, err error) {
-	for _, plan := range plans {
-		resource := plan.Resource
+	plans = p.holdPausedUntil(event, plans)
 
-		annotations := resource.GetAnnotations()
+	plans = p.holdYoung(event, plans)
 
-		notificationChannels := types.ParseEventNotificationChannels(annotations)
+	approvedPlans := p.checkForApprovals(event, plans)
 
-		p.sender.Send(types.EventNotification{
-			ResourceKind: resource.Kind(),
-			Identifier:   resource.Identifier,
-			Name:         "preparing to update resource",
-			Message:      fmt.Sprintf("Preparing to update %s %s/%s %s->%s (%s)", resource.Kind(), resource.Namespace, resource.Name, plan.CurrentVersion, plan.NewVersion, strings.Join(resource.GetImages(), ", ")),
-			CreatedAt:    time.Now(),
-			Type:         types.NotificationPreDeploymentUpdate,
-			Level:        types.LevelDebug,
-			Channels:     notificationChannels,
-			Metadata: map[string]string{
-				"provider":  p.GetName(),
-				"namespace": resource.GetNamespace(),
-				"name":      resource.GetName(),
-			},
-		})
+	readyPlans := p.holdOutsideWindow(event, approvedPlans)
 
-		var err error
+	readyPlans = p.holdStaged(event, readyPlans)
 
-		timestamp := time.Now().Format(time.RFC3339)
-		annotations["kubernetes.io/change-cause"] = fmt.Sprintf("keel automated update, version %s -> %s [%s]", plan.CurrentVersion, plan.NewVersion, timestamp)
+	readyPlans = p.holdFailing(readyPlans)
 
-		resource.SetAnnotations(annotations)
+	readyPlans = p.holdGrouped(event, readyPlans)
 
-		err = p.implementer.Update(resource)
-		kubernetesVersionedUpdatesCounter.With(prometheus.Labels{"kubernetes": fmt.Sprintf("%s/%s", resource.Namespace, resource.Name)}).Inc()
-		if err != nil {
-			log.WithFields(log.Fields{
-				"error":      err,
-				"namespace":  resource.Namespace,
-				"deployment": resource.Name,
-				"kind":       resource.Kind(),
-				"update":     fmt.Sprintf("%s->%s", plan.CurrentVersion, plan.NewVersion),
-			}).Error("provider.kubernetes: got error while updating resource")
-
-			p.sender.Send(types.EventNotification{
-				Name:         "update resource",
-				ResourceKind: resource.Kind(),
-				Identifier:   resource.Identifier,
-				Message:      fmt.Sprintf("%s %s/%s update %s->%s failed, error: %s", resource.Kind(), resource.Namespace, resource.Name, plan.CurrentVersion, plan.NewVersion, err),
-				CreatedAt:    time.Now(),
-				Type:         types.NotificationDeploymentUpdate,
-				Level:        types.LevelError,
-				Channels:     notificationChannels,
-				Metadata: map[string]string{
-					"provider":  p.GetName(),
-					"namespace": resource.GetNamespace(),
-					"name":      resource.GetName(),
-				},
-			})
+	return p.updateDeployments(ctx, readyPlans)
+}
 
+// updateDeployments - applies plans, with MAX_PARALLEL_ROLLOUTS they are queued
+// and applied by the rollout worker instead
+func (p *Provider) updateDeployments(ctx context.Context, plans []*UpdatePlan) (updated []*k8s.GenericResource, err error) {
+	for _, plan := range plans {
+		if p.rollouts != nil {
+			p.queueRollout(ctx, plan)
 			continue
 		}
-
-		err = p.updateComplete(plan)
-		if err != nil {
-			log.WithFields(log.Fields{
-				"error":     err,
-				"name":      resource.Name,
-				"kind":      resource.Kind(),
-				"namespace": resource.Namespace,
-			}).Warn("provider.kubernetes: got error while archiving approvals counter after successful update")
+		if ok, _ := p.updateDeployment(ctx, plan); ok {
+			updated = append(updated, plan.Resource)
 		}
+	}
 
-		var msg string
-		releaseNotes := types.ParseReleaseNotesURL(resource.GetAnnotations())
-		if releaseNotes != "" {
-			msg = fmt.Sprintf("Successfully updated %s %s/%s %s->%s (%s). Release notes: %s", resource.Kind(), resource.Namespace, resource.Name, plan.CurrentVersion, plan.NewVersion, strings.Join(resource.GetImages(), ", "), releaseNotes)
-		} else {
-			msg = fmt.Sprintf("Successfully updated %s %s/%s %s->%s (%s)", resource.Kind(), resource.Namespace, resource.Name, plan.CurrentVersion, plan.NewVersion, strings.Join(resource.GetImages(), ", "))
+	return
+}
+
+// updateDeployment - applies single plan, patched is set if the resource was
+// updated in the cluster (and not only committed to git). Plans are created
+// without the resource lock, it's acquired before applying one: the plan is
+// checked against the current resource, then signature verification, scanning,
+// git commit and the update run under the lock so two triggers (ie: poll and
+// webhook) firing close together never race. Lock is released after
+// UPDATE_LOCK_TIMEOUT even if the update hangs
+func (p *Provider) updateDeployment(ctx context.Context, plan *UpdatePlan) (ok, patched bool) {
+	resource := plan.Resource
+
+	ctx, unlock, err := p.locks.Lock(ctx, resource.Identifier)
+	if err != nil {
+		err = fmt.Errorf("failed to acquire lock for %s: %s", resource.Identifier, err)
+		log.WithFields(log.Fields{
+			"error":     err,
+			"name":      resource.Name,
+			"kind":      resource.Kind(),
+			"namespace": resource.Namespace,
+		}).Error("provider.kubernetes: update skipped")
+		p.updateFailed(plan, err)
+		return false, false
+	}
+	defer unlock()
+
+	if p.planOutdated(plan) {
+		log.WithFields(log.Fields{
+			"name":      resource.Name,
+			"kind":      resource.Kind(),
+			"namespace": resource.Namespace,
+			"update":    fmt.Sprintf("%s->%s", plan.CurrentVersion, plan.NewVersion),
+		}).Info("provider.kubernetes: resource images changed since the update was planned, skipping")
+		return false, false
+	}
+
+	// keel settings, including ones from KeelPolicy resources
+	settings := resource.GetKeelAnnotations()
+
+	notificationChannels := types.ParseEventNotificationChannelsFromLabelsOrAnnotations(resource.GetLabels(), settings)
+	notificationLevel := types.ParseEventNotificationLevel(resource.GetLabels(), settings)
+	plc := policy.GetPolicyFromLabelsOrAnnotations(resource.GetLabels(), settings)
+
+	p.sender.Send(types.EventNotification{
+		ResourceKind: resource.Kind(),
+		Identifier:   resource.Identifier,
+		Name:         "preparing to update resource",
+		Message:      fmt.Sprintf("Preparing to update %s %s/%s %s->%s (%s)", resource.Kind(), resource.Namespace, resource.Name, plan.CurrentVersion, plan.NewVersion, strings.Join(resource.GetImages(), ", ")),
+		CreatedAt:    time.Now(),
+		Type:         types.NotificationPreDeploymentUpdate,
+		Level:        types.LevelDebug,
+		Channels:     notificationChannels,
+		MinLevel:     notificationLevel,
+		Metadata: map[string]string{
+			"provider":  p.GetName(),
+			"namespace": resource.GetNamespace(),
+			"name":      resource.GetName(),
+			"previous":  plan.CurrentVersion,
+			"new":       plan.NewVersion,
+			"image":     plan.Image(),
+			"policy":    plc.Name(),
+			"trigger":   plan.Trigger,
+		},
+	})
+
+	if err := p.verifySignatures(ctx, plan, plc.Name()); err != nil {
+		return false, false
+	}
+
+	if err := p.scanImages(ctx, plan, plc.Name()); err != nil {
+		return false, false
+	}
+
+	if p.isDryRun(resource) {
+		p.reportDryRun(plan, plc.Name())
+		return false, false
+	}
+
+	if p.gitWriter != nil {
+		if err := p.writeToGit(ctx, plan, plc.Name()); err != nil {
+			return false, false
 		}
+		if !p.gitPatchCluster {
+			// cluster is updated from git, update is complete once committed
+			if err := p.updateComplete(plan); err != nil {
+				log.WithFields(log.Fields{
+					"error":     err,
+					"name":      resource.Name,
+					"kind":      resource.Kind(),
+					"namespace": resource.Namespace,
+				}).Warn("provider.kubernetes: got error while archiving approvals counter after committing update")
+			}
+			return true, false
+		}
+	}
+
+	if ctx.Err() != nil {
+		// lock expired while verifying or committing, another update might be
+		// in progress already
+		err = fmt.Errorf("lock for %s expired before the update was applied", resource.Identifier)
+		log.WithFields(log.Fields{
+			"error":     err,
+			"name":      resource.Name,
+			"kind":      resource.Kind(),
+			"namespace": resource.Namespace,
+		}).Error("provider.kubernetes: update skipped")
+		p.updateFailed(plan, err)
+		return false, false
+	}
+
+	setChangeCause(resource, "update", plan.Trigger, plan.CurrentVersion, plan.NewVersion)
+
+	_, span := tracing.Start(ctx, "provider.kubernetes.update",
+		attribute.String("kind", resource.Kind()),
+		attribute.String("namespace", resource.Namespace),
+		attribute.String("name", resource.Name),
+		attribute.String("update", fmt.Sprintf("%s->%s", plan.CurrentVersion, plan.NewVersion)),
+	)
+	err = p.update(resource)
+	tracing.End(span, err)
+	kubernetesVersionedUpdatesCounter.With(prometheus.Labels{"kubernetes": fmt.Sprintf("%s/%s", resource.Namespace, resource.Name)}).Inc()
+	if err != nil {
+		kubernetesFailedUpdatesCounter.With(prometheus.Labels{"kubernetes": fmt.Sprintf("%s/%s", resource.Namespace, resource.Name)}).Inc()
+		log.WithFields(log.Fields{
+			"error":      err,
+			"namespace":  resource.Namespace,
+			"deployment": resource.Name,
+			"kind":       resource.Kind(),
+			"update":     fmt.Sprintf("%s->%s", plan.CurrentVersion, plan.NewVersion),
+		}).Error("provider.kubernetes: got error while updating resource")
 
-		err = p.sender.Send(types.EventNotification{
+		p.sender.Send(types.EventNotification{
+			Name:         "update resource",
 			ResourceKind: resource.Kind(),
 			Identifier:   resource.Identifier,
-			Name:         "update resource",
-			Message:      msg,
+			Message:      fmt.Sprintf("%s %s/%s update %s->%s failed, error: %s", resource.Kind(), resource.Namespace, resource.Name, plan.CurrentVersion, plan.NewVersion, err),
 			CreatedAt:    time.Now(),
 			Type:         types.NotificationDeploymentUpdate,
-			Level:        types.LevelSuccess,
+			Level:        types.LevelError,
 			Channels:     notificationChannels,
+			MinLevel:     notificationLevel,
 			Metadata: map[string]string{
 				"provider":  p.GetName(),
 				"namespace": resource.GetNamespace(),
 				"name":      resource.GetName(),
-			},
-		})
-		if err != nil {
-			log.WithFields(log.Fields{
-				"error":     err,
-				"name":      resource.Name,
-				"kind":      resource.Kind(),
 				"previous":  plan.CurrentVersion,
 				"new":       plan.NewVersion,
-				"namespace": resource.Namespace,
-			}).Error("provider.kubernetes: got error while sending notification")
-		}
+				"image":     plan.Image(),
+				"policy":    plc.Name(),
+				"images":    strings.Join(resource.GetImages(), ", "),
+				"trigger":   plan.Trigger,
+			},
+		})
+		p.recordEvent(resource, v1.EventTypeWarning, EventReasonUpdateFailed, fmt.Sprintf("Update %s->%s failed: %s", plan.CurrentVersion, plan.NewVersion, err))
+		p.updateFailed(plan, err)
+
+		return false, false
+	}
 
+	p.updateSucceeded(plan)
+
+	p.verifyUpdate(plan)
+
+	err = p.updateComplete(plan)
+	if err != nil {
 		log.WithFields(log.Fields{
+			"error":     err,
+			"name":      resource.Name,
+			"kind":      resource.Kind(),
+			"namespace": resource.Namespace,
+		}).Warn("provider.kubernetes: got error while archiving approvals counter after successful update")
+	}
+
+	var msg string
+	releaseNotes := types.ParseReleaseNotesURL(settings)
+	if releaseNotes != "" {
+		msg = fmt.Sprintf("Successfully updated %s %s/%s %s->%s (%s). Release notes: %s", resource.Kind(), resource.Namespace, resource.Name, plan.CurrentVersion, plan.NewVersion, strings.Join(resource.GetImages(), ", "), releaseNotes)
+	} else {
+		msg = fmt.Sprintf("Successfully updated %s %s/%s %s->%s (%s)", resource.Kind(), resource.Namespace, resource.Name, plan.CurrentVersion, plan.NewVersion, strings.Join(resource.GetImages(), ", "))
+	}
+
+	metadata := map[string]string{
+		"provider":  p.GetName(),
+		"namespace": resource.GetNamespace(),
+		"name":      resource.GetName(),
+		"previous":  plan.CurrentVersion,
+		"new":       plan.NewVersion,
+		"image":     plan.Image(),
+		"policy":    plc.Name(),
+		"images":    strings.Join(resource.GetImages(), ", "),
+		"trigger":   plan.Trigger,
+	}
+	if commit := p.commit(plan); commit != nil {
+		msg = fmt.Sprintf("%s. Commit: %s", msg, commit)
+		metadata["commit"] = commit.SHA
+		metadata["commitMessage"] = commit.Title()
+		metadata["commitAuthor"] = commit.Author
+		metadata["commitURL"] = commit.URL
+	}
+
+	p.recordEvent(resource, v1.EventTypeNormal, EventReasonUpdated, fmt.Sprintf("Updated %s->%s (%s)", plan.CurrentVersion, plan.NewVersion, strings.Join(resource.GetImages(), ", ")))
+
+	err = p.sender.Send(types.EventNotification{
+		ResourceKind: resource.Kind(),
+		Identifier:   resource.Identifier,
+		Name:         "update resource",
+		Message:      msg,
+		CreatedAt:    time.Now(),
+		Type:         types.NotificationDeploymentUpdate,
+		Level:        types.LevelSuccess,
+		Channels:     notificationChannels,
+		MinLevel:     notificationLevel,
+		Metadata:     metadata,
+	})
+	if err != nil {
+		log.WithFields(log.Fields{
+			"error":     err,
 			"name":      resource.Name,
 			"kind":      resource.Kind(),
 			"previous":  plan.CurrentVersion,
 			"new":       plan.NewVersion,
 			"namespace": resource.Namespace,
-		}).Info("provider.kubernetes: resource updated")
-		updated = append(updated, resource)
+		}).Error("provider.kubernetes: got error while sending notification")
 	}
 
-	return
+	log.WithFields(log.Fields{
+		"name":      resource.Name,
+		"kind":      resource.Kind(),
+		"previous":  plan.CurrentVersion,
+		"new":       plan.NewVersion,
+		"namespace": resource.Namespace,
+	}).Info("provider.kubernetes: resource updated")
+	return true, true
+}
+
+// update - applies resource changes, callers must hold the resource lock
+func (p *Provider) update(resource *k8s.GenericResource) error {
+	return p.implementer.Update(resource)
+}
+
+// planOutdated - re-evaluates plan against the latest known version of the
+// resource, plan is outdated once resource images changed since it was
+// created (ie: another trigger already updated it)
+func (p *Provider) planOutdated(plan *UpdatePlan) bool {
+	if len(plan.PreviousImages) == 0 {
+		return false
+	}
+	current := p.currentResource(plan.Resource.Identifier)
+	if current == nil {
+		return false
+	}
+	return strings.Join(current.GetImages(), ",") != strings.Join(plan.PreviousImages, ",") ||
+		strings.Join(current.GetInitImages(), ",") != strings.Join(plan.PreviousInitImages, ",")
 }
 
 func getDesiredImage(delta map[string]string, currentImage string) (string, error) {
@@ -408,14 +827,18 @@ func (p *Provider) createUpdatePlans(repo *types.Repository) ([]*UpdatePlan, err
 	for _, resource := range p.cache.Values() {
 
 		labels := resource.GetLabels()
-		annotations := resource.GetAnnotations()
+		annotations := resource.GetKeelAnnotations()
 
 		plc := policy.GetPolicyFromLabelsOrAnnotations(labels, annotations)
-		if plc.Type() == policy.PolicyTypeNone {
+		if plc.Type() == policy.PolicyTypeNone && !policy.HasContainerPolicies(annotations) {
 			continue
 		}
 
-		updated, shouldUpdateDeployment, err := checkForUpdate(plc, repo, resource)
+		previousImages := resource.GetImages()
+		previousInitImages := resource.GetInitImages()
+
+		kubernetesResourcesScannedCounter.Inc()
+		updated, shouldUpdateDeployment, err := checkForUpdate(plc, p.resolveDigest(repo, resource), resource)
 		if err != nil {
 			log.WithFields(log.Fields{
 				"error":      err,
@@ -427,6 +850,8 @@ func (p *Provider) createUpdatePlans(repo *types.Repository) ([]*UpdatePlan, err
 		}
 
 		if shouldUpdateDeployment {
+			updated.PreviousImages = previousImages
+			updated.PreviousInitImages = previousInitImages
 			impacted = append(impacted, updated)
 		}
 	}
@@ -1,11 +1,14 @@
 package kubernetes
 
 import (
+	"context"
 	"io/ioutil"
 	"log"
 	"os"
 	"path/filepath"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/keel-hq/keel/approvals"
 	"github.com/keel-hq/keel/extension/notification"
@@ -16,6 +19,9 @@ import (
 	apps_v1 "k8s.io/api/apps/v1"
 	v1 "k8s.io/api/core/v1"
 	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+	batch_v1 "k8s.io/client-go/kubernetes/typed/batch/v1"
+	batch_v1beta1 "k8s.io/client-go/kubernetes/typed/batch/v1beta1"
 	core_v1 "k8s.io/client-go/kubernetes/typed/core/v1"
 )
 
@@ -52,8 +58,16 @@ type fakeImplementer struct {
 
 	// stores value of an updated deployment
 	updated *k8s.GenericResource
+	// returned by Update, attempts are counted
+	updateErr      error
+	updateAttempts int
+	// guards updated and updateAttempts for tests updating from a worker
+	mu sync.Mutex
 
 	availableSecret *v1.Secret
+
+	// backs jobs and cron jobs
+	client *fake.Clientset
 }
 
 func (i *fakeImplementer) Namespaces() (*v1.NamespaceList, error) {
@@ -69,10 +83,23 @@ func (i *fakeImplementer) Deployments(namespace string) (*apps_v1.DeploymentList
 }
 
 func (i *fakeImplementer) Update(obj *k8s.GenericResource) error {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	i.updateAttempts++
+	if i.updateErr != nil {
+		return i.updateErr
+	}
 	i.updated = obj
 	return nil
 }
 
+// lastUpdate - update attempts and last updated resource
+func (i *fakeImplementer) lastUpdate() (int, *k8s.GenericResource) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	return i.updateAttempts, i.updated
+}
+
 func (i *fakeImplementer) Secret(namespace, name string) (*v1.Secret, error) {
 	return i.availableSecret, nil
 }
@@ -81,6 +108,10 @@ func (i *fakeImplementer) Pods(namespace, labelSelector string) (*v1.PodList, er
 	return i.podList, nil
 }
 
+func (i *fakeImplementer) ServiceAccount(namespace, name string) (*v1.ServiceAccount, error) {
+	return &v1.ServiceAccount{}, nil
+}
+
 func (i *fakeImplementer) DeletePod(namespace, name string, opts *meta_v1.DeleteOptions) error {
 	i.deletedPods = append(i.deletedPods, &v1.Pod{
 		meta_v1.TypeMeta{},
@@ -98,6 +129,21 @@ func (i *fakeImplementer) ConfigMaps(namespace string) core_v1.ConfigMapInterfac
 	return nil
 }
 
+func (i *fakeImplementer) Jobs(namespace string) batch_v1.JobInterface {
+	return i.client.BatchV1().Jobs(namespace)
+}
+
+func (i *fakeImplementer) CronJobs(namespace string) batch_v1beta1.CronJobInterface {
+	return i.client.BatchV1beta1().CronJobs(namespace)
+}
+
+func (i *fakeImplementer) Events(namespace string) core_v1.EventInterface {
+	if i.client == nil {
+		i.client = fake.NewSimpleClientset()
+	}
+	return i.client.CoreV1().Events(namespace)
+}
+
 type fakeSender struct {
 	sentEvent types.EventNotification
 }
@@ -1445,3 +1491,83 @@ func TestTrackedImagesWithSecrets(t *testing.T) {
 		t.Errorf("expected very-secret, got: %s", imgs[0].Secrets[1])
 	}
 }
+
+type countingSender struct {
+	mu   sync.Mutex
+	sent []types.EventNotification
+}
+
+func (s *countingSender) Configure(*notification.Config) (bool, error) {
+	return true, nil
+}
+
+func (s *countingSender) Send(event types.EventNotification) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.sent = append(s.sent, event)
+	return nil
+}
+
+func TestUpdateNotificationsDeduplicated(t *testing.T) {
+	fp := &fakeImplementer{}
+	var deps []*apps_v1.Deployment
+	for _, name := range []string{"deployment-1", "deployment-2", "deployment-3"} {
+		deps = append(deps, &apps_v1.Deployment{
+			meta_v1.TypeMeta{},
+			meta_v1.ObjectMeta{
+				Name:      name,
+				Namespace: "xxxx",
+				Labels:    map[string]string{types.KeelPolicyLabel: "all"},
+			},
+			apps_v1.DeploymentSpec{
+				Template: v1.PodTemplateSpec{
+					Spec: v1.PodSpec{
+						Containers: []v1.Container{
+							{
+								Image: "gcr.io/v2-namespace/hello-world:10.0.0",
+							},
+						},
+					},
+				},
+			},
+			apps_v1.DeploymentStatus{},
+		})
+	}
+	grc := &k8s.GenericResourceCache{}
+	grc.Add(MustParseGRS(deps)...)
+
+	sender := notification.New(context.Background())
+	sender.Configure(&notification.Config{
+		Level:       types.LevelInfo,
+		Attempts:    1,
+		DedupWindow: time.Minute,
+	})
+	cs := &countingSender{}
+	notification.RegisterSender("countingSender", cs)
+	defer sender.UnregisterSender("countingSender")
+
+	approver, teardown := approver()
+	defer teardown()
+	provider, err := NewProvider(fp, sender, approver, grc)
+	if err != nil {
+		t.Fatalf("failed to get provider: %s", err)
+	}
+
+	updated, err := provider.processEvent(&types.Event{Repository: types.Repository{
+		Name: "gcr.io/v2-namespace/hello-world",
+		Tag:  "11.0.0",
+	}})
+	if err != nil {
+		t.Fatalf("got error while processing event: %s", err)
+	}
+	if len(updated) != 3 {
+		t.Fatalf("expected 3 updated resources, got: %d", len(updated))
+	}
+
+	if len(cs.sent) != 1 {
+		t.Fatalf("expected a single notification for the image push, got: %d", len(cs.sent))
+	}
+	if cs.sent[0].Type != types.NotificationDeploymentUpdate || cs.sent[0].Metadata["new"] != "11.0.0" {
+		t.Errorf("unexpected notification: %+v", cs.sent[0])
+	}
+}
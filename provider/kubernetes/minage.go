@@ -0,0 +1,131 @@
+package kubernetes
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/keel-hq/keel/internal/k8s"
+	"github.com/keel-hq/keel/types"
+	"github.com/keel-hq/keel/util/image"
+	"github.com/keel-hq/keel/util/timeutil"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// CreatedResolver - looks up creation time of the image tag in the registry
+type CreatedResolver interface {
+	Created(image *types.TrackedImage) (time.Time, error)
+}
+
+// SetCreatedResolver - age of new versions set via keel.sh/minAge is based on
+// image creation time, otherwise on when keel first saw the version
+func (p *Provider) SetCreatedResolver(resolver CreatedResolver) {
+	p.created = resolver
+}
+
+// versionSeen - when keel first saw the new version of the resource
+type versionSeen struct {
+	version string
+	at      time.Time
+}
+
+// getMinAge - returns minimum age of new versions, 0 if not set
+func getMinAge(annotations map[string]string) (time.Duration, error) {
+	val := strings.TrimSpace(annotations[types.KeelMinAgeAnnotation])
+	if val == "" {
+		return 0, nil
+	}
+	minAge, err := time.ParseDuration(val)
+	if err != nil || minAge < 0 {
+		return 0, fmt.Errorf("invalid min age '%s'", val)
+	}
+	return minAge, nil
+}
+
+// holdYoung - queues updates to versions younger than keel.sh/minAge until
+// they are old enough, protecting against quickly retagged or yanked releases
+func (p *Provider) holdYoung(event *types.Event, plans []*UpdatePlan) []*UpdatePlan {
+	var ready []*UpdatePlan
+	now := timeutil.Now()
+
+	for _, plan := range plans {
+		resource := plan.Resource
+		minAge, err := getMinAge(resource.GetKeelAnnotations())
+		if err != nil {
+			log.WithFields(log.Fields{
+				"error":     err,
+				"name":      resource.Name,
+				"kind":      resource.Kind(),
+				"namespace": resource.Namespace,
+			}).Error("provider.kubernetes: invalid min age, ignoring it")
+			ready = append(ready, plan)
+			continue
+		}
+		if minAge == 0 {
+			ready = append(ready, plan)
+			continue
+		}
+
+		readyAt := p.versionCreated(event, plan, now).Add(minAge)
+		if !now.Before(readyAt) {
+			ready = append(ready, plan)
+			continue
+		}
+
+		p.queue(event, plan, "", readyAt, fmt.Sprintf("%s, new version is younger than %s", readyAt.Format(time.RFC3339), minAge), now)
+	}
+
+	return ready
+}
+
+// versionCreated - creation time of the new version, falls back to when the
+// version was first seen if the registry can't tell
+func (p *Provider) versionCreated(event *types.Event, plan *UpdatePlan, now time.Time) time.Time {
+	resource := plan.Resource
+
+	p.queuedMu.Lock()
+	if p.firstSeen == nil {
+		p.firstSeen = make(map[string]versionSeen)
+	}
+	seen, ok := p.firstSeen[resource.Identifier]
+	if !ok || seen.version != plan.NewVersion {
+		seen = versionSeen{version: plan.NewVersion, at: now}
+		p.firstSeen[resource.Identifier] = seen
+	}
+	p.queuedMu.Unlock()
+
+	if p.created == nil {
+		return seen.at
+	}
+	ref, err := image.Parse(event.Repository.String())
+	if err != nil {
+		return seen.at
+	}
+
+	created, err := p.created.Created(trackedImage(ref, resource, p.cluster))
+	if err != nil || created.IsZero() {
+		log.WithFields(log.Fields{
+			"error":     err,
+			"name":      resource.Name,
+			"namespace": resource.Namespace,
+			"image":     event.Repository.String(),
+		}).Warn("provider.kubernetes: failed to get image creation time, using when the version was first seen")
+		return seen.at
+	}
+	return created
+}
+
+// trackedImage - image with registry credentials of the resource
+func trackedImage(ref *image.Reference, resource *k8s.GenericResource, cluster string) *types.TrackedImage {
+	return &types.TrackedImage{
+		Image:           ref,
+		Provider:        ProviderName,
+		Cluster:         cluster,
+		Namespace:       resource.Namespace,
+		Identifier:      resource.Identifier,
+		Secrets:         imagePullSecrets(resource),
+		PollCredentials: resource.GetKeelAnnotations()[types.KeelPollCredentialsAnnotation],
+		Meta:            map[string]string{"serviceAccount": serviceAccountName(resource)},
+	}
+}
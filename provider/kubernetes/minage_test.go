@@ -0,0 +1,134 @@
+package kubernetes
+
+import (
+	"testing"
+	"time"
+
+	"github.com/keel-hq/keel/internal/k8s"
+	"github.com/keel-hq/keel/types"
+	"github.com/keel-hq/keel/util/timeutil"
+
+	apps_v1 "k8s.io/api/apps/v1"
+	v1 "k8s.io/api/core/v1"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+type fakeCreatedResolver struct {
+	created time.Time
+}
+
+func (r *fakeCreatedResolver) Created(image *types.TrackedImage) (time.Time, error) {
+	return r.created, nil
+}
+
+func minAgeProvider(t *testing.T, fp *fakeImplementer) (*Provider, func()) {
+	grc := &k8s.GenericResourceCache{}
+	grc.Add(MustParseGR(&apps_v1.Deployment{
+		meta_v1.TypeMeta{},
+		meta_v1.ObjectMeta{
+			Name:        "deployment-1",
+			Namespace:   "ns-1",
+			Labels:      map[string]string{types.KeelPolicyLabel: "all"},
+			Annotations: map[string]string{types.KeelMinAgeAnnotation: "30m"},
+		},
+		apps_v1.DeploymentSpec{
+			Template: v1.PodTemplateSpec{
+				Spec: v1.PodSpec{
+					Containers: []v1.Container{
+						{
+							Image: "gcr.io/v2-namespace/hello-world:1.1.1",
+						},
+					},
+				},
+			},
+		},
+		apps_v1.DeploymentStatus{},
+	}))
+
+	approver, teardown := approver()
+	provider, err := NewProvider(fp, &fakeSender{}, approver, grc)
+	if err != nil {
+		teardown()
+		t.Fatalf("failed to get provider: %s", err)
+	}
+	return provider, teardown
+}
+
+func TestMinAgeCreated(t *testing.T) {
+	now := time.Date(2021, 3, 1, 20, 0, 0, 0, time.UTC)
+	timeutil.Now = func() time.Time { return now }
+	defer func() { timeutil.Now = time.Now }()
+
+	fp := &fakeImplementer{}
+	provider, teardown := minAgeProvider(t, fp)
+	defer teardown()
+	provider.SetCreatedResolver(&fakeCreatedResolver{created: now.Add(-10 * time.Minute)})
+
+	_, err := provider.processEvent(&types.Event{Repository: types.Repository{
+		Name: "gcr.io/v2-namespace/hello-world",
+		Tag:  "1.1.2",
+	}})
+	if err != nil {
+		t.Fatalf("got error while processing event: %s", err)
+	}
+	if fp.updated != nil {
+		t.Fatalf("resource shouldn't be updated to a version younger than min age")
+	}
+
+	queued := provider.QueuedUpdates()
+	if len(queued) != 1 {
+		t.Fatalf("expected 1 queued update, got: %d", len(queued))
+	}
+	if !queued[0].ReadyAt.Equal(now.Add(20 * time.Minute)) {
+		t.Errorf("unexpected ready time: %s", queued[0].ReadyAt)
+	}
+
+	provider.processQueued()
+	if fp.updated != nil {
+		t.Fatalf("resource shouldn't be updated before the version is old enough")
+	}
+
+	now = now.Add(20 * time.Minute)
+	provider.processQueued()
+	if fp.updated == nil {
+		t.Fatalf("expected queued update to be applied once version is old enough")
+	}
+	if fp.updated.Containers()[0].Image != "gcr.io/v2-namespace/hello-world:1.1.2" {
+		t.Errorf("unexpected image: %s", fp.updated.Containers()[0].Image)
+	}
+}
+
+func TestMinAgeFirstSeen(t *testing.T) {
+	now := time.Date(2021, 3, 1, 20, 0, 0, 0, time.UTC)
+	timeutil.Now = func() time.Time { return now }
+	defer func() { timeutil.Now = time.Now }()
+
+	fp := &fakeImplementer{}
+	provider, teardown := minAgeProvider(t, fp)
+	defer teardown()
+
+	event := &types.Event{Repository: types.Repository{
+		Name: "gcr.io/v2-namespace/hello-world",
+		Tag:  "1.1.2",
+	}}
+	_, err := provider.processEvent(event)
+	if err != nil {
+		t.Fatalf("got error while processing event: %s", err)
+	}
+	if fp.updated != nil {
+		t.Fatalf("resource shouldn't be updated to a version seen for the first time")
+	}
+
+	// seen again by the next poll, age is still counted from the first time
+	now = now.Add(29 * time.Minute)
+	provider.processEvent(event)
+	if fp.updated != nil {
+		t.Fatalf("resource shouldn't be updated before the version is old enough")
+	}
+
+	now = now.Add(time.Minute)
+	provider.processQueued()
+	if fp.updated == nil {
+		t.Fatalf("expected queued update to be applied once version is old enough")
+	}
+}
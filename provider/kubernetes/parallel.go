@@ -0,0 +1,231 @@
+package kubernetes
+
+import (
+	"context"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// EnvMaxParallelRollouts - how many resources can be rolling out at the same
+// time, further updates are queued and applied in order. Unlimited if not set or "0"
+const EnvMaxParallelRollouts = "MAX_PARALLEL_ROLLOUTS"
+
+// EnvRolloutWaitHealthy - set to true to hold rollout slot until the rollout is
+// complete and available, otherwise it's freed once the controller picks it up
+const EnvRolloutWaitHealthy = "ROLLOUT_WAIT_HEALTHY"
+
+// DefaultRolloutSlotTimeout - rollout slot is freed after this long even if
+// the rollout didn't finish, so one stuck resource doesn't block others
+const DefaultRolloutSlotTimeout = 10 * time.Minute
+
+func getMaxParallelRollouts() int {
+	val := os.Getenv(EnvMaxParallelRollouts)
+	if val == "" {
+		return 0
+	}
+
+	max, err := strconv.Atoi(val)
+	if err != nil || max < 0 {
+		log.WithFields(log.Fields{
+			"error": err,
+			"max":   val,
+		}).Warnf("provider.kubernetes: invalid %s, rollouts won't be limited", EnvMaxParallelRollouts)
+		return 0
+	}
+	return max
+}
+
+func getRolloutWaitHealthy() bool {
+	wait, _ := strconv.ParseBool(os.Getenv(EnvRolloutWaitHealthy))
+	return wait
+}
+
+// rolloutSlots - limits rollouts happening at the same time, nil means unlimited
+type rolloutSlots struct {
+	slots       chan struct{}
+	waitHealthy bool
+	timeout     time.Duration
+	interval    time.Duration
+
+	// plans waiting for a free slot, in the order they were planned
+	mu     sync.Mutex
+	queue  []*queuedRollout
+	queued chan struct{}
+}
+
+type queuedRollout struct {
+	ctx  context.Context
+	plan *UpdatePlan
+}
+
+func newRolloutSlots(max int, waitHealthy bool) *rolloutSlots {
+	if max <= 0 {
+		return nil
+	}
+	return &rolloutSlots{
+		slots:       make(chan struct{}, max),
+		waitHealthy: waitHealthy,
+		timeout:     DefaultRolloutSlotTimeout,
+		interval:    DefaultRolloutCheckInterval,
+		queued:      make(chan struct{}, 1),
+	}
+}
+
+// pending - number of queued plans
+func (r *rolloutSlots) pending() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return len(r.queue)
+}
+
+func (r *rolloutSlots) push(q *queuedRollout) int {
+	r.mu.Lock()
+	r.queue = append(r.queue, q)
+	pending := len(r.queue)
+	r.mu.Unlock()
+
+	select {
+	case r.queued <- struct{}{}:
+	default:
+	}
+	return pending
+}
+
+func (r *rolloutSlots) pop() *queuedRollout {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if len(r.queue) == 0 {
+		return nil
+	}
+	q := r.queue[0]
+	r.queue[0] = nil
+	r.queue = r.queue[1:]
+	return q
+}
+
+// queueRollout - queues plan to be applied by the rollout worker once a slot
+// is free, so event processing doesn't wait for other rollouts
+func (p *Provider) queueRollout(ctx context.Context, plan *UpdatePlan) {
+	pending := p.rollouts.push(&queuedRollout{ctx: ctx, plan: plan})
+
+	log.WithFields(log.Fields{
+		"name":      plan.Resource.Name,
+		"kind":      plan.Resource.Kind(),
+		"namespace": plan.Resource.Namespace,
+		"queued":    pending,
+	}).Debug("provider.kubernetes: update queued")
+}
+
+// runRollouts - applies queued plans in order as rollout slots free up,
+// returns once provider is stopping
+func (p *Provider) runRollouts() {
+	for {
+		q := p.rollouts.pop()
+		if q == nil {
+			select {
+			case <-p.rollouts.queued:
+				continue
+			case <-p.stop:
+				return
+			}
+		}
+
+		if !p.acquireRolloutSlot(q.plan) {
+			return
+		}
+		applied := false
+		p.inFlight(func() {
+			_, applied = p.updateDeployment(q.ctx, q.plan)
+		})
+		p.releaseRolloutSlot(q.plan, applied)
+	}
+}
+
+// acquireRolloutSlot - blocks the rollout worker until resource can be updated.
+// Returns false if provider is stopping
+func (p *Provider) acquireRolloutSlot(plan *UpdatePlan) bool {
+	select {
+	case p.rollouts.slots <- struct{}{}:
+		return true
+	default:
+	}
+
+	log.WithFields(log.Fields{
+		"name":      plan.Resource.Name,
+		"kind":      plan.Resource.Kind(),
+		"namespace": plan.Resource.Namespace,
+		"max":       cap(p.rollouts.slots),
+	}).Info("provider.kubernetes: max parallel rollouts reached, waiting for a free slot")
+
+	select {
+	case p.rollouts.slots <- struct{}{}:
+		return true
+	case <-p.stop:
+		return false
+	}
+}
+
+// releaseRolloutSlot - frees the slot straight away if the update wasn't applied,
+// otherwise once the rollout has started or, with ROLLOUT_WAIT_HEALTHY, finished
+func (p *Provider) releaseRolloutSlot(plan *UpdatePlan, applied bool) {
+	if !applied {
+		<-p.rollouts.slots
+		return
+	}
+
+	go func() {
+		defer func() { <-p.rollouts.slots }()
+		p.waitRolloutProgress(plan)
+	}()
+}
+
+// waitRolloutProgress - waits until the rollout started or, with ROLLOUT_WAIT_HEALTHY,
+// finished. Unlike waitForRollout it waits for the cache to catch up with the update
+func (p *Provider) waitRolloutProgress(plan *UpdatePlan) {
+	resource := plan.Resource
+	images := strings.Join(resource.GetImages(), ",")
+
+	timer := time.NewTimer(p.rollouts.timeout)
+	defer timer.Stop()
+	ticker := time.NewTicker(p.rollouts.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-p.stop:
+			return
+		case <-timer.C:
+			log.WithFields(log.Fields{
+				"name":      resource.Name,
+				"kind":      resource.Kind(),
+				"namespace": resource.Namespace,
+				"timeout":   p.rollouts.timeout,
+			}).Warn("provider.kubernetes: rollout didn't finish in time, freeing rollout slot")
+			return
+		case <-ticker.C:
+			current := p.currentResource(resource.Identifier)
+			if current == nil {
+				// resource was deleted
+				return
+			}
+			if strings.Join(current.GetImages(), ",") != images {
+				// cache didn't catch up with the update yet
+				continue
+			}
+			if !current.RolloutStarted() {
+				continue
+			}
+			if !p.rollouts.waitHealthy {
+				return
+			}
+			if done, failure := current.RolloutStatus(); done || failure != "" {
+				return
+			}
+		}
+	}
+}
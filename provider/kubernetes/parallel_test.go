@@ -0,0 +1,95 @@
+package kubernetes
+
+import (
+	"testing"
+	"time"
+
+	"github.com/keel-hq/keel/internal/k8s"
+	"github.com/keel-hq/keel/types"
+
+	apps_v1 "k8s.io/api/apps/v1"
+	v1 "k8s.io/api/core/v1"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestMaxParallelRollouts(t *testing.T) {
+	fp := &fakeImplementer{}
+	var deps []*apps_v1.Deployment
+	for _, name := range []string{"deployment-1", "deployment-2"} {
+		deps = append(deps, &apps_v1.Deployment{
+			meta_v1.TypeMeta{},
+			meta_v1.ObjectMeta{
+				Name:      name,
+				Namespace: "xxxx",
+				Labels:    map[string]string{types.KeelPolicyLabel: "all"},
+			},
+			apps_v1.DeploymentSpec{
+				Template: v1.PodTemplateSpec{
+					Spec: v1.PodSpec{
+						Containers: []v1.Container{
+							{
+								Image: "gcr.io/v2-namespace/hello-world:10.0.0",
+							},
+						},
+					},
+				},
+			},
+			apps_v1.DeploymentStatus{},
+		})
+	}
+
+	grc := &k8s.GenericResourceCache{}
+	grc.Add(MustParseGRS(deps)...)
+
+	approver, teardown := approver()
+	defer teardown()
+	provider, err := NewProvider(fp, &fakeSender{}, approver, grc)
+	if err != nil {
+		t.Fatalf("failed to get provider: %s", err)
+	}
+	provider.rollouts = newRolloutSlots(1, false)
+	provider.rollouts.interval = 10 * time.Millisecond
+	defer provider.Stop()
+	go provider.runRollouts()
+
+	updated, err := provider.processEvent(&types.Event{Repository: types.Repository{
+		Name: "gcr.io/v2-namespace/hello-world",
+		Tag:  "11.0.0",
+	}})
+	if err != nil {
+		t.Fatalf("got error while processing event: %s", err)
+	}
+	if len(updated) != 0 {
+		t.Errorf("expected updates to be queued, got %d updated", len(updated))
+	}
+
+	var first *k8s.GenericResource
+	for deadline := time.Now().Add(2 * time.Second); first == nil; {
+		if time.Now().After(deadline) {
+			t.Fatalf("expected first queued update to be applied")
+		}
+		time.Sleep(10 * time.Millisecond)
+		_, first = fp.lastUpdate()
+	}
+
+	time.Sleep(200 * time.Millisecond)
+	if attempts, _ := fp.lastUpdate(); attempts != 1 {
+		t.Fatalf("expected 1 update while rollout slot is taken, got: %d", attempts)
+	}
+	if pending := provider.rollouts.pending(); pending != 0 {
+		t.Errorf("expected second update to be waiting for a slot, got %d queued", pending)
+	}
+
+	// controller picked up the first update
+	grc.Add(first)
+
+	for deadline := time.Now().Add(2 * time.Second); ; {
+		if attempts, _ := fp.lastUpdate(); attempts == 2 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("expected second update to be applied once the rollout started")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
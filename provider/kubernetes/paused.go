@@ -0,0 +1,145 @@
+package kubernetes
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/keel-hq/keel/types"
+	"github.com/keel-hq/keel/util/timeutil"
+
+	v1 "k8s.io/api/core/v1"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// holdPaused - skips plans of resources with keel.sh/paused set, each
+// skipped version is reported once so operators know what would be updated
+func (p *Provider) holdPaused(plans []*UpdatePlan) []*UpdatePlan {
+	var ready []*UpdatePlan
+
+	p.pausedMu.Lock()
+	defer p.pausedMu.Unlock()
+
+	for _, plan := range plans {
+		resource := plan.Resource
+		if !isPaused(plan) {
+			delete(p.pausedReported, resource.Identifier)
+			ready = append(ready, plan)
+			continue
+		}
+
+		if p.pausedReported[resource.Identifier] == plan.NewVersion {
+			continue
+		}
+		p.pausedReported[resource.Identifier] = plan.NewVersion
+
+		log.WithFields(log.Fields{
+			"name":      resource.Name,
+			"kind":      resource.Kind(),
+			"namespace": resource.Namespace,
+			"update":    fmt.Sprintf("%s->%s", plan.CurrentVersion, plan.NewVersion),
+		}).Info("provider.kubernetes: resource is paused, skipping update")
+
+		msg := fmt.Sprintf("Update %s->%s skipped, %s is set", plan.CurrentVersion, plan.NewVersion, types.KeelPausedAnnotation)
+		p.recordEvent(resource, v1.EventTypeNormal, EventReasonUpdateSkipped, msg)
+
+		settings := resource.GetKeelAnnotations()
+		p.sender.Send(types.EventNotification{
+			ResourceKind: resource.Kind(),
+			Identifier:   resource.Identifier,
+			Name:         "update paused",
+			Message:      fmt.Sprintf("%s %s/%s: %s (%s)", resource.Kind(), resource.Namespace, resource.Name, msg, strings.Join(resource.GetImages(), ", ")),
+			CreatedAt:    time.Now(),
+			Type:         types.NotificationPreDeploymentUpdate,
+			Level:        types.LevelInfo,
+			Channels:     types.ParseEventNotificationChannelsFromLabelsOrAnnotations(resource.GetLabels(), settings),
+			MinLevel:     types.ParseEventNotificationLevel(resource.GetLabels(), settings),
+			Metadata: map[string]string{
+				"provider":  p.GetName(),
+				"namespace": resource.GetNamespace(),
+				"name":      resource.GetName(),
+				"previous":  plan.CurrentVersion,
+				"new":       plan.NewVersion,
+				"trigger":   plan.Trigger,
+			},
+		})
+	}
+
+	return ready
+}
+
+// holdPausedUntil - queues plans of resources with keel.sh/pauseUntil in the
+// future, newer updates replace queued ones so the latest version is applied
+// once the pause expires
+func (p *Provider) holdPausedUntil(event *types.Event, plans []*UpdatePlan) []*UpdatePlan {
+	var ready []*UpdatePlan
+	now := timeutil.Now()
+
+	for _, plan := range plans {
+		resource := plan.Resource
+		val := strings.TrimSpace(resource.GetKeelAnnotations()[types.KeelPauseUntilAnnotation])
+		if val == "" {
+			ready = append(ready, plan)
+			continue
+		}
+
+		until, err := parsePauseUntil(val)
+		if err != nil {
+			log.WithFields(log.Fields{
+				"error":     err,
+				"name":      resource.Name,
+				"kind":      resource.Kind(),
+				"namespace": resource.Namespace,
+			}).Errorf("provider.kubernetes: invalid %s value, ignoring it", types.KeelPauseUntilAnnotation)
+			ready = append(ready, plan)
+			continue
+		}
+
+		if !now.Before(until) {
+			ready = append(ready, plan)
+			continue
+		}
+
+		p.queue(event, plan, "", until, fmt.Sprintf("%s (%s)", until.Format(time.RFC3339), types.KeelPauseUntilAnnotation), now)
+	}
+
+	return ready
+}
+
+// pauseUntilLayouts - accepted keel.sh/pauseUntil formats, times without
+// zone are UTC
+var pauseUntilLayouts = []string{
+	time.RFC3339,
+	"2006-01-02T15:04Z07:00",
+	"2006-01-02T15:04:05",
+	"2006-01-02T15:04",
+	"2006-01-02",
+}
+
+func parsePauseUntil(val string) (time.Time, error) {
+	for _, layout := range pauseUntilLayouts {
+		if t, err := time.Parse(layout, val); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("unsupported time format '%s', expected RFC 3339 (ie: 2024-07-01T09:00Z)", val)
+}
+
+func isPaused(plan *UpdatePlan) bool {
+	val, ok := plan.Resource.GetKeelAnnotations()[types.KeelPausedAnnotation]
+	if !ok {
+		return false
+	}
+	paused, err := strconv.ParseBool(strings.TrimSpace(val))
+	if err != nil {
+		log.WithFields(log.Fields{
+			"error":     err,
+			"name":      plan.Resource.Name,
+			"namespace": plan.Resource.Namespace,
+		}).Errorf("provider.kubernetes: invalid %s value, ignoring it", types.KeelPausedAnnotation)
+		return false
+	}
+	return paused
+}
@@ -0,0 +1,178 @@
+package kubernetes
+
+import (
+	"testing"
+	"time"
+
+	"github.com/keel-hq/keel/internal/k8s"
+	"github.com/keel-hq/keel/types"
+	"github.com/keel-hq/keel/util/timeutil"
+
+	apps_v1 "k8s.io/api/apps/v1"
+	v1 "k8s.io/api/core/v1"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestPausedResource(t *testing.T) {
+	fp := &fakeImplementer{}
+	deps := []*apps_v1.Deployment{
+		{
+			meta_v1.TypeMeta{},
+			meta_v1.ObjectMeta{
+				Name:      "deployment-1",
+				Namespace: "xxxx",
+				Labels:    map[string]string{types.KeelPolicyLabel: "all"},
+				Annotations: map[string]string{
+					types.KeelPausedAnnotation: "true",
+				},
+			},
+			apps_v1.DeploymentSpec{
+				Template: v1.PodTemplateSpec{
+					Spec: v1.PodSpec{
+						Containers: []v1.Container{
+							{
+								Image: "gcr.io/v2-namespace/hello-world:10.0.0",
+							},
+						},
+					},
+				},
+			},
+			apps_v1.DeploymentStatus{},
+		},
+	}
+
+	grc := &k8s.GenericResourceCache{}
+	grc.Add(MustParseGRS(deps)...)
+
+	fs := &fakeSender{}
+	approver, teardown := approver()
+	defer teardown()
+	provider, err := NewProvider(fp, fs, approver, grc)
+	if err != nil {
+		t.Fatalf("failed to get provider: %s", err)
+	}
+
+	event := &types.Event{Repository: types.Repository{
+		Name: "gcr.io/v2-namespace/hello-world",
+		Tag:  "11.0.0",
+	}}
+	_, err = provider.processEvent(event)
+	if err != nil {
+		t.Fatalf("got error while processing event: %s", err)
+	}
+
+	if fp.updated != nil {
+		t.Fatalf("didn't expect paused resource to be updated")
+	}
+
+	if fs.sentEvent.Name != "update paused" {
+		t.Errorf("expected paused update to be reported, got: %s", fs.sentEvent.Name)
+	}
+	if fs.sentEvent.Message != "deployment xxxx/deployment-1: Update 10.0.0->11.0.0 skipped, keel.sh/paused is set (gcr.io/v2-namespace/hello-world:11.0.0)" {
+		t.Errorf("unexpected message: %s", fs.sentEvent.Message)
+	}
+
+	// same version is only reported once
+	fs.sentEvent = types.EventNotification{}
+	_, err = provider.processEvent(event)
+	if err != nil {
+		t.Fatalf("got error while processing event: %s", err)
+	}
+	if fs.sentEvent.Name != "" {
+		t.Errorf("didn't expect paused update to be reported again, got: %s", fs.sentEvent.Name)
+	}
+
+	// resuming the resource
+	deps[0].Annotations[types.KeelPausedAnnotation] = "false"
+	grc.Add(MustParseGRS(deps)...)
+
+	_, err = provider.processEvent(event)
+	if err != nil {
+		t.Fatalf("got error while processing event: %s", err)
+	}
+	if fp.updated == nil || fp.updated.Containers()[0].Image != "gcr.io/v2-namespace/hello-world:11.0.0" {
+		t.Errorf("expected resource to be updated after resuming")
+	}
+}
+
+func TestPausedUntil(t *testing.T) {
+	now := time.Date(2024, 6, 20, 12, 0, 0, 0, time.UTC)
+	timeutil.Now = func() time.Time { return now }
+	defer func() { timeutil.Now = time.Now }()
+
+	fp := &fakeImplementer{}
+	grc := &k8s.GenericResourceCache{}
+	grc.Add(MustParseGR(&apps_v1.Deployment{
+		meta_v1.TypeMeta{},
+		meta_v1.ObjectMeta{
+			Name:        "deployment-1",
+			Namespace:   "xxxx",
+			Labels:      map[string]string{types.KeelPolicyLabel: "all"},
+			Annotations: map[string]string{types.KeelPauseUntilAnnotation: "2024-07-01T09:00Z"},
+		},
+		apps_v1.DeploymentSpec{
+			Template: v1.PodTemplateSpec{
+				Spec: v1.PodSpec{
+					Containers: []v1.Container{
+						{
+							Image: "gcr.io/v2-namespace/hello-world:1.1.1",
+						},
+					},
+				},
+			},
+		},
+		apps_v1.DeploymentStatus{},
+	}))
+
+	approver, teardown := approver()
+	defer teardown()
+	provider, err := NewProvider(fp, &fakeSender{}, approver, grc)
+	if err != nil {
+		t.Fatalf("failed to get provider: %s", err)
+	}
+
+	for _, tag := range []string{"1.1.2", "1.1.3"} {
+		_, err = provider.processEvent(&types.Event{Repository: types.Repository{
+			Name: "gcr.io/v2-namespace/hello-world",
+			Tag:  tag,
+		}})
+		if err != nil {
+			t.Fatalf("got error while processing event: %s", err)
+		}
+	}
+	if fp.updated != nil {
+		t.Fatalf("resource shouldn't be updated while paused")
+	}
+
+	queued := provider.QueuedUpdates()
+	if len(queued) != 1 || queued[0].NewVersion != "1.1.3" {
+		t.Fatalf("expected latest update to be queued, got: %+v", queued)
+	}
+
+	now = time.Date(2024, 7, 1, 9, 0, 0, 0, time.UTC)
+	provider.processQueued()
+	if fp.updated == nil {
+		t.Fatalf("expected pending update to be applied once pause expired")
+	}
+	if fp.updated.Containers()[0].Image != "gcr.io/v2-namespace/hello-world:1.1.3" {
+		t.Errorf("unexpected image: %s", fp.updated.Containers()[0].Image)
+	}
+}
+
+func TestParsePauseUntil(t *testing.T) {
+	want := time.Date(2024, 7, 1, 9, 0, 0, 0, time.UTC)
+	for _, val := range []string{"2024-07-01T09:00:00Z", "2024-07-01T09:00Z", "2024-07-01T11:00+02:00", "2024-07-01T09:00"} {
+		got, err := parsePauseUntil(val)
+		if err != nil {
+			t.Errorf("%s: unexpected error: %s", val, err)
+			continue
+		}
+		if !got.Equal(want) {
+			t.Errorf("%s: expected %s, got %s", val, want, got)
+		}
+	}
+
+	if _, err := parsePauseUntil("next monday"); err == nil {
+		t.Errorf("expected invalid value to fail")
+	}
+}
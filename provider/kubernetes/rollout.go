@@ -0,0 +1,128 @@
+package kubernetes
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/keel-hq/keel/internal/k8s"
+	"github.com/keel-hq/keel/types"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// EnvRolloutDeadline - default deadline for rollouts after an update (ie: 10m),
+// resources that don't become available in time are rolled back. Rollouts
+// aren't monitored if not set
+const EnvRolloutDeadline = "ROLLOUT_DEADLINE"
+
+// DefaultRolloutCheckInterval - how often rollout status is checked
+const DefaultRolloutCheckInterval = 5 * time.Second
+
+// getRolloutDeadline - deadline from resource annotation or environment, 0 if
+// rollout shouldn't be monitored
+func getRolloutDeadline(annotations map[string]string) (time.Duration, error) {
+	val, ok := annotations[types.KeelRolloutDeadlineAnnotation]
+	if !ok {
+		val = os.Getenv(EnvRolloutDeadline)
+	}
+	val = strings.TrimSpace(val)
+	if val == "" || val == "0" {
+		return 0, nil
+	}
+
+	deadline, err := time.ParseDuration(val)
+	if err != nil || deadline < 0 {
+		return 0, fmt.Errorf("invalid rollout deadline '%s'", val)
+	}
+	return deadline, nil
+}
+
+// monitorRollout - watches resource status after an update, fails if the rollout
+// fails or doesn't complete before the deadline (ie: crashlooping pods, failing
+// readiness probes)
+func (v *updateVerifier) monitorRollout(deadline, interval time.Duration) error {
+	p := v.provider
+	resource := v.plan.Resource
+	images := strings.Join(resource.GetImages(), ",")
+
+	timer := time.NewTimer(deadline)
+	defer timer.Stop()
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	// check returns true when monitoring is finished, err is set if the
+	// rollout failed
+	check := func(final bool) (bool, error) {
+		current := p.currentResource(resource.Identifier)
+		if current == nil {
+			// resource was deleted or isn't in the cache yet
+			return final, nil
+		}
+		if strings.Join(current.GetImages(), ",") != images {
+			log.WithFields(log.Fields{
+				"name":      resource.Name,
+				"namespace": resource.Namespace,
+				"kind":      resource.Kind(),
+			}).Debug("provider.kubernetes: resource was updated again, stopping rollout monitor")
+			return true, nil
+		}
+
+		done, failure := current.RolloutStatus()
+		if done {
+			log.WithFields(log.Fields{
+				"name":      resource.Name,
+				"namespace": resource.Namespace,
+				"kind":      resource.Kind(),
+				"update":    fmt.Sprintf("%s->%s", v.plan.CurrentVersion, v.plan.NewVersion),
+			}).Info("provider.kubernetes: rollout complete")
+			return true, nil
+		}
+
+		var reason error
+		switch {
+		case failure != "":
+			reason = fmt.Errorf("rollout failed: %s", failure)
+		case final:
+			reason = fmt.Errorf("rollout didn't complete within %s", deadline)
+		default:
+			return false, nil
+		}
+
+		log.WithFields(log.Fields{
+			"error":     reason,
+			"name":      resource.Name,
+			"namespace": resource.Namespace,
+			"kind":      resource.Kind(),
+			"status":    current.GetStatus(),
+		}).Warn("provider.kubernetes: rollout failed")
+		return true, reason
+	}
+
+	for {
+		select {
+		case <-p.stop:
+			return errStopped
+		case <-v.failed:
+			return errStopped
+		case <-timer.C:
+			_, err := check(true)
+			return err
+		case <-ticker.C:
+			if done, err := check(false); done {
+				return err
+			}
+		}
+	}
+}
+
+// currentResource - latest known version of the resource
+func (p *Provider) currentResource(identifier string) *k8s.GenericResource {
+	for _, gr := range p.cache.Values() {
+		if gr.Identifier == identifier {
+			return gr
+		}
+	}
+	return nil
+}
@@ -0,0 +1,154 @@
+package kubernetes
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	"github.com/keel-hq/keel/internal/k8s"
+	"github.com/keel-hq/keel/types"
+
+	apps_v1 "k8s.io/api/apps/v1"
+	v1 "k8s.io/api/core/v1"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestGetRolloutDeadline(t *testing.T) {
+	os.Setenv(EnvRolloutDeadline, "10m")
+	defer os.Unsetenv(EnvRolloutDeadline)
+
+	tests := []struct {
+		name        string
+		annotations map[string]string
+		want        time.Duration
+		wantErr     bool
+	}{
+		{name: "env default", annotations: map[string]string{}, want: 10 * time.Minute},
+		{name: "annotation", annotations: map[string]string{types.KeelRolloutDeadlineAnnotation: "90s"}, want: 90 * time.Second},
+		{name: "disabled", annotations: map[string]string{types.KeelRolloutDeadlineAnnotation: "0"}, want: 0},
+		{name: "invalid", annotations: map[string]string{types.KeelRolloutDeadlineAnnotation: "soon"}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := getRolloutDeadline(tt.annotations)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("getRolloutDeadline() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("getRolloutDeadline() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func rolloutDeployment(img string, status apps_v1.DeploymentStatus) *k8s.GenericResource {
+	replicas := int32(1)
+	return MustParseGR(&apps_v1.Deployment{
+		meta_v1.TypeMeta{},
+		meta_v1.ObjectMeta{
+			Name:        "dep-1",
+			Namespace:   "xxxx",
+			Generation:  2,
+			Annotations: map[string]string{},
+			Labels:      map[string]string{types.KeelPolicyLabel: "all"},
+		},
+		apps_v1.DeploymentSpec{
+			Replicas: &replicas,
+			Template: v1.PodTemplateSpec{
+				Spec: v1.PodSpec{
+					Containers: []v1.Container{
+						{
+							Image: img,
+						},
+					},
+				},
+			},
+		},
+		status,
+	})
+}
+
+func TestMonitorRollout(t *testing.T) {
+	crashlooping := apps_v1.DeploymentStatus{ObservedGeneration: 2, Replicas: 2, UpdatedReplicas: 1, AvailableReplicas: 1, UnavailableReplicas: 1}
+	complete := apps_v1.DeploymentStatus{ObservedGeneration: 2, Replicas: 1, UpdatedReplicas: 1, AvailableReplicas: 1}
+	progressDeadline := apps_v1.DeploymentStatus{ObservedGeneration: 2, Replicas: 2, UpdatedReplicas: 1, AvailableReplicas: 1,
+		Conditions: []apps_v1.DeploymentCondition{
+			{Type: apps_v1.DeploymentProgressing, Status: v1.ConditionFalse, Reason: "ProgressDeadlineExceeded"},
+		},
+	}
+
+	tests := []struct {
+		name         string
+		cached       *k8s.GenericResource
+		deadline     time.Duration
+		wantRollback bool
+	}{
+		{
+			name:         "deadline exceeded",
+			cached:       rolloutDeployment("gcr.io/v2-namespace/hello-world:1.1.2", crashlooping),
+			deadline:     50 * time.Millisecond,
+			wantRollback: true,
+		},
+		{
+			name:         "progress deadline exceeded",
+			cached:       rolloutDeployment("gcr.io/v2-namespace/hello-world:1.1.2", progressDeadline),
+			deadline:     time.Minute,
+			wantRollback: true,
+		},
+		{
+			name:     "complete",
+			cached:   rolloutDeployment("gcr.io/v2-namespace/hello-world:1.1.2", complete),
+			deadline: time.Minute,
+		},
+		{
+			name:     "updated again",
+			cached:   rolloutDeployment("gcr.io/v2-namespace/hello-world:1.1.3", crashlooping),
+			deadline: 50 * time.Millisecond,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			grc := &k8s.GenericResourceCache{}
+			grc.Add(tt.cached)
+
+			fi := &fakeImplementer{}
+			fs := &fakeSender{}
+			approver, teardown := approver()
+			defer teardown()
+			provider, err := NewProvider(fi, fs, approver, grc)
+			if err != nil {
+				t.Fatalf("failed to get provider: %s", err)
+			}
+
+			plan := &UpdatePlan{
+				Resource:       rolloutDeployment("gcr.io/v2-namespace/hello-world:1.1.2", apps_v1.DeploymentStatus{}),
+				CurrentVersion: "1.1.1",
+				NewVersion:     "1.1.2",
+				PreviousImages: []string{"gcr.io/v2-namespace/hello-world:1.1.1"},
+			}
+
+			v := newUpdateVerifier(provider, plan)
+			v.run(func() error { return v.monitorRollout(tt.deadline, 5*time.Millisecond) })
+			v.wait()
+
+			if !tt.wantRollback {
+				if fi.updated != nil {
+					t.Errorf("resource shouldn't be rolled back")
+				}
+				return
+			}
+
+			if fi.updated == nil {
+				t.Fatalf("expected resource to be rolled back")
+			}
+			if fi.updated.GetImages()[0] != "gcr.io/v2-namespace/hello-world:1.1.1" {
+				t.Errorf("unexpected image after rollback: %s", fi.updated.GetImages()[0])
+			}
+			if fs.sentEvent.Name != "rollback resource" {
+				t.Errorf("expected rollback notification, got: %+v", fs.sentEvent)
+			}
+		})
+	}
+}
@@ -0,0 +1,119 @@
+package kubernetes
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/keel-hq/keel/pkg/trivy"
+	"github.com/keel-hq/keel/types"
+
+	v1 "k8s.io/api/core/v1"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// EventReasonVulnerable - update parked as the new image has vulnerabilities
+const EventReasonVulnerable = "KeelUpdateVulnerable"
+
+// maxReportedVulnerabilities - vulnerabilities listed in notifications, others are only counted
+const maxReportedVulnerabilities = 10
+
+// VulnerabilityScanner - scans images before updates are applied, see pkg/trivy
+type VulnerabilityScanner interface {
+	Scan(ctx context.Context, image string) (*trivy.Report, error)
+}
+
+// SetVulnerabilityScanner - updates to images with vulnerabilities at or above
+// scanner severity are parked until a newer version is available
+func (p *Provider) SetVulnerabilityScanner(scanner VulnerabilityScanner) {
+	p.scanner = scanner
+}
+
+// scanImages - returns an error if update can't be applied, vulnerable versions
+// are reported once and then skipped
+func (p *Provider) scanImages(ctx context.Context, plan *UpdatePlan, policyName string) error {
+	if p.scanner == nil {
+		return nil
+	}
+
+	resource := plan.Resource
+
+	p.parkedMu.Lock()
+	parked := p.parked[resource.Identifier] == plan.NewVersion
+	p.parkedMu.Unlock()
+	if parked {
+		log.WithFields(log.Fields{
+			"name":      resource.Name,
+			"kind":      resource.Kind(),
+			"namespace": resource.Namespace,
+			"version":   plan.NewVersion,
+		}).Debug("provider.kubernetes: version has vulnerabilities, update parked")
+		return fmt.Errorf("version %s has vulnerabilities", plan.NewVersion)
+	}
+
+	for _, image := range updatedImages(plan) {
+		report, err := p.scanner.Scan(ctx, image)
+		if err != nil {
+			log.WithFields(log.Fields{
+				"error":     err,
+				"name":      resource.Name,
+				"kind":      resource.Kind(),
+				"namespace": resource.Namespace,
+				"image":     image,
+			}).Error("provider.kubernetes: vulnerability scan failed")
+			p.recordEvent(resource, v1.EventTypeWarning, EventReasonUpdateFailed, fmt.Sprintf("Vulnerability scan of %s failed: %s", image, err))
+			p.updateFailed(plan, err)
+			return err
+		}
+		if report.Passed() {
+			continue
+		}
+
+		p.parkedMu.Lock()
+		p.parked[resource.Identifier] = plan.NewVersion
+		p.parkedMu.Unlock()
+
+		log.WithFields(log.Fields{
+			"name":            resource.Name,
+			"kind":            resource.Kind(),
+			"namespace":       resource.Namespace,
+			"image":           image,
+			"vulnerabilities": report.Summary(),
+		}).Warn("provider.kubernetes: image has vulnerabilities, update parked")
+
+		settings := resource.GetKeelAnnotations()
+		p.sender.Send(types.EventNotification{
+			ResourceKind: resource.Kind(),
+			Identifier:   resource.Identifier,
+			Name:         "vulnerabilities found",
+			Message: fmt.Sprintf("Parked %s %s/%s update %s->%s, image %s has vulnerabilities (%s):\n%s",
+				resource.Kind(), resource.Namespace, resource.Name, plan.CurrentVersion, plan.NewVersion, image, report.Summary(), report.Details(maxReportedVulnerabilities)),
+			CreatedAt: time.Now(),
+			Type:      types.NotificationSecurityEvent,
+			Level:     types.LevelWarn,
+			Channels:  types.ParseEventNotificationChannelsFromLabelsOrAnnotations(resource.GetLabels(), settings),
+			MinLevel:  types.ParseEventNotificationLevel(resource.GetLabels(), settings),
+			Metadata: map[string]string{
+				"provider":        p.GetName(),
+				"namespace":       resource.GetNamespace(),
+				"name":            resource.GetName(),
+				"previous":        plan.CurrentVersion,
+				"new":             plan.NewVersion,
+				"policy":          policyName,
+				"images":          strings.Join(resource.GetImages(), ", "),
+				"image":           image,
+				"vulnerabilities": report.Summary(),
+				"trigger":         plan.Trigger,
+			},
+		})
+		p.recordEvent(resource, v1.EventTypeWarning, EventReasonVulnerable, fmt.Sprintf("Update %s->%s parked, image %s has vulnerabilities: %s", plan.CurrentVersion, plan.NewVersion, image, report.Summary()))
+		return fmt.Errorf("image %s has vulnerabilities: %s", image, report.Summary())
+	}
+
+	p.parkedMu.Lock()
+	delete(p.parked, resource.Identifier)
+	p.parkedMu.Unlock()
+	return nil
+}
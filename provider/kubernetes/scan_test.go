@@ -0,0 +1,97 @@
+package kubernetes
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/keel-hq/keel/internal/k8s"
+	"github.com/keel-hq/keel/pkg/trivy"
+	"github.com/keel-hq/keel/types"
+
+	apps_v1 "k8s.io/api/apps/v1"
+	v1 "k8s.io/api/core/v1"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+type fakeScanner struct {
+	scans      int
+	vulnerable map[string]bool
+}
+
+func (s *fakeScanner) Scan(ctx context.Context, image string) (*trivy.Report, error) {
+	s.scans++
+	report := &trivy.Report{Image: image, Severity: "HIGH"}
+	if s.vulnerable[image] {
+		report.Vulnerabilities = []trivy.Vulnerability{
+			{VulnerabilityID: "CVE-2021-0001", PkgName: "openssl", InstalledVersion: "1.1.1g-r0", Severity: "CRITICAL"},
+		}
+	}
+	return report, nil
+}
+
+func TestVulnerabilityScan(t *testing.T) {
+	fp := &fakeImplementer{}
+	grc := &k8s.GenericResourceCache{}
+	grc.Add(MustParseGR(&apps_v1.Deployment{
+		meta_v1.TypeMeta{},
+		meta_v1.ObjectMeta{
+			Name:      "deployment-1",
+			Namespace: "xxxx",
+			Labels:    map[string]string{types.KeelPolicyLabel: "all"},
+		},
+		apps_v1.DeploymentSpec{
+			Template: v1.PodTemplateSpec{
+				Spec: v1.PodSpec{
+					Containers: []v1.Container{
+						{
+							Image: "gcr.io/v2-namespace/hello-world:10.0.0",
+						},
+					},
+				},
+			},
+		},
+		apps_v1.DeploymentStatus{},
+	}))
+
+	fs := &fakeSender{}
+	approver, teardown := approver()
+	defer teardown()
+	provider, err := NewProvider(fp, fs, approver, grc)
+	if err != nil {
+		t.Fatalf("failed to get provider: %s", err)
+	}
+	scanner := &fakeScanner{vulnerable: map[string]bool{
+		"gcr.io/v2-namespace/hello-world:11.0.0": true,
+	}}
+	provider.SetVulnerabilityScanner(scanner)
+
+	process := func(tag string) {
+		_, err := provider.processEvent(&types.Event{Repository: types.Repository{
+			Name: "gcr.io/v2-namespace/hello-world",
+			Tag:  tag,
+		}})
+		if err != nil {
+			t.Fatalf("got error while processing event: %s", err)
+		}
+	}
+
+	process("11.0.0")
+	if fp.updateAttempts != 0 {
+		t.Errorf("expected vulnerable image update to be parked, got %d updates", fp.updateAttempts)
+	}
+	if fs.sentEvent.Type != types.NotificationSecurityEvent || !strings.Contains(fs.sentEvent.Message, "CVE-2021-0001") {
+		t.Errorf("expected vulnerability report, got: %s", fs.sentEvent.Message)
+	}
+
+	// parked version isn't scanned again
+	process("11.0.0")
+	if scanner.scans != 1 {
+		t.Errorf("expected parked version to be skipped, got %d scans", scanner.scans)
+	}
+
+	process("12.0.0")
+	if fp.updateAttempts != 1 {
+		t.Errorf("expected newer image to be applied, got %d updates", fp.updateAttempts)
+	}
+}
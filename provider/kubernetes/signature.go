@@ -0,0 +1,105 @@
+package kubernetes
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/keel-hq/keel/types"
+
+	v1 "k8s.io/api/core/v1"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// EventReasonSignatureInvalid - update rejected as the new image isn't signed
+const EventReasonSignatureInvalid = "KeelSignatureInvalid"
+
+// SignatureVerifier - verifies image signatures before updates are applied,
+// see pkg/cosign and pkg/notary
+type SignatureVerifier interface {
+	Verify(ctx context.Context, image string) error
+}
+
+// AddSignatureVerifier - updates to images without a valid signature are rejected,
+// images have to pass all verifiers
+func (p *Provider) AddSignatureVerifier(verifier SignatureVerifier) {
+	p.verifiers = append(p.verifiers, verifier)
+}
+
+func (p *Provider) verifySignature(ctx context.Context, image string) error {
+	for _, verifier := range p.verifiers {
+		if err := verifier.Verify(ctx, image); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// updatedImages - images changed by the plan, including init containers
+func updatedImages(plan *UpdatePlan) []string {
+	var images []string
+	add := func(previous, current []string) {
+		for idx, image := range current {
+			if idx >= len(previous) || previous[idx] != image {
+				images = append(images, image)
+			}
+		}
+	}
+	add(plan.PreviousImages, plan.Resource.GetImages())
+	add(plan.PreviousInitImages, plan.Resource.GetInitImages())
+	return images
+}
+
+// verifySignatures - rejected updates are reported as security events and
+// backed off like failed updates
+func (p *Provider) verifySignatures(ctx context.Context, plan *UpdatePlan, policyName string) error {
+	if len(p.verifiers) == 0 {
+		return nil
+	}
+
+	resource := plan.Resource
+	for _, image := range updatedImages(plan) {
+		err := p.verifySignature(ctx, image)
+		if err == nil {
+			continue
+		}
+
+		log.WithFields(log.Fields{
+			"error":     err,
+			"name":      resource.Name,
+			"kind":      resource.Kind(),
+			"namespace": resource.Namespace,
+			"image":     image,
+		}).Error("provider.kubernetes: image signature verification failed, update rejected")
+
+		settings := resource.GetKeelAnnotations()
+		p.sender.Send(types.EventNotification{
+			ResourceKind: resource.Kind(),
+			Identifier:   resource.Identifier,
+			Name:         "signature verification failed",
+			Message:      fmt.Sprintf("Rejected %s %s/%s update %s->%s, image %s signature verification failed: %s", resource.Kind(), resource.Namespace, resource.Name, plan.CurrentVersion, plan.NewVersion, image, err),
+			CreatedAt:    time.Now(),
+			Type:         types.NotificationSecurityEvent,
+			Level:        types.LevelError,
+			Channels:     types.ParseEventNotificationChannelsFromLabelsOrAnnotations(resource.GetLabels(), settings),
+			MinLevel:     types.ParseEventNotificationLevel(resource.GetLabels(), settings),
+			Metadata: map[string]string{
+				"provider":  p.GetName(),
+				"namespace": resource.GetNamespace(),
+				"name":      resource.GetName(),
+				"previous":  plan.CurrentVersion,
+				"new":       plan.NewVersion,
+				"policy":    policyName,
+				"images":    strings.Join(resource.GetImages(), ", "),
+				"image":     image,
+				"trigger":   plan.Trigger,
+			},
+		})
+		p.recordEvent(resource, v1.EventTypeWarning, EventReasonSignatureInvalid, fmt.Sprintf("Update %s->%s rejected, image %s signature verification failed: %s", plan.CurrentVersion, plan.NewVersion, image, err))
+		p.updateFailed(plan, err)
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,84 @@
+package kubernetes
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/keel-hq/keel/internal/k8s"
+	"github.com/keel-hq/keel/types"
+
+	apps_v1 "k8s.io/api/apps/v1"
+	v1 "k8s.io/api/core/v1"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+type fakeVerifier struct {
+	signed map[string]bool
+}
+
+func (v *fakeVerifier) Verify(ctx context.Context, image string) error {
+	if !v.signed[image] {
+		return fmt.Errorf("no matching signatures")
+	}
+	return nil
+}
+
+func TestSignatureVerification(t *testing.T) {
+	fp := &fakeImplementer{}
+	grc := &k8s.GenericResourceCache{}
+	grc.Add(MustParseGR(&apps_v1.Deployment{
+		meta_v1.TypeMeta{},
+		meta_v1.ObjectMeta{
+			Name:      "deployment-1",
+			Namespace: "xxxx",
+			Labels:    map[string]string{types.KeelPolicyLabel: "all"},
+		},
+		apps_v1.DeploymentSpec{
+			Template: v1.PodTemplateSpec{
+				Spec: v1.PodSpec{
+					Containers: []v1.Container{
+						{
+							Image: "gcr.io/v2-namespace/hello-world:10.0.0",
+						},
+					},
+				},
+			},
+		},
+		apps_v1.DeploymentStatus{},
+	}))
+
+	fs := &fakeSender{}
+	approver, teardown := approver()
+	defer teardown()
+	provider, err := NewProvider(fp, fs, approver, grc)
+	if err != nil {
+		t.Fatalf("failed to get provider: %s", err)
+	}
+	provider.AddSignatureVerifier(&fakeVerifier{signed: map[string]bool{
+		"gcr.io/v2-namespace/hello-world:12.0.0": true,
+	}})
+
+	process := func(tag string) {
+		_, err := provider.processEvent(&types.Event{Repository: types.Repository{
+			Name: "gcr.io/v2-namespace/hello-world",
+			Tag:  tag,
+		}})
+		if err != nil {
+			t.Fatalf("got error while processing event: %s", err)
+		}
+	}
+
+	process("11.0.0")
+	if fp.updateAttempts != 0 {
+		t.Errorf("expected unsigned image update to be rejected, got %d updates", fp.updateAttempts)
+	}
+	if fs.sentEvent.Type != types.NotificationSecurityEvent {
+		t.Errorf("expected security notification, got: %s", fs.sentEvent.Type)
+	}
+
+	process("12.0.0")
+	if fp.updateAttempts != 1 {
+		t.Errorf("expected signed image update to be applied, got %d updates", fp.updateAttempts)
+	}
+}
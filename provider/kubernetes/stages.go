@@ -0,0 +1,225 @@
+package kubernetes
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/keel-hq/keel/internal/k8s"
+	"github.com/keel-hq/keel/types"
+	"github.com/keel-hq/keel/util/image"
+	"github.com/keel-hq/keel/util/timeutil"
+
+	v1 "k8s.io/api/core/v1"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// stages of keel.sh/stage resources, new versions reach production resources
+// only after they are healthy on staging resources using the same image
+const (
+	StageStaging    = "staging"
+	StageProduction = "production"
+)
+
+// EnvStageSoak - how long staging rollouts have to stay healthy before new
+// versions are promoted to production resources (ie: 1h)
+const EnvStageSoak = "STAGE_SOAK"
+
+// DefaultStageSoak - default soak time of staging rollouts
+const DefaultStageSoak = 30 * time.Minute
+
+// stagedUpdate - production update waiting for staging, pass is the
+// promotion check that held it last
+type stagedUpdate struct {
+	newVersion string
+	event      types.Event
+	pass       int
+}
+
+func getStage(resource *k8s.GenericResource) string {
+	stage, ok := resource.GetLabels()[types.KeelStageLabel]
+	if !ok {
+		stage = resource.GetKeelAnnotations()[types.KeelStageLabel]
+	}
+	return strings.ToLower(strings.TrimSpace(stage))
+}
+
+// getStageSoak - soak time from production resource annotation or environment
+func getStageSoak(annotations map[string]string) (time.Duration, error) {
+	val, ok := annotations[types.KeelStageSoakAnnotation]
+	if !ok {
+		val = os.Getenv(EnvStageSoak)
+	}
+	val = strings.TrimSpace(val)
+	if val == "" {
+		return DefaultStageSoak, nil
+	}
+
+	soak, err := time.ParseDuration(val)
+	if err != nil || soak < 0 {
+		return 0, fmt.Errorf("invalid stage soak time '%s'", val)
+	}
+	return soak, nil
+}
+
+// stagingResources - staging resources using the repository
+func (p *Provider) stagingResources(repository string) []*k8s.GenericResource {
+	var resources []*k8s.GenericResource
+	for _, gr := range p.cache.Values() {
+		if getStage(gr) != StageStaging {
+			continue
+		}
+		for _, img := range gr.GetImages() {
+			ref, err := image.Parse(img)
+			if err == nil && ref.Repository() == repository {
+				resources = append(resources, gr)
+				break
+			}
+		}
+	}
+	sort.Slice(resources, func(i, j int) bool { return resources[i].Identifier < resources[j].Identifier })
+	return resources
+}
+
+// stagingSoaked - whether all staging resources run the tag and their rollouts
+// have been healthy for the soak time, returns resources still waited for
+func (p *Provider) stagingSoaked(repository, tag string, soak time.Duration, now time.Time) []string {
+	var waiting []string
+
+	for _, gr := range p.stagingResources(repository) {
+		key := gr.Identifier + "@" + repository + ":" + tag
+
+		running := false
+		for _, img := range gr.GetImages() {
+			ref, err := image.Parse(img)
+			if err == nil && ref.Repository() == repository && ref.Tag() == tag {
+				running = true
+				break
+			}
+		}
+		done, _ := gr.RolloutStatus()
+		if !running || !done {
+			delete(p.stageHealthy, key)
+			waiting = append(waiting, gr.Identifier)
+			continue
+		}
+
+		since, ok := p.stageHealthy[key]
+		if !ok {
+			since = now
+			p.stageHealthy[key] = since
+		}
+		if now.Sub(since) < soak {
+			waiting = append(waiting, gr.Identifier)
+		}
+	}
+
+	return waiting
+}
+
+// holdStaged - holds updates of production resources until staging resources
+// using the same image are healthy on the new version for the soak time,
+// returns plans that can be applied now
+func (p *Provider) holdStaged(event *types.Event, plans []*UpdatePlan) []*UpdatePlan {
+	var ready []*UpdatePlan
+	now := timeutil.Now()
+
+	ref, err := image.Parse(event.Repository.Name)
+	if err != nil {
+		return plans
+	}
+
+	p.stagedMu.Lock()
+	defer p.stagedMu.Unlock()
+
+	for _, plan := range plans {
+		resource := plan.Resource
+		if getStage(resource) != StageProduction {
+			ready = append(ready, plan)
+			continue
+		}
+
+		soak, err := getStageSoak(resource.GetKeelAnnotations())
+		if err != nil {
+			log.WithFields(log.Fields{
+				"error":     err,
+				"name":      resource.Name,
+				"kind":      resource.Kind(),
+				"namespace": resource.Namespace,
+			}).Error("provider.kubernetes: invalid stage soak time, using default")
+			soak = DefaultStageSoak
+		}
+
+		waiting := p.stagingSoaked(ref.Repository(), event.Repository.Tag, soak, now)
+		if len(waiting) == 0 {
+			delete(p.staged, resource.Identifier)
+			ready = append(ready, plan)
+			continue
+		}
+
+		reason := fmt.Sprintf("staging %s healthy for %s", strings.Join(waiting, ", "), soak)
+
+		if existing, ok := p.staged[resource.Identifier]; !ok || existing.newVersion != plan.NewVersion {
+			p.recordEvent(resource, v1.EventTypeNormal, EventReasonUpdateSkipped, fmt.Sprintf("Update %s->%s held until %s", plan.CurrentVersion, plan.NewVersion, reason))
+			log.WithFields(log.Fields{
+				"name":      resource.Name,
+				"kind":      resource.Kind(),
+				"namespace": resource.Namespace,
+				"update":    fmt.Sprintf("%s->%s", plan.CurrentVersion, plan.NewVersion),
+				"waiting":   strings.Join(waiting, ", "),
+			}).Info("provider.kubernetes: production update held until staging soaks")
+		}
+
+		p.staged[resource.Identifier] = &stagedUpdate{
+			newVersion: plan.NewVersion,
+			event:      *event,
+			pass:       p.stagePass,
+		}
+	}
+
+	return ready
+}
+
+// processStaged - checks held production updates again, updates that are no
+// longer planned are dropped
+func (p *Provider) processStaged() {
+	var events []types.Event
+	seen := make(map[string]bool)
+
+	p.stagedMu.Lock()
+	p.stagePass++
+	pass := p.stagePass
+	for _, s := range p.staged {
+		key := s.event.Repository.Host + "/" + s.event.Repository.Name + ":" + s.event.Repository.Tag + "@" + s.event.Repository.Digest
+		if !seen[key] {
+			seen[key] = true
+			events = append(events, s.event)
+		}
+	}
+	p.stagedMu.Unlock()
+
+	for i := range events {
+		_, err := p.processEvent(&events[i])
+		if err != nil {
+			log.WithFields(log.Fields{
+				"error": err,
+				"image": events[i].Repository.Name,
+				"tag":   events[i].Repository.Tag,
+			}).Error("provider.kubernetes: failed to process staged event")
+		}
+	}
+
+	p.stagedMu.Lock()
+	for identifier, s := range p.staged {
+		if s.pass < pass {
+			delete(p.staged, identifier)
+		}
+	}
+	if len(p.staged) == 0 {
+		p.stageHealthy = make(map[string]time.Time)
+	}
+	p.stagedMu.Unlock()
+}
@@ -0,0 +1,143 @@
+package kubernetes
+
+import (
+	"testing"
+	"time"
+
+	"github.com/keel-hq/keel/internal/k8s"
+	"github.com/keel-hq/keel/types"
+	"github.com/keel-hq/keel/util/timeutil"
+
+	apps_v1 "k8s.io/api/apps/v1"
+	v1 "k8s.io/api/core/v1"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func stageDeployment(namespace, stage, image string, annotations map[string]string, status apps_v1.DeploymentStatus) *k8s.GenericResource {
+	return MustParseGR(&apps_v1.Deployment{
+		meta_v1.TypeMeta{},
+		meta_v1.ObjectMeta{
+			Name:        "app",
+			Namespace:   namespace,
+			Labels:      map[string]string{types.KeelPolicyLabel: "all", types.KeelStageLabel: stage},
+			Annotations: annotations,
+		},
+		apps_v1.DeploymentSpec{
+			Template: v1.PodTemplateSpec{
+				Spec: v1.PodSpec{
+					Containers: []v1.Container{
+						{
+							Image: image,
+						},
+					},
+				},
+			},
+		},
+		status,
+	})
+}
+
+func TestStagedRollout(t *testing.T) {
+	now := time.Date(2021, 3, 1, 10, 0, 0, 0, time.UTC)
+	timeutil.Now = func() time.Time { return now }
+	defer func() { timeutil.Now = time.Now }()
+
+	fp := &fakeImplementer{}
+	grc := &k8s.GenericResourceCache{}
+	grc.Add(
+		stageDeployment("staging", StageStaging, "gcr.io/v2-namespace/hello-world:1.1.1", nil, apps_v1.DeploymentStatus{}),
+		stageDeployment("production", StageProduction, "gcr.io/v2-namespace/hello-world:1.1.1", map[string]string{types.KeelStageSoakAnnotation: "1h"}, apps_v1.DeploymentStatus{}),
+	)
+
+	approver, teardown := approver()
+	defer teardown()
+	provider, err := NewProvider(fp, &fakeSender{}, approver, grc)
+	if err != nil {
+		t.Fatalf("failed to get provider: %s", err)
+	}
+
+	event := &types.Event{Repository: types.Repository{
+		Name: "gcr.io/v2-namespace/hello-world",
+		Tag:  "1.1.2",
+	}}
+	_, err = provider.processEvent(event)
+	if err != nil {
+		t.Fatalf("got error while processing event: %s", err)
+	}
+
+	if fp.updated == nil || fp.updated.Namespace != "staging" {
+		t.Fatalf("expected staging resource to be updated, got: %v", fp.updated)
+	}
+	fp.updated = nil
+
+	// staging rollout finished
+	grc.Add(stageDeployment("staging", StageStaging, "gcr.io/v2-namespace/hello-world:1.1.2", nil, apps_v1.DeploymentStatus{
+		Replicas:          1,
+		UpdatedReplicas:   1,
+		AvailableReplicas: 1,
+	}))
+
+	provider.processStaged()
+	if fp.updated != nil {
+		t.Fatalf("production resource shouldn't be updated before staging soaks")
+	}
+
+	now = now.Add(30 * time.Minute)
+	provider.processStaged()
+	if fp.updated != nil {
+		t.Fatalf("production resource shouldn't be updated before staging soaks")
+	}
+
+	now = now.Add(31 * time.Minute)
+	provider.processStaged()
+	if fp.updated == nil || fp.updated.Namespace != "production" {
+		t.Fatalf("expected production resource to be updated once staging soaked, got: %v", fp.updated)
+	}
+	if fp.updated.Containers()[0].Image != "gcr.io/v2-namespace/hello-world:1.1.2" {
+		t.Errorf("unexpected image: %s", fp.updated.Containers()[0].Image)
+	}
+	if len(provider.staged) != 0 {
+		t.Errorf("expected no staged updates, got: %d", len(provider.staged))
+	}
+}
+
+func TestStagedRolloutStagingUnhealthy(t *testing.T) {
+	now := time.Date(2021, 3, 1, 10, 0, 0, 0, time.UTC)
+	timeutil.Now = func() time.Time { return now }
+	defer func() { timeutil.Now = time.Now }()
+
+	fp := &fakeImplementer{}
+	grc := &k8s.GenericResourceCache{}
+	grc.Add(
+		// rolled out, but replicas are not available
+		stageDeployment("staging", StageStaging, "gcr.io/v2-namespace/hello-world:1.1.2", nil, apps_v1.DeploymentStatus{
+			Replicas:        1,
+			UpdatedReplicas: 1,
+		}),
+		stageDeployment("production", StageProduction, "gcr.io/v2-namespace/hello-world:1.1.1", map[string]string{types.KeelStageSoakAnnotation: "10m"}, apps_v1.DeploymentStatus{}),
+	)
+
+	approver, teardown := approver()
+	defer teardown()
+	provider, err := NewProvider(fp, &fakeSender{}, approver, grc)
+	if err != nil {
+		t.Fatalf("failed to get provider: %s", err)
+	}
+
+	_, err = provider.processEvent(&types.Event{Repository: types.Repository{
+		Name: "gcr.io/v2-namespace/hello-world",
+		Tag:  "1.1.2",
+	}})
+	if err != nil {
+		t.Fatalf("got error while processing event: %s", err)
+	}
+
+	now = now.Add(time.Hour)
+	provider.processStaged()
+	if fp.updated != nil {
+		t.Fatalf("production resource shouldn't be updated while staging is unhealthy")
+	}
+	if len(provider.staged) != 1 {
+		t.Errorf("expected 1 staged update, got: %d", len(provider.staged))
+	}
+}
@@ -0,0 +1,50 @@
+package kubernetes
+
+import (
+	"testing"
+	"time"
+
+	"github.com/keel-hq/keel/internal/k8s"
+)
+
+func TestStopWaitsForInFlightUpdate(t *testing.T) {
+	approver, teardown := approver()
+	defer teardown()
+	provider, err := NewProvider(&fakeImplementer{}, &fakeSender{}, approver, &k8s.GenericResourceCache{})
+	if err != nil {
+		t.Fatalf("failed to get provider: %s", err)
+	}
+
+	started := make(chan struct{})
+	release := make(chan struct{})
+	go provider.inFlight(func() {
+		close(started)
+		<-release
+	})
+	<-started
+
+	stopped := make(chan struct{})
+	go func() {
+		provider.Stop()
+		close(stopped)
+	}()
+
+	select {
+	case <-stopped:
+		t.Fatalf("expected stop to wait for the update in progress")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	close(release)
+	select {
+	case <-stopped:
+	case <-time.After(time.Second):
+		t.Fatalf("expected stop to return once the update finished")
+	}
+
+	ran := false
+	provider.inFlight(func() { ran = true })
+	if ran {
+		t.Errorf("expected no updates to run after stop")
+	}
+}
@@ -0,0 +1,102 @@
+package kubernetes
+
+import (
+	"context"
+	"testing"
+
+	"github.com/keel-hq/keel/internal/k8s"
+	"github.com/keel-hq/keel/pkg/tracing"
+	"github.com/keel-hq/keel/types"
+
+	"go.opentelemetry.io/otel"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+
+	apps_v1 "k8s.io/api/apps/v1"
+	v1 "k8s.io/api/core/v1"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestProcessEventTraced(t *testing.T) {
+	exporter := tracetest.NewInMemoryExporter()
+	previous := otel.GetTracerProvider()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter)))
+	defer otel.SetTracerProvider(previous)
+
+	fp := &fakeImplementer{}
+	grc := &k8s.GenericResourceCache{}
+	grc.Add(MustParseGR(&apps_v1.Deployment{
+		meta_v1.TypeMeta{},
+		meta_v1.ObjectMeta{
+			Name:      "deployment-1",
+			Namespace: "xxxx",
+			Labels:    map[string]string{types.KeelPolicyLabel: "all"},
+		},
+		apps_v1.DeploymentSpec{
+			Template: v1.PodTemplateSpec{
+				Spec: v1.PodSpec{
+					Containers: []v1.Container{
+						{
+							Image: "gcr.io/v2-namespace/hello-world:10.0.0",
+						},
+					},
+				},
+			},
+		},
+		apps_v1.DeploymentStatus{},
+	}))
+
+	approver, teardown := approver()
+	defer teardown()
+	provider, err := NewProvider(fp, &fakeSender{}, approver, grc)
+	if err != nil {
+		t.Fatalf("failed to get provider: %s", err)
+	}
+
+	// trigger span, ie: webhook request
+	ctx, trigger := tracing.Start(context.Background(), "http.webhook")
+	event := &types.Event{
+		Repository: types.Repository{
+			Name: "gcr.io/v2-namespace/hello-world",
+			Tag:  "11.0.0",
+		},
+		TriggerName:  "native",
+		TraceContext: tracing.Inject(ctx),
+	}
+	trigger.End()
+
+	_, err = provider.processEvent(event)
+	if err != nil {
+		t.Fatalf("got error while processing event: %s", err)
+	}
+	if fp.updated == nil {
+		t.Fatalf("expected deployment to be updated")
+	}
+
+	spans := map[string]*sdktrace.SpanSnapshot{}
+	for _, s := range exporter.GetSpans() {
+		spans[s.Name] = s
+	}
+
+	process, ok := spans["provider.kubernetes.process_event"]
+	if !ok {
+		t.Fatalf("expected process event span, got: %v", spans)
+	}
+	if process.SpanContext.TraceID() != trigger.SpanContext().TraceID() {
+		t.Errorf("expected process event span in trigger's trace")
+	}
+	if process.Parent.SpanID() != trigger.SpanContext().SpanID() {
+		t.Errorf("expected process event span to be a child of the trigger span")
+	}
+
+	for _, name := range []string{"provider.kubernetes.evaluate_policies", "provider.kubernetes.update"} {
+		span, ok := spans[name]
+		if !ok {
+			t.Errorf("expected %s span", name)
+			continue
+		}
+		if span.Parent.SpanID() != process.SpanContext.SpanID() {
+			t.Errorf("expected %s span to be a child of the process event span", name)
+		}
+	}
+}
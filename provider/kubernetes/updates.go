@@ -2,6 +2,8 @@ package kubernetes
 
 import (
 	"fmt"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/keel-hq/keel/internal/k8s"
@@ -9,9 +11,131 @@ import (
 	"github.com/keel-hq/keel/types"
 	"github.com/keel-hq/keel/util/image"
 
+	v1 "k8s.io/api/core/v1"
+
 	log "github.com/sirupsen/logrus"
 )
 
+// available modes for images that are pinned by both tag and digest,
+// set via keel.sh/digestPin label or annotation
+const (
+	// DigestPinRespect - pinned images are treated as immutable and never updated
+	DigestPinRespect = "respect"
+	// DigestPinUpdate - tag is updated and the digest of the new tag is pinned
+	DigestPinUpdate = "update"
+	// DigestPinDrop - tag is updated and the digest is dropped
+	DigestPinDrop = "drop"
+	// DigestPinAlways - updated images are pinned by digest, including images
+	// referenced by tag only, set via keel.sh/digest=true
+	DigestPinAlways = "always"
+)
+
+// available update modes, set via keel.sh/updateMode label or annotation
+const (
+	// UpdateModeImage - container image is updated, default
+	UpdateModeImage = "image"
+	// UpdateModeRestart - when only the digest of the tag changed, image is
+	// kept and pods are restarted through keel.sh/update-ts
+	UpdateModeRestart = "restart"
+)
+
+// getUpdateMode - returns update mode, annotations take precedence over labels
+func getUpdateMode(labels, annotations map[string]string) string {
+	mode, ok := annotations[types.KeelUpdateModeAnnotation]
+	if !ok {
+		mode = labels[types.KeelUpdateModeAnnotation]
+	}
+
+	switch strings.ToLower(strings.TrimSpace(mode)) {
+	case "", UpdateModeImage:
+		return UpdateModeImage
+	case UpdateModeRestart:
+		return UpdateModeRestart
+	default:
+		log.WithFields(log.Fields{
+			"mode": mode,
+		}).Warn("provider.kubernetes: unknown update mode, defaulting to image")
+		return UpdateModeImage
+	}
+}
+
+// getDigestPin - returns digest pin mode, annotations take precedence over labels
+func getDigestPin(labels, annotations map[string]string) string {
+	if digestEnabled(labels, annotations) {
+		return DigestPinAlways
+	}
+
+	mode, ok := annotations[types.KeelDigestPinAnnotation]
+	if !ok {
+		mode = labels[types.KeelDigestPinAnnotation]
+	}
+
+	switch strings.ToLower(strings.TrimSpace(mode)) {
+	case "":
+		return DigestPinRespect
+	case DigestPinRespect, DigestPinUpdate, DigestPinDrop:
+		return strings.ToLower(strings.TrimSpace(mode))
+	default:
+		log.WithFields(log.Fields{
+			"mode": mode,
+		}).Warn("provider.kubernetes: unknown digest pin mode, defaulting to respect")
+		return DigestPinRespect
+	}
+}
+
+// digestEnabled - whether updated images are always pinned by digest, set via
+// keel.sh/digest label or annotation
+func digestEnabled(labels, annotations map[string]string) bool {
+	val, ok := annotations[types.KeelDigestAnnotation]
+	if !ok {
+		val, ok = labels[types.KeelDigestAnnotation]
+	}
+	if !ok {
+		return false
+	}
+	enabled, err := strconv.ParseBool(strings.TrimSpace(val))
+	if err != nil {
+		log.WithFields(log.Fields{
+			"error": err,
+			"value": val,
+		}).Warnf("provider.kubernetes: invalid %s value, ignoring it", types.KeelDigestAnnotation)
+		return false
+	}
+	return enabled
+}
+
+// initContainersEnabled - whether init containers are tracked and updated
+// together with regular containers, set via keel.sh/initContainers
+func initContainersEnabled(resource *k8s.GenericResource) bool {
+	val, ok := resource.GetKeelAnnotations()[types.KeelInitContainersAnnotation]
+	if !ok {
+		val, ok = resource.GetLabels()[types.KeelInitContainersAnnotation]
+	}
+	if !ok {
+		return false
+	}
+	enabled, err := strconv.ParseBool(strings.TrimSpace(val))
+	if err != nil {
+		log.WithFields(log.Fields{
+			"error":     err,
+			"name":      resource.Name,
+			"namespace": resource.Namespace,
+		}).Errorf("provider.kubernetes: invalid %s value, ignoring it", types.KeelInitContainersAnnotation)
+		return false
+	}
+	return enabled
+}
+
+// trackedContainers - containers whose images are tracked, init containers
+// are included when enabled on the resource
+func trackedContainers(resource *k8s.GenericResource) []v1.Container {
+	containers := append([]v1.Container{}, resource.Containers()...)
+	if initContainersEnabled(resource) {
+		containers = append(containers, resource.InitContainers()...)
+	}
+	return containers
+}
+
 func checkForUpdate(plc policy.Policy, repo *types.Repository, resource *k8s.GenericResource) (updatePlan *UpdatePlan, shouldUpdateDeployment bool, err error) {
 	updatePlan = &UpdatePlan{}
 
@@ -27,68 +151,160 @@ func checkForUpdate(plc policy.Policy, repo *types.Repository, resource *k8s.Gen
 		"policy":    plc.Name(),
 	}).Debug("provider.kubernetes.checkVersionedDeployment: keel policy found, checking resource...")
 	shouldUpdateDeployment = false
-	for idx, c := range resource.Containers() {
-		containerImageRef, err := image.Parse(c.Image)
-		if err != nil {
-			log.WithFields(log.Fields{
-				"error":      err,
-				"image_name": c.Image,
-			}).Error("provider.kubernetes: failed to parse image name")
-			continue
+	annotations := resource.GetKeelAnnotations()
+	digestPin := getDigestPin(resource.GetLabels(), annotations)
+	restartMode := getUpdateMode(resource.GetLabels(), annotations) == UpdateModeRestart
+
+	apply := func(currentTag string, restart bool) {
+		// updating spec template annotations
+		setUpdateTime(resource)
+		if restart {
+			setRestartTime(resource)
 		}
 
-		log.WithFields(log.Fields{
-			"name":              resource.Name,
-			"namespace":         resource.Namespace,
-			"kind":              resource.Kind(),
-			"parsed_image_name": containerImageRef.Remote(),
-			"target_image_name": repo.Name,
-			"target_tag":        repo.Tag,
-			"policy":            plc.Name(),
-			"image":             c.Image,
-		}).Debug("provider.kubernetes: checking image")
+		shouldUpdateDeployment = true
 
-		if containerImageRef.Repository() != eventRepoRef.Repository() {
-			log.WithFields(log.Fields{
-				"parsed_image_name": containerImageRef.Remote(),
-				"target_image_name": repo.Name,
-			}).Debug("provider.kubernetes: images do not match, ignoring")
-			continue
-		}
+		updatePlan.CurrentVersion = currentTag
+		updatePlan.NewVersion = repo.Tag
+		updatePlan.Resource = resource
+	}
 
-		shouldUpdateContainer, err := plc.ShouldUpdate(containerImageRef.Tag(), eventRepoRef.Tag())
-		if err != nil {
-			log.WithFields(log.Fields{
-				"error":             err,
-				"parsed_image_name": containerImageRef.Remote(),
-				"target_image_name": repo.Name,
-				"policy":            plc.Name(),
-			}).Error("provider.kubernetes: failed to check whether container should be updated")
+	for idx, c := range resource.Containers() {
+		currentTag, newImage, ok, _ := checkContainer(plc, repo, eventRepoRef, resource, c, annotations, digestPin)
+		if !ok {
 			continue
 		}
+		resource.UpdateContainer(idx, newImage)
+		apply(currentTag, restartMode && newImage == c.Image)
+	}
+
+	if !initContainersEnabled(resource) {
+		return updatePlan, shouldUpdateDeployment, nil
+	}
 
-		if !shouldUpdateContainer {
+	for idx, c := range resource.InitContainers() {
+		currentTag, newImage, ok, _ := checkContainer(plc, repo, eventRepoRef, resource, c, annotations, digestPin)
+		if !ok {
 			continue
 		}
+		resource.UpdateInitContainer(idx, newImage)
+		apply(currentTag, restartMode && newImage == c.Image)
+	}
 
-		// updating spec template annotations
-		setUpdateTime(resource)
+	return updatePlan, shouldUpdateDeployment, nil
+}
+
+// checkContainer - returns current tag and image the container should be updated to,
+// reason explains why the container isn't updated
+func checkContainer(plc policy.Policy, repo *types.Repository, eventRepoRef *image.Reference, resource *k8s.GenericResource, c v1.Container, annotations map[string]string, digestPin string) (currentTag, newImage string, ok bool, reason string) {
+	containerPlc := policy.GetContainerPolicy(c.Name, plc, annotations)
+	if containerPlc.Type() == policy.PolicyTypeNone {
+		reason = "container has no policy"
+		return
+	}
+
+	imageRef, pinnedDigest := image.SplitTagDigest(c.Image)
+	containerImageRef, err := image.Parse(imageRef)
+	if err != nil {
+		log.WithFields(log.Fields{
+			"error":      err,
+			"image_name": c.Image,
+		}).Error("provider.kubernetes: failed to parse image name")
+		reason = fmt.Sprintf("failed to parse image: %s", err)
+		return
+	}
+
+	log.WithFields(log.Fields{
+		"name":              resource.Name,
+		"namespace":         resource.Namespace,
+		"kind":              resource.Kind(),
+		"parsed_image_name": containerImageRef.Remote(),
+		"target_image_name": repo.Name,
+		"target_tag":        repo.Tag,
+		"policy":            containerPlc.Name(),
+		"image":             c.Image,
+	}).Debug("provider.kubernetes: checking image")
+
+	if containerImageRef.Repository() != eventRepoRef.Repository() {
+		log.WithFields(log.Fields{
+			"parsed_image_name": containerImageRef.Remote(),
+			"target_image_name": repo.Name,
+		}).Debug("provider.kubernetes: images do not match, ignoring")
+		reason = "image doesn't match"
+		return
+	}
+
+	if pinnedDigest != "" && digestPin == DigestPinRespect {
+		log.WithFields(log.Fields{
+			"name":      resource.Name,
+			"namespace": resource.Namespace,
+			"image":     c.Image,
+		}).Debug("provider.kubernetes: image is pinned by digest, ignoring")
+		reason = "image is pinned by digest"
+		return
+	}
+
+	shouldUpdateContainer, err := containerPlc.ShouldUpdate(containerImageRef.Tag(), eventRepoRef.Tag())
+	if err != nil {
+		log.WithFields(log.Fields{
+			"error":             err,
+			"parsed_image_name": containerImageRef.Remote(),
+			"target_image_name": repo.Name,
+			"policy":            containerPlc.Name(),
+		}).Error("provider.kubernetes: failed to check whether container should be updated")
+		reason = fmt.Sprintf("policy %s failed: %s", containerPlc.Name(), err)
+		return
+	}
+
+	if !shouldUpdateContainer {
+		reason = policy.Explain(containerPlc, containerImageRef.Tag(), eventRepoRef.Tag())
+		return
+	}
 
-		// updating image
-		if containerImageRef.Registry() == image.DefaultRegistryHostname {
-			resource.UpdateContainer(idx, fmt.Sprintf("%s:%s", containerImageRef.ShortName(), repo.Tag))
-		} else {
-			resource.UpdateContainer(idx, fmt.Sprintf("%s:%s", containerImageRef.Repository(), repo.Tag))
+	// only the digest changed, pods are restarted to pull it. Images pinned
+	// by digest still have to change to pull the new digest
+	if pinnedDigest == "" && containerImageRef.Tag() == repo.Tag && getUpdateMode(resource.GetLabels(), annotations) == UpdateModeRestart {
+		if c.ImagePullPolicy != v1.PullAlways {
+			log.WithFields(log.Fields{
+				"name":      resource.Name,
+				"namespace": resource.Namespace,
+				"container": c.Name,
+				"image":     c.Image,
+			}).Warn("provider.kubernetes: restarting pods to pull new digest but container imagePullPolicy isn't Always, nodes may keep using cached image")
 		}
+		return containerImageRef.Tag(), c.Image, true, ""
+	}
 
-		shouldUpdateDeployment = true
+	if containerImageRef.Registry() == image.DefaultRegistryHostname {
+		newImage = fmt.Sprintf("%s:%s", containerImageRef.ShortName(), repo.Tag)
+	} else {
+		newImage = fmt.Sprintf("%s:%s", containerImageRef.Repository(), repo.Tag)
+	}
 
-		updatePlan.CurrentVersion = containerImageRef.Tag()
-		updatePlan.NewVersion = repo.Tag
-		updatePlan.Resource = resource
+	if pinDigest(digestPin, pinnedDigest) {
+		if repo.Digest == "" {
+			log.WithFields(log.Fields{
+				"name":      resource.Name,
+				"namespace": resource.Namespace,
+				"image":     c.Image,
+				"new_tag":   repo.Tag,
+			}).Warn("provider.kubernetes: image should be pinned by digest but event has no digest to pin, ignoring")
+			reason = "image should be pinned by digest but digest is unknown"
+			return
+		}
+		if repo.Digest == pinnedDigest && containerImageRef.Tag() == repo.Tag {
+			reason = "image is already pinned to the digest"
+			return
+		}
+		newImage = newImage + "@" + repo.Digest
 	}
 
-	return updatePlan, shouldUpdateDeployment, nil
+	return containerImageRef.Tag(), newImage, true, ""
+}
+
+// pinDigest - whether the new image is pinned by digest
+func pinDigest(digestPin, pinnedDigest string) bool {
+	return digestPin == DigestPinAlways || (pinnedDigest != "" && digestPin == DigestPinUpdate)
 }
 
 func setUpdateTime(resource *k8s.GenericResource) {
@@ -96,3 +312,11 @@ func setUpdateTime(resource *k8s.GenericResource) {
 	specAnnotations[types.KeelUpdateTimeAnnotation] = time.Now().String()
 	resource.SetSpecAnnotations(specAnnotations)
 }
+
+// setRestartTime - bumps pod template annotation so pods are restarted even
+// though images didn't change
+func setRestartTime(resource *k8s.GenericResource) {
+	specAnnotations := resource.GetSpecAnnotations()
+	specAnnotations[types.KeelUpdateTimestampAnnotation] = time.Now().UTC().Format(time.RFC3339)
+	resource.SetSpecAnnotations(specAnnotations)
+}
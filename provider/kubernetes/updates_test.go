@@ -1141,3 +1141,361 @@ func TestProvider_checkForUpdateSemver(t *testing.T) {
 		})
 	}
 }
+
+func TestProvider_checkForUpdateDigestPin(t *testing.T) {
+	const (
+		oldDigest = "sha256:0604af35299dd37ff23937d115d103532948b568a9dd8197d14c256a8ab8b0bb"
+		newDigest = "sha256:1c14a8b4ca83bc8f6cb1ec9a1f1a8a6a66d3f2b7cf3a1d4f2e1a7a4c9a2e6d11"
+	)
+
+	newDeployment := func(img string, annotations map[string]string) *k8s.GenericResource {
+		return MustParseGR(&apps_v1.Deployment{
+			meta_v1.TypeMeta{},
+			meta_v1.ObjectMeta{
+				Name:        "dep-1",
+				Namespace:   "xxxx",
+				Annotations: annotations,
+				Labels:      map[string]string{types.KeelPolicyLabel: "all"},
+			},
+			apps_v1.DeploymentSpec{
+				Template: v1.PodTemplateSpec{
+					ObjectMeta: meta_v1.ObjectMeta{
+						Annotations: map[string]string{},
+					},
+					Spec: v1.PodSpec{
+						Containers: []v1.Container{
+							{
+								Image: img,
+							},
+						},
+					},
+				},
+			},
+			apps_v1.DeploymentStatus{},
+		})
+	}
+
+	tests := []struct {
+		name                       string
+		policy                     policy.Policy
+		repo                       *types.Repository
+		annotations                map[string]string
+		image                      string
+		wantImage                  string
+		wantShouldUpdateDeployment bool
+	}{
+		{
+			name:        "semver, pinned image is respected by default",
+			policy:      policy.NewSemverPolicy(policy.SemverPolicyTypeAll, true),
+			repo:        &types.Repository{Name: "gcr.io/v2-namespace/app", Tag: "1.5.0", Digest: newDigest},
+			annotations: map[string]string{},
+			image:       "gcr.io/v2-namespace/app:1.4.0@" + oldDigest,
+			wantImage:   "gcr.io/v2-namespace/app:1.4.0@" + oldDigest,
+		},
+		{
+			name:        "force, pinned image is respected",
+			policy:      policy.NewForcePolicy(false),
+			repo:        &types.Repository{Name: "gcr.io/v2-namespace/app", Tag: "1.4.0", Digest: newDigest},
+			annotations: map[string]string{types.KeelDigestPinAnnotation: DigestPinRespect},
+			image:       "gcr.io/v2-namespace/app:1.4.0@" + oldDigest,
+			wantImage:   "gcr.io/v2-namespace/app:1.4.0@" + oldDigest,
+		},
+		{
+			name:                       "semver, update keeps tag@digest format",
+			policy:                     policy.NewSemverPolicy(policy.SemverPolicyTypeAll, true),
+			repo:                       &types.Repository{Name: "gcr.io/v2-namespace/app", Tag: "1.5.0", Digest: newDigest},
+			annotations:                map[string]string{types.KeelDigestPinAnnotation: DigestPinUpdate},
+			image:                      "gcr.io/v2-namespace/app:1.4.0@" + oldDigest,
+			wantImage:                  "gcr.io/v2-namespace/app:1.5.0@" + newDigest,
+			wantShouldUpdateDeployment: true,
+		},
+		{
+			name:        "semver, update without digest in the event is skipped",
+			policy:      policy.NewSemverPolicy(policy.SemverPolicyTypeAll, true),
+			repo:        &types.Repository{Name: "gcr.io/v2-namespace/app", Tag: "1.5.0"},
+			annotations: map[string]string{types.KeelDigestPinAnnotation: DigestPinUpdate},
+			image:       "gcr.io/v2-namespace/app:1.4.0@" + oldDigest,
+			wantImage:   "gcr.io/v2-namespace/app:1.4.0@" + oldDigest,
+		},
+		{
+			name:                       "force, update re-pins digest of the same tag",
+			policy:                     policy.NewForcePolicy(true),
+			repo:                       &types.Repository{Name: "gcr.io/v2-namespace/app", Tag: "1.4.0", Digest: newDigest},
+			annotations:                map[string]string{types.KeelDigestPinAnnotation: DigestPinUpdate},
+			image:                      "gcr.io/v2-namespace/app:1.4.0@" + oldDigest,
+			wantImage:                  "gcr.io/v2-namespace/app:1.4.0@" + newDigest,
+			wantShouldUpdateDeployment: true,
+		},
+		{
+			name:        "force, update with unchanged digest is skipped",
+			policy:      policy.NewForcePolicy(true),
+			repo:        &types.Repository{Name: "gcr.io/v2-namespace/app", Tag: "1.4.0", Digest: oldDigest},
+			annotations: map[string]string{types.KeelDigestPinAnnotation: DigestPinUpdate},
+			image:       "gcr.io/v2-namespace/app:1.4.0@" + oldDigest,
+			wantImage:   "gcr.io/v2-namespace/app:1.4.0@" + oldDigest,
+		},
+		{
+			name:                       "semver, drop removes the digest",
+			policy:                     policy.NewSemverPolicy(policy.SemverPolicyTypeAll, true),
+			repo:                       &types.Repository{Name: "gcr.io/v2-namespace/app", Tag: "1.5.0", Digest: newDigest},
+			annotations:                map[string]string{types.KeelDigestPinAnnotation: DigestPinDrop},
+			image:                      "gcr.io/v2-namespace/app:1.4.0@" + oldDigest,
+			wantImage:                  "gcr.io/v2-namespace/app:1.5.0",
+			wantShouldUpdateDeployment: true,
+		},
+		{
+			name:                       "glob, drop removes the digest",
+			policy:                     mustParseGlob("glob:release-*"),
+			repo:                       &types.Repository{Name: "gcr.io/v2-namespace/app", Tag: "release-2"},
+			annotations:                map[string]string{types.KeelDigestPinAnnotation: DigestPinDrop},
+			image:                      "gcr.io/v2-namespace/app:release-1@" + oldDigest,
+			wantImage:                  "gcr.io/v2-namespace/app:release-2",
+			wantShouldUpdateDeployment: true,
+		},
+		{
+			name:        "semver, unknown mode falls back to respect",
+			policy:      policy.NewSemverPolicy(policy.SemverPolicyTypeAll, true),
+			repo:        &types.Repository{Name: "gcr.io/v2-namespace/app", Tag: "1.5.0", Digest: newDigest},
+			annotations: map[string]string{types.KeelDigestPinAnnotation: "whatever"},
+			image:       "gcr.io/v2-namespace/app:1.4.0@" + oldDigest,
+			wantImage:   "gcr.io/v2-namespace/app:1.4.0@" + oldDigest,
+		},
+		{
+			name:                       "semver, digest pins image referenced by tag",
+			policy:                     policy.NewSemverPolicy(policy.SemverPolicyTypeAll, true),
+			repo:                       &types.Repository{Name: "gcr.io/v2-namespace/app", Tag: "1.5.0", Digest: newDigest},
+			annotations:                map[string]string{types.KeelDigestAnnotation: "true"},
+			image:                      "gcr.io/v2-namespace/app:1.4.0",
+			wantImage:                  "gcr.io/v2-namespace/app:1.5.0@" + newDigest,
+			wantShouldUpdateDeployment: true,
+		},
+		{
+			name:                       "semver, digest updates pinned image regardless of digest pin mode",
+			policy:                     policy.NewSemverPolicy(policy.SemverPolicyTypeAll, true),
+			repo:                       &types.Repository{Name: "gcr.io/v2-namespace/app", Tag: "1.5.0", Digest: newDigest},
+			annotations:                map[string]string{types.KeelDigestAnnotation: "true", types.KeelDigestPinAnnotation: DigestPinRespect},
+			image:                      "gcr.io/v2-namespace/app:1.4.0@" + oldDigest,
+			wantImage:                  "gcr.io/v2-namespace/app:1.5.0@" + newDigest,
+			wantShouldUpdateDeployment: true,
+		},
+		{
+			name:        "semver, digest without digest in the event is skipped",
+			policy:      policy.NewSemverPolicy(policy.SemverPolicyTypeAll, true),
+			repo:        &types.Repository{Name: "gcr.io/v2-namespace/app", Tag: "1.5.0"},
+			annotations: map[string]string{types.KeelDigestAnnotation: "true"},
+			image:       "gcr.io/v2-namespace/app:1.4.0",
+			wantImage:   "gcr.io/v2-namespace/app:1.4.0",
+		},
+		{
+			name:                       "semver, digest disabled updates tag",
+			policy:                     policy.NewSemverPolicy(policy.SemverPolicyTypeAll, true),
+			repo:                       &types.Repository{Name: "gcr.io/v2-namespace/app", Tag: "1.5.0", Digest: newDigest},
+			annotations:                map[string]string{types.KeelDigestAnnotation: "false"},
+			image:                      "gcr.io/v2-namespace/app:1.4.0",
+			wantImage:                  "gcr.io/v2-namespace/app:1.5.0",
+			wantShouldUpdateDeployment: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resource := newDeployment(tt.image, tt.annotations)
+			_, gotShouldUpdateDeployment, err := checkForUpdate(tt.policy, tt.repo, resource)
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+
+			if gotShouldUpdateDeployment != tt.wantShouldUpdateDeployment {
+				t.Errorf("checkForUpdate() gotShouldUpdateDeployment = %v, want %v", gotShouldUpdateDeployment, tt.wantShouldUpdateDeployment)
+			}
+
+			if got := resource.Containers()[0].Image; got != tt.wantImage {
+				t.Errorf("checkForUpdate() image = %s, want %s", got, tt.wantImage)
+			}
+		})
+	}
+}
+
+func TestProvider_checkForUpdateContainerPolicy(t *testing.T) {
+	resource := MustParseGR(&apps_v1.Deployment{
+		meta_v1.TypeMeta{},
+		meta_v1.ObjectMeta{
+			Name:      "dep-1",
+			Namespace: "xxxx",
+			Annotations: map[string]string{
+				types.KeelPolicyLabel:            "minor",
+				types.KeelPolicyLabel + ".envoy": "none",
+			},
+		},
+		apps_v1.DeploymentSpec{
+			Template: v1.PodTemplateSpec{
+				ObjectMeta: meta_v1.ObjectMeta{
+					Annotations: map[string]string{},
+				},
+				Spec: v1.PodSpec{
+					Containers: []v1.Container{
+						{
+							Name:  "app",
+							Image: "gcr.io/v2-namespace/envoy:1.1.0",
+						},
+						{
+							Name:  "envoy",
+							Image: "gcr.io/v2-namespace/envoy:1.1.0",
+						},
+					},
+				},
+			},
+		},
+		apps_v1.DeploymentStatus{},
+	})
+
+	repo := &types.Repository{Name: "gcr.io/v2-namespace/envoy", Tag: "1.2.0"}
+	_, shouldUpdate, err := checkForUpdate(policy.NewSemverPolicy(policy.SemverPolicyTypeMinor, true), repo, resource)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if !shouldUpdate {
+		t.Fatalf("expected deployment to be updated")
+	}
+
+	containers := resource.Containers()
+	if containers[0].Image != "gcr.io/v2-namespace/envoy:1.2.0" {
+		t.Errorf("expected app container to be updated, got: %s", containers[0].Image)
+	}
+	if containers[1].Image != "gcr.io/v2-namespace/envoy:1.1.0" {
+		t.Errorf("expected envoy container to be left alone, got: %s", containers[1].Image)
+	}
+}
+
+func TestProvider_checkForUpdateInitContainers(t *testing.T) {
+	newResource := func(annotations map[string]string) *k8s.GenericResource {
+		return MustParseGR(&apps_v1.Deployment{
+			meta_v1.TypeMeta{},
+			meta_v1.ObjectMeta{
+				Name:        "dep-1",
+				Namespace:   "xxxx",
+				Labels:      map[string]string{types.KeelPolicyLabel: "minor"},
+				Annotations: annotations,
+			},
+			apps_v1.DeploymentSpec{
+				Template: v1.PodTemplateSpec{
+					ObjectMeta: meta_v1.ObjectMeta{
+						Annotations: map[string]string{},
+					},
+					Spec: v1.PodSpec{
+						InitContainers: []v1.Container{
+							{
+								Name:  "migrations",
+								Image: "gcr.io/v2-namespace/app-migrations:1.1.0",
+							},
+						},
+						Containers: []v1.Container{
+							{
+								Name:  "app",
+								Image: "gcr.io/v2-namespace/app:1.1.0",
+							},
+						},
+					},
+				},
+			},
+			apps_v1.DeploymentStatus{},
+		})
+	}
+
+	repo := &types.Repository{Name: "gcr.io/v2-namespace/app-migrations", Tag: "1.2.0"}
+	plc := policy.NewSemverPolicy(policy.SemverPolicyTypeMinor, true)
+
+	resource := newResource(map[string]string{})
+	_, shouldUpdate, err := checkForUpdate(plc, repo, resource)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if shouldUpdate {
+		t.Errorf("didn't expect init containers to be updated without %s", types.KeelInitContainersAnnotation)
+	}
+
+	resource = newResource(map[string]string{types.KeelInitContainersAnnotation: "true"})
+	plan, shouldUpdate, err := checkForUpdate(plc, repo, resource)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if !shouldUpdate {
+		t.Fatalf("expected init container to be updated")
+	}
+	if plan.CurrentVersion != "1.1.0" || plan.NewVersion != "1.2.0" {
+		t.Errorf("unexpected plan versions: %s->%s", plan.CurrentVersion, plan.NewVersion)
+	}
+	if img := resource.InitContainers()[0].Image; img != "gcr.io/v2-namespace/app-migrations:1.2.0" {
+		t.Errorf("expected init container to be updated, got: %s", img)
+	}
+	if img := resource.Containers()[0].Image; img != "gcr.io/v2-namespace/app:1.1.0" {
+		t.Errorf("expected app container to be left alone, got: %s", img)
+	}
+}
+
+func TestProvider_checkForUpdateRestartMode(t *testing.T) {
+	const digest = "sha256:1c14a8b4ca83bc8f6cb1ec9a1f1a8a6a66d3f2b7cf3a1d4f2e1a7a4c9a2e6d11"
+
+	newResource := func(mode string) *k8s.GenericResource {
+		return MustParseGR(&apps_v1.Deployment{
+			meta_v1.TypeMeta{},
+			meta_v1.ObjectMeta{
+				Name:      "dep-1",
+				Namespace: "xxxx",
+				Annotations: map[string]string{
+					types.KeelPolicyLabel:          "force",
+					types.KeelDigestAnnotation:     "true",
+					types.KeelUpdateModeAnnotation: mode,
+				},
+			},
+			apps_v1.DeploymentSpec{
+				Template: v1.PodTemplateSpec{
+					ObjectMeta: meta_v1.ObjectMeta{
+						Annotations: map[string]string{},
+					},
+					Spec: v1.PodSpec{
+						Containers: []v1.Container{
+							{
+								Image:           "gcr.io/v2-namespace/hello-world:stable",
+								ImagePullPolicy: v1.PullAlways,
+							},
+						},
+					},
+				},
+			},
+			apps_v1.DeploymentStatus{},
+		})
+	}
+	repo := &types.Repository{Name: "gcr.io/v2-namespace/hello-world", Tag: "stable", Digest: digest}
+
+	resource := newResource(UpdateModeRestart)
+	_, shouldUpdate, err := checkForUpdate(policy.NewForcePolicy(false), repo, resource)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if !shouldUpdate {
+		t.Fatalf("expected pods to be restarted")
+	}
+	if img := resource.GetImages()[0]; img != "gcr.io/v2-namespace/hello-world:stable" {
+		t.Errorf("expected image to be kept, got: %s", img)
+	}
+	if resource.GetSpecAnnotations()[types.KeelUpdateTimestampAnnotation] == "" {
+		t.Errorf("expected %s to be set", types.KeelUpdateTimestampAnnotation)
+	}
+
+	// default mode pins the new digest
+	resource = newResource("")
+	_, shouldUpdate, err = checkForUpdate(policy.NewForcePolicy(false), repo, resource)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if !shouldUpdate {
+		t.Fatalf("expected deployment to be updated")
+	}
+	if img := resource.GetImages()[0]; img != "gcr.io/v2-namespace/hello-world:stable@"+digest {
+		t.Errorf("expected image to be pinned, got: %s", img)
+	}
+	if _, ok := resource.GetSpecAnnotations()[types.KeelUpdateTimestampAnnotation]; ok {
+		t.Errorf("expected %s not to be set", types.KeelUpdateTimestampAnnotation)
+	}
+}
@@ -0,0 +1,155 @@
+package kubernetes
+
+import (
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// updateVerifier - runs post-update checks of a single plan (health check,
+// rollout monitor, verification and health query) in parallel. Whichever check fails first
+// rolls the update back and stops the others, so an update is rolled back
+// at most once
+type updateVerifier struct {
+	provider *Provider
+	plan     *UpdatePlan
+
+	// closed once a check failed
+	failed chan struct{}
+	once   sync.Once
+	wg     sync.WaitGroup
+}
+
+func newUpdateVerifier(p *Provider, plan *UpdatePlan) *updateVerifier {
+	return &updateVerifier{
+		provider: p,
+		plan:     plan,
+		failed:   make(chan struct{}),
+	}
+}
+
+// verifyUpdate - starts post-update checks configured for the resource,
+// returns nil if there are none
+func (p *Provider) verifyUpdate(plan *UpdatePlan) *updateVerifier {
+	resource := plan.Resource
+	settings := resource.GetKeelAnnotations()
+	v := newUpdateVerifier(p, plan)
+	var checks []func() error
+
+	hc, err := getHealthCheck(settings)
+	if err != nil {
+		log.WithFields(log.Fields{
+			"error":     err,
+			"name":      resource.Name,
+			"kind":      resource.Kind(),
+			"namespace": resource.Namespace,
+		}).Error("provider.kubernetes: invalid health check configuration, update won't be verified")
+	} else if hc != nil {
+		checks = append(checks, func() error { return v.checkHealth(hc) })
+	}
+
+	rolloutDeadline, err := getRolloutDeadline(settings)
+	if err != nil {
+		log.WithFields(log.Fields{
+			"error":     err,
+			"name":      resource.Name,
+			"kind":      resource.Kind(),
+			"namespace": resource.Namespace,
+		}).Error("provider.kubernetes: invalid rollout deadline, rollout won't be monitored")
+	} else if rolloutDeadline > 0 {
+		checks = append(checks, func() error { return v.monitorRollout(rolloutDeadline, DefaultRolloutCheckInterval) })
+	}
+
+	verification, err := getVerification(settings)
+	if err != nil {
+		log.WithFields(log.Fields{
+			"error":     err,
+			"name":      resource.Name,
+			"kind":      resource.Kind(),
+			"namespace": resource.Namespace,
+		}).Error("provider.kubernetes: invalid verification configuration, update won't be verified")
+	} else if verification != nil {
+		checks = append(checks, func() error { return v.verify(verification, DefaultVerifyInterval) })
+	}
+
+	hq, err := getHealthQuery(settings)
+	if err != nil {
+		log.WithFields(log.Fields{
+			"error":     err,
+			"name":      resource.Name,
+			"kind":      resource.Kind(),
+			"namespace": resource.Namespace,
+		}).Error("provider.kubernetes: invalid health query configuration, update won't be verified")
+	} else if hq != nil && p.metrics == nil {
+		log.WithFields(log.Fields{
+			"name":      resource.Name,
+			"kind":      resource.Kind(),
+			"namespace": resource.Namespace,
+		}).Warn("provider.kubernetes: health query set but Prometheus URL is not configured, update won't be verified")
+	} else if hq != nil {
+		checks = append(checks, func() error { return v.checkHealthQuery(hq) })
+	}
+
+	if len(checks) == 0 {
+		return nil
+	}
+	for _, check := range checks {
+		v.run(check)
+	}
+	return v
+}
+
+// run - starts check, it returns the reason to roll back or nil once the
+// update passed or the check was stopped
+func (v *updateVerifier) run(check func() error) {
+	v.wg.Add(1)
+	go func() {
+		defer v.wg.Done()
+		if err := check(); err != nil && err != errStopped {
+			v.fail(err)
+		}
+	}()
+}
+
+// fail - rolls back the update on the first failure, rollback itself skips
+// resources that were updated again in the meantime
+func (v *updateVerifier) fail(reason error) {
+	v.once.Do(func() {
+		close(v.failed)
+
+		resource := v.plan.Resource
+		log.WithFields(log.Fields{
+			"error":     reason,
+			"name":      resource.Name,
+			"namespace": resource.Namespace,
+			"kind":      resource.Kind(),
+		}).Warn("provider.kubernetes: post-update check failed, rolling back")
+
+		if err := v.provider.rollback(v.plan, reason); err != nil {
+			log.WithFields(log.Fields{
+				"error":     err,
+				"name":      resource.Name,
+				"namespace": resource.Namespace,
+				"kind":      resource.Kind(),
+			}).Error("provider.kubernetes: failed to roll back resource")
+		}
+	})
+}
+
+// wait - waits for all checks to finish
+func (v *updateVerifier) wait() {
+	v.wg.Wait()
+}
+
+// sleep - waits for interval, returns false if checks should stop
+func (v *updateVerifier) sleep(interval time.Duration) bool {
+	select {
+	case <-v.provider.stop:
+		return false
+	case <-v.failed:
+		return false
+	case <-time.After(interval):
+		return true
+	}
+}
@@ -0,0 +1,76 @@
+package kubernetes
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/keel-hq/keel/types"
+)
+
+func TestVerifierRollsBackOnce(t *testing.T) {
+	fi := &fakeImplementer{}
+	provider, _, teardown := verifyProvider(t, fi)
+	defer teardown()
+
+	v := newUpdateVerifier(provider, verifyPlan())
+	for i := 0; i < 3; i++ {
+		i := i
+		v.run(func() error { return fmt.Errorf("check %d failed", i) })
+	}
+	// checks still running are stopped once the update is rolled back
+	stopped := make(chan error, 1)
+	v.run(func() error {
+		for v.sleep(time.Millisecond) {
+		}
+		stopped <- errStopped
+		return errStopped
+	})
+	v.wait()
+
+	if fi.updateAttempts != 1 {
+		t.Errorf("expected a single rollback, got %d updates", fi.updateAttempts)
+	}
+	select {
+	case <-stopped:
+	default:
+		t.Errorf("expected running check to be stopped")
+	}
+}
+
+func TestVerifyUpdateSingleRollback(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer ts.Close()
+
+	fi := &fakeImplementer{}
+	provider, fs, teardown := verifyProvider(t, fi)
+	defer teardown()
+	provider.SetMetricsQuerier(&fakeMetricsQuerier{values: []float64{0.2}})
+
+	plan := verifyPlan()
+	plan.Resource.SetAnnotations(map[string]string{
+		types.KeelHealthCheckURLAnnotation:              ts.URL,
+		types.KeelHealthCheckIntervalAnnotation:         "5ms",
+		types.KeelHealthCheckFailureThresholdAnnotation: "1",
+		types.KeelHealthQueryAnnotation:                 "error_ratio",
+		types.KeelHealthQueryThresholdAnnotation:        "> 0.1",
+		types.KeelHealthQueryIntervalAnnotation:         "5ms",
+	})
+
+	v := provider.verifyUpdate(plan)
+	if v == nil {
+		t.Fatalf("expected post-update checks to be started")
+	}
+	v.wait()
+
+	if fi.updateAttempts != 1 {
+		t.Errorf("expected a single rollback, got %d updates", fi.updateAttempts)
+	}
+	if fs.sentEvent.Name != "rollback resource" {
+		t.Errorf("expected rollback notification, got: %+v", fs.sentEvent)
+	}
+}
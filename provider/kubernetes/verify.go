@@ -0,0 +1,286 @@
+package kubernetes
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/keel-hq/keel/types"
+
+	batch_v1 "k8s.io/api/batch/v1"
+	v1 "k8s.io/api/core/v1"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// default post-update verification settings
+const (
+	DefaultVerifyTimeout  = 5 * time.Minute
+	DefaultVerifyInterval = 5 * time.Second
+	// DefaultVerifyJobTTL - how long finished verification jobs are kept
+	DefaultVerifyJobTTL = time.Hour
+)
+
+// verification - one-off smoke test run once the update is rolled out,
+// either an HTTP check or a job created from existing job or cron job
+type verification struct {
+	url     string
+	job     string
+	cronJob string
+	timeout time.Duration
+}
+
+func (v *verification) String() string {
+	switch {
+	case v.job != "":
+		return "job/" + v.job
+	case v.cronJob != "":
+		return "cronjob/" + v.cronJob
+	}
+	return v.url
+}
+
+// getVerification - parses verification from resource annotations, returns nil
+// if verification is not configured
+func getVerification(annotations map[string]string) (*verification, error) {
+	target := strings.TrimSpace(annotations[types.KeelVerifyAnnotation])
+	if target == "" {
+		return nil, nil
+	}
+
+	v := &verification{timeout: DefaultVerifyTimeout}
+
+	switch {
+	case strings.HasPrefix(target, "http://"), strings.HasPrefix(target, "https://"):
+		v.url = target
+	case strings.HasPrefix(target, "job/") && len(target) > len("job/"):
+		v.job = strings.TrimPrefix(target, "job/")
+	case strings.HasPrefix(target, "cronjob/") && len(target) > len("cronjob/"):
+		v.cronJob = strings.TrimPrefix(target, "cronjob/")
+	default:
+		return nil, fmt.Errorf("invalid verification '%s', expected URL, job/<name> or cronjob/<name>", target)
+	}
+
+	if val, ok := annotations[types.KeelVerifyTimeoutAnnotation]; ok {
+		timeout, err := time.ParseDuration(val)
+		if err != nil || timeout <= 0 {
+			return nil, fmt.Errorf("invalid verification timeout '%s'", val)
+		}
+		v.timeout = timeout
+	}
+
+	return v, nil
+}
+
+// verify - waits for the update to roll out and runs verification, fails if
+// verification fails
+func (v *updateVerifier) verify(ver *verification, interval time.Duration) error {
+	resource := v.plan.Resource
+	deadline := time.Now().Add(ver.timeout)
+
+	superseded, err := v.waitForRollout(deadline, interval)
+	if superseded {
+		return nil
+	}
+	if err == nil {
+		if ver.url != "" {
+			err = v.verifyURL(ver.url, deadline, interval)
+		} else {
+			err = v.verifyJob(ver, resource.Namespace, resource.Name, deadline, interval)
+		}
+	}
+
+	if err == errStopped {
+		return err
+	}
+
+	if err != nil {
+		return fmt.Errorf("verification %s failed: %s", ver, err)
+	}
+
+	log.WithFields(log.Fields{
+		"name":      resource.Name,
+		"namespace": resource.Namespace,
+		"kind":      resource.Kind(),
+		"verify":    ver.String(),
+	}).Info("provider.kubernetes: update verified")
+	return nil
+}
+
+var errStopped = fmt.Errorf("provider stopped")
+
+// waitForRollout - waits until the updated resource is available, superseded
+// is set if the resource was updated again in the meantime
+func (v *updateVerifier) waitForRollout(deadline time.Time, interval time.Duration) (superseded bool, err error) {
+	images := strings.Join(v.plan.Resource.GetImages(), ",")
+
+	for {
+		if current := v.provider.currentResource(v.plan.Resource.Identifier); current != nil {
+			if strings.Join(current.GetImages(), ",") != images {
+				return true, nil
+			}
+			done, failure := current.RolloutStatus()
+			if done {
+				return false, nil
+			}
+			if failure != "" {
+				return false, fmt.Errorf("rollout failed: %s", failure)
+			}
+		}
+
+		if time.Now().After(deadline) {
+			return false, fmt.Errorf("rollout didn't complete in time")
+		}
+		if !v.sleep(interval) {
+			return false, errStopped
+		}
+	}
+}
+
+// verifyURL - keeps checking URL until it returns 2xx or deadline passes
+func (v *updateVerifier) verifyURL(url string, deadline time.Time, interval time.Duration) error {
+	for {
+		err := checkURL(url)
+		if err == nil {
+			return nil
+		}
+		if time.Now().After(deadline) {
+			return err
+		}
+		if !v.sleep(interval) {
+			return errStopped
+		}
+	}
+}
+
+// verifyJob - runs job from existing job or cron job template and waits for it
+// to finish. Job is deleted once verification passes or times out, failed jobs
+// are kept for inspection until their TTL expires
+func (v *updateVerifier) verifyJob(ver *verification, namespace, resourceName string, deadline time.Time, interval time.Duration) error {
+	p := v.provider
+	job, err := p.verificationJob(ver, namespace, resourceName)
+	if err != nil {
+		return err
+	}
+
+	created, err := p.implementer.Jobs(namespace).Create(job)
+	if err != nil {
+		return fmt.Errorf("failed to create verification job: %s", err)
+	}
+
+	log.WithFields(log.Fields{
+		"job":       created.Name,
+		"namespace": namespace,
+		"name":      resourceName,
+	}).Info("provider.kubernetes: verification job created")
+
+	for {
+		current, err := p.implementer.Jobs(namespace).Get(created.Name, meta_v1.GetOptions{})
+		if err != nil {
+			return fmt.Errorf("failed to get verification job: %s", err)
+		}
+		for _, c := range current.Status.Conditions {
+			if c.Status != v1.ConditionTrue {
+				continue
+			}
+			switch c.Type {
+			case batch_v1.JobComplete:
+				p.deleteVerificationJob(namespace, created.Name)
+				return nil
+			case batch_v1.JobFailed:
+				return fmt.Errorf("job %s failed: %s", created.Name, c.Message)
+			}
+		}
+
+		if time.Now().After(deadline) {
+			// still running, it would keep testing the rolled back version
+			p.deleteVerificationJob(namespace, created.Name)
+			return fmt.Errorf("job %s didn't finish in time", created.Name)
+		}
+		if !v.sleep(interval) {
+			return errStopped
+		}
+	}
+}
+
+func (p *Provider) deleteVerificationJob(namespace, name string) {
+	propagation := meta_v1.DeletePropagationBackground
+	err := p.implementer.Jobs(namespace).Delete(name, &meta_v1.DeleteOptions{PropagationPolicy: &propagation})
+	if err != nil {
+		log.WithFields(log.Fields{
+			"error":     err,
+			"job":       name,
+			"namespace": namespace,
+		}).Warn("provider.kubernetes: failed to delete verification job")
+	}
+}
+
+// verificationJob - new job with spec of the referenced job or cron job template
+func (p *Provider) verificationJob(v *verification, namespace, resourceName string) (*batch_v1.Job, error) {
+	var name string
+	var spec batch_v1.JobSpec
+
+	if v.cronJob != "" {
+		cj, err := p.implementer.CronJobs(namespace).Get(v.cronJob, meta_v1.GetOptions{})
+		if err != nil {
+			return nil, fmt.Errorf("failed to get verification cron job: %s", err)
+		}
+		name = cj.Name
+		spec = *cj.Spec.JobTemplate.Spec.DeepCopy()
+	} else {
+		j, err := p.implementer.Jobs(namespace).Get(v.job, meta_v1.GetOptions{})
+		if err != nil {
+			return nil, fmt.Errorf("failed to get verification job: %s", err)
+		}
+		name = j.Name
+		spec = *j.Spec.DeepCopy()
+		// selector and its labels are generated for the new job
+		spec.Selector = nil
+		spec.ManualSelector = nil
+		for _, label := range generatedJobLabels {
+			delete(spec.Template.Labels, label)
+		}
+	}
+
+	// cleaned up by the TTL controller if keel doesn't delete it
+	ttl := int32(DefaultVerifyJobTTL.Seconds())
+	spec.TTLSecondsAfterFinished = &ttl
+
+	return &batch_v1.Job{
+		ObjectMeta: meta_v1.ObjectMeta{
+			Name:      verificationJobName(name, time.Now()),
+			Namespace: namespace,
+			Labels: map[string]string{
+				"keel.sh/verify": resourceName,
+			},
+		},
+		Spec: spec,
+	}, nil
+}
+
+// generatedJobLabels - set by the job controller on pod templates, legacy and
+// prefixed (k8s 1.27+) keys
+var generatedJobLabels = []string{
+	"controller-uid",
+	"job-name",
+	"batch.kubernetes.io/controller-uid",
+	"batch.kubernetes.io/job-name",
+}
+
+// verificationJobName - <name>-verify-<timestamp>, name is shortened so the
+// result is a valid label value (job-name label is set on its pods)
+func verificationJobName(name string, now time.Time) string {
+	suffix := fmt.Sprintf("verify-%d", now.Unix())
+	max := 63 - len(suffix) - 1
+	if len(name) > max {
+		name = name[:max]
+	}
+	name = strings.TrimFunc(name, func(r rune) bool {
+		return !(r >= 'a' && r <= 'z' || r >= '0' && r <= '9')
+	})
+	if name == "" {
+		return suffix
+	}
+	return name + "-" + suffix
+}
@@ -0,0 +1,303 @@
+package kubernetes
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/keel-hq/keel/internal/k8s"
+	"github.com/keel-hq/keel/types"
+
+	apps_v1 "k8s.io/api/apps/v1"
+	batch_v1 "k8s.io/api/batch/v1"
+	batch_v1beta1 "k8s.io/api/batch/v1beta1"
+	v1 "k8s.io/api/core/v1"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestGetVerification(t *testing.T) {
+	tests := []struct {
+		name        string
+		annotations map[string]string
+		want        *verification
+		wantErr     bool
+	}{
+		{name: "not configured", annotations: map[string]string{}},
+		{
+			name:        "url",
+			annotations: map[string]string{types.KeelVerifyAnnotation: "http://svc/smoke"},
+			want:        &verification{url: "http://svc/smoke", timeout: DefaultVerifyTimeout},
+		},
+		{
+			name:        "job",
+			annotations: map[string]string{types.KeelVerifyAnnotation: "job/smoke", types.KeelVerifyTimeoutAnnotation: "2m"},
+			want:        &verification{job: "smoke", timeout: 2 * time.Minute},
+		},
+		{
+			name:        "cron job",
+			annotations: map[string]string{types.KeelVerifyAnnotation: "cronjob/smoke"},
+			want:        &verification{cronJob: "smoke", timeout: DefaultVerifyTimeout},
+		},
+		{name: "unknown target", annotations: map[string]string{types.KeelVerifyAnnotation: "pod/smoke"}, wantErr: true},
+		{
+			name:        "invalid timeout",
+			annotations: map[string]string{types.KeelVerifyAnnotation: "job/smoke", types.KeelVerifyTimeoutAnnotation: "later"},
+			wantErr:     true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := getVerification(tt.annotations)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("getVerification() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.want == nil {
+				if got != nil {
+					t.Errorf("getVerification() = %+v, want nil", got)
+				}
+				return
+			}
+			if *got != *tt.want {
+				t.Errorf("getVerification() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func verifyProvider(t *testing.T, fi *fakeImplementer) (*Provider, *fakeSender, func()) {
+	complete := apps_v1.DeploymentStatus{ObservedGeneration: 2, Replicas: 1, UpdatedReplicas: 1, AvailableReplicas: 1}
+	grc := &k8s.GenericResourceCache{}
+	grc.Add(rolloutDeployment("gcr.io/v2-namespace/hello-world:1.1.2", complete))
+
+	fs := &fakeSender{}
+	approver, teardown := approver()
+	provider, err := NewProvider(fi, fs, approver, grc)
+	if err != nil {
+		teardown()
+		t.Fatalf("failed to get provider: %s", err)
+	}
+	return provider, fs, teardown
+}
+
+func verifyPlan() *UpdatePlan {
+	return &UpdatePlan{
+		Resource:       rolloutDeployment("gcr.io/v2-namespace/hello-world:1.1.2", apps_v1.DeploymentStatus{}),
+		CurrentVersion: "1.1.1",
+		NewVersion:     "1.1.2",
+		PreviousImages: []string{"gcr.io/v2-namespace/hello-world:1.1.1"},
+	}
+}
+
+func TestVerifyURL(t *testing.T) {
+	tests := []struct {
+		name         string
+		status       int
+		wantRollback bool
+	}{
+		{name: "passing", status: http.StatusOK},
+		{name: "failing", status: http.StatusInternalServerError, wantRollback: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(tt.status)
+			}))
+			defer ts.Close()
+
+			fi := &fakeImplementer{}
+			provider, fs, teardown := verifyProvider(t, fi)
+			defer teardown()
+
+			v := newUpdateVerifier(provider, verifyPlan())
+			v.run(func() error {
+				return v.verify(&verification{url: ts.URL, timeout: 50 * time.Millisecond}, 5*time.Millisecond)
+			})
+			v.wait()
+
+			if !tt.wantRollback {
+				if fi.updated != nil {
+					t.Errorf("verified resource shouldn't be rolled back")
+				}
+				return
+			}
+			if fi.updated == nil {
+				t.Fatalf("expected resource to be rolled back")
+			}
+			if fi.updated.GetImages()[0] != "gcr.io/v2-namespace/hello-world:1.1.1" {
+				t.Errorf("unexpected image after rollback: %s", fi.updated.GetImages()[0])
+			}
+			if fs.sentEvent.Name != "rollback resource" {
+				t.Errorf("expected rollback notification, got: %+v", fs.sentEvent)
+			}
+		})
+	}
+}
+
+func TestVerifyCronJob(t *testing.T) {
+	tests := []struct {
+		name         string
+		condition    batch_v1.JobConditionType
+		wantRollback bool
+		wantJobs     int
+	}{
+		{name: "complete", condition: batch_v1.JobComplete},
+		// failed jobs are kept for inspection
+		{name: "failed", condition: batch_v1.JobFailed, wantRollback: true, wantJobs: 1},
+		// running jobs are deleted on timeout
+		{name: "timeout", wantRollback: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := fake.NewSimpleClientset(&batch_v1beta1.CronJob{
+				ObjectMeta: meta_v1.ObjectMeta{Name: "smoke", Namespace: "xxxx"},
+				Spec: batch_v1beta1.CronJobSpec{
+					Schedule: "@daily",
+					JobTemplate: batch_v1beta1.JobTemplateSpec{
+						Spec: batch_v1.JobSpec{
+							Template: v1.PodTemplateSpec{
+								Spec: v1.PodSpec{
+									Containers: []v1.Container{{Name: "smoke", Image: "smoke:latest"}},
+								},
+							},
+						},
+					},
+				},
+			})
+
+			// finishing the job once keel creates it
+			done := make(chan struct{})
+			defer close(done)
+			go func() {
+				if tt.condition == "" {
+					return
+				}
+				for {
+					select {
+					case <-done:
+						return
+					case <-time.After(5 * time.Millisecond):
+					}
+					jobs, err := client.BatchV1().Jobs("xxxx").List(meta_v1.ListOptions{})
+					if err != nil || len(jobs.Items) == 0 {
+						continue
+					}
+					job := jobs.Items[0]
+					job.Status.Conditions = []batch_v1.JobCondition{{Type: tt.condition, Status: v1.ConditionTrue}}
+					client.BatchV1().Jobs("xxxx").UpdateStatus(&job)
+					return
+				}
+			}()
+
+			fi := &fakeImplementer{client: client}
+			provider, _, teardown := verifyProvider(t, fi)
+			defer teardown()
+
+			v := newUpdateVerifier(provider, verifyPlan())
+			v.run(func() error {
+				return v.verify(&verification{cronJob: "smoke", timeout: time.Second}, 5*time.Millisecond)
+			})
+			v.wait()
+
+			jobs, err := client.BatchV1().Jobs("xxxx").List(meta_v1.ListOptions{})
+			if err != nil {
+				t.Fatalf("failed to list jobs: %s", err)
+			}
+			if len(jobs.Items) != tt.wantJobs {
+				t.Fatalf("expected %d verification jobs, got: %d", tt.wantJobs, len(jobs.Items))
+			}
+			for _, job := range jobs.Items {
+				if job.Spec.Template.Spec.Containers[0].Image != "smoke:latest" {
+					t.Errorf("unexpected verification job image: %s", job.Spec.Template.Spec.Containers[0].Image)
+				}
+				if job.Spec.TTLSecondsAfterFinished == nil {
+					t.Errorf("expected verification job TTL to be set")
+				}
+			}
+
+			if tt.wantRollback && fi.updated == nil {
+				t.Errorf("expected resource to be rolled back")
+			}
+			if !tt.wantRollback && fi.updated != nil {
+				t.Errorf("verified resource shouldn't be rolled back")
+			}
+		})
+	}
+}
+
+func TestVerificationJobLabels(t *testing.T) {
+	client := fake.NewSimpleClientset(&batch_v1.Job{
+		ObjectMeta: meta_v1.ObjectMeta{Name: "smoke", Namespace: "xxxx"},
+		Spec: batch_v1.JobSpec{
+			Selector: &meta_v1.LabelSelector{MatchLabels: map[string]string{"controller-uid": "aaa"}},
+			Template: v1.PodTemplateSpec{
+				ObjectMeta: meta_v1.ObjectMeta{
+					Labels: map[string]string{
+						"app":                                "smoke",
+						"controller-uid":                     "aaa",
+						"job-name":                           "smoke",
+						"batch.kubernetes.io/controller-uid": "aaa",
+						"batch.kubernetes.io/job-name":       "smoke",
+					},
+				},
+			},
+		},
+	})
+
+	provider, _, teardown := verifyProvider(t, &fakeImplementer{client: client})
+	defer teardown()
+
+	job, err := provider.verificationJob(&verification{job: "smoke"}, "xxxx", "dep-1")
+	if err != nil {
+		t.Fatalf("failed to get verification job: %s", err)
+	}
+	if job.Spec.Selector != nil {
+		t.Errorf("expected selector to be cleared")
+	}
+	want := map[string]string{"app": "smoke"}
+	if !reflect.DeepEqual(job.Spec.Template.Labels, want) {
+		t.Errorf("unexpected template labels: %v", job.Spec.Template.Labels)
+	}
+}
+
+func TestVerificationJobName(t *testing.T) {
+	now := time.Unix(1600000000, 0)
+
+	tests := []struct {
+		name string
+		job  string
+		want string
+	}{
+		{name: "short", job: "smoke", want: "smoke-verify-1600000000"},
+		{
+			name: "truncated",
+			job:  "very-long-smoke-test-name-that-does-not-fit-in-a-label-value",
+			want: "very-long-smoke-test-name-that-does-not-fit-i-verify-1600000000",
+		},
+		{
+			// truncating leaves a trailing dash
+			name: "trimmed",
+			job:  "smoke-test-name-that-does-not-fit-in-a-label-value-ab-cd",
+			want: "smoke-test-name-that-does-not-fit-in-a-label-verify-1600000000",
+		},
+		{name: "leading dash", job: "-smoke", want: "smoke-verify-1600000000"},
+		{name: "nothing left", job: "---", want: "verify-1600000000"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := verificationJobName(tt.job, now)
+			if got != tt.want {
+				t.Errorf("verificationJobName() = %s, want %s", got, tt.want)
+			}
+			if len(got) > 63 {
+				t.Errorf("job name too long: %d", len(got))
+			}
+		})
+	}
+}
@@ -0,0 +1,350 @@
+package kubernetes
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/keel-hq/keel/types"
+	"github.com/keel-hq/keel/util/timeutil"
+
+	v1 "k8s.io/api/core/v1"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// DefaultUpdateWindowCheckInterval - how often queued updates are checked
+// against their update windows
+const DefaultUpdateWindowCheckInterval = time.Minute
+
+var timeRangeRE = regexp.MustCompile(`^(\d{1,2}):(\d{2})-(\d{1,2}):(\d{2})$`)
+
+var weekdays = map[string]time.Weekday{
+	"sun": time.Sunday,
+	"mon": time.Monday,
+	"tue": time.Tuesday,
+	"wed": time.Wednesday,
+	"thu": time.Thursday,
+	"fri": time.Friday,
+	"sat": time.Saturday,
+}
+
+// updateWindow - time range on selected week days, start and end are minutes
+// since midnight. Windows ending before they start span midnight
+type updateWindow struct {
+	days     [7]bool
+	start    int
+	end      int
+	location *time.Location
+}
+
+type updateWindows []updateWindow
+
+// parseUpdateWindows - parses "[days] HH:MM-HH:MM [timezone]" windows separated
+// with ";", days are comma separated names or ranges (ie: Mon-Fri,Sun) and default
+// to every day, timezone defaults to UTC
+func parseUpdateWindows(spec string) (updateWindows, error) {
+	var windows updateWindows
+
+	for _, entry := range strings.Split(spec, ";") {
+		fields := strings.Fields(entry)
+		if len(fields) == 0 {
+			continue
+		}
+
+		idx := -1
+		for i, f := range fields {
+			if timeRangeRE.MatchString(f) {
+				idx = i
+				break
+			}
+		}
+		if idx < 0 || idx > 1 || len(fields)-idx > 2 {
+			return nil, fmt.Errorf("invalid update window '%s', expected [days] HH:MM-HH:MM [timezone]", strings.TrimSpace(entry))
+		}
+
+		w := updateWindow{location: time.UTC}
+
+		if idx == 1 {
+			days, err := parseWeekdays(fields[0])
+			if err != nil {
+				return nil, err
+			}
+			w.days = days
+		} else {
+			for i := range w.days {
+				w.days[i] = true
+			}
+		}
+
+		parts := timeRangeRE.FindStringSubmatch(fields[idx])
+		start, err := minutesOfDay(parts[1], parts[2])
+		if err != nil {
+			return nil, err
+		}
+		end, err := minutesOfDay(parts[3], parts[4])
+		if err != nil {
+			return nil, err
+		}
+		w.start, w.end = start, end
+
+		if len(fields) > idx+1 {
+			loc, err := time.LoadLocation(fields[idx+1])
+			if err != nil {
+				return nil, fmt.Errorf("invalid update window timezone '%s': %s", fields[idx+1], err)
+			}
+			w.location = loc
+		}
+
+		windows = append(windows, w)
+	}
+
+	if len(windows) == 0 {
+		return nil, fmt.Errorf("update window is empty")
+	}
+
+	return windows, nil
+}
+
+func parseWeekdays(spec string) (days [7]bool, err error) {
+	for _, item := range strings.Split(spec, ",") {
+		bounds := strings.SplitN(item, "-", 2)
+		from, ok := weekdays[strings.ToLower(bounds[0])]
+		if !ok {
+			return days, fmt.Errorf("invalid update window day '%s'", bounds[0])
+		}
+		to := from
+		if len(bounds) == 2 {
+			to, ok = weekdays[strings.ToLower(bounds[1])]
+			if !ok {
+				return days, fmt.Errorf("invalid update window day '%s'", bounds[1])
+			}
+		}
+		// ranges can wrap around the week, ie: Fri-Mon
+		for d := from; ; d = (d + 1) % 7 {
+			days[d] = true
+			if d == to {
+				break
+			}
+		}
+	}
+	return days, nil
+}
+
+func minutesOfDay(hours, minutes string) (int, error) {
+	h, _ := strconv.Atoi(hours)
+	m, _ := strconv.Atoi(minutes)
+	if m > 59 || h > 24 || (h == 24 && m > 0) {
+		return 0, fmt.Errorf("invalid update window time '%s:%s'", hours, minutes)
+	}
+	return h*60 + m, nil
+}
+
+// open - whether t is inside the window, equal start and end means whole day
+func (w updateWindow) open(t time.Time) bool {
+	t = t.In(w.location)
+	minutes := t.Hour()*60 + t.Minute()
+	day := t.Weekday()
+
+	switch {
+	case w.start == w.end:
+		return w.days[day]
+	case w.start < w.end:
+		return w.days[day] && minutes >= w.start && minutes < w.end
+	default:
+		return (w.days[day] && minutes >= w.start) || (w.days[(day+6)%7] && minutes < w.end)
+	}
+}
+
+func (ws updateWindows) open(t time.Time) bool {
+	for _, w := range ws {
+		if w.open(t) {
+			return true
+		}
+	}
+	return false
+}
+
+// holdOutsideWindow - queues plans for resources whose update window is closed,
+// returns plans that can be applied now
+func (p *Provider) holdOutsideWindow(event *types.Event, plans []*UpdatePlan) []*UpdatePlan {
+	var ready []*UpdatePlan
+	now := timeutil.Now()
+
+	for _, plan := range plans {
+		resource := plan.Resource
+		spec := strings.TrimSpace(resource.GetKeelAnnotations()[types.KeelUpdateWindowAnnotation])
+		if spec == "" {
+			ready = append(ready, plan)
+			continue
+		}
+
+		windows, err := parseUpdateWindows(spec)
+		if err != nil {
+			// holding the update, applying it could break the freeze the window was meant for
+			p.invalidWindow(plan, err)
+			p.queue(event, plan, spec, time.Time{}, "update window is fixed", now)
+			continue
+		}
+
+		if windows.open(now) {
+			ready = append(ready, plan)
+			continue
+		}
+
+		p.queue(event, plan, spec, time.Time{}, "update window "+spec, now)
+	}
+
+	return ready
+}
+
+// invalidWindow - reports update held because its update window can't be parsed
+func (p *Provider) invalidWindow(plan *UpdatePlan, err error) {
+	resource := plan.Resource
+	log.WithFields(log.Fields{
+		"error":     err,
+		"name":      resource.Name,
+		"kind":      resource.Kind(),
+		"namespace": resource.Namespace,
+	}).Error("provider.kubernetes: invalid update window, holding update")
+
+	msg := fmt.Sprintf("Update %s->%s held, invalid %s: %s", plan.CurrentVersion, plan.NewVersion, types.KeelUpdateWindowAnnotation, err)
+	p.recordEvent(resource, v1.EventTypeWarning, EventReasonUpdateSkipped, msg)
+
+	settings := resource.GetKeelAnnotations()
+	p.sender.Send(types.EventNotification{
+		ResourceKind: resource.Kind(),
+		Identifier:   resource.Identifier,
+		Name:         "invalid update window",
+		Message:      fmt.Sprintf("%s %s/%s: %s", resource.Kind(), resource.Namespace, resource.Name, msg),
+		CreatedAt:    time.Now(),
+		Type:         types.NotificationPreDeploymentUpdate,
+		Level:        types.LevelWarn,
+		Channels:     types.ParseEventNotificationChannelsFromLabelsOrAnnotations(resource.GetLabels(), settings),
+		MinLevel:     types.ParseEventNotificationLevel(resource.GetLabels(), settings),
+		Metadata: map[string]string{
+			"provider":  p.GetName(),
+			"namespace": resource.GetNamespace(),
+			"name":      resource.GetName(),
+			"previous":  plan.CurrentVersion,
+			"new":       plan.NewVersion,
+			"window":    settings[types.KeelUpdateWindowAnnotation],
+			"trigger":   plan.Trigger,
+		},
+	})
+}
+
+// queue - stores update until the window opens and ready time passes, newer
+// updates of the same resource replace queued ones. Reason describes what the
+// update waits for
+func (p *Provider) queue(event *types.Event, plan *UpdatePlan, window string, readyAt time.Time, reason string, now time.Time) {
+	resource := plan.Resource
+
+	p.queuedMu.Lock()
+	defer p.queuedMu.Unlock()
+
+	if p.queued == nil {
+		p.queued = make(map[string]*types.QueuedUpdate)
+	}
+
+	queuedAt := now
+	if existing, ok := p.queued[resource.Identifier]; ok && existing.NewVersion == plan.NewVersion {
+		queuedAt = existing.QueuedAt
+	}
+
+	if queuedAt == now {
+		p.recordEvent(resource, v1.EventTypeNormal, EventReasonUpdateSkipped, fmt.Sprintf("Update %s->%s queued until %s", plan.CurrentVersion, plan.NewVersion, reason))
+	}
+
+	p.queued[resource.Identifier] = &types.QueuedUpdate{
+		Provider:       ProviderName,
+		Identifier:     resource.Identifier,
+		Kind:           resource.Kind(),
+		Namespace:      resource.Namespace,
+		Name:           resource.Name,
+		CurrentVersion: plan.CurrentVersion,
+		NewVersion:     plan.NewVersion,
+		Window:         window,
+		QueuedAt:       queuedAt,
+		ReadyAt:        readyAt,
+		Event:          *event,
+	}
+
+	log.WithFields(log.Fields{
+		"name":      resource.Name,
+		"kind":      resource.Kind(),
+		"namespace": resource.Namespace,
+		"update":    fmt.Sprintf("%s->%s", plan.CurrentVersion, plan.NewVersion),
+		"until":     reason,
+	}).Info("provider.kubernetes: update queued")
+}
+
+// processQueued - processes events of queued updates whose window is open
+// and ready time passed
+func (p *Provider) processQueued() {
+	now := timeutil.Now()
+
+	var events []types.Event
+	seen := make(map[string]bool)
+
+	p.queuedMu.Lock()
+	for identifier, q := range p.queued {
+		if now.Before(q.ReadyAt) {
+			continue
+		}
+		if q.Window != "" {
+			// window could have been fixed or removed since the update was queued
+			window := q.Window
+			if current := p.currentResource(identifier); current != nil {
+				window = strings.TrimSpace(current.GetKeelAnnotations()[types.KeelUpdateWindowAnnotation])
+			}
+			if window != "" {
+				windows, err := parseUpdateWindows(window)
+				if err != nil || !windows.open(now) {
+					continue
+				}
+			}
+		}
+		delete(p.queued, identifier)
+
+		key := q.Event.Repository.Host + "/" + q.Event.Repository.Name + ":" + q.Event.Repository.Tag + "@" + q.Event.Repository.Digest
+		if !seen[key] {
+			seen[key] = true
+			events = append(events, q.Event)
+		}
+	}
+	p.queuedMu.Unlock()
+
+	for i := range events {
+		_, err := p.processEvent(&events[i])
+		if err != nil {
+			log.WithFields(log.Fields{
+				"error": err,
+				"image": events[i].Repository.Name,
+				"tag":   events[i].Repository.Tag,
+			}).Error("provider.kubernetes: failed to process queued event")
+		}
+	}
+}
+
+// QueuedUpdates - updates waiting for their update window, oldest first
+func (p *Provider) QueuedUpdates() []*types.QueuedUpdate {
+	p.queuedMu.Lock()
+	defer p.queuedMu.Unlock()
+
+	queued := make([]*types.QueuedUpdate, 0, len(p.queued))
+	for _, q := range p.queued {
+		c := *q
+		queued = append(queued, &c)
+	}
+	sort.Slice(queued, func(i, j int) bool {
+		if queued[i].QueuedAt.Equal(queued[j].QueuedAt) {
+			return queued[i].Identifier < queued[j].Identifier
+		}
+		return queued[i].QueuedAt.Before(queued[j].QueuedAt)
+	})
+	return queued
+}
@@ -0,0 +1,221 @@
+package kubernetes
+
+import (
+	"testing"
+	"time"
+
+	"github.com/keel-hq/keel/internal/k8s"
+	"github.com/keel-hq/keel/types"
+	"github.com/keel-hq/keel/util/timeutil"
+
+	apps_v1 "k8s.io/api/apps/v1"
+	v1 "k8s.io/api/core/v1"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestUpdateWindowsOpen(t *testing.T) {
+	paris, err := time.LoadLocation("Europe/Paris")
+	if err != nil {
+		t.Skipf("timezone data not available: %s", err)
+	}
+
+	// 2021-03-01 is a Monday
+	monday := func(hour, min int, loc *time.Location) time.Time {
+		return time.Date(2021, 3, 1, hour, min, 0, 0, loc)
+	}
+
+	tests := []struct {
+		name    string
+		spec    string
+		t       time.Time
+		want    bool
+		wantErr bool
+	}{
+		{name: "inside", spec: "Mon-Fri 09:00-17:00 Europe/Paris", t: monday(10, 0, paris), want: true},
+		{name: "end is exclusive", spec: "Mon-Fri 09:00-17:00 Europe/Paris", t: monday(17, 0, paris)},
+		{name: "other timezone", spec: "Mon-Fri 09:00-17:00 Europe/Paris", t: monday(8, 30, time.UTC), want: true},
+		{name: "wrong day", spec: "Sat,Sun 09:00-17:00", t: monday(10, 0, time.UTC)},
+		{name: "every day", spec: "02:00-04:00", t: monday(3, 0, time.UTC), want: true},
+		{name: "overnight from previous day", spec: "Sun 22:00-06:00", t: monday(5, 0, time.UTC), want: true},
+		{name: "overnight not started", spec: "Mon 22:00-06:00", t: monday(5, 0, time.UTC)},
+		{name: "wrapping days", spec: "Fri-Mon 00:00-00:00", t: monday(12, 0, time.UTC), want: true},
+		{name: "multiple windows", spec: "Sat 10:00-12:00; Mon 11:00-13:00", t: monday(12, 0, time.UTC), want: true},
+		{name: "invalid day", spec: "Someday 09:00-17:00", wantErr: true},
+		{name: "invalid time", spec: "09:00-25:00", wantErr: true},
+		{name: "invalid timezone", spec: "09:00-17:00 Mars/Olympus", wantErr: true},
+		{name: "missing range", spec: "Mon-Fri", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			windows, err := parseUpdateWindows(tt.spec)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseUpdateWindows() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if got := windows.open(tt.t); got != tt.want {
+				t.Errorf("open() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestUpdateWindowQueue(t *testing.T) {
+	// 2021-03-01 is a Monday
+	timeutil.Now = func() time.Time {
+		return time.Date(2021, 3, 1, 20, 0, 0, 0, time.UTC)
+	}
+	defer func() { timeutil.Now = time.Now }()
+
+	fp := &fakeImplementer{}
+	grc := &k8s.GenericResourceCache{}
+	grc.Add(MustParseGR(&apps_v1.Deployment{
+		meta_v1.TypeMeta{},
+		meta_v1.ObjectMeta{
+			Name:        "deployment-1",
+			Namespace:   "ns-1",
+			Labels:      map[string]string{types.KeelPolicyLabel: "all"},
+			Annotations: map[string]string{types.KeelUpdateWindowAnnotation: "Mon-Fri 22:00-23:00"},
+		},
+		apps_v1.DeploymentSpec{
+			Template: v1.PodTemplateSpec{
+				Spec: v1.PodSpec{
+					Containers: []v1.Container{
+						{
+							Image: "gcr.io/v2-namespace/hello-world:1.1.1",
+						},
+					},
+				},
+			},
+		},
+		apps_v1.DeploymentStatus{},
+	}))
+
+	approver, teardown := approver()
+	defer teardown()
+	provider, err := NewProvider(fp, &fakeSender{}, approver, grc)
+	if err != nil {
+		t.Fatalf("failed to get provider: %s", err)
+	}
+
+	event := &types.Event{Repository: types.Repository{
+		Name: "gcr.io/v2-namespace/hello-world",
+		Tag:  "1.1.2",
+	}}
+	_, err = provider.processEvent(event)
+	if err != nil {
+		t.Fatalf("got error while processing event: %s", err)
+	}
+
+	if fp.updated != nil {
+		t.Fatalf("resource shouldn't be updated outside of update window")
+	}
+
+	queued := provider.QueuedUpdates()
+	if len(queued) != 1 {
+		t.Fatalf("expected 1 queued update, got: %d", len(queued))
+	}
+	if queued[0].Name != "deployment-1" || queued[0].NewVersion != "1.1.2" {
+		t.Errorf("unexpected queued update: %+v", queued[0])
+	}
+
+	// still outside of the window
+	provider.processQueued()
+	if fp.updated != nil {
+		t.Fatalf("resource shouldn't be updated outside of update window")
+	}
+
+	timeutil.Now = func() time.Time {
+		return time.Date(2021, 3, 1, 22, 30, 0, 0, time.UTC)
+	}
+	provider.processQueued()
+
+	if fp.updated == nil {
+		t.Fatalf("expected queued update to be applied once window opened")
+	}
+	if fp.updated.Containers()[0].Image != "gcr.io/v2-namespace/hello-world:1.1.2" {
+		t.Errorf("unexpected image: %s", fp.updated.Containers()[0].Image)
+	}
+	if len(provider.QueuedUpdates()) != 0 {
+		t.Errorf("expected queue to be empty")
+	}
+}
+
+func TestUpdateWindowInvalidHeld(t *testing.T) {
+	timeutil.Now = func() time.Time {
+		return time.Date(2021, 3, 1, 20, 0, 0, 0, time.UTC)
+	}
+	defer func() { timeutil.Now = time.Now }()
+
+	deployment := func(window string) *k8s.GenericResource {
+		return MustParseGR(&apps_v1.Deployment{
+			meta_v1.TypeMeta{},
+			meta_v1.ObjectMeta{
+				Name:        "deployment-1",
+				Namespace:   "ns-1",
+				Labels:      map[string]string{types.KeelPolicyLabel: "all"},
+				Annotations: map[string]string{types.KeelUpdateWindowAnnotation: window},
+			},
+			apps_v1.DeploymentSpec{
+				Template: v1.PodTemplateSpec{
+					Spec: v1.PodSpec{
+						Containers: []v1.Container{
+							{
+								Image: "gcr.io/v2-namespace/hello-world:1.1.1",
+							},
+						},
+					},
+				},
+			},
+			apps_v1.DeploymentStatus{},
+		})
+	}
+
+	fp := &fakeImplementer{}
+	fs := &fakeSender{}
+	grc := &k8s.GenericResourceCache{}
+	grc.Add(deployment("09:00-17:00 Mars/Olympus"))
+
+	approver, teardown := approver()
+	defer teardown()
+	provider, err := NewProvider(fp, fs, approver, grc)
+	if err != nil {
+		t.Fatalf("failed to get provider: %s", err)
+	}
+
+	_, err = provider.processEvent(&types.Event{Repository: types.Repository{
+		Name: "gcr.io/v2-namespace/hello-world",
+		Tag:  "1.1.2",
+	}})
+	if err != nil {
+		t.Fatalf("got error while processing event: %s", err)
+	}
+
+	if fp.updated != nil {
+		t.Fatalf("resource with invalid update window shouldn't be updated")
+	}
+	if fs.sentEvent.Name != "invalid update window" || fs.sentEvent.Level != types.LevelWarn {
+		t.Errorf("expected invalid update window warning, got: %+v", fs.sentEvent)
+	}
+	if len(provider.QueuedUpdates()) != 1 {
+		t.Fatalf("expected update to be queued")
+	}
+
+	provider.processQueued()
+	if fp.updated != nil {
+		t.Fatalf("resource with invalid update window shouldn't be updated")
+	}
+
+	// window fixed
+	grc.Add(deployment("18:00-22:00"))
+	provider.processQueued()
+
+	if fp.updated == nil {
+		t.Fatalf("expected queued update to be applied once window was fixed")
+	}
+	if len(provider.QueuedUpdates()) != 0 {
+		t.Errorf("expected queue to be empty")
+	}
+}
@@ -2,8 +2,14 @@ package provider
 
 import (
 	"context"
+	"encoding/json"
+	"sync"
+
+	"github.com/Masterminds/semver"
 
 	"github.com/keel-hq/keel/approvals"
+	"github.com/keel-hq/keel/cache"
+	"github.com/keel-hq/keel/internal/policy"
 	"github.com/keel-hq/keel/types"
 
 	log "github.com/sirupsen/logrus"
@@ -23,6 +29,26 @@ type Providers interface {
 	TrackedImages() ([]*types.TrackedImage, error)
 	List() []string // list all providers
 	Stop()          // stop all providers
+
+	Pause()       // suspend updates, events are queued until resumed
+	Resume()      // resume updates and apply queued events
+	Paused() bool // whether updates are paused
+
+	QueuedUpdates() []*types.QueuedUpdate // updates waiting for update windows
+
+	Explain(event types.Event) []*types.UpdateDecision // what would be updated, nothing is applied
+}
+
+// UpdateQueuer - optional provider interface, implemented by providers that
+// hold updates until resource update window opens
+type UpdateQueuer interface {
+	QueuedUpdates() []*types.QueuedUpdate
+}
+
+// Explainer - optional provider interface, implemented by providers that
+// can report policy decisions for an event without applying it
+type Explainer interface {
+	Explain(event types.Event) []*types.UpdateDecision
 }
 
 // New - new providers registry
@@ -52,6 +78,97 @@ type DefaultProviders struct {
 	providers        map[string]Provider
 	approvalsManager approvals.Manager
 	stopCh           chan struct{}
+
+	mu     sync.Mutex
+	paused bool
+	// set on shutdown, new events are rejected
+	stopped bool
+	// events received while paused
+	queued []types.Event
+
+	// optional, when set events are only applied by the leader replica
+	isLeader func() bool
+
+	// optional, pause state and queued events are persisted so they survive restarts
+	cache cache.Cache
+}
+
+// pauseCacheKey - persisted pause state
+const pauseCacheKey = "providers/paused"
+
+type pauseState struct {
+	Paused bool          `json:"paused"`
+	Queued []types.Event `json:"queued,omitempty"`
+}
+
+// WithLeader - only apply updates while isLeader returns true. Standby
+// replicas fail readiness so webhooks and API calls are routed to the leader,
+// events still reaching them (ie: during failover) are dropped
+func (p *DefaultProviders) WithLeader(isLeader func() bool) *DefaultProviders {
+	p.isLeader = isLeader
+	return p
+}
+
+// WithCache - persist pause state and queued events, updates paused before
+// a restart (or leader failover with a shared cache) stay paused
+func (p *DefaultProviders) WithCache(c cache.Cache) *DefaultProviders {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.cache = c
+
+	encoded, err := c.Get(pauseCacheKey)
+	if err != nil {
+		if err != cache.ErrNotFound {
+			log.WithFields(log.Fields{
+				"error": err,
+			}).Error("provider.defaultProviders: failed to restore pause state")
+		}
+		return p
+	}
+
+	var state pauseState
+	err = json.Unmarshal(encoded, &state)
+	if err != nil {
+		log.WithFields(log.Fields{
+			"error": err,
+		}).Error("provider.defaultProviders: failed to decode pause state")
+		return p
+	}
+	if state.Paused {
+		p.paused = true
+		p.queued = state.Queued
+		log.WithFields(log.Fields{
+			"queued": len(p.queued),
+		}).Warn("provider.defaultProviders: updates paused before restart, still paused")
+	}
+	return p
+}
+
+// persist - stores pause state, has to be called holding mu
+func (p *DefaultProviders) persist() {
+	if p.cache == nil {
+		return
+	}
+
+	var err error
+	if p.paused {
+		var encoded []byte
+		encoded, err = json.Marshal(&pauseState{Paused: true, Queued: p.queued})
+		if err == nil {
+			err = p.cache.Put(pauseCacheKey, encoded)
+		}
+	} else {
+		err = p.cache.Delete(pauseCacheKey)
+		if err == cache.ErrNotFound {
+			err = nil
+		}
+	}
+	if err != nil {
+		log.WithFields(log.Fields{
+			"error": err,
+		}).Error("provider.defaultProviders: failed to persist pause state")
+	}
 }
 
 func (p *DefaultProviders) subscribeToApproved() {
@@ -77,8 +194,42 @@ func (p *DefaultProviders) subscribeToApproved() {
 
 }
 
-// Submit - submit event to all providers
+// Submit - submit event to all providers, events are queued while
+// updates are paused
 func (p *DefaultProviders) Submit(event types.Event) error {
+	if p.isLeader != nil && !p.isLeader() {
+		log.WithFields(log.Fields{
+			"event":   event.Repository,
+			"trigger": event.TriggerName,
+		}).Warn("provider.Submit: not a leader, event dropped")
+		return nil
+	}
+
+	p.mu.Lock()
+	if p.stopped {
+		p.mu.Unlock()
+		log.WithFields(log.Fields{
+			"event":   event.Repository,
+			"trigger": event.TriggerName,
+		}).Warn("provider.Submit: shutting down, event dropped")
+		return nil
+	}
+	if p.paused {
+		p.queue(event)
+		p.persist()
+		p.mu.Unlock()
+		log.WithFields(log.Fields{
+			"event":   event.Repository,
+			"trigger": event.TriggerName,
+		}).Info("provider.Submit: updates are paused, event queued")
+		return nil
+	}
+	p.mu.Unlock()
+
+	return p.submit(event)
+}
+
+func (p *DefaultProviders) submit(event types.Event) error {
 	for _, provider := range p.providers {
 		err := provider.Submit(event)
 		if err != nil {
@@ -121,9 +272,130 @@ func (p *DefaultProviders) List() []string {
 	return list
 }
 
-// Stop - stop all providers
+// Stop - stops accepting events and stops all providers, providers finish
+// updates that are already in progress before returning
 func (p *DefaultProviders) Stop() {
+	p.mu.Lock()
+	if p.stopped {
+		p.mu.Unlock()
+		return
+	}
+	p.stopped = true
+	p.mu.Unlock()
+
+	if p.stopCh != nil {
+		close(p.stopCh)
+	}
+
 	for _, provider := range p.providers {
 		provider.Stop()
 	}
 }
+
+// Pause - suspends updates, triggers keep running and their events
+// are queued until updates are resumed
+func (p *DefaultProviders) Pause() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.paused = true
+	p.persist()
+	log.Warn("provider.defaultProviders: updates paused")
+}
+
+// Resume - resumes updates and submits queued events
+func (p *DefaultProviders) Resume() {
+	p.mu.Lock()
+	p.paused = false
+	queued := p.queued
+	p.queued = nil
+	p.persist()
+	p.mu.Unlock()
+
+	log.WithFields(log.Fields{
+		"queued": len(queued),
+	}).Info("provider.defaultProviders: updates resumed")
+
+	for _, event := range latestQueued(queued) {
+		p.submit(event)
+	}
+}
+
+// Paused - returns true if updates are paused
+func (p *DefaultProviders) Paused() bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.paused
+}
+
+// QueuedUpdates - updates held back by providers until update windows open
+func (p *DefaultProviders) QueuedUpdates() []*types.QueuedUpdate {
+	queued := []*types.QueuedUpdate{}
+	for _, provider := range p.providers {
+		if q, ok := provider.(UpdateQueuer); ok {
+			queued = append(queued, q.QueuedUpdates()...)
+		}
+	}
+	return queued
+}
+
+// Explain - policy decisions of all providers for the event, nothing is applied
+func (p *DefaultProviders) Explain(event types.Event) []*types.UpdateDecision {
+	decisions := []*types.UpdateDecision{}
+	for _, provider := range p.providers {
+		if e, ok := provider.(Explainer); ok {
+			decisions = append(decisions, e.Explain(event)...)
+		}
+	}
+	return decisions
+}
+
+// queue - stores event, repeated events for the same tag are replaced
+// with the latest one (ie: new digest)
+func (p *DefaultProviders) queue(event types.Event) {
+	for i, e := range p.queued {
+		if e.Repository.Host == event.Repository.Host &&
+			e.Repository.Name == event.Repository.Name &&
+			e.Repository.Tag == event.Repository.Tag {
+			p.queued = append(p.queued[:i], p.queued[i+1:]...)
+			break
+		}
+	}
+	p.queued = append(p.queued, event)
+}
+
+// latestQueued - events replayed on resume: non-semver tags in the order they
+// arrived followed by the highest semver tag of every image. Lower versions are
+// dropped, policies accepting any tag (ie: force, glob) would otherwise apply
+// them after the newest one
+func latestQueued(events []types.Event) []types.Event {
+	var (
+		latest []types.Event
+		images []string
+	)
+	highest := make(map[string]types.Event)
+	versions := make(map[string]*semver.Version)
+
+	for _, event := range events {
+		v, err := semver.NewVersion(event.Repository.Tag)
+		if err != nil {
+			latest = append(latest, event)
+			continue
+		}
+
+		key := event.Chart + "|" + event.Repository.Host + "/" + event.Repository.Name + "|" + event.Target
+		current, ok := versions[key]
+		if !ok {
+			images = append(images, key)
+		}
+		if !ok || v.GreaterThan(current) ||
+			(v.Equal(current) && policy.CompareBuildMetadata(v.Metadata(), current.Metadata()) > 0) {
+			versions[key] = v
+			highest[key] = event
+		}
+	}
+
+	for _, key := range images {
+		latest = append(latest, highest[key])
+	}
+	return latest
+}
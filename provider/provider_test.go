@@ -0,0 +1,151 @@
+package provider
+
+import (
+	"testing"
+
+	"github.com/keel-hq/keel/cache/memory"
+	"github.com/keel-hq/keel/types"
+)
+
+type fakeProvider struct {
+	submitted []types.Event
+}
+
+func (p *fakeProvider) Submit(event types.Event) error {
+	p.submitted = append(p.submitted, event)
+	return nil
+}
+
+func (p *fakeProvider) TrackedImages() ([]*types.TrackedImage, error) {
+	return nil, nil
+}
+
+func (p *fakeProvider) GetName() string {
+	return "fp"
+}
+
+func (p *fakeProvider) Stop() {}
+
+func TestPauseResume(t *testing.T) {
+	fp := &fakeProvider{}
+	dp := &DefaultProviders{
+		providers: map[string]Provider{fp.GetName(): fp},
+	}
+
+	dp.Pause()
+	if !dp.Paused() {
+		t.Fatalf("expected updates to be paused")
+	}
+
+	events := []types.Event{
+		{Repository: types.Repository{Name: "karolisr/keel", Tag: "1.1.0"}},
+		{Repository: types.Repository{Name: "karolisr/keel", Tag: "latest", Digest: "sha256:aaa"}},
+		{Repository: types.Repository{Name: "karolisr/keel", Tag: "2.0.0"}},
+		{Repository: types.Repository{Name: "karolisr/keel", Tag: "latest", Digest: "sha256:bbb"}},
+		{Repository: types.Repository{Name: "karolisr/keel", Tag: "1.1.0"}},
+	}
+	for _, e := range events {
+		dp.Submit(e)
+	}
+
+	if len(fp.submitted) != 0 {
+		t.Fatalf("expected no events to be submitted while paused, got: %d", len(fp.submitted))
+	}
+
+	dp.Resume()
+	if dp.Paused() {
+		t.Fatalf("expected updates to be resumed")
+	}
+
+	// lower versions are dropped so they can't be applied after the newest one
+	if len(fp.submitted) != 2 {
+		t.Fatalf("expected 2 events after deduplication, got: %d", len(fp.submitted))
+	}
+
+	if fp.submitted[0].Repository.Digest != "sha256:bbb" {
+		t.Errorf("expected latest digest to be kept, got: %s", fp.submitted[0].Repository.Digest)
+	}
+	if fp.submitted[1].Repository.Tag != "2.0.0" {
+		t.Errorf("expected highest version last, got: %s", fp.submitted[1].Repository.Tag)
+	}
+
+	// not paused anymore, events go straight through
+	dp.Submit(types.Event{Repository: types.Repository{Name: "karolisr/keel", Tag: "2.0.1"}})
+	if len(fp.submitted) != 3 {
+		t.Errorf("expected event to be submitted, got: %d", len(fp.submitted))
+	}
+}
+
+func TestSubmitStandby(t *testing.T) {
+	fp := &fakeProvider{}
+	leader := false
+	dp := (&DefaultProviders{
+		providers: map[string]Provider{fp.GetName(): fp},
+	}).WithLeader(func() bool { return leader })
+
+	event := types.Event{Repository: types.Repository{Name: "karolisr/keel", Tag: "1.1.0"}}
+
+	dp.Submit(event)
+	if len(fp.submitted) != 0 {
+		t.Fatalf("expected standby replica to ignore events, got: %d", len(fp.submitted))
+	}
+
+	leader = true
+	dp.Submit(event)
+	if len(fp.submitted) != 1 {
+		t.Fatalf("expected leader to submit event, got: %d", len(fp.submitted))
+	}
+}
+
+func TestSubmitStopped(t *testing.T) {
+	fp := &fakeProvider{}
+	dp := &DefaultProviders{
+		providers: map[string]Provider{fp.GetName(): fp},
+	}
+
+	dp.Stop()
+	dp.Submit(types.Event{Repository: types.Repository{Name: "karolisr/keel", Tag: "1.1.0"}})
+	if len(fp.submitted) != 0 {
+		t.Errorf("expected events to be dropped after stop, got: %d", len(fp.submitted))
+	}
+
+	// stopping twice is a no-op
+	dp.Stop()
+}
+
+func TestPauseRestored(t *testing.T) {
+	c := memory.New()
+	dp := (&DefaultProviders{
+		providers: map[string]Provider{"fp": &fakeProvider{}},
+	}).WithCache(c)
+
+	dp.Pause()
+	dp.Submit(types.Event{Repository: types.Repository{Name: "karolisr/keel", Tag: "1.1.0"}})
+
+	// restarted
+	fp := &fakeProvider{}
+	dp = (&DefaultProviders{
+		providers: map[string]Provider{fp.GetName(): fp},
+	}).WithCache(c)
+
+	if !dp.Paused() {
+		t.Fatalf("expected updates to stay paused after restart")
+	}
+	dp.Submit(types.Event{Repository: types.Repository{Name: "karolisr/keel", Tag: "1.2.0"}})
+	if len(fp.submitted) != 0 {
+		t.Fatalf("expected no events to be submitted while paused, got: %d", len(fp.submitted))
+	}
+
+	dp.Resume()
+	if len(fp.submitted) != 1 || fp.submitted[0].Repository.Tag != "1.2.0" {
+		t.Errorf("expected highest queued version to be submitted, got: %+v", fp.submitted)
+	}
+
+	// resumed before restart
+	dp = (&DefaultProviders{
+		providers: map[string]Provider{fp.GetName(): fp},
+	}).WithCache(c)
+	if dp.Paused() {
+		t.Errorf("expected updates not to be paused after resume")
+	}
+}
@@ -0,0 +1,124 @@
+package registry
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/rusenask/docker-registry-client/registry"
+)
+
+type imageManifest struct {
+	MediaType string `json:"mediaType"`
+	Config    struct {
+		Digest string `json:"digest"`
+	} `json:"config"`
+	Manifests []struct {
+		Digest   string   `json:"digest"`
+		Platform Platform `json:"platform"`
+	} `json:"manifests"`
+}
+
+type imageConfig struct {
+	Created time.Time `json:"created"`
+}
+
+// Created - get image creation time from the image config blob, manifest lists
+// are resolved to the configured platform (linux/amd64 by default)
+func (c *DefaultClient) Created(opts Opts) (time.Time, error) {
+	if opts.Tag == "" {
+		return time.Time{}, ErrTagNotSupplied
+	}
+	opts = c.mirrors.rewrite(opts)
+
+	// fallback to HTTP if the registry doesn't speak HTTPS https://github.com/keel-hq/keel/issues/331
+INIT_CLIENT:
+	hub, err := c.getRegistryClient(opts.Registry, opts.Username, opts.Password)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	created, err := imageCreated(hub, opts.Name, opts.Tag, c.platform)
+	if err != nil {
+		if strings.Contains(err.Error(), "server gave HTTP response to HTTPS client") && strings.HasPrefix(opts.Registry, "https://") && c.transport.isInsecure(registryHost(opts.Registry)) {
+			opts.Registry = strings.Replace(opts.Registry, "https://", "http://", 1)
+			goto INIT_CLIENT
+		}
+		return time.Time{}, err
+	}
+
+	return created, nil
+}
+
+func imageCreated(hub *registry.Registry, name, tag string, platform *Platform) (time.Time, error) {
+	manifest, err := getImageManifest(hub, name, tag)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	if len(manifest.Manifests) > 0 {
+		if platform == nil {
+			platform, _ = ParsePlatform(defaultPlatform)
+		}
+		// falling back to the first image if the platform isn't in the list
+		reference := manifest.Manifests[0].Digest
+		for _, m := range manifest.Manifests {
+			if platform.matches(m.Platform) {
+				reference = m.Digest
+				break
+			}
+		}
+		manifest, err = getImageManifest(hub, name, reference)
+		if err != nil {
+			return time.Time{}, err
+		}
+	}
+
+	if manifest.Config.Digest == "" {
+		return time.Time{}, fmt.Errorf("manifest of %s:%s doesn't reference an image config", name, tag)
+	}
+
+	url := fmt.Sprintf("%s/v2/%s/blobs/%s", strings.TrimSuffix(hub.URL, "/"), name, manifest.Config.Digest)
+	resp, err := hub.Client.Get(url)
+	if err != nil {
+		return time.Time{}, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return time.Time{}, fmt.Errorf("failed to get image config, registry returned status code %d", resp.StatusCode)
+	}
+
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	var config imageConfig
+	err = json.Unmarshal(body, &config)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("failed to decode image config: %s", err)
+	}
+	if config.Created.IsZero() {
+		return time.Time{}, fmt.Errorf("image config of %s:%s has no creation time", name, tag)
+	}
+
+	return config.Created, nil
+}
+
+func getImageManifest(hub *registry.Registry, name, reference string) (*imageManifest, error) {
+	body, _, err := fetchManifest(hub, name, reference)
+	if err != nil {
+		return nil, err
+	}
+
+	var manifest imageManifest
+	err = json.Unmarshal(body, &manifest)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode manifest: %s", err)
+	}
+	return &manifest, nil
+}
@@ -0,0 +1,53 @@
+package registry
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestCreated(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/v2/foo/bar/manifests/master-1":
+			w.Header().Set("Content-Type", "application/vnd.docker.distribution.manifest.list.v2+json")
+			fmt.Fprint(w, manifestListResp)
+		case "/v2/foo/bar/manifests/sha256:1111111111111111111111111111111111111111111111111111111111111111",
+			"/v2/foo/bar/manifests/master-2":
+			w.Header().Set("Content-Type", "application/vnd.docker.distribution.manifest.v2+json")
+			fmt.Fprint(w, schema2Manifest)
+		case "/v2/foo/bar/blobs/sha256:5d0da3dc976460b72c77d94c8a1ad043720b0416bfc16c52c45d4847e53fadb6":
+			fmt.Fprint(w, `{"architecture": "amd64", "created": "2020-06-01T10:00:00.123Z", "os": "linux"}`)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer ts.Close()
+
+	expected := time.Date(2020, time.June, 1, 10, 0, 0, 123000000, time.UTC)
+	client := New()
+	for _, tag := range []string{"master-1", "master-2"} {
+		created, err := client.Created(Opts{
+			Registry: ts.URL,
+			Name:     "foo/bar",
+			Tag:      tag,
+		})
+		if err != nil {
+			t.Fatalf("%s: failed to get creation time: %s", tag, err)
+		}
+		if !created.Equal(expected) {
+			t.Errorf("%s: unexpected creation time: %s", tag, created)
+		}
+	}
+
+	_, err := client.Created(Opts{
+		Registry: ts.URL,
+		Name:     "foo/bar",
+		Tag:      "missing",
+	})
+	if err == nil {
+		t.Errorf("expected error for missing tag")
+	}
+}
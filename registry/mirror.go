@@ -0,0 +1,96 @@
+package registry
+
+import (
+	"os"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// EnvMirrors - comma separated list of registry mirrors, images are polled through
+// the mirror while workloads keep original image names. Mirror can include scheme and
+// path prefix for pull-through caches that serve upstream under a project,
+// ie: docker.io=mirror.local:5000,quay.io=https://harbor.local/quay-proxy
+const EnvMirrors = "REGISTRY_MIRRORS"
+
+// mirror - registry address with optional repository prefix
+type mirror struct {
+	scheme string // keeps original scheme if empty
+	host   string
+	prefix string
+}
+
+type mirrors map[string]mirror
+
+func mirrorsFromEnv() mirrors {
+	m := make(mirrors)
+
+	for _, entry := range strings.Split(os.Getenv(EnvMirrors), ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		parts := strings.SplitN(entry, "=", 2)
+		if len(parts) != 2 || strings.TrimSpace(parts[0]) == "" || strings.TrimSpace(parts[1]) == "" {
+			log.WithFields(log.Fields{
+				"entry": entry,
+			}).Error("registry client: invalid registry mirror entry, expected <registry>=<mirror>")
+			continue
+		}
+
+		var mr mirror
+		address := strings.TrimSpace(parts[1])
+		if i := strings.Index(address, "://"); i > 0 {
+			mr.scheme = address[:i]
+			address = address[i+3:]
+		}
+		address = strings.Trim(address, "/")
+		if i := strings.Index(address, "/"); i > 0 {
+			mr.host = address[:i]
+			mr.prefix = address[i+1:]
+		} else {
+			mr.host = address
+		}
+
+		upstream := registryHost(strings.TrimSpace(parts[0]))
+		m[upstream] = mr
+		// images from Docker Hub are referenced with index.docker.io
+		if upstream == "docker.io" {
+			m["index.docker.io"] = mr
+		}
+	}
+
+	return m
+}
+
+// rewrite - points registry opts at the mirror if upstream registry has one
+func (m mirrors) rewrite(opts Opts) Opts {
+	upstream := registryHost(opts.Registry)
+	mr, ok := m[upstream]
+	if !ok {
+		return opts
+	}
+
+	scheme := mr.scheme
+	if scheme == "" {
+		scheme = "https"
+		if strings.HasPrefix(opts.Registry, "http://") {
+			scheme = "http"
+		}
+	}
+
+	rewritten := opts
+	rewritten.Registry = scheme + "://" + mr.host
+	if mr.prefix != "" {
+		rewritten.Name = mr.prefix + "/" + opts.Name
+	}
+
+	log.WithFields(log.Fields{
+		"registry": opts.Registry,
+		"name":     opts.Name,
+		"mirror":   rewritten.Registry,
+		"mirrored": rewritten.Name,
+	}).Debug("registry client: using registry mirror")
+
+	return rewritten
+}
@@ -0,0 +1,172 @@
+package registry
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/opencontainers/go-digest"
+	"github.com/rusenask/docker-registry-client/registry"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// EnvPlatform - platform (os/arch[/variant]) whose manifest digest is tracked
+// for multi-arch images, defaults to linux/amd64. Set to "index" to track
+// manifest list digests instead
+const EnvPlatform = "REGISTRY_PLATFORM"
+
+const (
+	defaultPlatform = "linux/amd64"
+	indexPlatform   = "index"
+)
+
+// Platform - image platform from manifest list
+type Platform struct {
+	OS           string `json:"os"`
+	Architecture string `json:"architecture"`
+	Variant      string `json:"variant,omitempty"`
+}
+
+func (p Platform) String() string {
+	if p.Variant != "" {
+		return p.OS + "/" + p.Architecture + "/" + p.Variant
+	}
+	return p.OS + "/" + p.Architecture
+}
+
+// ParsePlatform - parses os/arch[/variant]
+func ParsePlatform(platform string) (*Platform, error) {
+	parts := strings.Split(strings.TrimSpace(platform), "/")
+	if len(parts) < 2 || len(parts) > 3 || parts[0] == "" || parts[1] == "" {
+		return nil, fmt.Errorf("invalid platform %q, expected os/arch[/variant]", platform)
+	}
+	p := &Platform{OS: parts[0], Architecture: parts[1]}
+	if len(parts) == 3 {
+		p.Variant = parts[2]
+	}
+	return p, nil
+}
+
+// matches - variant is only compared if configured
+func (p Platform) matches(other Platform) bool {
+	if p.OS != other.OS || p.Architecture != other.Architecture {
+		return false
+	}
+	return p.Variant == "" || p.Variant == other.Variant
+}
+
+// platformFromEnv - nil if manifest list digests should be tracked
+func platformFromEnv() *Platform {
+	value := os.Getenv(EnvPlatform)
+	if value == "" {
+		value = defaultPlatform
+	}
+	if value == indexPlatform {
+		return nil
+	}
+
+	p, err := ParsePlatform(value)
+	if err != nil {
+		log.WithFields(log.Fields{
+			"error":    err,
+			"platform": value,
+		}).Errorf("registry client: invalid platform, using %s", defaultPlatform)
+		p, _ = ParsePlatform(defaultPlatform)
+	}
+	return p
+}
+
+type manifestList struct {
+	MediaType string `json:"mediaType"`
+	Manifests []struct {
+		Digest   string   `json:"digest"`
+		Platform Platform `json:"platform"`
+	} `json:"manifests"`
+}
+
+func isManifestList(mediaType string) bool {
+	return mediaType == "application/vnd.docker.distribution.manifest.list.v2+json" ||
+		mediaType == "application/vnd.oci.image.index.v1+json"
+}
+
+// platformDigest - digest of the manifest for platform, when tag points to a manifest
+// list only the platform specific manifest is compared so rebuilding unrelated
+// architectures doesn't trigger updates. Falls back to manifest list digest if the
+// platform isn't in the list or platform is nil. Digest is computed from the manifest
+// if registry header can't be trusted or is missing
+func platformDigest(hub *registry.Registry, name, tag string, platform *Platform, trustHeader bool) (string, error) {
+	body, header, err := fetchManifest(hub, name, tag)
+	if err != nil {
+		return "", err
+	}
+
+	manifestDigest := digest.FromBytes(body).String()
+	if trustHeader && header.Get("Docker-Content-Digest") != "" {
+		if d, err := digest.Parse(header.Get("Docker-Content-Digest")); err == nil {
+			manifestDigest = d.String()
+		}
+	}
+
+	if platform == nil {
+		return manifestDigest, nil
+	}
+
+	var list manifestList
+	if err := json.Unmarshal(body, &list); err != nil {
+		// not a manifest list, nothing to resolve
+		return manifestDigest, nil
+	}
+
+	mediaType := list.MediaType
+	if mediaType == "" {
+		mediaType = strings.TrimSpace(strings.Split(header.Get("Content-Type"), ";")[0])
+	}
+	if !isManifestList(mediaType) {
+		return manifestDigest, nil
+	}
+
+	for _, m := range list.Manifests {
+		if platform.matches(m.Platform) {
+			return m.Digest, nil
+		}
+	}
+
+	log.WithFields(log.Fields{
+		"name":     name,
+		"tag":      tag,
+		"platform": platform.String(),
+	}).Debug("registry client: platform not found in manifest list, using manifest list digest")
+
+	return manifestDigest, nil
+}
+
+// fetchManifest - gets manifest accepting both manifests and manifest lists
+func fetchManifest(hub *registry.Registry, name, tag string) ([]byte, http.Header, error) {
+	url := fmt.Sprintf("%s/v2/%s/manifests/%s", strings.TrimSuffix(hub.URL, "/"), name, tag)
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		return nil, nil, err
+	}
+	req.Header.Set("Accept", strings.Join(manifestMediaTypes, ", "))
+
+	resp, err := hub.Client.Do(req)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, nil, fmt.Errorf("failed to get manifest, registry returned status code %d", resp.StatusCode)
+	}
+
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return body, resp.Header, nil
+}
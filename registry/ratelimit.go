@@ -0,0 +1,209 @@
+package registry
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/rusenask/docker-registry-client/registry"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// backoff bounds for rate limited registries, doubles with every
+// consecutive 429 unless registry asks for a longer wait with Retry-After
+const (
+	minBackoff = time.Minute
+	maxBackoff = time.Hour
+)
+
+var registryRateLimitRemaining = prometheus.NewGaugeVec(
+	prometheus.GaugeOpts{
+		Name: "registry_rate_limit_remaining",
+		Help: "Remaining requests reported by registry RateLimit-Remaining header, partitioned by registry.",
+	},
+	[]string{"registry"},
+)
+
+var registryRateLimitLimit = prometheus.NewGaugeVec(
+	prometheus.GaugeOpts{
+		Name: "registry_rate_limit_limit",
+		Help: "Request limit reported by registry RateLimit-Limit header, partitioned by registry.",
+	},
+	[]string{"registry"},
+)
+
+var registryRateLimitedCounter = prometheus.NewCounterVec(
+	prometheus.CounterOpts{
+		Name: "registry_rate_limited_total",
+		Help: "How many times registry responded with 429 Too Many Requests, partitioned by registry.",
+	},
+	[]string{"registry"},
+)
+
+func init() {
+	prometheus.MustRegister(registryRateLimitRemaining)
+	prometheus.MustRegister(registryRateLimitLimit)
+	prometheus.MustRegister(registryRateLimitedCounter)
+}
+
+// RateLimitedError - returned without querying the registry while it's
+// backing off after 429 Too Many Requests
+type RateLimitedError struct {
+	Registry string
+	Until    time.Time
+}
+
+func (e *RateLimitedError) Error() string {
+	return fmt.Sprintf("registry %s is rate limited, next request allowed at %s", e.Registry, e.Until.Format(time.RFC3339))
+}
+
+// IsRateLimited - whether registry call was skipped because of rate limiting
+func IsRateLimited(err error) bool {
+	var rateLimited *RateLimitedError
+	return errors.As(err, &rateLimited)
+}
+
+type backoff struct {
+	until    time.Time
+	failures int
+}
+
+// rateLimiter - tracks rate limited registries, stretching their poll
+// frequency until backoff expires
+type rateLimiter struct {
+	mu       sync.Mutex
+	backoffs map[string]*backoff
+	now      func() time.Time
+}
+
+func newRateLimiter() *rateLimiter {
+	return &rateLimiter{
+		backoffs: make(map[string]*backoff),
+		now:      time.Now,
+	}
+}
+
+// check - returns error if registry is still backing off
+func (l *rateLimiter) check(host string) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	b, ok := l.backoffs[host]
+	if ok && l.now().Before(b.until) {
+		return &RateLimitedError{Registry: host, Until: b.until}
+	}
+	return nil
+}
+
+// limited - registers 429 response, returns how long requests will be skipped
+func (l *rateLimiter) limited(host string, header http.Header) time.Duration {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	b, ok := l.backoffs[host]
+	if !ok {
+		b = &backoff{}
+		l.backoffs[host] = b
+	}
+	b.failures++
+
+	wait := minBackoff
+	for i := 1; i < b.failures && wait < maxBackoff; i++ {
+		wait *= 2
+	}
+	if retryAfter := parseRetryAfter(header.Get("Retry-After"), l.now()); retryAfter > wait {
+		wait = retryAfter
+	}
+	if wait > maxBackoff {
+		wait = maxBackoff
+	}
+
+	b.until = l.now().Add(wait)
+	return wait
+}
+
+// ok - registry responded, resetting backoff
+func (l *rateLimiter) ok(host string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	delete(l.backoffs, host)
+}
+
+// parseRetryAfter - Retry-After is either delay in seconds or HTTP date
+func parseRetryAfter(value string, now time.Time) time.Duration {
+	if value == "" {
+		return 0
+	}
+	if seconds, err := strconv.Atoi(value); err == nil {
+		return time.Duration(seconds) * time.Second
+	}
+	if date, err := http.ParseTime(value); err == nil {
+		return date.Sub(now)
+	}
+	return 0
+}
+
+// parseRateLimit - parses RateLimit-* header value, ie: "76;w=21600"
+func parseRateLimit(value string) (float64, bool) {
+	if value == "" {
+		return 0, false
+	}
+	n, err := strconv.ParseFloat(strings.TrimSpace(strings.Split(value, ";")[0]), 64)
+	if err != nil {
+		return 0, false
+	}
+	return n, true
+}
+
+func observeRateLimit(host string, header http.Header) {
+	if remaining, ok := parseRateLimit(header.Get("RateLimit-Remaining")); ok {
+		registryRateLimitRemaining.With(prometheus.Labels{"registry": host}).Set(remaining)
+	}
+	if limit, ok := parseRateLimit(header.Get("RateLimit-Limit")); ok {
+		registryRateLimitLimit.With(prometheus.Labels{"registry": host}).Set(limit)
+	}
+}
+
+// rateLimitTransport - wraps registry client transport, registries that are
+// backing off are not queried at all
+type rateLimitTransport struct {
+	Transport http.RoundTripper
+	host      string
+	limiter   *rateLimiter
+}
+
+func (t *rateLimitTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if err := t.limiter.check(t.host); err != nil {
+		return nil, err
+	}
+
+	resp, err := t.Transport.RoundTrip(req)
+	if err != nil {
+		// registry client turns error responses into errors
+		statusErr, ok := err.(*registry.HttpStatusError)
+		if !ok {
+			return resp, err
+		}
+		observeRateLimit(t.host, statusErr.Response.Header)
+		if statusErr.Response.StatusCode == http.StatusTooManyRequests {
+			registryRateLimitedCounter.With(prometheus.Labels{"registry": t.host}).Inc()
+			wait := t.limiter.limited(t.host, statusErr.Response.Header)
+			log.WithFields(log.Fields{
+				"registry": t.host,
+				"backoff":  wait.String(),
+			}).Warn("registry client: rate limited, backing off")
+		}
+		return resp, err
+	}
+
+	observeRateLimit(t.host, resp.Header)
+	t.limiter.ok(t.host)
+
+	return resp, nil
+}
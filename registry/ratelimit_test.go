@@ -0,0 +1,95 @@
+package registry
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestDigestRateLimited(t *testing.T) {
+	var requests int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/v2/" {
+			w.WriteHeader(http.StatusOK)
+			return
+		}
+		atomic.AddInt32(&requests, 1)
+		w.Header().Set("RateLimit-Limit", "100;w=21600")
+		w.Header().Set("RateLimit-Remaining", "0;w=21600")
+		w.Header().Set("Retry-After", "120")
+		w.WriteHeader(http.StatusTooManyRequests)
+	}))
+	defer ts.Close()
+
+	client := New()
+	opts := Opts{
+		Registry: ts.URL,
+		Name:     "foo/bar",
+		Tag:      "1.0.0",
+	}
+
+	_, err := client.Digest(opts)
+	if err == nil {
+		t.Fatalf("expected error")
+	}
+	if IsRateLimited(err) {
+		t.Errorf("first request should reach registry")
+	}
+
+	_, err = client.Digest(opts)
+	if !IsRateLimited(err) {
+		t.Errorf("expected rate limited error, got: %v", err)
+	}
+	if requests != 1 {
+		t.Errorf("expected registry to be queried once while backing off, got: %d", requests)
+	}
+}
+
+func TestRateLimiterBackoff(t *testing.T) {
+	now := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	l := newRateLimiter()
+	l.now = func() time.Time { return now }
+
+	host := "index.docker.io"
+
+	for _, expected := range []time.Duration{time.Minute, 2 * time.Minute, 4 * time.Minute} {
+		if wait := l.limited(host, http.Header{}); wait != expected {
+			t.Errorf("expected backoff %s, got: %s", expected, wait)
+		}
+	}
+
+	if err := l.check(host); err == nil {
+		t.Errorf("expected registry to be backing off")
+	}
+
+	now = now.Add(5 * time.Minute)
+	if err := l.check(host); err != nil {
+		t.Errorf("expected backoff to expire, got: %s", err)
+	}
+
+	// longer Retry-After wins, capped to max backoff
+	l.ok(host)
+	if wait := l.limited(host, http.Header{"Retry-After": []string{"600"}}); wait != 10*time.Minute {
+		t.Errorf("expected Retry-After to be used, got: %s", wait)
+	}
+	if wait := l.limited(host, http.Header{"Retry-After": []string{"86400"}}); wait != maxBackoff {
+		t.Errorf("expected max backoff, got: %s", wait)
+	}
+}
+
+func TestParseRateLimit(t *testing.T) {
+	for value, expected := range map[string]float64{
+		"76;w=21600": 76,
+		"100":        100,
+	} {
+		n, ok := parseRateLimit(value)
+		if !ok || n != expected {
+			t.Errorf("parseRateLimit(%q) = %v, %v", value, n, ok)
+		}
+	}
+	if _, ok := parseRateLimit("invalid"); ok {
+		t.Errorf("expected invalid value to be ignored")
+	}
+}
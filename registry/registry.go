@@ -3,9 +3,11 @@ package registry
 import (
 	"errors"
 	"hash/fnv"
+	"net/http"
 	"os"
 	"strings"
 	"sync"
+	"time"
 
 	"github.com/rusenask/docker-registry-client/registry"
 
@@ -15,11 +17,44 @@ import (
 // EnvInsecure - uses insecure registry client to skip cert verification
 const EnvInsecure = "INSECURE_REGISTRY"
 
+// EnvUntrustedDigestRegistries - comma separated list of registry hosts (ie: registry.local:5000)
+// that return missing or inconsistent Docker-Content-Digest headers, digests for images from
+// these registries are computed from the manifest instead
+const EnvUntrustedDigestRegistries = "UNTRUSTED_DIGEST_REGISTRIES"
+
+// manifest media types accepted when fetching manifests to compute digests
+var manifestMediaTypes = []string{
+	"application/vnd.docker.distribution.manifest.list.v2+json",
+	"application/vnd.docker.distribution.manifest.v2+json",
+	"application/vnd.oci.image.index.v1+json",
+	"application/vnd.oci.image.manifest.v1+json",
+}
+
 // errors
 var (
 	ErrTagNotSupplied = errors.New("tag not supplied")
 )
 
+// IsUnauthorized - whether registry rejected supplied credentials, short lived
+// tokens (ie: ECR) should be refreshed before retrying
+func IsUnauthorized(err error) bool {
+	var statusErr *registry.HttpStatusError
+	if !errors.As(err, &statusErr) || statusErr.Response == nil {
+		return false
+	}
+	return statusErr.Response.StatusCode == http.StatusUnauthorized
+}
+
+// StatusCode - returns HTTP status code of a failed registry request, 0 if
+// request failed before registry responded
+func StatusCode(err error) int {
+	var statusErr *registry.HttpStatusError
+	if !errors.As(err, &statusErr) || statusErr.Response == nil {
+		return 0
+	}
+	return statusErr.Response.StatusCode
+}
+
 // Repository - holds repository related info
 type Repository struct {
 	Name string
@@ -30,18 +65,28 @@ type Repository struct {
 type Client interface {
 	Get(opts Opts) (*Repository, error)
 	Digest(opts Opts) (string, error)
+	Created(opts Opts) (time.Time, error)
 }
 
 // New - new registry client
 func New() *DefaultClient {
-	insecure := false
-	if os.Getenv(EnvInsecure) == "true" {
-		insecure = true
+	untrusted := make(map[string]bool)
+	for _, host := range strings.Split(os.Getenv(EnvUntrustedDigestRegistries), ",") {
+		host = strings.TrimSpace(host)
+		if host != "" {
+			untrusted[registryHost(host)] = true
+		}
 	}
+
 	return &DefaultClient{
-		mu:         &sync.Mutex{},
-		registries: make(map[uint32]*registry.Registry),
-		insecure:   insecure,
+		mu:                      &sync.Mutex{},
+		registries:              make(map[uint32]*registry.Registry),
+		transport:               transportOptsFromEnv(),
+		mirrors:                 mirrorsFromEnv(),
+		platform:                platformFromEnv(),
+		untrustedDigestRegistry: untrusted,
+		rateLimiter:             newRateLimiter(),
+		tokenRealms:             tokenRealmsFromEnv(),
 	}
 }
 
@@ -50,7 +95,24 @@ type DefaultClient struct {
 	// a map of registries to reuse for polling
 	mu         *sync.Mutex
 	registries map[uint32]*registry.Registry
-	insecure   bool
+
+	// custom CAs, insecure registries and proxies
+	transport transportOpts
+
+	// upstream registries polled through mirrors
+	mirrors mirrors
+
+	// platform to resolve manifest lists for, nil to track manifest list digests
+	platform *Platform
+
+	// registries that can't be trusted to return correct digest headers
+	untrustedDigestRegistry map[string]bool
+
+	// registries backing off after 429 responses
+	rateLimiter *rateLimiter
+
+	// token endpoints replacing ones announced by registries, by registry host
+	tokenRealms map[string]string
 }
 
 // Opts - registry client opts. If username & password are not supplied
@@ -84,13 +146,25 @@ func (c *DefaultClient) getRegistryClient(registryAddress, username, password st
 	}
 
 	url := strings.TrimSuffix(registryAddress, "/")
-	if os.Getenv(EnvInsecure) == "true" {
-		r = registry.NewInsecure(url, username, password)
-	} else {
-		r = registry.New(url, username, password)
+	transport, err := c.transport.newTransport(registryHost(url))
+	if err != nil {
+		return nil, err
 	}
 
-	r.Logf = LogFormatter
+	username, password = robotCredentials(registryHost(url), username, password)
+
+	r = &registry.Registry{
+		URL: url,
+		Client: &http.Client{
+			Transport: c.wrapTransport(transport, url, username, password),
+		},
+		Logf: LogFormatter,
+	}
+	r.Client.Transport = &rateLimitTransport{
+		Transport: r.Client.Transport,
+		host:      registryHost(url),
+		limiter:   c.rateLimiter,
+	}
 
 	c.registries[h] = r
 
@@ -99,6 +173,7 @@ func (c *DefaultClient) getRegistryClient(registryAddress, username, password st
 
 // Get - get repository
 func (c *DefaultClient) Get(opts Opts) (*Repository, error) {
+	opts = c.mirrors.rewrite(opts)
 
 	// fallback to HTTP if the registry doesn't speak HTTPS https://github.com/keel-hq/keel/issues/331
 INIT_CLIENT:
@@ -107,9 +182,9 @@ INIT_CLIENT:
 		return nil, err
 	}
 
-	tags, err := hub.Tags(opts.Name)
+	tags, err := listTags(hub, opts.Name)
 	if err != nil {
-		if strings.Contains(err.Error(), "server gave HTTP response to HTTPS client") && strings.HasPrefix(opts.Registry, "https://") && c.insecure {
+		if strings.Contains(err.Error(), "server gave HTTP response to HTTPS client") && strings.HasPrefix(opts.Registry, "https://") && c.transport.isInsecure(registryHost(opts.Registry)) {
 			opts.Registry = strings.Replace(opts.Registry, "https://", "http://", 1)
 			goto INIT_CLIENT
 		}
@@ -127,6 +202,7 @@ func (c *DefaultClient) Digest(opts Opts) (string, error) {
 	if opts.Tag == "" {
 		return "", ErrTagNotSupplied
 	}
+	opts = c.mirrors.rewrite(opts)
 
 	// fallback to HTTP if the registry doesn't speak HTTPS https://github.com/keel-hq/keel/issues/331
 INIT_CLIENT:
@@ -135,14 +211,21 @@ INIT_CLIENT:
 		return "", err
 	}
 
-	manifestDigest, err := hub.ManifestDigest(opts.Name, opts.Tag)
+	manifestDigest, err := platformDigest(hub, opts.Name, opts.Tag, c.platform, !c.untrustedDigestRegistry[registryHost(opts.Registry)])
 	if err != nil {
-		if strings.Contains(err.Error(), "server gave HTTP response to HTTPS client") && strings.HasPrefix(opts.Registry, "https://") && c.insecure {
+		if strings.Contains(err.Error(), "server gave HTTP response to HTTPS client") && strings.HasPrefix(opts.Registry, "https://") && c.transport.isInsecure(registryHost(opts.Registry)) {
 			opts.Registry = strings.Replace(opts.Registry, "https://", "http://", 1)
 			goto INIT_CLIENT
 		}
 		return "", err
 	}
 
-	return manifestDigest.String(), nil
+	return manifestDigest, nil
+}
+
+// registryHost - strips scheme and trailing slash from registry address
+func registryHost(address string) string {
+	address = strings.TrimPrefix(address, "https://")
+	address = strings.TrimPrefix(address, "http://")
+	return strings.TrimSuffix(address, "/")
 }
@@ -1,12 +1,16 @@
 package registry
 
 import (
+	"encoding/pem"
+	"io/ioutil"
 	"net/http"
 	"net/http/httptest"
+	"path/filepath"
 	"strings"
 
 	"github.com/keel-hq/keel/constants"
 
+	"github.com/opencontainers/go-digest"
 	"github.com/rusenask/docker-registry-client/registry"
 
 	"fmt"
@@ -313,3 +317,511 @@ func TestGetDockerHubManyTags(t *testing.T) {
 	}
 	fmt.Println(tags)
 }
+
+var schema2Manifest = `{
+  "schemaVersion": 2,
+  "mediaType": "application/vnd.docker.distribution.manifest.v2+json",
+  "config": {
+    "mediaType": "application/vnd.docker.container.image.v1+json",
+    "size": 1512,
+    "digest": "sha256:5d0da3dc976460b72c77d94c8a1ad043720b0416bfc16c52c45d4847e53fadb6"
+  },
+  "layers": [
+    {
+      "mediaType": "application/vnd.docker.image.rootfs.diff.tar.gzip",
+      "size": 2813316,
+      "digest": "sha256:cbdbe7a5bc2a134ca8ec91be58565ec07d037386d1f1d8385412d224deafca08"
+    }
+  ]
+}`
+
+// newManifestServer - registry that serves manifest with the supplied digest header,
+// empty header value means that the header is not set at all
+func newManifestServer(digestHeader string) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/v2/" {
+			w.WriteHeader(http.StatusOK)
+			return
+		}
+		if r.URL.Path != "/v2/foo/bar/manifests/1.0.0" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Header().Set("Content-Type", "application/vnd.docker.distribution.manifest.v2+json")
+		if digestHeader != "" {
+			w.Header().Set("Docker-Content-Digest", digestHeader)
+		}
+		if r.Method == http.MethodHead {
+			return
+		}
+		fmt.Fprint(w, schema2Manifest)
+	}))
+}
+
+func TestDigestMissingHeader(t *testing.T) {
+	ts := newManifestServer("")
+	defer ts.Close()
+
+	client := New()
+	d, err := client.Digest(Opts{
+		Registry: ts.URL,
+		Name:     "foo/bar",
+		Tag:      "1.0.0",
+	})
+	if err != nil {
+		t.Fatalf("error while getting digest: %s", err)
+	}
+
+	expected := digest.FromBytes([]byte(schema2Manifest)).String()
+	if d != expected {
+		t.Errorf("unexpected digest: %s, expected: %s", d, expected)
+	}
+}
+
+func TestDigestUntrustedRegistry(t *testing.T) {
+	// registry returns digest that doesn't match the manifest
+	ts := newManifestServer("sha256:0604af35299dd37ff23937d115d103532948b568a9dd8197d14c256a8ab8b0bb")
+	defer ts.Close()
+
+	os.Setenv(EnvUntrustedDigestRegistries, strings.TrimPrefix(ts.URL, "http://"))
+	defer os.Unsetenv(EnvUntrustedDigestRegistries)
+
+	client := New()
+	d, err := client.Digest(Opts{
+		Registry: ts.URL,
+		Name:     "foo/bar",
+		Tag:      "1.0.0",
+	})
+	if err != nil {
+		t.Fatalf("error while getting digest: %s", err)
+	}
+
+	expected := digest.FromBytes([]byte(schema2Manifest)).String()
+	if d != expected {
+		t.Errorf("unexpected digest: %s, expected: %s", d, expected)
+	}
+}
+
+func TestDigestUnauthorized(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusUnauthorized)
+	}))
+	defer ts.Close()
+
+	client := New()
+	_, err := client.Digest(Opts{
+		Registry: ts.URL,
+		Name:     "foo/bar",
+		Tag:      "1.0.0",
+		Username: "AWS",
+		Password: "expired",
+	})
+	if !IsUnauthorized(err) {
+		t.Errorf("expected unauthorized error, got: %v", err)
+	}
+
+	if IsUnauthorized(fmt.Errorf("connection refused")) {
+		t.Errorf("didn't expect unauthorized error")
+	}
+
+	if IsUnauthorized(&registry.HttpStatusError{}) {
+		t.Errorf("didn't expect unauthorized error without response")
+	}
+}
+
+func TestStatusCode(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer ts.Close()
+
+	client := New()
+	_, err := client.Digest(Opts{
+		Registry: ts.URL,
+		Name:     "foo/bar",
+		Tag:      "1.0.0",
+	})
+	if code := StatusCode(err); code != http.StatusServiceUnavailable {
+		t.Errorf("expected status code %d, got: %d (%v)", http.StatusServiceUnavailable, code, err)
+	}
+
+	if code := StatusCode(fmt.Errorf("connection refused")); code != 0 {
+		t.Errorf("expected no status code, got: %d", code)
+	}
+}
+
+func newTLSManifestServer() *httptest.Server {
+	return httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/vnd.docker.distribution.manifest.v2+json")
+		w.Header().Set("Docker-Content-Digest", digest.FromBytes([]byte(schema2Manifest)).String())
+		fmt.Fprint(w, schema2Manifest)
+	}))
+}
+
+func TestCustomCABundle(t *testing.T) {
+	ts := newTLSManifestServer()
+	defer ts.Close()
+
+	os.Unsetenv(EnvInsecure)
+
+	opts := Opts{
+		Registry: ts.URL,
+		Name:     "foo/bar",
+		Tag:      "1.0.0",
+	}
+
+	// unknown CA
+	_, err := New().Digest(opts)
+	if err == nil {
+		t.Fatalf("expected certificate verification to fail")
+	}
+
+	bundle, err := ioutil.TempFile("", "keel-ca")
+	if err != nil {
+		t.Fatalf("failed to create CA bundle: %s", err)
+	}
+	defer os.Remove(bundle.Name())
+	pem.Encode(bundle, &pem.Block{Type: "CERTIFICATE", Bytes: ts.Certificate().Raw})
+	bundle.Close()
+
+	os.Setenv(EnvCABundle, bundle.Name())
+	defer os.Unsetenv(EnvCABundle)
+
+	d, err := New().Digest(opts)
+	if err != nil {
+		t.Fatalf("error while getting digest: %s", err)
+	}
+	if d != digest.FromBytes([]byte(schema2Manifest)).String() {
+		t.Errorf("unexpected digest: %s", d)
+	}
+}
+
+func TestCertsDir(t *testing.T) {
+	ts := newTLSManifestServer()
+	defer ts.Close()
+
+	os.Unsetenv(EnvInsecure)
+
+	dir, err := ioutil.TempDir("", "keel-certs")
+	if err != nil {
+		t.Fatalf("failed to create certs dir: %s", err)
+	}
+	defer os.RemoveAll(dir)
+
+	host := strings.TrimPrefix(ts.URL, "https://")
+	os.MkdirAll(filepath.Join(dir, host), 0755)
+	cert := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: ts.Certificate().Raw})
+	ioutil.WriteFile(filepath.Join(dir, host, "ca.crt"), cert, 0644)
+
+	os.Setenv(EnvCertsDir, dir)
+	defer os.Unsetenv(EnvCertsDir)
+
+	_, err = New().Digest(Opts{
+		Registry: ts.URL,
+		Name:     "foo/bar",
+		Tag:      "1.0.0",
+	})
+	if err != nil {
+		t.Fatalf("error while getting digest: %s", err)
+	}
+}
+
+func TestInsecureRegistriesAllowlist(t *testing.T) {
+	ts := newTLSManifestServer()
+	defer ts.Close()
+
+	os.Unsetenv(EnvInsecure)
+	os.Setenv(EnvInsecureRegistries, "other.local:5000, "+strings.TrimPrefix(ts.URL, "https://"))
+	defer os.Unsetenv(EnvInsecureRegistries)
+
+	client := New()
+	_, err := client.Digest(Opts{
+		Registry: ts.URL,
+		Name:     "foo/bar",
+		Tag:      "1.0.0",
+	})
+	if err != nil {
+		t.Fatalf("error while getting digest: %s", err)
+	}
+
+	if client.transport.isInsecure("registry.local:5000") {
+		t.Errorf("registry outside the allowlist shouldn't be insecure")
+	}
+}
+
+func TestRegistryProxy(t *testing.T) {
+	var proxied []string
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		proxied = append(proxied, r.URL.Host)
+		w.Header().Set("Content-Type", "application/vnd.docker.distribution.manifest.v2+json")
+		w.Header().Set("Docker-Content-Digest", digest.FromBytes([]byte(schema2Manifest)).String())
+		fmt.Fprint(w, schema2Manifest)
+	}))
+	defer proxy.Close()
+
+	os.Setenv(EnvProxies, "registry.internal:5000="+proxy.URL)
+	defer os.Unsetenv(EnvProxies)
+
+	client := New()
+	d, err := client.Digest(Opts{
+		Registry: "http://registry.internal:5000",
+		Name:     "foo/bar",
+		Tag:      "1.0.0",
+	})
+	if err != nil {
+		t.Fatalf("error while getting digest: %s", err)
+	}
+	if d != digest.FromBytes([]byte(schema2Manifest)).String() {
+		t.Errorf("unexpected digest: %s", d)
+	}
+
+	if len(proxied) != 1 || proxied[0] != "registry.internal:5000" {
+		t.Errorf("expected request to go through the proxy, got: %v", proxied)
+	}
+}
+
+func TestRegistryProxyInvalid(t *testing.T) {
+	os.Setenv(EnvProxy, "proxy.local")
+	os.Setenv(EnvProxies, "quay.io,registry.local=http://proxy.local:3128")
+	defer os.Unsetenv(EnvProxy)
+	defer os.Unsetenv(EnvProxies)
+
+	opts := transportOptsFromEnv()
+	if opts.proxy != nil {
+		t.Errorf("expected invalid proxy to be ignored, got: %s", opts.proxy)
+	}
+	if len(opts.proxies) != 1 || opts.proxies["registry.local"].Host != "proxy.local:3128" {
+		t.Errorf("unexpected proxies: %v", opts.proxies)
+	}
+}
+
+func TestRegistryMirror(t *testing.T) {
+	var paths []string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		paths = append(paths, r.URL.Path)
+		w.Header().Set("Content-Type", "application/vnd.docker.distribution.manifest.v2+json")
+		w.Header().Set("Docker-Content-Digest", digest.FromBytes([]byte(schema2Manifest)).String())
+		fmt.Fprint(w, schema2Manifest)
+	}))
+	defer ts.Close()
+
+	os.Setenv(EnvMirrors, "docker.io="+ts.URL+"/hub-proxy")
+	defer os.Unsetenv(EnvMirrors)
+
+	client := New()
+	d, err := client.Digest(Opts{
+		Registry: "https://index.docker.io",
+		Name:     "library/nginx",
+		Tag:      "1.19",
+	})
+	if err != nil {
+		t.Fatalf("error while getting digest: %s", err)
+	}
+	if d != digest.FromBytes([]byte(schema2Manifest)).String() {
+		t.Errorf("unexpected digest: %s", d)
+	}
+
+	if len(paths) != 1 || paths[0] != "/v2/hub-proxy/library/nginx/manifests/1.19" {
+		t.Errorf("expected manifest to be fetched from the mirror, got: %v", paths)
+	}
+}
+
+func TestMirrorsFromEnv(t *testing.T) {
+	os.Setenv(EnvMirrors, "quay.io=mirror.local:5000, invalid, gcr.io=http://gcr-mirror.local/")
+	defer os.Unsetenv(EnvMirrors)
+
+	m := mirrorsFromEnv()
+	if len(m) != 2 {
+		t.Fatalf("unexpected mirrors: %v", m)
+	}
+
+	opts := m.rewrite(Opts{Registry: "https://quay.io", Name: "foo/bar"})
+	if opts.Registry != "https://mirror.local:5000" || opts.Name != "foo/bar" {
+		t.Errorf("unexpected rewrite: %+v", opts)
+	}
+
+	opts = m.rewrite(Opts{Registry: "https://gcr.io", Name: "foo/bar"})
+	if opts.Registry != "http://gcr-mirror.local" || opts.Name != "foo/bar" {
+		t.Errorf("unexpected rewrite: %+v", opts)
+	}
+
+	opts = m.rewrite(Opts{Registry: "https://registry.local", Name: "foo/bar"})
+	if opts.Registry != "https://registry.local" {
+		t.Errorf("registry without mirror shouldn't be rewritten: %+v", opts)
+	}
+}
+
+var manifestListResp = `{
+  "schemaVersion": 2,
+  "mediaType": "application/vnd.docker.distribution.manifest.list.v2+json",
+  "manifests": [
+    {
+      "mediaType": "application/vnd.docker.distribution.manifest.v2+json",
+      "size": 527,
+      "digest": "sha256:1111111111111111111111111111111111111111111111111111111111111111",
+      "platform": {"architecture": "amd64", "os": "linux"}
+    },
+    {
+      "mediaType": "application/vnd.docker.distribution.manifest.v2+json",
+      "size": 527,
+      "digest": "sha256:2222222222222222222222222222222222222222222222222222222222222222",
+      "platform": {"architecture": "arm64", "os": "linux", "variant": "v8"}
+    }
+  ]
+}`
+
+func TestDigestManifestListPlatform(t *testing.T) {
+	indexDigest := digest.FromBytes([]byte(manifestListResp)).String()
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.Contains(r.Header.Get("Accept"), "manifest.list.v2+json") {
+			w.Header().Set("Content-Type", "application/vnd.docker.distribution.manifest.list.v2+json")
+			w.Header().Set("Docker-Content-Digest", indexDigest)
+			fmt.Fprint(w, manifestListResp)
+			return
+		}
+		// registry picks default platform for clients that don't accept lists
+		w.Header().Set("Content-Type", "application/vnd.docker.distribution.manifest.v2+json")
+		w.Header().Set("Docker-Content-Digest", "sha256:1111111111111111111111111111111111111111111111111111111111111111")
+		fmt.Fprint(w, schema2Manifest)
+	}))
+	defer ts.Close()
+
+	defer os.Unsetenv(EnvPlatform)
+
+	for _, tc := range []struct {
+		platform string
+		expected string
+	}{
+		{platform: "", expected: "sha256:1111111111111111111111111111111111111111111111111111111111111111"},
+		{platform: "linux/arm64", expected: "sha256:2222222222222222222222222222222222222222222222222222222222222222"},
+		{platform: "linux/arm64/v8", expected: "sha256:2222222222222222222222222222222222222222222222222222222222222222"},
+		{platform: "linux/ppc64le", expected: indexDigest},
+		{platform: "index", expected: indexDigest},
+	} {
+		os.Setenv(EnvPlatform, tc.platform)
+
+		d, err := New().Digest(Opts{
+			Registry: ts.URL,
+			Name:     "foo/bar",
+			Tag:      "1.0.0",
+		})
+		if err != nil {
+			t.Fatalf("%s: error while getting digest: %s", tc.platform, err)
+		}
+		if d != tc.expected {
+			t.Errorf("%s: unexpected digest: %s, expected: %s", tc.platform, d, tc.expected)
+		}
+	}
+}
+
+func TestParsePlatform(t *testing.T) {
+	p, err := ParsePlatform("linux/arm/v7")
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if p.OS != "linux" || p.Architecture != "arm" || p.Variant != "v7" || p.String() != "linux/arm/v7" {
+		t.Errorf("unexpected platform: %+v", p)
+	}
+
+	for _, invalid := range []string{"linux", "linux/", "/amd64", "linux/arm/v7/x"} {
+		if _, err := ParsePlatform(invalid); err == nil {
+			t.Errorf("expected error for %q", invalid)
+		}
+	}
+}
+
+func TestGetTagsLinkPagination(t *testing.T) {
+	pages := map[string]string{
+		"":      `{"name":"foo/bar","tags":["1.0.0","1.1.0"]}`,
+		"1.1.0": `{"name":"foo/bar","tags":["1.2.0","2.0.0"]}`,
+		"2.0.0": `{"name":"foo/bar","tags":["2.1.0"]}`,
+	}
+	var requests []string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests = append(requests, r.URL.RawQuery)
+		last := r.URL.Query().Get("last")
+		switch last {
+		case "":
+			// absolute link without brackets like quay.io
+			w.Header().Set("Link", "http://"+r.Host+"/v2/foo/bar/tags/list?n=2&last=1.1.0; rel=next")
+		case "1.1.0":
+			w.Header().Set("Link", `</v2/foo/bar/tags/list?n=2&last=2.0.0>; rel="next"`)
+		}
+		fmt.Fprint(w, pages[last])
+	}))
+	defer ts.Close()
+
+	repo, err := New().Get(Opts{
+		Registry: ts.URL,
+		Name:     "foo/bar",
+	})
+	if err != nil {
+		t.Fatalf("failed to get tags: %s", err)
+	}
+
+	expected := []string{"1.0.0", "1.1.0", "1.2.0", "2.0.0", "2.1.0"}
+	if strings.Join(repo.Tags, ",") != strings.Join(expected, ",") {
+		t.Errorf("unexpected tags: %v", repo.Tags)
+	}
+	if len(requests) != 3 || requests[0] != fmt.Sprintf("n=%d", tagsPageSize) {
+		t.Errorf("unexpected requests: %v", requests)
+	}
+}
+
+func TestGetTagsLastPaging(t *testing.T) {
+	// registry truncates list to page size without Link header
+	var all []string
+	for i := 0; i < tagsPageSize+10; i++ {
+		all = append(all, fmt.Sprintf("1.0.%d", i))
+	}
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := 0
+		if last := r.URL.Query().Get("last"); last != "" {
+			for i, tag := range all {
+				if tag == last {
+					start = i + 1
+				}
+			}
+		}
+		end := start + tagsPageSize
+		if end > len(all) {
+			end = len(all)
+		}
+		fmt.Fprintf(w, `{"name":"foo/bar","tags":["%s"]}`, strings.Join(all[start:end], `","`))
+	}))
+	defer ts.Close()
+
+	repo, err := New().Get(Opts{
+		Registry: ts.URL,
+		Name:     "foo/bar",
+	})
+	if err != nil {
+		t.Fatalf("failed to get tags: %s", err)
+	}
+	if len(repo.Tags) != len(all) || repo.Tags[len(repo.Tags)-1] != all[len(all)-1] {
+		t.Errorf("expected %d tags, got %d", len(all), len(repo.Tags))
+	}
+}
+
+func TestGetTagsPageSizeRejected(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("n") != "" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		fmt.Fprint(w, `{"name":"foo/bar","tags":["1.0.0"]}`)
+	}))
+	defer ts.Close()
+
+	repo, err := New().Get(Opts{
+		Registry: ts.URL,
+		Name:     "foo/bar",
+	})
+	if err != nil {
+		t.Fatalf("failed to get tags: %s", err)
+	}
+	if len(repo.Tags) != 1 {
+		t.Errorf("unexpected tags: %v", repo.Tags)
+	}
+}
@@ -0,0 +1,120 @@
+package registry
+
+import (
+	"net/http"
+	"os"
+	"regexp"
+	"strings"
+
+	"github.com/rusenask/docker-registry-client/registry"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// EnvTokenRealms - comma separated list of per registry token endpoints used
+// instead of the realm announced by the registry, ie: when Harbor external URL
+// isn't reachable from the cluster: harbor.local=http://harbor-core.harbor/service/token
+const EnvTokenRealms = "REGISTRY_TOKEN_REALMS"
+
+// quayTokenUser - username Quay expects with OAuth application tokens
+const quayTokenUser = "$oauthtoken"
+
+// harborRobotPrefix - Harbor robot account names, ie: robot$ci (1.x),
+// robot$project+ci (2.x)
+const harborRobotPrefix = "robot$"
+
+var realmParam = regexp.MustCompile(`realm="[^"]*"`)
+
+// robotCredentials - normalizes Quay and Harbor robot account credentials.
+// Robot names are often stored with the '$' escaped or URL encoded, which
+// registries reject as unknown users. Quay tokens without username are
+// OAuth application tokens
+func robotCredentials(host, username, password string) (string, string) {
+	username = strings.TrimSpace(username)
+	password = strings.TrimSpace(password)
+
+	for _, escaped := range []string{`\$`, "%24"} {
+		if strings.HasPrefix(username, "robot"+escaped) {
+			username = harborRobotPrefix + strings.TrimPrefix(username, "robot"+escaped)
+		}
+		if username == strings.Replace(quayTokenUser, "$", escaped, 1) {
+			username = quayTokenUser
+		}
+	}
+
+	if username == "" && password != "" && isQuay(host) {
+		username = quayTokenUser
+	}
+
+	return username, password
+}
+
+func isQuay(host string) bool {
+	return host == "quay.io" || strings.HasSuffix(host, ".quay.io")
+}
+
+func tokenRealmsFromEnv() map[string]string {
+	realms := make(map[string]string)
+
+	for _, entry := range strings.Split(os.Getenv(EnvTokenRealms), ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		parts := strings.SplitN(entry, "=", 2)
+		if len(parts) != 2 || strings.TrimSpace(parts[0]) == "" || !strings.Contains(parts[1], "://") {
+			log.WithFields(log.Fields{
+				"entry": entry,
+			}).Error("registry client: invalid token realm entry, expected <registry>=<token endpoint URL>")
+			continue
+		}
+		realms[registryHost(strings.TrimSpace(parts[0]))] = strings.TrimSpace(parts[1])
+	}
+
+	return realms
+}
+
+// realmTransport - replaces token endpoint in bearer challenges
+type realmTransport struct {
+	Transport http.RoundTripper
+	realm     string
+}
+
+func (t *realmTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	resp, err := t.Transport.RoundTrip(req)
+	if err != nil || resp.StatusCode != http.StatusUnauthorized {
+		return resp, err
+	}
+
+	challenges := resp.Header[http.CanonicalHeaderKey("WWW-Authenticate")]
+	for i, challenge := range challenges {
+		if strings.HasPrefix(strings.ToLower(challenge), "bearer") {
+			challenges[i] = realmParam.ReplaceAllLiteralString(challenge, `realm="`+t.realm+`"`)
+		}
+	}
+	return resp, nil
+}
+
+// wrapTransport - same chain as registry.WrapTransport with token realm
+// overrides applied below token authentication
+func (c *DefaultClient) wrapTransport(transport *http.Transport, url, username, password string) http.RoundTripper {
+	var base http.RoundTripper = transport
+	if realm, ok := c.tokenRealms[registryHost(url)]; ok {
+		base = &realmTransport{Transport: transport, realm: realm}
+	}
+
+	return &registry.ErrorTransport{
+		Transport: &registry.BasicTransport{
+			Transport: &registry.TokenTransport{
+				Transport: base,
+				Username:  username,
+				Password:  password,
+				// client to retrieve tokens
+				Client: &http.Client{Transport: transport},
+			},
+			URL:      url,
+			Username: username,
+			Password: password,
+		},
+	}
+}
@@ -0,0 +1,86 @@
+package registry
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/opencontainers/go-digest"
+)
+
+func TestRobotCredentials(t *testing.T) {
+	tests := []struct {
+		host               string
+		username, password string
+		expectedUsername   string
+	}{
+		{"harbor.local", "robot$ci", "secret", "robot$ci"},
+		{"harbor.local", `robot\$project+ci`, "secret", "robot$project+ci"},
+		{"harbor.local", "robot%24ci\n", "secret", "robot$ci"},
+		{"quay.io", "org+ci", "token", "org+ci"},
+		{"quay.io", "", "token", "$oauthtoken"},
+		{"quay.io", `\$oauthtoken`, "token", "$oauthtoken"},
+		{"quay.io", "", "", ""},
+		{"registry.local", "", "token", ""},
+		{"registry.local", "robotnik", "secret", "robotnik"},
+	}
+
+	for _, tt := range tests {
+		username, _ := robotCredentials(tt.host, tt.username, tt.password)
+		if username != tt.expectedUsername {
+			t.Errorf("%s %q: expected username %q, got %q", tt.host, tt.username, tt.expectedUsername, username)
+		}
+	}
+}
+
+func TestTokenRealmOverride(t *testing.T) {
+	tokenServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		username, password, ok := r.BasicAuth()
+		if !ok || username != "robot$project+ci" || password != "secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		if r.URL.Query().Get("scope") != "repository:project/app:pull" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		fmt.Fprint(w, `{"token": "robot-token"}`)
+	}))
+	defer tokenServer.Close()
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer robot-token" {
+			// external URL unreachable from the cluster
+			w.Header().Set("WWW-Authenticate", `Bearer realm="https://harbor.example.com/service/token",service="harbor-registry",scope="repository:project/app:pull"`)
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.Header().Set("Content-Type", "application/vnd.docker.distribution.manifest.v2+json")
+		w.Header().Set("Docker-Content-Digest", digest.FromBytes([]byte(schema2Manifest)).String())
+		fmt.Fprint(w, schema2Manifest)
+	}))
+	defer ts.Close()
+
+	os.Setenv(EnvTokenRealms, strings.TrimPrefix(ts.URL, "http://")+"="+tokenServer.URL+"/service/token")
+	defer os.Unsetenv(EnvTokenRealms)
+
+	client := New()
+	d, err := client.Digest(Opts{
+		Registry: ts.URL,
+		Name:     "project/app",
+		Tag:      "1.0.0",
+		Username: `robot\$project+ci`,
+		Password: "secret",
+	})
+	if err != nil {
+		t.Fatalf("error while getting digest: %s", err)
+	}
+
+	expected := digest.FromBytes([]byte(schema2Manifest)).String()
+	if d != expected {
+		t.Errorf("unexpected digest: %s, expected: %s", d, expected)
+	}
+}
@@ -0,0 +1,143 @@
+package registry
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+
+	"github.com/rusenask/docker-registry-client/registry"
+
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	// tagsPageSize - tags requested per page, registries may return less
+	tagsPageSize = 1000
+	// maxTagsPages - guards against registries that keep returning next page
+	maxTagsPages = 1000
+)
+
+// matches RFC 5988 Link header next page, brackets and quotes are optional as
+// some registries (ie: quay.io) don't include them
+var nextLinkRE = regexp.MustCompile(`^ *<?([^;>]+)>? *(?:;[^;]*)*; *rel="?next"?(?:;.*)?`)
+
+type tagsPage struct {
+	Tags []string `json:"tags"`
+}
+
+// listTags - lists all repository tags following Link header pagination. Registries
+// that truncate the list without Link header are paged with n and last parameters
+func listTags(hub *registry.Registry, name string) ([]string, error) {
+	base, err := url.Parse(fmt.Sprintf("%s/v2/%s/tags/list", strings.TrimSuffix(hub.URL, "/"), name))
+	if err != nil {
+		return nil, err
+	}
+
+	first := withPaging(base, tagsPageSize, "")
+	page, next, err := getTagsPage(hub, first)
+	if isBadRequest(err) {
+		// registry doesn't accept our page size, let it pick one
+		log.WithFields(log.Fields{
+			"name": name,
+		}).Debug("registry client: registry rejected tags page size, listing tags without it")
+		page, next, err = getTagsPage(hub, base)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var tags []string
+	seen := make(map[string]bool)
+	requested := first
+
+	for pages := 1; ; pages++ {
+		added := 0
+		for _, tag := range page.Tags {
+			if !seen[tag] {
+				seen[tag] = true
+				tags = append(tags, tag)
+				added++
+			}
+		}
+
+		if next == nil && len(page.Tags) >= tagsPageSize && added > 0 {
+			// full page without Link header, asking for tags after the last one
+			next = withPaging(base, tagsPageSize, page.Tags[len(page.Tags)-1])
+		}
+		if next == nil || added == 0 || next.String() == requested.String() {
+			return tags, nil
+		}
+		if pages >= maxTagsPages {
+			log.WithFields(log.Fields{
+				"name":  name,
+				"pages": pages,
+				"tags":  len(tags),
+			}).Warn("registry client: too many tag pages, tag list is truncated")
+			return tags, nil
+		}
+
+		requested = next
+		page, next, err = getTagsPage(hub, next)
+		if err != nil {
+			return nil, err
+		}
+	}
+}
+
+func withPaging(base *url.URL, n int, last string) *url.URL {
+	u := *base
+	q := u.Query()
+	q.Set("n", fmt.Sprintf("%d", n))
+	if last != "" {
+		q.Set("last", last)
+	}
+	u.RawQuery = q.Encode()
+	return &u
+}
+
+// getTagsPage - gets tags page and next page URL if registry returned one
+func getTagsPage(hub *registry.Registry, pageURL *url.URL) (*tagsPage, *url.URL, error) {
+	hub.Logf("registry.tags url=%s", pageURL)
+
+	resp, err := hub.Client.Get(pageURL.String())
+	if err != nil {
+		return nil, nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, nil, fmt.Errorf("failed to list tags, registry returned status code %d", resp.StatusCode)
+	}
+
+	page := &tagsPage{}
+	if err := json.NewDecoder(resp.Body).Decode(page); err != nil {
+		return nil, nil, err
+	}
+
+	for _, link := range resp.Header[http.CanonicalHeaderKey("Link")] {
+		parts := nextLinkRE.FindStringSubmatch(link)
+		if parts == nil {
+			continue
+		}
+		next, err := url.Parse(strings.TrimSpace(parts[1]))
+		if err != nil {
+			return nil, nil, fmt.Errorf("invalid next page link %q: %s", parts[1], err)
+		}
+		// links are usually relative to registry
+		return page, pageURL.ResolveReference(next), nil
+	}
+
+	return page, nil, nil
+}
+
+func isBadRequest(err error) bool {
+	var statusErr *registry.HttpStatusError
+	if !errors.As(err, &statusErr) {
+		return false
+	}
+	return statusErr.Response.StatusCode == http.StatusBadRequest
+}
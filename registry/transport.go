@@ -0,0 +1,210 @@
+package registry
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// EnvInsecureRegistries - comma separated list of registry hosts (ie: harbor.local:5000)
+// that are allowed to skip certificate verification and fall back to plain HTTP
+const EnvInsecureRegistries = "INSECURE_REGISTRIES"
+
+// EnvCABundle - path to PEM encoded CA certificates trusted for all registries
+// in addition to system roots
+const EnvCABundle = "REGISTRY_CA_BUNDLE"
+
+// EnvCertsDir - directory with per registry CA certificates, same layout as
+// /etc/docker/certs.d: <dir>/<registry host>/*.crt
+const EnvCertsDir = "REGISTRY_CERTS_DIR"
+
+// EnvProxy - proxy URL for registry calls, unlike HTTPS_PROXY it doesn't
+// affect connection to Kubernetes API
+const EnvProxy = "REGISTRY_PROXY"
+
+// EnvProxies - comma separated list of per registry proxies,
+// ie: registry.local:5000=http://proxy.local:3128,quay.io=http://proxy2.local:3128
+const EnvProxies = "REGISTRY_PROXIES"
+
+// transportOpts - TLS and proxy configuration for registry HTTP clients
+type transportOpts struct {
+	// insecure - skip verification for all registries
+	insecure bool
+	// insecureRegistries - hosts that skip verification
+	insecureRegistries map[string]bool
+	// caBundle - PEM encoded certificates from EnvCABundle
+	caBundle []byte
+	certsDir string
+
+	// proxy - used for all registries, HTTP(S)_PROXY env variables are used if not set
+	proxy *url.URL
+	// proxies - per registry host proxies
+	proxies map[string]*url.URL
+}
+
+func transportOptsFromEnv() transportOpts {
+	opts := transportOpts{
+		insecure:           os.Getenv(EnvInsecure) == "true",
+		insecureRegistries: make(map[string]bool),
+		certsDir:           os.Getenv(EnvCertsDir),
+		proxies:            make(map[string]*url.URL),
+	}
+
+	for _, host := range strings.Split(os.Getenv(EnvInsecureRegistries), ",") {
+		host = strings.TrimSpace(host)
+		if host != "" {
+			opts.insecureRegistries[registryHost(host)] = true
+		}
+	}
+
+	if path := os.Getenv(EnvCABundle); path != "" {
+		bundle, err := ioutil.ReadFile(path)
+		if err != nil {
+			log.WithFields(log.Fields{
+				"error": err,
+				"path":  path,
+			}).Error("registry client: failed to read CA bundle, using system roots")
+		} else {
+			opts.caBundle = bundle
+		}
+	}
+
+	if proxy := os.Getenv(EnvProxy); proxy != "" {
+		proxyURL, err := parseProxy(proxy)
+		if err != nil {
+			log.WithFields(log.Fields{
+				"error": err,
+				"proxy": proxy,
+			}).Error("registry client: invalid registry proxy, ignoring")
+		} else {
+			opts.proxy = proxyURL
+		}
+	}
+
+	for _, entry := range strings.Split(os.Getenv(EnvProxies), ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		parts := strings.SplitN(entry, "=", 2)
+		if len(parts) != 2 {
+			log.WithFields(log.Fields{
+				"entry": entry,
+			}).Error("registry client: invalid registry proxy entry, expected <registry>=<proxy URL>")
+			continue
+		}
+		proxyURL, err := parseProxy(strings.TrimSpace(parts[1]))
+		if err != nil {
+			log.WithFields(log.Fields{
+				"error": err,
+				"entry": entry,
+			}).Error("registry client: invalid registry proxy, ignoring")
+			continue
+		}
+		opts.proxies[registryHost(strings.TrimSpace(parts[0]))] = proxyURL
+	}
+
+	return opts
+}
+
+func parseProxy(proxy string) (*url.URL, error) {
+	proxyURL, err := url.Parse(proxy)
+	if err != nil {
+		return nil, err
+	}
+	if proxyURL.Scheme == "" || proxyURL.Host == "" {
+		return nil, fmt.Errorf("proxy URL must include scheme and host")
+	}
+	return proxyURL, nil
+}
+
+// proxyFunc - per registry proxy, global registry proxy or proxy from environment
+func (o transportOpts) proxyFunc(host string) func(*http.Request) (*url.URL, error) {
+	if proxyURL, ok := o.proxies[host]; ok {
+		return http.ProxyURL(proxyURL)
+	}
+	if o.proxy != nil {
+		return http.ProxyURL(o.proxy)
+	}
+	return http.ProxyFromEnvironment
+}
+
+// isInsecure - whether certificate verification is skipped for the host
+func (o transportOpts) isInsecure(host string) bool {
+	return o.insecure || o.insecureRegistries[host]
+}
+
+// tlsConfig - builds TLS config for registry host, custom CAs are added to system roots
+func (o transportOpts) tlsConfig(host string) (*tls.Config, error) {
+	if o.isInsecure(host) {
+		return &tls.Config{InsecureSkipVerify: true}, nil
+	}
+
+	var certs [][]byte
+	if len(o.caBundle) > 0 {
+		certs = append(certs, o.caBundle)
+	}
+
+	if o.certsDir != "" {
+		files, err := filepath.Glob(filepath.Join(o.certsDir, host, "*.crt"))
+		if err != nil {
+			return nil, err
+		}
+		for _, file := range files {
+			cert, err := ioutil.ReadFile(file)
+			if err != nil {
+				return nil, fmt.Errorf("failed to read registry CA %s: %s", file, err)
+			}
+			certs = append(certs, cert)
+		}
+	}
+
+	if len(certs) == 0 {
+		return nil, nil
+	}
+
+	pool, err := x509.SystemCertPool()
+	if err != nil || pool == nil {
+		pool = x509.NewCertPool()
+	}
+	for _, cert := range certs {
+		if !pool.AppendCertsFromPEM(cert) {
+			return nil, fmt.Errorf("no valid certificates found in registry CA for %s", host)
+		}
+	}
+
+	return &tls.Config{RootCAs: pool}, nil
+}
+
+// newTransport - same settings as docker-registry-client transports with
+// registry specific TLS and proxy config
+func (o transportOpts) newTransport(host string) (*http.Transport, error) {
+	tlsConfig, err := o.tlsConfig(host)
+	if err != nil {
+		return nil, err
+	}
+
+	return &http.Transport{
+		Proxy: o.proxyFunc(host),
+		DialContext: (&net.Dialer{
+			Timeout:   10 * time.Second,
+			KeepAlive: 30 * time.Second,
+			DualStack: true,
+		}).DialContext,
+		TLSClientConfig:       tlsConfig,
+		MaxIdleConns:          10,
+		IdleConnTimeout:       90 * time.Second,
+		TLSHandshakeTimeout:   10 * time.Second,
+		ExpectContinueTimeout: 1 * time.Second,
+	}, nil
+}
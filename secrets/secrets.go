@@ -37,6 +37,9 @@ type Getter interface {
 type DefaultGetter struct {
 	kubernetesImplementer kubernetes.Implementer
 	defaultDockerConfig   DockerCfg // default configuration supplied by optional environment variable
+
+	// implementers of other clusters, by cluster name
+	clusters map[string]kubernetes.Implementer
 }
 
 // NewGetter - create new default getter
@@ -53,12 +56,36 @@ func NewGetter(implementer kubernetes.Implementer, defaultDockerConfig DockerCfg
 	}
 }
 
+// WithCluster - secrets of images tracked in the named cluster are looked up
+// with the cluster implementer
+func (g *DefaultGetter) WithCluster(name string, implementer kubernetes.Implementer) *DefaultGetter {
+	if g.clusters == nil {
+		g.clusters = make(map[string]kubernetes.Implementer)
+	}
+	g.clusters[name] = implementer
+	return g
+}
+
+func (g *DefaultGetter) implementer(image *types.TrackedImage) kubernetes.Implementer {
+	if implementer, ok := g.clusters[image.Cluster]; ok {
+		return implementer
+	}
+	return g.kubernetesImplementer
+}
+
 // Get - get secret for tracked image
 func (g *DefaultGetter) Get(image *types.TrackedImage) (*types.Credentials, error) {
 	if image.Namespace == "" {
 		return nil, ErrNamespaceNotSpecified
 	}
 
+	// polling credentials replace any other credentials
+	if image.PollCredentials != "" {
+		pollImage := *image
+		pollImage.Secrets = []string{image.PollCredentials}
+		return g.getCredentialsFromSecret(&pollImage)
+	}
+
 	// checking in default creds
 	creds, found := g.lookupDefaultDockerConfig(image)
 	if found {
@@ -78,6 +105,10 @@ func (g *DefaultGetter) Get(image *types.TrackedImage) (*types.Credentials, erro
 			image.Secrets = secrets
 		}
 	}
+
+	// pods also get image pull secrets of their service account
+	image.Secrets = appendMissing(image.Secrets, g.serviceAccountSecrets(image)...)
+
 	if len(image.Secrets) == 0 {
 		return nil, ErrSecretsNotSpecified
 	}
@@ -98,7 +129,7 @@ func (g *DefaultGetter) lookupSecrets(image *types.TrackedImage) ([]string, erro
 		return secrets, nil
 	}
 
-	podList, err := g.kubernetesImplementer.Pods(image.Namespace, selector)
+	podList, err := g.implementer(image).Pods(image.Namespace, selector)
 	if err != nil {
 		return secrets, err
 	}
@@ -130,6 +161,45 @@ func (g *DefaultGetter) lookupSecrets(image *types.TrackedImage) ([]string, erro
 	return secrets, nil
 }
 
+func (g *DefaultGetter) serviceAccountSecrets(image *types.TrackedImage) []string {
+	name, ok := image.Meta["serviceAccount"]
+	if !ok {
+		return nil
+	}
+
+	sa, err := g.implementer(image).ServiceAccount(image.Namespace, name)
+	if err != nil {
+		log.WithFields(log.Fields{
+			"namespace":       image.Namespace,
+			"service_account": name,
+			"error":           err,
+		}).Debug("secrets.defaultGetter: failed to get service account")
+		return nil
+	}
+
+	var secrets []string
+	for _, s := range sa.ImagePullSecrets {
+		secrets = append(secrets, s.Name)
+	}
+	return secrets
+}
+
+func appendMissing(secrets []string, names ...string) []string {
+	for _, name := range names {
+		found := false
+		for _, s := range secrets {
+			if s == name {
+				found = true
+				break
+			}
+		}
+		if !found {
+			secrets = append(secrets, name)
+		}
+	}
+	return secrets
+}
+
 func getPodImagePullSecrets(pod *v1.Pod) []string {
 	var secrets []string
 	for _, s := range pod.Spec.ImagePullSecrets {
@@ -144,7 +214,7 @@ func (g *DefaultGetter) getCredentialsFromSecret(image *types.TrackedImage) (*ty
 	secretFound := false
 
 	for _, secretRef := range image.Secrets {
-		secret, err := g.kubernetesImplementer.Secret(image.Namespace, secretRef)
+		secret, err := g.implementer(image).Secret(image.Namespace, secretRef)
 		if err != nil {
 			log.WithFields(log.Fields{
 				"image":      image.Image.Repository(),
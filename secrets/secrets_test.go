@@ -101,6 +101,52 @@ func TestGetDockerConfigJSONSecret(t *testing.T) {
 		t.Errorf("unexpected pass: %s", creds.Password)
 	}
 }
+func TestGetServiceAccountSecret(t *testing.T) {
+	imgRef, _ := image.Parse("quay.io/karolisr/webhook-demo:0.0.11")
+
+	impl := &testutil.FakeK8sImplementer{
+		AvailableSecret: map[string]*v1.Secret{
+			"myregistrysecret": {
+				Data: map[string][]byte{
+					dockerConfigJSONKey: []byte(secretDockerConfigJSONPayload),
+				},
+				Type: v1.SecretTypeDockerConfigJson,
+			},
+		},
+		AvailableServiceAccounts: map[string]*v1.ServiceAccount{
+			"builder": {
+				ImagePullSecrets: []v1.LocalObjectReference{
+					{Name: "myregistrysecret"},
+				},
+			},
+		},
+	}
+
+	getter := NewGetter(impl, nil)
+
+	// no secrets on the pod spec
+	trackedImage := &types.TrackedImage{
+		Image:     imgRef,
+		Namespace: "default",
+		Meta:      map[string]string{"serviceAccount": "builder"},
+	}
+
+	creds, err := getter.Get(trackedImage)
+	if err != nil {
+		t.Fatalf("failed to get creds: %s", err)
+	}
+
+	if creds.Username != "keeluser+keeltest" {
+		t.Errorf("unexpected username: %s", creds.Username)
+	}
+
+	// secrets are only added once
+	getter.Get(trackedImage)
+	if len(trackedImage.Secrets) != 1 {
+		t.Errorf("unexpected secrets: %v", trackedImage.Secrets)
+	}
+}
+
 func TestGetDockerConfigJSONSecretUsernmePassword(t *testing.T) {
 	imgRef, _ := image.Parse("karolisr/webhook-demo:0.0.11")
 
@@ -178,6 +224,53 @@ func TestGetFromDefaultCredentials(t *testing.T) {
 	}
 }
 
+func TestGetPollCredentials(t *testing.T) {
+	imgRef, _ := image.Parse("karolisr/webhook-demo:0.0.11")
+
+	impl := &testutil.FakeK8sImplementer{
+		AvailableSecret: map[string]*v1.Secret{
+			"pollsecret": {
+				Data: map[string][]byte{
+					dockerConfigJSONKey: []byte(secretDockerConfigJSONPayloadWithUsernamePassword),
+				},
+				Type: v1.SecretTypeDockerConfigJson,
+			},
+		},
+	}
+
+	// default credentials are ignored for images with poll credentials
+	getter := NewGetter(impl, DockerCfg{
+		"https://index.docker.io/v1/": &Auth{
+			Username: "aa",
+			Password: "bb",
+		},
+	})
+
+	trackedImage := &types.TrackedImage{
+		Image:           imgRef,
+		Namespace:       "default",
+		Secrets:         []string{"myregistrysecret"},
+		PollCredentials: "pollsecret",
+	}
+
+	creds, err := getter.Get(trackedImage)
+	if err != nil {
+		t.Errorf("failed to get creds: %s", err)
+	}
+
+	if creds.Username != "login" {
+		t.Errorf("unexpected username: %s", creds.Username)
+	}
+
+	if creds.Password != "somepass" {
+		t.Errorf("unexpected pass: %s", creds.Password)
+	}
+
+	if len(trackedImage.Secrets) != 1 || trackedImage.Secrets[0] != "myregistrysecret" {
+		t.Errorf("image pull secrets shouldn't be changed: %v", trackedImage.Secrets)
+	}
+}
+
 func TestGetSecretNotFound(t *testing.T) {
 	imgRef, _ := image.Parse("karolisr/webhook-demo:0.0.11")
 
@@ -0,0 +1,173 @@
+package kafka
+
+import (
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/segmentio/kafka-go"
+	"github.com/segmentio/kafka-go/sasl"
+	"github.com/segmentio/kafka-go/sasl/plain"
+	"github.com/segmentio/kafka-go/sasl/scram"
+
+	"github.com/keel-hq/keel/provider"
+	"github.com/keel-hq/keel/types"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// DefaultGroupID - default consumer group
+const DefaultGroupID = "keel"
+
+// available SASL mechanisms
+const (
+	SASLMechanismPlain       = "plain"
+	SASLMechanismScramSHA256 = "scram-sha-256"
+	SASLMechanismScramSHA512 = "scram-sha-512"
+)
+
+// Example of message, same payload as native webhook
+// {
+//   "name": "gcr.io/v2-namespace/hello-world",
+//   "tag": "1.1.1",
+//   "digest": "sha256:..." (optional)
+// }
+
+// Consumer - Kafka consumer, submits received image events to providers
+type Consumer struct {
+	providers provider.Providers
+
+	reader *kafka.Reader
+	topic  string
+}
+
+// Opts - consumer options
+type Opts struct {
+	Brokers []string
+	Topic   string
+	GroupID string
+
+	TLS bool
+
+	// SASLMechanism is optional, one of plain, scram-sha-256, scram-sha-512
+	SASLMechanism string
+	Username      string
+	Password      string
+
+	Providers provider.Providers
+}
+
+// New - create new Kafka consumer
+func New(opts *Opts) (*Consumer, error) {
+	if len(opts.Brokers) == 0 {
+		return nil, fmt.Errorf("at least one broker is required")
+	}
+	if opts.Topic == "" {
+		return nil, fmt.Errorf("topic is required")
+	}
+
+	groupID := opts.GroupID
+	if groupID == "" {
+		groupID = DefaultGroupID
+	}
+
+	dialer := &kafka.Dialer{
+		Timeout:   10 * time.Second,
+		DualStack: true,
+	}
+
+	if opts.TLS {
+		dialer.TLS = &tls.Config{}
+	}
+
+	if opts.SASLMechanism != "" {
+		mechanism, err := getSASLMechanism(opts.SASLMechanism, opts.Username, opts.Password)
+		if err != nil {
+			return nil, err
+		}
+		dialer.SASLMechanism = mechanism
+	}
+
+	return &Consumer{
+		providers: opts.Providers,
+		topic:     opts.Topic,
+		reader: kafka.NewReader(kafka.ReaderConfig{
+			Brokers: opts.Brokers,
+			Topic:   opts.Topic,
+			GroupID: groupID,
+			Dialer:  dialer,
+		}),
+	}, nil
+}
+
+func getSASLMechanism(name, username, password string) (sasl.Mechanism, error) {
+	switch strings.ToLower(name) {
+	case SASLMechanismPlain:
+		return plain.Mechanism{Username: username, Password: password}, nil
+	case SASLMechanismScramSHA256:
+		return scram.Mechanism(scram.SHA256, username, password)
+	case SASLMechanismScramSHA512:
+		return scram.Mechanism(scram.SHA512, username, password)
+	}
+	return nil, fmt.Errorf("unknown SASL mechanism: %s", name)
+}
+
+// Start - start consuming messages, blocks until context is cancelled
+func (c *Consumer) Start(ctx context.Context) error {
+	defer c.reader.Close()
+
+	log.WithFields(log.Fields{
+		"topic": c.topic,
+	}).Info("trigger.kafka: consuming events...")
+
+	for {
+		msg, err := c.reader.ReadMessage(ctx)
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			log.WithFields(log.Fields{
+				"error": err,
+				"topic": c.topic,
+			}).Error("trigger.kafka: failed to read message")
+			time.Sleep(5 * time.Second)
+			continue
+		}
+
+		c.handle(msg.Value)
+	}
+}
+
+func (c *Consumer) handle(data []byte) {
+	repo := types.Repository{}
+	err := json.Unmarshal(data, &repo)
+	if err != nil {
+		log.WithFields(log.Fields{
+			"error": err,
+		}).Error("trigger.kafka: failed to decode message")
+		return
+	}
+
+	if repo.Name == "" || repo.Tag == "" {
+		log.WithFields(log.Fields{
+			"name": repo.Name,
+			"tag":  repo.Tag,
+		}).Warn("trigger.kafka: name and tag are required, ignoring message")
+		return
+	}
+
+	err = c.providers.Submit(types.Event{
+		Repository:  repo,
+		CreatedAt:   time.Now(),
+		TriggerName: "kafka",
+	})
+	if err != nil {
+		log.WithFields(log.Fields{
+			"error": err,
+			"image": repo.Name,
+		}).Error("trigger.kafka: failed to submit event")
+	}
+}
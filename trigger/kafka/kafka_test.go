@@ -0,0 +1,72 @@
+package kafka
+
+import (
+	"testing"
+
+	"github.com/keel-hq/keel/types"
+)
+
+type fakeProviders struct {
+	submitted []types.Event
+}
+
+func (p *fakeProviders) Submit(event types.Event) error {
+	p.submitted = append(p.submitted, event)
+	return nil
+}
+
+func (p *fakeProviders) TrackedImages() ([]*types.TrackedImage, error)     { return nil, nil }
+func (p *fakeProviders) List() []string                                    { return nil }
+func (p *fakeProviders) Stop()                                             {}
+func (p *fakeProviders) Pause()                                            {}
+func (p *fakeProviders) Resume()                                           {}
+func (p *fakeProviders) Paused() bool                                      { return false }
+func (p *fakeProviders) QueuedUpdates() []*types.QueuedUpdate              { return nil }
+func (p *fakeProviders) Explain(event types.Event) []*types.UpdateDecision { return nil }
+
+func TestHandle(t *testing.T) {
+	tests := []struct {
+		name      string
+		data      string
+		submitted int
+	}{
+		{name: "valid", data: `{"name": "gcr.io/v2-namespace/hello-world", "tag": "1.1.1", "digest": "sha256:aaa"}`, submitted: 1},
+		{name: "missing tag", data: `{"name": "gcr.io/v2-namespace/hello-world"}`},
+		{name: "malformed", data: `{"name": `},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fp := &fakeProviders{}
+			c := &Consumer{providers: fp}
+			c.handle([]byte(tt.data))
+
+			if len(fp.submitted) != tt.submitted {
+				t.Fatalf("expected %d events, got: %d", tt.submitted, len(fp.submitted))
+			}
+			if tt.submitted == 0 {
+				return
+			}
+
+			event := fp.submitted[0]
+			if event.Repository.Name != "gcr.io/v2-namespace/hello-world" || event.Repository.Tag != "1.1.1" || event.Repository.Digest != "sha256:aaa" {
+				t.Errorf("unexpected repository: %+v", event.Repository)
+			}
+			if event.TriggerName != "kafka" {
+				t.Errorf("unexpected trigger name: %s", event.TriggerName)
+			}
+		})
+	}
+}
+
+func TestGetSASLMechanism(t *testing.T) {
+	for _, name := range []string{"plain", "SCRAM-SHA-256", "scram-sha-512"} {
+		if _, err := getSASLMechanism(name, "user", "pass"); err != nil {
+			t.Errorf("%s: unexpected error: %s", name, err)
+		}
+	}
+
+	if _, err := getSASLMechanism("gssapi", "user", "pass"); err == nil {
+		t.Errorf("expected error for unknown mechanism")
+	}
+}
@@ -0,0 +1,139 @@
+package nats
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/nats-io/nats.go"
+
+	"github.com/keel-hq/keel/provider"
+	"github.com/keel-hq/keel/types"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// DefaultSubject - default subject to subscribe to
+const DefaultSubject = "keel.images"
+
+// Example of message, same payload as native webhook
+// {
+//   "name": "gcr.io/v2-namespace/hello-world",
+//   "tag": "1.1.1",
+//   "digest": "sha256:..." (optional)
+// }
+
+// Subscriber - NATS subscriber, submits received image events to providers
+type Subscriber struct {
+	providers provider.Providers
+
+	url     string
+	subject string
+	queue   string
+}
+
+// Opts - subscriber options
+type Opts struct {
+	URL     string
+	Subject string
+	// Queue is optional, when set - only one subscriber in the queue group
+	// receives each message
+	Queue     string
+	Providers provider.Providers
+}
+
+// New - create new NATS subscriber
+func New(opts *Opts) (*Subscriber, error) {
+	if opts.URL == "" {
+		return nil, fmt.Errorf("NATS URL is required")
+	}
+
+	subject := opts.Subject
+	if subject == "" {
+		subject = DefaultSubject
+	}
+
+	return &Subscriber{
+		providers: opts.Providers,
+		url:       opts.URL,
+		subject:   subject,
+		queue:     opts.Queue,
+	}, nil
+}
+
+// Start - connect and subscribe, blocks until context is cancelled
+func (s *Subscriber) Start(ctx context.Context) error {
+	conn, err := nats.Connect(s.url,
+		nats.Name("keel"),
+		nats.MaxReconnects(-1),
+		nats.ReconnectWait(5*time.Second),
+		nats.DisconnectErrHandler(func(_ *nats.Conn, err error) {
+			log.WithFields(log.Fields{
+				"error": err,
+			}).Warn("trigger.nats: disconnected")
+		}),
+		nats.ReconnectHandler(func(c *nats.Conn) {
+			log.WithFields(log.Fields{
+				"url": c.ConnectedUrl(),
+			}).Info("trigger.nats: reconnected")
+		}),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to connect to NATS, error: %s", err)
+	}
+	defer conn.Close()
+
+	handler := func(msg *nats.Msg) {
+		s.handle(msg.Data)
+	}
+
+	if s.queue != "" {
+		_, err = conn.QueueSubscribe(s.subject, s.queue, handler)
+	} else {
+		_, err = conn.Subscribe(s.subject, handler)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to subscribe to %s, error: %s", s.subject, err)
+	}
+
+	log.WithFields(log.Fields{
+		"subject": s.subject,
+		"queue":   s.queue,
+	}).Info("trigger.nats: subscribing for events...")
+
+	<-ctx.Done()
+
+	return conn.Drain()
+}
+
+func (s *Subscriber) handle(data []byte) {
+	repo := types.Repository{}
+	err := json.Unmarshal(data, &repo)
+	if err != nil {
+		log.WithFields(log.Fields{
+			"error": err,
+		}).Error("trigger.nats: failed to decode message")
+		return
+	}
+
+	if repo.Name == "" || repo.Tag == "" {
+		log.WithFields(log.Fields{
+			"name": repo.Name,
+			"tag":  repo.Tag,
+		}).Warn("trigger.nats: name and tag are required, ignoring message")
+		return
+	}
+
+	err = s.providers.Submit(types.Event{
+		Repository:  repo,
+		CreatedAt:   time.Now(),
+		TriggerName: "nats",
+	})
+	if err != nil {
+		log.WithFields(log.Fields{
+			"error": err,
+			"image": repo.Name,
+		}).Error("trigger.nats: failed to submit event")
+	}
+}
@@ -0,0 +1,37 @@
+package poll
+
+import (
+	"time"
+
+	"github.com/keel-hq/keel/registry"
+	"github.com/keel-hq/keel/types"
+)
+
+// CreatedResolver - looks up image creation time in the registry with
+// credentials of the tracked image, used by providers to hold back updates
+// to tags that are too new
+type CreatedResolver struct {
+	registryClient registry.Client
+}
+
+// NewCreatedResolver - new registry backed creation time resolver
+func NewCreatedResolver(registryClient registry.Client) *CreatedResolver {
+	return &CreatedResolver{registryClient: registryClient}
+}
+
+// Created - creation time of the tracked image tag
+func (r *CreatedResolver) Created(ti *types.TrackedImage) (created time.Time, err error) {
+	registryOpts := registry.Opts{
+		Registry: ti.Image.Scheme() + "://" + ti.Image.Registry(),
+		Name:     ti.Image.ShortName(),
+		Tag:      ti.Image.Tag(),
+	}
+
+	started := time.Now()
+	err = withCredentials(ti, &registryOpts, func() (err error) {
+		created, err = r.registryClient.Created(registryOpts)
+		return err
+	})
+	observeRegistryPoll(ti.Image.Registry(), operationManifest, started, err)
+	return created, err
+}
@@ -0,0 +1,264 @@
+package poll
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/keel-hq/keel/pkg/tracing"
+	"github.com/keel-hq/keel/provider"
+	"github.com/keel-hq/keel/registry"
+	"github.com/keel-hq/keel/types"
+	"github.com/keel-hq/keel/util/version"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"go.opentelemetry.io/otel/attribute"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// createdCacheTTL - how long image creation times are kept, tags are rarely
+// moved to a different image so this mostly bounds memory of removed tags
+const createdCacheTTL = 24 * time.Hour
+
+// ordersByCreated - whether tracked image is updated to the newest image of
+// its non semver tags instead of watching the current tag's digest
+func ordersByCreated(ti *types.TrackedImage) bool {
+	if ti.TagOrder != types.TagOrderCreated {
+		return false
+	}
+	_, err := version.GetVersion(ti.Image.Tag())
+	return err != nil
+}
+
+type createdEntry struct {
+	created time.Time
+	fetched time.Time
+}
+
+// createdCache - image creation times by registry/name:tag, every lookup
+// costs a manifest and a config blob request
+type createdCache struct {
+	mu      sync.Mutex
+	entries map[string]createdEntry
+}
+
+func newCreatedCache() *createdCache {
+	return &createdCache{entries: make(map[string]createdEntry)}
+}
+
+func (c *createdCache) get(key string) (time.Time, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entry, ok := c.entries[key]
+	if !ok || time.Since(entry.fetched) > createdCacheTTL {
+		return time.Time{}, false
+	}
+	return entry.created, true
+}
+
+func (c *createdCache) set(key string, created time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := time.Now()
+	for k, entry := range c.entries {
+		if now.Sub(entry.fetched) > createdCacheTTL {
+			delete(c.entries, k)
+		}
+	}
+	c.entries[key] = createdEntry{created: created, fetched: now}
+}
+
+// WatchCreatedTagsJob - watch non semver tags and update to the most recently
+// created image allowed by the policy
+type WatchCreatedTagsJob struct {
+	providers      provider.Providers
+	registryClient registry.Client
+	details        *watchDetails
+	created        *createdCache
+}
+
+// NewWatchCreatedTagsJob - new created tags watcher job
+func NewWatchCreatedTagsJob(providers provider.Providers, registryClient registry.Client, details *watchDetails, created *createdCache) *WatchCreatedTagsJob {
+	return &WatchCreatedTagsJob{
+		providers:      providers,
+		registryClient: registryClient,
+		details:        details,
+		created:        created,
+	}
+}
+
+// Run - main function to check schedule
+func (j *WatchCreatedTagsJob) Run() {
+	j.details.mu.RLock()
+	defer j.details.mu.RUnlock()
+
+	ctx, span := tracing.Start(context.Background(), "poll.watch_created_tags",
+		attribute.String("image", j.details.trackedImage.Image.String()),
+	)
+	defer span.End()
+
+	reg := j.details.trackedImage.Image.Scheme() + "://" + j.details.trackedImage.Image.Registry()
+
+	registryOpts := registry.Opts{
+		Registry: reg,
+		Name:     j.details.trackedImage.Image.ShortName(),
+		Tag:      j.details.trackedImage.Image.Tag(),
+	}
+
+	_, lookupSpan := tracing.Start(ctx, "registry.tags", attribute.String("registry", reg))
+	started := time.Now()
+	var repository *registry.Repository
+	err := withCredentials(j.details.trackedImage, &registryOpts, func() (err error) {
+		repository, err = j.registryClient.Get(registryOpts)
+		return err
+	})
+	tracing.End(lookupSpan, err)
+	observeRegistryPoll(j.details.trackedImage.Image.Registry(), operationTags, started, err)
+
+	if registry.IsRateLimited(err) {
+		log.WithFields(log.Fields{
+			"error": err,
+			"image": j.details.trackedImage.Image.String(),
+		}).Debug("trigger.poll.WatchCreatedTagsJob: registry is rate limited, skipping check")
+		j.details.rateLimited()
+		return
+	}
+	if err != nil {
+		j.details.polled("", "", err)
+		log.WithFields(log.Fields{
+			"error":        err,
+			"registry_url": reg,
+			"image":        j.details.trackedImage.Image.String(),
+		}).Error("trigger.poll.WatchCreatedTagsJob: failed to get repository")
+		return
+	}
+
+	registriesScannedCounter.With(prometheus.Labels{"registry": j.details.trackedImage.Image.Registry(), "image": j.details.trackedImage.Image.Repository()}).Inc()
+
+	events, err := j.computeEvents(registryOpts, repository.Tags)
+	if err != nil {
+		j.details.polled("", "", err)
+		log.WithFields(log.Fields{
+			"error":           err,
+			"repository_tags": repository.Tags,
+			"image":           j.details.trackedImage.Image.String(),
+		}).Error("trigger.poll.WatchCreatedTagsJob: failed to process tags")
+		return
+	}
+
+	seenTag := j.details.trackedImage.Image.Tag()
+	if len(events) > 0 {
+		seenTag = events[0].Repository.Tag
+	}
+	j.details.polled(seenTag, j.details.digest, nil)
+
+	for _, e := range events {
+		e.TraceContext = tracing.Inject(ctx)
+
+		// resolving digest of the new tag so images that are pinned
+		// by both tag and digest can be updated
+		registryOpts.Tag = e.Repository.Tag
+		digest, err := j.registryClient.Digest(registryOpts)
+		if err != nil {
+			log.WithFields(log.Fields{
+				"error":   err,
+				"image":   j.details.trackedImage.Image.String(),
+				"new_tag": e.Repository.Tag,
+			}).Warn("trigger.poll.WatchCreatedTagsJob: failed to resolve digest for the new tag")
+		} else {
+			e.Repository.Digest = digest
+		}
+
+		err = j.providers.Submit(e)
+		if err != nil {
+			log.WithFields(log.Fields{
+				"repository": j.details.trackedImage.Image.Repository(),
+				"new_tag":    e.Repository.Tag,
+				"error":      err,
+			}).Error("trigger.poll.WatchCreatedTagsJob: error while submitting an event")
+		} else {
+			j.details.submitted()
+		}
+	}
+}
+
+// computeEvents - newest tag allowed by the policy of each related image,
+// only tags created after the current one are considered
+func (j *WatchCreatedTagsJob) computeEvents(registryOpts registry.Opts, tags []string) ([]types.Event, error) {
+	trackedImages, err := j.providers.TrackedImages()
+	if err != nil {
+		return nil, err
+	}
+
+	current := j.details.trackedImage.Image.Tag()
+	currentCreated, err := j.createdAt(registryOpts, current)
+	if err != nil {
+		return nil, err
+	}
+
+	events := []types.Event{}
+
+	for _, trackedImage := range getRelatedTrackedImages(j.details.trackedImage, trackedImages) {
+		if !ordersByCreated(trackedImage) || trackedImage.Image.Tag() != current {
+			continue
+		}
+
+		var newest string
+		newestCreated := currentCreated
+		for _, tag := range tags {
+			if tag == current {
+				continue
+			}
+			update, err := trackedImage.Policy.ShouldUpdate(current, tag)
+			if err != nil || !update {
+				continue
+			}
+			created, err := j.createdAt(registryOpts, tag)
+			if err != nil {
+				log.WithFields(log.Fields{
+					"error": err,
+					"image": j.details.trackedImage.Image.String(),
+					"tag":   tag,
+				}).Debug("trigger.poll.WatchCreatedTagsJob: failed to get image creation time")
+				continue
+			}
+			if created.After(newestCreated) {
+				newest = tag
+				newestCreated = created
+			}
+		}
+
+		if newest != "" && !exists(newest, events) {
+			events = append(events, types.Event{
+				Repository: types.Repository{
+					Name: j.details.trackedImage.Image.Repository(),
+					Tag:  newest,
+				},
+				TriggerName: types.TriggerTypePoll.String(),
+			})
+		}
+	}
+
+	return events, nil
+}
+
+func (j *WatchCreatedTagsJob) createdAt(registryOpts registry.Opts, tag string) (time.Time, error) {
+	key := j.details.trackedImage.Image.Registry() + "/" + registryOpts.Name + ":" + tag
+	if created, ok := j.created.get(key); ok {
+		return created, nil
+	}
+
+	registryOpts.Tag = tag
+	started := time.Now()
+	created, err := j.registryClient.Created(registryOpts)
+	observeRegistryPoll(j.details.trackedImage.Image.Registry(), operationManifest, started, err)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	j.created.set(key, created)
+	return created, nil
+}
@@ -0,0 +1,44 @@
+package poll
+
+import (
+	"github.com/keel-hq/keel/extension/credentialshelper"
+	"github.com/keel-hq/keel/registry"
+	"github.com/keel-hq/keel/types"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// withCredentials - sets registry credentials from credentials helpers and calls
+// registry. Short lived tokens (ie: ECR) can expire before helper refreshes them,
+// if registry rejects them, cached token is dropped and the call is retried once
+func withCredentials(ti *types.TrackedImage, opts *registry.Opts, call func() error) error {
+	setCredentials(ti, opts)
+
+	err := call()
+	if !registry.IsUnauthorized(err) || opts.Password == "" {
+		return err
+	}
+
+	credentialshelper.InvalidateCredentials(ti)
+
+	rejected := opts.Password
+	setCredentials(ti, opts)
+	if opts.Password == rejected {
+		// nothing to refresh
+		return err
+	}
+
+	log.WithFields(log.Fields{
+		"image": ti.Image.String(),
+	}).Info("trigger.poll: registry rejected credentials, retrying with refreshed credentials")
+
+	return call()
+}
+
+func setCredentials(ti *types.TrackedImage, opts *registry.Opts) {
+	creds, err := credentialshelper.GetCredentials(ti)
+	if err == nil {
+		opts.Username = creds.Username
+		opts.Password = creds.Password
+	}
+}
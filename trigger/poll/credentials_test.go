@@ -0,0 +1,86 @@
+package poll
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/keel-hq/keel/extension/credentialshelper"
+	"github.com/keel-hq/keel/registry"
+	"github.com/keel-hq/keel/types"
+	"github.com/keel-hq/keel/util/image"
+
+	dockerregistry "github.com/rusenask/docker-registry-client/registry"
+)
+
+// refreshingCredentialsHelper - hands out new token after cached one is invalidated
+type refreshingCredentialsHelper struct {
+	token       string
+	invalidated int
+}
+
+func (h *refreshingCredentialsHelper) GetCredentials(image *types.TrackedImage) (*types.Credentials, error) {
+	return &types.Credentials{Username: "AWS", Password: h.token}, nil
+}
+
+func (h *refreshingCredentialsHelper) IsEnabled() bool { return true }
+
+func (h *refreshingCredentialsHelper) InvalidateCredentials(image *types.TrackedImage) {
+	h.invalidated++
+	h.token = "refreshed"
+}
+
+func TestWithCredentialsRefreshesRejectedToken(t *testing.T) {
+	helper := &refreshingCredentialsHelper{token: "expired"}
+	credentialshelper.RegisterCredentialsHelper("refreshing", helper)
+	defer credentialshelper.UnregisterCredentialsHelper("refreshing")
+
+	reference, _ := image.Parse("528670773427.dkr.ecr.us-east-2.amazonaws.com/foo/bar:1.1")
+	ti := &types.TrackedImage{Image: reference}
+
+	var passwords []string
+	opts := registry.Opts{Name: "foo/bar", Tag: "1.1"}
+	err := withCredentials(ti, &opts, func() error {
+		passwords = append(passwords, opts.Password)
+		if opts.Password == "expired" {
+			return &dockerregistry.HttpStatusError{Response: &http.Response{StatusCode: http.StatusUnauthorized}}
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if helper.invalidated != 1 {
+		t.Errorf("expected token to be invalidated once, got: %d", helper.invalidated)
+	}
+	if len(passwords) != 2 || passwords[1] != "refreshed" {
+		t.Errorf("expected retry with refreshed token, got: %v", passwords)
+	}
+}
+
+func TestWithCredentialsNoRetryWithoutRefresh(t *testing.T) {
+	fakeHelper := &fakeCredentialsHelper{
+		creds: &types.Credentials{
+			Username: "user-xx",
+			Password: "pass-xx",
+		},
+	}
+	credentialshelper.RegisterCredentialsHelper("fake", fakeHelper)
+	defer credentialshelper.UnregisterCredentialsHelper("fake")
+
+	reference, _ := image.Parse("foo/bar:1.1")
+	ti := &types.TrackedImage{Image: reference}
+
+	calls := 0
+	opts := registry.Opts{Name: "foo/bar", Tag: "1.1"}
+	err := withCredentials(ti, &opts, func() error {
+		calls++
+		return &dockerregistry.HttpStatusError{Response: &http.Response{StatusCode: http.StatusUnauthorized}}
+	})
+	if !registry.IsUnauthorized(err) {
+		t.Errorf("expected unauthorized error, got: %v", err)
+	}
+	if calls != 1 {
+		t.Errorf("expected single call, got: %d", calls)
+	}
+}
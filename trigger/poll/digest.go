@@ -0,0 +1,36 @@
+package poll
+
+import (
+	"time"
+
+	"github.com/keel-hq/keel/registry"
+	"github.com/keel-hq/keel/types"
+)
+
+// DigestResolver - looks up image digests in the registry with credentials of
+// the tracked image, used by providers to pin updated images by digest
+type DigestResolver struct {
+	registryClient registry.Client
+}
+
+// NewDigestResolver - new registry backed digest resolver
+func NewDigestResolver(registryClient registry.Client) *DigestResolver {
+	return &DigestResolver{registryClient: registryClient}
+}
+
+// Digest - digest of the tracked image tag
+func (r *DigestResolver) Digest(ti *types.TrackedImage) (digest string, err error) {
+	registryOpts := registry.Opts{
+		Registry: ti.Image.Scheme() + "://" + ti.Image.Registry(),
+		Name:     ti.Image.ShortName(),
+		Tag:      ti.Image.Tag(),
+	}
+
+	started := time.Now()
+	err = withCredentials(ti, &registryOpts, func() (err error) {
+		digest, err = r.registryClient.Digest(registryOpts)
+		return err
+	})
+	observeRegistryPoll(ti.Image.Registry(), operationManifest, started, err)
+	return digest, err
+}
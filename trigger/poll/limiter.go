@@ -0,0 +1,70 @@
+package poll
+
+import (
+	"sync"
+)
+
+// limiter - bounds concurrent registry checks so that one slow registry
+// doesn't pile up goroutines and big registries aren't hammered
+type limiter struct {
+	total chan struct{}
+
+	perRegistry int
+	mu          sync.Mutex
+	registries  map[string]chan struct{}
+}
+
+// newLimiter - zero total or perRegistry means unlimited
+func newLimiter(total, perRegistry int) *limiter {
+	l := &limiter{
+		perRegistry: perRegistry,
+		registries:  make(map[string]chan struct{}),
+	}
+	if total > 0 {
+		l.total = make(chan struct{}, total)
+	}
+	return l
+}
+
+// acquire - blocks until check against registry can run, returned function
+// has to be called once check is done
+func (l *limiter) acquire(registry string) (release func()) {
+	if l == nil {
+		return func() {}
+	}
+
+	// registry slot first so checks waiting on a busy registry don't
+	// hold workers that other registries could use
+	reg := l.registry(registry)
+	if reg != nil {
+		reg <- struct{}{}
+	}
+	if l.total != nil {
+		l.total <- struct{}{}
+	}
+
+	return func() {
+		if l.total != nil {
+			<-l.total
+		}
+		if reg != nil {
+			<-reg
+		}
+	}
+}
+
+func (l *limiter) registry(registry string) chan struct{} {
+	if l.perRegistry <= 0 {
+		return nil
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	reg, ok := l.registries[registry]
+	if !ok {
+		reg = make(chan struct{}, l.perRegistry)
+		l.registries[registry] = reg
+	}
+	return reg
+}
@@ -0,0 +1,61 @@
+package poll
+
+import (
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestLimiter(t *testing.T) {
+	l := newLimiter(3, 1)
+
+	var mu sync.Mutex
+	running := map[string]int{}
+	maxRunning := map[string]int{}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		registry := "gcr.io"
+		if i%2 == 0 {
+			registry = "index.docker.io"
+		}
+		wg.Add(1)
+		go func(registry string) {
+			defer wg.Done()
+			release := l.acquire(registry)
+			defer release()
+
+			mu.Lock()
+			running[registry]++
+			if running[registry] > maxRunning[registry] {
+				maxRunning[registry] = running[registry]
+			}
+			mu.Unlock()
+
+			time.Sleep(10 * time.Millisecond)
+
+			mu.Lock()
+			running[registry]--
+			mu.Unlock()
+		}(registry)
+	}
+	wg.Wait()
+
+	for registry, max := range maxRunning {
+		if max != 1 {
+			t.Errorf("expected 1 concurrent check against %s, got: %d", registry, max)
+		}
+	}
+}
+
+func TestLimiterUnlimited(t *testing.T) {
+	var l *limiter
+	release := l.acquire("gcr.io")
+	release()
+
+	l = newLimiter(0, 0)
+	for i := 0; i < 100; i++ {
+		// would block if limited
+		l.acquire("gcr.io")
+	}
+}
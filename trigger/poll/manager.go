@@ -2,14 +2,46 @@ package poll
 
 import (
 	"context"
+	"fmt"
 	"sync"
 	"time"
 
+	"github.com/keel-hq/keel/pkg/tracing"
 	"github.com/keel-hq/keel/provider"
 
+	"github.com/prometheus/client_golang/prometheus"
+	"go.opentelemetry.io/otel/attribute"
+
 	log "github.com/sirupsen/logrus"
 )
 
+var pollScansSkippedCounter = prometheus.NewCounter(
+	prometheus.CounterOpts{
+		Name: "poll_trigger_scans_skipped_total",
+		Help: "How many periodic scans of tracked images were skipped because the previous scan was still running.",
+	},
+)
+
+func init() {
+	prometheus.MustRegister(pollScansSkippedCounter)
+}
+
+// defaultScanInterval - how often tracked images are scanned
+const defaultScanInterval = 3 * time.Second
+
+// resyncInterval - how often tracked images are rescanned when changes are
+// announced by a Notifier
+const resyncInterval = time.Minute
+
+// changeDelay - how long to wait for a burst of changes (ie: rollout) to settle
+// before rescanning
+const changeDelay = time.Second
+
+// Notifier - announces tracked resource changes, ie: kubernetes informer events
+type Notifier interface {
+	Register(ch chan int, last int)
+}
+
 // DefaultManager - default manager is responsible for scanning deployments and identifying
 // deployments that have market
 type DefaultManager struct {
@@ -20,11 +52,23 @@ type DefaultManager struct {
 
 	mu *sync.Mutex
 
-	// scanTick - scan interval in seconds, defaults to 60 seconds
-	scanTick int
+	// scanInterval - how often tracked images are scanned, defaults to
+	// defaultScanInterval or resyncInterval when there's a notifier
+	scanInterval time.Duration
+
+	// optional, rescan on changes instead of every scanInterval
+	notifier Notifier
 
 	// root context
 	ctx context.Context
+
+	// lastTick - last time scan loop ran, lastScan - last successful scan
+	stateMu  sync.RWMutex
+	lastTick time.Time
+	lastScan time.Time
+
+	// closed once the initial scan finished
+	initialScan chan struct{}
 }
 
 // NewPollManager - new default poller
@@ -33,10 +77,35 @@ func NewPollManager(providers provider.Providers, watcher Watcher) *DefaultManag
 		providers: providers,
 		watcher:   watcher,
 		mu:        &sync.Mutex{},
-		scanTick:  3,
+
+		initialScan: make(chan struct{}),
 	}
 }
 
+// WithNotifier - rescan tracked images as soon as resources change, periodic
+// scans are then only used to resync
+func (s *DefaultManager) WithNotifier(n Notifier) *DefaultManager {
+	s.notifier = n
+	return s
+}
+
+// WithScanInterval - override how often tracked images are scanned
+func (s *DefaultManager) WithScanInterval(interval time.Duration) *DefaultManager {
+	s.scanInterval = interval
+	return s
+}
+
+// interval - how often tracked images are scanned periodically
+func (s *DefaultManager) interval() time.Duration {
+	switch {
+	case s.scanInterval > 0:
+		return s.scanInterval
+	case s.notifier != nil:
+		return resyncInterval
+	}
+	return defaultScanInterval
+}
+
 // Start - start scanning deployment for changes
 func (s *DefaultManager) Start(ctx context.Context) error {
 	// setting root context
@@ -46,21 +115,50 @@ func (s *DefaultManager) Start(ctx context.Context) error {
 
 	// initial scan
 	err := s.scan(ctx)
+	s.scanned(err)
 	if err != nil {
 		log.WithFields(log.Fields{
 			"error": err,
 		}).Error("trigger.poll.manager: scan failed")
 	}
+	close(s.initialScan)
+
+	// nil channel blocks forever when there's no notifier
+	var changes chan int
+	var last int
+	if s.notifier != nil {
+		changes = make(chan int, 1)
+		s.notifier.Register(changes, last)
+	}
 
-	ticker := time.NewTicker(time.Duration(s.scanTick) * time.Second)
+	ticker := time.NewTicker(s.interval())
 	defer ticker.Stop()
 
 	for {
 		select {
 		case <-ctx.Done():
 			return nil
-		case <-ticker.C:
+		case last = <-changes:
+			select {
+			case <-ctx.Done():
+				return nil
+			case <-time.After(changeDelay):
+			}
+			err := s.scan(ctx)
+			s.scanned(err)
+			if err != nil {
+				log.WithFields(log.Fields{
+					"error": err,
+				}).Error("trigger.poll.manager: kubernetes scan failed")
+			}
+			// changes that happened during the scan are delivered immediately
+			s.notifier.Register(changes, last)
+		case tick := <-ticker.C:
+			if s.overlapped(tick) {
+				continue
+			}
 			err := s.scan(ctx)
+			s.scanned(err)
 			if err != nil {
 				log.WithFields(log.Fields{
 					"error": err,
@@ -70,11 +168,15 @@ func (s *DefaultManager) Start(ctx context.Context) error {
 	}
 }
 
-func (s *DefaultManager) scan(ctx context.Context) error {
+func (s *DefaultManager) scan(ctx context.Context) (err error) {
+	_, span := tracing.Start(ctx, "poll.scan")
+	defer func() { tracing.End(span, err) }()
+
 	trackedImages, err := s.providers.TrackedImages()
 	if err != nil {
 		return err
 	}
+	span.SetAttributes(attribute.Int("tracked_images", len(trackedImages)))
 
 	err = s.watcher.Watch(trackedImages...)
 	if err != nil {
@@ -85,3 +187,74 @@ func (s *DefaultManager) scan(ctx context.Context) error {
 
 	return nil
 }
+
+func (s *DefaultManager) scanned(err error) {
+	s.stateMu.Lock()
+	defer s.stateMu.Unlock()
+
+	s.lastTick = time.Now()
+	if err == nil {
+		s.lastScan = s.lastTick
+	}
+}
+
+// overlapped - whether the tick fired while the previous scan was running,
+// ticker keeps such tick and would start another scan right after a scan that
+// took longer than the interval
+func (s *DefaultManager) overlapped(tick time.Time) bool {
+	s.stateMu.RLock()
+	lastTick := s.lastTick
+	s.stateMu.RUnlock()
+
+	if !tick.Before(lastTick) {
+		return false
+	}
+
+	pollScansSkippedCounter.Inc()
+	log.WithFields(log.Fields{
+		"interval": s.interval(),
+	}).Warn("trigger.poll.manager: previous scan took longer than scan interval, skipping scan")
+	return true
+}
+
+// staleAfter - how long scan loop can be silent before it's considered stuck
+func (s *DefaultManager) staleAfter() time.Duration {
+	stale := 10 * s.interval()
+	if stale < time.Minute {
+		return time.Minute
+	}
+	return stale
+}
+
+// Alive - returns error if scan loop has started but stopped ticking
+func (s *DefaultManager) Alive() error {
+	s.stateMu.RLock()
+	defer s.stateMu.RUnlock()
+
+	if s.lastTick.IsZero() {
+		return nil
+	}
+	if since := time.Since(s.lastTick); since > s.staleAfter() {
+		return fmt.Errorf("scan loop hasn't run for %s", since.Round(time.Second))
+	}
+	return nil
+}
+
+// Scanned - closed once the initial scan finished, successful or not
+func (s *DefaultManager) Scanned() <-chan struct{} {
+	return s.initialScan
+}
+
+// Ready - returns error if tracked images couldn't be scanned recently
+func (s *DefaultManager) Ready() error {
+	s.stateMu.RLock()
+	defer s.stateMu.RUnlock()
+
+	if s.lastScan.IsZero() {
+		return fmt.Errorf("initial scan hasn't completed")
+	}
+	if since := time.Since(s.lastScan); since > s.staleAfter() {
+		return fmt.Errorf("last successful scan was %s ago", since.Round(time.Second))
+	}
+	return nil
+}
@@ -2,12 +2,15 @@ package poll
 
 import (
 	"context"
+	"fmt"
 	"io/ioutil"
 	"log"
 	"os"
 	"path/filepath"
+	"time"
 
 	"github.com/keel-hq/keel/approvals"
+	"github.com/keel-hq/keel/internal/k8s"
 	"github.com/keel-hq/keel/pkg/store/sql"
 
 	// "github.com/keel-hq/keel/cache/memory"
@@ -195,3 +198,147 @@ func TestCheckECRDeployment(t *testing.T) {
 		t.Errorf("unexpected tag: %s", watcher.watched[keyA].trackedImage.Image.Tag())
 	}
 }
+
+func TestManagerAliveAndReady(t *testing.T) {
+	pm := NewPollManager(nil, nil)
+
+	if err := pm.Alive(); err != nil {
+		t.Errorf("expected manager to be alive before start, got: %s", err)
+	}
+	if err := pm.Ready(); err == nil {
+		t.Errorf("expected manager not to be ready before initial scan")
+	}
+
+	pm.scanned(nil)
+	if err := pm.Alive(); err != nil {
+		t.Errorf("unexpected error: %s", err)
+	}
+	if err := pm.Ready(); err != nil {
+		t.Errorf("unexpected error: %s", err)
+	}
+
+	// failed scans keep the loop alive but make manager unready once stale
+	pm.stateMu.Lock()
+	pm.lastScan = time.Now().Add(-2 * pm.staleAfter())
+	pm.stateMu.Unlock()
+	pm.scanned(fmt.Errorf("failed to list tracked images"))
+
+	if err := pm.Alive(); err != nil {
+		t.Errorf("unexpected error: %s", err)
+	}
+	if err := pm.Ready(); err == nil {
+		t.Errorf("expected manager not to be ready after stale scan")
+	}
+
+	// stuck loop
+	pm.stateMu.Lock()
+	pm.lastTick = time.Now().Add(-2 * pm.staleAfter())
+	pm.stateMu.Unlock()
+
+	if err := pm.Alive(); err == nil {
+		t.Errorf("expected manager not to be alive after loop got stuck")
+	}
+}
+
+func TestManagerSkipsOverlappingTicks(t *testing.T) {
+	pm := NewPollManager(nil, nil)
+
+	// scan started before the tick and finished after it
+	tick := time.Now().Add(-time.Millisecond)
+	pm.scanned(nil)
+
+	if !pm.overlapped(tick) {
+		t.Errorf("expected tick queued during scan to be skipped")
+	}
+	if pm.overlapped(time.Now()) {
+		t.Errorf("didn't expect tick after scan to be skipped")
+	}
+}
+
+type countingWatcher struct {
+	watched chan int
+}
+
+func (w *countingWatcher) Watch(images ...*types.TrackedImage) error {
+	w.watched <- len(images)
+	return nil
+}
+
+func (w *countingWatcher) Unwatch(image string) error {
+	return nil
+}
+
+func (w *countingWatcher) WatchState(ref *image.Reference) (*types.WatchState, bool) {
+	return nil, false
+}
+
+func TestManagerRescansOnChanges(t *testing.T) {
+	imgA, _ := image.Parse("gcr.io/v2-namespace/hello-world:1.1.1")
+	fp := &fakeProvider{
+		images: []*types.TrackedImage{
+			{
+				Image:        imgA,
+				Trigger:      types.TriggerTypePoll,
+				Provider:     "fp",
+				PollSchedule: types.KeelPollDefaultSchedule,
+			},
+		},
+	}
+
+	store, teardown := newTestingUtils()
+	defer teardown()
+	am := approvals.New(&approvals.Opts{
+		Store: store,
+	})
+
+	providers := provider.New([]provider.Provider{fp}, am)
+
+	watcher := &countingWatcher{watched: make(chan int, 10)}
+	notifier := &k8s.Cond{}
+	pm := NewPollManager(providers, watcher).WithNotifier(notifier)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go pm.Start(ctx)
+
+	// initial scan
+	select {
+	case <-watcher.watched:
+	case <-time.After(5 * time.Second):
+		t.Fatalf("expected initial scan")
+	}
+	select {
+	case <-pm.Scanned():
+	case <-time.After(5 * time.Second):
+		t.Fatalf("expected initial scan to be announced")
+	}
+
+	// resync is far away, scan should be triggered by the change
+	notifier.Notify()
+
+	select {
+	case count := <-watcher.watched:
+		if count != 1 {
+			t.Errorf("expected 1 tracked image, got: %d", count)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatalf("expected scan after change")
+	}
+}
+
+func TestManagerInterval(t *testing.T) {
+	pm := NewPollManager(nil, nil)
+	if pm.interval() != defaultScanInterval {
+		t.Errorf("unexpected default interval: %s", pm.interval())
+	}
+
+	pm.WithNotifier(&k8s.Cond{})
+	if pm.interval() != resyncInterval {
+		t.Errorf("expected resync interval with notifier, got: %s", pm.interval())
+	}
+
+	pm.WithScanInterval(30 * time.Second)
+	if pm.interval() != 30*time.Second {
+		t.Errorf("expected configured interval, got: %s", pm.interval())
+	}
+}
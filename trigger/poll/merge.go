@@ -0,0 +1,101 @@
+package poll
+
+import (
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/keel-hq/keel/types"
+
+	"github.com/rusenask/cron"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// scheduleSamples - activations used to estimate how often a schedule runs
+const scheduleSamples = 10
+
+// scheduleReference - fixed point in time schedules are compared from, so
+// merging is stable between scans
+var scheduleReference = time.Date(2000, time.January, 1, 0, 0, 0, 0, time.UTC)
+
+// credentialsKey - identifies credentials used to query the registry. Images
+// without explicit pull secrets share credentials helpers and can be merged
+// across namespaces
+func credentialsKey(ti *types.TrackedImage) string {
+	if ti.PollCredentials != "" {
+		return ti.Namespace + "/poll=" + ti.PollCredentials
+	}
+	if len(ti.Secrets) == 0 {
+		return ""
+	}
+	secrets := append([]string{}, ti.Secrets...)
+	sort.Strings(secrets)
+	return ti.Namespace + "/" + strings.Join(secrets, ",")
+}
+
+// watchKey - images with the same key are checked by a single watch
+func watchKey(ti *types.TrackedImage) string {
+	key := getImageIdentifier(ti.Image)
+	if ordersByCreated(ti) {
+		key = key + "#order=" + ti.TagOrder
+	}
+	if creds := credentialsKey(ti); creds != "" {
+		key = key + "#" + creds
+	}
+	return key
+}
+
+// watchesImage - whether watch key belongs to the image identifier
+func watchesImage(key, identifier string) bool {
+	return key == identifier || strings.HasPrefix(key, identifier+"#")
+}
+
+// scheduleInterval - average time between schedule activations
+func scheduleInterval(spec string) (time.Duration, error) {
+	schedule, err := cron.Parse(spec)
+	if err != nil {
+		return 0, err
+	}
+	first := schedule.Next(scheduleReference)
+	next := first
+	for i := 0; i < scheduleSamples; i++ {
+		next = schedule.Next(next)
+	}
+	return next.Sub(first) / scheduleSamples, nil
+}
+
+// mergeTrackedImages - merges images checked by the same watch, the most
+// frequent of requested schedules is used and pre-release tags are combined
+func mergeTrackedImages(images ...*types.TrackedImage) *types.TrackedImage {
+	merged := *images[0]
+
+	var (
+		best     time.Duration
+		tags     []string
+		seenTags = map[string]bool{}
+	)
+	for _, ti := range images {
+		interval, err := scheduleInterval(ti.PollSchedule)
+		if err != nil {
+			log.WithFields(log.Fields{
+				"error":    err,
+				"image":    ti.String(),
+				"schedule": ti.PollSchedule,
+			}).Error("trigger.poll.RepositoryWatcher: invalid cron schedule, ignoring it")
+		} else if best == 0 || interval < best || (interval == best && ti.PollSchedule < merged.PollSchedule) {
+			best = interval
+			merged.PollSchedule = ti.PollSchedule
+		}
+
+		for _, tag := range ti.Tags {
+			if !seenTags[tag] {
+				seenTags[tag] = true
+				tags = append(tags, tag)
+			}
+		}
+	}
+	merged.Tags = tags
+
+	return &merged
+}
@@ -0,0 +1,138 @@
+package poll
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/keel-hq/keel/approvals"
+	"github.com/keel-hq/keel/provider"
+)
+
+func TestScheduleInterval(t *testing.T) {
+	tests := []struct {
+		spec string
+		want time.Duration
+	}{
+		{spec: "@every 10m", want: 10 * time.Minute},
+		{spec: "@hourly", want: time.Hour},
+		{spec: "0 */5 * * * *", want: 5 * time.Minute},
+	}
+	for _, tt := range tests {
+		got, err := scheduleInterval(tt.spec)
+		if err != nil {
+			t.Errorf("%s: unexpected error: %s", tt.spec, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("%s: expected %s, got %s", tt.spec, tt.want, got)
+		}
+	}
+
+	if _, err := scheduleInterval("not a schedule"); err == nil {
+		t.Errorf("expected error for invalid schedule")
+	}
+}
+
+func TestMergeTrackedImages(t *testing.T) {
+	a := mustParse("gcr.io/v2-namespace/hello-world:1.1.1", "@every 10m")
+	a.Tags = []string{"dev"}
+	b := mustParse("gcr.io/v2-namespace/hello-world:1.1.1", "@every 1m")
+	b.Tags = []string{"dev", "prod"}
+	c := mustParse("gcr.io/v2-namespace/hello-world:1.1.1", "invalid")
+
+	merged := mergeTrackedImages(a, b, c)
+	if merged.PollSchedule != "@every 1m" {
+		t.Errorf("expected most frequent schedule, got: %s", merged.PollSchedule)
+	}
+	if len(merged.Tags) != 2 || merged.Tags[0] != "dev" || merged.Tags[1] != "prod" {
+		t.Errorf("unexpected tags: %v", merged.Tags)
+	}
+	if a.PollSchedule != "@every 10m" {
+		t.Errorf("merged images shouldn't be modified")
+	}
+}
+
+func TestWatchKeyCredentials(t *testing.T) {
+	public := mustParse("gcr.io/v2-namespace/hello-world:1.1.1", "@every 10m")
+	public.Namespace = "default"
+	other := mustParse("gcr.io/v2-namespace/hello-world:1.1.1", "@every 10m")
+	other.Namespace = "other"
+
+	if watchKey(public) != watchKey(other) {
+		t.Errorf("expected images without pull secrets to share a watch")
+	}
+
+	private := mustParse("gcr.io/v2-namespace/hello-world:1.1.1", "@every 10m")
+	private.Namespace = "other"
+	private.Secrets = []string{"b", "a"}
+	if watchKey(private) == watchKey(public) {
+		t.Errorf("expected images with pull secrets to have their own watch")
+	}
+	if watchKey(private) != "gcr.io/v2-namespace/hello-world#other/a,b" {
+		t.Errorf("unexpected key: %s", watchKey(private))
+	}
+
+	polled := mustParse("gcr.io/v2-namespace/hello-world:1.1.1", "@every 10m")
+	polled.Namespace = "other"
+	polled.Secrets = []string{"b", "a"}
+	polled.PollCredentials = "cache-creds"
+	if watchKey(polled) != "gcr.io/v2-namespace/hello-world#other/poll=cache-creds" {
+		t.Errorf("unexpected key: %s", watchKey(polled))
+	}
+}
+
+func TestWatchMergesConsumers(t *testing.T) {
+	fp := &fakeProvider{}
+	store, teardown := newTestingUtils()
+	defer teardown()
+	am := approvals.New(&approvals.Opts{
+		Store: store,
+	})
+
+	providers := provider.New([]provider.Provider{fp}, am)
+
+	frc := &fakeRegistryClient{
+		digestToReturn: "sha256:0604af35299dd37ff23937d115d103532948b568a9dd8197d14c256a8ab8b0bb",
+		tagsToReturn:   []string{"5.0.0"},
+	}
+
+	watcher := NewRepositoryWatcher(providers, frc)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	watcher.Start(ctx)
+
+	slow := mustParse("gcr.io/v2-namespace/hello-world:1.1.1", "@every 10m")
+	slow.Identifier = "deployment/default/slow"
+	fast := mustParse("gcr.io/v2-namespace/hello-world:1.2.0", "@every 1m")
+	fast.Identifier = "deployment/default/fast"
+
+	err := watcher.Watch(slow, fast)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if len(watcher.watched) != 1 {
+		t.Fatalf("expected a single watch, found: %d", len(watcher.watched))
+	}
+	if len(watcher.cron.Entries()) != 1 {
+		t.Errorf("expected a single cron job, found: %d", len(watcher.cron.Entries()))
+	}
+
+	details := watcher.watched["gcr.io/v2-namespace/hello-world"]
+	if details.schedule != "@every 1m" {
+		t.Errorf("expected the most frequent schedule, got: %s", details.schedule)
+	}
+	if len(details.consumers) != 2 {
+		t.Errorf("expected both resources to consume the watch, got: %v", details.consumers)
+	}
+
+	// the frequent consumer is gone, watch slows down
+	err = watcher.Watch(slow)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if details.schedule != "@every 10m" {
+		t.Errorf("expected schedule to be updated, got: %s", details.schedule)
+	}
+}
@@ -1,16 +1,20 @@
 package poll
 
 import (
+	"context"
 	"sort"
 	"strings"
+	"time"
 
 	"github.com/Masterminds/semver"
-	"github.com/keel-hq/keel/extension/credentialshelper"
+	"github.com/keel-hq/keel/internal/policy"
+	"github.com/keel-hq/keel/pkg/tracing"
 	"github.com/keel-hq/keel/provider"
 	"github.com/keel-hq/keel/registry"
 	"github.com/keel-hq/keel/types"
 
 	"github.com/prometheus/client_golang/prometheus"
+	"go.opentelemetry.io/otel/attribute"
 
 	log "github.com/sirupsen/logrus"
 )
@@ -39,6 +43,11 @@ func (j *WatchRepositoryTagsJob) Run() {
 	j.details.mu.RLock()
 	defer j.details.mu.RUnlock()
 
+	ctx, span := tracing.Start(context.Background(), "poll.watch_repository_tags",
+		attribute.String("image", j.details.trackedImage.Image.String()),
+	)
+	defer span.End()
+
 	reg := j.details.trackedImage.Image.Scheme() + "://" + j.details.trackedImage.Image.Registry()
 	if j.details.latest == "" {
 		j.details.latest = j.details.trackedImage.Image.Tag()
@@ -50,15 +59,26 @@ func (j *WatchRepositoryTagsJob) Run() {
 		Tag:      j.details.latest,
 	}
 
-	creds, err := credentialshelper.GetCredentials(j.details.trackedImage)
-	if err == nil {
-		registryOpts.Username = creds.Username
-		registryOpts.Password = creds.Password
-	}
-
-	repository, err := j.registryClient.Get(registryOpts)
+	_, lookupSpan := tracing.Start(ctx, "registry.tags", attribute.String("registry", reg))
+	started := time.Now()
+	var repository *registry.Repository
+	err := withCredentials(j.details.trackedImage, &registryOpts, func() (err error) {
+		repository, err = j.registryClient.Get(registryOpts)
+		return err
+	})
+	tracing.End(lookupSpan, err)
+	observeRegistryPoll(j.details.trackedImage.Image.Registry(), operationTags, started, err)
 
+	if registry.IsRateLimited(err) {
+		log.WithFields(log.Fields{
+			"error": err,
+			"image": j.details.trackedImage.Image.String(),
+		}).Debug("trigger.poll.WatchRepositoryTagsJob: registry is rate limited, skipping check")
+		j.details.rateLimited()
+		return
+	}
 	if err != nil {
+		j.details.polled("", "", err)
 		log.WithFields(log.Fields{
 			"error":        err,
 			"registry_url": reg,
@@ -67,6 +87,13 @@ func (j *WatchRepositoryTagsJob) Run() {
 		return
 	}
 
+	// highest version in the registry, current tag if there are no versions
+	seenTag := j.details.trackedImage.Image.Tag()
+	if versions := semverSort(repository.Tags); len(versions) > 0 {
+		seenTag = versions[0].Original()
+	}
+	j.details.polled(seenTag, j.details.digest, nil)
+
 	registriesScannedCounter.With(prometheus.Labels{"registry": j.details.trackedImage.Image.Registry(), "image": j.details.trackedImage.Image.Repository()}).Inc()
 
 	log.WithFields(log.Fields{
@@ -75,7 +102,7 @@ func (j *WatchRepositoryTagsJob) Run() {
 		"image_name":      j.details.trackedImage.Image.Remote(),
 	}).Debug("trigger.poll.WatchRepositoryTagsJob: checking tags")
 
-	err = j.processTags(repository.Tags)
+	err = j.processTags(ctx, registryOpts, repository.Tags)
 	if err != nil {
 		log.WithFields(log.Fields{
 			"error":           err,
@@ -164,14 +191,22 @@ func semverSort(tags []string) []*semver.Version {
 		versions = append(versions, v)
 	}
 	// Sort desc, following semver
-	sort.Slice(versions, func(i, j int) bool { return versions[j].LessThan(versions[i]) })
+	sort.Slice(versions, func(i, j int) bool {
+		if versions[i].Equal(versions[j]) {
+			// build metadata only matters to policies with keel.sh/buildMetadata=order
+			return policy.CompareBuildMetadata(versions[j].Metadata(), versions[i].Metadata()) < 0
+		}
+		return versions[j].LessThan(versions[i])
+	})
 	return versions
 }
 
+// getRelatedTrackedImages - images of the same repository that are checked with
+// the same credentials, other credentials have their own watch
 func getRelatedTrackedImages(ours *types.TrackedImage, all []*types.TrackedImage) []*types.TrackedImage {
 	b := all[:0]
 	for _, x := range all {
-		if x.Image.Repository() == ours.Image.Repository() {
+		if x.Image.Repository() == ours.Image.Repository() && credentialsKey(x) == credentialsKey(ours) {
 			b = append(b, x)
 		}
 	}
@@ -179,13 +214,29 @@ func getRelatedTrackedImages(ours *types.TrackedImage, all []*types.TrackedImage
 	return b
 }
 
-func (j *WatchRepositoryTagsJob) processTags(tags []string) error {
+func (j *WatchRepositoryTagsJob) processTags(ctx context.Context, registryOpts registry.Opts, tags []string) error {
 
 	events, err := j.computeEvents(tags)
 	if err != nil {
 		return err
 	}
 	for _, e := range events {
+		e.TraceContext = tracing.Inject(ctx)
+
+		// resolving digest of the new tag so images that are pinned
+		// by both tag and digest can be updated
+		registryOpts.Tag = e.Repository.Tag
+		digest, err := j.registryClient.Digest(registryOpts)
+		if err != nil {
+			log.WithFields(log.Fields{
+				"error":   err,
+				"image":   j.details.trackedImage.Image.String(),
+				"new_tag": e.Repository.Tag,
+			}).Warn("trigger.poll.WatchRepositoryTagsJob: failed to resolve digest for the new tag")
+		} else {
+			e.Repository.Digest = digest
+		}
+
 		err = j.providers.Submit(e)
 		if err != nil {
 			log.WithFields(log.Fields{
@@ -193,6 +244,8 @@ func (j *WatchRepositoryTagsJob) processTags(tags []string) error {
 				"new_tag":    e.Repository.Tag,
 				"error":      err,
 			}).Error("trigger.poll.WatchRepositoryTagsJob: error while submitting an event")
+		} else {
+			j.details.submitted()
 		}
 	}
 	return nil
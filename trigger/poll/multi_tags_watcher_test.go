@@ -313,3 +313,19 @@ func TestWatchMultipleTagsWithCredentialsHelper(t *testing.T) {
 	})
 
 }
+
+func TestWatchAllTagsVersionPrefix(t *testing.T) {
+	availableTags := []string{"v1.2.5", "1.2.4", "v1.2.3"}
+
+	tolerated := policy.NewSemverPolicy(policy.SemverPolicyTypePatch, true)
+	testRunHelper([]runTestCase{{"1.2.3", "v1.2.5", tolerated}}, availableTags, t)
+
+	matched := policy.NewSemverPolicy(policy.SemverPolicyTypePatch, true)
+	matched.SetVersionHandling(policy.VersionPrefixMatch, "")
+	testRunHelper([]runTestCase{{"1.2.3", "1.2.4", matched}}, availableTags, t)
+	testRunHelper([]runTestCase{{"v1.2.3", "v1.2.5", matched}}, availableTags, t)
+
+	rejected := policy.NewSemverPolicy(policy.SemverPolicyTypePatch, true)
+	rejected.SetVersionHandling(policy.VersionPrefixReject, "")
+	testRunHelper([]runTestCase{{"1.2.3", "1.2.4", rejected}}, availableTags, t)
+}
@@ -1,12 +1,16 @@
 package poll
 
 import (
-	"github.com/keel-hq/keel/extension/credentialshelper"
+	"context"
+	"time"
+
+	"github.com/keel-hq/keel/pkg/tracing"
 	"github.com/keel-hq/keel/provider"
 	"github.com/keel-hq/keel/registry"
 	"github.com/keel-hq/keel/types"
 	"github.com/prometheus/client_golang/prometheus"
 	log "github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel/attribute"
 )
 
 // WatchTagJob - Watch specific tag job
@@ -28,6 +32,11 @@ func NewWatchTagJob(providers provider.Providers, registryClient registry.Client
 
 // Run - main function to check schedule
 func (j *WatchTagJob) Run() {
+	ctx, span := tracing.Start(context.Background(), "poll.watch_tag",
+		attribute.String("image", j.details.trackedImage.Image.String()),
+	)
+	defer span.End()
+
 	reg := j.details.trackedImage.Image.Scheme() + "://" + j.details.trackedImage.Image.Registry()
 	registryOpts := registry.Opts{
 		Registry: reg,
@@ -35,16 +44,27 @@ func (j *WatchTagJob) Run() {
 		Tag:      j.details.trackedImage.Image.Tag(),
 	}
 
-	creds, err := credentialshelper.GetCredentials(j.details.trackedImage)
-	if err == nil {
-		registryOpts.Username = creds.Username
-		registryOpts.Password = creds.Password
-	}
-
-	currentDigest, err := j.registryClient.Digest(registryOpts)
+	_, lookupSpan := tracing.Start(ctx, "registry.digest", attribute.String("registry", reg))
+	started := time.Now()
+	var currentDigest string
+	err := withCredentials(j.details.trackedImage, &registryOpts, func() (err error) {
+		currentDigest, err = j.registryClient.Digest(registryOpts)
+		return err
+	})
+	tracing.End(lookupSpan, err)
+	observeRegistryPoll(j.details.trackedImage.Image.Registry(), operationManifest, started, err)
 
 	registriesScannedCounter.With(prometheus.Labels{"registry": j.details.trackedImage.Image.Registry(), "image": j.details.trackedImage.Image.Repository()}).Inc()
 
+	if registry.IsRateLimited(err) {
+		log.WithFields(log.Fields{
+			"error": err,
+			"image": j.details.trackedImage.Image.String(),
+		}).Debug("trigger.poll.WatchTagJob: registry is rate limited, skipping check")
+		j.details.rateLimited()
+		return
+	}
+	j.details.polled(j.details.trackedImage.Image.Tag(), currentDigest, err)
 	if err != nil {
 		log.WithFields(log.Fields{
 			"error": err,
@@ -64,6 +84,7 @@ func (j *WatchTagJob) Run() {
 	if j.details.digest != currentDigest {
 		// updating digest
 		j.details.digest = currentDigest
+		j.details.persist()
 
 		event := types.Event{
 			Repository: types.Repository{
@@ -71,7 +92,8 @@ func (j *WatchTagJob) Run() {
 				Tag:    j.details.trackedImage.Image.Tag(),
 				Digest: currentDigest,
 			},
-			TriggerName: types.TriggerTypePoll.String(),
+			TriggerName:  types.TriggerTypePoll.String(),
+			TraceContext: tracing.Inject(ctx),
 		}
 		log.WithFields(log.Fields{
 			"image":      j.details.trackedImage.Image.String(),
@@ -86,6 +108,8 @@ func (j *WatchTagJob) Run() {
 				"digest":     currentDigest,
 				"error":      err,
 			}).Error("trigger.poll.WatchRepositoryTagsJob: error while submitting an event")
+		} else {
+			j.details.submitted()
 		}
 
 	}
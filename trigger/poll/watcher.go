@@ -2,11 +2,15 @@ package poll
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
+	"math/rand"
+	"strconv"
 	"strings"
 	"sync"
+	"time"
 
-	"github.com/keel-hq/keel/extension/credentialshelper"
+	"github.com/keel-hq/keel/cache"
 	"github.com/keel-hq/keel/provider"
 	"github.com/keel-hq/keel/registry"
 	"github.com/keel-hq/keel/types"
@@ -34,24 +38,221 @@ var pollTriggerTrackedImages = prometheus.NewGauge(
 	},
 )
 
+var registryPollDuration = prometheus.NewHistogramVec(
+	prometheus.HistogramOpts{
+		Name:    "registry_poll_duration_seconds",
+		Help:    "How long registry lookups took, partitioned by registry and operation (tags or manifest).",
+		Buckets: prometheus.DefBuckets,
+	},
+	[]string{"registry", "operation"},
+)
+
+var pollLastRunTimestamp = prometheus.NewGaugeVec(
+	prometheus.GaugeOpts{
+		Name: "poll_trigger_last_run_timestamp_seconds",
+		Help: "When watched image was last checked, partitioned by registry, image and result.",
+	},
+	[]string{"registry", "image", "result"},
+)
+
+var pollNextRunTimestamp = prometheus.NewGaugeVec(
+	prometheus.GaugeOpts{
+		Name: "poll_trigger_next_run_timestamp_seconds",
+		Help: "When watched image is checked next according to its schedule, partitioned by registry and image.",
+	},
+	[]string{"registry", "image"},
+)
+
+var registryPollErrorsCounter = prometheus.NewCounterVec(
+	prometheus.CounterOpts{
+		Name: "registry_poll_errors_total",
+		Help: "How many registry lookups failed, partitioned by registry, operation and status code.",
+	},
+	[]string{"registry", "operation", "code"},
+)
+
+// registry lookup operations
+const (
+	operationTags     = "tags"
+	operationManifest = "manifest"
+)
+
 func init() {
 	prometheus.MustRegister(registriesScannedCounter)
 	prometheus.MustRegister(pollTriggerTrackedImages)
+	prometheus.MustRegister(registryPollDuration)
+	prometheus.MustRegister(registryPollErrorsCounter)
+	prometheus.MustRegister(pollLastRunTimestamp)
+	prometheus.MustRegister(pollNextRunTimestamp)
+}
+
+// observeRegistryPoll - records registry lookup latency and failures, failures
+// without a response from the registry (ie: timeouts) are recorded with code "none"
+func observeRegistryPoll(registryHost, operation string, started time.Time, err error) {
+	registryPollDuration.With(prometheus.Labels{"registry": registryHost, "operation": operation}).Observe(time.Since(started).Seconds())
+	if err == nil {
+		return
+	}
+	code := "none"
+	if statusCode := registry.StatusCode(err); statusCode != 0 {
+		code = strconv.Itoa(statusCode)
+	}
+	registryPollErrorsCounter.With(prometheus.Labels{"registry": registryHost, "operation": operation, "code": code}).Inc()
 }
 
 // Watcher - generic watcher interface
 type Watcher interface {
 	Watch(image ...*types.TrackedImage) error
 	Unwatch(image string) error
+	// WatchState - job state of the watch that covers the image
+	WatchState(ref *image.Reference) (*types.WatchState, bool)
 }
 
 type watchDetails struct {
 	trackedImage *types.TrackedImage
 	digest       string // image digest
 	latest       string // latest tag
-	schedule     string
+	// poll schedule, written holding both RepositoryWatcher.mu and stateMu
+	schedule string
+
+	// job checking the registry, run on schedule or on demand
+	job cron.Job
+
+	// resources using the watched repository, guarded by RepositoryWatcher.mu
+	consumers map[string]bool
+
+	// optional, persists digest between restarts
+	cache cache.Cache
+	key   string
 
 	mu sync.RWMutex
+
+	// result of the last registry check
+	stateMu    sync.Mutex
+	lastPoll   time.Time
+	lastError  string
+	lastResult string
+	seenTag    string
+	seenDigest string
+}
+
+// polled - records registry check result, tag and digest are kept from
+// previous checks if empty
+func (d *watchDetails) polled(tag, digest string, err error) {
+	d.stateMu.Lock()
+	defer d.stateMu.Unlock()
+
+	d.lastPoll = time.Now()
+	if err != nil {
+		d.lastError = err.Error()
+		d.setResult(types.PollResultError)
+		return
+	}
+	d.lastError = ""
+	d.setResult(types.PollResultOK)
+	if tag != "" {
+		d.seenTag = tag
+	}
+	if digest != "" {
+		d.seenDigest = digest
+	}
+}
+
+// submitted - records that the last registry check found a new version
+func (d *watchDetails) submitted() {
+	d.stateMu.Lock()
+	defer d.stateMu.Unlock()
+	d.setResult(types.PollResultUpdate)
+}
+
+// rateLimited - records registry check skipped while registry backs off
+func (d *watchDetails) rateLimited() {
+	d.stateMu.Lock()
+	defer d.stateMu.Unlock()
+
+	d.lastPoll = time.Now()
+	d.setResult(types.PollResultRateLimited)
+}
+
+// setResult - updates last result and run metrics, stateMu must be held (it
+// also guards schedule read here from job goroutines)
+func (d *watchDetails) setResult(result string) {
+	registryHost, img := d.trackedImage.Image.Registry(), d.trackedImage.Image.Remote()
+	if d.lastResult != "" && d.lastResult != result {
+		pollLastRunTimestamp.DeleteLabelValues(registryHost, img, d.lastResult)
+	}
+	d.lastResult = result
+	pollLastRunTimestamp.With(prometheus.Labels{"registry": registryHost, "image": img, "result": result}).Set(float64(d.lastPoll.Unix()))
+
+	if d.schedule == "" {
+		return
+	}
+	if schedule, err := cron.Parse(d.schedule); err == nil {
+		pollNextRunTimestamp.With(prometheus.Labels{"registry": registryHost, "image": img}).Set(float64(schedule.Next(time.Now()).Unix()))
+	}
+}
+
+// forgetMetrics - drops run metrics of removed watch
+func (d *watchDetails) forgetMetrics() {
+	d.stateMu.Lock()
+	defer d.stateMu.Unlock()
+
+	registryHost, img := d.trackedImage.Image.Registry(), d.trackedImage.Image.Remote()
+	if d.lastResult != "" {
+		pollLastRunTimestamp.DeleteLabelValues(registryHost, img, d.lastResult)
+	}
+	pollNextRunTimestamp.DeleteLabelValues(registryHost, img)
+}
+
+func (d *watchDetails) state(next time.Time) *types.WatchState {
+	d.stateMu.Lock()
+	defer d.stateMu.Unlock()
+
+	state := &types.WatchState{
+		Image:      d.trackedImage.Image.Remote(),
+		Schedule:   d.schedule,
+		Tag:        d.seenTag,
+		Digest:     d.seenDigest,
+		LastPoll:   d.lastPoll,
+		LastError:  d.lastError,
+		LastResult: d.lastResult,
+	}
+	if !next.IsZero() {
+		state.NextPoll = &next
+	}
+	return state
+}
+
+// watchState - persisted watch details
+type watchState struct {
+	Digest string `json:"digest"`
+	Tag    string `json:"tag"`
+}
+
+func watchCacheKey(key string) string {
+	return "poll/" + key
+}
+
+func (d *watchDetails) persist() {
+	if d.cache == nil {
+		return
+	}
+
+	encoded, err := json.Marshal(&watchState{
+		Digest: d.digest,
+		Tag:    d.trackedImage.Image.Tag(),
+	})
+	if err != nil {
+		return
+	}
+
+	err = d.cache.Put(watchCacheKey(d.key), encoded)
+	if err != nil {
+		log.WithFields(log.Fields{
+			"error": err,
+			"image": d.trackedImage.Image.String(),
+		}).Warn("trigger.poll: failed to persist image digest")
+	}
 }
 
 // RepositoryWatcher - repository watcher cron
@@ -64,8 +265,29 @@ type RepositoryWatcher struct {
 	// internal map of internal watches
 	// map[registry/name]=image.Reference
 	watched map[string]*watchDetails
+	// guards watched, it's read by the tracked images API
+	mu sync.RWMutex
 
 	cron *cron.Cron
+
+	cache cache.Cache
+
+	// jitter - max random delay of scheduled registry checks
+	jitter time.Duration
+
+	// optional, bounds concurrent registry checks
+	limiter *limiter
+
+	// optional, only repositories owned by this replica are polled
+	shard Shard
+
+	// image creation times of tags ordered by creation
+	created *createdCache
+}
+
+// Shard - splits polled repositories between keel replicas
+type Shard interface {
+	Owns(key string) bool
 }
 
 // NewRepositoryWatcher - create new repository watcher
@@ -77,6 +299,94 @@ func NewRepositoryWatcher(providers provider.Providers, registryClient registry.
 		registryClient: registryClient,
 		watched:        make(map[string]*watchDetails),
 		cron:           c,
+		created:        newCreatedCache(),
+	}
+}
+
+// WithCache - persist last seen digests so that changes which happened while
+// keel wasn't running are detected after restart
+func (w *RepositoryWatcher) WithCache(c cache.Cache) *RepositoryWatcher {
+	w.cache = c
+	return w
+}
+
+// WithJitter - delay scheduled registry checks by a random duration up to
+// jitter so that images sharing a schedule (or keel instances) don't query
+// registries at the same instant
+func (w *RepositoryWatcher) WithJitter(jitter time.Duration) *RepositoryWatcher {
+	w.jitter = jitter
+	return w
+}
+
+// WithShard - only poll repositories assigned to this replica, the rest are
+// polled by other replicas sharing the shard group
+func (w *RepositoryWatcher) WithShard(shard Shard) *RepositoryWatcher {
+	w.shard = shard
+	return w
+}
+
+// WithConcurrency - limit how many registry checks run at the same time, in
+// total and per registry. Zero means unlimited
+func (w *RepositoryWatcher) WithConcurrency(total, perRegistry int) *RepositoryWatcher {
+	w.limiter = newLimiter(total, perRegistry)
+	return w
+}
+
+// scheduledJob - delays job runs and waits for a free worker, cron runs every
+// job in its own goroutine
+type scheduledJob struct {
+	job      cron.Job
+	registry string
+	jitter   time.Duration
+	limiter  *limiter
+}
+
+func (j *scheduledJob) Run() {
+	if j.jitter > 0 {
+		time.Sleep(time.Duration(rand.Int63n(int64(j.jitter))))
+	}
+	release := j.limiter.acquire(j.registry)
+	defer release()
+	j.job.Run()
+}
+
+func (w *RepositoryWatcher) scheduled(registry string, job cron.Job) cron.Job {
+	if w.jitter <= 0 && w.limiter == nil {
+		return job
+	}
+	return &scheduledJob{job: job, registry: registry, jitter: w.jitter, limiter: w.limiter}
+}
+
+// cachedDigest - last seen digest from previous runs
+func (w *RepositoryWatcher) cachedDigest(key, tag string) (string, bool) {
+	if w.cache == nil {
+		return "", false
+	}
+
+	encoded, err := w.cache.Get(watchCacheKey(key))
+	if err != nil {
+		return "", false
+	}
+
+	var state watchState
+	err = json.Unmarshal(encoded, &state)
+	if err != nil || state.Tag != tag || state.Digest == "" {
+		return "", false
+	}
+
+	return state.Digest, true
+}
+
+func (w *RepositoryWatcher) forget(key string) {
+	if w.cache == nil {
+		return
+	}
+	err := w.cache.Delete(watchCacheKey(key))
+	if err != nil {
+		log.WithFields(log.Fields{
+			"error":    err,
+			"job_name": key,
+		}).Warn("trigger.poll.RepositoryWatcher: failed to delete persisted image digest")
 	}
 }
 
@@ -110,11 +420,18 @@ func (w *RepositoryWatcher) Unwatch(imageName string) error {
 		}).Error("trigger.poll.RepositoryWatcher.Unwatch: failed to parse image")
 		return err
 	}
-	key := getImageIdentifier(imageRef)
-	_, ok := w.watched[key]
-	if ok {
-		w.cron.DeleteJob(key)
-		delete(w.watched, key)
+	identifier := getImageIdentifier(imageRef)
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	for key, details := range w.watched {
+		if watchesImage(key, identifier) {
+			w.cron.DeleteJob(key)
+			details.forgetMetrics()
+			delete(w.watched, key)
+			w.forget(key)
+		}
 	}
 
 	return nil
@@ -123,28 +440,48 @@ func (w *RepositoryWatcher) Unwatch(imageName string) error {
 // Watch - starts watching repository for changes, if it's already watching - ignores,
 // if details changed - updates details
 func (w *RepositoryWatcher) Watch(images ...*types.TrackedImage) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
 
 	var errs []string
-	tracked := map[string]bool{}
+	// watch key -> resources using it
+	consumers := map[string]map[string]bool{}
 
+	// resources using the same repository with the same credentials share a watch
+	var keys []string
+	grouped := map[string][]*types.TrackedImage{}
 	for _, image := range images {
 		if image.Trigger != types.TriggerTypePoll {
 			continue
 		}
-		identifier, err := w.watch(image)
+		if w.shard != nil && !w.shard.Owns(image.Image.Registry()+"/"+image.Image.ShortName()) {
+			continue
+		}
+		key := watchKey(image)
+		if _, ok := grouped[key]; !ok {
+			keys = append(keys, key)
+		}
+		grouped[key] = append(grouped[key], image)
+	}
+
+	for _, key := range keys {
+		err := w.watch(key, mergeTrackedImages(grouped[key]...))
 		if err != nil {
 			errs = append(errs, err.Error())
 			continue
 		}
-		tracked[identifier] = true
+		consumers[key] = map[string]bool{}
+		for _, image := range grouped[key] {
+			consumers[key][image.Identifier] = true
+		}
 	}
 
-	pollTriggerTrackedImages.Set(float64(len(tracked)))
+	pollTriggerTrackedImages.Set(float64(len(consumers)))
 
 	// removing registries that should not be tracked anymore
 	// for example: deployment using image X was deleted so we should not query
 	// registry that points to image X as nothing is using it anymore
-	w.unwatch(tracked)
+	w.unwatch(consumers)
 
 	if len(errs) > 0 {
 		return fmt.Errorf("encountered errors while adding images: %s", strings.Join(errs, ", "))
@@ -153,24 +490,38 @@ func (w *RepositoryWatcher) Watch(images ...*types.TrackedImage) error {
 	return nil
 }
 
-func (w *RepositoryWatcher) unwatch(tracked map[string]bool) {
+func (w *RepositoryWatcher) unwatch(consumers map[string]map[string]bool) {
 	for key, details := range w.watched {
-		if !tracked[key] {
+		current := consumers[key]
+		for identifier := range details.consumers {
+			if !current[identifier] {
+				log.WithFields(log.Fields{
+					"job_name": key,
+					"image":    details.trackedImage.String(),
+					"resource": identifier,
+				}).Debug("trigger.poll.RepositoryWatcher: resource doesn't use image anymore")
+			}
+		}
+		details.consumers = current
+
+		if len(current) == 0 {
 			log.WithFields(log.Fields{
 				"job_name": key,
 				"image":    details.trackedImage.String(),
 				"schedule": details.schedule,
 			}).Info("trigger.poll.RepositoryWatcher: image no tracked anymore, removing watcher")
 			w.cron.DeleteJob(key)
+			details.forgetMetrics()
 			delete(w.watched, key)
+			w.forget(key)
 		}
 	}
 }
 
-func (w *RepositoryWatcher) watch(image *types.TrackedImage) (string, error) {
+func (w *RepositoryWatcher) watch(key string, image *types.TrackedImage) error {
 
 	if image.PollSchedule == "" {
-		return "", fmt.Errorf("cron schedule cannot be empty")
+		return fmt.Errorf("cron schedule cannot be empty")
 	}
 
 	_, err := cron.Parse(image.PollSchedule)
@@ -180,24 +531,22 @@ func (w *RepositoryWatcher) watch(image *types.TrackedImage) (string, error) {
 			"image":    image.String(),
 			"schedule": image.PollSchedule,
 		}).Error("trigger.poll.RepositoryWatcher.addJob: invalid cron schedule")
-		return "", fmt.Errorf("invalid cron schedule: %s", err)
+		return fmt.Errorf("invalid cron schedule: %s", err)
 	}
 
-	key := getImageIdentifier(image.Image)
-
 	// checking whether it's already being watched
 	details, ok := w.watched[key]
 	if !ok {
 		// err = w.addJob(imageRef, registryUsername, registryPassword, schedule)
-		err = w.addJob(image, image.PollSchedule)
+		err = w.addJob(key, image, image.PollSchedule)
 		if err != nil {
 			log.WithFields(log.Fields{
 				"error": err,
 				"image": image.String(),
 			}).Error("trigger.poll.RepositoryWatcher.Watch: failed to add image watch job")
-			return "", err
+			return err
 		}
-		return key, nil
+		return nil
 	}
 
 	// checking schedule
@@ -208,6 +557,10 @@ func (w *RepositoryWatcher) watch(image *types.TrackedImage) (string, error) {
 				"error": err,
 				"image": image.String(),
 			}).Error("trigger.poll.RepositoryWatcher.Watch: failed to update image watch job")
+		} else {
+			details.stateMu.Lock()
+			details.schedule = image.PollSchedule
+			details.stateMu.Unlock()
 		}
 	}
 
@@ -218,10 +571,75 @@ func (w *RepositoryWatcher) watch(image *types.TrackedImage) (string, error) {
 	details.mu.Unlock()
 
 	// nothing to do
-	return key, nil
+	return nil
 }
 
-func (w *RepositoryWatcher) addJob(ti *types.TrackedImage, schedule string) error {
+// WatchState - last registry check and next scheduled check of the watch
+// that covers the image, false if image isn't watched
+func (w *RepositoryWatcher) WatchState(ref *image.Reference) (*types.WatchState, bool) {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+
+	identifier := getImageIdentifier(ref)
+	if details, ok := w.watched[identifier]; ok {
+		return details.state(w.nextRun(identifier)), true
+	}
+	// image might be watched with explicit credentials
+	for key, details := range w.watched {
+		if watchesImage(key, identifier) {
+			return details.state(w.nextRun(key)), true
+		}
+	}
+	return nil, false
+}
+
+// nextRun - next activation of the cron job, zero if the job isn't scheduled
+// or cron isn't running
+func (w *RepositoryWatcher) nextRun(key string) time.Time {
+	for _, entry := range w.cron.Entries() {
+		if entry.Name == key {
+			return entry.Next
+		}
+	}
+	return time.Time{}
+}
+
+// Check - checks watched repository of the image right away instead of
+// waiting for the schedule, returns the number of checked watches
+func (w *RepositoryWatcher) Check(imageName string) (int, error) {
+	ref, err := image.Parse(imageName)
+	if err != nil {
+		return 0, err
+	}
+	repository := ref.Registry() + "/" + ref.ShortName()
+
+	// semver watches are keyed by repository, digest watches by repository
+	// and tag, both optionally followed by credentials
+	var jobs []cron.Job
+	w.mu.RLock()
+	for key, details := range w.watched {
+		k := strings.SplitN(key, "#", 2)[0]
+		if (k == repository || strings.HasPrefix(k, repository+":")) && details.job != nil {
+			jobs = append(jobs, details.job)
+		}
+	}
+	w.mu.RUnlock()
+
+	for _, job := range jobs {
+		release := w.limiter.acquire(ref.Registry())
+		job.Run()
+		release()
+	}
+
+	log.WithFields(log.Fields{
+		"image":   imageName,
+		"watches": len(jobs),
+	}).Info("trigger.poll.RepositoryWatcher: checked repository on demand")
+
+	return len(jobs), nil
+}
+
+func (w *RepositoryWatcher) addJob(key string, ti *types.TrackedImage, schedule string) error {
 	// getting initial digest
 	reg := ti.Image.Scheme() + "://" + ti.Image.Registry()
 
@@ -231,13 +649,13 @@ func (w *RepositoryWatcher) addJob(ti *types.TrackedImage, schedule string) erro
 		Tag:      ti.Image.Tag(),
 	}
 
-	creds, err := credentialshelper.GetCredentials(ti)
-	if err == nil {
-		registryOpts.Username = creds.Username
-		registryOpts.Password = creds.Password
-	}
-
-	digest, err := w.registryClient.Digest(registryOpts)
+	started := time.Now()
+	var digest string
+	err := withCredentials(ti, &registryOpts, func() (err error) {
+		digest, err = w.registryClient.Digest(registryOpts)
+		return err
+	})
+	observeRegistryPoll(ti.Image.Registry(), operationManifest, started, err)
 	if err != nil {
 		log.WithFields(log.Fields{
 			"error":    err,
@@ -248,12 +666,19 @@ func (w *RepositoryWatcher) addJob(ti *types.TrackedImage, schedule string) erro
 		return err
 	}
 
-	key := getImageIdentifier(ti.Image)
 	details := &watchDetails{
 		trackedImage: ti,
 		digest:       digest, // current image digest
 		latest:       ti.Image.Tag(),
 		schedule:     schedule,
+		cache:        w.cache,
+		key:          key,
+	}
+
+	// digest seen before restart, first job run will submit an event
+	// if image was updated in the meantime
+	if cached, ok := w.cachedDigest(key, ti.Image.Tag()); ok {
+		details.digest = cached
 	}
 
 	// adding job to internal map
@@ -262,10 +687,26 @@ func (w *RepositoryWatcher) addJob(ti *types.TrackedImage, schedule string) erro
 	// checking tag type, for versioned (semver) tags we setup a watch all tags job
 	// and for non-semver types we create a single tag watcher which
 	// checks digest
+	if ordersByCreated(ti) {
+		job := NewWatchCreatedTagsJob(w.providers, w.registryClient, details, w.created)
+		details.job = job
+		log.WithFields(log.Fields{
+			"job_name": key,
+			"image":    ti.Image.String(),
+			"schedule": schedule,
+		}).Info("trigger.poll.RepositoryWatcher: new watch created tags job added")
+
+		// running it now
+		job.Run()
+
+		return w.cron.AddJob(key, schedule, w.scheduled(ti.Image.Registry(), job))
+	}
+
 	_, err = version.GetVersion(ti.Image.Tag())
 	if err != nil {
 		// adding new job
 		job := NewWatchTagJob(w.providers, w.registryClient, details)
+		details.job = job
 		log.WithFields(log.Fields{
 			"job_name": key,
 			"image":    ti.Image.String(),
@@ -275,12 +716,14 @@ func (w *RepositoryWatcher) addJob(ti *types.TrackedImage, schedule string) erro
 
 		// running it now
 		job.Run()
+		details.persist()
 
-		return w.cron.AddJob(key, schedule, job)
+		return w.cron.AddJob(key, schedule, w.scheduled(ti.Image.Registry(), job))
 	}
 
 	// adding new job
 	job := NewWatchRepositoryTagsJob(w.providers, w.registryClient, details)
+	details.job = job
 	log.WithFields(log.Fields{
 		"job_name": key,
 		"image":    ti.Image.String(),
@@ -291,6 +734,6 @@ func (w *RepositoryWatcher) addJob(ti *types.TrackedImage, schedule string) erro
 	// running it now
 	job.Run()
 
-	return w.cron.AddJob(key, schedule, job)
+	return w.cron.AddJob(key, schedule, w.scheduled(ti.Image.Registry(), job))
 
 }
@@ -3,11 +3,13 @@ package poll
 import (
 	"context"
 	"errors"
+	"fmt"
 	"os"
 	"testing"
+	"time"
 
 	"github.com/keel-hq/keel/approvals"
-	// "github.com/keel-hq/keel/cache/memory"
+	"github.com/keel-hq/keel/cache/memory"
 	"github.com/keel-hq/keel/extension/credentialshelper"
 	"github.com/keel-hq/keel/internal/policy"
 	"github.com/keel-hq/keel/provider"
@@ -37,6 +39,10 @@ type fakeRegistryClient struct {
 	digestErrToReturn error
 
 	tagsToReturn []string
+
+	// creation time by tag, requests are counted
+	createdToReturn map[string]time.Time
+	createdRequests int
 }
 
 func (c *fakeRegistryClient) Get(opts registry.Opts) (*registry.Repository, error) {
@@ -52,6 +58,16 @@ func (c *fakeRegistryClient) Digest(opts registry.Opts) (digest string, err erro
 	return c.digestToReturn, c.digestErrToReturn
 }
 
+func (c *fakeRegistryClient) Created(opts registry.Opts) (time.Time, error) {
+	c.opts = opts
+	created, ok := c.createdToReturn[opts.Tag]
+	if !ok {
+		return time.Time{}, fmt.Errorf("tag %s not found", opts.Tag)
+	}
+	c.createdRequests++
+	return created, nil
+}
+
 // ======== fake provider for testing =======
 type fakeProvider struct {
 	submitted []types.Event
@@ -585,3 +601,433 @@ func TestUnwatchAfterNotTrackedAnymore(t *testing.T) {
 		t.Errorf("expected to find watching 3 entries, found: %d", len(watcher.watched))
 	}
 }
+
+func TestUnwatchAfterLastConsumerRemoved(t *testing.T) {
+	fp := &fakeProvider{}
+	store, teardown := newTestingUtils()
+	defer teardown()
+	am := approvals.New(&approvals.Opts{
+		Store: store,
+	})
+
+	providers := provider.New([]provider.Provider{fp}, am)
+
+	frc := &fakeRegistryClient{
+		digestToReturn: "sha256:0604af35299dd37ff23937d115d103532948b568a9dd8197d14c256a8ab8b0bb",
+		tagsToReturn:   []string{"5.0.0"},
+	}
+
+	watcher := NewRepositoryWatcher(providers, frc)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	watcher.Start(ctx)
+
+	consumedBy := func(img, identifier string) *types.TrackedImage {
+		ti := mustParse(img, "@every 10m")
+		ti.Identifier = identifier
+		return ti
+	}
+
+	watcher.Watch(
+		consumedBy("gcr.io/v2-namespace/hello-world:1.1.1", "deployment/default/app-1"),
+		consumedBy("gcr.io/v2-namespace/hello-world:1.1.1", "deployment/default/app-2"),
+	)
+
+	details, ok := watcher.watched["gcr.io/v2-namespace/hello-world"]
+	if !ok {
+		t.Fatalf("hello-world watcher not found")
+	}
+	if len(details.consumers) != 2 {
+		t.Errorf("expected 2 consumers, got: %v", details.consumers)
+	}
+
+	// first deployment deleted or relabelled
+	watcher.Watch(consumedBy("gcr.io/v2-namespace/hello-world:1.1.1", "deployment/default/app-2"))
+
+	details, ok = watcher.watched["gcr.io/v2-namespace/hello-world"]
+	if !ok {
+		t.Fatalf("expected image to be watched while it has consumers")
+	}
+	if len(details.consumers) != 1 || !details.consumers["deployment/default/app-2"] {
+		t.Errorf("unexpected consumers: %v", details.consumers)
+	}
+
+	watcher.Watch()
+
+	if len(watcher.watched) != 0 {
+		t.Errorf("expected image without consumers to be unwatched, found: %d", len(watcher.watched))
+	}
+}
+
+func TestWatchRestoresPersistedDigest(t *testing.T) {
+	fp := &fakeProvider{}
+	store, teardown := newTestingUtils()
+	defer teardown()
+	am := approvals.New(&approvals.Opts{
+		Store: store,
+	})
+
+	providers := provider.New([]provider.Provider{fp}, am)
+
+	frc := &fakeRegistryClient{
+		digestToReturn: "sha256:aaa",
+	}
+
+	c := memory.New()
+
+	// first run, digest gets persisted
+	watcher := NewRepositoryWatcher(providers, frc).WithCache(c)
+	watcher.Watch(mustParse("gcr.io/v2-namespace/hello-world:master", "@every 10m"))
+
+	if len(fp.submitted) != 0 {
+		t.Fatalf("didn't expect any events on first run, got: %d", len(fp.submitted))
+	}
+	if _, err := c.Get(watchCacheKey("gcr.io/v2-namespace/hello-world:master")); err != nil {
+		t.Fatalf("expected digest to be persisted, got: %s", err)
+	}
+
+	// image was updated while keel wasn't running
+	frc.digestToReturn = "sha256:bbb"
+
+	restarted := NewRepositoryWatcher(providers, frc).WithCache(c)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	restarted.Start(ctx)
+	restarted.Watch(mustParse("gcr.io/v2-namespace/hello-world:master", "@every 10m"))
+
+	if len(fp.submitted) != 1 {
+		t.Fatalf("expected digest change to be detected after restart, got %d events", len(fp.submitted))
+	}
+	if fp.submitted[0].Repository.Digest != "sha256:bbb" {
+		t.Errorf("unexpected digest: %s", fp.submitted[0].Repository.Digest)
+	}
+
+	// untracked images are removed from the cache
+	restarted.Watch()
+	if _, err := c.Get(watchCacheKey("gcr.io/v2-namespace/hello-world:master")); err == nil {
+		t.Errorf("expected persisted digest to be removed")
+	}
+}
+
+type countingJob struct {
+	runs int
+}
+
+func (j *countingJob) Run() {
+	j.runs++
+}
+
+func TestScheduledJitter(t *testing.T) {
+	job := &countingJob{}
+
+	w := NewRepositoryWatcher(nil, nil)
+	if w.scheduled("index.docker.io", job) != job {
+		t.Errorf("expected job to run without delay when jitter is not set")
+	}
+
+	w.WithJitter(50 * time.Millisecond)
+	scheduled := w.scheduled("index.docker.io", job)
+	if _, ok := scheduled.(*scheduledJob); !ok {
+		t.Fatalf("expected scheduled job, got: %T", scheduled)
+	}
+
+	started := time.Now()
+	scheduled.Run()
+	if time.Since(started) > time.Second {
+		t.Errorf("job was delayed for longer than jitter")
+	}
+	if job.runs != 1 {
+		t.Errorf("expected job to run once, got: %d", job.runs)
+	}
+}
+
+func TestWatchState(t *testing.T) {
+	fp := &fakeProvider{}
+	store, teardown := newTestingUtils()
+	defer teardown()
+	am := approvals.New(&approvals.Opts{
+		Store: store,
+	})
+	providers := provider.New([]provider.Provider{fp}, am)
+
+	frc := &fakeRegistryClient{
+		digestToReturn: "sha256:0604af35299dd37ff23937d115d103532948b568a9dd8197d14c256a8ab8b0bb",
+	}
+	watcher := NewRepositoryWatcher(providers, frc)
+
+	tracked := mustParse("gcr.io/v2-namespace/hello-world:latest", "@every 10m")
+	if err := watcher.Watch(tracked); err != nil {
+		t.Fatalf("failed to watch: %s", err)
+	}
+
+	state, ok := watcher.WatchState(tracked.Image)
+	if !ok {
+		t.Fatalf("expected image to be watched")
+	}
+	if state.Tag != "latest" || state.Digest != frc.digestToReturn {
+		t.Errorf("unexpected watch state: %+v", state)
+	}
+	if state.Schedule != "@every 10m" {
+		t.Errorf("unexpected schedule: %s", state.Schedule)
+	}
+	if state.LastPoll.IsZero() {
+		t.Errorf("expected last poll time to be set")
+	}
+	if state.LastResult != types.PollResultOK {
+		t.Errorf("unexpected last result: %s", state.LastResult)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	watcher.Start(ctx)
+
+	state, _ = watcher.WatchState(tracked.Image)
+	if state.NextPoll == nil {
+		t.Fatalf("expected next poll time to be set")
+	}
+	if until := time.Until(*state.NextPoll); until <= 0 || until > 10*time.Minute {
+		t.Errorf("unexpected next poll: %s", state.NextPoll)
+	}
+
+	other, _ := image.Parse("gcr.io/v2-namespace/other:latest")
+	if _, ok := watcher.WatchState(other); ok {
+		t.Errorf("image shouldn't be watched")
+	}
+}
+
+func TestWatchStateLastError(t *testing.T) {
+	fp := &fakeProvider{}
+	store, teardown := newTestingUtils()
+	defer teardown()
+	am := approvals.New(&approvals.Opts{
+		Store: store,
+	})
+	providers := provider.New([]provider.Provider{fp}, am)
+
+	frc := &fakeRegistryClient{
+		digestToReturn: "sha256:0604af35299dd37ff23937d115d103532948b568a9dd8197d14c256a8ab8b0bb",
+	}
+	details := &watchDetails{
+		trackedImage: mustParse("gcr.io/v2-namespace/hello-world:latest", "@every 10m"),
+		digest:       frc.digestToReturn,
+	}
+	job := NewWatchTagJob(providers, frc, details)
+	job.Run()
+
+	frc.digestErrToReturn = errors.New("registry unavailable")
+	job.Run()
+
+	state := details.state(time.Time{})
+	if state.LastError != "registry unavailable" {
+		t.Errorf("unexpected last error: %s", state.LastError)
+	}
+	if state.LastResult != types.PollResultError {
+		t.Errorf("unexpected last result: %s", state.LastResult)
+	}
+	if state.Digest != frc.digestToReturn {
+		t.Errorf("expected last seen digest to be kept, got: %s", state.Digest)
+	}
+}
+
+func TestWatcherCheck(t *testing.T) {
+	fp := &fakeProvider{}
+	store, teardown := newTestingUtils()
+	defer teardown()
+	am := approvals.New(&approvals.Opts{
+		Store: store,
+	})
+	providers := provider.New([]provider.Provider{fp}, am)
+
+	frc := &fakeRegistryClient{
+		digestToReturn: "sha256:0604af35299dd37ff23937d115d103532948b568a9dd8197d14c256a8ab8b0bb",
+		tagsToReturn:   []string{"1.1.1"},
+	}
+
+	watcher := NewRepositoryWatcher(providers, frc)
+	watcher.Watch(
+		mustParse("gcr.io/v2-namespace/hello-world:1.1.1", "@every 10m"),
+		mustParse("gcr.io/v2-namespace/hello-world:master", "@every 10m"),
+		mustParse("gcr.io/v2-namespace/greetings-world:1.1.1", "@every 10m"),
+	)
+
+	frc.opts = registry.Opts{}
+	checked, err := watcher.Check("gcr.io/v2-namespace/hello-world")
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if checked != 2 {
+		t.Errorf("expected 2 checked watches, got: %d", checked)
+	}
+	if frc.opts.Name != "v2-namespace/hello-world" {
+		t.Errorf("expected registry to be queried, got: %+v", frc.opts)
+	}
+
+	checked, err = watcher.Check("gcr.io/v2-namespace/unknown")
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if checked != 0 {
+		t.Errorf("expected no checked watches, got: %d", checked)
+	}
+}
+
+type fakeShard struct {
+	owned map[string]bool
+}
+
+func (s *fakeShard) Owns(key string) bool {
+	return s.owned[key]
+}
+
+func TestWatchShard(t *testing.T) {
+	fp := &fakeProvider{}
+	store, teardown := newTestingUtils()
+	defer teardown()
+	am := approvals.New(&approvals.Opts{
+		Store: store,
+	})
+
+	providers := provider.New([]provider.Provider{fp}, am)
+
+	frc := &fakeRegistryClient{
+		digestToReturn: "sha256:0604af35299dd37ff23937d115d103532948b568a9dd8197d14c256a8ab8b0bb",
+		tagsToReturn:   []string{"5.0.0"},
+	}
+
+	shard := &fakeShard{owned: map[string]bool{"gcr.io/v2-namespace/hello-world": true}}
+	watcher := NewRepositoryWatcher(providers, frc).WithShard(shard)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	watcher.Start(ctx)
+
+	tracked := []*types.TrackedImage{
+		mustParse("gcr.io/v2-namespace/hello-world:1.1.1", "@every 10m"),
+		mustParse("gcr.io/v2-namespace/greetings-world:1.1.1", "@every 10m"),
+	}
+
+	watcher.Watch(tracked...)
+
+	if len(watcher.watched) != 1 {
+		t.Fatalf("expected to watch 1 repository, found: %d", len(watcher.watched))
+	}
+	if _, ok := watcher.watched["gcr.io/v2-namespace/hello-world"]; !ok {
+		t.Errorf("expected owned repository to be watched")
+	}
+
+	// repositories move when replicas come and go
+	shard.owned = map[string]bool{"gcr.io/v2-namespace/greetings-world": true}
+	watcher.Watch(tracked...)
+
+	if _, ok := watcher.watched["gcr.io/v2-namespace/greetings-world"]; !ok || len(watcher.watched) != 1 {
+		t.Errorf("expected only the newly owned repository to be watched, got: %d", len(watcher.watched))
+	}
+}
+
+func TestWatchCreatedTagsJob(t *testing.T) {
+	reference, _ := image.Parse("foo/bar:main-a1b2c3")
+	globPolicy, _ := policy.NewGlobPolicy("glob:main-*")
+	fp := &fakeProvider{
+		images: []*types.TrackedImage{
+			{
+				Image:    reference,
+				Policy:   globPolicy,
+				TagOrder: types.TagOrderCreated,
+			},
+		},
+	}
+	store, teardown := newTestingUtils()
+	defer teardown()
+	am := approvals.New(&approvals.Opts{
+		Store: store,
+	})
+
+	providers := provider.New([]provider.Provider{fp}, am)
+
+	now := time.Now()
+	frc := &fakeRegistryClient{
+		tagsToReturn: []string{"main-a1b2c3", "main-ffffff", "main-000000", "dev-999999"},
+		createdToReturn: map[string]time.Time{
+			"main-a1b2c3": now.Add(-2 * time.Hour),
+			// lexically highest, but created before the current tag
+			"main-ffffff": now.Add(-3 * time.Hour),
+			"main-000000": now.Add(-1 * time.Hour),
+			"dev-999999":  now,
+		},
+	}
+
+	details := &watchDetails{
+		trackedImage: fp.images[0],
+	}
+
+	job := NewWatchCreatedTagsJob(providers, frc, details, newCreatedCache())
+
+	job.Run()
+
+	if len(fp.submitted) != 1 {
+		t.Fatalf("expected 1 submitted event, got: %d", len(fp.submitted))
+	}
+	if fp.submitted[0].Repository.Tag != "main-000000" {
+		t.Errorf("expected event repository tag main-000000, but got: %s", fp.submitted[0].Repository.Tag)
+	}
+	if frc.createdRequests != 3 {
+		t.Errorf("expected 3 creation time lookups, got: %d", frc.createdRequests)
+	}
+
+	// creation times are cached between checks
+	job.Run()
+
+	if frc.createdRequests != 3 {
+		t.Errorf("expected creation times to be cached, got %d lookups", frc.createdRequests)
+	}
+}
+
+func TestWatchScheduleChangeWhileRunning(t *testing.T) {
+	fp := &fakeProvider{}
+	store, teardown := newTestingUtils()
+	defer teardown()
+	am := approvals.New(&approvals.Opts{
+		Store: store,
+	})
+	providers := provider.New([]provider.Provider{fp}, am)
+
+	frc := &fakeRegistryClient{
+		tagsToReturn: []string{"1.1.1"},
+	}
+	watcher := NewRepositoryWatcher(providers, frc)
+
+	tracked := func(schedule string) *types.TrackedImage {
+		ti := mustParse("gcr.io/v2-namespace/hello-world:1.1.1", schedule)
+		ti.Policy = policy.NewSemverPolicy(policy.SemverPolicyTypeAll, true)
+		return ti
+	}
+	if err := watcher.Watch(tracked("@every 10m")); err != nil {
+		t.Fatalf("failed to watch: %s", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	watcher.Start(ctx)
+
+	// jobs record their result while the schedule changes, run with -race
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < 50; i++ {
+			watcher.Check("gcr.io/v2-namespace/hello-world")
+		}
+	}()
+	for i := 0; i < 50; i++ {
+		if err := watcher.Watch(tracked(fmt.Sprintf("@every %dm", 10+i%2))); err != nil {
+			t.Fatalf("failed to watch: %s", err)
+		}
+	}
+	<-done
+
+	state, ok := watcher.WatchState(tracked("@every 11m").Image)
+	if !ok {
+		t.Fatalf("expected image to be watched")
+	}
+	if state.Schedule != "@every 11m" {
+		t.Errorf("unexpected schedule: %s", state.Schedule)
+	}
+}
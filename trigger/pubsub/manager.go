@@ -8,9 +8,22 @@ import (
 
 	"github.com/keel-hq/keel/provider"
 
+	"github.com/prometheus/client_golang/prometheus"
+
 	log "github.com/sirupsen/logrus"
 )
 
+var pubsubScansSkippedCounter = prometheus.NewCounter(
+	prometheus.CounterOpts{
+		Name: "pubsub_trigger_scans_skipped_total",
+		Help: "How many periodic scans of tracked images were skipped because the previous scan was still running.",
+	},
+)
+
+func init() {
+	prometheus.MustRegister(pubsubScansSkippedCounter)
+}
+
 // DefaultManager - subscription manager
 type DefaultManager struct {
 	providers provider.Providers
@@ -32,6 +45,8 @@ type DefaultManager struct {
 
 	// scanTick - scan interval in seconds, defaults to 60 seconds
 	scanTick int
+	// lastScan - when the last scan finished
+	lastScan time.Time
 
 	// root context
 	ctx context.Context
@@ -63,6 +78,7 @@ func (s *DefaultManager) Start(ctx context.Context) error {
 
 	// initial scan
 	err := s.scan(ctx)
+	s.lastScan = time.Now()
 	if err != nil {
 		log.WithFields(log.Fields{
 			"error": err,
@@ -76,9 +92,16 @@ func (s *DefaultManager) Start(ctx context.Context) error {
 		select {
 		case <-ctx.Done():
 			return nil
-		case <-ticker.C:
+		case tick := <-ticker.C:
+			// tick fired while the previous scan was running
+			if tick.Before(s.lastScan) {
+				pubsubScansSkippedCounter.Inc()
+				log.Warn("trigger.pubsub.manager: previous scan took longer than scan interval, skipping scan")
+				continue
+			}
 			log.Debug("performing scan")
 			err := s.scan(ctx)
+			s.lastScan = time.Now()
 			if err != nil {
 				log.WithFields(log.Fields{
 					"error": err,
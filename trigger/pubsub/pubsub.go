@@ -102,12 +102,16 @@ func (s *PubsubSubscriber) ensureSubscription(ctx context.Context, subscriptionI
 			"subscription": subscriptionID,
 			"topic":        topicID,
 		}).Debug("trigger.pubsub: subscription exists")
+		s.ensureNoExpiration(ctx, sub)
 		return nil
 	}
 
 	_, err = s.client.CreateSubscription(ctx, subscriptionID, pubsub.SubscriptionConfig{
 		Topic:       s.client.Topic(topicID),
 		AckDeadline: 10 * time.Second,
+		// subscriptions are deleted after 31 days of inactivity by default,
+		// registries that rarely get pushes would silently stop triggering updates
+		ExpirationPolicy: time.Duration(0),
 	})
 	if err != nil {
 		return fmt.Errorf("failed to create subscription %s, error: %s", subscriptionID, err)
@@ -115,6 +119,33 @@ func (s *PubsubSubscriber) ensureSubscription(ctx context.Context, subscriptionI
 	return nil
 }
 
+// ensureNoExpiration - subscriptions created by older versions have default expiration
+// policy, updating them so they don't expire
+func (s *PubsubSubscriber) ensureNoExpiration(ctx context.Context, sub *pubsub.Subscription) {
+	cfg, err := sub.Config(ctx)
+	if err != nil {
+		log.WithFields(log.Fields{
+			"error":        err,
+			"subscription": sub.ID(),
+		}).Warn("trigger.pubsub: failed to get subscription config")
+		return
+	}
+
+	if ttl, ok := cfg.ExpirationPolicy.(time.Duration); ok && ttl == 0 {
+		return
+	}
+
+	_, err = sub.Update(ctx, pubsub.SubscriptionConfigToUpdate{
+		ExpirationPolicy: time.Duration(0),
+	})
+	if err != nil {
+		log.WithFields(log.Fields{
+			"error":        err,
+			"subscription": sub.ID(),
+		}).Warn("trigger.pubsub: failed to disable subscription expiration")
+	}
+}
+
 // Subscribe - initiate PubsubSubscriber
 func (s *PubsubSubscriber) Subscribe(ctx context.Context, topic, subscription string) error {
 	// ensuring that topic exists
@@ -129,6 +160,8 @@ func (s *PubsubSubscriber) Subscribe(ctx context.Context, topic, subscription st
 	}
 
 	sub := s.client.Subscription(subscription)
+	// ack deadline is extended automatically while providers process the event
+	sub.ReceiveSettings.MaxExtension = 10 * time.Minute
 	log.WithFields(log.Fields{
 		"topic":        topic,
 		"subscription": subscription,
@@ -0,0 +1,197 @@
+package sqs
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/sqs"
+	"github.com/aws/aws-sdk-go/service/sqs/sqsiface"
+
+	"github.com/keel-hq/keel/provider"
+	"github.com/keel-hq/keel/types"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// Subscriber - consumes ECR image push events delivered through
+// EventBridge -> SQS (optionally through SNS)
+type Subscriber struct {
+	providers provider.Providers
+
+	queueURL string
+	client   sqsiface.SQSAPI
+}
+
+// Opts - subscriber options
+type Opts struct {
+	QueueURL string
+	// Region is optional, when not set - AWS_REGION or shared config is used
+	Region    string
+	Providers provider.Providers
+}
+
+// New - create new SQS subscriber. Credentials are resolved using the default
+// AWS credential chain (environment, shared config, web identity, instance role)
+func New(opts *Opts) (*Subscriber, error) {
+	if opts.QueueURL == "" {
+		return nil, fmt.Errorf("queue URL is required")
+	}
+
+	cfg := aws.NewConfig()
+	if opts.Region != "" {
+		cfg = cfg.WithRegion(opts.Region)
+	}
+
+	sess, err := session.NewSessionWithOptions(session.Options{
+		Config:            *cfg,
+		SharedConfigState: session.SharedConfigEnable,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create AWS session, error: %s", err)
+	}
+
+	return &Subscriber{
+		providers: opts.Providers,
+		queueURL:  opts.QueueURL,
+		client:    sqs.New(sess),
+	}, nil
+}
+
+// Example of ECR push event delivered by EventBridge
+// {
+//   "version": "0",
+//   "id": "13cde686-328b-6117-af20-0e5566167482",
+//   "detail-type": "ECR Image Action",
+//   "source": "aws.ecr",
+//   "account": "123456789012",
+//   "time": "2019-11-16T01:54:34Z",
+//   "region": "us-west-2",
+//   "resources": [],
+//   "detail": {
+//     "result": "SUCCESS",
+//     "repository-name": "my-repository-name",
+//     "image-digest": "sha256:7f5b2640fe6fb4f46592dfd3410c4a79dac4f89e4782432e0378abcd1234",
+//     "action-type": "PUSH",
+//     "image-tag": "latest"
+//   }
+// }
+
+type ecrEvent struct {
+	DetailType string `json:"detail-type"`
+	Source     string `json:"source"`
+	Account    string `json:"account"`
+	Region     string `json:"region"`
+	Detail     struct {
+		Result         string `json:"result"`
+		RepositoryName string `json:"repository-name"`
+		ImageDigest    string `json:"image-digest"`
+		ActionType     string `json:"action-type"`
+		ImageTag       string `json:"image-tag"`
+	} `json:"detail"`
+}
+
+// snsNotification - events can be fanned out through SNS before reaching the queue
+type snsNotification struct {
+	Type    string `json:"Type"`
+	Message string `json:"Message"`
+}
+
+// Start - start receiving messages, blocks until context is cancelled
+func (s *Subscriber) Start(ctx context.Context) error {
+	log.WithFields(log.Fields{
+		"queue_url": s.queueURL,
+	}).Info("trigger.sqs: receiving ECR events...")
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		default:
+		}
+
+		out, err := s.client.ReceiveMessageWithContext(ctx, &sqs.ReceiveMessageInput{
+			QueueUrl:            aws.String(s.queueURL),
+			MaxNumberOfMessages: aws.Int64(10),
+			WaitTimeSeconds:     aws.Int64(20),
+		})
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			log.WithFields(log.Fields{
+				"error":     err,
+				"queue_url": s.queueURL,
+			}).Error("trigger.sqs: failed to receive messages")
+			time.Sleep(5 * time.Second)
+			continue
+		}
+
+		for _, msg := range out.Messages {
+			s.handle(aws.StringValue(msg.Body))
+
+			_, err = s.client.DeleteMessageWithContext(ctx, &sqs.DeleteMessageInput{
+				QueueUrl:      aws.String(s.queueURL),
+				ReceiptHandle: msg.ReceiptHandle,
+			})
+			if err != nil {
+				log.WithFields(log.Fields{
+					"error":      err,
+					"message_id": aws.StringValue(msg.MessageId),
+				}).Error("trigger.sqs: failed to delete message")
+			}
+		}
+	}
+}
+
+func (s *Subscriber) handle(body string) {
+	var notification snsNotification
+	if err := json.Unmarshal([]byte(body), &notification); err == nil && notification.Type == "Notification" {
+		body = notification.Message
+	}
+
+	var event ecrEvent
+	err := json.Unmarshal([]byte(body), &event)
+	if err != nil {
+		log.WithFields(log.Fields{
+			"error": err,
+		}).Error("trigger.sqs: failed to decode message")
+		return
+	}
+
+	// we only care about successful pushes of tagged images
+	if event.Source != "aws.ecr" || event.Detail.ActionType != "PUSH" || event.Detail.Result != "SUCCESS" {
+		return
+	}
+
+	if event.Detail.ImageTag == "" {
+		return
+	}
+
+	name := fmt.Sprintf("%s.dkr.ecr.%s.amazonaws.com/%s", event.Account, event.Region, event.Detail.RepositoryName)
+
+	log.WithFields(log.Fields{
+		"image":  name,
+		"tag":    event.Detail.ImageTag,
+		"digest": event.Detail.ImageDigest,
+	}).Debug("trigger.sqs: got ECR push event")
+
+	err = s.providers.Submit(types.Event{
+		Repository: types.Repository{
+			Name:   name,
+			Tag:    event.Detail.ImageTag,
+			Digest: event.Detail.ImageDigest,
+		},
+		CreatedAt:   time.Now(),
+		TriggerName: "sqs",
+	})
+	if err != nil {
+		log.WithFields(log.Fields{
+			"error": err,
+			"image": name,
+		}).Error("trigger.sqs: failed to submit event")
+	}
+}
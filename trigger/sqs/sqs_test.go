@@ -0,0 +1,92 @@
+package sqs
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/keel-hq/keel/types"
+)
+
+type fakeProviders struct {
+	submitted []types.Event
+}
+
+func (p *fakeProviders) Submit(event types.Event) error {
+	p.submitted = append(p.submitted, event)
+	return nil
+}
+
+func (p *fakeProviders) TrackedImages() ([]*types.TrackedImage, error)     { return nil, nil }
+func (p *fakeProviders) List() []string                                    { return nil }
+func (p *fakeProviders) Stop()                                             {}
+func (p *fakeProviders) Pause()                                            {}
+func (p *fakeProviders) Resume()                                           {}
+func (p *fakeProviders) Paused() bool                                      { return false }
+func (p *fakeProviders) QueuedUpdates() []*types.QueuedUpdate              { return nil }
+func (p *fakeProviders) Explain(event types.Event) []*types.UpdateDecision { return nil }
+
+var ecrPushEvent = `{
+  "version": "0",
+  "id": "13cde686-328b-6117-af20-0e5566167482",
+  "detail-type": "ECR Image Action",
+  "source": "aws.ecr",
+  "account": "123456789012",
+  "time": "2019-11-16T01:54:34Z",
+  "region": "us-west-2",
+  "resources": [],
+  "detail": {
+    "result": "SUCCESS",
+    "repository-name": "team/app",
+    "image-digest": "sha256:7f5b2640fe6fb4f46592dfd3410c4a79dac4f89e4782432e0378abcd1234",
+    "action-type": "PUSH",
+    "image-tag": "1.2.3"
+  }
+}`
+
+func TestHandle(t *testing.T) {
+	snsWrapped := `{"Type": "Notification", "MessageId": "xx", "Message": ` + jsonString(ecrPushEvent) + `}`
+
+	tests := []struct {
+		name      string
+		body      string
+		submitted int
+	}{
+		{name: "eventbridge event", body: ecrPushEvent, submitted: 1},
+		{name: "sns wrapped event", body: snsWrapped, submitted: 1},
+		{name: "delete action", body: `{"source": "aws.ecr", "detail": {"action-type": "DELETE", "result": "SUCCESS", "image-tag": "1.2.3"}}`},
+		{name: "failed push", body: `{"source": "aws.ecr", "detail": {"action-type": "PUSH", "result": "FAILURE", "image-tag": "1.2.3"}}`},
+		{name: "untagged push", body: `{"source": "aws.ecr", "detail": {"action-type": "PUSH", "result": "SUCCESS"}}`},
+		{name: "malformed", body: `not json`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fp := &fakeProviders{}
+			s := &Subscriber{providers: fp}
+			s.handle(tt.body)
+
+			if len(fp.submitted) != tt.submitted {
+				t.Fatalf("expected %d events, got: %d", tt.submitted, len(fp.submitted))
+			}
+			if tt.submitted == 0 {
+				return
+			}
+
+			repo := fp.submitted[0].Repository
+			if repo.Name != "123456789012.dkr.ecr.us-west-2.amazonaws.com/team/app" {
+				t.Errorf("unexpected image name: %s", repo.Name)
+			}
+			if repo.Tag != "1.2.3" {
+				t.Errorf("unexpected tag: %s", repo.Tag)
+			}
+			if repo.Digest != "sha256:7f5b2640fe6fb4f46592dfd3410c4a79dac4f89e4782432e0378abcd1234" {
+				t.Errorf("unexpected digest: %s", repo.Digest)
+			}
+		})
+	}
+}
+
+func jsonString(s string) string {
+	b, _ := json.Marshal(s)
+	return string(b)
+}
@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"strings"
 	"time"
 )
 
@@ -36,6 +37,10 @@ type Approval struct {
 
 	Message string `json:"message"`
 
+	// Channel is an optional bot channel override for this
+	// approval, empty for default approvals channel
+	Channel string `json:"channel"`
+
 	CurrentVersion string `json:"currentVersion"`
 	NewVersion     string `json:"newVersion"`
 
@@ -52,6 +57,10 @@ type Approval struct {
 	// IDs for audit
 	Voters JSONB `json:"voters" gorm:"type:json"`
 
+	// Approvers is an optional comma separated list of
+	// groups whose members can vote, anyone can if empty
+	Approvers string `json:"approvers,omitempty"`
+
 	// Explicitly rejected approval
 	// can be set directly by user
 	// so even if deadline is not reached approval
@@ -84,6 +93,17 @@ func (a *Approval) AddVoter(voter string) {
 	a.Voters[voter] = time.Now()
 }
 
+// GetApprovers - returns approver groups, empty if anyone can vote
+func (a *Approval) GetApprovers() []string {
+	var groups []string
+	for _, group := range strings.Split(a.Approvers, ",") {
+		if group = strings.TrimSpace(group); group != "" {
+			groups = append(groups, group)
+		}
+	}
+	return groups
+}
+
 // ApprovalStatus - approval status type used in approvals
 // to determine whether it was rejected/approved or still pending
 type ApprovalStatus int
@@ -25,7 +25,7 @@ const (
 // deployment updates and approval actions
 type AuditLog struct {
 	ID        string    `json:"id" gorm:"primary_key;type:varchar(36)"`
-	CreatedAt time.Time `json:"createdAt"`
+	CreatedAt time.Time `json:"createdAt" gorm:"index"`
 	UpdatedAt time.Time `json:"updatedAt"`
 
 	AccountID string `json:"accountId"`
@@ -37,6 +37,12 @@ type AuditLog struct {
 	ResourceKind string `json:"resourceKind"` // approval/deployment/daemonset/statefulset/etc...
 	Identifier   string `json:"identifier"`
 
+	// affected resource, set for update entries so history
+	// can be queried
+	Namespace string `json:"namespace" gorm:"index"`
+	Name      string `json:"name"`
+	Image     string `json:"image"` // comma separated list of images, queried through AuditLogImage
+
 	Message     string `json:"message"`
 	Payload     string `json:"payload"` // can be used for bigger messages such as webhook payload
 	PayloadType string `json:"payloadType"`
@@ -44,6 +50,14 @@ type AuditLog struct {
 	Metadata JSONB `json:"metadata" gorm:"type:json"`
 }
 
+// AuditLogImage - one row per image of an audit log entry so entries can be
+// filtered by any of the resource's images, not just the first one
+type AuditLogImage struct {
+	AuditLogID string `gorm:"primary_key;type:varchar(36)"`
+	Image      string `gorm:"primary_key;index"` // ie: gcr.io/v2-namespace/foo:1.1.2
+	Repository string `gorm:"index"`             // image without tag or digest
+}
+
 // SetMetadata - set audit log metadata (providers, namespaces)
 func (l *AuditLog) SetMetadata(m map[string]string) {
 	meta := make(map[string]interface{})
@@ -63,6 +77,13 @@ type AuditLogQuery struct {
 	Offset   int    `json:"offset"`
 
 	ResourceKindFilter []string `json:"resourceKindFilter"`
+	ActionFilter       []string `json:"actionFilter"`
+
+	Namespace string    `json:"namespace"`
+	Name      string    `json:"name"`
+	Image     string    `json:"image"` // matches entries with the exact image or repository
+	From      time.Time `json:"from"`
+	To        time.Time `json:"to"`
 }
 
 type AuditLogStatsQuery struct {
@@ -0,0 +1,38 @@
+package types
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Commit - Git commit the image tag was built from
+type Commit struct {
+	SHA     string `json:"sha"`
+	Message string `json:"message"`
+	Author  string `json:"author"`
+	URL     string `json:"url"`
+}
+
+// Title - first line of the commit message
+func (c *Commit) Title() string {
+	return strings.TrimSpace(strings.SplitN(c.Message, "\n", 2)[0])
+}
+
+// ShortSHA - abbreviated commit SHA
+func (c *Commit) ShortSHA() string {
+	if len(c.SHA) > 7 {
+		return c.SHA[:7]
+	}
+	return c.SHA
+}
+
+func (c *Commit) String() string {
+	s := fmt.Sprintf("%s %s", c.ShortSHA(), c.Title())
+	if c.Author != "" {
+		s = fmt.Sprintf("%s (%s)", s, c.Author)
+	}
+	if c.URL != "" {
+		s = s + " " + c.URL
+	}
+	return s
+}
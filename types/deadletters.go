@@ -0,0 +1,29 @@
+package types
+
+import (
+	"time"
+)
+
+// TriggerNameReplay - trigger name of events replayed from the dead-letter store
+const TriggerNameReplay = "replay"
+
+// DeadLetter - event that couldn't be applied, kept until it's replayed
+// or discarded
+type DeadLetter struct {
+	ID string `json:"id" gorm:"primary_key;type:varchar(36)"`
+
+	// Provider that failed to apply the event
+	Provider string `json:"provider"`
+
+	// Identifier of the resource that failed to update, empty when the
+	// event failed before resources were evaluated (ie: API server down)
+	Identifier string `json:"identifier"`
+
+	Event *Event `json:"event" gorm:"type:json"`
+
+	Error    string `json:"error"`
+	Attempts int    `json:"attempts"`
+
+	CreatedAt time.Time `json:"createdAt"`
+	UpdatedAt time.Time `json:"updatedAt"`
+}
@@ -0,0 +1,31 @@
+package types
+
+import (
+	"time"
+)
+
+// actions recorded in resource history
+const (
+	HistoryActionDetected   = "detected"
+	HistoryActionApproved   = "approved"
+	HistoryActionApplied    = "applied"
+	HistoryActionFailed     = "failed"
+	HistoryActionRolledBack = "rolledBack"
+)
+
+// HistoryEntry - action keel took on a resource
+type HistoryEntry struct {
+	Action     string `json:"action"`
+	Cluster    string `json:"cluster,omitempty"`
+	Identifier string `json:"identifier"`
+	Kind       string `json:"kind"`
+	Namespace  string `json:"namespace"`
+	Name       string `json:"name"`
+
+	CurrentVersion string `json:"currentVersion,omitempty"`
+	NewVersion     string `json:"newVersion,omitempty"`
+	Trigger        string `json:"trigger,omitempty"`
+	Message        string `json:"message,omitempty"`
+
+	CreatedAt time.Time `json:"createdAt"`
+}
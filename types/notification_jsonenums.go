@@ -18,6 +18,9 @@ var (
 		"NotificationSystemEvent":         NotificationSystemEvent,
 		"NotificationUpdateApproved":      NotificationUpdateApproved,
 		"NotificationUpdateRejected":      NotificationUpdateRejected,
+		"NotificationUpdateExpired":       NotificationUpdateExpired,
+		"NotificationApprovalRequested":   NotificationApprovalRequested,
+		"NotificationSecurityEvent":       NotificationSecurityEvent,
 	}
 
 	_NotificationValueToName = map[Notification]string{
@@ -30,6 +33,9 @@ var (
 		NotificationSystemEvent:         "NotificationSystemEvent",
 		NotificationUpdateApproved:      "NotificationUpdateApproved",
 		NotificationUpdateRejected:      "NotificationUpdateRejected",
+		NotificationUpdateExpired:       "NotificationUpdateExpired",
+		NotificationApprovalRequested:   "NotificationApprovalRequested",
+		NotificationSecurityEvent:       "NotificationSecurityEvent",
 	}
 )
 
@@ -46,6 +52,9 @@ func init() {
 			interface{}(NotificationSystemEvent).(fmt.Stringer).String():         NotificationSystemEvent,
 			interface{}(NotificationUpdateApproved).(fmt.Stringer).String():      NotificationUpdateApproved,
 			interface{}(NotificationUpdateRejected).(fmt.Stringer).String():      NotificationUpdateRejected,
+			interface{}(NotificationUpdateExpired).(fmt.Stringer).String():       NotificationUpdateExpired,
+			interface{}(NotificationApprovalRequested).(fmt.Stringer).String():   NotificationApprovalRequested,
+			interface{}(NotificationSecurityEvent).(fmt.Stringer).String():       NotificationSecurityEvent,
 		}
 	}
 }
@@ -12,12 +12,14 @@ var (
 		"ProviderTypeUnknown":    ProviderTypeUnknown,
 		"ProviderTypeKubernetes": ProviderTypeKubernetes,
 		"ProviderTypeHelm":       ProviderTypeHelm,
+		"ProviderTypeExecutor":   ProviderTypeExecutor,
 	}
 
 	_ProviderTypeValueToName = map[ProviderType]string{
 		ProviderTypeUnknown:    "ProviderTypeUnknown",
 		ProviderTypeKubernetes: "ProviderTypeKubernetes",
 		ProviderTypeHelm:       "ProviderTypeHelm",
+		ProviderTypeExecutor:   "ProviderTypeExecutor",
 	}
 )
 
@@ -28,6 +30,7 @@ func init() {
 			interface{}(ProviderTypeUnknown).(fmt.Stringer).String():    ProviderTypeUnknown,
 			interface{}(ProviderTypeKubernetes).(fmt.Stringer).String(): ProviderTypeKubernetes,
 			interface{}(ProviderTypeHelm).(fmt.Stringer).String():       ProviderTypeHelm,
+			interface{}(ProviderTypeExecutor).(fmt.Stringer).String():   ProviderTypeExecutor,
 		}
 	}
 }
@@ -2,6 +2,7 @@ package types
 
 import (
 	"fmt"
+	"time"
 
 	"github.com/keel-hq/keel/util/image"
 )
@@ -17,7 +18,9 @@ type TrackedImage struct {
 	Trigger      TriggerType       `json:"trigger"`
 	PollSchedule string            `json:"pollSchedule"`
 	Provider     string            `json:"provider"`
+	Cluster      string            `json:"cluster,omitempty"` // set when keel manages several clusters
 	Namespace    string            `json:"namespace"`
+	Identifier   string            `json:"identifier"` // resource using the image, ie: deployment/default/app
 	Secrets      []string          `json:"secrets"`
 	Meta         map[string]string `json:"meta"` // metadata supplied by providers
 	// a list of pre-release tags, ie: 1.0.0-dev, 1.5.0-prod get translated into
@@ -25,8 +28,41 @@ type TrackedImage struct {
 	// combined semver tags
 	Tags   []string `json:"tags"`
 	Policy Policy   `json:"policy"`
+	// TagOrder - how non semver tags are ordered, TagOrderCreated picks the
+	// newest image allowed by the policy. Only the current tag's digest is
+	// watched when empty
+	TagOrder string `json:"tagOrder,omitempty"`
+	// PollCredentials - dockerconfigjson secret used for polling instead of
+	// image pull secrets, ie: when polling a registry behind a pull-through cache
+	PollCredentials string `json:"pollCredentials,omitempty"`
 }
 
+// TagOrderCreated - non semver tags are ordered by image creation time
+const TagOrderCreated = "created"
+
+// WatchState - poll trigger state of a watched image
+type WatchState struct {
+	Image    string `json:"image"`
+	Schedule string `json:"schedule"`
+	// Tag - latest tag seen in the registry, watched tag for non semver images
+	Tag       string    `json:"tag"`
+	Digest    string    `json:"digest"`
+	LastPoll  time.Time `json:"lastPoll"`
+	LastError string    `json:"lastError,omitempty"`
+	// LastResult - outcome of the last registry check, see PollResult* constants
+	LastResult string `json:"lastResult,omitempty"`
+	// NextPoll - next scheduled registry check, not set if the job isn't scheduled
+	NextPoll *time.Time `json:"nextPoll,omitempty"`
+}
+
+// outcomes of registry checks
+const (
+	PollResultOK          = "ok"
+	PollResultUpdate      = "update" // new version found, event submitted
+	PollResultError       = "error"
+	PollResultRateLimited = "rate_limited"
+)
+
 type Policy interface {
 	ShouldUpdate(current, new string) (bool, error)
 	Name() string
@@ -23,6 +23,10 @@ const KeelPolicyLabel = "keel.sh/policy"
 
 const KeelImagePullSecretAnnotation = "keel.sh/imagePullSecret"
 
+// KeelPollCredentialsAnnotation - secret with registry credentials used only for polling,
+// image pull secrets and default credentials are ignored when set
+const KeelPollCredentialsAnnotation = "keel.sh/pollCredentials"
+
 // KeelTriggerLabel - trigger label is used to specify custom trigger types
 // for example keel.sh/trigger=poll would signal poll trigger to start watching for repository
 // changes
@@ -35,34 +39,183 @@ const KeelForceTagMatchLabel = "keel.sh/matchTag"
 // KeelMatchPreReleaseAnnotation - label or annotation to set pre-release matching for SemVer, defaults to true for backward compatibility
 const KeelMatchPreReleaseAnnotation = "keel.sh/matchPreRelease"
 
+// KeelVersionPrefixAnnotation - label or annotation to set handling of the "v"
+// prefix of SemVer tags: tolerate (default, v1.2.3 equals 1.2.3), match (prefix
+// has to match the current tag) or reject (prefixed tags are never updated to)
+const KeelVersionPrefixAnnotation = "keel.sh/versionPrefix"
+
+// KeelBuildMetadataAnnotation - label or annotation to set handling of SemVer
+// build metadata (1.2.3+build45, usually chart versions as image tags can't
+// contain '+'): ignore (default, versions differing only in metadata are equal)
+// or order (metadata orders otherwise equal versions)
+const KeelBuildMetadataAnnotation = "keel.sh/buildMetadata"
+
+// KeelExcludeTagsAnnotation - comma separated globs (ie: *-debug,*-rc*) or regexp: prefixed
+// patterns of tags that are never updated to, even if they satisfy the policy
+const KeelExcludeTagsAnnotation = "keel.sh/excludeTags"
+
+// KeelTagOrderAnnotation - set to "created" to update non semver tags (ie: with glob
+// or force policies) to the newest image allowed by the policy, images are ordered by
+// creation time from the image config
+const KeelTagOrderAnnotation = "keel.sh/tagOrder"
+
 // KeelPollScheduleAnnotation - optional variable to setup custom schedule for polling, defaults to @every 10m
 const KeelPollScheduleAnnotation = "keel.sh/pollSchedule"
 
 // KeelPollDefaultSchedule - defaul polling schedule
 const KeelPollDefaultSchedule = "@every 1m"
 
-// KeelDigestAnnotation - digest annotation
+// KeelDigestAnnotation - label or annotation, when true updated images are
+// always pinned by digest (ie: app:1.4.0@sha256:...), even if the current
+// image is referenced by tag only
 const KeelDigestAnnotation = "keel.sh/digest"
 
+// KeelDigestPinAnnotation - label or annotation to control how images referenced
+// by both tag and digest (ie: app:1.4.0@sha256:...) are updated: respect (default),
+// update or drop
+const KeelDigestPinAnnotation = "keel.sh/digestPin"
+
 // KeelNotificationChanAnnotation - optional notification to override
 // default notification channel(-s) per deployment/chart
 const KeelNotificationChanAnnotation = "keel.sh/notify"
 
+// KeelNotificationLevelAnnotation - optional label or annotation to override
+// minimum notification level per deployment/chart
+const KeelNotificationLevelAnnotation = "keel.sh/notificationLevel"
+
 // KeelMinimumApprovalsLabel - min approvals
 const KeelMinimumApprovalsLabel = "keel.sh/approvals"
 
+// KeelApproversAnnotation - optional comma separated approver groups (ie:
+// team-payments,sre), only their members can approve or reject updates
+const KeelApproversAnnotation = "keel.sh/approvers"
+
 // KeelUpdateTimeAnnotation - update time
 const KeelUpdateTimeAnnotation = "keel.sh/update-time"
 
-// KeelApprovalDeadlineLabel - approval deadline
+// KeelUpdateModeAnnotation - label or annotation, "restart" keeps images whose
+// tag didn't change (only the digest did) and restarts pods by bumping
+// keel.sh/update-ts instead, defaults to "image"
+const KeelUpdateModeAnnotation = "keel.sh/updateMode"
+
+// KeelUpdateTimestampAnnotation - pod template annotation bumped to restart
+// pods when update mode is "restart"
+const KeelUpdateTimestampAnnotation = "keel.sh/update-ts"
+
+// KeelApprovalDeadlineLabel - approval deadline, hours (ie: 24) or duration (ie: 30m).
+// Pending approvals expire and are deleted once it passes
 const KeelApprovalDeadlineLabel = "keel.sh/approvalDeadline"
 
 // KeelApprovalDeadlineDefault - default deadline in hours
 const KeelApprovalDeadlineDefault = 24
 
+// KeelApprovalsChannelAnnotation - optional bot channel to override default
+// approvals channel per deployment
+const KeelApprovalsChannelAnnotation = "keel.sh/approvalsChannel"
+
+// KeelHealthCheckURLAnnotation - optional external health check endpoint that is
+// polled after an update, resource is rolled back if it keeps failing
+const KeelHealthCheckURLAnnotation = "keel.sh/healthCheckURL"
+
+// KeelHealthCheckPeriodAnnotation - for how long health check is verified after
+// an update, defaults to 5m
+const KeelHealthCheckPeriodAnnotation = "keel.sh/healthCheckPeriod"
+
+// KeelHealthCheckIntervalAnnotation - interval between health checks, defaults to 30s
+const KeelHealthCheckIntervalAnnotation = "keel.sh/healthCheckInterval"
+
+// KeelHealthCheckFailureThresholdAnnotation - consecutive failed health checks
+// before rolling back, defaults to 3
+const KeelHealthCheckFailureThresholdAnnotation = "keel.sh/healthCheckFailureThreshold"
+
+// KeelHealthQueryAnnotation - optional PromQL expression that is evaluated after
+// an update, resource is rolled back if it breaches the threshold. Requires
+// Prometheus URL to be configured
+const KeelHealthQueryAnnotation = "keel.sh/healthQuery"
+
+// KeelHealthQueryThresholdAnnotation - comparison breaching health query values
+// have to match, ie: "> 0.05" or "< 1". Without threshold, any sample returned
+// by the query is a breach (same as alerting rules)
+const KeelHealthQueryThresholdAnnotation = "keel.sh/healthQueryThreshold"
+
+// KeelHealthQueryPeriodAnnotation - soak window after an update during which
+// health query is evaluated, defaults to 5m
+const KeelHealthQueryPeriodAnnotation = "keel.sh/healthQueryPeriod"
+
+// KeelHealthQueryIntervalAnnotation - interval between health query evaluations, defaults to 30s
+const KeelHealthQueryIntervalAnnotation = "keel.sh/healthQueryInterval"
+
+// KeelRolloutDeadlineAnnotation - how long the rollout can take after an update
+// before resource is rolled back (ie: 10m), overrides ROLLOUT_DEADLINE, "0" disables
+const KeelRolloutDeadlineAnnotation = "keel.sh/rolloutDeadline"
+
+// KeelVerifyAnnotation - check run after update is rolled out, either HTTP(S) URL
+// that has to return 2xx or job/<name>, cronjob/<name> whose job template is run
+// and has to succeed. Resource is rolled back if verification fails
+const KeelVerifyAnnotation = "keel.sh/verify"
+
+// KeelVerifyTimeoutAnnotation - how long rollout and verification can take, defaults to 5m
+const KeelVerifyTimeoutAnnotation = "keel.sh/verifyTimeout"
+
+// KeelMinAgeAnnotation - how old the new version has to be before the resource
+// is updated (ie: 30m), younger updates are queued. Age is based on image
+// creation time, or on when keel first saw the version if it's not known
+const KeelMinAgeAnnotation = "keel.sh/minAge"
+
+// KeelUpdateWindowAnnotation - when updates can be applied, ie: "Mon-Fri 09:00-17:00 Europe/Paris",
+// multiple windows are separated with ";". Updates found outside the window, or while
+// the window is invalid, are queued
+const KeelUpdateWindowAnnotation = "keel.sh/updateWindow"
+
+// KeelPausedAnnotation - set to "true" to freeze updates of a single resource,
+// keel keeps tracking it and reports updates it would have applied
+const KeelPausedAnnotation = "keel.sh/paused"
+
+// KeelPauseUntilAnnotation - freezes updates of a single resource until the
+// time (ie: 2024-07-01T09:00Z), latest update found meanwhile is applied once
+// it passes
+const KeelPauseUntilAnnotation = "keel.sh/pauseUntil"
+
+// KeelDryRunAnnotation - set to "true" to evaluate and report updates of a single
+// resource without applying them
+const KeelDryRunAnnotation = "keel.sh/dryRun"
+
+// KeelInitContainersAnnotation - set to "true" to also track and update init containers,
+// they follow the same policies as regular containers
+const KeelInitContainersAnnotation = "keel.sh/initContainers"
+
+// KeelIgnoreContainersAnnotation - comma separated names of containers that are neither
+// polled nor updated, ie: "istio-proxy,cloudsql-proxy"
+const KeelIgnoreContainersAnnotation = "keel.sh/ignore-containers"
+
+// KeelOnlyContainersAnnotation - comma separated names of the only containers that are
+// polled and updated, others are ignored
+const KeelOnlyContainersAnnotation = "keel.sh/only-containers"
+
+// KeelUpdateAnnotation - set by keel on updated resources, describes the last
+// update (trigger, previous and new version, time) as JSON
+const KeelUpdateAnnotation = "keel.sh/update"
+
+// KeelGroupAnnotation - resources in the same namespace sharing a group are only
+// updated once new versions are available for all of them, and then together
+const KeelGroupAnnotation = "keel.sh/group"
+
+// KeelStageLabel - "staging" or "production", new versions are applied to production
+// resources only after staging resources using the same image are healthy on them
+const KeelStageLabel = "keel.sh/stage"
+
+// KeelStageSoakAnnotation - how long staging rollouts have to stay healthy before
+// the production resource is updated, ie: "1h"
+const KeelStageSoakAnnotation = "keel.sh/stageSoak"
+
 // KeelReleasePage - optional release notes URL passed on with notification
 const KeelReleaseNotesURL = "keel.sh/releaseNotes"
 
+// KeelSourceRepoAnnotation - optional Git repository the images are built from
+// (ie: github.com/acme/app), commits referenced by image tags are looked up
+// there and included in notifications and approval requests
+const KeelSourceRepoAnnotation = "keel.sh/sourceRepo"
+
 // Repository - represents main docker repository fields that
 // keel cares about
 type Repository struct {
@@ -92,6 +245,14 @@ type Event struct {
 	CreatedAt  time.Time  `json:"createdAt,omitempty"`
 	// optional field to identify trigger
 	TriggerName string `json:"triggerName,omitempty"`
+	// optional trace context propagated from the trigger
+	TraceContext map[string]string `json:"traceContext,omitempty"`
+	// optional resource identifier (ie: deployment/default/wd), forces the
+	// update of this resource only, regardless of its update policy
+	Target string `json:"target,omitempty"`
+	// optional chart repository URL, set when a new version of the
+	// Repository.Name chart (Repository.Tag) was published instead of an image
+	Chart string `json:"chart,omitempty"`
 }
 
 func (e *Event) Value() (driver.Value, error) {
@@ -115,6 +276,42 @@ func (e *Event) Scan(src interface{}) error {
 	return nil
 }
 
+// QueuedUpdate - update held back until resource update window opens or
+// new version is old enough
+type QueuedUpdate struct {
+	Provider       string    `json:"provider"`
+	Identifier     string    `json:"identifier"`
+	Kind           string    `json:"kind"`
+	Namespace      string    `json:"namespace"`
+	Name           string    `json:"name"`
+	CurrentVersion string    `json:"currentVersion"`
+	NewVersion     string    `json:"newVersion"`
+	Window         string    `json:"window"`
+	QueuedAt       time.Time `json:"queuedAt"`
+	// ReadyAt - update is held at least until then (ie: keel.sh/minAge)
+	ReadyAt time.Time `json:"readyAt,omitempty"`
+
+	// Event - event that is processed again once the window opens
+	Event Event `json:"-"`
+}
+
+// UpdateDecision - what a provider would do with a container of the resource
+// on the event, used to explain events without applying them
+type UpdateDecision struct {
+	Provider   string `json:"provider"`
+	Identifier string `json:"identifier"`
+	Kind       string `json:"kind"`
+	Namespace  string `json:"namespace"`
+	Name       string `json:"name"`
+	Container  string `json:"container"`
+	Image      string `json:"image"`
+	Policy     string `json:"policy"`
+	Update     bool   `json:"update"`
+	NewImage   string `json:"newImage,omitempty"`
+	// Reason - why the container isn't updated
+	Reason string `json:"reason,omitempty"`
+}
+
 // Version - version container
 type Version struct {
 	Major      int64
@@ -186,8 +383,12 @@ type EventNotification struct {
 	ResourceKind string       `json:"resourceKind"`
 	Identifier   string       `json:"identifier"`
 	// Channels is an optional variable to override
-	// default channel(-s) when performing an update
+	// default channel(-s) when performing an update,
+	// http(s) URLs are used as webhook endpoints
 	Channels []string `json:"-"`
+	// MinLevel is an optional variable to override
+	// configured minimum notification level
+	MinLevel *Level `json:"-"`
 
 	Metadata map[string]string `json:"metadata"`
 }
@@ -210,6 +411,60 @@ func ParseEventNotificationChannels(annotations map[string]string) []string {
 	return channels
 }
 
+// ParseEventNotificationChannelsFromLabelsOrAnnotations - annotations take precedence,
+// labels can only hold a single channel as commas are not allowed in label values
+func ParseEventNotificationChannelsFromLabelsOrAnnotations(labels map[string]string, annotations map[string]string) []string {
+	channels := ParseEventNotificationChannels(annotations)
+	if len(channels) > 0 {
+		return channels
+	}
+	return ParseEventNotificationChannels(labels)
+}
+
+// ParseEventNotificationLevel - parses minimum notification level override from
+// labels or annotations, returns nil if it's not set or invalid
+func ParseEventNotificationLevel(labels map[string]string, annotations map[string]string) *Level {
+	lvl, ok := annotations[KeelNotificationLevelAnnotation]
+	if !ok {
+		lvl, ok = labels[KeelNotificationLevelAnnotation]
+	}
+	if !ok {
+		return nil
+	}
+
+	level, err := ParseLevel(lvl)
+	if err != nil {
+		return nil
+	}
+	return &level
+}
+
+// ChannelNames - notification channels, excluding webhook endpoints
+func (e EventNotification) ChannelNames() []string {
+	var names []string
+	for _, c := range e.Channels {
+		if !isEndpoint(c) {
+			names = append(names, c)
+		}
+	}
+	return names
+}
+
+// Endpoints - webhook endpoints from notification channels
+func (e EventNotification) Endpoints() []string {
+	var endpoints []string
+	for _, c := range e.Channels {
+		if isEndpoint(c) {
+			endpoints = append(endpoints, c)
+		}
+	}
+	return endpoints
+}
+
+func isEndpoint(channel string) bool {
+	return strings.HasPrefix(channel, "http://") || strings.HasPrefix(channel, "https://")
+}
+
 func ParseReleaseNotesURL(annotations map[string]string) string {
 	if annotations == nil {
 		return ""
@@ -238,6 +493,14 @@ const (
 
 	NotificationUpdateApproved
 	NotificationUpdateRejected
+	NotificationUpdateExpired
+	NotificationApprovalRequested
+
+	// image signature verification failed
+	NotificationSecurityEvent
+
+	// periodic summary of aggregated notifications
+	NotificationDigest
 )
 
 func (n Notification) String() string {
@@ -260,6 +523,14 @@ func (n Notification) String() string {
 		return "update approved"
 	case NotificationUpdateRejected:
 		return "update rejected "
+	case NotificationUpdateExpired:
+		return "update expired"
+	case NotificationApprovalRequested:
+		return "approval requested"
+	case NotificationSecurityEvent:
+		return "security event"
+	case NotificationDigest:
+		return "digest"
 	default:
 		return "unknown"
 	}
@@ -345,6 +616,7 @@ const (
 	ProviderTypeUnknown ProviderType = iota
 	ProviderTypeKubernetes
 	ProviderTypeHelm
+	ProviderTypeExecutor
 )
 
 func (t ProviderType) String() string {
@@ -355,6 +627,8 @@ func (t ProviderType) String() string {
 		return "kubernetes"
 	case ProviderTypeHelm:
 		return "helm"
+	case ProviderTypeExecutor:
+		return "executor"
 	default:
 		return ""
 	}
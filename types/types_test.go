@@ -157,3 +157,50 @@ func TestParseReleaseNotesURL(t *testing.T) {
 		})
 	}
 }
+
+func TestParseEventNotificationChannelsFromLabelsOrAnnotations(t *testing.T) {
+	labels := map[string]string{KeelNotificationChanAnnotation: "label-chan"}
+	annotations := map[string]string{KeelNotificationChanAnnotation: "chan1, https://hooks.example.com/x"}
+
+	if got := ParseEventNotificationChannelsFromLabelsOrAnnotations(labels, annotations); !reflect.DeepEqual(got, []string{"chan1", "https://hooks.example.com/x"}) {
+		t.Errorf("expected annotation channels, got: %v", got)
+	}
+	if got := ParseEventNotificationChannelsFromLabelsOrAnnotations(labels, nil); !reflect.DeepEqual(got, []string{"label-chan"}) {
+		t.Errorf("expected label channel, got: %v", got)
+	}
+}
+
+func TestEventNotificationChannelNamesAndEndpoints(t *testing.T) {
+	event := EventNotification{
+		Channels: []string{"chan1", "https://hooks.example.com/x", "http://internal/hook"},
+	}
+
+	if got := event.ChannelNames(); !reflect.DeepEqual(got, []string{"chan1"}) {
+		t.Errorf("unexpected channel names: %v", got)
+	}
+	if got := event.Endpoints(); !reflect.DeepEqual(got, []string{"https://hooks.example.com/x", "http://internal/hook"}) {
+		t.Errorf("unexpected endpoints: %v", got)
+	}
+}
+
+func TestParseEventNotificationLevel(t *testing.T) {
+	if lvl := ParseEventNotificationLevel(nil, nil); lvl != nil {
+		t.Errorf("expected no level, got: %s", lvl)
+	}
+	if lvl := ParseEventNotificationLevel(nil, map[string]string{KeelNotificationLevelAnnotation: "bogus"}); lvl != nil {
+		t.Errorf("expected no level for invalid value, got: %s", lvl)
+	}
+
+	lvl := ParseEventNotificationLevel(
+		map[string]string{KeelNotificationLevelAnnotation: "debug"},
+		map[string]string{KeelNotificationLevelAnnotation: "error"},
+	)
+	if lvl == nil || *lvl != LevelError {
+		t.Errorf("expected annotation level error, got: %v", lvl)
+	}
+
+	lvl = ParseEventNotificationLevel(map[string]string{KeelNotificationLevelAnnotation: "debug"}, nil)
+	if lvl == nil || *lvl != LevelDebug {
+		t.Errorf("expected label level debug, got: %v", lvl)
+	}
+}
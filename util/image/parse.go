@@ -129,3 +129,22 @@ func ParseRepo(remote string) (*Repository, error) {
 		Scheme:     ref.scheme,
 	}, nil
 }
+
+// SplitTagDigest - splits reference that is pinned by both tag and digest
+// (ie: app:1.4.0@sha256:...) into tagged reference and digest. References
+// that don't have both are returned unchanged with an empty digest.
+func SplitTagDigest(remote string) (tagged, digest string) {
+	idx := strings.LastIndex(remote, "@")
+	if idx == -1 {
+		return remote, ""
+	}
+
+	name := remote[:idx]
+	// tag separator has to be in the last path component, otherwise it's
+	// a registry port
+	if !strings.Contains(name[strings.LastIndex(name, "/")+1:], ":") {
+		return remote, ""
+	}
+
+	return name, remote[idx+1:]
+}
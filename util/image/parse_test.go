@@ -142,3 +142,43 @@ func TestParseRepo(t *testing.T) {
 		})
 	}
 }
+
+func TestSplitTagDigest(t *testing.T) {
+	tests := []struct {
+		remote     string
+		wantTagged string
+		wantDigest string
+	}{
+		{
+			remote:     "app:1.4.0@sha256:0604af35299dd37ff23937d115d103532948b568a9dd8197d14c256a8ab8b0bb",
+			wantTagged: "app:1.4.0",
+			wantDigest: "sha256:0604af35299dd37ff23937d115d103532948b568a9dd8197d14c256a8ab8b0bb",
+		},
+		{
+			remote:     "localhost:5000/foo/app:1.4.0@sha256:0604af35299dd37ff23937d115d103532948b568a9dd8197d14c256a8ab8b0bb",
+			wantTagged: "localhost:5000/foo/app:1.4.0",
+			wantDigest: "sha256:0604af35299dd37ff23937d115d103532948b568a9dd8197d14c256a8ab8b0bb",
+		},
+		{
+			// digest only, nothing to split
+			remote:     "localhost:5000/foo/app@sha256:0604af35299dd37ff23937d115d103532948b568a9dd8197d14c256a8ab8b0bb",
+			wantTagged: "localhost:5000/foo/app@sha256:0604af35299dd37ff23937d115d103532948b568a9dd8197d14c256a8ab8b0bb",
+		},
+		{
+			remote:     "localhost:5000/foo/app:1.4.0",
+			wantTagged: "localhost:5000/foo/app:1.4.0",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.remote, func(t *testing.T) {
+			tagged, digest := SplitTagDigest(tt.remote)
+			if tagged != tt.wantTagged {
+				t.Errorf("SplitTagDigest() tagged = %s, want %s", tagged, tt.wantTagged)
+			}
+			if digest != tt.wantDigest {
+				t.Errorf("SplitTagDigest() digest = %s, want %s", digest, tt.wantDigest)
+			}
+		})
+	}
+}
@@ -10,6 +10,8 @@ import (
 	apps_v1 "k8s.io/api/apps/v1"
 	v1 "k8s.io/api/core/v1"
 	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	batch_v1 "k8s.io/client-go/kubernetes/typed/batch/v1"
+	batch_v1beta1 "k8s.io/client-go/kubernetes/typed/batch/v1beta1"
 	core_v1 "k8s.io/client-go/kubernetes/typed/core/v1"
 )
 
@@ -27,6 +29,8 @@ type FakeK8sImplementer struct {
 	AvailablePods *v1.PodList
 	DeletedPods   []*v1.Pod
 
+	AvailableServiceAccounts map[string]*v1.ServiceAccount
+
 	// error to return
 	Error error
 }
@@ -69,11 +73,35 @@ func (i *FakeK8sImplementer) Pods(namespace, labelSelector string) (*v1.PodList,
 	return i.AvailablePods, nil
 }
 
+// ServiceAccount - get service account
+func (i *FakeK8sImplementer) ServiceAccount(namespace, name string) (*v1.ServiceAccount, error) {
+	sa, ok := i.AvailableServiceAccounts[name]
+	if !ok {
+		return nil, fmt.Errorf("service account %s not found", name)
+	}
+	return sa, nil
+}
+
 // ConfigMaps - returns nothing (not implemented)
 func (i *FakeK8sImplementer) ConfigMaps(namespace string) core_v1.ConfigMapInterface {
 	panic("not implemented")
 }
 
+// Jobs - returns nothing (not implemented)
+func (i *FakeK8sImplementer) Jobs(namespace string) batch_v1.JobInterface {
+	panic("not implemented")
+}
+
+// CronJobs - returns nothing (not implemented)
+func (i *FakeK8sImplementer) CronJobs(namespace string) batch_v1beta1.CronJobInterface {
+	panic("not implemented")
+}
+
+// Events - returns nothing (not implemented)
+func (i *FakeK8sImplementer) Events(namespace string) core_v1.EventInterface {
+	panic("not implemented")
+}
+
 // DeletePod - adds pod to DeletedPods list
 func (i *FakeK8sImplementer) DeletePod(namespace, name string, opts *meta_v1.DeleteOptions) error {
 	i.DeletedPods = append(i.DeletedPods, &v1.Pod{